@@ -0,0 +1,187 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ClipAudio is what is done with a clip's sound, e.g. to keep shouting from
+// the stands out of clips that are published
+type ClipAudio string
+
+const (
+	// AudioKeep keeps the camera's sound
+	AudioKeep ClipAudio = ""
+	// AudioQuiet turns the camera's sound down 12 dB
+	AudioQuiet ClipAudio = "quiet"
+	// AudioMute silences the clip, keeping a silent audio track so it still
+	// combines with the others
+	AudioMute ClipAudio = "mute"
+	// AudioMusic replaces the camera's sound with a music bed
+	AudioMusic ClipAudio = "music"
+)
+
+// ClipAudios lists the clip audio options, default first
+var ClipAudios = []ClipAudio{AudioKeep, AudioQuiet, AudioMute, AudioMusic}
+
+// musicFadeOut is how long the music bed fades out at the end of a clip
+const musicFadeOut = 1.0
+
+// volumeFilter returns the filter applied to the camera's sound (AudioQuiet
+// and AudioMute), or "" if it is kept or replaced
+func (a ClipAudio) volumeFilter() string {
+	switch a {
+	case AudioQuiet:
+		return "volume=-12dB"
+	case AudioMute:
+		return "volume=0"
+	}
+	return ""
+}
+
+// musicFilter returns the filter that cuts a looped music bed to a clip's
+// duration, fading it in and out
+func musicFilter(durationSec float64) string {
+	filter := fmt.Sprintf("atrim=duration=%.3f,asetpts=PTS-STARTPTS", durationSec)
+	if durationSec > 2*musicFadeOut {
+		filter += fmt.Sprintf(",afade=t=in:st=0:d=%.3f,afade=t=out:st=%.3f:d=%.3f",
+			musicFadeOut/2, durationSec-musicFadeOut, musicFadeOut)
+	}
+	return filter
+}
+
+// musicInputArgs returns the input arguments that loop a music bed for as
+// long as it is needed
+func musicInputArgs(musicPath string) []string {
+	return []string{"-stream_loop", "-1", "-i", musicPath}
+}
+
+// ApplyClipAudio writes inputPath's clip to outputPath with its sound
+// treated as audio says: turned down, muted, or replaced with musicPath
+// looped to the clip's length. The video, chapters and tags are copied as they are.
+func (f *FFmpeg) ApplyClipAudio(inputPath, outputPath string, audio ClipAudio, musicPath string) error {
+	if audio == AudioMusic && musicPath == "" {
+		return fmt.Errorf("no music bed chosen for %s", filepath.Base(inputPath))
+	}
+	hasAudio := f.hasAudio(inputPath)
+
+	args := []string{"-i", inputPath}
+	mapArgs := append([]string{"-map", "0:v"}, audioMapArgs(f.audioStream("0", inputPath), hasAudio)...)
+	var audioArgs []string
+	switch {
+	case audio == AudioMusic:
+		duration, err := f.GetDuration(inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to read clip duration: %w", err)
+		}
+		args = append(args, musicInputArgs(musicPath)...)
+		mapArgs = []string{"-map", "0:v", "-map", "1:a:0"}
+		audioArgs = append([]string{"-af", musicFilter(duration)}, clipAudioArgs(true)...)
+	case audio.volumeFilter() != "" && hasAudio:
+		audioArgs = append([]string{"-af", audio.volumeFilter()}, clipAudioArgs(true)...)
+	default:
+		audioArgs = []string{"-c:a", "copy"}
+	}
+
+	args = append(args, mapArgs...)
+	args = append(args,
+		"-map_metadata", "0",
+		"-map_chapters", "0",
+		"-c:v", "copy",
+	)
+	args = append(args, audioArgs...)
+	args = append(args, f.codecTagArgs(inputPath)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("failed to change clip audio: %s", stderr.String())
+	}
+	return nil
+}
+
+// WithClipAudio calls extract to write a clip to outputPath with its sound
+// treated as audio says (see ApplyClipAudio). When the sound is changed the
+// clip is cut into a temporary file first.
+func (f *FFmpeg) WithClipAudio(outputPath string, audio ClipAudio, musicPath string, extract func(path string) error) error {
+	if audio == AudioKeep {
+		return extract(outputPath)
+	}
+	if audio == AudioMusic && musicPath == "" {
+		return fmt.Errorf("no music bed chosen for %s", filepath.Base(outputPath))
+	}
+
+	cut, err := os.CreateTemp(f.TempDir(), "ffmpeg-clip-audio-*"+filepath.Ext(outputPath))
+	if err != nil {
+		return fmt.Errorf("failed to create temp clip: %w", err)
+	}
+	cut.Close()
+	defer os.Remove(cut.Name())
+
+	if err := extract(cut.Name()); err != nil {
+		return err
+	}
+	return f.ApplyClipAudio(cut.Name(), outputPath, audio, musicPath)
+}
+
+// reelAudioFilter treats the sound of the reel's clips as opts.Audio says,
+// in a filter from buildConcatFilter (after any transition, which reads each
+// clip's sound once as [<i>:a]). musicIndex is the input index of the music bed.
+func reelAudioFilter(filterStr string, inputPaths []string, opts ReelOptions, musicIndex int) string {
+	var music []int // Clips whose sound is replaced by the music bed
+	var chains []string
+	for i, path := range inputPaths {
+		audio := opts.Audio[path]
+		if audio == AudioMusic && opts.MusicPath != "" && i < len(opts.durations) && opts.durations[i] > 0 {
+			music = append(music, i)
+			continue
+		}
+		if audio == AudioMusic {
+			audio = AudioMute // No music bed, or no duration to cut it to
+		}
+		if filter := audio.volumeFilter(); filter != "" {
+			label := fmt.Sprintf("[ca%d]", i)
+			chains = append(chains, fmt.Sprintf("[%d:a]%s%s", i, filter, label))
+			filterStr = strings.Replace(filterStr, fmt.Sprintf("[%d:a]", i), label, 1)
+		}
+	}
+
+	if len(music) > 0 {
+		split := fmt.Sprintf("[%d:a]asplit=%d", musicIndex, len(music))
+		for j := range music {
+			split += fmt.Sprintf("[mus%d]", j)
+		}
+		chains = append(chains, split)
+		for j, i := range music {
+			label := fmt.Sprintf("[ca%d]", i)
+			chains = append(chains, fmt.Sprintf("[mus%d]%s%s", j, musicFilter(opts.durations[i]), label))
+			filterStr = strings.Replace(filterStr, fmt.Sprintf("[%d:a]", i), label, 1)
+		}
+	}
+
+	if len(chains) == 0 {
+		return filterStr
+	}
+	return strings.Join(chains, ";") + ";" + filterStr
+}
+
+// usesMusic returns true if any of the reel's clips has its sound replaced
+// by the music bed
+func (o ReelOptions) usesMusic() bool {
+	if o.MusicPath == "" {
+		return false
+	}
+	for _, audio := range o.Audio {
+		if audio == AudioMusic {
+			return true
+		}
+	}
+	return false
+}
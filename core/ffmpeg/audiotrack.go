@@ -0,0 +1,151 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Some cameras and editors write more than one audio stream: GoPros with wind
+// reduction can carry the processed and the raw microphone sound, and MOVs from
+// editors often add a silent or commentary track. Mapping "0:a" takes all of
+// them (or ffmpeg's pick in a filter graph), so clips are cut from the track
+// chosen with SetAudioTrack instead.
+
+// AudioTrack describes one of a file's audio streams
+type AudioTrack struct {
+	Index      int    // Position among the file's audio streams (the N in -map 0:a:N)
+	Codec      string // e.g. "aac", "pcm_s24le"
+	Channels   int
+	SampleRate int
+	Title      string // The stream's title or handler name, e.g. "GoPro AAC"
+	Language   string // e.g. "eng" (empty if untagged)
+}
+
+// Label describes the track for a selector, e.g. "Track 1: GoPro AAC (aac, 2 ch, 48 kHz)"
+func (t AudioTrack) Label() string {
+	label := fmt.Sprintf("Track %d", t.Index+1)
+	if t.Title != "" {
+		label += ": " + t.Title
+	}
+	details := []string{t.Codec}
+	if t.Channels > 0 {
+		details = append(details, fmt.Sprintf("%d ch", t.Channels))
+	}
+	if t.SampleRate > 0 {
+		details = append(details, fmt.Sprintf("%g kHz", float64(t.SampleRate)/1000))
+	}
+	if t.Language != "" && t.Language != "und" {
+		details = append(details, t.Language)
+	}
+	return label + " (" + strings.Join(details, ", ") + ")"
+}
+
+// GetAudioTracks probes the audio streams of a file, in order (empty if it
+// has none). Results are cached until the file changes (see probecache.go).
+func (f *FFmpeg) GetAudioTracks(videoPath string) ([]AudioTrack, error) {
+	key, ok := fileProbeKey(videoPath)
+	if ok {
+		if tracks, cached := f.cachedAudioTracks(key); cached {
+			return tracks, nil
+		}
+	}
+	tracks, err := f.probeAudioTracks(videoPath)
+	if err == nil && ok {
+		f.cacheAudioTracks(key, tracks)
+	}
+	return tracks, err
+}
+
+// probeAudioTracks runs ffprobe for GetAudioTracks
+func (f *FFmpeg) probeAudioTracks(videoPath string) ([]AudioTrack, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "a",
+		"-show_entries", "stream=codec_name,channels,sample_rate:stream_tags=title,handler_name,language",
+		"-of", "json",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	var probe struct {
+		Streams []struct {
+			CodecName  string            `json:"codec_name"`
+			Channels   int               `json:"channels"`
+			SampleRate string            `json:"sample_rate"`
+			Tags       map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse audio streams: %w", err)
+	}
+
+	tracks := make([]AudioTrack, 0, len(probe.Streams))
+	for i, s := range probe.Streams {
+		track := AudioTrack{
+			Index:    i,
+			Codec:    s.CodecName,
+			Channels: s.Channels,
+			Language: s.Tags["language"],
+		}
+		track.SampleRate, _ = strconv.Atoi(s.SampleRate)
+		// GoPro pads its handler names with tabs and spaces
+		track.Title = strings.TrimSpace(s.Tags["title"])
+		if track.Title == "" {
+			track.Title = strings.TrimSpace(s.Tags["handler_name"])
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, nil
+}
+
+// SetAudioTrack sets which audio stream (0 = the first) clips, spanning clips,
+// combined files and the full game export take from their sources. Sources
+// with fewer streams use their first.
+func (f *FFmpeg) SetAudioTrack(index int) {
+	f.encoderMu.Lock()
+	f.audioTrack = max(index, 0)
+	f.encoderMu.Unlock()
+}
+
+// SelectedAudioTrack returns the audio stream set with SetAudioTrack
+func (f *FFmpeg) SelectedAudioTrack() int {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+	return f.audioTrack
+}
+
+// sourceAudioTrack returns the selected audio track of a source, or its first
+// if it doesn't have that many (or can't be probed)
+func (f *FFmpeg) sourceAudioTrack(path string) (AudioTrack, bool) {
+	tracks, err := f.GetAudioTracks(path)
+	if err != nil || len(tracks) == 0 {
+		return AudioTrack{}, false
+	}
+	if index := f.SelectedAudioTrack(); index < len(tracks) {
+		return tracks[index], true
+	}
+	return tracks[0], true
+}
+
+// audioStream returns the stream specifier of a source's selected audio track
+// for the given input ("0", "1", ...), e.g. "0:a:1"
+func (f *FFmpeg) audioStream(input, path string) string {
+	index := 0
+	if f.SelectedAudioTrack() > 0 {
+		if track, ok := f.sourceAudioTrack(path); ok {
+			index = track.Index
+		}
+	}
+	return fmt.Sprintf("%s:a:%d", input, index)
+}
@@ -0,0 +1,111 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os/exec"
+)
+
+const (
+	// beatSampleRate is the rate music is decoded at to find its beat
+	beatSampleRate = 11025
+	// beatHop is the number of samples per step of the onset envelope (~23 ms)
+	beatHop = 256
+	// beatAnalysisSeconds is how much of the track is analyzed
+	beatAnalysisSeconds = 60
+
+	// Tempos searched, in beats per minute
+	minTempo = 70.0
+	maxTempo = 180.0
+)
+
+// DetectTempo finds the tempo of a music track (beats per minute) and where
+// its first full beat falls (seconds into the track), from the first minute.
+// The beat is taken from how often the sound's loudness jumps (drum hits,
+// chord changes), so it suits music with a clear beat; a half or double tempo
+// is as good for cutting to.
+func (f *FFmpeg) DetectTempo(musicPath string) (bpm, firstBeat float64, err error) {
+	cmd := exec.Command(f.ffmpegPath,
+		"-v", "error",
+		"-t", fmt.Sprint(beatAnalysisSeconds),
+		"-i", musicPath,
+		"-ac", "1",
+		"-ar", fmt.Sprint(beatSampleRate),
+		"-f", "s16le",
+		"-",
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return 0, 0, fmt.Errorf("failed to decode music: %s", stderr.String())
+	}
+
+	samples := make([]int16, stdout.Len()/2)
+	binary.Read(bytes.NewReader(stdout.Bytes()[:len(samples)*2]), binary.LittleEndian, samples)
+
+	onsets := onsetEnvelope(samples)
+	hop := float64(beatHop) / beatSampleRate
+	minLag := int(60 / maxTempo / hop)
+	maxLag := int(60/minTempo/hop) + 1
+	if len(onsets) < maxLag*4 {
+		return 0, 0, fmt.Errorf("%s is too short to find its beat", musicPath)
+	}
+
+	// The beat period is the lag at which the envelope best matches itself
+	bestLag, bestScore := 0, 0.0
+	for lag := minLag; lag <= maxLag; lag++ {
+		score := 0.0
+		for i := lag; i < len(onsets); i++ {
+			score += onsets[i] * onsets[i-lag]
+		}
+		if score > bestScore {
+			bestLag, bestScore = lag, score
+		}
+	}
+	if bestLag == 0 {
+		return 0, 0, fmt.Errorf("no beat found in %s", musicPath)
+	}
+
+	// Refine the period between envelope steps together with the phase: the
+	// offset whose beats land on the strongest onsets. A small error in the
+	// period adds up over a minute of beats, so both are searched at once.
+	period, phase, best := float64(bestLag), 0.0, -1.0
+	for p := float64(bestLag) - 1; p <= float64(bestLag)+1; p += 0.02 {
+		for offset := 0.0; offset < p; offset++ {
+			sum := 0.0
+			for t := offset; int(t) < len(onsets); t += p {
+				sum += onsets[int(t+0.5)%len(onsets)]
+			}
+			if sum > best {
+				period, phase, best = p, offset, sum
+			}
+		}
+	}
+
+	return math.Round(60/(period*hop)*10) / 10, phase * hop, nil
+}
+
+// onsetEnvelope returns how much louder each step of the audio is than the
+// one before (0 when it gets quieter)
+func onsetEnvelope(samples []int16) []float64 {
+	steps := len(samples) / beatHop
+	onsets := make([]float64, steps)
+	prev := 0.0
+	for i := 0; i < steps; i++ {
+		energy := 0.0
+		for _, s := range samples[i*beatHop : (i+1)*beatHop] {
+			energy += float64(s) * float64(s)
+		}
+		level := math.Log1p(energy / beatHop)
+		if i > 0 && level > prev {
+			onsets[i] = level - prev
+		}
+		prev = level
+	}
+	return onsets
+}
@@ -0,0 +1,136 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+const (
+	// benchmarkSeconds is how much video each encoder encodes in the benchmark
+	benchmarkSeconds = 10
+	// speedBlend is how much a finished encode's measured speed moves the
+	// stored speed, so estimates follow the machine without one odd run
+	// (a busy CPU, a hot laptop) replacing the benchmark
+	speedBlend = 0.3
+)
+
+// EncodeSpeed is how fast each encoder re-encodes on this machine, in pixels
+// per second (frame size x frame rate x video seconds / wall seconds), so it
+// scales to sources of any resolution and frame rate
+type EncodeSpeed struct {
+	NVENC    float64   `json:"nvenc"` // 0 = NVENC unavailable
+	CPU      float64   `json:"cpu"`
+	Measured time.Time `json:"measured"`
+}
+
+// BenchmarkEncoders encodes benchmarkSeconds of samplePath (or a generated
+// 1080p60 test pattern if samplePath is "") with NVENC and the CPU encoder,
+// using the clip extraction settings, and returns their speeds. NVENC is
+// skipped (speed 0) if it isn't available.
+func (f *FFmpeg) BenchmarkEncoders(samplePath string) (*EncodeSpeed, error) {
+	inputArgs := []string{"-f", "lavfi", "-i", fmt.Sprintf("testsrc2=s=1920x1080:r=60:d=%d", benchmarkSeconds)}
+	pixelsPerSecond := 1920.0 * 1080 * 60
+	if samplePath != "" {
+		info, err := f.GetStreamInfo(samplePath)
+		if err != nil {
+			return nil, err
+		}
+		inputArgs = []string{"-t", fmt.Sprint(benchmarkSeconds), "-i", samplePath}
+		pixelsPerSecond = sourcePixelRate(info)
+	}
+
+	speed := &EncodeSpeed{Measured: time.Now()}
+
+	if status := f.NVENCStatus(); status == nil || !status.Persistent() {
+		if elapsed, err := f.benchmarkEncode(inputArgs, sourceVideoArgs(nil, true)); err == nil {
+			speed.NVENC = pixelsPerSecond * benchmarkSeconds / elapsed.Seconds()
+		}
+	}
+
+	elapsed, err := f.benchmarkEncode(inputArgs, sourceVideoArgs(nil, false))
+	if err != nil {
+		return nil, err
+	}
+	speed.CPU = pixelsPerSecond * benchmarkSeconds / elapsed.Seconds()
+
+	return speed, nil
+}
+
+// benchmarkEncode times one encode to the null muxer
+func (f *FFmpeg) benchmarkEncode(inputArgs, encoderArgs []string) (time.Duration, error) {
+	args := append([]string{"-hide_banner"}, inputArgs...)
+	args = append(args, encoderArgs...)
+	args = append(args, "-an", "-f", "null", "-")
+
+	cmd := exec.Command(f.ffmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := f.execute(cmd); err != nil {
+		return 0, fmt.Errorf("benchmark encode failed: %s", stderr.String())
+	}
+	return time.Since(start), nil
+}
+
+// sourcePixelRate returns a source's pixels per second of video, assuming
+// 1080p60 for anything that can't be read
+func sourcePixelRate(info *StreamInfo) float64 {
+	if info == nil || info.Width == 0 || info.Height == 0 {
+		return 1920.0 * 1080 * 60
+	}
+	fps := info.FPS()
+	if fps <= 0 {
+		fps = 60
+	}
+	return float64(info.Width*info.Height) * fps
+}
+
+// Estimate returns how long re-encoding videoSeconds of source would take with
+// each encoder (0 for an encoder that wasn't measured). source may be nil.
+func (s *EncodeSpeed) Estimate(videoSeconds float64, source *StreamInfo) (nvenc, cpu time.Duration) {
+	if s == nil {
+		return 0, 0
+	}
+	pixels := sourcePixelRate(source) * videoSeconds
+	if s.NVENC > 0 {
+		nvenc = time.Duration(pixels / s.NVENC * float64(time.Second))
+	}
+	if s.CPU > 0 {
+		cpu = time.Duration(pixels / s.CPU * float64(time.Second))
+	}
+	return nvenc, cpu
+}
+
+// Record blends the speed of a finished encode into the stored speed, so
+// estimates improve as jobs run. elapsed is the wall time the encode took.
+func (s *EncodeSpeed) Record(nvenc bool, videoSeconds float64, source *StreamInfo, elapsed time.Duration) {
+	if s == nil || videoSeconds <= 0 || elapsed <= 0 {
+		return
+	}
+	measured := sourcePixelRate(source) * videoSeconds / elapsed.Seconds()
+
+	stored := &s.CPU
+	if nvenc {
+		stored = &s.NVENC
+	}
+	if *stored == 0 {
+		*stored = measured
+		return
+	}
+	*stored = *stored*(1-speedBlend) + measured*speedBlend
+}
+
+// FormatEstimate formats a rough duration, e.g. "~14 min" or "~1h 35m"
+func FormatEstimate(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1 min"
+	case d < time.Hour:
+		return fmt.Sprintf("~%d min", int(d.Round(time.Minute).Minutes()))
+	}
+	d = d.Round(5 * time.Minute)
+	return fmt.Sprintf("~%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,226 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	// audioBitrateKbps matches the "-b:a 192k" used by all encode paths
+	audioBitrateKbps = 192
+
+	// muxOverhead reserves a share of the target size for MP4 container overhead
+	// (moov atom, chapters, interleaving) so the final file lands under the cap
+	muxOverhead = 0.02
+
+	// minVideoBitrateKbps is the lowest video bitrate we'll accept for 1080p output
+	minVideoBitrateKbps = 500
+)
+
+// TargetVideoBitrate calculates the video bitrate (kbps) needed for an output of
+// durationSec seconds to fit within targetSizeMB megabytes, after subtracting
+// the audio bitrate and container overhead
+func TargetVideoBitrate(durationSec, targetSizeMB float64) (int, error) {
+	if durationSec <= 0 {
+		return 0, fmt.Errorf("cannot calculate bitrate: unknown duration")
+	}
+	if targetSizeMB <= 0 {
+		return 0, fmt.Errorf("target size must be greater than 0")
+	}
+
+	// MB -> kilobits (1 MB = 1024*1024 bytes, 1 kbit = 1000 bits)
+	totalKbits := targetSizeMB * 1024 * 1024 * 8 / 1000 * (1 - muxOverhead)
+	videoKbps := int(totalKbits/durationSec) - audioBitrateKbps
+
+	if videoKbps < minVideoBitrateKbps {
+		return 0, fmt.Errorf("target size %.0f MB is too small for %.0f minutes of video (would need %d kbps)",
+			targetSizeMB, durationSec/60, videoKbps)
+	}
+
+	return videoKbps, nil
+}
+
+// nullOutput returns the platform's null device for discarding first-pass output
+func nullOutput() string {
+	if runtime.GOOS == "windows" {
+		return "NUL"
+	}
+	return "/dev/null"
+}
+
+// encodeTargetSizeNVENC encodes with NVENC in constrained VBR mode.
+// maxrate equals the target bitrate so the output can't overshoot the size cap.
+func (f *FFmpeg) encodeTargetSizeNVENC(inputPaths []string, metaFile, outputPath string, videoKbps int, totalDuration float64, opts ReelOptions, progress func(float64, string)) error {
+	args := append([]string{}, progressArgs...)
+
+	// Add each input file
+	for _, path := range inputPaths {
+		args = append(args, opts.clipInput(path)...)
+	}
+
+	// Add metadata file, then any extra inputs (watermark)
+	args = append(args, "-i", metaFile)
+	args = append(args, opts.extraInputs()...)
+
+	bitrate := fmt.Sprintf("%dk", videoKbps)
+	args = append(args,
+		"-filter_complex", buildReelFilter(inputPaths, opts, len(inputPaths)+1),
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)),
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+		"-c:v", "h264_nvenc",
+		"-preset", "p5",
+		"-profile:v", "high",
+		"-rc", "vbr",
+		"-multipass", "fullres", // NVENC's internal two-pass mode
+		"-b:v", bitrate,
+		"-maxrate", bitrate,
+		"-bufsize", fmt.Sprintf("%dk", videoKbps*2),
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", fmt.Sprintf("%dk", audioBitrateKbps),
+		"-movflags", "+faststart",
+		"-y",
+		outputPath,
+	)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	reportProgress(cmd, totalDuration, 0.15, 0.99, fmt.Sprintf("Encoding (GPU, %d kbps)", videoKbps), progress)
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("encode cancelled")
+		}
+		return fmt.Errorf("nvenc target size encode failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// encodeTargetSizeCPU runs a classic libx264 two-pass encode at the target bitrate.
+// Pass 1 analyzes the video (output discarded), pass 2 writes the final file.
+func (f *FFmpeg) encodeTargetSizeCPU(inputPaths []string, metaFile, outputPath string, videoKbps int, totalDuration float64, opts ReelOptions, progress func(float64, string)) error {
+	// Pass log files go in their own temp dir (libx264 writes several files)
+	passDir, err := os.MkdirTemp(f.TempDir(), "ffmpeg-2pass-*")
+	if err != nil {
+		return fmt.Errorf("failed to create pass log directory: %w", err)
+	}
+	defer os.RemoveAll(passDir)
+	passLog := filepath.Join(passDir, "pass")
+
+	bitrate := fmt.Sprintf("%dk", videoKbps)
+
+	// Pass 1: analyzes the video, output discarded (no metadata input, so extras
+	// follow the clips). The reel's audio is mapped too, as ffmpeg refuses a
+	// filter graph with an output left unconnected; the null muxer drops it.
+	progress(0.15, fmt.Sprintf("Pass 1/2: analyzing video (%s)...", bitrate))
+	pass1 := append([]string{}, progressArgs...)
+	for _, path := range inputPaths {
+		pass1 = append(pass1, opts.clipInput(path)...)
+	}
+	pass1 = append(pass1, opts.extraInputs()...)
+	pass1 = append(pass1,
+		"-filter_complex", buildReelFilter(inputPaths, opts, len(inputPaths)),
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-c:v", "libx264",
+		"-preset", "medium",
+		"-profile:v", "high",
+		"-b:v", bitrate,
+		"-pix_fmt", "yuv420p",
+		"-pass", "1",
+		"-passlogfile", passLog,
+		"-c:a", "pcm_s16le",
+		"-f", "null",
+		"-y",
+		nullOutput(),
+	)
+
+	cmd := f.command(pass1...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	reportProgress(cmd, totalDuration, 0.15, 0.55, "Pass 1/2: analyzing", progress)
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("encode cancelled")
+		}
+		return fmt.Errorf("pass 1 failed: %s", stderr.String())
+	}
+
+	// Pass 2: final encode with audio and chapters
+	progress(0.55, fmt.Sprintf("Pass 2/2: encoding at %s...", bitrate))
+	pass2 := append([]string{}, progressArgs...)
+	for _, path := range inputPaths {
+		pass2 = append(pass2, opts.clipInput(path)...)
+	}
+	pass2 = append(pass2, "-i", metaFile)
+	pass2 = append(pass2, opts.extraInputs()...)
+	pass2 = append(pass2,
+		"-filter_complex", buildReelFilter(inputPaths, opts, len(inputPaths)+1),
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)),
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+		"-c:v", "libx264",
+		"-preset", "medium",
+		"-profile:v", "high",
+		"-b:v", bitrate,
+		"-pix_fmt", "yuv420p",
+		"-pass", "2",
+		"-passlogfile", passLog,
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", fmt.Sprintf("%dk", audioBitrateKbps),
+		"-movflags", "+faststart",
+		"-y",
+		outputPath,
+	)
+
+	cmd = f.command(pass2...)
+
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	reportProgress(cmd, totalDuration, 0.55, 0.99, fmt.Sprintf("Pass 2/2: encoding (%s)", bitrate), progress)
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("encode cancelled")
+		}
+		return fmt.Errorf("pass 2 failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// encodeTargetSize encodes inputs to fit within targetSizeMB.
+// Uses NVENC constrained VBR unless forceCPU is set, falling back to libx264 two-pass.
+func (f *FFmpeg) encodeTargetSize(inputPaths []string, metaFile, outputPath string, totalDuration, targetSizeMB float64, forceCPU bool, opts ReelOptions, progress func(float64, string)) error {
+	videoKbps, err := TargetVideoBitrate(totalDuration, targetSizeMB)
+	if err != nil {
+		return err
+	}
+	opts = f.withToneMap(inputPaths, opts)
+
+	if !forceCPU {
+		progress(0.15, fmt.Sprintf("Encoding at %d kbps to fit %.0f MB (GPU)...", videoKbps, targetSizeMB))
+		err = f.tryNVENC(func() error {
+			return f.encodeTargetSizeNVENC(inputPaths, metaFile, outputPath, videoKbps, totalDuration, opts, progress)
+		})
+		if err == nil || f.IsCancelled() {
+			return err
+		}
+		progress(0.15, fallbackMessage(err)+" (two-pass)")
+	}
+
+	return f.encodeTargetSizeCPU(inputPaths, metaFile, outputPath, videoKbps, totalDuration, opts, progress)
+}
@@ -0,0 +1,109 @@
+package ffmpeg
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// chainLabels matches the labels at the start (inputs) or end (outputs) of a
+// filter chain
+var (
+	chainInputs  = regexp.MustCompile(`^((?:\[[^\]]+\])+)`)
+	chainOutputs = regexp.MustCompile(`((?:\[[^\]]+\])+)$`)
+	label        = regexp.MustCompile(`\[[^\]]+\]`)
+)
+
+// unmappedOutputs returns the outputs of a filter graph that no other chain
+// reads and the command doesn't -map, which ffmpeg rejects ("Filter ... has
+// an unconnected output")
+func unmappedOutputs(call []string) []string {
+	graph := argAt(call, "-filter_complex")
+	consumed := make(map[string]bool)
+	var produced []string
+	for _, chain := range strings.Split(graph, ";") {
+		if m := chainInputs.FindString(chain); m != "" {
+			for _, l := range label.FindAllString(m, -1) {
+				consumed[l] = true
+			}
+		}
+		if m := chainOutputs.FindString(chain); m != "" {
+			produced = append(produced, label.FindAllString(m, -1)...)
+		}
+	}
+	var unmapped []string
+	for _, l := range produced {
+		if consumed[l] {
+			continue
+		}
+		mapped := false
+		for i, arg := range call {
+			if arg == "-map" && i+1 < len(call) && call[i+1] == l {
+				mapped = true
+			}
+		}
+		if !mapped {
+			unmapped = append(unmapped, l)
+		}
+	}
+	return unmapped
+}
+
+func TestEncodeTargetSizeCPUPasses(t *testing.T) {
+	dir := t.TempDir()
+	inputs := []string{filepath.Join(dir, "001.mp4"), filepath.Join(dir, "002.mov")}
+	tests := []struct {
+		name string
+		opts ReelOptions
+	}{
+		{"plain", DefaultReelOptions},
+		{"watermark", ReelOptions{Conform: DefaultConform, Watermark: &Watermark{ImagePath: filepath.Join(dir, "logo.png"), Corner: "top-right", Scale: 0.1, Opacity: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeRunner{}
+			f := NewWithRunner(fake)
+			f.SetTempDir(t.TempDir())
+
+			output := filepath.Join(t.TempDir(), "reel.mp4")
+			err := f.encodeTargetSize(inputs, filepath.Join(dir, "meta.txt"), output, 120, 50, true, tt.opts, func(float64, string) {})
+			if err != nil {
+				t.Fatalf("encodeTargetSize: %v", err)
+			}
+
+			var passes [][]string
+			for _, call := range fake.Calls() {
+				if call[0] == "ffmpeg" && slices.Contains(call, "-pass") {
+					passes = append(passes, call)
+				}
+			}
+			if len(passes) != 2 {
+				t.Fatalf("ran %d passes, want 2: %v", len(passes), fake.Calls())
+			}
+
+			pass1 := passes[0]
+			if argAt(pass1, "-pass") != "1" || argAt(pass1, "-f") != "null" {
+				t.Errorf("first pass isn't pass 1 to the null muxer: %v", pass1)
+			}
+			if slices.Contains(pass1, "-an") {
+				t.Errorf("pass 1 drops audio with -an, leaving the concat's audio output unconnected: %v", pass1)
+			}
+			for i, pass := range passes {
+				if unmapped := unmappedOutputs(pass); len(unmapped) > 0 {
+					t.Errorf("pass %d leaves filter outputs %v unconnected: %s", i+1, unmapped, argAt(pass, "-filter_complex"))
+				}
+				if argAt(pass, "-c:v") != "libx264" || argAt(pass, "-b:v") == "" {
+					t.Errorf("pass %d isn't a libx264 bitrate encode: %v", i+1, pass)
+				}
+			}
+			if argAt(passes[0], "-passlogfile") != argAt(passes[1], "-passlogfile") {
+				t.Errorf("passes use different log files: %q and %q", argAt(passes[0], "-passlogfile"), argAt(passes[1], "-passlogfile"))
+			}
+			if last := passes[1][len(passes[1])-1]; last != output {
+				t.Errorf("pass 2 writes %q, want %q", last, output)
+			}
+		})
+	}
+}
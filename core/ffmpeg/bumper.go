@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// IsStillImage returns true if the path is an image that needs a duration
+// to be used as a video (e.g., a team logo PNG used as an outro card)
+func IsStillImage(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".bmp":
+		return true
+	}
+	return false
+}
+
+// ConformBumper re-encodes an intro/outro bumper (video or still image) so that it
+// matches the codec, resolution, frame rate and audio format of ref.
+// The result can then be joined with the reel clips using stream copy, and the
+// encode path gets consistent inputs as well.
+// stillDuration is the length in seconds used when the input is an image.
+func (f *FFmpeg) ConformBumper(inputPath, outputPath string, ref *StreamInfo, stillDuration float64) error {
+	sampleRate := ref.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 48000
+	}
+	channelLayout := "stereo"
+	if ref.Channels == 1 {
+		channelLayout = "mono"
+	}
+	silence := fmt.Sprintf("anullsrc=r=%d:cl=%s", sampleRate, channelLayout)
+
+	args := []string{}
+	if IsStillImage(inputPath) {
+		// Loop the image for the requested duration with a silent audio track
+		dur := fmt.Sprintf("%.3f", stillDuration)
+		args = append(args,
+			"-loop", "1",
+			"-t", dur,
+			"-i", inputPath,
+			"-f", "lavfi",
+			"-t", dur,
+			"-i", silence,
+			"-map", "0:v:0",
+			"-map", "1:a:0",
+		)
+	} else {
+		// Use the bumper's own audio if it has any, otherwise add silence
+		src, err := f.GetStreamInfo(inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to probe bumper: %w", err)
+		}
+		if src.AudioCodec != "" {
+			args = append(args,
+				"-i", inputPath,
+				"-map", "0:v:0",
+				"-map", "0:a:0",
+			)
+		} else {
+			args = append(args,
+				"-i", inputPath,
+				"-f", "lavfi",
+				"-i", silence,
+				"-map", "0:v:0",
+				"-map", "1:a:0",
+				"-shortest",
+			)
+		}
+	}
+
+	// Scale/pad to the reel's resolution and retime to its frame rate
+	vf := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1",
+		ref.Width, ref.Height, ref.Width, ref.Height)
+	if ref.FrameRate != "" && ref.FrameRate != "0/0" {
+		vf += ",fps=" + ref.FrameRate
+	}
+	args = append(args, "-vf", vf)
+
+	args = append(args, bumperVideoCodecArgs(ref)...)
+	args = append(args, bumperAudioCodecArgs(ref)...)
+	args = append(args,
+		"-ar", fmt.Sprintf("%d", sampleRate),
+		"-ac", fmt.Sprintf("%d", max(ref.Channels, 1)),
+		"-y",
+		outputPath,
+	)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("failed to conform bumper %s: %s", filepath.Base(inputPath), stderr.String())
+	}
+
+	return nil
+}
+
+// bumperVideoCodecArgs returns encoder arguments that produce the same codec as ref
+func bumperVideoCodecArgs(ref *StreamInfo) []string {
+	pixFmt := ref.PixFmt
+	if pixFmt == "" {
+		pixFmt = "yuv420p"
+	}
+
+	switch ref.VideoCodec {
+	case "dnxhd":
+		// Shutter Encoder DNxHR HQ output
+		return []string{"-c:v", "dnxhd", "-profile:v", "dnxhr_hq", "-pix_fmt", pixFmt}
+	case "prores":
+		return []string{"-c:v", "prores_ks", "-profile:v", "3", "-pix_fmt", pixFmt}
+	case "hevc":
+		return []string{"-c:v", "libx265", "-crf", "20", "-tag:v", "hvc1", "-pix_fmt", pixFmt}
+	default:
+		// H.264 (extracted YouTube clips)
+		return []string{"-c:v", "libx264", "-preset", "medium", "-profile:v", "high", "-crf", "18", "-pix_fmt", pixFmt}
+	}
+}
+
+// bumperAudioCodecArgs returns encoder arguments that produce the same audio codec as ref
+func bumperAudioCodecArgs(ref *StreamInfo) []string {
+	if strings.HasPrefix(ref.AudioCodec, "pcm_") {
+		return []string{"-c:a", ref.AudioCodec}
+	}
+	return []string{"-c:a", "aac", "-b:a", "192k"}
+}
@@ -0,0 +1,113 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestCancelExportKillsEveryRunningCommand(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep command")
+	}
+	// "ffmpeg" is sleep, so each command runs until it is killed
+	f := newFFmpeg(sleep, sleep)
+
+	const workers = 3
+	done := make(chan error, workers)
+	for range workers {
+		go func() { done <- f.run(f.command("30")) }()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f.runningMu.Lock()
+		running := len(f.running)
+		f.runningMu.Unlock()
+		if running == workers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d commands started", running, workers)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := f.CancelExport(); err != nil {
+		t.Fatal(err)
+	}
+	for range workers {
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Error("cancelled command succeeded")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("a command was still running after CancelExport")
+		}
+	}
+
+	// Nothing starts until the cancel is reset
+	if err := f.run(f.command("0")); !errors.Is(err, errCancelled) {
+		t.Errorf("command after cancel: got %v, want %v", err, errCancelled)
+	}
+	f.ResetCancel()
+	if err := f.run(f.command("0")); err != nil {
+		t.Errorf("command after reset: %v", err)
+	}
+}
+
+func TestCancelExportWhileWorkersCheck(t *testing.T) {
+	fake := &FakeRunner{}
+	f := NewWithRunner(fake)
+
+	// Parallel workers check the flag while another goroutine cancels
+	// (run with -race)
+	done := make(chan bool)
+	for range 4 {
+		go func() {
+			for !f.IsCancelled() {
+				f.run(f.command("-version"))
+			}
+			done <- true
+		}()
+	}
+	f.CancelExport()
+	for range 4 {
+		<-done
+	}
+
+	calls := len(fake.Calls())
+	if err := f.run(f.command("-version")); !errors.Is(err, errCancelled) {
+		t.Errorf("command after cancel: got %v, want %v", err, errCancelled)
+	}
+	if len(fake.Calls()) != calls {
+		t.Error("command ran after cancel")
+	}
+}
+
+func TestWithCancelCancelsOnItsOwn(t *testing.T) {
+	f := NewWithRunner(&FakeRunner{})
+	job, other := f.WithCancel(), f.WithCancel()
+
+	job.CancelExport()
+	if err := job.run(job.command("-version")); !errors.Is(err, errCancelled) {
+		t.Errorf("cancelled handle: got %v, want %v", err, errCancelled)
+	}
+	for name, h := range map[string]*FFmpeg{"other handle": other, "original": f} {
+		if h.IsCancelled() {
+			t.Errorf("%s is cancelled", name)
+		}
+		if err := h.run(h.command("-version")); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// Settings are shared
+	job.SetTempDir("work")
+	if got := f.TempDir(); got != "work" {
+		t.Errorf("TempDir = %q, want the handle's %q", got, "work")
+	}
+}
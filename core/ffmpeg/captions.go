@@ -0,0 +1,102 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Caption is a subtitle shown from Start to End seconds into a video
+type Caption struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+// ReelCaptions times one caption per reel input, in the order the inputs are
+// combined with hard cuts. texts maps input path -> caption; inputs without
+// one (e.g. intro/outro bumpers) still take up their time but get no caption.
+func (f *FFmpeg) ReelCaptions(inputPaths []string, texts map[string]string) ([]Caption, error) {
+	return f.ReelCaptionsWithTransition(inputPaths, texts, Transition{})
+}
+
+// ReelCaptionsWithTransition is ReelCaptions for inputs combined with the
+// given transition. Where clips crossfade, each caption ends as the next one
+// starts.
+func (f *FFmpeg) ReelCaptionsWithTransition(inputPaths []string, texts map[string]string, transition Transition) ([]Caption, error) {
+	var durations []float64
+	for _, path := range inputPaths {
+		dur, err := f.GetDuration(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to time captions: %w", err)
+		}
+		durations = append(durations, dur)
+	}
+
+	offsets, total := transition.reelOffsets(durations)
+	var captions []Caption
+	for i, path := range inputPaths {
+		end := total
+		if i < len(offsets)-1 {
+			end = offsets[i+1]
+		}
+		if text := strings.TrimSpace(texts[path]); text != "" {
+			captions = append(captions, Caption{Start: offsets[i], End: end, Text: text})
+		}
+	}
+	return captions, nil
+}
+
+// FormatSRT formats captions as a SubRip (.srt) file
+func FormatSRT(captions []Caption) string {
+	var sb strings.Builder
+	for i, c := range captions {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1, srtTime(c.Start), srtTime(c.End), c.Text)
+	}
+	return sb.String()
+}
+
+// srtTime formats seconds as an SRT timestamp (HH:MM:SS,mmm)
+func srtTime(seconds float64) string {
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// EmbedCaptions copies videoPath to outputPath with the captions added as a
+// soft subtitle track (mov_text) that players can turn on and off. Video,
+// audio, chapters and tags are copied unchanged.
+func (f *FFmpeg) EmbedCaptions(videoPath, outputPath string, captions []Caption) error {
+	srtFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-captions-*.srt")
+	if err != nil {
+		return fmt.Errorf("failed to create captions file: %w", err)
+	}
+	defer os.Remove(srtFile.Name())
+
+	fmt.Fprint(srtFile, FormatSRT(captions))
+	srtFile.Close()
+
+	cmd := f.command(
+		"-i", videoPath,
+		"-i", srtFile.Name(),
+		"-map", "0:v",
+		"-map", "0:a?",
+		"-map", "1:s",
+		"-map_metadata", "0",
+		"-map_chapters", "0",
+		"-c", "copy",
+		"-c:s", "mov_text",
+		"-metadata:s:s:0", "language=eng",
+		"-metadata:s:s:0", "handler_name=Highlights",
+		"-y", outputPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg caption embed failed: %s", stderr.String())
+	}
+
+	return nil
+}
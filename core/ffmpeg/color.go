@@ -0,0 +1,114 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NeutralTemperature is the white balance (Kelvin) that leaves colors unchanged
+const NeutralTemperature = 6500
+
+// ColorCorrection is a per-period grade applied to re-encoded clips, so clips
+// from periods shot under different light match in the reel
+type ColorCorrection struct {
+	LUTPath     string  `json:"lut_path,omitempty"` // .cube 3D LUT (empty = none)
+	Exposure    float64 `json:"exposure"`           // Stops, -3 to +3 (0 = unchanged)
+	Temperature float64 `json:"temperature"`        // Kelvin; lower is warmer, higher is cooler (0 or 6500 = unchanged)
+}
+
+// IsZero reports whether the correction leaves the video unchanged
+func (c *ColorCorrection) IsZero() bool {
+	if c == nil {
+		return true
+	}
+	return c.LUTPath == "" && c.Exposure == 0 &&
+		(c.Temperature == 0 || c.Temperature == NeutralTemperature)
+}
+
+// filterChain returns the comma-separated video filters for the correction,
+// or "" if there is nothing to apply. Exposure and white balance are corrected
+// before the LUT, as a LUT expects a balanced image.
+func (c *ColorCorrection) filterChain() string {
+	if c.IsZero() {
+		return ""
+	}
+
+	var filters []string
+	if c.Exposure != 0 {
+		filters = append(filters, fmt.Sprintf("exposure=exposure=%.2f", c.Exposure))
+	}
+	if c.Temperature != 0 && c.Temperature != NeutralTemperature {
+		filters = append(filters, fmt.Sprintf("colortemperature=temperature=%.0f", c.Temperature))
+	}
+	if c.LUTPath != "" {
+		filters = append(filters, "lut3d=file="+escapeFilterPath(c.LUTPath))
+	}
+	return strings.Join(filters, ",")
+}
+
+// escapeFilterPath escapes a file path for use as a filter option inside a
+// filter graph. Both levels of ffmpeg's escaping apply: the option value
+// (':' and quotes) and then the graph itself (backslashes, brackets, ',' and ';').
+func escapeFilterPath(path string) string {
+	path = strings.ReplaceAll(path, "\\", "/")
+
+	value := strings.NewReplacer(":", "\\:", "'", "\\'").Replace(path)
+
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		"'", "\\'",
+		"[", "\\[",
+		"]", "\\]",
+		",", "\\,",
+		";", "\\;",
+	).Replace(value)
+}
+
+// clipVideoFilters returns filter_complex steps that apply source (the source's
+// deinterlace/rotation filters, may be ""), color correct and then watermark
+// the stream labelled base, producing a stream labelled outv.
+// logoIndex is the input index of the logo. Returns nil if there is nothing to do.
+func clipVideoFilters(base, source string, color *ColorCorrection, watermark *Watermark, logoIndex int) []string {
+	graded := color.filterChain()
+	if source != "" && graded != "" {
+		graded = source + "," + graded
+	} else if source != "" {
+		graded = source
+	}
+	hasLogo := watermark != nil && watermark.ImagePath != ""
+
+	var filters []string
+	if graded != "" {
+		out := "outv"
+		if hasLogo {
+			out = "graded"
+		}
+		filters = append(filters, fmt.Sprintf("[%s]%s[%s]", base, graded, out))
+		base = out
+	}
+	if hasLogo {
+		filters = append(filters, watermark.overlayFilter(base, logoIndex, "outv"))
+	}
+	return filters
+}
+
+// clipFilterArgs returns the extra input and video mapping needed to turn
+// (source filters), color correct and/or watermark a single clip. logoIndex is
+// the input index the logo will be given. Returns nil slices when there is
+// nothing to apply so callers keep their default mapping. The audio stream
+// (see audioStream) is mapped only if the source has some.
+func clipFilterArgs(source string, watermark *Watermark, color *ColorCorrection, logoIndex int, audioStream string, hasAudio bool) (inputArgs, mapArgs []string) {
+	filters := clipVideoFilters("0:v", source, color, watermark, logoIndex)
+	if filters == nil {
+		return nil, nil
+	}
+	if watermark != nil && watermark.ImagePath != "" {
+		inputArgs = []string{"-i", watermark.ImagePath}
+	}
+	mapArgs = []string{
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[outv]",
+	}
+	mapArgs = append(mapArgs, audioMapArgs(audioStream, hasAudio)...)
+	return inputArgs, mapArgs
+}
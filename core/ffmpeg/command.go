@@ -0,0 +1,120 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxCommandLog caps how many recorded command lines are kept between TakeCommands calls
+const maxCommandLog = 500
+
+// command creates an ffmpeg command and records its command line, so the UI can
+// show exactly what was (or, in dry-run mode, would have been) run
+func (f *FFmpeg) command(args ...string) *exec.Cmd {
+	if threads := f.Threads(); threads > 0 {
+		args = threadArgs(args, threads)
+	}
+	cmd := exec.Command(f.ffmpegPath, args...)
+
+	f.encoderMu.Lock()
+	if len(f.commandLog) < maxCommandLog {
+		f.commandLog = append(f.commandLog, CommandLine(f.ffmpegPath, args))
+	}
+	f.encoderMu.Unlock()
+
+	return cmd
+}
+
+// run executes a command created by command. In dry-run mode the inputs are
+// checked but nothing is executed, and the operation carries on as if ffmpeg
+// had succeeded (ffprobe calls still run, so durations and chapters are real).
+// Failed commands are recorded for FailureFor, and commands run for the
+// history handler.
+func (f *FFmpeg) run(cmd *exec.Cmd) error {
+	if !f.DryRun() {
+		started := time.Now()
+		err := f.executeCancellable(cmd)
+		if err != nil && !f.IsCancelled() {
+			f.recordFailure(cmd)
+		}
+		if !f.IsCancelled() {
+			f.recordHistory(cmd, started, err)
+		}
+		return err
+	}
+
+	err := validateInputs(cmd.Args[1:])
+	if err != nil && cmd.Stderr != nil {
+		// Callers report failures from the captured stderr
+		fmt.Fprint(cmd.Stderr, err.Error())
+	}
+	return err
+}
+
+// SetDryRun enables or disables dry-run mode
+func (f *FFmpeg) SetDryRun(enabled bool) {
+	f.encoderMu.Lock()
+	f.dryRun = enabled
+	f.encoderMu.Unlock()
+}
+
+// DryRun returns true if ffmpeg commands are only recorded, not executed
+func (f *FFmpeg) DryRun() bool {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+	return f.dryRun
+}
+
+// TakeCommands returns the ffmpeg command lines recorded since the last call
+// and clears the log
+func (f *FFmpeg) TakeCommands() []string {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+	cmds := f.commandLog
+	f.commandLog = nil
+	return cmds
+}
+
+// CommandLine formats a program and its arguments as a copy-pasteable shell command
+func CommandLine(program string, args []string) string {
+	parts := []string{quoteArg(program)}
+	for _, arg := range args {
+		parts = append(parts, quoteArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteArg wraps an argument in double quotes if it contains characters the
+// shell would interpret (works for both cmd.exe and POSIX shells for typical paths)
+func quoteArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(arg, " \t\"'&|;<>()[]*?$`\\,=") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// validateInputs checks that every file passed with -i exists.
+// Virtual inputs (-f lavfi sources) are skipped.
+func validateInputs(args []string) error {
+	lavfi := false
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "-f":
+			lavfi = args[i+1] == "lavfi"
+		case "-i":
+			if !lavfi {
+				if _, err := os.Stat(args[i+1]); err != nil {
+					return fmt.Errorf("input not found: %s", args[i+1])
+				}
+			}
+			lavfi = false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// concatEntry is one file in a concat demuxer list
+type concatEntry struct {
+	path     string
+	inpoint  float64 // Seconds into the file to start at (0 = its start)
+	outpoint float64 // Seconds into the file to stop at (0 = its end)
+}
+
+// concatFiles returns the entries of a list joining whole files
+func concatFiles(paths []string) []concatEntry {
+	entries := make([]concatEntry, len(paths))
+	for i, path := range paths {
+		entries[i] = concatEntry{path: path}
+	}
+	return entries
+}
+
+// ConcatQuote returns path quoted for a "file" line of an ffmpeg concat list.
+// The path is made absolute, as the demuxer reads relative paths from the
+// list's folder, and wrapped in single quotes, inside which everything but a
+// quote is taken as is (spaces, backslashes, accents and other unicode); each
+// quote in it closes the quotes, adds an escaped quote and opens them again.
+// A path with a line break can't be listed and returns an error.
+func ConcatQuote(path string) (string, error) {
+	if strings.ContainsAny(path, "\r\n") {
+		return "", fmt.Errorf("can't join %q: its name has a line break", filepath.Base(path))
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	return "'" + strings.ReplaceAll(abs, "'", `'\''`) + "'", nil
+}
+
+// writeConcatList writes a concat demuxer list of entries to the temp folder
+// and returns its path, for use with -f concat -safe 0. The caller removes it.
+func (f *FFmpeg) writeConcatList(entries []concatEntry) (string, error) {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		quoted, err := ConcatQuote(e.path)
+		if err != nil {
+			return "", err
+		}
+		line := "file " + quoted + "\n"
+		if e.inpoint > 0 {
+			line += fmt.Sprintf("inpoint %.3f\n", e.inpoint)
+		}
+		if e.outpoint > 0 {
+			line += fmt.Sprintf("outpoint %.3f\n", e.outpoint)
+		}
+		lines = append(lines, line)
+	}
+
+	file, err := os.CreateTemp(f.TempDir(), "ffmpeg-concat-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create concat file: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	for _, line := range lines {
+		w.WriteString(line)
+	}
+	err = w.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write concat file: %w", err)
+	}
+	return file.Name(), nil
+}
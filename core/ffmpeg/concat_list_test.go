@@ -0,0 +1,103 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConcatQuote(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"plain", filepath.Join(dir, "clip.mp4"), "'" + filepath.Join(dir, "clip.mp4") + "'"},
+		{"spaces", filepath.Join(dir, "Game 3", "clip 01.mp4"), "'" + filepath.Join(dir, "Game 3", "clip 01.mp4") + "'"},
+		{"apostrophe", filepath.Join(dir, "St. Mary's", "clip.mp4"), "'" + filepath.Join(dir, "St. Mary") + `'\''` + filepath.Join("s", "clip.mp4") + "'"},
+		{"two apostrophes", filepath.Join(dir, "'a'.mp4"), "'" + dir + string(filepath.Separator) + `'\''a'\''.mp4'`},
+		{"unicode", filepath.Join(dir, "Åre Hästar", "mål 🏒.mp4"), "'" + filepath.Join(dir, "Åre Hästar", "mål 🏒.mp4") + "'"},
+		{"backslash", filepath.Join(dir, `back\slash.mp4`), "'" + filepath.Join(dir, `back\slash.mp4`) + "'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConcatQuote(tt.path)
+			if err != nil {
+				t.Fatalf("ConcatQuote(%q): %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("ConcatQuote(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcatQuoteRelative(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ConcatQuote(filepath.Join("clips", "clip.mp4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "'" + filepath.Join(wd, "clips", "clip.mp4") + "'"; got != want {
+		t.Errorf("ConcatQuote of a relative path = %s, want %s", got, want)
+	}
+}
+
+func TestConcatQuoteLineBreak(t *testing.T) {
+	for _, path := range []string{"two\nlines.mp4", "carriage\rreturn.mp4"} {
+		if got, err := ConcatQuote(path); err == nil {
+			t.Errorf("ConcatQuote(%q) = %s, want an error", path, got)
+		} else if !strings.Contains(err.Error(), "line break") {
+			t.Errorf("ConcatQuote(%q) error = %v, want one about the line break", path, err)
+		}
+	}
+}
+
+func TestWriteConcatList(t *testing.T) {
+	f := NewWithRunner(&FakeRunner{})
+	f.SetTempDir(t.TempDir())
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "Coach's cut.mp4")
+	second := filepath.Join(dir, "period 2.mp4")
+	list, err := f.writeConcatList([]concatEntry{
+		{path: first},
+		{path: second, inpoint: 12.5, outpoint: 30},
+	})
+	if err != nil {
+		t.Fatalf("writeConcatList: %v", err)
+	}
+	defer os.Remove(list)
+	if filepath.Dir(list) != f.TempDir() {
+		t.Errorf("list written to %s, want the temp folder %s", filepath.Dir(list), f.TempDir())
+	}
+
+	data, err := os.ReadFile(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "file '" + filepath.Join(dir, "Coach") + `'\''` + "s cut.mp4'\n" +
+		"file '" + second + "'\n" +
+		"inpoint 12.500\n" +
+		"outpoint 30.000\n"
+	if string(data) != want {
+		t.Errorf("concat list =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestWriteConcatListLineBreak(t *testing.T) {
+	f := NewWithRunner(&FakeRunner{})
+	f.SetTempDir(t.TempDir())
+
+	if _, err := f.writeConcatList(concatFiles([]string{"ok.mp4", "bad\nname.mp4"})); err == nil {
+		t.Error("expected an error for a name with a line break")
+	}
+	if entries, _ := os.ReadDir(f.TempDir()); len(entries) != 0 {
+		t.Errorf("left %d files in the temp folder", len(entries))
+	}
+}
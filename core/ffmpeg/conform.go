@@ -0,0 +1,200 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Conform describes the output format that all clips are scaled/retimed to
+// when combining with re-encoding
+type Conform struct {
+	Width     int
+	Height    int
+	FrameRate string // e.g. "60" or "30000/1001"; empty keeps each clip's timing
+}
+
+// DefaultConform is the standard 1080p output used by the encode paths
+var DefaultConform = Conform{Width: 1920, Height: 1080}
+
+// ReelOptions holds the video processing applied when re-encoding clips into a reel
+type ReelOptions struct {
+	Conform    Conform
+	Watermark  *Watermark   // nil = no logo overlay
+	Scoreboard *Scoreboard  // nil = no score overlay
+	Counter    *ClipCounter // nil = no clip counter
+	Tags       Tags         // Metadata tags written into the reel
+	Transition Transition   // How clips meet (zero = hard cuts)
+	// ChapterTitles renames the chapters of the clips (nil = as in the clips)
+	ChapterTitles ChapterTitles
+	// Audio treats the sound of clips, by path (see ClipAudio), with
+	// MusicPath as the music bed of AudioMusic clips
+	Audio     map[string]ClipAudio
+	MusicPath string
+
+	toneMap   map[string]bool // HDR inputs to tone-map to SDR (see withToneMap)
+	durations []float64       // Input durations, needed for transitions
+	trims     map[string]Trim // Parts of the inputs kept, by path (full game export)
+}
+
+// DefaultReelOptions conforms to 1080p with no extra processing
+var DefaultReelOptions = ReelOptions{Conform: DefaultConform}
+
+// clipInput returns the ffmpeg input for one of the reel's clips, trimmed if
+// the options trim it
+func (o ReelOptions) clipInput(path string) []string {
+	return trimInputArgs(path, o.trims[path])
+}
+
+// extraInputs returns the additional ffmpeg inputs needed by the reel options
+// (the watermark image, then the music bed). They must be added after the
+// clips and metadata file.
+func (o ReelOptions) extraInputs() []string {
+	var args []string
+	if o.Watermark != nil && o.Watermark.ImagePath != "" {
+		args = append(args, "-i", o.Watermark.ImagePath)
+	}
+	if o.usesMusic() {
+		args = append(args, musicInputArgs(o.MusicPath)...)
+	}
+	return args
+}
+
+// buildReelFilter builds the full filter_complex for a reel: conform the clips and
+// concat them (or crossfade them together), then apply any overlays. extraIndex is the input index of the first extra
+// input returned by extraInputs. The result always produces [outv] and [outa].
+func buildReelFilter(inputPaths []string, opts ReelOptions, extraIndex int) string {
+	filterStr := buildConcatFilter(len(inputPaths), opts.Conform)
+	for i, path := range inputPaths {
+		if opts.toneMap[path] {
+			label := fmt.Sprintf("[%d:v]", i)
+			filterStr = strings.Replace(filterStr, label, label+toneMapFilter+",", 1)
+		}
+	}
+	if opts.Scoreboard != nil {
+		// Drawn on each clip before the concat, so it follows the clip's own timeline
+		for i, path := range inputPaths {
+			if texts := opts.Scoreboard.Clips[path]; len(texts) > 0 {
+				label := fmt.Sprintf("[v%d];", i)
+				filterStr = strings.Replace(filterStr, label, ","+opts.Scoreboard.drawFilter(texts, opts.Conform.Height)+label, 1)
+			}
+		}
+	}
+	if opts.Counter != nil {
+		// Drawn on each clip before the concat, so it fades with the clip
+		index := make(map[string]int, len(opts.Counter.Clips))
+		for i, path := range opts.Counter.Clips {
+			index[path] = i
+		}
+		for i, path := range inputPaths {
+			if n, ok := index[path]; ok {
+				label := fmt.Sprintf("[v%d];", i)
+				filterStr = strings.Replace(filterStr, label, ","+opts.Counter.drawFilter(n, opts.Conform.Height)+label, 1)
+			}
+		}
+	}
+	if len(opts.durations) == len(inputPaths) {
+		filterStr = opts.Transition.apply(filterStr, opts.durations)
+	}
+	musicIndex := extraIndex
+	if opts.Watermark != nil && opts.Watermark.ImagePath != "" {
+		filterStr = strings.Replace(filterStr, "[outv][outa]", "[reelv][outa]", 1)
+		filterStr += ";" + opts.Watermark.overlayFilter("reelv", extraIndex, "outv")
+		musicIndex++
+	}
+	if len(opts.Audio) > 0 {
+		filterStr = reelAudioFilter(filterStr, inputPaths, opts, musicIndex)
+	}
+	return filterStr
+}
+
+// String returns a display string such as "1920x1080 @ 60 fps"
+func (c Conform) String() string {
+	if c.FrameRate == "" {
+		return fmt.Sprintf("%dx%d", c.Width, c.Height)
+	}
+	return fmt.Sprintf("%dx%d @ %s fps", c.Width, c.Height, c.FrameRate)
+}
+
+// buildConcatFilter builds the filter_complex string that scales (with letterboxing)
+// and optionally retimes each input to the conform format, then concatenates video and audio
+// Example: [0:v]scale=1920:1080:...,fps=60[v0];[1:v]...[v1];[v0][0:a][v1][1:a]concat=n=2:v=1:a=1[outv][outa]
+func buildConcatFilter(inputCount int, conform Conform) string {
+	filterStr := ""
+	for i := 0; i < inputCount; i++ {
+		filterStr += fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1",
+			i, conform.Width, conform.Height, conform.Width, conform.Height)
+		if conform.FrameRate != "" {
+			filterStr += ",fps=" + conform.FrameRate
+		}
+		filterStr += fmt.Sprintf("[v%d];", i)
+	}
+	for i := 0; i < inputCount; i++ {
+		filterStr += fmt.Sprintf("[v%d][%d:a]", i, i)
+	}
+	filterStr += fmt.Sprintf("concat=n=%d:v=1:a=1[outv][outa]", inputCount)
+	return filterStr
+}
+
+// MajorityConform probes the given clips and returns the most common resolution
+// and frame rate among them. Clips that can't be probed are skipped.
+// Falls back to DefaultConform if nothing could be probed.
+func (f *FFmpeg) MajorityConform(clipPaths []string) Conform {
+	resCounts := make(map[[2]int]int)
+	fpsCounts := make(map[string]int)
+
+	for _, path := range clipPaths {
+		info, err := f.GetStreamInfo(path)
+		if err != nil || info.Width == 0 || info.Height == 0 {
+			continue
+		}
+		resCounts[[2]int{info.Width, info.Height}]++
+		if info.FrameRate != "" && info.FrameRate != "0/0" {
+			fpsCounts[info.FrameRate]++
+		}
+	}
+
+	if len(resCounts) == 0 {
+		return DefaultConform
+	}
+
+	// Pick the most common; break ties by the larger resolution / higher frame rate
+	var resList [][2]int
+	for res := range resCounts {
+		resList = append(resList, res)
+	}
+	sort.Slice(resList, func(i, j int) bool {
+		if resCounts[resList[i]] != resCounts[resList[j]] {
+			return resCounts[resList[i]] > resCounts[resList[j]]
+		}
+		return resList[i][0]*resList[i][1] > resList[j][0]*resList[j][1]
+	})
+
+	var fpsList []string
+	for fps := range fpsCounts {
+		fpsList = append(fpsList, fps)
+	}
+	sort.Slice(fpsList, func(i, j int) bool {
+		if fpsCounts[fpsList[i]] != fpsCounts[fpsList[j]] {
+			return fpsCounts[fpsList[i]] > fpsCounts[fpsList[j]]
+		}
+		return frameRateValue(fpsList[i]) > frameRateValue(fpsList[j])
+	})
+
+	conform := Conform{Width: resList[0][0], Height: resList[0][1]}
+	if len(fpsList) > 0 {
+		conform.FrameRate = fpsList[0]
+	}
+	return conform
+}
+
+// frameRateValue converts an ffprobe rate such as "60000/1001" to frames per second
+func frameRateValue(rate string) float64 {
+	var num, den float64
+	if n, _ := fmt.Sscanf(rate, "%g/%g", &num, &den); n == 2 && den != 0 {
+		return num / den
+	}
+	var fps float64
+	fmt.Sscanf(rate, "%g", &fps)
+	return fps
+}
@@ -0,0 +1,19 @@
+package ffmpeg
+
+import "fmt"
+
+// ClipCounter is a "3/27" counter burned into the corner of each clip of a
+// reel, so viewers know how far along they are
+type ClipCounter struct {
+	Corner   string   // One of WatermarkCorners
+	FontFile string   // "" = ffmpeg's default font
+	Clips    []string // Input paths of the clips counted, in reel order (bumpers aren't)
+}
+
+// drawFilter returns the drawtext filter that shows the counter of the clip
+// at index (from 0) of the counted clips, sized for frames of the given height
+func (c *ClipCounter) drawFilter(index, height int) string {
+	x, y := textPosition(c.Corner)
+	text := fmt.Sprintf("%d/%d", index+1, len(c.Clips))
+	return fmt.Sprintf("drawtext=text=%s:x=%s:y=%s:%s", escapeFilterValue(text), x, y, textStyle(c.FontFile, height))
+}
@@ -0,0 +1,144 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// frameTolerance absorbs the rounding of cut points to milliseconds when
+// comparing them with frame timestamps
+const frameTolerance = 0.0005
+
+// FirstFrame returns the position (seconds into the video) of the first frame
+// of a clip cut from startSec: the first frame at or after it when the clip is
+// re-encoded (the two-pass seek is frame-accurate), or the keyframe at or
+// before it when it is stream copied, which can only start on a keyframe.
+// Only packet headers around the cut are read.
+func (f *FFmpeg) FirstFrame(videoPath string, startSec float64, streamCopy bool) (float64, error) {
+	frames, err := f.framesAround(videoPath, startSec)
+	if err != nil {
+		return 0, err
+	}
+
+	if streamCopy {
+		keyframe := -1.0
+		for _, fr := range frames {
+			if fr.keyframe && fr.pts <= startSec+frameTolerance {
+				keyframe = fr.pts
+			}
+		}
+		if keyframe >= 0 {
+			return keyframe, nil
+		}
+	}
+	for _, fr := range frames {
+		if fr.pts >= startSec-frameTolerance {
+			return fr.pts, nil
+		}
+	}
+	return 0, fmt.Errorf("no frames at %.3fs in %s", startSec, videoPath)
+}
+
+// LastFrame returns the position (seconds into the video) of the last frame
+// of a clip that ends at endSec: the last frame that starts before it
+func (f *FFmpeg) LastFrame(videoPath string, endSec float64) (float64, error) {
+	frames, err := f.framesAround(videoPath, endSec)
+	if err != nil {
+		return 0, err
+	}
+
+	last := -1.0
+	for _, fr := range frames {
+		if fr.pts < endSec-frameTolerance {
+			last = fr.pts
+		}
+	}
+	if last < 0 {
+		return 0, fmt.Errorf("no frames before %.3fs in %s", endSec, videoPath)
+	}
+	return last, nil
+}
+
+// videoFrame is a video packet's presentation time (seconds into the video)
+// and whether it is a keyframe
+type videoFrame struct {
+	pts      float64
+	keyframe bool
+}
+
+// framesAround returns the video frames from the keyframe at or before atSec
+// to a second after it, in presentation order. ffprobe seeks the way ffmpeg's
+// input seek does, so the first frame is where a stream copy from atSec starts.
+func (f *FFmpeg) framesAround(videoPath string, atSec float64) ([]videoFrame, error) {
+	startTime, err := f.formatStartTime(videoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-read_intervals", fmt.Sprintf("%.3f%%+1", max(atSec, 0)+startTime),
+		"-show_entries", "packet=pts_time,flags",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	// Lines are "pts_time,flags" where flags contains K for keyframes
+	var frames []videoFrame
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), ",")
+		if len(parts) < 2 {
+			continue
+		}
+		pts, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, videoFrame{pts: pts - startTime, keyframe: strings.Contains(parts[1], "K")})
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no video frames found near %.3fs in %s", atSec, videoPath)
+	}
+
+	// Packets are in decode order, which differs from presentation order with B-frames
+	sort.Slice(frames, func(i, j int) bool { return frames[i].pts < frames[j].pts })
+	return frames, nil
+}
+
+// formatStartTime returns the timestamp a video starts at, which ffmpeg's
+// seeks are relative to
+func (f *FFmpeg) formatStartTime(videoPath string) (float64, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=start_time",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	startTime, err := strconv.ParseFloat(strings.TrimSpace(stdout.String()), 64)
+	if err != nil {
+		return 0, nil // "N/A": the timestamps start at 0
+	}
+	return startTime, nil
+}
@@ -0,0 +1,151 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const (
+	// deadAirEdgeTolerance is how close (in seconds) to a clip's start or end
+	// a stretch of dead air must reach to count as leading or trailing
+	deadAirEdgeTolerance = 0.5
+	// deadAirFreezeNoise is how much (dB) frames may differ and still count
+	// as the picture standing still, allowing for sensor noise
+	deadAirFreezeNoise = "-50dB"
+	// MinDeadAirKeep is the least (in seconds) a trim leaves before the first
+	// highlight and after the last, however quiet the clip is around them
+	MinDeadAirKeep = 1.0
+)
+
+// DeadAirOptions says what counts as dead air in a clip
+type DeadAirOptions struct {
+	NoiseDB     float64 // Level (dBFS) the sound stays below, e.g. -35
+	MinDuration float64 // Shortest stretch counted, in seconds
+	Freeze      bool    // Also count stretches where the picture stands still
+}
+
+// DeadAir is the dead air found at the start and end of a clip
+type DeadAir struct {
+	Lead     float64 // Seconds from the start until something happens
+	Tail     float64 // Seconds from when it stops happening to the end
+	Duration float64 // The clip's length
+}
+
+// deadInterval is one stretch of silence or stillness, in seconds into the clip
+type deadInterval struct {
+	start, end float64
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: (-?[\d.]+)`)
+	freezeStartPattern  = regexp.MustCompile(`freeze_start: (-?[\d.]+)`)
+	freezeEndPattern    = regexp.MustCompile(`freeze_end: (-?[\d.]+)`)
+)
+
+// DetectDeadAir finds the dead air at the start and end of a clip (e.g. the
+// wait for a faceoff before the play starts): stretches at least
+// opts.MinDuration long where the sound stays below opts.NoiseDB (ffmpeg's
+// silencedetect) or, with opts.Freeze, the picture stands still
+// (freezedetect). A clip without sound is only checked for stillness.
+func (f *FFmpeg) DetectDeadAir(clipPath string, opts DeadAirOptions) (DeadAir, error) {
+	duration, err := f.GetDuration(clipPath)
+	if err != nil {
+		return DeadAir{}, err
+	}
+	audio := f.hasAudio(clipPath)
+	freeze := opts.Freeze && f.HasFilter("freezedetect")
+	if !audio && !freeze {
+		return DeadAir{Duration: duration}, nil
+	}
+
+	args := []string{"-hide_banner", "-nostats", "-i", clipPath}
+	if audio {
+		args = append(args, "-map", "0:a:0",
+			"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", opts.NoiseDB, opts.MinDuration))
+	}
+	if freeze {
+		// A small picture is plenty to see whether anything moves
+		args = append(args, "-map", "0:v:0",
+			"-vf", fmt.Sprintf("scale=320:-2,freezedetect=n=%s:d=%g", deadAirFreezeNoise, opts.MinDuration))
+	}
+	args = append(args, "-f", "null", "-")
+
+	cmd := exec.Command(f.ffmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := f.execute(cmd); err != nil {
+		return DeadAir{}, fmt.Errorf("failed to check %s for dead air: %s", clipPath, stderr.String())
+	}
+
+	log := stderr.String()
+	intervals := parseDeadIntervals(log, silenceStartPattern, silenceEndPattern, duration)
+	intervals = append(intervals, parseDeadIntervals(log, freezeStartPattern, freezeEndPattern, duration)...)
+	lead, tail := deadAirEdges(intervals, duration)
+	return DeadAir{Lead: lead, Tail: tail, Duration: duration}, nil
+}
+
+// parseDeadIntervals pairs the start and end times a detect filter logged.
+// A stretch still running at the end of the clip has no end logged by older
+// ffmpeg builds, and ends with the clip.
+func parseDeadIntervals(log string, startPattern, endPattern *regexp.Regexp, duration float64) []deadInterval {
+	times := func(pattern *regexp.Regexp) []float64 {
+		var values []float64
+		for _, m := range pattern.FindAllStringSubmatch(log, -1) {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+	starts, ends := times(startPattern), times(endPattern)
+
+	intervals := make([]deadInterval, 0, len(starts))
+	for i, start := range starts {
+		end := duration
+		if i < len(ends) {
+			end = ends[i]
+		}
+		intervals = append(intervals, deadInterval{start: max(start, 0), end: min(end, duration)})
+	}
+	return intervals
+}
+
+// deadAirEdges joins overlapping stretches and returns how long the one
+// touching the clip's start and the one touching its end last
+func deadAirEdges(intervals []deadInterval, duration float64) (lead, tail float64) {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+	var merged []deadInterval
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && iv.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, iv.end)
+			continue
+		}
+		merged = append(merged, iv)
+	}
+
+	for _, iv := range merged {
+		if iv.start <= deadAirEdgeTolerance {
+			lead = iv.end
+		}
+		if iv.end >= duration-deadAirEdgeTolerance {
+			tail = duration - iv.start
+		}
+	}
+	return lead, tail
+}
+
+// Trim returns the seconds before and after the highlights a clip cut with
+// before and after would have without its dead air, keeping at least
+// MinDeadAirKeep on each side (or what it had, if less)
+func (d DeadAir) Trim(before, after float64) (float64, float64) {
+	trimmed := func(seconds, dead float64) float64 {
+		keep := min(seconds, MinDeadAirKeep)
+		return max(seconds-dead, keep)
+	}
+	return trimmed(before, d.Lead), trimmed(after, d.Tail)
+}
@@ -0,0 +1,146 @@
+package ffmpeg
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DownloadURL is the ffmpeg build Download fetches: gyan.dev's release
+// essentials build for Windows, a zip with ffmpeg.exe and ffprobe.exe in bin/
+const DownloadURL = "https://www.gyan.dev/ffmpeg/builds/ffmpeg-release-essentials.zip"
+
+// CanDownload reports whether Download has an ffmpeg build for this system.
+// Elsewhere ffmpeg comes from the system's package manager.
+func CanDownload() bool {
+	return runtime.GOOS == "windows"
+}
+
+// Download fetches ffmpeg and ffprobe from DownloadURL into dir and returns
+// the ffmpeg path. progress (may be nil) is called with the fraction
+// downloaded (0-1), or -1 if the size isn't known.
+func Download(dir string, progress func(float64)) (string, error) {
+	if !CanDownload() {
+		return "", fmt.Errorf("no ffmpeg download for %s; install ffmpeg with your package manager", runtime.GOOS)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	resp, err := http.Get(DownloadURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download ffmpeg: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download ffmpeg: %s", resp.Status)
+	}
+
+	archive, err := os.CreateTemp(dir, "ffmpeg-download-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create download file: %w", err)
+	}
+	defer os.Remove(archive.Name())
+
+	counter := &downloadCounter{total: resp.ContentLength, progress: progress}
+	_, err = io.Copy(io.MultiWriter(archive, counter), resp.Body)
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download ffmpeg: %w", err)
+	}
+
+	ffmpegName, ffprobeName := "ffmpeg.exe", "ffprobe.exe"
+	if err := unzipTools(archive.Name(), dir, ffmpegName, ffprobeName); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ffmpegName), nil
+}
+
+// unzipTools extracts the named executables from the bin/ folder of an ffmpeg
+// build's zip into dir
+func unzipTools(archivePath, dir string, names ...string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open ffmpeg download: %w", err)
+	}
+	defer reader.Close()
+
+	for _, name := range names {
+		var found *zip.File
+		for _, file := range reader.File {
+			if path.Base(file.Name) == name && strings.Contains(file.Name, "bin/") {
+				found = file
+				break
+			}
+		}
+		if found == nil {
+			return fmt.Errorf("ffmpeg download has no %s", name)
+		}
+		if err := extractZipFile(found, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes one file of a zip to outputPath, under a temporary
+// name until it is complete
+func extractZipFile(file *zip.File, outputPath string) error {
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s from ffmpeg download: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	tmpPath := outputPath + ".partial"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outputPath, err)
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+// downloadCounter counts the bytes written through it and reports them as a
+// fraction of total, each time another percent is done
+type downloadCounter struct {
+	written  int64
+	total    int64
+	percent  int64
+	progress func(float64)
+}
+
+func (w *downloadCounter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	if w.progress == nil {
+		return len(p), nil
+	}
+	if w.total <= 0 {
+		w.progress(-1)
+		return len(p), nil
+	}
+	if percent := w.written * 100 / w.total; percent != w.percent {
+		w.percent = percent
+		w.progress(float64(w.written) / float64(w.total))
+	}
+	return len(p), nil
+}
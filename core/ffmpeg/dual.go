@@ -0,0 +1,89 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// ExtractClipDual extracts a clip twice in one ffmpeg run, decoding the source
+// once: re-encoded to encodedPath (.mp4, as ExtractClipWithChapters, with the
+// watermark and color correction) and stream copied to copyPath (.mov, as
+// ExtractClipStreamCopyWithChapters, for editing). Both get the chapter
+// markers and tags. The copy starts at the keyframe before startSec, as any
+// stream copy does; the encode is cut exactly.
+func (f *FFmpeg) ExtractClipDual(inputPath, encodedPath, copyPath string, startSec, durationSec float64, chapters []ClipChapter, tags Tags, watermark *Watermark, color *ColorCorrection) error {
+	metaPath, err := f.writeSpanChapterFile(chapters, tags, durationSec)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metaPath)
+
+	hasAudio := f.hasAudio(inputPath)
+
+	err = f.tryNVENC(func() error {
+		return f.extractClipDual(inputPath, metaPath, encodedPath, copyPath, startSec, durationSec, watermark, color, hasAudio, true)
+	})
+	if err == nil {
+		return nil
+	}
+	return f.extractClipDual(inputPath, metaPath, encodedPath, copyPath, startSec, durationSec, watermark, color, hasAudio, false)
+}
+
+func (f *FFmpeg) extractClipDual(inputPath, metaPath, encodedPath, copyPath string, startSec, durationSec float64, watermark *Watermark, color *ColorCorrection, hasAudio, nvenc bool) error {
+	// Logo (if any) is input 2, after the video and metadata file
+	audio := f.audioStream("0", inputPath)
+	filterInputs, filterMaps := clipFilterArgs(f.sourceFilter(inputPath), watermark, color, 2, audio, hasAudio)
+	if filterMaps == nil {
+		filterMaps = append([]string{"-map", "0:v"}, audioMapArgs(audio, hasAudio)...)
+	}
+	rotateInput, rotateOutput := f.streamCopyRotationArgs(inputPath)
+
+	// One input seek serves both outputs: ffmpeg decodes from the keyframe
+	// before startSec and drops the frames before it from the encode, while
+	// the copy keeps them for the edit list, as a stream copy cut does
+	args := append(f.sourceInputArgs(inputPath), rotateInput...)
+	args = append(args,
+		"-ss", fmt.Sprintf("%.3f", startSec),
+		"-i", inputPath,
+		"-i", metaPath,
+	)
+	args = append(args, filterInputs...)
+	duration := fmt.Sprintf("%.3f", durationSec)
+
+	// Re-encoded output
+	args = append(args, "-t", duration)
+	args = append(args, filterMaps...)
+	args = append(args,
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+	)
+	args = append(args, f.clipEncoderArgs(inputPath, nvenc)...)
+	args = append(args, f.sourceAudioArgs(inputPath, encodedPath, hasAudio)...)
+	args = append(args, "-y", encodedPath)
+
+	// Stream copied output
+	args = append(args, "-t", duration, "-map", "0:v")
+	args = append(args, audioMapArgs(audio, hasAudio)...)
+	args = append(args,
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c", "copy",
+	)
+	args = append(args, f.codecTagArgs(inputPath)...)
+	args = append(args, rotateOutput...)
+	args = append(args, "-y", copyPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		if nvenc {
+			return fmt.Errorf("nvenc failed: %s", stderr.String())
+		}
+		return fmt.Errorf("cpu extract failed: %s", stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// EncoderFailureKind classifies why an NVENC encode failed
+type EncoderFailureKind int
+
+const (
+	// FailureUnknown is any error we don't recognize
+	FailureUnknown EncoderFailureKind = iota
+	// FailureNotBuilt means this ffmpeg build has no h264_nvenc encoder
+	FailureNotBuilt
+	// FailureNoDevice means no NVIDIA GPU / CUDA driver was found
+	FailureNoDevice
+	// FailureDriverTooOld means the installed driver is older than ffmpeg's NVENC API
+	FailureDriverTooOld
+	// FailureSessionLimit means all NVENC sessions are busy (consumer GPUs allow a few
+	// at once, e.g. OBS recording in the background) - usually transient
+	FailureSessionLimit
+	// FailureOutOfMemory means the GPU ran out of memory - usually transient
+	FailureOutOfMemory
+	// FailureUnsupportedInput means NVENC rejected the input (resolution, pixel format)
+	FailureUnsupportedInput
+)
+
+// EncoderError is a classified NVENC failure
+type EncoderError struct {
+	Kind   EncoderFailureKind
+	Stderr string // Full ffmpeg output, for diagnostics
+}
+
+func (e *EncoderError) Error() string {
+	return fmt.Sprintf("nvenc failed (%s): %s", e.Reason(), e.Stderr)
+}
+
+// Reason returns a short, user-facing explanation of the failure
+func (e *EncoderError) Reason() string {
+	switch e.Kind {
+	case FailureNotBuilt:
+		return "this ffmpeg build does not include NVENC"
+	case FailureNoDevice:
+		return "no NVIDIA GPU or driver found"
+	case FailureDriverTooOld:
+		return "NVIDIA driver is too old for this ffmpeg - update the GPU driver"
+	case FailureSessionLimit:
+		return "all GPU encoder sessions are busy (close OBS, Shadowplay or other encoders)"
+	case FailureOutOfMemory:
+		return "GPU out of memory"
+	case FailureUnsupportedInput:
+		return "GPU encoder does not support this video format"
+	}
+	return "unknown GPU encoder error"
+}
+
+// Transient returns true for failures that may succeed if retried shortly
+func (e *EncoderError) Transient() bool {
+	return e.Kind == FailureSessionLimit || e.Kind == FailureOutOfMemory
+}
+
+// Persistent returns true for failures that will fail for every encode this run,
+// so NVENC should not be attempted again
+func (e *EncoderError) Persistent() bool {
+	return e.Kind == FailureNotBuilt || e.Kind == FailureNoDevice || e.Kind == FailureDriverTooOld
+}
+
+// ClassifyNVENCError inspects ffmpeg output from a failed NVENC encode
+func ClassifyNVENCError(stderr string) *EncoderError {
+	s := strings.ToLower(stderr)
+	kind := FailureUnknown
+
+	switch {
+	case strings.Contains(s, "unknown encoder"):
+		kind = FailureNotBuilt
+	case strings.Contains(s, "cannot load nvcuda"),
+		strings.Contains(s, "cannot load libcuda"),
+		strings.Contains(s, "cannot load nvencodeapi"),
+		strings.Contains(s, "cannot load libnvidia-encode"),
+		strings.Contains(s, "no capable devices found"),
+		strings.Contains(s, "no nvenc capable devices"):
+		kind = FailureNoDevice
+	case strings.Contains(s, "driver does not support the required nvenc api version"),
+		strings.Contains(s, "minimum required nvidia driver"):
+		kind = FailureDriverTooOld
+	case strings.Contains(s, "incompatible client key"),
+		strings.Contains(s, "openencodesessionex failed: out of memory"):
+		// Both are what the driver returns when the session limit is reached
+		kind = FailureSessionLimit
+	case strings.Contains(s, "out of memory"):
+		kind = FailureOutOfMemory
+	case strings.Contains(s, "invalid param"),
+		strings.Contains(s, "unsupported"):
+		kind = FailureUnsupportedInput
+	}
+
+	return &EncoderError{Kind: kind, Stderr: stderr}
+}
+
+// errCPUPreferred is returned by tryNVENC when GPU encoding is turned off
+var errCPUPreferred = errors.New("GPU encoding is turned off in Settings")
+
+const (
+	// nvencRetries is how many times a transient NVENC failure is retried
+	nvencRetries = 2
+	// nvencRetryDelay is the wait between retries (lets other sessions finish)
+	nvencRetryDelay = 3 * time.Second
+)
+
+// CheckNVENC runs a tiny test encode to see whether NVENC works on this machine.
+// The result is remembered: if NVENC is unusable, later encodes go straight to
+// CPU instead of failing on the GPU first. Returns nil if NVENC is available.
+func (f *FFmpeg) CheckNVENC() *EncoderError {
+	cmd := exec.Command(f.ffmpegPath,
+		"-hide_banner",
+		"-f", "lavfi",
+		"-i", "color=c=black:s=256x144:d=0.1",
+		"-c:v", "h264_nvenc",
+		"-f", "null",
+		"-",
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	var result *EncoderError
+	if err := f.execute(cmd); err != nil {
+		result = ClassifyNVENCError(stderr.String())
+	}
+
+	f.encoderMu.Lock()
+	f.nvencStatus = result
+	f.encoderMu.Unlock()
+
+	return result
+}
+
+// NVENCStatus returns the result of the last CheckNVENC (nil = available or not checked)
+func (f *FFmpeg) NVENCStatus() *EncoderError {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+	return f.nvencStatus
+}
+
+// SetFallbackHandler registers a callback invoked when an encode falls back from
+// NVENC to CPU. It is called once per failure kind per run, so a batch of clips
+// doesn't raise the same notice for every clip.
+func (f *FFmpeg) SetFallbackHandler(handler func(*EncoderError)) {
+	f.encoderMu.Lock()
+	f.onFallback = handler
+	f.encoderMu.Unlock()
+}
+
+// SetPreferCPU turns GPU encoding off (true), so every encode goes straight
+// to the CPU encoder, or back on
+func (f *FFmpeg) SetPreferCPU(cpu bool) {
+	f.encoderMu.Lock()
+	f.preferCPU = cpu
+	f.encoderMu.Unlock()
+}
+
+// PreferCPU returns true if GPU encoding is turned off
+func (f *FFmpeg) PreferCPU() bool {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+	return f.preferCPU
+}
+
+// tryNVENC runs an NVENC encode, retrying transient failures.
+// Returns nil on success. On failure the returned error means the caller should
+// fall back to CPU (unless the operation was cancelled).
+func (f *FFmpeg) tryNVENC(encode func() error) error {
+	f.encoderMu.Lock()
+	known := f.nvencStatus
+	preferCPU := f.preferCPU
+	f.encoderMu.Unlock()
+
+	if preferCPU {
+		return errCPUPreferred
+	}
+
+	// Skip the GPU entirely if the health check already found it unusable
+	if known != nil && known.Persistent() {
+		f.notifyFallback(known)
+		return known
+	}
+
+	var encErr *EncoderError
+	for attempt := 0; attempt <= nvencRetries; attempt++ {
+		err := encode()
+		if err == nil {
+			return nil
+		}
+		if f.IsCancelled() || f.DryRun() {
+			return err
+		}
+
+		encErr = ClassifyNVENCError(err.Error())
+		if !encErr.Transient() || attempt == nvencRetries {
+			break
+		}
+		time.Sleep(nvencRetryDelay)
+	}
+
+	// Remember persistent failures so later encodes skip the GPU
+	if encErr.Persistent() {
+		f.encoderMu.Lock()
+		f.nvencStatus = encErr
+		f.encoderMu.Unlock()
+	}
+
+	f.notifyFallback(encErr)
+	return encErr
+}
+
+// notifyFallback calls the fallback handler once per failure kind
+func (f *FFmpeg) notifyFallback(e *EncoderError) {
+	f.encoderMu.Lock()
+	if f.notifiedFallbacks == nil {
+		f.notifiedFallbacks = make(map[EncoderFailureKind]bool)
+	}
+	handler := f.onFallback
+	already := f.notifiedFallbacks[e.Kind]
+	f.notifiedFallbacks[e.Kind] = true
+	f.encoderMu.Unlock()
+
+	if handler != nil && !already {
+		handler(e)
+	}
+}
+
+// fallbackMessage formats a progress message explaining a CPU fallback
+func fallbackMessage(err error) string {
+	if errors.Is(err, errCPUPreferred) {
+		return "Encoding on the CPU (GPU encoding is turned off in Settings)..."
+	}
+	if e, ok := err.(*EncoderError); ok {
+		return "Fell back to CPU because: " + e.Reason()
+	}
+	return "GPU encoding not available, using CPU..."
+}
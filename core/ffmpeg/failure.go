@@ -0,0 +1,98 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxFailures caps how many failed commands are kept for FailureFor
+const maxFailures = 20
+
+// Failure is an ffmpeg command that failed, kept so the full command and
+// output can be shown after the fact
+type Failure struct {
+	Command string // Copy-pasteable command line
+	Stderr  string // Full ffmpeg output
+	Time    time.Time
+}
+
+// failureHints maps ffmpeg output (lowercase) to a suggested fix. Every hint
+// with a matching pattern is suggested, in this order.
+var failureHints = []struct {
+	patterns []string
+	hint     string
+}{
+	{[]string{"unknown encoder", "cannot load nvcuda", "cannot load libcuda", "no capable devices found", "no nvenc capable devices"},
+		"NVENC isn't available on this machine - clips are re-encoded on the CPU instead. Check the NVIDIA driver if you expected GPU encoding."},
+	{[]string{"driver does not support the required nvenc api version", "minimum required nvidia driver"},
+		"The NVIDIA driver is too old for this ffmpeg - update the GPU driver, or use a CPU quality option."},
+	{[]string{"incompatible client key", "openencodesessionex failed"},
+		"All GPU encoder sessions are busy - close OBS, ShadowPlay or other recorders and try again."},
+	{[]string{"10 bit encode not supported", "unsupported pixel format", "invalid param", "provided device doesn't support required nvenc features"},
+		"NVENC doesn't support this source's pixel format or size (e.g. 10-bit HEVC) - try a CPU quality option."},
+	{[]string{"moov atom not found", "invalid data found when processing input", "error while decoding", "corrupt"},
+		"The source looks damaged or incomplete - run Verify Sources in Step 1, or convert it again."},
+	{[]string{"no such file or directory", "does not exist"},
+		"A file is missing - check that the drive or network share is connected and the file wasn't moved."},
+	{[]string{"permission denied", "access is denied"},
+		"ffmpeg can't write there - pick an output folder you have write access to, and close any player that has the file open."},
+	{[]string{"no space left on device", "not enough space"},
+		"The output drive is full - free some space or pick another output folder."},
+	{[]string{"input/output error", "network name is no longer available", "semaphore timeout"},
+		"The disk or network share stopped responding - check the connection and try again."},
+	{[]string{"no such filter", "unrecognized option", "option not found"},
+		"This ffmpeg build is missing a feature the operation needs - install a recent full build (ffmpeg 6.1 or later)."},
+	{[]string{"error while opening encoder", "could not open encoder"},
+		"The encoder rejected the settings - try another quality option, or CPU encoding."},
+}
+
+// Suggest returns likely fixes for an ffmpeg failure from its output
+func Suggest(output string) []string {
+	s := strings.ToLower(output)
+	var hints []string
+	for _, h := range failureHints {
+		for _, pattern := range h.patterns {
+			if strings.Contains(s, pattern) {
+				hints = append(hints, h.hint)
+				break
+			}
+		}
+	}
+	return hints
+}
+
+// recordFailure keeps a failed command made by command and run by run
+func (f *FFmpeg) recordFailure(cmd *exec.Cmd) {
+	fl := &Failure{
+		Command: CommandLine(f.ffmpegPath, cmd.Args[1:]),
+		Time:    time.Now(),
+	}
+	if stderr, ok := cmd.Stderr.(*bytes.Buffer); ok {
+		fl.Stderr = stderr.String()
+	}
+
+	f.encoderMu.Lock()
+	f.failures = append(f.failures, fl)
+	if len(f.failures) > maxFailures {
+		f.failures = f.failures[len(f.failures)-maxFailures:]
+	}
+	f.encoderMu.Unlock()
+}
+
+// FailureFor returns the failed command behind an error message, matched by
+// the ffmpeg output the message contains (most recent first), or nil if the
+// error didn't come from a recorded ffmpeg command
+func (f *FFmpeg) FailureFor(message string) *Failure {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+
+	for i := len(f.failures) - 1; i >= 0; i-- {
+		stderr := strings.TrimSpace(f.failures[i].Stderr)
+		if stderr != "" && strings.Contains(message, stderr) {
+			return f.failures[i]
+		}
+	}
+	return nil
+}
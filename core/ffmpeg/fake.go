@@ -0,0 +1,107 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FakeResponse is the recorded result of one command
+type FakeResponse struct {
+	Stdout string
+	Stderr string
+	// Output is written to the command's output file (its last argument);
+	// nil creates it empty. Only used for ffmpeg commands that succeed.
+	Output []byte
+	// Err fails the command (Stderr is still written)
+	Err error
+}
+
+// fakeRule answers the commands of one program whose arguments contain all of match
+type fakeRule struct {
+	program  string
+	match    []string
+	response FakeResponse
+}
+
+// FakeRunner is an FFmpegRunner that answers commands with recorded responses
+// instead of running them, and records every command it was given:
+//
+//	fake := &ffmpeg.FakeRunner{}
+//	fake.On("ffprobe", []string{"format=duration"}, ffmpeg.FakeResponse{Stdout: "1200.5\n"})
+//	ff := ffmpeg.NewWithRunner(fake)
+//
+// A command matching no rule succeeds with no output.
+type FakeRunner struct {
+	mu    sync.Mutex
+	rules []fakeRule
+	calls [][]string
+}
+
+// On answers commands of program ("ffmpeg" or "ffprobe") whose arguments
+// include every one of match with response. Rules added later win, so a test
+// can override a default answer.
+func (r *FakeRunner) On(program string, match []string, response FakeResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, fakeRule{program: program, match: match, response: response})
+}
+
+// Calls returns the command lines run so far, program first
+func (r *FakeRunner) Calls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Run implements FFmpegRunner
+func (r *FakeRunner) Run(cmd *exec.Cmd) error {
+	program := strings.TrimSuffix(filepath.Base(cmd.Args[0]), ".exe")
+	args := cmd.Args[1:]
+
+	r.mu.Lock()
+	r.calls = append(r.calls, slices.Clone(cmd.Args))
+	var response FakeResponse
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		rule := r.rules[i]
+		if rule.program == program && containsAll(args, rule.match) {
+			response = rule.response
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if cmd.Stdout != nil {
+		io.WriteString(cmd.Stdout, response.Stdout)
+	}
+	if cmd.Stderr != nil {
+		io.WriteString(cmd.Stderr, response.Stderr)
+	}
+	if response.Err != nil {
+		return response.Err
+	}
+
+	if program == "ffmpeg" && len(args) > 0 {
+		if output := args[len(args)-1]; output != "-" && filepath.Ext(output) != "" {
+			if err := os.WriteFile(output, response.Output, 0644); err != nil {
+				return fmt.Errorf("fake ffmpeg: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// containsAll returns true if every one of want is among args
+func containsAll(args, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(args, w) {
+			return false
+		}
+	}
+	return true
+}
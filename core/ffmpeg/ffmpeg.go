@@ -0,0 +1,1910 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FFmpeg wraps ffmpeg and ffprobe executables. Handles made with WithCancel
+// share the executables, settings and caches but are cancelled on their own.
+type FFmpeg struct {
+	*shared
+	*cancelScope
+}
+
+// cancelScope is what CancelExport stops: the operations run through one handle
+type cancelScope struct {
+	// cancelled is set by CancelExport until ResetCancel. Atomic, since
+	// parallel workers check it while another goroutine cancels.
+	cancelled atomic.Bool
+	// runningMu guards the ffmpeg processes running now, so CancelExport can
+	// kill all of them (see runner.go)
+	runningMu sync.Mutex
+	running   map[*exec.Cmd]struct{}
+}
+
+// shared is the part of an FFmpeg its WithCancel handles share
+type shared struct {
+	ffmpegPath  string
+	ffprobePath string
+
+	// NVENC health and fallback reporting (see encoder.go).
+	// encoderMu also guards the command recording fields below.
+	encoderMu         sync.Mutex
+	nvencStatus       *EncoderError
+	onFallback        func(*EncoderError)
+	notifiedFallbacks map[EncoderFailureKind]bool
+	preferCPU         bool // GPU encoding turned off in the settings
+	audioCopy         bool // Copy AAC audio into re-encoded clips (see streams.go)
+	audioTrack        int  // Source audio stream clips are cut with (see audiotrack.go)
+
+	// Command recording and dry-run mode (see command.go)
+	dryRun     bool
+	commandLog []string
+	failures   []*Failure         // Recent failed commands (see failure.go)
+	onHistory  func(HistoryEntry) // Called for each command run (see history.go)
+
+	// Thread limit and process priority (see throttle.go)
+	throttleMu  sync.Mutex
+	threads     int
+	lowPriority bool
+
+	// Rough seek window for two-pass seeking (see seek.go)
+	seekMu          sync.Mutex
+	roughSeekWindow float64            // 0 = automatic from keyframe interval
+	seekWindows     map[string]float64 // Probed windows per source file
+
+	// Decoders available in this ffmpeg build (see source.go)
+	decodersOnce sync.Once
+	decoders     map[string]bool
+
+	// Source rotation and per-source overrides (see rotation.go)
+	rotationMu        sync.Mutex
+	orientations      map[string]Orientation // Probed per source file
+	rotationOverrides map[string]int         // Clockwise degrees by source file
+	optionsOnce       sync.Once
+	options           string // "ffmpeg -h long" output
+
+	// HDR handling (see hdr.go)
+	hdrMu       sync.Mutex
+	hdrMode     HDRMode
+	sourceInfos map[string]*StreamInfo // Probed per source file
+	filtersOnce sync.Once
+	filters     map[string]bool // Filters in this ffmpeg build
+
+	// Probe results by file version (see probecache.go)
+	probeMu       sync.Mutex
+	streamInfos   map[probeKey]StreamInfo
+	metadataInfos map[probeKey]VideoMetadataInfo
+	chapterInfos  map[probeKey][]ChapterInfo
+	audioTracks   map[probeKey][]AudioTrack
+
+	// Re-encoded clip quality and custom arguments (see quality.go, preset_args.go)
+	qualityMu   sync.Mutex
+	clipQuality ClipQuality
+	presetArgs  map[string][]string
+
+	// tempDir holds concat lists, chapter files and pass logs ("" = the system
+	// temp folder), switched to each project's work folder (see workdir.go)
+	tempMu  sync.Mutex
+	tempDir string
+
+	// runner runs the commands (nil = for real, see runner.go)
+	runner FFmpegRunner
+}
+
+// New creates a new FFmpeg wrapper, looking for binaries in the bin/ folder
+func New() (*FFmpeg, error) {
+	// Get the executable directory
+	exePath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	exeDir := filepath.Dir(exePath)
+
+	// Try multiple locations for ffmpeg
+	searchPaths := []string{
+		filepath.Join(exeDir, "bin"),       // Next to executable
+		filepath.Join(exeDir, "..", "bin"), // Parent/bin (for development)
+		"bin",                              // Relative to working directory
+	}
+
+	var ffmpegPath, ffprobePath string
+	ffmpegName := "ffmpeg"
+	ffprobeName := "ffprobe"
+	if runtime.GOOS == "windows" {
+		ffmpegName = "ffmpeg.exe"
+		ffprobeName = "ffprobe.exe"
+	}
+
+	for _, searchPath := range searchPaths {
+		candidate := filepath.Join(searchPath, ffmpegName)
+		if _, err := os.Stat(candidate); err == nil {
+			ffmpegPath = candidate
+			ffprobePath = filepath.Join(searchPath, ffprobeName)
+			break
+		}
+	}
+
+	// Fall back to PATH
+	if ffmpegPath == "" {
+		ffmpegPath, _ = exec.LookPath(ffmpegName)
+		ffprobePath, _ = exec.LookPath(ffprobeName)
+	}
+
+	if ffmpegPath == "" {
+		return nil, fmt.Errorf("ffmpeg not found. Please place ffmpeg.exe in the bin/ folder")
+	}
+	if ffprobePath == "" {
+		return nil, fmt.Errorf("ffprobe not found. Please place ffprobe.exe in the bin/ folder")
+	}
+
+	return newFFmpeg(ffmpegPath, ffprobePath), nil
+}
+
+// NewFromPath creates a new FFmpeg wrapper for the ffmpeg executable at
+// ffmpegPath, with ffprobe expected in the same folder
+func NewFromPath(ffmpegPath string) (*FFmpeg, error) {
+	ffprobeName := "ffprobe"
+	if runtime.GOOS == "windows" {
+		ffprobeName = "ffprobe.exe"
+	}
+	ffprobePath := filepath.Join(filepath.Dir(ffmpegPath), ffprobeName)
+
+	if info, err := os.Stat(ffmpegPath); err != nil || info.IsDir() {
+		return nil, fmt.Errorf("ffmpeg not found at %s", ffmpegPath)
+	}
+	if _, err := os.Stat(ffprobePath); err != nil {
+		return nil, fmt.Errorf("ffprobe not found next to ffmpeg (%s)", ffprobePath)
+	}
+
+	return newFFmpeg(ffmpegPath, ffprobePath), nil
+}
+
+// newFFmpeg creates an FFmpeg wrapper for the given executables
+func newFFmpeg(ffmpegPath, ffprobePath string) *FFmpeg {
+	return &FFmpeg{
+		shared:      &shared{ffmpegPath: ffmpegPath, ffprobePath: ffprobePath},
+		cancelScope: &cancelScope{},
+	}
+}
+
+// WithCancel returns a handle to the same ffmpeg whose operations are
+// cancelled on their own: CancelExport on it kills only the processes it
+// started, and cancelling f or another handle doesn't touch them. Give each
+// job one so cancelling it can't stop or un-cancel another.
+func (f *FFmpeg) WithCancel() *FFmpeg {
+	return &FFmpeg{shared: f.shared, cancelScope: &cancelScope{}}
+}
+
+// Path returns the ffmpeg executable in use
+func (f *FFmpeg) Path() string {
+	return f.ffmpegPath
+}
+
+// SetTempDir sets the folder temporary files are written to, so several
+// copies of an app running at once keep theirs apart ("" = the system temp
+// folder). Use a WorkDir's path to keep them with the project.
+func (f *FFmpeg) SetTempDir(dir string) {
+	f.tempMu.Lock()
+	f.tempDir = dir
+	f.tempMu.Unlock()
+}
+
+// TempDir returns the folder temporary files are written to ("" = the system temp folder)
+func (f *FFmpeg) TempDir() string {
+	f.tempMu.Lock()
+	defer f.tempMu.Unlock()
+	return f.tempDir
+}
+
+// CancelExport cancels the operations running through this handle (see
+// WithCancel), killing every ffmpeg process they have running. Operations
+// started later fail until ResetCancel.
+func (f *FFmpeg) CancelExport() error {
+	f.runningMu.Lock()
+	defer f.runningMu.Unlock()
+	f.cancelled.Store(true)
+
+	var errs []error
+	for cmd := range f.running {
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsCancelled returns true if the current operation was cancelled
+func (f *FFmpeg) IsCancelled() bool {
+	return f.cancelled.Load()
+}
+
+// ResetCancel resets the cancel flag for a new operation
+func (f *FFmpeg) ResetCancel() {
+	f.cancelled.Store(false)
+}
+
+// ExtractMetadata extracts chapter metadata from a video file using ffmpeg
+func (f *FFmpeg) ExtractMetadata(inputPath, outputPath string) error {
+	cmd := f.command(
+		"-i", inputPath,
+		"-f", "ffmetadata",
+		"-y", // Overwrite output
+		outputPath,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// GetTimecode extracts the timecode from a GoPro video file
+// Returns the timecode as a string in format "HH:MM:SS:FF"
+func (f *FFmpeg) GetTimecode(goProPath string) (string, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "d:0",
+		"-show_entries", "stream_tags=timecode",
+		"-of", "csv=p=0",
+		goProPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return "", fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	timecode := strings.TrimSpace(stdout.String())
+	if timecode == "" {
+		return "", fmt.Errorf("no timecode found in %s", goProPath)
+	}
+
+	return timecode, nil
+}
+
+// GetDuration returns the duration of a video file in seconds
+func (f *FFmpeg) GetDuration(videoPath string) (float64, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	durationStr := strings.TrimSpace(stdout.String())
+	duration, err := strconv.ParseFloat(durationStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse duration: %w", err)
+	}
+
+	return duration, nil
+}
+
+// ExtractClip extracts a clip from a video file using two-pass seeking for accuracy
+// Uses NVIDIA NVENC hardware encoding if available, falls back to CPU
+// If color is non-nil the clip is color corrected, and if watermark is non-nil
+// the logo is overlaid on it
+func (f *FFmpeg) ExtractClip(inputPath, outputPath string, startSec, durationSec float64, watermark *Watermark, color *ColorCorrection) error {
+	// Two-pass seeking: rough seek to before the previous keyframe, then fine seek
+	roughSeek, fineSeek := f.seekPoints(inputPath, startSec)
+	hasAudio := f.hasAudio(inputPath)
+
+	// Try NVENC first (much faster with NVIDIA GPU)
+	err := f.tryNVENC(func() error {
+		return f.extractClipNVENC(inputPath, outputPath, roughSeek, fineSeek, durationSec, watermark, color, hasAudio)
+	})
+	if err == nil {
+		return nil
+	}
+
+	// Fall back to CPU encoding
+	return f.extractClipCPU(inputPath, outputPath, roughSeek, fineSeek, durationSec, watermark, color, hasAudio)
+}
+
+// extractClipNVENC uses NVIDIA hardware encoding (YouTube-optimized settings)
+func (f *FFmpeg) extractClipNVENC(inputPath, outputPath string, roughSeek, fineSeek, durationSec float64, watermark *Watermark, color *ColorCorrection, hasAudio bool) error {
+	filterInputs, filterMaps := clipFilterArgs(f.sourceFilter(inputPath), watermark, color, 1, f.audioStream("0", inputPath), hasAudio)
+
+	args := append(f.sourceInputArgs(inputPath),
+		"-ss", fmt.Sprintf("%.3f", roughSeek),
+		"-i", inputPath,
+	)
+	args = append(args, filterInputs...)
+	args = append(args,
+		"-ss", fmt.Sprintf("%.3f", fineSeek),
+		"-t", fmt.Sprintf("%.3f", durationSec),
+	)
+	args = append(args, filterMaps...)
+	// H.264 High, constant quality (QP 18 at p4 for ClipHigh), 8-bit yuv420p
+	// for compatibility; see clipEncoderArgs for 10-bit/HDR sources
+	args = append(args, f.clipEncoderArgs(inputPath, true)...)
+	args = append(args, f.sourceAudioArgs(inputPath, outputPath, hasAudio)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("nvenc failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// extractClipCPU uses software encoding (fallback, YouTube-optimized settings)
+func (f *FFmpeg) extractClipCPU(inputPath, outputPath string, roughSeek, fineSeek, durationSec float64, watermark *Watermark, color *ColorCorrection, hasAudio bool) error {
+	filterInputs, filterMaps := clipFilterArgs(f.sourceFilter(inputPath), watermark, color, 1, f.audioStream("0", inputPath), hasAudio)
+
+	args := append(f.sourceInputArgs(inputPath),
+		"-ss", fmt.Sprintf("%.3f", roughSeek),
+		"-i", inputPath,
+	)
+	args = append(args, filterInputs...)
+	args = append(args,
+		"-ss", fmt.Sprintf("%.3f", fineSeek),
+		"-t", fmt.Sprintf("%.3f", durationSec),
+	)
+	args = append(args, filterMaps...)
+	// H.264 High, CRF 18 at the medium preset for ClipHigh, 8-bit yuv420p
+	// for compatibility; see clipEncoderArgs for 10-bit/HDR sources
+	args = append(args, f.clipEncoderArgs(inputPath, false)...)
+	args = append(args, f.sourceAudioArgs(inputPath, outputPath, hasAudio)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg extract failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ExtractClipStreamCopy extracts a clip without re-encoding (fast, keeps original codec)
+func (f *FFmpeg) ExtractClipStreamCopy(inputPath, outputPath string, startSec, durationSec float64) error {
+	rotateInput, rotateOutput := f.streamCopyRotationArgs(inputPath)
+
+	args := append(rotateInput,
+		"-ss", fmt.Sprintf("%.3f", startSec),
+		"-i", inputPath,
+		"-t", fmt.Sprintf("%.3f", durationSec),
+		"-c", "copy", // No re-encoding
+		"-map", "0:v", // Only video
+	)
+	args = append(args, audioMapArgs(f.audioStream("0", inputPath), f.hasAudio(inputPath))...) // and audio, if any
+	args = append(args, f.codecTagArgs(inputPath)...)
+	args = append(args, rotateOutput...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg stream copy failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ClipChapter holds chapter information for embedding in extracted clips
+type ClipChapter struct {
+	OffsetMs int64  // Position in milliseconds from clip start
+	Title    string // Chapter title
+}
+
+// ExtractClipWithChapters extracts a clip with embedded chapter markers
+// Uses two-pass seeking for accuracy and embeds chapter metadata and tags
+// If color is non-nil the clip is color corrected, and if watermark is non-nil
+// the logo is overlaid on it
+func (f *FFmpeg) ExtractClipWithChapters(inputPath, outputPath string, startSec, durationSec float64, chapters []ClipChapter, tags Tags, watermark *Watermark, color *ColorCorrection) error {
+	// Two-pass seeking: rough seek to before the previous keyframe, then fine seek
+	roughSeek, fineSeek := f.seekPoints(inputPath, startSec)
+
+	// Create metadata file with chapters
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-clip-meta-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer os.Remove(metaFile.Name())
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	tags.writeTo(metaFile, "")
+	fmt.Fprintf(metaFile, "\n")
+
+	durationMs := int64(durationSec * 1000)
+	for i, ch := range chapters {
+		// Calculate end time (next chapter start or clip end)
+		var endMs int64
+		if i < len(chapters)-1 {
+			endMs = chapters[i+1].OffsetMs
+		} else {
+			endMs = durationMs
+		}
+
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.OffsetMs)
+		fmt.Fprintf(metaFile, "END=%d\n", endMs)
+		fmt.Fprintf(metaFile, "title=%s\n\n", escapeMetadata(ch.Title))
+	}
+	metaFile.Close()
+	hasAudio := f.hasAudio(inputPath)
+
+	// Try NVENC first, fall back to CPU
+	err = f.tryNVENC(func() error {
+		return f.extractClipWithChaptersNVENC(inputPath, metaFile.Name(), outputPath, roughSeek, fineSeek, durationSec, watermark, color, hasAudio)
+	})
+	if err == nil {
+		return nil
+	}
+
+	return f.extractClipWithChaptersCPU(inputPath, metaFile.Name(), outputPath, roughSeek, fineSeek, durationSec, watermark, color, hasAudio)
+}
+
+func (f *FFmpeg) extractClipWithChaptersNVENC(inputPath, metaFile, outputPath string, roughSeek, fineSeek, durationSec float64, watermark *Watermark, color *ColorCorrection, hasAudio bool) error {
+	// Logo (if any) is input 2, after the video and metadata file
+	filterInputs, filterMaps := clipFilterArgs(f.sourceFilter(inputPath), watermark, color, 2, f.audioStream("0", inputPath), hasAudio)
+	if filterMaps == nil {
+		filterMaps = append([]string{"-map", "0:v"}, audioMapArgs(f.audioStream("0", inputPath), hasAudio)...)
+	}
+
+	args := append(f.sourceInputArgs(inputPath),
+		"-ss", fmt.Sprintf("%.3f", roughSeek),
+		"-i", inputPath,
+		"-i", metaFile,
+	)
+	args = append(args, filterInputs...)
+	args = append(args,
+		"-ss", fmt.Sprintf("%.3f", fineSeek),
+		"-t", fmt.Sprintf("%.3f", durationSec),
+	)
+	args = append(args, filterMaps...)
+	args = append(args,
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+	)
+	args = append(args, f.clipEncoderArgs(inputPath, true)...)
+	args = append(args, f.sourceAudioArgs(inputPath, outputPath, hasAudio)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("nvenc failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+func (f *FFmpeg) extractClipWithChaptersCPU(inputPath, metaFile, outputPath string, roughSeek, fineSeek, durationSec float64, watermark *Watermark, color *ColorCorrection, hasAudio bool) error {
+	// Logo (if any) is input 2, after the video and metadata file
+	filterInputs, filterMaps := clipFilterArgs(f.sourceFilter(inputPath), watermark, color, 2, f.audioStream("0", inputPath), hasAudio)
+	if filterMaps == nil {
+		filterMaps = append([]string{"-map", "0:v"}, audioMapArgs(f.audioStream("0", inputPath), hasAudio)...)
+	}
+
+	args := append(f.sourceInputArgs(inputPath),
+		"-ss", fmt.Sprintf("%.3f", roughSeek),
+		"-i", inputPath,
+		"-i", metaFile,
+	)
+	args = append(args, filterInputs...)
+	args = append(args,
+		"-ss", fmt.Sprintf("%.3f", fineSeek),
+		"-t", fmt.Sprintf("%.3f", durationSec),
+	)
+	args = append(args, filterMaps...)
+	args = append(args,
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+	)
+	args = append(args, f.clipEncoderArgs(inputPath, false)...)
+	args = append(args, f.sourceAudioArgs(inputPath, outputPath, hasAudio)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("cpu extract failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ExtractClipStreamCopyWithChapters extracts a clip without re-encoding but with chapter markers and tags
+func (f *FFmpeg) ExtractClipStreamCopyWithChapters(inputPath, outputPath string, startSec, durationSec float64, chapters []ClipChapter, tags Tags) error {
+	// Create metadata file with chapters
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-clip-meta-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer os.Remove(metaFile.Name())
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	tags.writeTo(metaFile, "")
+	fmt.Fprintf(metaFile, "\n")
+
+	durationMs := int64(durationSec * 1000)
+	for i, ch := range chapters {
+		var endMs int64
+		if i < len(chapters)-1 {
+			endMs = chapters[i+1].OffsetMs
+		} else {
+			endMs = durationMs
+		}
+
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.OffsetMs)
+		fmt.Fprintf(metaFile, "END=%d\n", endMs)
+		fmt.Fprintf(metaFile, "title=%s\n\n", escapeMetadata(ch.Title))
+	}
+	metaFile.Close()
+
+	rotateInput, rotateOutput := f.streamCopyRotationArgs(inputPath)
+
+	args := append(rotateInput,
+		"-ss", fmt.Sprintf("%.3f", startSec),
+		"-i", inputPath,
+		"-i", metaFile.Name(),
+		"-t", fmt.Sprintf("%.3f", durationSec),
+		"-map", "0:v",
+	)
+	args = append(args, audioMapArgs(f.audioStream("0", inputPath), f.hasAudio(inputPath))...)
+	args = append(args,
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c", "copy",
+	)
+	args = append(args, f.codecTagArgs(inputPath)...)
+	args = append(args, rotateOutput...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg stream copy with chapters failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ConcatClips concatenates multiple clips into a single output file
+// Preserves and merges chapter markers from all input clips, and writes tags.
+// Clips in mixed containers (stream copied .mov and re-encoded .mp4) are
+// remuxed to MP4 first, since nothing is re-encoded.
+func (f *FFmpeg) ConcatClips(inputPaths []string, outputPath string, tags Tags) error {
+	return f.ConcatClipsWithTitles(inputPaths, outputPath, tags, nil)
+}
+
+// ConcatClipsWithTitles is ConcatClips with the clips' chapters renamed by
+// titles (nil = keep them)
+func (f *FFmpeg) ConcatClipsWithTitles(inputPaths []string, outputPath string, tags Tags, titles ChapterTitles) error {
+	// Ensure output has .mp4 extension
+	if !strings.HasSuffix(strings.ToLower(outputPath), ".mp4") {
+		outputPath = outputPath + ".mp4"
+	}
+
+	// Step 1: Get durations and chapters from each input clip
+	var durations []float64
+	var allChapters []ChapterInfo
+	var totalDuration float64
+
+	for i, inputPath := range inputPaths {
+		// Get duration
+		dur, err := f.GetDuration(inputPath)
+		if err != nil {
+			// If we can't get duration, fall back to simple concat without chapters
+			return f.concatClipsSimple(inputPaths, outputPath)
+		}
+		durations = append(durations, dur)
+
+		// Get chapters from this clip
+		chapters, _ := f.GetChapters(inputPath)
+		chapters = titles.apply(inputPath, chapters)
+
+		// Calculate time offset for this file
+		var offset float64
+		for j := 0; j < i; j++ {
+			offset += durations[j]
+		}
+
+		// If clip has no chapters, add one at the start to mark the clip boundary
+		if len(chapters) == 0 {
+			// Use filename as chapter title
+			baseName := filepath.Base(inputPath)
+			// Remove extension for cleaner title
+			title := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+			allChapters = append(allChapters, ChapterInfo{
+				StartMs: int64(offset * 1000),
+				EndMs:   int64((offset + dur) * 1000),
+				Title:   title,
+			})
+		} else {
+			// Add existing chapters with offset
+			for _, ch := range chapters {
+				allChapters = append(allChapters, ChapterInfo{
+					StartMs: ch.StartMs + int64(offset*1000),
+					EndMs:   ch.EndMs + int64(offset*1000),
+					Title:   ch.Title,
+				})
+			}
+		}
+
+		totalDuration += dur
+	}
+
+	// Step 2: Create concat file list, with MOV clips remuxed if mixed with MP4s
+	concatPaths, cleanup, err := f.concatInputs(inputPaths)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	concatList, err := f.writeConcatList(concatFiles(concatPaths))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(concatList)
+
+	// Step 3: Create metadata file with merged chapters
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-meta-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer os.Remove(metaFile.Name())
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	tags.writeTo(metaFile, "Combined Clips")
+	fmt.Fprintf(metaFile, "\n")
+
+	for _, ch := range allChapters {
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.StartMs)
+		fmt.Fprintf(metaFile, "END=%d\n", ch.EndMs)
+		if ch.Title != "" {
+			fmt.Fprintf(metaFile, "title=%s\n", escapeMetadata(ch.Title))
+		}
+		fmt.Fprintf(metaFile, "\n")
+	}
+	metaFile.Close()
+
+	// Step 4: Run ffmpeg with merged metadata
+	// Note: DNxHR MOV files from Shutter Encoder have unknown metadata streams (stream 3+)
+	// -err_detect ignore_err tells ffmpeg to continue despite probe warnings
+	// We use explicit stream mapping to only copy video and audio streams
+	args := []string{
+		"-err_detect", "ignore_err",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", concatList,
+		"-i", metaFile.Name(),
+		"-map", "0:v:0", // First video stream only
+		"-map", "0:a:0", // First audio stream only
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c", "copy",
+	}
+	args = append(args, f.codecTagArgs(inputPaths[0])...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg concat failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// concatClipsSimple is a fallback that concatenates without chapter preservation
+func (f *FFmpeg) concatClipsSimple(inputPaths []string, outputPath string) error {
+	inputPaths, cleanup, err := f.concatInputs(inputPaths)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	concatList, err := f.writeConcatList(concatFiles(inputPaths))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(concatList)
+
+	args := []string{
+		"-err_detect", "ignore_err",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", concatList,
+		"-map", "0:v:0",
+		"-map", "0:a:0",
+		"-c", "copy",
+	}
+	args = append(args, f.codecTagArgs(inputPaths[0])...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg concat failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ConcatClipsWithEncode combines clips with re-encoding for smaller file size
+// Preserves and merges chapter markers from all input clips
+// If forceCPU is true, uses libx264 instead of NVENC
+// If targetSizeMB > 0, crf is ignored and the bitrate is chosen so the output fits that size
+// All clips are scaled/retimed to the conform resolution and frame rate, and any
+// overlays in opts (e.g. watermark) are applied
+func (f *FFmpeg) ConcatClipsWithEncode(inputPaths []string, outputPath string, crf string, forceCPU bool, targetSizeMB float64, opts ReelOptions) error {
+	_, err := f.concatClipsWithEncode(inputPaths, outputPath, crf, forceCPU, targetSizeMB, opts)
+	return err
+}
+
+// concatClipsWithEncode is ConcatClipsWithEncode, also returning whether the
+// CPU encoder wrote the output (forced, or as the fallback from NVENC)
+func (f *FFmpeg) concatClipsWithEncode(inputPaths []string, outputPath string, crf string, forceCPU bool, targetSizeMB float64, opts ReelOptions) (bool, error) {
+	// Ensure output has .mp4 extension
+	if !strings.HasSuffix(strings.ToLower(outputPath), ".mp4") {
+		outputPath = outputPath + ".mp4"
+	}
+
+	// Step 1: Get durations and chapters from each input clip
+	var durations []float64
+	var allChapters []ChapterInfo
+
+	for _, inputPath := range inputPaths {
+		dur, err := f.GetDuration(inputPath)
+		if err != nil {
+			dur = 0 // Continue without duration
+		}
+		durations = append(durations, dur)
+	}
+
+	// Clip start times in the reel (crossfaded clips overlap the one before)
+	offsets, totalDuration := opts.Transition.reelOffsets(durations)
+	opts.durations = durations
+	if opts.Transition.effective(durations).Style == TransitionCrossfade && opts.Conform.FrameRate == "" {
+		// xfade needs every clip at the same frame rate: use the first clip's
+		if info, err := f.GetStreamInfo(inputPaths[0]); err == nil && info.FrameRate != "" && info.FrameRate != "0/0" {
+			opts.Conform.FrameRate = info.FrameRate
+		}
+	}
+
+	for i, inputPath := range inputPaths {
+		dur := durations[i]
+		offset := offsets[i]
+
+		// Get chapters from this clip
+		chapters, _ := f.GetChapters(inputPath)
+		chapters = opts.ChapterTitles.apply(inputPath, chapters)
+
+		// If clip has no chapters, add one at the start to mark the clip boundary
+		if len(chapters) == 0 {
+			title := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+			allChapters = append(allChapters, ChapterInfo{
+				StartMs: int64(offset * 1000),
+				EndMs:   int64((offset + dur) * 1000),
+				Title:   title,
+			})
+		} else {
+			// Add existing chapters with offset
+			for _, ch := range chapters {
+				allChapters = append(allChapters, ChapterInfo{
+					StartMs: ch.StartMs + int64(offset*1000),
+					EndMs:   ch.EndMs + int64(offset*1000),
+					Title:   ch.Title,
+				})
+			}
+		}
+	}
+
+	// Step 2: Create metadata file with merged chapters
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-meta-*.txt")
+	if err != nil {
+		return forceCPU, fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer os.Remove(metaFile.Name())
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	opts.Tags.writeTo(metaFile, "Combined Clips")
+	fmt.Fprintf(metaFile, "\n")
+
+	for _, ch := range allChapters {
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.StartMs)
+		fmt.Fprintf(metaFile, "END=%d\n", ch.EndMs)
+		if ch.Title != "" {
+			fmt.Fprintf(metaFile, "title=%s\n", escapeMetadata(ch.Title))
+		}
+		fmt.Fprintf(metaFile, "\n")
+	}
+	metaFile.Close()
+
+	// Reels are 8-bit H.264, so HDR clips (e.g. kept as HDR HEVC) are tone-mapped
+	opts = f.withToneMap(inputPaths, opts)
+
+	// Step 3: Run ffmpeg with re-encoding using filter_complex concat
+	// This avoids issues with unknown streams in DNxHR MOV files
+	if targetSizeMB > 0 {
+		return forceCPU, f.encodeTargetSize(inputPaths, metaFile.Name(), outputPath, totalDuration, targetSizeMB, forceCPU, opts, func(float64, string) {})
+	}
+
+	if forceCPU {
+		return true, f.concatClipsEncodeCPU(inputPaths, metaFile.Name(), outputPath, crf, opts)
+	}
+
+	// Try NVENC first, fall back to CPU
+	err = f.tryNVENC(func() error {
+		return f.concatClipsEncodeNVENC(inputPaths, metaFile.Name(), outputPath, crf, opts)
+	})
+	if err != nil && !f.IsCancelled() {
+		return true, f.concatClipsEncodeCPU(inputPaths, metaFile.Name(), outputPath, crf, opts)
+	}
+	return false, err
+}
+
+func (f *FFmpeg) concatClipsEncodeNVENC(inputPaths []string, metaFile, outputPath, crf string, opts ReelOptions) error {
+	qp := crf
+
+	// Build ffmpeg command using filter_complex concat instead of concat demuxer
+	// This avoids issues with unknown streams in DNxHR MOV files
+	args := []string{}
+
+	// Add each input file
+	for _, path := range inputPaths {
+		args = append(args, "-i", path)
+	}
+
+	// Add metadata file, then any extra inputs (watermark)
+	args = append(args, "-i", metaFile)
+	args = append(args, opts.extraInputs()...)
+
+	// Build filter_complex string: scale/retime each video to the conform format, then concat
+	// This handles clips with different resolutions and frame rates (e.g. 4K60 and 2.7K120 camera modes)
+	// Example: [0:v]scale=1920:1080:...[v0];[1:v]scale=1920:1080:...[v1];[v0][0:a][v1][1:a]concat=n=2:v=1:a=1[outv][outa]
+	filterStr := buildReelFilter(inputPaths, opts, len(inputPaths)+1)
+
+	args = append(args,
+		"-filter_complex", filterStr,
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)), // metadata file is last input
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+		"-c:v", "h264_nvenc",
+		"-preset", "p4",
+		"-profile:v", "high",
+		"-rc", "constqp",
+		"-qp", qp,
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+	)
+	args = append(args, f.PresetArgs(PresetReel)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("combine cancelled")
+		}
+		return fmt.Errorf("nvenc encode failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+func (f *FFmpeg) concatClipsEncodeCPU(inputPaths []string, metaFile, outputPath, crf string, opts ReelOptions) error {
+	// Build ffmpeg command using filter_complex concat instead of concat demuxer
+	// This avoids issues with unknown streams in DNxHR MOV files
+	args := []string{}
+
+	// Add each input file
+	for _, path := range inputPaths {
+		args = append(args, "-i", path)
+	}
+
+	// Add metadata file, then any extra inputs (watermark)
+	args = append(args, "-i", metaFile)
+	args = append(args, opts.extraInputs()...)
+
+	// Build filter_complex string: scale/retime each video to the conform format, then concat
+	filterStr := buildReelFilter(inputPaths, opts, len(inputPaths)+1)
+
+	args = append(args,
+		"-filter_complex", filterStr,
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)), // metadata file is last input
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+		"-c:v", "libx264",
+		"-preset", "medium",
+		"-profile:v", "high",
+		"-crf", crf,
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+	)
+	args = append(args, f.PresetArgs(PresetReel)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("combine cancelled")
+		}
+		return fmt.Errorf("cpu encode failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// VideoMetadataInfo holds metadata information about a video file
+type VideoMetadataInfo struct {
+	HasTimecode  bool
+	Timecode     string
+	ChapterCount int
+	HasChapters  bool
+	CreationTime string
+	Duration     float64
+}
+
+// CheckVideoMetadata checks if a video file has preserved metadata (timecode,
+// chapters). Results are cached until the file changes (see probecache.go).
+func (f *FFmpeg) CheckVideoMetadata(videoPath string) (*VideoMetadataInfo, error) {
+	key, ok := fileProbeKey(videoPath)
+	if ok {
+		if info, cached := f.cachedMetadataInfo(key); cached {
+			return info, nil
+		}
+	}
+	info := &VideoMetadataInfo{}
+	defer func() {
+		if ok {
+			f.cacheMetadataInfo(key, info)
+		}
+	}()
+
+	// Get timecode from video stream tags
+	timecode, err := f.GetTimecodeFromVideo(videoPath)
+	if err == nil && timecode != "" {
+		info.HasTimecode = true
+		info.Timecode = timecode
+	}
+
+	// Get chapter count
+	chapters, err := f.GetChapterCount(videoPath)
+	if err == nil && chapters > 0 {
+		info.HasChapters = true
+		info.ChapterCount = chapters
+	}
+
+	// Get duration
+	duration, err := f.GetDuration(videoPath)
+	if err == nil {
+		info.Duration = duration
+	}
+
+	return info, nil
+}
+
+// GetTimecodeFromVideo extracts timecode from video stream tags (works with converted MOV files)
+func (f *FFmpeg) GetTimecodeFromVideo(videoPath string) (string, error) {
+	// First try video stream tags
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream_tags=timecode",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err == nil {
+		timecode := strings.TrimSpace(stdout.String())
+		if timecode != "" {
+			return timecode, nil
+		}
+	}
+
+	// Fall back to format tags (some files store it there)
+	cmd = exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format_tags=timecode",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	stdout.Reset()
+	stderr.Reset()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err == nil {
+		timecode := strings.TrimSpace(stdout.String())
+		if timecode != "" {
+			return timecode, nil
+		}
+	}
+
+	// Fall back to data stream (original GoPro files)
+	return f.GetTimecode(videoPath)
+}
+
+// GetTimecodeRate returns the frame rate a video's timecode counts frames at
+// (e.g. 29.97 or 119.88) and whether it is drop-frame. The rate is the tmcd
+// track's, or the video stream's when the track doesn't report one or the
+// timecode is only a tag (as in converted MOVs). Drop-frame timecodes are
+// written with a ';' before the frames (HH:MM:SS;FF).
+func (f *FFmpeg) GetTimecodeRate(videoPath string) (fps float64, dropFrame bool, err error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_tag_string,r_frame_rate,avg_frame_rate:stream_tags=timecode",
+		"-of", "compact=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return 0, false, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	var tmcdRate, videoRate float64
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := make(map[string]string)
+		for _, pair := range strings.Split(strings.TrimSpace(line), "|") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				fields[k] = v
+			}
+		}
+		if strings.Contains(fields["tag:timecode"], ";") {
+			dropFrame = true
+		}
+
+		// Timecode tracks without a rate report 0/0 or a placeholder like 1/0
+		rate := frameRateValue(fields["r_frame_rate"])
+		if rate < 10 || rate > 480 {
+			rate = frameRateValue(fields["avg_frame_rate"])
+		}
+		if rate < 10 || rate > 480 {
+			rate = 0
+		}
+		switch {
+		case fields["codec_tag_string"] == "tmcd" && tmcdRate == 0:
+			tmcdRate = rate
+		case fields["codec_type"] == "video" && videoRate == 0:
+			videoRate = rate
+		}
+	}
+
+	fps = tmcdRate
+	if fps <= 0 {
+		fps = videoRate
+	}
+	if fps <= 0 {
+		return 0, false, fmt.Errorf("no frame rate found in %s", videoPath)
+	}
+	return fps, dropFrame, nil
+}
+
+// GetCreationTime returns the creation time the camera recorded in a video's
+// container tags
+func (f *FFmpeg) GetCreationTime(videoPath string) (time.Time, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format_tags=creation_time",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return time.Time{}, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	value := strings.TrimSpace(stdout.String())
+	if value == "" {
+		return time.Time{}, fmt.Errorf("no creation time in %s", videoPath)
+	}
+	created, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid creation time %q: %w", value, err)
+	}
+	return created, nil
+}
+
+// GetChapterCount returns the number of chapters in a video file
+func (f *FFmpeg) GetChapterCount(videoPath string) (int, error) {
+	chapters, err := f.GetChapters(videoPath)
+	if err != nil {
+		return 0, err
+	}
+	return len(chapters), nil
+}
+
+// ParseTimecode parses a timecode string "HH:MM:SS:FF" into total seconds
+// Assumes ~60fps (GoPro standard)
+func ParseTimecode(timecode string) (float64, error) {
+	// Match HH:MM:SS:FF or HH:MM:SS;FF
+	re := regexp.MustCompile(`(\d{2}):(\d{2}):(\d{2})[:;](\d{2})`)
+	matches := re.FindStringSubmatch(timecode)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid timecode format: %s", timecode)
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+	frames, _ := strconv.Atoi(matches[4])
+
+	// GoPro typically uses ~60fps
+	const fps = 60.0
+	totalSeconds := float64(hours*3600+minutes*60+seconds) + float64(frames)/fps
+
+	return totalSeconds, nil
+}
+
+// ChapterInfo holds chapter timing information
+type ChapterInfo struct {
+	StartMs int64
+	EndMs   int64
+	Title   string
+}
+
+// ChapterTitles replaces the chapter titles of reel clips, by clip path: the
+// n'th title renames the clip's n'th chapter ("" = keep it). This lets titles
+// renamed after a clip was extracted reach the reel.
+type ChapterTitles map[string][]string
+
+// apply returns a clip's chapters with any replacement titles
+func (t ChapterTitles) apply(path string, chapters []ChapterInfo) []ChapterInfo {
+	titles := t[path]
+	for i := range chapters {
+		if i < len(titles) && titles[i] != "" {
+			chapters[i].Title = titles[i]
+		}
+	}
+	return chapters
+}
+
+// probedChapters is the output of ffprobe -show_chapters -of json
+type probedChapters struct {
+	Chapters []struct {
+		TimeBase  string `json:"time_base"` // e.g. "1/1000"
+		Start     int64  `json:"start"`     // In time_base units
+		End       int64  `json:"end"`
+		StartTime string `json:"start_time"` // Seconds, used if time_base can't be read
+		EndTime   string `json:"end_time"`
+		Tags      struct {
+			Title string `json:"title"`
+		} `json:"tags"`
+	} `json:"chapters"`
+}
+
+// GetChapters extracts chapter information from a video file, reading
+// ffprobe's JSON output directly (no metadata file is written). Results are
+// cached until the file changes.
+func (f *FFmpeg) GetChapters(videoPath string) ([]ChapterInfo, error) {
+	key, cacheable := fileProbeKey(videoPath)
+	if cacheable {
+		if chapters, ok := f.cachedChapters(key); ok {
+			return chapters, nil
+		}
+	}
+
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_chapters",
+		"-of", "json",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	var probed probedChapters
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 {
+		if err := json.Unmarshal(out, &probed); err != nil {
+			return nil, fmt.Errorf("failed to read chapters of %s: %w", filepath.Base(videoPath), err)
+		}
+	}
+
+	var chapters []ChapterInfo
+	for _, ch := range probed.Chapters {
+		// Exact milliseconds from the chapter's time base (GoPro uses 1/1000,
+		// Shutter Encoder MOVs 1/10000000)
+		var num, den int64
+		if _, err := fmt.Sscanf(ch.TimeBase, "%d/%d", &num, &den); err == nil && num > 0 && den > 0 {
+			chapters = append(chapters, ChapterInfo{
+				StartMs: ch.Start * num * 1000 / den,
+				EndMs:   ch.End * num * 1000 / den,
+				Title:   ch.Tags.Title,
+			})
+			continue
+		}
+		startTime, _ := strconv.ParseFloat(ch.StartTime, 64)
+		endTime, _ := strconv.ParseFloat(ch.EndTime, 64)
+		chapters = append(chapters, ChapterInfo{
+			StartMs: int64(startTime * 1000),
+			EndMs:   int64(endTime * 1000),
+			Title:   ch.Tags.Title,
+		})
+	}
+
+	if cacheable {
+		f.cacheChapters(key, chapters)
+	}
+	return chapters, nil
+}
+
+// VideoResolution holds video dimensions
+type VideoResolution struct {
+	Width  int
+	Height int
+}
+
+// GetResolution returns the resolution of a video file
+func (f *FFmpeg) GetResolution(videoPath string) (*VideoResolution, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	output := strings.TrimSpace(stdout.String())
+	parts := strings.Split(output, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("unexpected resolution format: %s", output)
+	}
+
+	width, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse width: %w", err)
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse height: %w", err)
+	}
+
+	return &VideoResolution{Width: width, Height: height}, nil
+}
+
+// StreamInfo holds the codec parameters of a file's first video and audio streams
+type StreamInfo struct {
+	VideoCodec string // e.g. "h264", "hevc", "dnxhd"
+	Width      int
+	Height     int
+	FrameRate  string // As reported by ffprobe, e.g. "60000/1001"
+	PixFmt     string // e.g. "yuv420p"
+	AudioCodec string // e.g. "aac", "pcm_s16le" (empty if no audio)
+	SampleRate int
+	Channels   int
+
+	// Color description as reported by ffprobe (empty or "unknown" if untagged)
+	ColorPrimaries string // e.g. "bt709", "bt2020"
+	ColorTransfer  string // e.g. "bt709", "arib-std-b67" (HLG), "smpte2084" (PQ)
+	ColorSpace     string // e.g. "bt709", "bt2020nc"
+	ColorRange     string // "tv" or "pc"
+}
+
+// FPS returns the video frame rate as frames per second (0 if unknown)
+func (s *StreamInfo) FPS() float64 {
+	return frameRateValue(s.FrameRate)
+}
+
+// GetStreamInfo probes the first video and audio stream of a file. Results
+// are cached until the file changes (see probecache.go).
+func (f *FFmpeg) GetStreamInfo(videoPath string) (*StreamInfo, error) {
+	key, ok := fileProbeKey(videoPath)
+	if ok {
+		if info, cached := f.cachedStreamInfo(key); cached {
+			return info, nil
+		}
+	}
+	info, err := f.probeStreamInfo(videoPath)
+	if err == nil && ok {
+		f.cacheStreamInfo(key, info)
+	}
+	return info, err
+}
+
+// probeStreamInfo runs ffprobe for GetStreamInfo
+func (f *FFmpeg) probeStreamInfo(videoPath string) (*StreamInfo, error) {
+	// compact format prints one "key=value|key=value" line per stream,
+	// so fields can be read by name regardless of ffprobe's output order
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_name,width,height,r_frame_rate,pix_fmt,color_primaries,color_transfer,color_space,color_range,sample_rate,channels",
+		"-of", "compact=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	info := &StreamInfo{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := make(map[string]string)
+		for _, pair := range strings.Split(strings.TrimSpace(line), "|") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				fields[k] = v
+			}
+		}
+
+		switch fields["codec_type"] {
+		case "video":
+			if info.VideoCodec != "" {
+				continue // Only the first video stream
+			}
+			info.VideoCodec = fields["codec_name"]
+			info.Width, _ = strconv.Atoi(fields["width"])
+			info.Height, _ = strconv.Atoi(fields["height"])
+			info.FrameRate = fields["r_frame_rate"]
+			info.PixFmt = fields["pix_fmt"]
+			info.ColorPrimaries = fields["color_primaries"]
+			info.ColorTransfer = fields["color_transfer"]
+			info.ColorSpace = fields["color_space"]
+			info.ColorRange = fields["color_range"]
+		case "audio":
+			if info.AudioCodec != "" {
+				continue // Only the first audio stream
+			}
+			info.AudioCodec = fields["codec_name"]
+			info.SampleRate, _ = strconv.Atoi(fields["sample_rate"])
+			info.Channels, _ = strconv.Atoi(fields["channels"])
+		}
+	}
+
+	if info.VideoCodec == "" {
+		return nil, fmt.Errorf("no video stream found in %s", videoPath)
+	}
+
+	return info, nil
+}
+
+// CombineSplitGoPro combines split GoPro files into a single file
+// Uses stream copy if all files have matching dimensions, otherwise re-encodes
+// Preserves and merges chapter markers (HiLights) from all input files, offset
+// by the durations of the files before them, and keeps the first file's timecode
+func (f *FFmpeg) CombineSplitGoPro(inputPaths []string, outputPath string) error {
+	if len(inputPaths) < 2 {
+		return fmt.Errorf("need at least 2 files to combine")
+	}
+
+	// Step 1: Check if all files have the same resolution
+	var resolutions []*VideoResolution
+	needsReencode := false
+	var targetWidth, targetHeight int
+
+	for _, inputPath := range inputPaths {
+		res, err := f.GetResolution(inputPath)
+		if err != nil {
+			// If we can't get resolution, assume we need re-encoding
+			needsReencode = true
+			break
+		}
+		resolutions = append(resolutions, res)
+	}
+
+	if !needsReencode && len(resolutions) > 0 {
+		targetWidth = resolutions[0].Width
+		targetHeight = resolutions[0].Height
+		for _, res := range resolutions[1:] {
+			if res.Width != targetWidth || res.Height != targetHeight {
+				needsReencode = true
+				// Use the largest resolution as target
+				if res.Width*res.Height > targetWidth*targetHeight {
+					targetWidth = res.Width
+					targetHeight = res.Height
+				}
+				break
+			}
+		}
+	}
+
+	// Step 2: Place each file in the combined recording and merge their chapters (HiLights)
+	segments, err := f.probeSplitSegments(inputPaths)
+	if err != nil {
+		return err
+	}
+
+	var perSegment [][]ChapterInfo
+	for _, inputPath := range inputPaths {
+		chapters, _ := f.GetChapters(inputPath)
+		perSegment = append(perSegment, chapters)
+	}
+	allChapters := mergeSegmentChapters(segments, perSegment)
+
+	// Step 3: Create metadata file with merged chapters
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-meta-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer os.Remove(metaFile.Name())
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	fmt.Fprintf(metaFile, "\n")
+
+	for _, ch := range allChapters {
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.StartMs)
+		fmt.Fprintf(metaFile, "END=%d\n", ch.EndMs)
+		if ch.Title != "" {
+			fmt.Fprintf(metaFile, "title=%s\n", escapeMetadata(ch.Title))
+		}
+		fmt.Fprintf(metaFile, "\n")
+	}
+	metaFile.Close()
+
+	if needsReencode {
+		// Use filter_complex with scale to normalize resolutions
+		return f.combineSplitGoProReencode(inputPaths, metaFile.Name(), outputPath, targetWidth, targetHeight, timecodeArgs(segments))
+	}
+
+	// Step 4: Stream copy (fast path for matching dimensions)
+	concatList, err := f.writeConcatList(concatFiles(inputPaths))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(concatList)
+
+	// The concat demuxer can drop the rotation flag, so set it from the first part
+	rotateInput, rotateOutput := f.streamCopyRotationArgs(inputPaths[0])
+
+	args := append(rotateInput,
+		"-f", "concat",
+		"-safe", "0",
+		"-i", concatList,
+		"-i", metaFile.Name(),
+		"-map", "0:v",
+		"-map", "0:a", // Every audio track, so one can still be chosen for the clips
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c", "copy", // No re-encoding
+	)
+	args = append(args, f.codecTagArgs(inputPaths[0])...)
+	args = append(args, rotateOutput...)
+	args = append(args, timecodeArgs(segments)...) // The combined file keeps the first part's timecode
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg combine failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// combineSplitGoProReencode combines files with different resolutions using re-encoding
+// outputArgs are added before the output file (e.g. the timecode)
+func (f *FFmpeg) combineSplitGoProReencode(inputPaths []string, metaFile, outputPath string, targetWidth, targetHeight int, outputArgs []string) error {
+	// Build ffmpeg command with filter_complex to scale and concat
+	args := []string{}
+
+	// Add each input file
+	for _, path := range inputPaths {
+		args = append(args, "-i", path)
+	}
+
+	// Add metadata file as last input
+	args = append(args, "-i", metaFile)
+
+	// Build filter_complex: scale each input to target resolution, then concat
+	// Example: [0:v]scale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2[v0];...
+	filterStr := ""
+	for i := range inputPaths {
+		filterStr += fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1[v%d];",
+			i, targetWidth, targetHeight, targetWidth, targetHeight, i)
+	}
+	// Add the selected audio track of each file
+	for i, path := range inputPaths {
+		filterStr += fmt.Sprintf("[v%d][%s]", i, f.audioStream(strconv.Itoa(i), path))
+	}
+	filterStr += fmt.Sprintf("concat=n=%d:v=1:a=1[outv][outa]", len(inputPaths))
+
+	args = append(args,
+		"-filter_complex", filterStr,
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)),
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+	)
+	args = append(args, outputArgs...)
+
+	// Keep the source codec (HEVC stays HEVC) rather than converting the recording
+	ref, _ := f.GetStreamInfo(inputPaths[0])
+
+	// Try NVENC first, fall back to CPU
+	nvencArgs := append(append([]string{}, args...), sourceVideoArgs(ref, true)...)
+	nvencArgs = append(nvencArgs,
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", "192k",
+		"-y",
+		outputPath,
+	)
+
+	var stderr bytes.Buffer
+	err := f.tryNVENC(func() error {
+		cmd := f.command(nvencArgs...)
+		stderr.Reset()
+		cmd.Stderr = &stderr
+		if err := f.run(cmd); err != nil {
+			return fmt.Errorf("nvenc failed: %s", stderr.String())
+		}
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	// Fall back to CPU encoding
+	cpuArgs := append(append([]string{}, args...), sourceVideoArgs(ref, false)...)
+	cpuArgs = append(cpuArgs,
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", "192k",
+		"-y",
+		outputPath,
+	)
+
+	cmd := f.command(cpuArgs...)
+	stderr.Reset()
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg combine with re-encode failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ExportFullGame combines multiple MOV files into a single YouTube-ready video
+// with re-encoding and merged chapter markers
+// If forceCPU is true, uses libx264 instead of NVENC for better compression efficiency
+// If targetSizeMB > 0, crf is ignored and the bitrate is chosen so the output fits that size
+func (f *FFmpeg) ExportFullGame(inputPaths []string, outputPath string, crf string, forceCPU bool, targetSizeMB float64, progress func(float64, string)) error {
+	return f.ExportFullGameTrimmed(inputPaths, nil, outputPath, crf, forceCPU, targetSizeMB, progress)
+}
+
+// ExportFullGameTrimmed is ExportFullGame keeping only part of some inputs:
+// trims maps input path -> the part of it kept (missing = the whole file);
+// chapters outside the kept part are dropped
+func (f *FFmpeg) ExportFullGameTrimmed(inputPaths []string, trims map[string]Trim, outputPath string, crf string, forceCPU bool, targetSizeMB float64, progress func(float64, string)) error {
+	if len(inputPaths) == 0 {
+		return fmt.Errorf("no input files")
+	}
+
+	// Step 1: Get durations and chapters from each file
+	progress(0.05, "Analyzing source files...")
+
+	var durations []float64
+	var allChapters []ChapterInfo
+	var totalDuration float64
+	periodNames := []string{"1st Period", "2nd Period", "3rd Period", "OT"}
+
+	for i, inputPath := range inputPaths {
+		// Get duration
+		fullDur, err := f.GetDuration(inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to get duration of %s: %w", inputPath, err)
+		}
+		trim := trims[inputPath]
+		if err := trim.Validate(fullDur); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(inputPath), err)
+		}
+		dur := trim.Length(fullDur)
+		durations = append(durations, dur)
+
+		// Get chapters
+		chapters, _ := f.GetChapters(inputPath)
+
+		// Calculate time offset for this file
+		var offset float64
+		for j := 0; j < i; j++ {
+			offset += durations[j]
+		}
+
+		// Add period start marker
+		periodName := "Period"
+		if i < len(periodNames) {
+			periodName = periodNames[i]
+		} else {
+			periodName = fmt.Sprintf("Period %d", i+1)
+		}
+
+		allChapters = append(allChapters, ChapterInfo{
+			StartMs: int64(offset * 1000),
+			EndMs:   int64((offset + dur) * 1000),
+			Title:   periodName,
+		})
+
+		// Add HiLight chapters inside the kept part, shifted by the trimmed start and offset
+		inMs := int64(max(trim.In, 0) * 1000)
+		endMs := int64(trim.End(fullDur) * 1000)
+		for j, ch := range chapters {
+			if ch.StartMs < inMs || ch.StartMs >= endMs {
+				continue
+			}
+			allChapters = append(allChapters, ChapterInfo{
+				StartMs: ch.StartMs - inMs + int64(offset*1000),
+				EndMs:   min(ch.EndMs, endMs) - inMs + int64(offset*1000),
+				Title:   fmt.Sprintf("%s - Highlight %d", periodName, j+1),
+			})
+		}
+
+		totalDuration += dur
+	}
+
+	// Step 2: Create metadata file with chapters
+	progress(0.1, "Preparing files...")
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-meta-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer os.Remove(metaFile.Name())
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	fmt.Fprintf(metaFile, "title=Full Game\n")
+	fmt.Fprintf(metaFile, "\n")
+
+	for _, ch := range allChapters {
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.StartMs)
+		fmt.Fprintf(metaFile, "END=%d\n", ch.EndMs)
+		fmt.Fprintf(metaFile, "title=%s\n", escapeMetadata(ch.Title))
+		fmt.Fprintf(metaFile, "\n")
+	}
+	metaFile.Close()
+
+	// Step 4: Run ffmpeg with YouTube-optimized settings using filter_complex
+	// This handles DNxHR MOV files with unknown streams and different resolutions
+	progress(0.15, "Encoding video (this may take a while)...")
+
+	if targetSizeMB > 0 {
+		opts := DefaultReelOptions
+		opts.trims = trims
+		err = f.encodeTargetSize(inputPaths, metaFile.Name(), outputPath, totalDuration, targetSizeMB, forceCPU, opts, progress)
+	} else if forceCPU {
+		progress(0.15, "Using CPU encoding for best compression...")
+		err = f.exportFullGameCPU(inputPaths, trims, metaFile.Name(), outputPath, crf, totalDuration, progress)
+	} else {
+		err = f.tryNVENC(func() error {
+			return f.exportFullGameNVENC(inputPaths, trims, metaFile.Name(), outputPath, crf, totalDuration, progress)
+		})
+		if err != nil && !f.IsCancelled() {
+			progress(0.15, fallbackMessage(err))
+			err = f.exportFullGameCPU(inputPaths, trims, metaFile.Name(), outputPath, crf, totalDuration, progress)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	progress(1.0, "Export complete!")
+	return nil
+}
+
+// exportFullGameNVENC exports using NVIDIA hardware encoding with filter_complex,
+// reporting speed and time remaining for the totalDuration seconds of output
+func (f *FFmpeg) exportFullGameNVENC(inputPaths []string, trims map[string]Trim, metaFile, outputPath, crf string, totalDuration float64, progress func(float64, string)) error {
+	qp := crf
+
+	args := append([]string{}, progressArgs...)
+
+	// Add each input file, trimmed to the part kept
+	for _, path := range inputPaths {
+		args = append(args, trimInputArgs(path, trims[path])...)
+	}
+
+	// Add metadata file as last input
+	args = append(args, "-i", metaFile)
+
+	// Build filter_complex: scale each video to 1920x1080, then concat
+	// This handles DNxHR MOV files with unknown streams and different resolutions
+	filterStr := ""
+	for i, path := range inputPaths {
+		filterStr += fmt.Sprintf("[%d:v]%sscale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1[v%d];", i, f.sdrFilterPrefix(path), i)
+	}
+	for i, path := range inputPaths {
+		filterStr += fmt.Sprintf("[v%d][%s]", i, f.audioStream(strconv.Itoa(i), path))
+	}
+	filterStr += fmt.Sprintf("concat=n=%d:v=1:a=1[outv][outa]", len(inputPaths))
+
+	args = append(args,
+		"-filter_complex", filterStr,
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)),
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+		"-c:v", "h264_nvenc",
+		"-preset", "p4",
+		"-profile:v", "high",
+		"-rc", "constqp",
+		"-qp", qp,
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+	)
+	args = append(args, f.PresetArgs(PresetReel)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	reportProgress(cmd, totalDuration, 0.15, 0.99, "Encoding", progress)
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("export cancelled")
+		}
+		return fmt.Errorf("nvenc export failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// exportFullGameCPU exports using software encoding with filter_complex,
+// reporting speed and time remaining for the totalDuration seconds of output
+func (f *FFmpeg) exportFullGameCPU(inputPaths []string, trims map[string]Trim, metaFile, outputPath, crf string, totalDuration float64, progress func(float64, string)) error {
+	args := append([]string{}, progressArgs...)
+
+	// Add each input file, trimmed to the part kept
+	for _, path := range inputPaths {
+		args = append(args, trimInputArgs(path, trims[path])...)
+	}
+
+	// Add metadata file as last input
+	args = append(args, "-i", metaFile)
+
+	// Build filter_complex: scale each video to 1920x1080, then concat
+	// This handles DNxHR MOV files with unknown streams and different resolutions
+	filterStr := ""
+	for i, path := range inputPaths {
+		filterStr += fmt.Sprintf("[%d:v]%sscale=1920:1080:force_original_aspect_ratio=decrease,pad=1920:1080:(ow-iw)/2:(oh-ih)/2,setsar=1[v%d];", i, f.sdrFilterPrefix(path), i)
+	}
+	for i, path := range inputPaths {
+		filterStr += fmt.Sprintf("[v%d][%s]", i, f.audioStream(strconv.Itoa(i), path))
+	}
+	filterStr += fmt.Sprintf("concat=n=%d:v=1:a=1[outv][outa]", len(inputPaths))
+
+	args = append(args,
+		"-filter_complex", filterStr,
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-map_metadata", fmt.Sprintf("%d", len(inputPaths)),
+		"-map_chapters", fmt.Sprintf("%d", len(inputPaths)),
+		"-c:v", "libx264",
+		"-preset", "medium",
+		"-profile:v", "high",
+		"-crf", crf,
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-ar", "48000",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+	)
+	args = append(args, f.PresetArgs(PresetReel)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	reportProgress(cmd, totalDuration, 0.15, 0.99, "Encoding", progress)
+
+	if err := f.run(cmd); err != nil {
+		if f.IsCancelled() {
+			return fmt.Errorf("export cancelled")
+		}
+		return fmt.Errorf("cpu export failed: %s", stderr.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// HDRMode is how 10-bit and HDR sources are re-encoded into clips
+type HDRMode string
+
+const (
+	// HDRToneMap encodes 8-bit H.264 as for any other source. HDR (HLG or PQ)
+	// footage is tone-mapped to SDR BT.709 first, and 10-bit SDR footage keeps
+	// its color tags, so neither comes out washed out.
+	HDRToneMap HDRMode = "tonemap"
+	// HDRPreserve encodes 10-bit sources as 10-bit HEVC, keeping their color
+	// primaries, transfer and matrix (HDR stays HDR)
+	HDRPreserve HDRMode = "preserve"
+)
+
+// HDRModes lists the supported modes, default first
+var HDRModes = []HDRMode{HDRToneMap, HDRPreserve}
+
+// toneMapFilter converts HDR frames to SDR BT.709: linearize, map the
+// highlights down with the Hable curve, then convert to BT.709 8-bit.
+// zscale needs an ffmpeg built with libzimg (e.g. the gyan.dev builds).
+const toneMapFilter = "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709," +
+	"tonemap=tonemap=hable:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p"
+
+// IsHDR returns true for HLG or PQ (HDR10) video
+func (s *StreamInfo) IsHDR() bool {
+	return s.ColorTransfer == "arib-std-b67" || s.ColorTransfer == "smpte2084"
+}
+
+// TenBit returns true for video with more than 8 bits per channel
+func (s *StreamInfo) TenBit() bool {
+	return strings.Contains(s.PixFmt, "10") || strings.Contains(s.PixFmt, "12")
+}
+
+// SetHDRMode sets how 10-bit and HDR sources are re-encoded into clips
+func (f *FFmpeg) SetHDRMode(mode HDRMode) {
+	f.hdrMu.Lock()
+	f.hdrMode = mode
+	f.hdrMu.Unlock()
+}
+
+// HDRMode returns how 10-bit and HDR sources are re-encoded into clips
+func (f *FFmpeg) HDRMode() HDRMode {
+	f.hdrMu.Lock()
+	defer f.hdrMu.Unlock()
+	if f.hdrMode == "" {
+		return HDRToneMap
+	}
+	return f.hdrMode
+}
+
+// sourceInfo returns the stream info of a source file, probed once and
+// cached (nil if it can't be probed)
+func (f *FFmpeg) sourceInfo(videoPath string) *StreamInfo {
+	f.hdrMu.Lock()
+	info, ok := f.sourceInfos[videoPath]
+	f.hdrMu.Unlock()
+	if ok {
+		return info
+	}
+
+	info, err := f.GetStreamInfo(videoPath)
+	if err != nil {
+		info = nil
+	}
+
+	f.hdrMu.Lock()
+	if f.sourceInfos == nil {
+		f.sourceInfos = make(map[string]*StreamInfo)
+	}
+	f.sourceInfos[videoPath] = info
+	f.hdrMu.Unlock()
+	return info
+}
+
+// HasFilter reports whether this ffmpeg build has the named filter.
+// The filter list is read once and cached.
+func (f *FFmpeg) HasFilter(name string) bool {
+	f.filtersOnce.Do(func() {
+		cmd := exec.Command(f.ffmpegPath, "-hide_banner", "-filters")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		if err := f.execute(cmd); err != nil {
+			return // Unknown: don't report filters as missing
+		}
+
+		// Lines look like " ... zscale            V->V       Apply resizing, colorspace and bit depth conversion."
+		f.filters = make(map[string]bool)
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			if fields := strings.Fields(line); len(fields) >= 3 && strings.Contains(fields[2], "->") {
+				f.filters[fields[1]] = true
+			}
+		}
+	})
+
+	return f.filters == nil || f.filters[name]
+}
+
+// canToneMap returns true if videoPath is HDR and this ffmpeg can tone-map it
+func (f *FFmpeg) canToneMap(videoPath string) bool {
+	info := f.sourceInfo(videoPath)
+	return info != nil && info.IsHDR() && f.HasFilter("zscale")
+}
+
+// toneMaps returns true if clips from videoPath are tone-mapped to SDR
+func (f *FFmpeg) toneMaps(videoPath string) bool {
+	return f.HDRMode() == HDRToneMap && f.canToneMap(videoPath)
+}
+
+// hdrFilter returns the filter that tone-maps videoPath's frames to SDR, or
+// "" if they need none
+func (f *FFmpeg) hdrFilter(videoPath string) string {
+	if f.toneMaps(videoPath) {
+		return toneMapFilter
+	}
+	return ""
+}
+
+// sdrFilterPrefix returns the tone-map filter and a trailing comma for HDR
+// sources going into an 8-bit H.264 export (whatever the HDR mode), or ""
+func (f *FFmpeg) sdrFilterPrefix(videoPath string) string {
+	if f.canToneMap(videoPath) {
+		return toneMapFilter + ","
+	}
+	return ""
+}
+
+// withToneMap returns opts set to tone-map the HDR inputs of an 8-bit H.264
+// reel or export
+func (f *FFmpeg) withToneMap(inputPaths []string, opts ReelOptions) ReelOptions {
+	opts.toneMap = nil
+	for _, path := range inputPaths {
+		if f.canToneMap(path) {
+			if opts.toneMap == nil {
+				opts.toneMap = make(map[string]bool)
+			}
+			opts.toneMap[path] = true
+		}
+	}
+	return opts
+}
+
+// clipEncoderArgs returns the video encoder arguments for a clip cut from
+// videoPath at the clip quality, followed by its custom arguments (see
+// SetPresetArgs)
+func (f *FFmpeg) clipEncoderArgs(videoPath string, nvenc bool) []string {
+	return append(f.clipVideoArgs(videoPath, nvenc), f.PresetArgs(string(f.ClipQuality()))...)
+}
+
+// clipVideoArgs returns the video encoder arguments for a clip cut from
+// videoPath at the clip quality: 8-bit H.264 (tagged with the source's colors,
+// or BT.709 when tone-mapped), or 10-bit HEVC for 10-bit sources in
+// HDRPreserve mode
+func (f *FFmpeg) clipVideoArgs(videoPath string, nvenc bool) []string {
+	info := f.sourceInfo(videoPath)
+
+	if info != nil && info.TenBit() && f.HDRMode() == HDRPreserve {
+		args := f.hevcClipArgs(nvenc)
+		if nvenc {
+			return append(args, colorTagArgs(info)...)
+		}
+		if params := x265ColorParams(info); params != "" {
+			args = append(args, "-x265-params", params)
+		}
+		return append(args, colorTagArgs(info)...)
+	}
+
+	args := f.h264ClipArgs(nvenc)
+	switch {
+	case f.toneMaps(videoPath):
+		args = append(args, "-color_primaries", "bt709", "-color_trc", "bt709", "-colorspace", "bt709", "-color_range", "tv")
+	case info != nil && info.TenBit():
+		args = append(args, colorTagArgs(info)...)
+	}
+	return args
+}
+
+// colorTagArgs returns the output options that tag a video with the same
+// colors as info
+func colorTagArgs(info *StreamInfo) []string {
+	var args []string
+	for _, tag := range []struct{ option, value string }{
+		{"-color_primaries", info.ColorPrimaries},
+		{"-color_trc", info.ColorTransfer},
+		{"-colorspace", info.ColorSpace},
+		{"-color_range", info.ColorRange},
+	} {
+		if tag.value != "" && tag.value != "unknown" {
+			args = append(args, tag.option, tag.value)
+		}
+	}
+	return args
+}
+
+// x265ColorParams returns the x265 options that write the source's colors
+// into the HEVC stream itself, repeated at every keyframe so players that
+// start mid-stream still see them
+func x265ColorParams(info *StreamInfo) string {
+	params := []string{"repeat-headers=1"}
+	for _, p := range []struct{ name, value string }{
+		{"colorprim", info.ColorPrimaries},
+		{"transfer", info.ColorTransfer},
+		{"colormatrix", info.ColorSpace},
+	} {
+		if p.value != "" && p.value != "unknown" {
+			params = append(params, p.name+"="+p.value)
+		}
+	}
+	return strings.Join(params, ":")
+}
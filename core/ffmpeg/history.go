@@ -0,0 +1,193 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxHistoryTempFile caps the size of a temporary input kept with a history
+// entry (chapter metadata and concat lists are a few KB)
+const maxHistoryTempFile = 64 * 1024
+
+// HistoryEntry is an ffmpeg command that was run, kept so it can be looked up
+// and run again later (see Rerun)
+type HistoryEntry struct {
+	Time     time.Time     `json:"time"`
+	Args     []string      `json:"args"`   // ffmpeg's arguments
+	Inputs   []string      `json:"inputs"` // Files read with -i
+	Output   string        `json:"output"` // File written, under its final name
+	Duration time.Duration `json:"duration"`
+	Success  bool          `json:"success"`
+	// TempFiles holds the contents of small inputs written to the temp folder
+	// for the command (chapter metadata, concat lists), by path, as they are
+	// deleted once it finishes
+	TempFiles map[string]string `json:"temp_files,omitempty"`
+}
+
+// Command returns the entry's copy-pasteable command line
+func (e HistoryEntry) Command() string {
+	return CommandLine("ffmpeg", e.Args)
+}
+
+// Encoder returns the video encoder the command used, e.g. "h264_nvenc",
+// "libx264" or "copy" ("" if it doesn't name one)
+func (e HistoryEntry) Encoder() string {
+	encoder := ""
+	for i := 0; i < len(e.Args)-1; i++ {
+		switch e.Args[i] {
+		case "-c:v", "-vcodec", "-codec:v":
+			encoder = e.Args[i+1]
+		case "-c", "-codec":
+			if encoder == "" {
+				encoder = e.Args[i+1]
+			}
+		}
+	}
+	return encoder
+}
+
+// SetHistoryHandler registers a callback invoked after each ffmpeg command
+// that writes a file (not dry runs, probes or thumbnails), to keep a history
+// of the operations
+func (f *FFmpeg) SetHistoryHandler(handler func(HistoryEntry)) {
+	f.encoderMu.Lock()
+	f.onHistory = handler
+	f.encoderMu.Unlock()
+}
+
+// recordHistory reports a command run by run to the history handler
+func (f *FFmpeg) recordHistory(cmd *exec.Cmd, started time.Time, err error) {
+	f.encoderMu.Lock()
+	handler := f.onHistory
+	f.encoderMu.Unlock()
+	args := cmd.Args[1:]
+	if handler == nil || len(args) == 0 {
+		return
+	}
+	output := args[len(args)-1]
+	if output == "-" || output == os.DevNull || strings.EqualFold(output, "NUL") {
+		return // Analysis passes (e.g. the first pass of a two-pass encode)
+	}
+	if IsPartial(output) {
+		output = finalPath(output)
+	}
+
+	entry := HistoryEntry{
+		Time:     started,
+		Args:     slices.Clone(args),
+		Inputs:   inputPaths(args),
+		Output:   output,
+		Duration: time.Since(started),
+		Success:  err == nil,
+	}
+	// Temporary inputs are deleted once the command returns, so keep them
+	for _, input := range entry.Inputs {
+		if !f.isTempFile(input) {
+			continue
+		}
+		if info, err := os.Stat(input); err != nil || info.Size() > maxHistoryTempFile {
+			continue
+		}
+		if data, err := os.ReadFile(input); err == nil {
+			if entry.TempFiles == nil {
+				entry.TempFiles = make(map[string]string)
+			}
+			entry.TempFiles[input] = string(data)
+		}
+	}
+	handler(entry)
+}
+
+// isTempFile returns true if path is in the folder temporary files are written to
+func (f *FFmpeg) isTempFile(path string) bool {
+	dir := f.TempDir()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// inputPaths returns the files an ffmpeg command reads with -i, skipping
+// virtual inputs (-f lavfi sources)
+func inputPaths(args []string) []string {
+	var inputs []string
+	lavfi := false
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "-f":
+			lavfi = args[i+1] == "lavfi"
+		case "-i":
+			if !lavfi {
+				inputs = append(inputs, args[i+1])
+			}
+			lavfi = false
+		}
+	}
+	return inputs
+}
+
+// finalPath returns the name a partial output is moved to (see partialPath)
+func finalPath(partial string) string {
+	ext := filepath.Ext(partial)
+	return strings.TrimSuffix(strings.TrimSuffix(partial, ext), partialMarker) + ext
+}
+
+// Rerun runs a command from the history again with the same arguments,
+// writing its output under the same name (through a partial file, like
+// WriteOutput). Temporary inputs kept with the entry are written again first
+// if they were deleted.
+// Fails if an input no longer exists, and for the passes of a two-pass
+// encode, which need the log of the pass before them.
+func (f *FFmpeg) Rerun(entry HistoryEntry) error {
+	if len(entry.Args) == 0 || entry.Output == "" {
+		return fmt.Errorf("nothing to run again")
+	}
+	if slices.Contains(entry.Args, "-pass") {
+		return fmt.Errorf("one pass of a two-pass encode can't be run again on its own - run the operation again from its step")
+	}
+
+	args := slices.Clone(entry.Args)
+	for path, content := range entry.TempFiles {
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		tmp, err := os.CreateTemp(f.TempDir(), "ffmpeg-rerun-*"+filepath.Ext(path))
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
+		_, err = tmp.WriteString(content)
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("failed to write temp file: %w", err)
+		}
+		for i, arg := range args {
+			if arg == path {
+				args[i] = tmp.Name()
+			}
+		}
+	}
+	if err := validateInputs(args); err != nil {
+		return err
+	}
+
+	return f.WriteOutput(entry.Output, func(path string) error {
+		args[len(args)-1] = path
+		cmd := f.command(args...)
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		if err := f.run(cmd); err != nil {
+			return fmt.Errorf("ffmpeg re-run failed: %s", stderr.String())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os/exec"
+)
+
+const (
+	// loudnessSampleRate is the rate a video's sound is decoded at to measure
+	// its loudness: low enough to decode an hour in seconds, high enough to
+	// keep a crowd's cheer and the referee's whistle
+	loudnessSampleRate = 8000
+	// LoudnessStep is the length (in seconds) each value Loudness returns covers
+	LoudnessStep = 1.0
+	// silenceDB is the level reported for a step of digital silence
+	silenceDB = -100.0
+)
+
+// loudnessMeter turns decoded 16-bit mono samples into one RMS level (dBFS)
+// per LoudnessStep, as ffmpeg writes them, so an hour-long period isn't held
+// in memory
+type loudnessMeter struct {
+	perStep int
+	count   int
+	energy  float64
+	partial []byte // Half a sample left over from the last write
+	levels  []float64
+}
+
+// Write adds decoded samples to the meter
+func (m *loudnessMeter) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(m.partial) > 0 {
+		p = append(m.partial, p...)
+		m.partial = nil
+	}
+	for len(p) >= 2 {
+		s := float64(int16(binary.LittleEndian.Uint16(p)))
+		m.energy += s * s
+		m.count++
+		if m.count == m.perStep {
+			m.flush()
+		}
+		p = p[2:]
+	}
+	if len(p) == 1 {
+		m.partial = []byte{p[0]}
+	}
+	return n, nil
+}
+
+// flush ends the current step, adding its level
+func (m *loudnessMeter) flush() {
+	if m.count == 0 {
+		return
+	}
+	level := silenceDB
+	if rms := math.Sqrt(m.energy/float64(m.count)) / math.MaxInt16; rms > 0 {
+		level = max(20*math.Log10(rms), silenceDB)
+	}
+	m.levels = append(m.levels, level)
+	m.count, m.energy = 0, 0
+}
+
+// Loudness measures how loud a video's sound (its selected audio track, see
+// SetAudioTrack) is over time: one RMS level in dBFS per LoudnessStep from
+// the start, so sudden cheers, whistles and bench noise can be found. A video
+// without sound returns an error.
+func (f *FFmpeg) Loudness(videoPath string) ([]float64, error) {
+	if !f.hasAudio(videoPath) {
+		return nil, fmt.Errorf("%s has no audio", videoPath)
+	}
+	cmd := exec.Command(f.ffmpegPath,
+		"-v", "error",
+		"-i", videoPath,
+		"-map", f.audioStream("0", videoPath),
+		"-ac", "1",
+		"-ar", fmt.Sprint(loudnessSampleRate),
+		"-f", "s16le",
+		"-",
+	)
+
+	meter := &loudnessMeter{perStep: int(loudnessSampleRate * LoudnessStep)}
+	var stderr bytes.Buffer
+	cmd.Stdout = meter
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %s", stderr.String())
+	}
+	meter.flush()
+	return meter.levels, nil
+}
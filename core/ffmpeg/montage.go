@@ -0,0 +1,154 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// DefaultMontageLength is the intro montage's length in seconds
+	DefaultMontageLength = 25.0
+	// montageSnippet is about how long each clip is shown in a montage; the
+	// exact length is a whole number of beats of the music
+	montageSnippet = 1.0
+	// montageFade is how long the music fades out at the end of a montage
+	montageFade = 1.0
+)
+
+// MontageSnippet is a clip used in an intro montage and where its highlight is
+type MontageSnippet struct {
+	ClipPath string
+	At       float64 // Seconds into the clip of the highlight
+	Order    int     // The montage shows its snippets in this order (e.g. the reel's)
+}
+
+// Montage is a fast-cut intro made of short snippets of the best clips, cut on
+// the beat of a music track, e.g. 25 one-beat-long shots before the reel
+type Montage struct {
+	// Snippets are the candidate clips, best first. As many as fit in Length
+	// are used, each once.
+	Snippets  []MontageSnippet
+	MusicPath string
+	Length    float64 // Target length in seconds (0 = DefaultMontageLength)
+	BPM       float64 // Tempo of the music (0 = detect it, see DetectTempo)
+	// Ramp speeds up into each highlight and plays the highlight itself in slow
+	// motion, instead of showing the snippet at normal speed
+	Ramp bool
+}
+
+// CreateMontage writes a montage to outputPath in the codec, resolution,
+// frame rate and audio format of ref, so it can lead a reel like an intro
+// bumper (see ConformBumper). Each snippet lasts the whole number of beats
+// closest to a second, and the music starts on its first beat so the cuts
+// land on the beat. The camera's sound is replaced by the music, which fades
+// out at the end.
+func (f *FFmpeg) CreateMontage(m Montage, outputPath string, ref *StreamInfo) error {
+	if len(m.Snippets) == 0 {
+		return fmt.Errorf("no clips for the montage")
+	}
+	if m.MusicPath == "" {
+		return fmt.Errorf("no music chosen for the montage")
+	}
+	length := m.Length
+	if length <= 0 {
+		length = DefaultMontageLength
+	}
+
+	bpm, firstBeat := m.BPM, 0.0
+	if bpm <= 0 {
+		var err error
+		if bpm, firstBeat, err = f.DetectTempo(m.MusicPath); err != nil {
+			return fmt.Errorf("failed to find the music's beat (enter its tempo instead): %w", err)
+		}
+	}
+	beat := 60 / bpm
+	cut := beat * max(1, math.Round(montageSnippet/beat))
+
+	count := min(max(1, int(length/cut)), len(m.Snippets))
+	snippets := slices.Clone(m.Snippets[:count])
+	slices.SortStableFunc(snippets, func(a, b MontageSnippet) int { return a.Order - b.Order })
+	total := float64(count) * cut
+
+	// A ramped snippet plays a snippet's worth of footage before the highlight
+	// at double speed, then a quarter of that around the highlight at half speed
+	fast, slow := cut, cut/4
+	if !m.Ramp {
+		fast, slow = 0, cut
+	}
+
+	var args []string
+	for _, s := range snippets {
+		start := max(s.At-slow/2-fast, 0)
+		args = append(args,
+			"-ss", fmt.Sprintf("%.3f", start),
+			"-t", fmt.Sprintf("%.3f", fast+slow),
+			"-i", s.ClipPath,
+		)
+	}
+	args = append(args, "-ss", fmt.Sprintf("%.3f", firstBeat))
+	args = append(args, musicInputArgs(m.MusicPath)...)
+
+	format := fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1",
+		ref.Width, ref.Height, ref.Width, ref.Height)
+	if ref.FrameRate != "" && ref.FrameRate != "0/0" {
+		format += ",fps=" + ref.FrameRate
+	}
+
+	var filter strings.Builder
+	var labels string
+	segments := 0
+	for i := range snippets {
+		if m.Ramp {
+			fmt.Fprintf(&filter, "[%d:v]split[f%d][s%d];", i, i, i)
+			fmt.Fprintf(&filter, "[f%d]trim=0:%.3f,setpts=(PTS-STARTPTS)/2,%s[a%d];", i, fast, format, i)
+			fmt.Fprintf(&filter, "[s%d]trim=%.3f:%.3f,setpts=(PTS-STARTPTS)*2,%s[b%d];", i, fast, fast+slow, format, i)
+			labels += fmt.Sprintf("[a%d][b%d]", i, i)
+			segments += 2
+		} else {
+			fmt.Fprintf(&filter, "[%d:v]trim=0:%.3f,setpts=PTS-STARTPTS,%s[a%d];", i, slow, format, i)
+			labels += fmt.Sprintf("[a%d]", i)
+			segments++
+		}
+	}
+	fmt.Fprintf(&filter, "%sconcat=n=%d:v=1:a=0[outv];", labels, segments)
+
+	sampleRate := ref.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 48000
+	}
+	channelLayout := "stereo"
+	if ref.Channels == 1 {
+		channelLayout = "mono"
+	}
+	fmt.Fprintf(&filter, "[%d:a]atrim=0:%.3f,asetpts=PTS-STARTPTS,afade=t=out:st=%.3f:d=%.3f,aformat=sample_rates=%d:channel_layouts=%s[outa]",
+		len(snippets), total, max(total-montageFade, 0), montageFade, sampleRate, channelLayout)
+
+	args = append(args,
+		"-filter_complex", filter.String(),
+		"-map", "[outv]",
+		"-map", "[outa]",
+	)
+	args = append(args, bumperVideoCodecArgs(ref)...)
+	args = append(args, bumperAudioCodecArgs(ref)...)
+	args = append(args,
+		"-ar", fmt.Sprintf("%d", sampleRate),
+		"-ac", fmt.Sprintf("%d", max(ref.Channels, 1)),
+		"-t", fmt.Sprintf("%.3f", total),
+		"-y",
+		outputPath,
+	)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("failed to create montage %s: %s", filepath.Base(outputPath), stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// partialMarker is inserted before the extension of an output that is
+	// still being written ("clip.mp4" -> "clip.partial.mp4")
+	partialMarker = ".partial"
+	// outputAttempts is how many times an output is written before giving up
+	// on transient I/O errors
+	outputAttempts = 3
+	// outputRetryDelay is the wait before the first retry, doubled each time
+	outputRetryDelay = 5 * time.Second
+)
+
+// transientIOErrors are error messages (lowercase) that point to a flaky
+// disk or network share rather than a problem with the video
+var transientIOErrors = []string{
+	"input/output error",
+	"i/o error",
+	"network name is no longer available",
+	"network path was not found",
+	"semaphore timeout",
+	"stale file handle",
+	"connection reset",
+	"connection timed out",
+	"broken pipe",
+	"resource temporarily unavailable",
+	"device not ready",
+	"no such device",
+}
+
+// partialPath returns the temporary name outputPath is written under
+func partialPath(outputPath string) string {
+	ext := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, ext) + partialMarker + ext
+}
+
+// IsPartial returns true if path is an output left behind by an interrupted
+// write (see WriteOutput)
+func IsPartial(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), partialMarker)
+}
+
+// CleanPartials deletes the outputs of interrupted writes in folder and
+// returns the paths removed
+func CleanPartials(folder string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		if entry.IsDir() || !IsPartial(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(folder, entry.Name())
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove partial file: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// WriteOutput calls write to create outputPath, but has it write to a
+// temporary name in the same folder ("clip.partial.mp4") that is renamed into
+// place once the write succeeds, so a write cut off halfway (a network share
+// dropping out, a removable drive pulled) never leaves a broken file under
+// the real name. The partial file is removed when the write fails, and
+// failures that look like transient I/O errors are retried with backoff.
+// In dry-run mode write is called with outputPath itself.
+func (f *FFmpeg) WriteOutput(outputPath string, write func(path string) error) error {
+	if f.DryRun() {
+		return write(outputPath)
+	}
+
+	tmpPath := partialPath(outputPath)
+	delay := outputRetryDelay
+	var err error
+	for attempt := 1; attempt <= outputAttempts; attempt++ {
+		err = write(tmpPath)
+		if err == nil {
+			err = finishOutput(tmpPath, outputPath)
+		}
+		if err == nil {
+			return nil
+		}
+		os.Remove(tmpPath)
+
+		if f.IsCancelled() || !isTransientIOError(err) || attempt == outputAttempts {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return err
+}
+
+// finishOutput checks that a write produced a file and moves it into place
+func finishOutput(tmpPath, outputPath string) error {
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return fmt.Errorf("output was not written: %w", err)
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("output is empty: %s", filepath.Base(outputPath))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return fmt.Errorf("failed to move output into place: %w", err)
+	}
+	return nil
+}
+
+// isTransientIOError returns true if err looks like a temporary disk or
+// network failure worth retrying
+func isTransientIOError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range transientIOErrors {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
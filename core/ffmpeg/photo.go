@@ -0,0 +1,37 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractStill saves the frame at atSec as a full-size, high quality JPEG (for
+// photos of a highlight, rather than a thumbnail), with the color correction
+// (may be nil) applied. Runs as a logged command, so dry runs show it.
+func (f *FFmpeg) ExtractStill(inputPath, outputPath string, atSec float64, color *ColorCorrection) error {
+	if atSec < 0 {
+		atSec = 0
+	}
+
+	args := append(f.sourceInputArgs(inputPath),
+		"-ss", fmt.Sprintf("%.3f", atSec),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", f.frameFilter(inputPath, color.filterChain(), ""),
+		"-q:v", "2",
+		"-f", "image2",
+		"-y",
+		outputPath,
+	)
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("failed to extract photo from %s: %s", filepath.Base(inputPath), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PresetReel is the preset of combined reels, full-game exports and vertical
+// reels for SetPresetArgs. Re-encoded clips use their ClipQuality as preset.
+const PresetReel = "reel"
+
+// Presets lists the encoding presets custom arguments can be set for: the
+// clip qualities, then PresetReel
+func Presets() []string {
+	var presets []string
+	for _, quality := range ClipQualities {
+		presets = append(presets, string(quality))
+	}
+	return append(presets, PresetReel)
+}
+
+// SetPresetArgs sets custom arguments added to the command line of every
+// encode at a preset (see Presets), after the app's own encoder options so
+// they can override them: e.g. "-c:v libsvtav1 -crf 32" for an ffmpeg built
+// with SVT-AV1, or global options like "-loglevel warning". They are not
+// added to two-pass target size encodes, whose bitrate is worked out, or to
+// stream copies. Check them with CheckPresetArgs first.
+func (f *FFmpeg) SetPresetArgs(args map[string][]string) {
+	f.qualityMu.Lock()
+	f.presetArgs = make(map[string][]string, len(args))
+	for preset, a := range args {
+		if len(a) > 0 {
+			f.presetArgs[preset] = append([]string{}, a...)
+		}
+	}
+	f.qualityMu.Unlock()
+}
+
+// PresetArgs returns the custom arguments of a preset set with SetPresetArgs
+func (f *FFmpeg) PresetArgs(preset string) []string {
+	f.qualityMu.Lock()
+	defer f.qualityMu.Unlock()
+	return append([]string{}, f.presetArgs[preset]...)
+}
+
+// CheckPresetArgs encodes half a second of test video and audio with the
+// encoder options of preset followed by args, to tell whether this ffmpeg
+// accepts them. It checks on every encoder the preset's encodes may run on:
+// libx264, which encodes when GPU encoding is off or NVENC fails, and
+// h264_nvenc too when GPU encoding is on and NVENC works here (see
+// CheckNVENC). The error names the encoder and holds ffmpeg's complaint.
+func (f *FFmpeg) CheckPresetArgs(preset string, args []string) error {
+	encoders, err := f.presetEncoders(preset)
+	if err != nil {
+		return err
+	}
+
+	for _, encoder := range encoders {
+		check := []string{
+			"-hide_banner", "-nostdin",
+			"-f", "lavfi", "-i", "testsrc2=size=640x360:rate=30:duration=0.5",
+			"-f", "lavfi", "-i", "sine=frequency=440:duration=0.5",
+		}
+		check = append(check, encoder...)
+		check = append(check, "-c:a", "aac", "-b:a", "192k")
+		check = append(check, args...)
+		check = append(check, "-f", "null", "-")
+
+		// Not recorded with the commands of the project's operations
+		cmd := exec.Command(f.ffmpegPath, check...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := f.execute(cmd); err != nil {
+			if line := lastLine(stderr.String()); line != "" {
+				return fmt.Errorf("ffmpeg rejected %q with %s: %s", strings.Join(args, " "), encoder[1], line)
+			}
+			return fmt.Errorf("ffmpeg rejected %q with %s: %w", strings.Join(args, " "), encoder[1], err)
+		}
+	}
+	return nil
+}
+
+// presetEncoders returns the video encoder options a preset's encodes may
+// use, the CPU encoder's first (see CheckPresetArgs)
+func (f *FFmpeg) presetEncoders(preset string) ([][]string, error) {
+	rate := clipRate{level: 23, preset: "medium", nvencPset: "p4"}
+	if r, ok := clipRates[ClipQuality(preset)]; ok {
+		rate = r
+	} else if preset != PresetReel {
+		return nil, fmt.Errorf("unknown encoding preset %q", preset)
+	}
+	level := strconv.Itoa(rate.level)
+
+	encoders := [][]string{
+		{"-c:v", "libx264", "-preset", rate.preset, "-profile:v", "high", "-crf", level, "-pix_fmt", "yuv420p"},
+	}
+	if !f.PreferCPU() && f.CheckNVENC() == nil {
+		encoders = append(encoders,
+			[]string{"-c:v", "h264_nvenc", "-preset", rate.nvencPset, "-profile:v", "high", "-rc", "constqp", "-qp", level, "-pix_fmt", "yuv420p"})
+	}
+	return encoders, nil
+}
+
+// SplitArgs splits custom arguments typed on one line as a POSIX shell would,
+// so a line copied from a terminal gives the same arguments: at spaces, tabs
+// and line breaks, except inside single or double quotes
+// (-metadata comment="Game 3" is two arguments). A backslash outside quotes
+// keeps the next character as is (Game\ 3 is one argument) and one before a
+// line break joins the lines; inside double quotes it only escapes ", \, $
+// and `, and inside single quotes it is kept, so quote Windows paths
+// ('C:\Fonts\arial.ttf').
+func SplitArgs(text string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+			switch {
+			case r == '\n':
+				// A line continuation
+			case quote == '"' && !strings.ContainsRune(`"\$`+"`", r):
+				arg.WriteRune('\\')
+				arg.WriteRune(r)
+			default:
+				arg.WriteRune(r)
+				inArg = true
+			}
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("the arguments end with a backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// lastLine returns the last line of ffmpeg's output that isn't blank
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
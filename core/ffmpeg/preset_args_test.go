@@ -0,0 +1,148 @@
+package ffmpeg
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"blank", " \t\n ", nil},
+		{"spaces", "-c:v libx265  -crf 28", []string{"-c:v", "libx265", "-crf", "28"}},
+		{"tabs and line breaks", "-loglevel\twarning\n-threads 4\r\n", []string{"-loglevel", "warning", "-threads", "4"}},
+		{"double quotes", `-metadata comment="Game 3"`, []string{"-metadata", "comment=Game 3"}},
+		{"single quotes", `-metadata 'title=North vs South'`, []string{"-metadata", "title=North vs South"}},
+		{"quote inside the other", `-metadata "comment=St. Mary's" -vf 'drawtext=text="Go"'`, []string{"-metadata", "comment=St. Mary's", "-vf", `drawtext=text="Go"`}},
+		{"empty quotes", `-metadata comment=""`, []string{"-metadata", "comment="}},
+		{"empty argument", `'' x`, []string{"", "x"}},
+		{"escaped space", `-metadata title=Game\ 3`, []string{"-metadata", "title=Game 3"}},
+		{"escaped quote", `-metadata comment=St.\ Mary\'s`, []string{"-metadata", "comment=St. Mary's"}},
+		{"escaped backslash", `a\\b`, []string{`a\b`}},
+		{"filter escape", `-vf drawtext=text=10\\:30`, []string{"-vf", `drawtext=text=10\:30`}},
+		{"line continuation", "-c:v libx265 \\\n-crf 28", []string{"-c:v", "libx265", "-crf", "28"}},
+		{"backslash in double quotes", `"C:\Fonts\a.ttf" "say \"hi\"" "a\\b"`, []string{`C:\Fonts\a.ttf`, `say "hi"`, `a\b`}},
+		{"backslash in single quotes", `'C:\Fonts\a.ttf'`, []string{`C:\Fonts\a.ttf`}},
+		{"unicode", `-metadata title="Mål ⚑"`, []string{"-metadata", "title=Mål ⚑"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitArgs(tt.text)
+			if err != nil {
+				t.Fatalf("SplitArgs(%q): %v", tt.text, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SplitArgs(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitArgsErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`-metadata comment="Game 3`, `unclosed " quote`},
+		{`-metadata 'title`, `unclosed ' quote`},
+		{`"it's'`, `unclosed " quote`},
+		{`-crf 28 \`, "backslash"},
+	}
+	for _, tt := range tests {
+		if _, err := SplitArgs(tt.text); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SplitArgs(%q) error = %v, want one with %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+// checkEncoders returns the video encoder of each preset check in calls
+func checkEncoders(calls [][]string) []string {
+	var encoders []string
+	for _, call := range calls {
+		if slices.Contains(call, "testsrc2=size=640x360:rate=30:duration=0.5") {
+			encoders = append(encoders, call[slices.Index(call, "-c:v")+1])
+		}
+	}
+	return encoders
+}
+
+func TestCheckPresetArgsEncoders(t *testing.T) {
+	tests := []struct {
+		name      string
+		preferCPU bool
+		nvenc     error // Result of the NVENC test encode
+		want      []string
+	}{
+		{"GPU on", false, nil, []string{"libx264", "h264_nvenc"}},
+		{"GPU off", true, nil, []string{"libx264"}},
+		{"no NVENC", false, errors.New("exit status 1"), []string{"libx264"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeRunner{}
+			fake.On("ffmpeg", []string{"color=c=black:s=256x144:d=0.1"}, FakeResponse{Stderr: "Cannot load nvcuda.dll\n", Err: tt.nvenc})
+			f := NewWithRunner(fake)
+			f.SetPreferCPU(tt.preferCPU)
+
+			if err := f.CheckPresetArgs(string(ClipDraft), []string{"-tune", "film"}); err != nil {
+				t.Fatalf("CheckPresetArgs: %v", err)
+			}
+			if got := checkEncoders(fake.Calls()); !slices.Equal(got, tt.want) {
+				t.Errorf("checked with %v, want %v", got, tt.want)
+			}
+			for _, call := range fake.Calls() {
+				if slices.Contains(call, "testsrc2=size=640x360:rate=30:duration=0.5") {
+					if argAt(call, "-tune") != "film" {
+						t.Errorf("custom arguments missing from %v", call)
+					}
+					if argAt(call, "-preset") != "veryfast" && argAt(call, "-preset") != "p1" {
+						t.Errorf("draft check uses preset %q: %v", argAt(call, "-preset"), call)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPresetArgsRejected(t *testing.T) {
+	fake := &FakeRunner{}
+	fake.On("ffmpeg", []string{"h264_nvenc", "-tune"}, FakeResponse{
+		Stderr: "[h264_nvenc @ 0x1] Unknown tune\nError setting option tune to value film.\n",
+		Err:    errors.New("exit status 1"),
+	})
+	f := NewWithRunner(fake)
+
+	err := f.CheckPresetArgs(PresetReel, []string{"-tune", "film"})
+	if err == nil {
+		t.Fatal("expected NVENC to reject the arguments")
+	}
+	if !strings.Contains(err.Error(), "h264_nvenc") || !strings.Contains(err.Error(), "Error setting option tune") {
+		t.Errorf("error = %v, want one naming h264_nvenc with ffmpeg's last line", err)
+	}
+
+	f.SetPreferCPU(true)
+	if err := f.CheckPresetArgs(PresetReel, []string{"-tune", "film"}); err != nil {
+		t.Errorf("with GPU encoding off: %v", err)
+	}
+}
+
+func TestCheckPresetArgsUnknownPreset(t *testing.T) {
+	f := NewWithRunner(&FakeRunner{})
+	if err := f.CheckPresetArgs("ultra", nil); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+// argAt returns the argument following flag in a command line ("" = none)
+func argAt(call []string, flag string) string {
+	if i := slices.Index(call, flag); i >= 0 && i+1 < len(call) {
+		return call[i+1]
+	}
+	return ""
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly || windows)
+
+package ffmpeg
+
+import "os/exec"
+
+// startLowPriority starts cmd like cmd.Start; priorities aren't supported here
+func startLowPriority(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
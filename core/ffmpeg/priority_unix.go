@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package ffmpeg
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// lowPriorityNice is the niceness low-priority commands run at
+const lowPriorityNice = 10
+
+// startLowPriority starts cmd like cmd.Start, lowering its priority as soon
+// as it has started. The command still runs if its priority can't be changed.
+func startLowPriority(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, lowPriorityNice)
+	return nil
+}
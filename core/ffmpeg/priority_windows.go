@@ -0,0 +1,20 @@
+//go:build windows
+
+package ffmpeg
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// belowNormalPriorityClass is the BELOW_NORMAL_PRIORITY_CLASS process creation flag
+const belowNormalPriorityClass = 0x00004000
+
+// startLowPriority starts cmd like cmd.Start, created below normal priority
+func startLowPriority(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= belowNormalPriorityClass
+	return cmd.Start()
+}
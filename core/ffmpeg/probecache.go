@@ -0,0 +1,102 @@
+package ffmpeg
+
+import (
+	"os"
+	"slices"
+	"time"
+)
+
+// maxProbeCache caps how many files' probe results are kept; the cache is
+// emptied when it fills up, so scanning a season's archive can't grow it
+// without bound
+const maxProbeCache = 2000
+
+// probeKey identifies a version of a file: probe results are reused until
+// the file's size or modification time changes (e.g. a clip written again)
+type probeKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// fileProbeKey returns the key for the current version of path, or false if
+// it can't be read (nothing is cached for it)
+func fileProbeKey(path string) (probeKey, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return probeKey{}, false
+	}
+	return probeKey{path: path, size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// cachedStreamInfo returns a copy of the cached streams of a file version
+func (f *FFmpeg) cachedStreamInfo(key probeKey) (*StreamInfo, bool) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	info, ok := f.streamInfos[key]
+	return &info, ok
+}
+
+// cacheStreamInfo remembers the streams of a file version
+func (f *FFmpeg) cacheStreamInfo(key probeKey, info *StreamInfo) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	if f.streamInfos == nil || len(f.streamInfos) >= maxProbeCache {
+		f.streamInfos = make(map[probeKey]StreamInfo)
+	}
+	f.streamInfos[key] = *info
+}
+
+// cachedMetadataInfo returns a copy of the cached metadata of a file version
+func (f *FFmpeg) cachedMetadataInfo(key probeKey) (*VideoMetadataInfo, bool) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	info, ok := f.metadataInfos[key]
+	return &info, ok
+}
+
+// cacheMetadataInfo remembers the metadata of a file version
+func (f *FFmpeg) cacheMetadataInfo(key probeKey, info *VideoMetadataInfo) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	if f.metadataInfos == nil || len(f.metadataInfos) >= maxProbeCache {
+		f.metadataInfos = make(map[probeKey]VideoMetadataInfo)
+	}
+	f.metadataInfos[key] = *info
+}
+
+// cachedChapters returns a copy of the cached chapters of a file version
+func (f *FFmpeg) cachedChapters(key probeKey) ([]ChapterInfo, bool) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	chapters, ok := f.chapterInfos[key]
+	return slices.Clone(chapters), ok
+}
+
+// cacheChapters remembers the chapters of a file version
+func (f *FFmpeg) cacheChapters(key probeKey, chapters []ChapterInfo) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	if f.chapterInfos == nil || len(f.chapterInfos) >= maxProbeCache {
+		f.chapterInfos = make(map[probeKey][]ChapterInfo)
+	}
+	f.chapterInfos[key] = slices.Clone(chapters)
+}
+
+// cachedAudioTracks returns a copy of the cached audio tracks of a file version
+func (f *FFmpeg) cachedAudioTracks(key probeKey) ([]AudioTrack, bool) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	tracks, ok := f.audioTracks[key]
+	return slices.Clone(tracks), ok
+}
+
+// cacheAudioTracks remembers the audio tracks of a file version
+func (f *FFmpeg) cacheAudioTracks(key probeKey, tracks []AudioTrack) {
+	f.probeMu.Lock()
+	defer f.probeMu.Unlock()
+	if f.audioTracks == nil || len(f.audioTracks) >= maxProbeCache {
+		f.audioTracks = make(map[probeKey][]AudioTrack)
+	}
+	f.audioTracks[key] = slices.Clone(tracks)
+}
@@ -0,0 +1,124 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// progressArgs make ffmpeg write machine-readable progress to stdout (read by
+// reportProgress) instead of the usual stats line on stderr
+var progressArgs = []string{"-progress", "pipe:1", "-nostats"}
+
+// EncodeProgress is a snapshot of a running encode, from ffmpeg's -progress output
+type EncodeProgress struct {
+	Position float64 // Seconds of output encoded so far
+	Duration float64 // Seconds the output will be (0 = unknown)
+	FPS      float64 // Frames encoded per second (0 = not known yet)
+	Speed    float64 // Multiple of realtime (0 = not known yet)
+}
+
+// Fraction returns how much of the output has been encoded, from 0 to 1
+func (p EncodeProgress) Fraction() float64 {
+	if p.Duration <= 0 {
+		return 0
+	}
+	return min(max(p.Position/p.Duration, 0), 1)
+}
+
+// Remaining returns the estimated seconds left at the current speed (-1 if unknown)
+func (p EncodeProgress) Remaining() float64 {
+	if p.Speed <= 0 || p.Duration <= 0 {
+		return -1
+	}
+	return max(p.Duration-p.Position, 0) / p.Speed
+}
+
+// Status describes the speed and time left, e.g. "Encoding at 2.3x, ~22
+// minutes remaining", starting with action ("Encoding", "Pass 1/2: analyzing")
+func (p EncodeProgress) Status(action string) string {
+	if p.Speed <= 0 {
+		return action + "..."
+	}
+	status := fmt.Sprintf("%s at %.1fx", action, p.Speed)
+	if remaining := p.Remaining(); remaining >= 0 {
+		status += ", " + formatRemaining(remaining) + " remaining"
+	}
+	return status
+}
+
+// formatRemaining formats an estimated time left for a status line
+func formatRemaining(seconds float64) string {
+	minutes := int(seconds/60 + 0.5)
+	switch {
+	case seconds < 60:
+		return "less than a minute"
+	case minutes == 1:
+		return "~1 minute"
+	case minutes < 90:
+		return fmt.Sprintf("~%d minutes", minutes)
+	default:
+		return fmt.Sprintf("~%dh %02dm", minutes/60, minutes%60)
+	}
+}
+
+// progressWriter parses the key=value blocks ffmpeg writes with -progress
+// (one block per update, ending in "progress=continue" or "progress=end")
+type progressWriter struct {
+	current EncodeProgress
+	partial []byte
+	update  func(EncodeProgress)
+}
+
+// Write implements io.Writer for cmd.Stdout
+func (w *progressWriter) Write(data []byte) (int, error) {
+	w.partial = append(w.partial, data...)
+	for {
+		end := bytes.IndexByte(w.partial, '\n')
+		if end < 0 {
+			break
+		}
+		w.parseLine(strings.TrimSpace(string(w.partial[:end])))
+		w.partial = w.partial[end+1:]
+	}
+	return len(data), nil
+}
+
+// parseLine handles one key=value line
+func (w *progressWriter) parseLine(line string) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return
+	}
+	switch key {
+	case "out_time_us":
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+			w.current.Position = float64(us) / 1e6
+		}
+	case "fps":
+		if fps, err := strconv.ParseFloat(value, 64); err == nil {
+			w.current.FPS = fps
+		}
+	case "speed":
+		// "2.31x", or "N/A" until enough has been encoded
+		if speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "x"), 64); err == nil {
+			w.current.Speed = speed
+		}
+	case "progress":
+		w.update(w.current)
+	}
+}
+
+// reportProgress sends the progress of cmd (whose arguments must include
+// progressArgs) to progress as it runs. The encode covers from..to of the whole
+// operation's progress and its status reads like EncodeProgress.Status(action).
+func reportProgress(cmd *exec.Cmd, duration, from, to float64, action string, progress func(float64, string)) {
+	cmd.Stdout = &progressWriter{
+		current: EncodeProgress{Duration: duration},
+		update: func(p EncodeProgress) {
+			progress(from+(to-from)*p.Fraction(), p.Status(action))
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package ffmpeg
+
+import "strconv"
+
+// ClipQuality is how re-encoded clips are encoded. Reels and full-game exports
+// have their own quality, chosen when they are made.
+type ClipQuality string
+
+const (
+	// ClipHigh is CRF/QP 18 at the medium (NVENC p4) preset, for final clips
+	ClipHigh ClipQuality = "high"
+	// ClipBalanced is CRF/QP 21, about half the size of ClipHigh
+	ClipBalanced ClipQuality = "balanced"
+	// ClipDraft is CRF/QP 27 at the fastest presets, for quick rough cuts
+	// that are re-cut or only combined into a reel later
+	ClipDraft ClipQuality = "draft"
+)
+
+// ClipQualities lists the supported qualities, default first
+var ClipQualities = []ClipQuality{ClipHigh, ClipBalanced, ClipDraft}
+
+// clipRate is the rate control of a clip quality
+type clipRate struct {
+	level     int    // H.264 CRF (CPU) or QP (NVENC); HEVC uses level+2
+	preset    string // libx264/libx265 preset
+	nvencPset string // NVENC preset
+}
+
+var clipRates = map[ClipQuality]clipRate{
+	ClipHigh:     {18, "medium", "p4"},
+	ClipBalanced: {21, "medium", "p4"},
+	ClipDraft:    {27, "veryfast", "p1"},
+}
+
+// SetClipQuality sets how re-encoded clips are encoded
+func (f *FFmpeg) SetClipQuality(quality ClipQuality) {
+	f.qualityMu.Lock()
+	f.clipQuality = quality
+	f.qualityMu.Unlock()
+}
+
+// ClipQuality returns how re-encoded clips are encoded
+func (f *FFmpeg) ClipQuality() ClipQuality {
+	f.qualityMu.Lock()
+	defer f.qualityMu.Unlock()
+	if _, ok := clipRates[f.clipQuality]; !ok {
+		return ClipHigh
+	}
+	return f.clipQuality
+}
+
+// h264ClipArgs returns the H.264 encoder arguments for a clip at the current
+// quality: High profile, 8-bit yuv420p for compatibility
+func (f *FFmpeg) h264ClipArgs(nvenc bool) []string {
+	rate := clipRates[f.ClipQuality()]
+	level := strconv.Itoa(rate.level)
+	if nvenc {
+		return []string{"-c:v", "h264_nvenc", "-preset", rate.nvencPset, "-profile:v", "high", "-rc", "constqp", "-qp", level, "-pix_fmt", "yuv420p"}
+	}
+	return []string{"-c:v", "libx264", "-preset", rate.preset, "-profile:v", "high", "-crf", level, "-pix_fmt", "yuv420p"}
+}
+
+// hevcClipArgs returns the 10-bit HEVC encoder arguments for a clip at the
+// current quality
+func (f *FFmpeg) hevcClipArgs(nvenc bool) []string {
+	rate := clipRates[f.ClipQuality()]
+	level := strconv.Itoa(rate.level + 2)
+	if nvenc {
+		return []string{"-c:v", "hevc_nvenc", "-preset", rate.nvencPset, "-profile:v", "main10", "-rc", "constqp", "-qp", level, "-pix_fmt", "p010le", "-tag:v", "hvc1"}
+	}
+	return []string{"-c:v", "libx265", "-preset", rate.preset, "-crf", level, "-pix_fmt", "yuv420p10le", "-tag:v", "hvc1"}
+}
+
+// CanStreamCopyMP4 reports whether a source's video can be stream copied into
+// an .mp4 clip that plays everywhere (YouTube, browsers, phones): only H.264
+// can. Other codecs, like GoPro HEVC, are stream copied into .mov instead.
+func (f *FFmpeg) CanStreamCopyMP4(videoPath string) bool {
+	info := f.sourceInfo(videoPath)
+	return info != nil && info.VideoCodec == "h264"
+}
@@ -0,0 +1,224 @@
+package ffmpeg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// segmentRecordName is the file in a reel's segment folder recording what
+// each segment was encoded from
+const segmentRecordName = "segments.json"
+
+// reelSegment is one clip of a reel, encoded on its own
+type reelSegment struct {
+	Key  string `json:"key"`  // What it was encoded from (see segmentKey)
+	File string `json:"file"` // Segment file in the segment folder
+	CPU  bool   `json:"cpu"`  // Encoded by the CPU encoder rather than NVENC
+}
+
+// SegmentStats says how much of a reel ConcatClipsSegmented had to encode
+type SegmentStats struct {
+	Encoded int // Clips encoded (again)
+	Reused  int // Clips whose segment from an earlier build was unchanged
+}
+
+// SegmentFolder returns the folder next to a reel holding the segments it
+// was built from (".Reel_2024-11-09.segments" for "Reel_2024-11-09.mp4")
+func SegmentFolder(reelPath string) string {
+	name := strings.TrimSuffix(filepath.Base(reelPath), filepath.Ext(reelPath))
+	return filepath.Join(filepath.Dir(reelPath), "."+name+".segments")
+}
+
+// CanSegmentReel returns true if a reel encoded with opts comes out the same
+// built clip by clip (see ConcatClipsSegmented): crossfades blend two clips,
+// and a target size spreads the bitrate over the whole reel
+func CanSegmentReel(opts ReelOptions, targetSizeMB float64) bool {
+	return targetSizeMB <= 0 && opts.Transition.Style != TransitionCrossfade
+}
+
+// ConcatClipsSegmented writes the same reel as ConcatClipsWithEncode, but
+// encodes each clip to a segment of its own in segmentFolder (see
+// SegmentFolder) and joins the segments with a stream copy. The segments
+// are kept with a record of what each was encoded from (the clip's size and
+// modification time, and the reel settings that apply to it), so rebuilding
+// the reel after re-extracting a few clips only encodes those again. Every
+// segment of a reel must come from the same encoder to be joined, so when
+// NVENC fails partway, segments it wrote are encoded again on the CPU.
+// progress (if not nil) is called before each clip is encoded, with its
+// number among those encoded, how many are, and how many were reused. Use
+// only when CanSegmentReel.
+func (f *FFmpeg) ConcatClipsSegmented(inputPaths []string, outputPath, segmentFolder, crf string, forceCPU bool, opts ReelOptions, progress func(n, total, reused int)) (SegmentStats, error) {
+	if len(inputPaths) == 0 {
+		return SegmentStats{}, fmt.Errorf("no clips to combine")
+	}
+	if err := os.MkdirAll(segmentFolder, 0755); err != nil {
+		return SegmentStats{}, fmt.Errorf("failed to create segment folder: %w", err)
+	}
+	hideFolder(segmentFolder)
+
+	previous := make(map[string]reelSegment)
+	if data, err := os.ReadFile(filepath.Join(segmentFolder, segmentRecordName)); err == nil {
+		var record []reelSegment
+		if json.Unmarshal(data, &record) == nil {
+			for _, seg := range record {
+				previous[seg.Key] = seg
+			}
+		}
+	}
+
+	// Tags go on the joined reel
+	segOpts := opts
+	segOpts.Tags = Tags{}
+	segments := make([]reelSegment, len(inputPaths))
+	ready := make([]bool, len(inputPaths)) // Segments in the record
+	reused := make(map[int]bool)
+	var todo []int
+	for i, path := range inputPaths {
+		key := f.segmentKey(path, crf, forceCPU, segOpts)
+		if seg, ok := previous[key]; ok && fileExists(filepath.Join(segmentFolder, seg.File)) {
+			segments[i], ready[i], reused[i] = seg, true, true
+			continue
+		}
+		segments[i] = reelSegment{Key: key, File: key[:16] + ".mp4"}
+		todo = append(todo, i)
+	}
+
+	// Each clip is counted once, even if encoded again for the CPU
+	encoded := make(map[int]bool)
+	count := func() SegmentStats {
+		return SegmentStats{Encoded: len(encoded), Reused: len(reused)}
+	}
+	encodes, total := 0, len(todo)
+	encode := func(i int, cpu bool) error {
+		encodes++
+		if progress != nil {
+			progress(encodes, total, len(reused))
+		}
+		seg := &segments[i]
+		err := f.WriteOutput(filepath.Join(segmentFolder, seg.File), func(path string) error {
+			var err error
+			seg.CPU, err = f.concatClipsWithEncode([]string{inputPaths[i]}, path, crf, cpu, 0, segOpts)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		ready[i], encoded[i] = true, true
+		delete(reused, i)
+		return f.saveSegmentRecord(segmentFolder, segments, ready)
+	}
+	for _, i := range todo {
+		if err := encode(i, forceCPU); err != nil {
+			return count(), err
+		}
+	}
+
+	// Segments from both encoders can't be joined: move them all to the CPU
+	mixed := false
+	for _, seg := range segments {
+		if seg.CPU != segments[0].CPU {
+			mixed = true
+		}
+	}
+	if mixed {
+		for _, seg := range segments {
+			if !seg.CPU {
+				total++
+			}
+		}
+		for i, seg := range segments {
+			if !seg.CPU {
+				if err := encode(i, true); err != nil {
+					return count(), err
+				}
+			}
+		}
+	}
+
+	paths := make([]string, len(segments))
+	for i, seg := range segments {
+		paths[i] = filepath.Join(segmentFolder, seg.File)
+	}
+	if err := f.ConcatClips(paths, outputPath, opts.Tags); err != nil {
+		return count(), err
+	}
+	f.pruneSegments(segmentFolder, segments)
+	return count(), nil
+}
+
+// segmentKey identifies what a clip's segment is encoded from: the clip file
+// as it is now, and everything about the reel's encode that shows in it
+func (f *FFmpeg) segmentKey(path, crf string, forceCPU bool, opts ReelOptions) string {
+	// The filter for this clip alone holds its scoreboard, counter, sound
+	// treatment, conform format and logo placement
+	parts := []string{
+		fileStamp(path),
+		buildReelFilter([]string{path}, opts, 2),
+		fmt.Sprintf("crf=%s cpu=%t transition=%s/%g", crf, forceCPU, opts.Transition.Style, opts.Transition.Duration),
+		fmt.Sprintf("titles=%q", opts.ChapterTitles[path]),
+		fmt.Sprintf("args=%q", f.PresetArgs(PresetReel)),
+	}
+	if opts.Watermark != nil && opts.Watermark.ImagePath != "" {
+		parts = append(parts, fileStamp(opts.Watermark.ImagePath))
+	}
+	if opts.Audio[path] == AudioMusic && opts.MusicPath != "" {
+		parts = append(parts, fileStamp(opts.MusicPath))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// fileStamp identifies a file's contents by its path, size and modification time
+func fileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return path + " missing"
+	}
+	return fmt.Sprintf("%s %d %d", path, info.Size(), info.ModTime().UnixNano())
+}
+
+// fileExists returns true if path is a file that can be read
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// saveSegmentRecord writes what the reel's ready segments were encoded from
+func (f *FFmpeg) saveSegmentRecord(segmentFolder string, segments []reelSegment, ready []bool) error {
+	var record []reelSegment
+	for i, seg := range segments {
+		if ready[i] {
+			record = append(record, seg)
+		}
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode segment record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(segmentFolder, segmentRecordName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write segment record: %w", err)
+	}
+	return nil
+}
+
+// pruneSegments deletes the segments in segmentFolder the reel no longer uses
+func (f *FFmpeg) pruneSegments(segmentFolder string, segments []reelSegment) {
+	used := map[string]bool{segmentRecordName: true}
+	for _, seg := range segments {
+		used[seg.File] = true
+	}
+	entries, err := os.ReadDir(segmentFolder)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !used[entry.Name()] {
+			os.Remove(filepath.Join(segmentFolder, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RemuxToMP4 stream copies a clip's first video and audio streams into an MP4,
+// keeping its chapters and metadata. Nothing is re-encoded, so codecs the MP4
+// container can't hold (e.g. DNxHR or PCM audio) fail.
+func (f *FFmpeg) RemuxToMP4(inputPath, outputPath string) error {
+	args := []string{
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-map_metadata", "0",
+		"-map_chapters", "0",
+		"-c", "copy",
+	}
+	args = append(args, f.codecTagArgs(inputPath)...)
+	args = append(args, "-movflags", "+faststart", "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("can't stream copy %s into an MP4 (re-encode the reel instead): %s", filepath.Base(inputPath), stderr.String())
+	}
+
+	return nil
+}
+
+// mixedContainers returns true if the clips aren't all in the same container
+// (e.g. .mp4 clips re-encoded in Step 2 and .mov clips stream copied)
+func mixedContainers(paths []string) bool {
+	for _, path := range paths[1:] {
+		if !strings.EqualFold(filepath.Ext(path), filepath.Ext(paths[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// concatInputs returns the clips to give the concat demuxer for a stream
+// copy join. When the containers are mixed, the MOV clips are remuxed to
+// temporary MP4s first, since the demuxer needs every part to be alike;
+// cleanup removes them.
+func (f *FFmpeg) concatInputs(paths []string) (inputs []string, cleanup func(), err error) {
+	if len(paths) == 0 || !mixedContainers(paths) {
+		return paths, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp(f.TempDir(), "ffmpeg-remux-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create remux folder: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	inputs = make([]string, len(paths))
+	for i, path := range paths {
+		if !strings.EqualFold(filepath.Ext(path), ".mov") {
+			inputs[i] = path
+			continue
+		}
+		inputs[i] = filepath.Join(dir, fmt.Sprintf("%03d_%s.mp4", i, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))))
+		if err := f.RemuxToMP4(path, inputs[i]); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	return inputs, cleanup, nil
+}
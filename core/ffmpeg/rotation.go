@@ -0,0 +1,192 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Orientation is how a source video has to be turned to play upright, as
+// recorded in its rotation flag, and whether it is interlaced
+type Orientation struct {
+	Rotation   int  // Degrees clockwise (0, 90, 180 or 270)
+	Interlaced bool // Field order other than progressive
+}
+
+// GetOrientation reads a video's rotation flag (display matrix side data, or
+// the older "rotate" tag) and field order. Results are cached per file.
+func (f *FFmpeg) GetOrientation(videoPath string) (Orientation, error) {
+	f.rotationMu.Lock()
+	cached, ok := f.orientations[videoPath]
+	f.rotationMu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=field_order:stream_side_data=rotation:stream_tags=rotate",
+		"-of", "default=nw=1",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return Orientation{}, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	var o Orientation
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "rotation":
+			// Display matrix rotation is counter-clockwise
+			if deg, err := strconv.ParseFloat(value, 64); err == nil {
+				o.Rotation = normalizeRotation(-int(math.Round(deg)))
+			}
+		case "TAG:rotate":
+			// The older tag is clockwise; the side data wins if both are present
+			if deg, err := strconv.Atoi(value); err == nil && o.Rotation == 0 {
+				o.Rotation = normalizeRotation(deg)
+			}
+		case "field_order":
+			o.Interlaced = value != "progressive" && value != "unknown" && value != ""
+		}
+	}
+
+	f.rotationMu.Lock()
+	if f.orientations == nil {
+		f.orientations = make(map[string]Orientation)
+	}
+	f.orientations[videoPath] = o
+	f.rotationMu.Unlock()
+
+	return o, nil
+}
+
+// normalizeRotation rounds degrees to a quarter turn in 0-270
+func normalizeRotation(deg int) int {
+	deg = int(math.Round(float64(deg)/90)) * 90
+	return ((deg % 360) + 360) % 360
+}
+
+// SetRotationOverride sets how many degrees clockwise videoPath is turned in
+// extracted clips, replacing its rotation flag (e.g. for a camera mounted
+// upside down that didn't record it). A negative value removes the override.
+func (f *FFmpeg) SetRotationOverride(videoPath string, clockwise int) {
+	f.rotationMu.Lock()
+	defer f.rotationMu.Unlock()
+
+	if clockwise < 0 {
+		delete(f.rotationOverrides, videoPath)
+		return
+	}
+	if f.rotationOverrides == nil {
+		f.rotationOverrides = make(map[string]int)
+	}
+	f.rotationOverrides[videoPath] = normalizeRotation(clockwise)
+}
+
+// ClearRotationOverrides removes all rotation overrides
+func (f *FFmpeg) ClearRotationOverrides() {
+	f.rotationMu.Lock()
+	f.rotationOverrides = nil
+	f.rotationMu.Unlock()
+}
+
+// rotationOverride returns the override for videoPath, if one is set
+func (f *FFmpeg) rotationOverride(videoPath string) (int, bool) {
+	f.rotationMu.Lock()
+	defer f.rotationMu.Unlock()
+	deg, ok := f.rotationOverrides[videoPath]
+	return deg, ok
+}
+
+// sourceInputArgs returns input options for re-encoding videoPath. With an
+// override, ffmpeg's automatic rotation from the flag is turned off so
+// sourceFilter can apply the override instead.
+func (f *FFmpeg) sourceInputArgs(videoPath string) []string {
+	if _, ok := f.rotationOverride(videoPath); ok {
+		return []string{"-noautorotate"}
+	}
+	return nil
+}
+
+// sourceFilter returns the filters that make videoPath's frames progressive,
+// upright and (for HDR in tone-map mode) SDR when re-encoding, or "" if there
+// is nothing to do. Without an override, ffmpeg already rotates by the flag on
+// its own.
+func (f *FFmpeg) sourceFilter(videoPath string) string {
+	var filters []string
+	if o, err := f.GetOrientation(videoPath); err == nil && o.Interlaced {
+		filters = append(filters, "yadif")
+	}
+	if deg, ok := f.rotationOverride(videoPath); ok {
+		if rotate := rotationFilter(deg); rotate != "" {
+			filters = append(filters, rotate)
+		}
+	}
+	if toneMap := f.hdrFilter(videoPath); toneMap != "" {
+		filters = append(filters, toneMap)
+	}
+	return strings.Join(filters, ",")
+}
+
+// rotationFilter returns the filter that turns frames clockwise by deg
+func rotationFilter(deg int) string {
+	switch normalizeRotation(deg) {
+	case 90:
+		return "transpose=clock"
+	case 180:
+		return "hflip,vflip"
+	case 270:
+		return "transpose=cclock"
+	}
+	return ""
+}
+
+// streamCopyRotationArgs returns the input and output options that give a
+// stream copy of videoPath the right rotation flag. Stream copy can't turn
+// the frames, so the flag is set explicitly: the override if there is one,
+// otherwise the source's own flag (which the concat demuxer can drop).
+func (f *FFmpeg) streamCopyRotationArgs(videoPath string) (inputArgs, outputArgs []string) {
+	deg, override := f.rotationOverride(videoPath)
+	if !override {
+		o, err := f.GetOrientation(videoPath)
+		if err != nil || o.Rotation == 0 {
+			return nil, nil
+		}
+		deg = o.Rotation
+	}
+
+	if f.HasOption("display_rotation") {
+		// ffmpeg 6.1+: sets the display matrix; counter-clockwise
+		return []string{"-display_rotation:v:0", strconv.Itoa((360 - deg) % 360)}, nil
+	}
+	// Older builds: the mov muxer turns the clockwise rotate tag into the matrix
+	return nil, []string{"-metadata:s:v:0", "rotate=" + strconv.Itoa(deg)}
+}
+
+// HasOption reports whether this ffmpeg build knows the named command line
+// option. The option list is read once and cached.
+func (f *FFmpeg) HasOption(name string) bool {
+	f.optionsOnce.Do(func() {
+		cmd := exec.Command(f.ffmpegPath, "-hide_banner", "-h", "long")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		if err := f.execute(cmd); err == nil {
+			f.options = stdout.String()
+		}
+	})
+	return strings.Contains(f.options, "-"+name+" ") || strings.Contains(f.options, "-"+name+"[")
+}
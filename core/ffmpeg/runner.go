@@ -0,0 +1,88 @@
+package ffmpeg
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// FFmpegRunner runs the ffmpeg and ffprobe commands an FFmpeg builds. The
+// default runs them for real; FakeRunner answers them with recorded output so
+// the analyzer, overlap logic and extraction can be exercised without the
+// binaries.
+type FFmpegRunner interface {
+	// Run runs cmd to completion like cmd.Run, writing to cmd.Stdout and
+	// cmd.Stderr if they are set
+	Run(cmd *exec.Cmd) error
+}
+
+// NewWithRunner creates an FFmpeg wrapper whose commands are run by runner,
+// with "ffmpeg" and "ffprobe" as the program names (nothing is looked up)
+func NewWithRunner(runner FFmpegRunner) *FFmpeg {
+	f := newFFmpeg("ffmpeg", "ffprobe")
+	f.runner = runner
+	return f
+}
+
+// SetRunner sets what runs the ffmpeg and ffprobe commands (nil = run them for real)
+func (f *FFmpeg) SetRunner(runner FFmpegRunner) {
+	f.runner = runner
+}
+
+// execute runs a command through the runner, below normal priority if set
+// (see SetLowPriority)
+func (f *FFmpeg) execute(cmd *exec.Cmd) error {
+	if f.runner != nil {
+		return f.runner.Run(cmd)
+	}
+	if err := f.start(cmd); err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
+// start starts a command, below normal priority if set
+func (f *FFmpeg) start(cmd *exec.Cmd) error {
+	if f.LowPriority() {
+		return startLowPriority(cmd)
+	}
+	return cmd.Start()
+}
+
+// errCancelled is returned for operations started after CancelExport
+var errCancelled = errors.New("operation cancelled")
+
+// executeCancellable runs an operation's command like execute, keeping it in
+// the running processes CancelExport kills. Nothing is started once the
+// operation is cancelled.
+func (f *FFmpeg) executeCancellable(cmd *exec.Cmd) error {
+	if f.runner != nil {
+		if f.IsCancelled() {
+			return errCancelled
+		}
+		return f.runner.Run(cmd)
+	}
+
+	// Started with runningMu held, so a cancel either sees the process or
+	// stops it from starting
+	f.runningMu.Lock()
+	if f.IsCancelled() {
+		f.runningMu.Unlock()
+		return errCancelled
+	}
+	if err := f.start(cmd); err != nil {
+		f.runningMu.Unlock()
+		return err
+	}
+	if f.running == nil {
+		f.running = make(map[*exec.Cmd]struct{})
+	}
+	f.running[cmd] = struct{}{}
+	f.runningMu.Unlock()
+
+	err := cmd.Wait()
+
+	f.runningMu.Lock()
+	delete(f.running, cmd)
+	f.runningMu.Unlock()
+	return err
+}
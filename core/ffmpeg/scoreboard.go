@@ -0,0 +1,97 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Scoreboard is a score overlay burned into the clips of a reel, updating
+// when the score changes during a clip
+type Scoreboard struct {
+	Corner   string                 // One of WatermarkCorners
+	FontFile string                 // "" = ffmpeg's default font
+	Clips    map[string][]ScoreText // Input path -> the scores shown over it (no entry = no scoreboard)
+}
+
+// ScoreText is scoreboard text shown from Start seconds into a clip until the
+// next ScoreText, or the end of the clip
+type ScoreText struct {
+	Start float64
+	Text  string
+}
+
+// fontCandidates are bold sans-serif fonts tried for the scoreboard, per OS.
+// Windows ffmpeg builds often can't find a font on their own.
+var fontCandidates = map[string][]string{
+	"windows": {`C:\Windows\Fonts\arialbd.ttf`, `C:\Windows\Fonts\segoeuib.ttf`, `C:\Windows\Fonts\arial.ttf`},
+	"darwin":  {"/System/Library/Fonts/Supplemental/Arial Bold.ttf", "/Library/Fonts/Arial Bold.ttf", "/System/Library/Fonts/Helvetica.ttc"},
+	"linux":   {"/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf", "/usr/share/fonts/dejavu/DejaVuSans-Bold.ttf", "/usr/share/fonts/TTF/DejaVuSans-Bold.ttf"},
+}
+
+// DefaultFontFile returns a font installed on this machine for the
+// scoreboard, or "" to leave it to ffmpeg
+func DefaultFontFile() string {
+	for _, path := range fontCandidates[runtime.GOOS] {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// drawFilter returns the drawtext filters (comma separated, to append to a
+// clip's filter chain) that show texts over the clip, sized for frames of the
+// given height
+func (s *Scoreboard) drawFilter(texts []ScoreText, height int) string {
+	x, y := textPosition(s.Corner)
+	style := textStyle(s.FontFile, height)
+
+	var filters []string
+	for i, text := range texts {
+		enable := fmt.Sprintf("gte(t\\,%.3f)", text.Start)
+		if i+1 < len(texts) {
+			enable = fmt.Sprintf("gte(t\\,%.3f)*lt(t\\,%.3f)", text.Start, texts[i+1].Start)
+		}
+		filters = append(filters, fmt.Sprintf("drawtext=text=%s:x=%s:y=%s:%s:enable=%s",
+			escapeFilterValue(text.Text), x, y, style, enable))
+	}
+	return strings.Join(filters, ",")
+}
+
+// textPosition returns the drawtext position of text in a corner (one of
+// WatermarkCorners), keeping a small margin (2% of the frame) from the edges
+// like the watermark
+func textPosition(corner string) (x, y string) {
+	x, y = "w*0.02", "h*0.02"
+	switch corner {
+	case "top-right":
+		x = "w-tw-w*0.02"
+	case "bottom-left":
+		y = "h-th-h*0.02"
+	case "bottom-right":
+		x = "w-tw-w*0.02"
+		y = "h-th-h*0.02"
+	}
+	return x, y
+}
+
+// textStyle returns the drawtext style of overlay text (white on a dark box)
+// for frames of the given height
+func textStyle(fontFile string, height int) string {
+	style := fmt.Sprintf("fontcolor=white:fontsize=%d:box=1:boxcolor=black@0.6:boxborderw=%d:expansion=none",
+		max(height*45/1000, 12), max(height/90, 4))
+	if fontFile != "" {
+		style += ":fontfile=" + escapeFilterValue(filepath.ToSlash(fontFile))
+	}
+	return style
+}
+
+// escapeFilterValue escapes a value for a filter option inside a
+// filter_complex: once for the option parser, then for the graph parser
+func escapeFilterValue(value string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(option)
+}
@@ -0,0 +1,129 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultRoughSeekWindow is used when the keyframe interval can't be probed.
+	// 60 seconds is always before a keyframe, even with GoPro's long GOPs.
+	defaultRoughSeekWindow = 60.0
+	// keyframeProbeSeconds is how much of the file is scanned for keyframes
+	keyframeProbeSeconds = 30
+	// roughSeekMargin is added to the probed keyframe interval for safety
+	roughSeekMargin = 1.0
+)
+
+// SetRoughSeekWindow sets how many seconds before the clip start the first
+// (keyframe) seek lands. 0 = automatic, based on the source's keyframe interval.
+func (f *FFmpeg) SetRoughSeekWindow(seconds float64) {
+	f.seekMu.Lock()
+	defer f.seekMu.Unlock()
+	if seconds < 0 {
+		seconds = 0
+	}
+	f.roughSeekWindow = seconds
+}
+
+// RoughSeekWindow returns the configured rough seek window (0 = automatic)
+func (f *FFmpeg) RoughSeekWindow() float64 {
+	f.seekMu.Lock()
+	defer f.seekMu.Unlock()
+	return f.roughSeekWindow
+}
+
+// seekPoints splits a clip start into the two-pass seek offsets: a rough input
+// seek far enough back to land before a keyframe, then an exact output seek.
+// In automatic mode the window is the source's longest keyframe interval (probed
+// once per file) plus a margin, so only a GOP's worth of video is decoded
+// instead of a full minute.
+func (f *FFmpeg) seekPoints(inputPath string, startSec float64) (roughSeek, fineSeek float64) {
+	window := f.RoughSeekWindow()
+	if window == 0 {
+		window = f.autoSeekWindow(inputPath)
+	}
+
+	roughSeek = startSec - window
+	if roughSeek < 0 {
+		roughSeek = 0
+	}
+	return roughSeek, startSec - roughSeek
+}
+
+// autoSeekWindow returns the rough seek window for a file from its keyframe interval
+func (f *FFmpeg) autoSeekWindow(inputPath string) float64 {
+	f.seekMu.Lock()
+	window, ok := f.seekWindows[inputPath]
+	f.seekMu.Unlock()
+	if ok {
+		return window
+	}
+
+	window = defaultRoughSeekWindow
+	if interval, err := f.KeyframeInterval(inputPath); err == nil && interval > 0 {
+		window = interval + roughSeekMargin
+		if window > defaultRoughSeekWindow {
+			window = defaultRoughSeekWindow
+		}
+	}
+
+	f.seekMu.Lock()
+	if f.seekWindows == nil {
+		f.seekWindows = make(map[string]float64)
+	}
+	f.seekWindows[inputPath] = window
+	f.seekMu.Unlock()
+
+	return window
+}
+
+// KeyframeInterval returns the longest gap in seconds between video keyframes in
+// the first part of a file. Only packet headers are read, so this is fast.
+func (f *FFmpeg) KeyframeInterval(videoPath string) (float64, error) {
+	cmd := exec.Command(f.ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-read_intervals", fmt.Sprintf("%%+%d", keyframeProbeSeconds),
+		"-show_entries", "packet=pts_time,flags",
+		"-of", "csv=p=0",
+		videoPath,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %s", stderr.String())
+	}
+
+	// Lines are "pts_time,flags" where flags contains K for keyframes
+	var keyframes []float64
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), ",")
+		if len(parts) < 2 || !strings.Contains(parts[1], "K") {
+			continue
+		}
+		pts, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			continue
+		}
+		keyframes = append(keyframes, pts)
+	}
+
+	if len(keyframes) < 2 {
+		return 0, fmt.Errorf("not enough keyframes found in %s", videoPath)
+	}
+
+	var longest float64
+	for i := 1; i < len(keyframes); i++ {
+		if gap := keyframes[i] - keyframes[i-1]; gap > longest {
+			longest = gap
+		}
+	}
+	return longest, nil
+}
@@ -0,0 +1,187 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Max360Ext is the extension of GoPro MAX 360° recordings
+const Max360Ext = ".360"
+
+// IsMax360 returns true for GoPro MAX .360 files
+func IsMax360(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), Max360Ext)
+}
+
+// SourceCheck describes a source video's codec and anything that will stop
+// it being cut into clips
+type SourceCheck struct {
+	Codec    string // e.g. "h264", "hevc"
+	Width    int
+	Height   int
+	FPS      float64 // Frames per second (0 if unknown)
+	TenBit   bool    // 10-bit video (GoPro HDR / 10-bit color modes)
+	Is360    bool    // GoPro MAX 360° footage
+	Rotation int     // Rotation flag, degrees clockwise
+	NoAudio  bool    // No audio stream (camera audio muted), so clips are silent
+	Warnings []string
+}
+
+// Label returns a short codec description for display, e.g. "HEVC 10-bit"
+func (c *SourceCheck) Label() string {
+	label := strings.ToUpper(c.Codec)
+	if c.Codec == "h264" {
+		label = "H.264"
+	}
+	if c.TenBit {
+		label += " 10-bit"
+	}
+	if c.Is360 {
+		label += " (360°)"
+	}
+	if c.Rotation != 0 {
+		label += fmt.Sprintf(", rotated %d°", c.Rotation)
+	}
+	if c.NoAudio {
+		label += ", no audio"
+	}
+	return label
+}
+
+// Format returns the resolution and frame rate for display, e.g.
+// "3840x2160 @ 59.94 fps"
+func (c *SourceCheck) Format() string {
+	format := fmt.Sprintf("%dx%d", c.Width, c.Height)
+	if c.FPS > 0 {
+		format += " @ " + strconv.FormatFloat(math.Round(c.FPS*100)/100, 'f', -1, 64) + " fps"
+	}
+	return format
+}
+
+// CheckSource probes a source video's codec and checks that this ffmpeg build
+// can decode it. GoPro MAX .360 files are flagged, as they must be reframed
+// to a flat video before clips can be cut from them, and so are files without
+// audio. A file without a video stream is an error.
+func (f *FFmpeg) CheckSource(path string) (*SourceCheck, error) {
+	info, err := f.GetStreamInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s can't be cut into clips: %w", filepath.Base(path), err)
+	}
+
+	check := &SourceCheck{
+		Codec:   info.VideoCodec,
+		Width:   info.Width,
+		Height:  info.Height,
+		FPS:     info.FPS(),
+		TenBit:  strings.Contains(info.PixFmt, "10"),
+		Is360:   IsMax360(path),
+		NoAudio: info.AudioCodec == "",
+	}
+	if o, err := f.GetOrientation(path); err == nil {
+		check.Rotation = o.Rotation
+	}
+
+	if !f.CanDecode(info.VideoCodec) {
+		check.Warnings = append(check.Warnings, fmt.Sprintf(
+			"this ffmpeg build can't decode %s video - install a full ffmpeg build (e.g. the gyan.dev \"full\" build on Windows)",
+			strings.ToUpper(info.VideoCodec)))
+	}
+	if check.Is360 {
+		check.Warnings = append(check.Warnings,
+			"360° footage can't be cut into flat clips directly - reframe it in GoPro Player or Quik, export it with the same "+
+				"name (e.g. GS010092.MOV) into this folder, and keep the .360 next to it for the HiLights and timecode")
+	}
+	if check.NoAudio {
+		check.Warnings = append(check.Warnings, check.SilentNotice(path))
+	}
+
+	return check, nil
+}
+
+// SilentNotice says that clips from a source without audio will be silent
+func (c *SourceCheck) SilentNotice(path string) string {
+	return fmt.Sprintf("%s has no audio (camera audio muted?) - its clips will be silent", filepath.Base(path))
+}
+
+// CanDecode reports whether this ffmpeg build has a decoder for codec.
+// The decoder list is read once and cached.
+func (f *FFmpeg) CanDecode(codec string) bool {
+	f.decodersOnce.Do(func() {
+		f.decoders = make(map[string]bool)
+
+		cmd := exec.Command(f.ffmpegPath, "-hide_banner", "-decoders")
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		if err := f.execute(cmd); err != nil {
+			f.decoders = nil // Unknown: don't report codecs as unsupported
+			return
+		}
+
+		// Lines look like " V....D hevc                 HEVC (High Efficiency Video Coding)"
+		listing := false
+		for _, line := range strings.Split(stdout.String(), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			if fields[0] == "------" {
+				listing = true
+				continue
+			}
+			if listing {
+				f.decoders[fields[1]] = true
+			}
+		}
+	})
+
+	if f.decoders == nil || codec == "" {
+		return true
+	}
+	return f.decoders[codec]
+}
+
+// codecTagArgs returns the codec tag to use when stream copying inputPath.
+// HEVC is tagged hvc1 so QuickTime, Premiere and Apple devices can play it
+// (ffmpeg defaults to hev1).
+func (f *FFmpeg) codecTagArgs(inputPath string) []string {
+	info, err := f.GetStreamInfo(inputPath)
+	if err != nil || info.VideoCodec != "hevc" {
+		return nil
+	}
+	return []string{"-tag:v", "hvc1"}
+}
+
+// sourceVideoArgs returns the encoder arguments for re-encoding a GoPro source
+// (rather than a YouTube clip), keeping its codec: HEVC sources stay HEVC, with
+// 10-bit color preserved, and everything else becomes H.264
+func sourceVideoArgs(ref *StreamInfo, nvenc bool) []string {
+	tenBit := ref != nil && strings.Contains(ref.PixFmt, "10")
+
+	if ref == nil || ref.VideoCodec != "hevc" {
+		if nvenc {
+			return []string{"-c:v", "h264_nvenc", "-preset", "p4", "-profile:v", "high", "-rc", "constqp", "-qp", "18", "-pix_fmt", "yuv420p"}
+		}
+		return []string{"-c:v", "libx264", "-preset", "medium", "-profile:v", "high", "-crf", "18", "-pix_fmt", "yuv420p"}
+	}
+
+	if nvenc {
+		pixFmt := "yuv420p"
+		profile := "main"
+		if tenBit {
+			pixFmt = "p010le"
+			profile = "main10"
+		}
+		return []string{"-c:v", "hevc_nvenc", "-preset", "p4", "-profile:v", profile, "-rc", "constqp", "-qp", "20", "-pix_fmt", pixFmt, "-tag:v", "hvc1"}
+	}
+
+	pixFmt := "yuv420p"
+	if tenBit {
+		pixFmt = "yuv420p10le"
+	}
+	return []string{"-c:v", "libx265", "-preset", "medium", "-crf", "20", "-pix_fmt", pixFmt, "-tag:v", "hvc1"}
+}
@@ -0,0 +1,221 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SpanSource describes a clip that runs past the end of its source file and
+// continues in the next GoPro chapter file (GX01xxxx -> GX02xxxx)
+type SpanSource struct {
+	FirstPath     string  // File the clip starts in
+	FirstDuration float64 // Duration of FirstPath in seconds
+	NextPath      string  // File the recording continues in
+}
+
+// headDuration returns how many seconds of NextPath the clip needs
+func (s SpanSource) headDuration(startSec, durationSec float64) float64 {
+	return startSec + durationSec - s.FirstDuration
+}
+
+// writeSpanChapterFile writes an FFMETADATA file with the clip's tags and chapter markers
+func (f *FFmpeg) writeSpanChapterFile(chapters []ClipChapter, tags Tags, durationSec float64) (string, error) {
+	metaFile, err := os.CreateTemp(f.TempDir(), "ffmpeg-clip-meta-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer metaFile.Close()
+
+	fmt.Fprintf(metaFile, ";FFMETADATA1\n")
+	tags.writeTo(metaFile, "")
+	fmt.Fprintf(metaFile, "\n")
+
+	durationMs := int64(durationSec * 1000)
+	for i, ch := range chapters {
+		endMs := durationMs
+		if i < len(chapters)-1 {
+			endMs = chapters[i+1].OffsetMs
+		}
+
+		fmt.Fprintf(metaFile, "[CHAPTER]\n")
+		fmt.Fprintf(metaFile, "TIMEBASE=1/1000\n")
+		fmt.Fprintf(metaFile, "START=%d\n", ch.OffsetMs)
+		fmt.Fprintf(metaFile, "END=%d\n", endMs)
+		fmt.Fprintf(metaFile, "title=%s\n\n", escapeMetadata(ch.Title))
+	}
+
+	return metaFile.Name(), nil
+}
+
+// ExtractClipSpanning extracts a clip that starts in src.FirstPath and continues
+// into src.NextPath. The tail of the first file and the head of the next are
+// trimmed and joined with the concat filter in a single encode, so the clip is
+// not truncated at the chapter file boundary.
+// Chapters, tags, watermark and color behave as in ExtractClipWithChapters (chapters may be nil).
+func (f *FFmpeg) ExtractClipSpanning(src SpanSource, outputPath string, startSec, durationSec float64, chapters []ClipChapter, tags Tags, watermark *Watermark, color *ColorCorrection) error {
+	metaPath, err := f.writeSpanChapterFile(chapters, tags, durationSec)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metaPath)
+
+	// Both files need audio to join it; otherwise the clip is silent
+	hasAudio := f.hasAudio(src.FirstPath) && f.hasAudio(src.NextPath)
+	args := f.spanningInputArgs(src, metaPath, startSec, durationSec, watermark, color, hasAudio)
+
+	// Try NVENC first, fall back to CPU
+	err = f.tryNVENC(func() error {
+		return f.extractClipSpanningNVENC(args, src.FirstPath, outputPath, hasAudio)
+	})
+	if err == nil {
+		return nil
+	}
+
+	return f.extractClipSpanningCPU(args, src.FirstPath, outputPath, hasAudio)
+}
+
+// spanningInputArgs builds the inputs, filter graph and mapping shared by the
+// NVENC and CPU spanning encodes
+func (f *FFmpeg) spanningInputArgs(src SpanSource, metaPath string, startSec, durationSec float64, watermark *Watermark, color *ColorCorrection, hasAudio bool) []string {
+	// Two-pass seeking into the first file, as in ExtractClip
+	roughSeek, fineSeek := f.seekPoints(src.FirstPath, startSec)
+
+	// Both files are from one recording, so share the first file's rotation override
+	sourceArgs := f.sourceInputArgs(src.FirstPath)
+	args := append(append([]string{}, sourceArgs...),
+		"-ss", fmt.Sprintf("%.3f", roughSeek),
+		"-i", src.FirstPath,
+		"-t", fmt.Sprintf("%.3f", src.headDuration(startSec, durationSec)),
+	)
+	args = append(args, sourceArgs...)
+	args = append(args,
+		"-i", src.NextPath,
+		"-i", metaPath,
+	)
+
+	// Inputs: 0 = first file, 1 = next file, 2 = chapters, 3 = logo
+	post := clipVideoFilters("joined", f.sourceFilter(src.FirstPath), color, watermark, 3)
+	concatOut := "outv"
+	if post != nil {
+		concatOut = "joined"
+	}
+	if watermark != nil && watermark.ImagePath != "" {
+		args = append(args, "-i", watermark.ImagePath)
+	}
+
+	var filters []string
+	maps := []string{"-map", "[outv]"}
+	if hasAudio {
+		filters = []string{
+			fmt.Sprintf("[0:v]trim=start=%.3f,setpts=PTS-STARTPTS[v0]", fineSeek),
+			fmt.Sprintf("[%s]atrim=start=%.3f,asetpts=PTS-STARTPTS[a0]", f.audioStream("0", src.FirstPath), fineSeek),
+			"[1:v]setpts=PTS-STARTPTS[v1]",
+			fmt.Sprintf("[%s]asetpts=PTS-STARTPTS[a1]", f.audioStream("1", src.NextPath)),
+			fmt.Sprintf("[v0][a0][v1][a1]concat=n=2:v=1:a=1[%s][outa]", concatOut),
+		}
+		maps = append(maps, "-map", "[outa]")
+	} else {
+		filters = []string{
+			fmt.Sprintf("[0:v]trim=start=%.3f,setpts=PTS-STARTPTS[v0]", fineSeek),
+			"[1:v]setpts=PTS-STARTPTS[v1]",
+			fmt.Sprintf("[v0][v1]concat=n=2:v=1:a=0[%s]", concatOut),
+		}
+	}
+	filters = append(filters, post...)
+
+	args = append(args, "-filter_complex", strings.Join(filters, ";"))
+	args = append(args, maps...)
+	args = append(args,
+		"-map_metadata", "2",
+		"-map_chapters", "2",
+		"-t", fmt.Sprintf("%.3f", durationSec),
+	)
+	return args
+}
+
+// The encoder is picked from firstPath, as both files share its colors
+func (f *FFmpeg) extractClipSpanningNVENC(inputArgs []string, firstPath, outputPath string, hasAudio bool) error {
+	args := append(append([]string{}, inputArgs...), f.clipEncoderArgs(firstPath, true)...)
+	args = append(args, clipAudioArgs(hasAudio)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("nvenc failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+func (f *FFmpeg) extractClipSpanningCPU(inputArgs []string, firstPath, outputPath string, hasAudio bool) error {
+	args := append(append([]string{}, inputArgs...), f.clipEncoderArgs(firstPath, false)...)
+	args = append(args, clipAudioArgs(hasAudio)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("cpu extract failed: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// ExtractClipStreamCopySpanning is the stream copy version of ExtractClipSpanning.
+// It uses the concat demuxer with in/out points, so like ExtractClipStreamCopy the
+// clip starts on the nearest keyframe rather than the exact frame.
+func (f *FFmpeg) ExtractClipStreamCopySpanning(src SpanSource, outputPath string, startSec, durationSec float64, chapters []ClipChapter, tags Tags) error {
+	metaPath, err := f.writeSpanChapterFile(chapters, tags, durationSec)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metaPath)
+
+	concatList, err := f.writeConcatList([]concatEntry{
+		{path: src.FirstPath, inpoint: startSec},
+		{path: src.NextPath, outpoint: src.headDuration(startSec, durationSec)},
+	})
+	if err != nil {
+		return err
+	}
+	defer os.Remove(concatList)
+
+	rotateInput, rotateOutput := f.streamCopyRotationArgs(src.FirstPath)
+
+	args := append(rotateInput,
+		"-f", "concat",
+		"-safe", "0",
+		"-i", concatList,
+		"-i", metaPath,
+		"-map", "0:v",
+	)
+	args = append(args, audioMapArgs(f.audioStream("0", src.FirstPath), f.hasAudio(src.FirstPath) && f.hasAudio(src.NextPath))...)
+	args = append(args,
+		"-map_metadata", "1",
+		"-map_chapters", "1",
+		"-c", "copy",
+	)
+	args = append(args, f.codecTagArgs(src.FirstPath)...)
+	args = append(args, rotateOutput...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg stream copy across chapter files failed: %s", stderr.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// splitTimecodeTolerance is how far a chapter file's timecode may be from
+	// the end of the previous one before the parts are reported as not continuous
+	splitTimecodeTolerance = 1.0
+	// duplicateChapterMs is how close two HiLights must be to count as the same one
+	duplicateChapterMs = 100
+)
+
+// SplitSegment is one chapter file of a split GoPro recording and where it
+// lands in the combined file
+type SplitSegment struct {
+	Path     string
+	Duration float64 // Seconds, as the concat demuxer measures it (container duration)
+	Offset   float64 // Seconds from the start of the combined file
+	Timecode string  // Camera timecode of the first frame ("" if none)
+}
+
+// probeSplitSegments reads each chapter file's duration and timecode and
+// places the files end to end
+func (f *FFmpeg) probeSplitSegments(inputPaths []string) ([]SplitSegment, error) {
+	var segments []SplitSegment
+	var offset float64
+
+	for _, inputPath := range inputPaths {
+		dur, err := f.GetDuration(inputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get duration of %s: %w", inputPath, err)
+		}
+
+		timecode, err := f.GetTimecode(inputPath)
+		if err != nil {
+			timecode, _ = f.GetTimecodeFromVideo(inputPath)
+		}
+
+		segments = append(segments, SplitSegment{
+			Path:     inputPath,
+			Duration: dur,
+			Offset:   offset,
+			Timecode: timecode,
+		})
+		offset += dur
+	}
+
+	return segments, nil
+}
+
+// CheckSplitSegments compares where each chapter file starts by timecode with
+// where it lands when the files are joined end to end. They disagree when a
+// part is missing or belongs to another recording, in which case clock times
+// after the join would be wrong. Returns one warning per disagreement.
+func (f *FFmpeg) CheckSplitSegments(inputPaths []string) ([]string, error) {
+	segments, err := f.probeSplitSegments(inputPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for i := 1; i < len(segments); i++ {
+		first, curr := segments[0], segments[i]
+		gap, ok := timecodeOffset(first.Timecode, curr.Timecode)
+		if !ok {
+			continue
+		}
+
+		drift := gap - curr.Offset
+		if math.Abs(drift) <= splitTimecodeTolerance {
+			continue
+		}
+		if drift > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s starts %.1fs after the end of the previous part by timecode - a part may be missing, so clock times after it will be %.1fs early",
+				filepath.Base(curr.Path), drift, drift))
+		} else {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s starts %.1fs before the end of the previous part by timecode - check it belongs to this recording",
+				filepath.Base(curr.Path), -drift))
+		}
+	}
+
+	return warnings, nil
+}
+
+// timecodeOffset returns how many seconds after from the timecode to is,
+// allowing for recordings that run past midnight
+func timecodeOffset(from, to string) (float64, bool) {
+	if from == "" || to == "" {
+		return 0, false
+	}
+	start, err := ParseTimecode(from)
+	if err != nil {
+		return 0, false
+	}
+	end, err := ParseTimecode(to)
+	if err != nil {
+		return 0, false
+	}
+
+	offset := end - start
+	if offset < 0 {
+		offset += 24 * 3600
+	}
+	return offset, true
+}
+
+// mergeSegmentChapters places each chapter file's HiLights in the combined
+// file. Chapters are normally measured from the start of their own file and
+// are shifted by the durations of the files before it. Some firmware and
+// tools instead write them from the start of the recording; a file whose
+// chapters run past its own end but all fall within its place in the
+// recording is taken as already offset. HiLights repeated across files are
+// kept once.
+func mergeSegmentChapters(segments []SplitSegment, perSegment [][]ChapterInfo) []ChapterInfo {
+	var merged []ChapterInfo
+
+	for i, seg := range segments {
+		if i >= len(perSegment) {
+			break
+		}
+		chapters := perSegment[i]
+
+		offsetMs := int64(seg.Offset * 1000)
+		if i > 0 && chaptersAlreadyOffset(seg, chapters) {
+			offsetMs = 0
+		}
+
+		for _, ch := range chapters {
+			placed := ChapterInfo{
+				StartMs: ch.StartMs + offsetMs,
+				EndMs:   ch.EndMs + offsetMs,
+				Title:   ch.Title,
+			}
+			if placed.EndMs < placed.StartMs {
+				placed.EndMs = placed.StartMs
+			}
+			if !hasChapterNear(merged, placed.StartMs) {
+				merged = append(merged, placed)
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].StartMs < merged[j].StartMs
+	})
+	return merged
+}
+
+// chaptersAlreadyOffset reports whether a chapter file's chapters are
+// measured from the start of the recording rather than the file
+func chaptersAlreadyOffset(seg SplitSegment, chapters []ChapterInfo) bool {
+	durationMs := int64(seg.Duration * 1000)
+	startMs := int64(seg.Offset * 1000)
+
+	pastEnd := false
+	for _, ch := range chapters {
+		if ch.StartMs < startMs-duplicateChapterMs || ch.StartMs > startMs+durationMs+duplicateChapterMs {
+			return false
+		}
+		if ch.StartMs > durationMs {
+			pastEnd = true
+		}
+	}
+	return pastEnd
+}
+
+// hasChapterNear reports whether chapters has one starting within
+// duplicateChapterMs of startMs
+func hasChapterNear(chapters []ChapterInfo, startMs int64) bool {
+	for _, ch := range chapters {
+		diff := ch.StartMs - startMs
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= duplicateChapterMs {
+			return true
+		}
+	}
+	return false
+}
+
+// timecodeArgs returns the output option that gives a combined file the
+// first chapter file's timecode, so it is analyzed as one recording
+func timecodeArgs(segments []SplitSegment) []string {
+	if len(segments) == 0 || segments[0].Timecode == "" {
+		return nil
+	}
+	return []string{"-timecode", segments[0].Timecode}
+}
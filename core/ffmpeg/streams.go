@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Sources recorded with the camera's audio muted have no audio stream. Clips
+// from them are cut without audio rather than failing on "-map 0:a".
+
+// hasAudio reports whether a source has an audio stream. A source that can't be
+// probed is taken to have one, so ffmpeg reports what is actually wrong with it.
+func (f *FFmpeg) hasAudio(path string) bool {
+	info, err := f.GetStreamInfo(path)
+	return err != nil || info.AudioCodec != ""
+}
+
+// audioMapArgs maps an audio stream (e.g. "0:a:0", see audioStream), or
+// nothing if the source has no audio
+func audioMapArgs(stream string, hasAudio bool) []string {
+	if !hasAudio {
+		return nil
+	}
+	return []string{"-map", stream}
+}
+
+// clipAudioArgs encodes a clip's audio as AAC at 48kHz (YouTube recommended),
+// or leaves the clip silent if the source has no audio
+func clipAudioArgs(hasAudio bool) []string {
+	if !hasAudio {
+		return []string{"-an"}
+	}
+	return []string{"-c:a", "aac", "-ar", "48000", "-b:a", "192k"}
+}
+
+// audioCopyContainers are the output formats AAC audio can be copied into
+var audioCopyContainers = map[string]bool{".mp4": true, ".mov": true, ".m4v": true, ".mkv": true}
+
+// SetAudioCopy turns copying the source's audio into re-encoded clips on or
+// off. When on, AAC audio (as GoPros record it) is copied as it is instead of
+// being encoded again at 192k, which keeps its quality and saves a little time;
+// other codecs, and outputs that can't hold AAC, are still encoded.
+func (f *FFmpeg) SetAudioCopy(enabled bool) {
+	f.encoderMu.Lock()
+	f.audioCopy = enabled
+	f.encoderMu.Unlock()
+}
+
+// AudioCopy returns true if AAC audio is copied into re-encoded clips
+func (f *FFmpeg) AudioCopy() bool {
+	f.encoderMu.Lock()
+	defer f.encoderMu.Unlock()
+	return f.audioCopy
+}
+
+// sourceAudioArgs returns the audio arguments for a re-encode whose audio is
+// passed through unfiltered: a copy of inputPath's selected audio track when
+// audio copy is on and it is AAC going into a container that can hold it,
+// otherwise as clipAudioArgs
+func (f *FFmpeg) sourceAudioArgs(inputPath, outputPath string, hasAudio bool) []string {
+	if !hasAudio || !f.AudioCopy() || !audioCopyContainers[strings.ToLower(filepath.Ext(outputPath))] {
+		return clipAudioArgs(hasAudio)
+	}
+	if track, ok := f.sourceAudioTrack(inputPath); !ok || track.Codec != "aac" {
+		return clipAudioArgs(hasAudio)
+	}
+	return []string{"-c:a", "copy"}
+}
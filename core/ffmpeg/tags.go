@@ -0,0 +1,110 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tags are the global metadata tags written into a clip or reel ("title",
+// "date", "artist", "comment", ...), which media libraries like Plex use to
+// list the files. Empty values are left out.
+type Tags map[string]string
+
+// WithCreationTime returns a copy of the tags with the MP4 creation_time set
+// to at, which photo libraries sort videos by. The zero time leaves it out, so
+// the output gets ffmpeg's default.
+func (t Tags) WithCreationTime(at time.Time) Tags {
+	tags := make(Tags, len(t)+1)
+	for key, value := range t {
+		tags[key] = value
+	}
+	if !at.IsZero() {
+		tags["creation_time"] = at.UTC().Format("2006-01-02T15:04:05.000000Z")
+	}
+	return tags
+}
+
+// SetFileTime sets a written file's modification (and access) time to at,
+// e.g. to when its footage was recorded. The zero time leaves it as it is.
+func SetFileTime(path string, at time.Time) error {
+	if at.IsZero() {
+		return nil
+	}
+	if err := os.Chtimes(path, at, at); err != nil {
+		return fmt.Errorf("failed to set the file time of %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeTo writes the tags as global lines of an FFMETADATA file, before its
+// first [CHAPTER] section. defaultTitle is written if the tags have no title
+// ("" = none).
+func (t Tags) writeTo(w io.Writer, defaultTitle string) {
+	if t["title"] == "" && defaultTitle != "" {
+		fmt.Fprintf(w, "title=%s\n", escapeMetadata(defaultTitle))
+	}
+
+	keys := make([]string, 0, len(t))
+	for key, value := range t {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s=%s\n", key, escapeMetadata(t[key]))
+	}
+}
+
+// metadataEscaper escapes the characters FFMETADATA files treat specially
+var metadataEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"=", `\=`,
+	";", `\;`,
+	"#", `\#`,
+	"\n", "\\\n",
+)
+
+// escapeMetadata escapes a value for an FFMETADATA file
+func escapeMetadata(value string) string {
+	return metadataEscaper.Replace(value)
+}
+
+// RetagClip copies a clip to outputPath with its streams, chapters and other
+// metadata as they are but the given tags set (an empty value removes the tag),
+// e.g. to put a note typed after extracting into its title without re-encoding
+func (f *FFmpeg) RetagClip(videoPath, outputPath string, tags Tags) error {
+	args := []string{
+		"-i", videoPath,
+		"-map", "0:v",
+		"-map", "0:a?",
+		"-map_metadata", "0",
+		"-map_chapters", "0",
+		"-c", "copy",
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-metadata", key+"="+tags[key])
+	}
+	args = append(args, f.codecTagArgs(videoPath)...)
+	args = append(args, "-y", outputPath)
+
+	cmd := f.command(args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.run(cmd); err != nil {
+		return fmt.Errorf("ffmpeg retag failed: %s", stderr.String())
+	}
+	return nil
+}
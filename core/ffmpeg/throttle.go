@@ -0,0 +1,49 @@
+package ffmpeg
+
+import "strconv"
+
+// SetThreads limits how many threads ffmpeg's decoders, filters and encoders
+// each use (0 = ffmpeg's default, about one per core), so an encode leaves
+// the computer usable. NVENC encodes hardly use the CPU either way.
+func (f *FFmpeg) SetThreads(threads int) {
+	f.throttleMu.Lock()
+	f.threads = max(threads, 0)
+	f.throttleMu.Unlock()
+}
+
+// Threads returns the thread limit set with SetThreads (0 = none)
+func (f *FFmpeg) Threads() int {
+	f.throttleMu.Lock()
+	defer f.throttleMu.Unlock()
+	return f.threads
+}
+
+// SetLowPriority runs ffmpeg and ffprobe below normal priority (nice 10 on
+// Unix, "below normal" on Windows), so other programs get the CPU first
+func (f *FFmpeg) SetLowPriority(enabled bool) {
+	f.throttleMu.Lock()
+	f.lowPriority = enabled
+	f.throttleMu.Unlock()
+}
+
+// LowPriority returns true if commands run below normal priority
+func (f *FFmpeg) LowPriority() bool {
+	f.throttleMu.Lock()
+	defer f.throttleMu.Unlock()
+	return f.lowPriority
+}
+
+// threadArgs adds a thread limit to an ffmpeg command line: for the filter
+// graphs (global options, first), each input's decoder (before its -i) and
+// each output's encoders (before the -y or "-f null" that precedes it)
+func threadArgs(args []string, threads int) []string {
+	n := strconv.Itoa(threads)
+	limited := []string{"-filter_threads", n, "-filter_complex_threads", n}
+	for i, arg := range args {
+		if arg == "-i" || arg == "-y" || (arg == "-f" && i+1 < len(args) && args[i+1] == "null") {
+			limited = append(limited, "-threads", n)
+		}
+		limited = append(limited, arg)
+	}
+	return limited
+}
@@ -0,0 +1,71 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractFrame saves a single frame at atSec as a JPEG thumbnail, width pixels
+// wide (height keeps the aspect ratio).
+// Runs directly rather than through the command log since thumbnails are
+// generated in the background, not as part of an extract/combine/export.
+func (f *FFmpeg) ExtractFrame(inputPath, outputPath string, atSec float64, width int) error {
+	return f.extractFrame(inputPath, outputPath, atSec, width, "")
+}
+
+// ExtractCorrectedFrame is ExtractFrame with a color correction applied, used
+// to preview a period's grade before extracting clips
+func (f *FFmpeg) ExtractCorrectedFrame(inputPath, outputPath string, atSec float64, width int, color *ColorCorrection) error {
+	return f.extractFrame(inputPath, outputPath, atSec, width, color.filterChain())
+}
+
+// extractFrame saves one frame, running filters (may be empty) before scaling
+func (f *FFmpeg) extractFrame(inputPath, outputPath string, atSec float64, width int, filters string) error {
+	if atSec < 0 {
+		atSec = 0
+	}
+
+	args := append(f.sourceInputArgs(inputPath),
+		"-ss", fmt.Sprintf("%.3f", atSec),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", f.frameFilter(inputPath, filters, fmt.Sprintf("scale=%d:-2", width)),
+		"-q:v", "4",
+		"-f", "image2",
+		"-y",
+		outputPath,
+	)
+	cmd := exec.Command(f.ffmpegPath, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := f.execute(cmd); err != nil {
+		return fmt.Errorf("failed to extract frame from %s: %s", filepath.Base(inputPath), stderr.String())
+	}
+
+	return nil
+}
+
+// frameFilter returns the filter chain for a still frame: the source's
+// rotation/deinterlace (so the frame is turned the way clips are), filters (may
+// be empty), then last (may be empty)
+func (f *FFmpeg) frameFilter(inputPath, filters, last string) string {
+	var chain []string
+	if source := f.sourceFilter(inputPath); source != "" {
+		chain = append(chain, source)
+	}
+	if filters != "" {
+		chain = append(chain, filters)
+	}
+	if last != "" {
+		chain = append(chain, last)
+	}
+	if len(chain) == 0 {
+		return "null"
+	}
+	return strings.Join(chain, ",")
+}
@@ -0,0 +1,123 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transition styles for a re-encoded reel
+const (
+	TransitionCut       = "cut"       // Hard cuts between clips
+	TransitionFade      = "fade"      // Each clip fades in from and out to black and silence
+	TransitionCrossfade = "crossfade" // Each clip blends into the next (xfade/acrossfade)
+)
+
+// TransitionStyles lists the transition styles, default first
+var TransitionStyles = []string{TransitionCut, TransitionFade, TransitionCrossfade}
+
+// DefaultTransitionDuration is the fade length in seconds when none is set
+const DefaultTransitionDuration = 0.5
+
+// Transition is how the clips of a re-encoded reel meet
+type Transition struct {
+	Style    string  // One of TransitionStyles ("" = cut)
+	Duration float64 // Seconds (0 = DefaultTransitionDuration)
+}
+
+// effective returns the transition that can actually be applied to clips of
+// these durations: the fade is shortened to half the shortest clip, and
+// clips of unknown length (0) fall back to hard cuts
+func (t Transition) effective(durations []float64) Transition {
+	if t.Style != TransitionFade && t.Style != TransitionCrossfade {
+		return Transition{Style: TransitionCut}
+	}
+	if t.Duration <= 0 {
+		t.Duration = DefaultTransitionDuration
+	}
+	for _, dur := range durations {
+		if dur <= 0 {
+			return Transition{Style: TransitionCut}
+		}
+		t.Duration = min(t.Duration, dur/2)
+	}
+	return t
+}
+
+// overlap returns how many seconds each clip overlaps the next
+func (t Transition) overlap() float64 {
+	if t.Style == TransitionCrossfade {
+		return t.Duration
+	}
+	return 0
+}
+
+// reelOffsets returns where each clip starts in the reel and the reel's
+// length, given the clip durations in order. Crossfaded clips overlap the
+// one before them.
+func (t Transition) reelOffsets(durations []float64) (offsets []float64, total float64) {
+	t = t.effective(durations)
+	for i, dur := range durations {
+		if i > 0 {
+			total -= t.overlap()
+		}
+		offsets = append(offsets, total)
+		total += dur
+	}
+	return offsets, total
+}
+
+// apply adds the transition to a conform/concat filter from buildConcatFilter
+// (with any per-clip filters already inserted before each [v<i>])
+func (t Transition) apply(filterStr string, durations []float64) string {
+	t = t.effective(durations)
+	n := len(durations)
+
+	switch t.Style {
+	case TransitionFade:
+		var audio string
+		for i, dur := range durations {
+			fadeOut := dur - t.Duration
+			label := fmt.Sprintf("[v%d];", i)
+			filterStr = strings.Replace(filterStr, label,
+				fmt.Sprintf(",fade=t=in:st=0:d=%.3f,fade=t=out:st=%.3f:d=%.3f%s", t.Duration, fadeOut, t.Duration, label), 1)
+			audio += fmt.Sprintf("[%d:a]afade=t=in:st=0:d=%.3f,afade=t=out:st=%.3f:d=%.3f[a%d];", i, t.Duration, fadeOut, t.Duration, i)
+			filterStr = strings.Replace(filterStr, fmt.Sprintf("[v%d][%d:a]", i, i), fmt.Sprintf("[v%d][a%d]", i, i), 1)
+		}
+		// Audio chains go before the concat that uses them
+		concatAt := strings.Index(filterStr, "[v0][a0]")
+		return filterStr[:concatAt] + audio + filterStr[concatAt:]
+
+	case TransitionCrossfade:
+		if n < 2 {
+			return filterStr
+		}
+		// xfade needs the same pixel format on both sides
+		for i := range durations {
+			label := fmt.Sprintf("[v%d];", i)
+			filterStr = strings.Replace(filterStr, label, ",format=yuv420p"+label, 1)
+		}
+		// Replace the concat with a chain of pairwise blends
+		filterStr = filterStr[:strings.Index(filterStr, "[v0][0:a]")]
+		for i := range durations {
+			// acrossfade needs matching sample formats on both sides
+			filterStr += fmt.Sprintf("[%d:a]aformat=sample_fmts=fltp:sample_rates=48000:channel_layouts=stereo[a%d];", i, i)
+		}
+		offsets, _ := t.reelOffsets(durations)
+		prevV, prevA := "[v0]", "[a0]"
+		for i := 1; i < n; i++ {
+			outV, outA := fmt.Sprintf("[xv%d]", i), fmt.Sprintf("[xa%d]", i)
+			if i == n-1 {
+				outV, outA = "[outv]", "[outa]"
+			}
+			filterStr += fmt.Sprintf("%s[v%d]xfade=transition=fade:duration=%.3f:offset=%.3f%s;", prevV, i, t.Duration, offsets[i], outV)
+			filterStr += fmt.Sprintf("%s[a%d]acrossfade=d=%.3f%s", prevA, i, t.Duration, outA)
+			if i < n-1 {
+				filterStr += ";"
+			}
+			prevV, prevA = outV, outA
+		}
+		return filterStr
+	}
+
+	return filterStr
+}
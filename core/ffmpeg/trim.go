@@ -0,0 +1,55 @@
+package ffmpeg
+
+import (
+	"fmt"
+)
+
+// Trim is the part of a source video the full game export keeps, cutting the
+// warmup before puck drop and the dead time after the period ends
+type Trim struct {
+	In  float64 `json:"in,omitempty"`  // Seconds cut from the start
+	Out float64 `json:"out,omitempty"` // Where the kept part ends, in seconds (0 = the end of the video)
+}
+
+// IsZero returns true if the trim keeps the whole video
+func (t Trim) IsZero() bool {
+	return t.In <= 0 && t.Out <= 0
+}
+
+// End returns where the kept part ends in a video of duration seconds
+func (t Trim) End(duration float64) float64 {
+	if t.Out > 0 && t.Out < duration {
+		return t.Out
+	}
+	return duration
+}
+
+// Length returns how many seconds of a video of duration seconds are kept
+func (t Trim) Length(duration float64) float64 {
+	return max(t.End(duration)-max(t.In, 0), 0)
+}
+
+// Validate returns an error if the trim keeps nothing of a video of duration seconds
+func (t Trim) Validate(duration float64) error {
+	if t.In < 0 || t.Out < 0 {
+		return fmt.Errorf("trim points can't be negative")
+	}
+	if t.Length(duration) <= 0 {
+		return fmt.Errorf("trim start (%.1fs) must be before its end (%.1fs)", t.In, t.End(duration))
+	}
+	return nil
+}
+
+// trimInputArgs returns the ffmpeg input for path, seeking past the trimmed
+// start and stopping at the trimmed end. Input seeking is frame accurate when
+// re-encoding.
+func trimInputArgs(path string, trim Trim) []string {
+	var args []string
+	if trim.In > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", trim.In))
+	}
+	if trim.Out > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", trim.Out-max(trim.In, 0)))
+	}
+	return append(args, "-i", path)
+}
@@ -0,0 +1,183 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	// corruptSectionGap merges decode errors closer than this (seconds) into one section
+	corruptSectionGap = 5.0
+	// truncationTolerance is how far short of its duration a file may decode
+	// before it is reported as truncated (the last GOP can end early)
+	truncationTolerance = 2.0
+)
+
+// CorruptSection is a stretch of a video where decoding reported errors
+type CorruptSection struct {
+	Start  float64 // Seconds into the video
+	End    float64
+	Errors int
+	Sample string // First error message, for diagnostics
+}
+
+// VerifyResult is the outcome of decoding a source video end to end
+type VerifyResult struct {
+	Path     string
+	Duration float64 // Container duration (seconds)
+	Decoded  float64 // How far decoding got (seconds)
+	Sections []CorruptSection
+	Fatal    string // Set if the file couldn't be read at all
+}
+
+// OK returns true if the file decoded fully without errors
+func (r *VerifyResult) OK() bool {
+	return r.Fatal == "" && len(r.Sections) == 0 && !r.Truncated()
+}
+
+// Truncated returns true if decoding stopped well before the file's duration
+// (a copy that was cut short)
+func (r *VerifyResult) Truncated() bool {
+	return r.Fatal == "" && r.Duration > 0 && r.Decoded < r.Duration-truncationTolerance
+}
+
+// Summary describes the problems found, one per line ("" if OK)
+func (r *VerifyResult) Summary() string {
+	if r.Fatal != "" {
+		return "unreadable: " + r.Fatal
+	}
+
+	var lines []string
+	if r.Truncated() {
+		lines = append(lines, fmt.Sprintf("truncated: only %s of %s decodes",
+			formatClock(r.Decoded), formatClock(r.Duration)))
+	}
+	for _, s := range r.Sections {
+		lines = append(lines, fmt.Sprintf("corrupt %s-%s (%d errors): %s",
+			formatClock(s.Start), formatClock(s.End), s.Errors, s.Sample))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatClock formats seconds as M:SS or H:MM:SS
+func formatClock(seconds float64) string {
+	total := int(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// VerifySource decodes the whole of videoPath (video and audio, discarding the
+// output) and reports where decoding failed. progress (may be nil) is called
+// with the fraction decoded. Decoding runs as fast as the CPU allows, usually
+// many times realtime. The check can be stopped with CancelExport.
+func (f *FFmpeg) VerifySource(videoPath string, progress func(fraction float64)) (*VerifyResult, error) {
+	result := &VerifyResult{Path: videoPath}
+
+	duration, err := f.GetDuration(videoPath)
+	if err != nil {
+		result.Fatal = strings.TrimSpace(strings.TrimPrefix(err.Error(), "ffprobe failed: "))
+		return result, nil
+	}
+	result.Duration = duration
+
+	cmd := exec.Command(f.ffmpegPath,
+		"-v", "error",
+		"-nostats",
+		"-progress", "pipe:1",
+		"-i", videoPath,
+		"-f", "null",
+		"-",
+	)
+
+	// Errors are placed at the position the progress output last reported
+	var mu sync.Mutex
+	var position float64
+	var errs []decodeError
+
+	cmd.Stdout = &progressWriter{
+		update: func(p EncodeProgress) {
+			mu.Lock()
+			position = p.Position
+			mu.Unlock()
+			if progress != nil && duration > 0 {
+				progress(min(p.Position/duration, 1))
+			}
+		},
+	}
+	cmd.Stderr = &lineWriter{
+		line: func(line string) {
+			mu.Lock()
+			errs = append(errs, decodeError{at: position, message: line})
+			mu.Unlock()
+		},
+	}
+
+	runErr := f.executeCancellable(cmd)
+	var execErr *exec.Error
+	if errors.As(runErr, &execErr) {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", runErr)
+	}
+	if f.IsCancelled() {
+		return nil, fmt.Errorf("verify cancelled")
+	}
+
+	result.Decoded = position
+	result.Sections = groupDecodeErrors(errs)
+	if runErr != nil && position == 0 {
+		// Nothing decoded: the container itself is broken
+		result.Fatal = "ffmpeg could not decode the file"
+		if len(errs) > 0 {
+			result.Fatal = errs[len(errs)-1].message
+		}
+		result.Sections = nil
+	}
+	return result, nil
+}
+
+// decodeError is one error line from ffmpeg and where in the video it happened
+type decodeError struct {
+	at      float64
+	message string
+}
+
+// groupDecodeErrors merges errors that are close together into sections
+func groupDecodeErrors(errs []decodeError) []CorruptSection {
+	var sections []CorruptSection
+	for _, e := range errs {
+		if n := len(sections); n > 0 && e.at-sections[n-1].End <= corruptSectionGap {
+			sections[n-1].End = max(sections[n-1].End, e.at)
+			sections[n-1].Errors++
+			continue
+		}
+		sections = append(sections, CorruptSection{Start: e.at, End: e.at, Errors: 1, Sample: e.message})
+	}
+	return sections
+}
+
+// lineWriter passes each non-blank line written to it to line
+type lineWriter struct {
+	partial []byte
+	line    func(string)
+}
+
+// Write implements io.Writer for cmd.Stderr
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.partial = append(w.partial, data...)
+	for {
+		end := bytes.IndexByte(w.partial, '\n')
+		if end < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.partial[:end])); line != "" {
+			w.line(line)
+		}
+		w.partial = w.partial[end+1:]
+	}
+	return len(data), nil
+}
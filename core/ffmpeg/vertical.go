@@ -0,0 +1,133 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Size of a vertical (9:16) export, as Instagram and TikTok expect
+const (
+	VerticalWidth  = 1080
+	VerticalHeight = 1920
+)
+
+// VerticalPan is where the 9:16 crop window sits from Start seconds into the
+// video on (until the next pan): X is 0 at the left edge, 0.5 centered and 1
+// at the right edge
+type VerticalPan struct {
+	Start float64
+	X     float64
+}
+
+// VerticalPans times a crop position for each reel input, in the order the
+// inputs are combined with the given transition. pans maps input path -> X;
+// inputs without one (e.g. bumpers) are centered.
+func (f *FFmpeg) VerticalPans(inputPaths []string, pans map[string]float64, transition Transition) ([]VerticalPan, error) {
+	var durations []float64
+	for _, path := range inputPaths {
+		dur, err := f.GetDuration(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to time crop positions: %w", err)
+		}
+		durations = append(durations, dur)
+	}
+
+	offsets, _ := transition.reelOffsets(durations)
+	result := make([]VerticalPan, len(inputPaths))
+	for i, path := range inputPaths {
+		x, ok := pans[path]
+		if !ok {
+			x = 0.5
+		}
+		result[i] = VerticalPan{Start: offsets[i], X: x}
+	}
+	return result, nil
+}
+
+// verticalFilter returns the filter chain that crops a full-height 9:16 window
+// out of each frame, moved across the frame by pans (centered if there are
+// none), and scales it to VerticalWidth x VerticalHeight
+func verticalFilter(pans []VerticalPan) string {
+	// crop evaluates x for every frame, so the window can jump at each pan's start
+	x := "0.5"
+	if len(pans) > 0 {
+		x = fmt.Sprintf("%.3f", clampPan(pans[len(pans)-1].X))
+		for i := len(pans) - 2; i >= 0; i-- {
+			x = fmt.Sprintf("if(lt(t\\,%.3f)\\,%.3f\\,%s)", pans[i+1].Start, clampPan(pans[i].X), x)
+		}
+	}
+	return fmt.Sprintf("crop=w=min(iw\\,ih*9/16):h=min(ih\\,iw*16/9):x=(iw-ow)*%s:y=(ih-oh)/2,scale=%d:%d,setsar=1",
+		x, VerticalWidth, VerticalHeight)
+}
+
+// clampPan keeps a crop position inside the frame
+func clampPan(x float64) float64 {
+	return min(max(x, 0), 1)
+}
+
+// ExportVertical re-encodes a video (usually a combined reel) as a 1080x1920
+// vertical video for social media, cropping a full-height 9:16 window out of
+// each frame where pans put it. Audio is copied when it can be (see
+// SetAudioCopy), otherwise re-encoded; chapters and tags are kept.
+func (f *FFmpeg) ExportVertical(inputPath, outputPath, crf string, forceCPU bool, pans []VerticalPan, progress func(float64, string)) error {
+	duration, err := f.GetDuration(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to get duration: %w", err)
+	}
+
+	encode := func(nvenc bool) error {
+		videoArgs := []string{"-c:v", "libx264", "-preset", "medium", "-profile:v", "high", "-crf", crf}
+		name := "cpu"
+		if nvenc {
+			videoArgs = []string{"-c:v", "h264_nvenc", "-preset", "p4", "-profile:v", "high", "-rc", "constqp", "-qp", crf}
+			name = "nvenc"
+		}
+
+		args := append([]string{}, progressArgs...)
+		args = append(args,
+			"-i", inputPath,
+			"-vf", f.sdrFilterPrefix(inputPath)+verticalFilter(pans),
+			"-map", "0:v:0",
+			"-map", "0:a?",
+			"-map_metadata", "0",
+			"-map_chapters", "0",
+		)
+		args = append(args, videoArgs...)
+		args = append(args, "-pix_fmt", "yuv420p")
+		args = append(args, f.sourceAudioArgs(inputPath, outputPath, true)...)
+		args = append(args, "-movflags", "+faststart")
+		args = append(args, f.PresetArgs(PresetReel)...)
+		args = append(args, "-y", outputPath)
+
+		cmd := f.command(args...)
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		reportProgress(cmd, duration, 0, 0.99, "Encoding vertical", progress)
+
+		if err := f.run(cmd); err != nil {
+			if f.IsCancelled() {
+				return fmt.Errorf("vertical export cancelled")
+			}
+			return fmt.Errorf("%s vertical export failed: %s", name, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+
+	if forceCPU {
+		err = encode(false)
+	} else {
+		err = f.tryNVENC(func() error { return encode(true) })
+		if err != nil && !f.IsCancelled() {
+			progress(0, fallbackMessage(err))
+			err = encode(false)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	progress(1.0, "Vertical export complete!")
+	return nil
+}
@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"fmt"
+)
+
+// Watermark describes a logo image overlaid on encoded clips and reels
+type Watermark struct {
+	ImagePath string
+	Corner    string  // "top-left", "top-right", "bottom-left", "bottom-right"
+	Opacity   float64 // 0.0 (invisible) to 1.0 (fully opaque)
+	Scale     float64 // Logo width as a fraction of the video width (e.g. 0.15)
+}
+
+// WatermarkCorners lists the supported logo positions
+var WatermarkCorners = []string{"top-left", "top-right", "bottom-left", "bottom-right"}
+
+// overlayFilter returns filter_complex steps that overlay the logo (input logoIndex)
+// onto the stream labelled base, producing a stream labelled out.
+// The logo is scaled relative to the video width so it looks the same at any resolution.
+func (w *Watermark) overlayFilter(base string, logoIndex int, out string) string {
+	opacity := w.Opacity
+	if opacity <= 0 || opacity > 1 {
+		opacity = 1
+	}
+	scale := w.Scale
+	if scale <= 0 || scale > 1 {
+		scale = 0.15
+	}
+
+	// Keep a small margin (2% of the frame) from the edges
+	x, y := "W*0.02", "H*0.02"
+	switch w.Corner {
+	case "top-right":
+		x = "W-w-W*0.02"
+	case "bottom-left":
+		y = "H-h-H*0.02"
+	case "bottom-right":
+		x = "W-w-W*0.02"
+		y = "H-h-H*0.02"
+	}
+
+	return fmt.Sprintf("[%d:v]format=rgba,colorchannelmixer=aa=%.2f[wmlogo];"+
+		"[wmlogo][%s]scale2ref=w=main_w*%.3f:h=ow*ih/iw[wmscaled][wmbase];"+
+		"[wmbase][wmscaled]overlay=%s:%s[%s]",
+		logoIndex, opacity, base, scale, x, y, out)
+}
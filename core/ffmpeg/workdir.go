@@ -0,0 +1,116 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorkDirName is the hidden folder inside a project folder its work files
+// (concat lists, chapter files, pass logs, remuxed parts) are written to, so
+// they stay on the same drive as the videos and are found again after a crash
+const WorkDirName = ".gopro-work"
+
+const (
+	// workRunPrefix starts the name of each run's folder inside WorkDirName
+	workRunPrefix = "run-"
+	// workDirHeartbeat is how often a run's folder is touched while it is open
+	workDirHeartbeat = 30 * time.Second
+	// workDirStale is how long a run's folder may go untouched before it is
+	// taken to be left by a copy that crashed
+	workDirStale = 4 * workDirHeartbeat
+)
+
+// WorkDir is one run's folder of work files inside a project folder. It is
+// touched on a heartbeat while open, so the folders of runs that crashed can
+// be told from those of copies still running and cleaned up (see
+// CleanWorkDir). Use its Path with SetTempDir.
+type WorkDir struct {
+	path string
+	stop chan struct{}
+	once sync.Once
+}
+
+// OpenWorkDir cleans up the work files crashed runs left in projectFolder,
+// then creates this run's folder
+func OpenWorkDir(projectFolder string) (*WorkDir, error) {
+	CleanWorkDir(projectFolder)
+
+	root := filepath.Join(projectFolder, WorkDirName)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create work folder: %w", err)
+	}
+	hideFolder(root)
+	path, err := os.MkdirTemp(root, fmt.Sprintf("%s%d-*", workRunPrefix, os.Getpid()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work folder: %w", err)
+	}
+
+	w := &WorkDir{path: path, stop: make(chan struct{})}
+	go w.heartbeat()
+	return w, nil
+}
+
+// Path returns the run's folder
+func (w *WorkDir) Path() string {
+	return w.path
+}
+
+// heartbeat touches the run's folder until it is closed
+func (w *WorkDir) heartbeat() {
+	ticker := time.NewTicker(workDirHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			os.Chtimes(w.path, now, now)
+		}
+	}
+}
+
+// Close stops the heartbeat and removes the run's folder with everything in
+// it, and the project's work folder once no other run uses it
+func (w *WorkDir) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.stop)
+		if err = os.RemoveAll(w.path); err != nil {
+			err = fmt.Errorf("failed to remove work folder: %w", err)
+		}
+		os.Remove(filepath.Dir(w.path)) // Fails while other runs' folders are left
+	})
+	return err
+}
+
+// CleanWorkDir removes the run folders in projectFolder's work folder that
+// haven't been touched for workDirStale (left by runs that crashed), and any
+// loose files, and returns how many it removed. Folders of runs still open,
+// in this copy or another, are kept.
+func CleanWorkDir(projectFolder string) int {
+	root := filepath.Join(projectFolder, WorkDirName)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), workRunPrefix) {
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < workDirStale {
+				continue
+			}
+		}
+		if os.RemoveAll(path) == nil {
+			removed++
+		}
+	}
+	os.Remove(root) // Only once empty
+	return removed
+}
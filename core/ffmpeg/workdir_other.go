@@ -0,0 +1,6 @@
+//go:build !windows
+
+package ffmpeg
+
+// hideFolder does nothing: the leading dot hides the folder here
+func hideFolder(path string) {}
@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanWorkDir(t *testing.T) {
+	project := t.TempDir()
+	root := filepath.Join(project, WorkDirName)
+	stale := filepath.Join(root, workRunPrefix+"100-crashed")
+	live := filepath.Join(root, workRunPrefix+"200-running")
+	for _, dir := range []string{stale, live} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "concat.txt"), []byte("file 'a.mp4'\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loose := filepath.Join(root, "ffmpeg-concat-1.txt")
+	if err := os.WriteFile(loose, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The crashed run's folder was last touched well over workDirStale ago;
+	// the running one's heartbeat just touched it
+	old := time.Now().Add(-2 * workDirStale)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := os.Chtimes(live, now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed := CleanWorkDir(project); removed != 2 {
+		t.Errorf("CleanWorkDir removed %d, want 2 (the stale run folder and the loose file)", removed)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale run folder was kept: %v", err)
+	}
+	if _, err := os.Stat(loose); !os.IsNotExist(err) {
+		t.Errorf("loose file was kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(live, "concat.txt")); err != nil {
+		t.Errorf("live run folder was removed: %v", err)
+	}
+}
+
+func TestWorkDirClose(t *testing.T) {
+	project := t.TempDir()
+	w, err := OpenWorkDir(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed := CleanWorkDir(project); removed != 0 {
+		t.Errorf("CleanWorkDir removed %d folders of an open run", removed)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(project, WorkDirName)); !os.IsNotExist(err) {
+		t.Errorf("work folder left after the last run closed: %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package ffmpeg
+
+import "syscall"
+
+// hideFolder sets a folder's hidden attribute, as its leading dot only hides
+// it on other systems
+func hideFolder(path string) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return
+	}
+	attrs, err := syscall.GetFileAttributes(name)
+	if err != nil {
+		return
+	}
+	syscall.SetFileAttributes(name, attrs|syscall.FILE_ATTRIBUTE_HIDDEN)
+}
@@ -0,0 +1,3 @@
+module github.com/jacobe603/gopro-clip-extractor/core
+
+go 1.25.5
@@ -0,0 +1,422 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// AnalysisResult contains all analyzed chapters with metadata
+type AnalysisResult struct {
+	Periods  []Period  `json:"periods"`
+	Chapters []Chapter `json:"chapters"`
+	// DroppedChapters lists HiLights collapsed by the dedup pass (double presses)
+	DroppedChapters []Chapter `json:"dropped_chapters,omitempty"`
+	// Warnings lists problems found by the sanity checks (see ValidatePeriods)
+	Warnings []PeriodWarning `json:"warnings,omitempty"`
+}
+
+// Analyzer handles the analysis of GoPro footage
+type Analyzer struct {
+	ff *ffmpeg.FFmpeg
+	// dedupThreshold collapses chapters closer than this many seconds (0 = disabled)
+	dedupThreshold float64
+	// split divides recordings that cover several periods
+	split PeriodSplit
+	// clockZone is the zone the camera's clock was set to (nil = this computer's)
+	clockZone *time.Location
+	// speedBurstKmh suggests chapters where the GPS speed stays over this (0 = off)
+	speedBurstKmh float64
+	// auto suggests candidate chapters at intervals or audio spikes
+	auto AutoChapters
+	// manualStarts are start times of day entered by hand, by video file
+	manualStarts map[string]time.Duration
+}
+
+// NewAnalyzer creates a new analyzer
+func NewAnalyzer(ff *ffmpeg.FFmpeg) *Analyzer {
+	return &Analyzer{ff: ff}
+}
+
+// SetDedupThreshold sets the minimum spacing in seconds between HiLights.
+// Chapters closer together than this are treated as a double press and collapsed.
+func (a *Analyzer) SetDedupThreshold(seconds float64) {
+	a.dedupThreshold = seconds
+}
+
+// SetPeriodSplit sets how recordings that run across more than one period
+// are split into logical periods (the zero PeriodSplit leaves them whole)
+func (a *Analyzer) SetPeriodSplit(split PeriodSplit) {
+	a.split = split
+}
+
+// SetClockZone sets the time zone the camera's clock was set to, for footage
+// recorded somewhere other than this computer's zone (nil = this computer's)
+func (a *Analyzer) SetClockZone(loc *time.Location) {
+	a.clockZone = loc
+}
+
+// SetSpeedBursts makes the analysis suggest a chapter wherever the GPS speed
+// in a GoPro MP4 stays over kmh (e.g. a helmet camera on a breakaway), where
+// no HiLight was marked (0 = off)
+func (a *Analyzer) SetSpeedBursts(kmh float64) {
+	a.speedBurstKmh = kmh
+}
+
+// SetAutoChapters makes the analysis suggest candidate chapters across each
+// period, at fixed intervals and/or where the sound gets suddenly louder, for
+// games where nobody pressed the HiLight button (the zero value suggests none)
+func (a *Analyzer) SetAutoChapters(auto AutoChapters) {
+	a.auto = auto
+}
+
+// SetManualStarts sets the clock time of day of the first frame of videos
+// whose timecode is missing or wrong (e.g. Quik exports and re-muxed MOVs
+// without a tmcd track), by video file. They are used instead of the timecode.
+func (a *Analyzer) SetManualStarts(starts map[string]time.Duration) {
+	a.manualStarts = starts
+}
+
+// AnalyzePeriods processes multiple periods and returns all chapters with clock times
+func (a *Analyzer) AnalyzePeriods(periods []Period) (*AnalysisResult, error) {
+	periodChapters := make(map[string][]Chapter)
+	var droppedChapters []Chapter
+
+	// Timecode frames are counted at each video's own rate (30, 25, 120 fps...)
+	periods = append([]Period{}, periods...)
+	for i := range periods {
+		a.readTimecodeRate(&periods[i])
+	}
+
+	for _, period := range periods {
+		var chapters []Chapter
+		var err error
+
+		// Parse the metadata file for chapters
+		// If MetadataFile points to the video itself (a MOV with metadata, or a
+		// GoPro MP4 used directly), extract directly
+		if period.MetadataFile == period.VideoFile {
+			chapters, err = a.extractChaptersFromVideo(period.VideoFile)
+		} else {
+			chapters, err = ParseFFMetadata(period.MetadataFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metadata for %s: %w", period.Name, err)
+		}
+
+		// Pick up HiLights tagged after recording (e.g. in the Quik app), which
+		// aren't in the exported chapter metadata
+		if ext := filepath.Ext(period.SourceGoPro); strings.EqualFold(ext, ".mp4") || strings.EqualFold(ext, ffmpeg.Max360Ext) {
+			if tags, err := ReadHiLights(period.SourceGoPro); err == nil {
+				chapters, _ = MergeHiLights(chapters, tags)
+			}
+
+			// Suggest highlights where the camera moved fast but nobody pressed the button
+			if a.speedBurstKmh > 0 {
+				if speeds, err := ReadGPSSpeeds(period.SourceGoPro); err == nil {
+					chapters, _ = MergeSpeedBursts(chapters, DetectSpeedBursts(speeds, a.speedBurstKmh))
+				}
+			}
+		}
+
+		// Suggest candidates across the period, e.g. when nobody pressed the button
+		if !a.auto.IsZero() {
+			chapters, _ = MergeAutoChapters(chapters, a.autoChapters(period))
+		}
+
+		if len(chapters) == 0 {
+			continue // No chapters in this period
+		}
+
+		// Collapse double-pressed HiLights before mapping to clock time
+		chapters, dropped := DeduplicateChapters(chapters, a.dedupThreshold)
+
+		start, err := a.PeriodStart(period)
+		if err != nil {
+			return nil, err
+		}
+
+		// Map chapters to clock times
+		periodChapters[period.Name] = MapChaptersFrom(chapters, start)
+
+		if len(dropped) > 0 {
+			for _, ch := range MapChaptersFrom(dropped, start) {
+				ch.Period = period.Name
+				droppedChapters = append(droppedChapters, ch)
+			}
+		}
+	}
+
+	// Split recordings that cover several periods, renaming the periods
+	periods, periodChapters, droppedChapters = a.split.splitPeriods(periods, periodChapters, droppedChapters)
+
+	// Merge and sort all chapters
+	allChapters := MergeAndSortChapters(periodChapters)
+
+	return &AnalysisResult{
+		Periods:         periods,
+		Chapters:        allChapters,
+		DroppedChapters: droppedChapters,
+		Warnings:        ValidatePeriods(a.periodSpans(periods), allChapters),
+	}, nil
+}
+
+// autoChapters returns the chapters AutoChapters suggests for a period's video.
+// Whatever can't be read (e.g. a video without sound) suggests nothing.
+func (a *Analyzer) autoChapters(period Period) []Chapter {
+	var suggested []Chapter
+	if a.auto.Interval > 0 {
+		if duration, err := a.ff.GetDuration(period.VideoFile); err == nil {
+			suggested = append(suggested, IntervalChapters(time.Duration(duration*float64(time.Second)), a.auto.Interval)...)
+		}
+	}
+	if a.auto.AudioSpikes {
+		if levels, err := a.ff.Loudness(period.VideoFile); err == nil {
+			step := time.Duration(ffmpeg.LoudnessStep * float64(time.Second))
+			// Spikes go first, so an interval chapter near one is the one left out
+			suggested = append(AudioSpikeChapters(DetectAudioSpikes(levels, step)), suggested...)
+		}
+	}
+	return suggested
+}
+
+// periodSpans returns the clock time range of each period's video for the
+// sanity checks. Start or duration is left zero where it can't be read.
+func (a *Analyzer) periodSpans(periods []Period) []PeriodSpan {
+	var spans []PeriodSpan
+	for _, period := range periods {
+		span := PeriodSpan{Name: period.Name, Offset: period.SplitStart}
+		if start, err := a.PeriodStart(period); err == nil {
+			span.Start = start.Add(period.SplitStart)
+		}
+		if duration, err := a.ff.GetDuration(period.VideoFile); err == nil {
+			span.Duration = time.Duration(duration * float64(time.Second))
+			if period.SplitEnd > 0 && period.SplitEnd < span.Duration {
+				span.Duration = period.SplitEnd
+			}
+			span.Duration -= period.SplitStart
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// readTimecodeRate fills in the frame rate of a period's timecode, read from
+// the video its timecode comes from, unless it's already known. A rate that
+// can't be read is left at 0 (DefaultTimecodeFPS).
+func (a *Analyzer) readTimecodeRate(period *Period) {
+	if period.TimecodeFPS > 0 {
+		return
+	}
+	fps, dropFrame, err := a.ff.GetTimecodeRate(period.SourceGoPro)
+	if err != nil && period.VideoFile != period.SourceGoPro {
+		fps, dropFrame, err = a.ff.GetTimecodeRate(period.VideoFile)
+	}
+	if err == nil {
+		period.TimecodeFPS = fps
+		period.DropFrame = dropFrame
+	}
+}
+
+// periodTimecode returns the GoPro start timecode for a period.
+// Uses GetTimecodeFromVideo for MOV files, GetTimecode for original GoPro files.
+func (a *Analyzer) periodTimecode(period Period) (string, error) {
+	if period.UseMovMetadata {
+		return a.ff.GetTimecodeFromVideo(period.SourceGoPro)
+	}
+	return a.ff.GetTimecode(period.SourceGoPro)
+}
+
+// PeriodStart returns the clock time of the first frame of a period's video.
+// The time of day comes from the GoPro timecode (or the start entered by hand)
+// and the date from the file's creation time, so chapters keep their real
+// date (today if it can't be read). A timecode that can't be read is
+// reported as a *MissingTimecodeError.
+func (a *Analyzer) PeriodStart(period Period) (time.Time, error) {
+	created, err := a.ff.GetCreationTime(period.SourceGoPro)
+	if err != nil && period.VideoFile != period.SourceGoPro {
+		created, err = a.ff.GetCreationTime(period.VideoFile)
+	}
+	if err != nil {
+		created = time.Now()
+	}
+
+	if timeOfDay, ok := a.manualStarts[period.VideoFile]; ok {
+		return NearestDay(created, timeOfDay, a.clockZone), nil
+	}
+
+	timecode, err := a.periodTimecode(period)
+	if err != nil {
+		return time.Time{}, &MissingTimecodeError{Period: period.Name, VideoFile: period.VideoFile, Err: err}
+	}
+
+	start, err := ParseTimecodeAt(timecode, period.TimecodeFPS, period.DropFrame, created, a.clockZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse timecode for %s: %w", period.Name, err)
+	}
+	return start, nil
+}
+
+// PeriodStartTimes returns the wall-clock time of the first frame of each period,
+// used to map imported wall-clock event times onto the period videos
+func (a *Analyzer) PeriodStartTimes(result *AnalysisResult) (map[string]time.Time, error) {
+	starts := make(map[string]time.Time)
+
+	// Derive from existing chapters where possible (no ffmpeg call needed)
+	for _, ch := range result.Chapters {
+		if _, ok := starts[ch.Period]; !ok {
+			starts[ch.Period] = ch.ClockTime.Add(-ch.VideoTime)
+		}
+	}
+
+	for _, period := range result.Periods {
+		if _, ok := starts[period.Name]; ok {
+			continue
+		}
+		start, err := a.PeriodStart(period)
+		if err != nil {
+			return nil, err
+		}
+		starts[period.Name] = start
+	}
+
+	return starts, nil
+}
+
+// SaveToJSON saves the analysis result to a JSON file
+func (result *AnalysisResult) SaveToJSON(path string) error {
+	// Ensure directory exists
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFromJSON loads an analysis result from a JSON file
+func LoadFromJSON(path string) (*AnalysisResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var result AnalysisResult
+	if err := json.NewDecoder(file).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetPeriodVideoFile returns the video file path for a given period name
+func (result *AnalysisResult) GetPeriodVideoFile(periodName string) string {
+	for _, p := range result.Periods {
+		if p.Name == periodName {
+			return p.VideoFile
+		}
+	}
+	return ""
+}
+
+// extractChaptersFromVideo reads chapter markers directly from a video file
+// with ffprobe. Nothing is written, so it works in read-only folders.
+func (a *Analyzer) extractChaptersFromVideo(videoPath string) ([]Chapter, error) {
+	probed, err := a.ff.GetChapters(videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chapters: %w", err)
+	}
+
+	chapters := make([]Chapter, len(probed))
+	for i, ch := range probed {
+		chapters[i] = Chapter{
+			Number:    i + 1,
+			StartMs:   ch.StartMs,
+			VideoTime: time.Duration(ch.StartMs) * time.Millisecond,
+			Title:     ch.Title,
+		}
+	}
+	return chapters, nil
+}
+
+// ChapterJSON is a JSON-friendly version of Chapter for serialization
+type ChapterJSON struct {
+	Number      int    `json:"number"`
+	StartMs     int64  `json:"start_ms"`
+	VideoTime   string `json:"video_time"`
+	ClockTime   string `json:"clock_time"`
+	ClockDate   string `json:"clock_date,omitempty"` // Full clock time (RFC 3339), so the date survives a reload
+	GlobalOrder int    `json:"global_order"`
+	Period      string `json:"period"`
+	Label       string `json:"label,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// MarshalJSON implements custom JSON marshaling for Chapter
+func (c Chapter) MarshalJSON() ([]byte, error) {
+	cj := ChapterJSON{
+		Number:      c.Number,
+		StartMs:     c.StartMs,
+		VideoTime:   FormatVideoTime(c.VideoTime),
+		ClockTime:   c.ClockTime.Format("15:04:05.000"),
+		GlobalOrder: c.GlobalOrder,
+		Period:      c.Period,
+		Label:       c.Label,
+		Title:       c.Title,
+	}
+	if !c.ClockTime.IsZero() {
+		cj.ClockDate = c.ClockTime.Format(time.RFC3339Nano)
+	}
+	return json.Marshal(cj)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for Chapter
+func (c *Chapter) UnmarshalJSON(data []byte) error {
+	var cj ChapterJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return err
+	}
+
+	c.Number = cj.Number
+	c.StartMs = cj.StartMs
+	c.GlobalOrder = cj.GlobalOrder
+	c.Period = cj.Period
+	c.Label = cj.Label
+	c.Title = cj.Title
+
+	// Prefer the exact start in milliseconds; fall back to video time (MM:SS format)
+	if cj.StartMs > 0 {
+		c.VideoTime = time.Duration(cj.StartMs) * time.Millisecond
+	} else {
+		var minutes, seconds int
+		fmt.Sscanf(cj.VideoTime, "%d:%d", &minutes, &seconds)
+		c.VideoTime = (time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+	}
+
+	// Parse clock time, with its date if it was saved (older files only have the time of day)
+	if t, err := time.Parse(time.RFC3339Nano, cj.ClockDate); err == nil {
+		c.ClockTime = t
+	} else {
+		c.ClockTime, _ = time.Parse("15:04:05.000", cj.ClockTime)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Labels of the chapters AutoChapters suggests rather than the camera marked
+const (
+	IntervalLabel   = "Interval"
+	AudioSpikeLabel = "Loud moment"
+)
+
+// MinAutoInterval is the shortest interval AutoChapters suggests chapters at,
+// so suggestions don't cover each other
+const MinAutoInterval = 15 * time.Second
+
+const (
+	// autoChapterMatchWindow is how close to an existing chapter a suggestion
+	// may be before it is taken to be the same moment
+	autoChapterMatchWindow = 10 * time.Second
+	// audioSpikeBaseline is how much of the sound before a moment its
+	// loudness is compared with
+	audioSpikeBaseline = 30 * time.Second
+	// audioSpikeRise is how many dB over that a moment must be to count as a
+	// spike (a cheer, the goal horn, the bench banging the boards)
+	audioSpikeRise = 10.0
+	// audioSpikeGap joins spikes this close together into one, at the loudest
+	audioSpikeGap = 20 * time.Second
+	// audioSpikeFloor is the level (dBFS) a spike must reach, so a cough in a
+	// quiet stretch isn't suggested
+	audioSpikeFloor = -40.0
+)
+
+// AutoChapters suggests candidate chapters across each period for games
+// where nobody pressed the HiLight button. Suggestions start unticked in
+// Step 2 like speed bursts (see IsSuggested). The zero value suggests none.
+type AutoChapters struct {
+	// Interval suggests a chapter every Interval through the video (0 = off)
+	Interval time.Duration
+	// AudioSpikes suggests a chapter where the sound gets suddenly louder
+	AudioSpikes bool
+}
+
+// IsZero reports whether no chapters are suggested
+func (o AutoChapters) IsZero() bool {
+	return o.Interval <= 0 && !o.AudioSpikes
+}
+
+// AudioSpike is a moment where a video's sound jumps above what came before
+type AudioSpike struct {
+	At   time.Duration // Offset of the loudest step into the video
+	Rise float64       // dB above the sound before it
+}
+
+// IntervalTimes returns the offsets of a chapter every interval (at least
+// MinAutoInterval) through a video of the given length, starting one
+// interval in
+func IntervalTimes(duration, interval time.Duration) []time.Duration {
+	if interval <= 0 {
+		return nil
+	}
+	interval = max(interval, MinAutoInterval)
+	var times []time.Duration
+	for at := interval; at < duration; at += interval {
+		times = append(times, at)
+	}
+	return times
+}
+
+// DetectAudioSpikes finds the moments where the sound (one level in dBFS per
+// step, see ffmpeg.Loudness) rises audioSpikeRise over the average of the
+// audioSpikeBaseline before it. Spikes close together are joined at the
+// loudest.
+func DetectAudioSpikes(levels []float64, step time.Duration) []AudioSpike {
+	window := int(audioSpikeBaseline / step)
+	if window < 1 || len(levels) <= window {
+		return nil
+	}
+
+	var spikes []AudioSpike
+	sum := 0.0
+	for i := 0; i < window; i++ {
+		sum += levels[i]
+	}
+	for i := window; i < len(levels); i++ {
+		baseline := sum / float64(window)
+		sum += levels[i] - levels[i-window]
+
+		rise := levels[i] - baseline
+		if rise < audioSpikeRise || levels[i] < audioSpikeFloor {
+			continue
+		}
+		spike := AudioSpike{At: time.Duration(i) * step, Rise: rise}
+		if n := len(spikes); n > 0 && spike.At-spikes[n-1].At <= audioSpikeGap {
+			if spike.Rise > spikes[n-1].Rise {
+				spikes[n-1] = spike
+			}
+			continue
+		}
+		spikes = append(spikes, spike)
+	}
+	return spikes
+}
+
+// MergeAutoChapters adds the suggested chapters no existing chapter covers
+// (one within autoChapterMatchWindow), numbered after the existing ones (see
+// numberAdded), and returns how many were added.
+func MergeAutoChapters(chapters, suggested []Chapter) ([]Chapter, int) {
+	added := 0
+	for _, s := range suggested {
+		covered := false
+		for _, ch := range chapters {
+			diff := ch.VideoTime - s.VideoTime
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff <= autoChapterMatchWindow {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		chapters = append(chapters, s)
+		added++
+	}
+
+	numberAdded(chapters, added)
+	return chapters, added
+}
+
+// IntervalChapters returns the chapters suggested every interval through a
+// video of the given length, labelled with their offset
+func IntervalChapters(duration, interval time.Duration) []Chapter {
+	var chapters []Chapter
+	for _, at := range IntervalTimes(duration, interval) {
+		chapters = append(chapters, suggestedChapter(at,
+			fmt.Sprintf("%s %d:%02d", IntervalLabel, int(at.Minutes()), int(at.Seconds())%60)))
+	}
+	return chapters
+}
+
+// AudioSpikeChapters returns the chapters suggested at audio spikes, labelled
+// with how much louder the moment was
+func AudioSpikeChapters(spikes []AudioSpike) []Chapter {
+	var chapters []Chapter
+	for _, s := range spikes {
+		chapters = append(chapters, suggestedChapter(s.At, fmt.Sprintf("%s (+%.0f dB)", AudioSpikeLabel, s.Rise)))
+	}
+	return chapters
+}
+
+// suggestedChapter returns a chapter at offset at with a label
+func suggestedChapter(at time.Duration, label string) Chapter {
+	at = at.Truncate(time.Millisecond)
+	return Chapter{
+		StartMs:   at.Milliseconds(),
+		VideoTime: at,
+		Label:     label,
+	}
+}
+
+// IsAutoChapter returns true if a chapter was suggested by AutoChapters
+// rather than marked on the camera
+func IsAutoChapter(ch Chapter) bool {
+	return strings.HasPrefix(ch.Label, IntervalLabel) || strings.HasPrefix(ch.Label, AudioSpikeLabel)
+}
+
+// IsSuggested returns true if a chapter was suggested by the analysis (a GPS
+// speed burst or AutoChapters), so it starts unticked
+func IsSuggested(ch Chapter) bool {
+	return IsSpeedBurst(ch) || IsAutoChapter(ch)
+}
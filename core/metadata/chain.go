@@ -0,0 +1,53 @@
+package metadata
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// goProChapterRe matches GoPro chaptered file names: GX<chapter><video id>, e.g. GX020092.
+// GoPro splits long recordings into ~4GB files with the same video ID.
+var goProChapterRe = regexp.MustCompile(`(?i)^(GX|GH)(\d{2})(\d{4})$`)
+
+// NextChapterPeriod returns the period whose video continues the given period's
+// recording (GX01xxxx -> GX02xxxx), or nil if the period's file is not part of a
+// chaptered recording or the next file was not loaded as a period.
+// Clips near the end of a chapter file can then be extended into the next file.
+func (result *AnalysisResult) NextChapterPeriod(periodName string) *Period {
+	current := result.GetPeriodVideoFile(periodName)
+	if current == "" {
+		return nil
+	}
+
+	prefix, seq, videoID, ok := parseGoProChapterName(current)
+	if !ok {
+		return nil
+	}
+
+	for i := range result.Periods {
+		p := &result.Periods[i]
+		pPrefix, pSeq, pVideoID, ok := parseGoProChapterName(p.VideoFile)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(pPrefix, prefix) && pVideoID == videoID && pSeq == seq+1 &&
+			strings.EqualFold(filepath.Ext(p.VideoFile), filepath.Ext(current)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// parseGoProChapterName splits a GoPro chapter file name into prefix, chapter
+// sequence number and video ID
+func parseGoProChapterName(path string) (prefix string, seq int, videoID string, ok bool) {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	matches := goProChapterRe.FindStringSubmatch(base)
+	if matches == nil {
+		return "", 0, "", false
+	}
+	seq, _ = strconv.Atoi(matches[2])
+	return matches[1], seq, matches[3], true
+}
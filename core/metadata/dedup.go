@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DeduplicateChapters collapses chapters that are closer together than thresholdSec
+// into a single chapter. This handles double-pressed HiLight buttons, which produce
+// two chapters only a second or two apart for the same play.
+//
+// The first chapter of each cluster is kept; the rest are returned as dropped.
+// Chapter numbers are left unchanged so filenames still match the source markers.
+//
+// Parameters:
+//   - chapters: Chapters from a single video file (any order)
+//   - thresholdSec: Chapters closer than this many seconds are collapsed (0 disables)
+//
+// Returns:
+//   - kept: Chapters to use, sorted by video time
+//   - dropped: Chapters that were collapsed into an earlier one
+func DeduplicateChapters(chapters []Chapter, thresholdSec float64) (kept, dropped []Chapter) {
+	if len(chapters) == 0 || thresholdSec <= 0 {
+		return chapters, nil
+	}
+
+	sorted := make([]Chapter, len(chapters))
+	copy(sorted, chapters)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].VideoTime < sorted[j].VideoTime
+	})
+
+	kept = append(kept, sorted[0])
+	prev := sorted[0]
+	for _, ch := range sorted[1:] {
+		// Compare against the previous press (not the last kept chapter) so a
+		// rapid burst of presses collapses into one, however long the burst is
+		if (ch.VideoTime - prev.VideoTime).Seconds() < thresholdSec {
+			dropped = append(dropped, ch)
+		} else {
+			kept = append(kept, ch)
+		}
+		prev = ch
+	}
+
+	return kept, dropped
+}
+
+// GetDedupSummary returns a summary string listing the HiLight markers that were
+// dropped as duplicates during analysis. Returns "" if nothing was dropped.
+func (result *AnalysisResult) GetDedupSummary() string {
+	if len(result.DroppedChapters) == 0 {
+		return ""
+	}
+
+	var markers []string
+	for _, ch := range result.DroppedChapters {
+		markers = append(markers, fmt.Sprintf("%s Ch%02d @ %s",
+			ch.Period, ch.Number, FormatVideoTime(ch.VideoTime)))
+	}
+
+	return fmt.Sprintf("Dropped %d duplicate HiLights: %s",
+		len(result.DroppedChapters), strings.Join(markers, ", "))
+}
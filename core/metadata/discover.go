@@ -0,0 +1,188 @@
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// DiscoverOptions controls DiscoverPeriodsWith
+type DiscoverOptions struct {
+	// Workers is how many MOVs are probed at once (0 = one at a time), which
+	// over USB or a network share is most of the time a scan takes
+	Workers int
+	// Bare makes a MOV with a timecode but no chapters anywhere a period of
+	// its own too, for chapters suggested by the analysis (see AutoChapters)
+	Bare bool
+}
+
+// DiscoverPeriods finds the periods in a working folder without the GUI, using
+// the same rules as Step 1: one period per MOV file (sorted by name), with
+// chapters read from the MOV itself, a <name>_metadata.txt file, or extracted
+// from the matching GoPro MP4 (or MAX .360) into <name>_metadata.txt.
+// A GoPro MP4 with no MOV of the same name is used directly (see DirectPeriod).
+// Videos listed in excluded are left out, as are backup copies of another
+// video (see FindDuplicateVideos), and copies and MOV files with no usable
+// metadata are skipped and reported in the warnings. MOVs are probed one at a
+// time; see DiscoverPeriodsWith to probe several at once.
+func DiscoverPeriods(ff *ffmpeg.FFmpeg, folder string, excluded []string) ([]Period, []string, error) {
+	return DiscoverPeriodsWith(ff, folder, excluded, DiscoverOptions{})
+}
+
+// DiscoverPeriodsWith is DiscoverPeriods with options, e.g. to probe several
+// MOVs at once
+func DiscoverPeriodsWith(ff *ffmpeg.FFmpeg, folder string, excluded []string, opts DiscoverOptions) ([]Period, []string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read folder: %w", err)
+	}
+
+	var movFiles []string
+	mp4Files := make(map[string]string)  // Base name -> path
+	metaFiles := make(map[string]string) // Base name -> path
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		baseName := strings.TrimSuffix(name, filepath.Ext(name))
+		fullPath := filepath.Join(folder, name)
+
+		switch {
+		case ext == ".mov":
+			if !containsPath(excluded, fullPath) {
+				movFiles = append(movFiles, fullPath)
+			}
+		case (ext == ".mp4" || ext == ffmpeg.Max360Ext) && !strings.HasSuffix(baseName, "_metadata"):
+			mp4Files[baseName] = fullPath
+		case ext == ".txt" && strings.HasSuffix(baseName, "_metadata"):
+			metaFiles[strings.TrimSuffix(baseName, "_metadata")] = fullPath
+		}
+	}
+
+	// GoPro MP4s never converted to a MOV are periods of their own
+	videos := append([]string{}, movFiles...)
+	converted := make(map[string]bool)
+	for _, movPath := range movFiles {
+		converted[strings.TrimSuffix(filepath.Base(movPath), filepath.Ext(movPath))] = true
+	}
+	for baseName, mp4Path := range mp4Files {
+		if !converted[baseName] && strings.EqualFold(filepath.Ext(mp4Path), ".mp4") && !containsPath(excluded, mp4Path) {
+			videos = append(videos, mp4Path)
+		}
+	}
+	// A copy of a video would be a bogus extra period
+	var warnings []string
+	duplicates := FindDuplicateVideos(ff, videos)
+	for _, d := range duplicates {
+		warnings = append(warnings, fmt.Sprintf("%s, left out", d))
+	}
+	videos = WithoutDuplicates(videos, duplicates)
+
+	sort.Slice(videos, func(i, j int) bool { return filepath.Base(videos[i]) < filepath.Base(videos[j]) })
+	infos := probeVideos(ff, videos, opts.Workers)
+
+	var periods []Period
+	for i, videoPath := range videos {
+		name := fmt.Sprintf("%dPeriod", i+1)
+		if !strings.EqualFold(filepath.Ext(videoPath), ".mov") {
+			periods = append(periods, DirectPeriod(name, videoPath))
+			continue
+		}
+
+		baseName := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+		p := Period{
+			Name:      name,
+			VideoFile: videoPath,
+		}
+
+		if info := infos[i]; info != nil && info.HasChapters && info.HasTimecode {
+			p.UseMovMetadata = true
+			p.MetadataFile = videoPath
+			p.SourceGoPro = videoPath
+			periods = append(periods, p)
+			continue
+		}
+
+		mp4Path, hasMP4 := mp4Files[baseName]
+		metaPath, hasMeta := metaFiles[baseName]
+		if !hasMeta && hasMP4 {
+			metaPath = filepath.Join(folder, baseName+"_metadata.txt")
+			if err := ff.ExtractMetadata(mp4Path, metaPath); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: metadata extraction failed: %v", filepath.Base(videoPath), err))
+				continue
+			}
+			hasMeta = true
+		}
+		if !hasMeta && opts.Bare && infos[i] != nil && infos[i].HasTimecode {
+			p.UseMovMetadata = true
+			p.MetadataFile = videoPath
+			p.SourceGoPro = videoPath
+			periods = append(periods, p)
+			continue
+		}
+		if !hasMeta {
+			warnings = append(warnings, fmt.Sprintf("%s: no chapter metadata found", filepath.Base(videoPath)))
+			continue
+		}
+
+		p.MetadataFile = metaPath
+		if hasMP4 {
+			p.SourceGoPro = mp4Path
+		} else {
+			p.SourceGoPro = videoPath
+			p.UseMovMetadata = true
+		}
+		periods = append(periods, p)
+	}
+
+	if len(videos) == 0 {
+		return nil, nil, fmt.Errorf("no MOV or GoPro MP4 files found in %s", folder)
+	}
+	return periods, warnings, nil
+}
+
+// probeVideos checks the metadata of the MOVs among videos, up to workers at
+// a time. The result is indexed like videos, nil where a video wasn't probed
+// or its probe failed.
+func probeVideos(ff *ffmpeg.FFmpeg, videos []string, workers int) []*ffmpeg.VideoMetadataInfo {
+	infos := make([]*ffmpeg.VideoMetadataInfo, len(videos))
+	queue := make(chan int, len(videos))
+	for i, videoPath := range videos {
+		if strings.EqualFold(filepath.Ext(videoPath), ".mov") {
+			queue <- i
+		}
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1) && w < len(queue); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				if info, err := ff.CheckVideoMetadata(videos[i]); err == nil {
+					infos[i] = info
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return infos
+}
+
+// containsPath reports whether paths contains path
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if filepath.Clean(p) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
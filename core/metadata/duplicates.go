@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// DuplicateVideo is a video that looks like a backup copy of another in the
+// same folder (e.g. "GX010092 (1).MP4" next to "GX010092.MP4")
+type DuplicateVideo struct {
+	Path     string // The copy, left out of the periods
+	Original string // The video it copies, used instead
+}
+
+// String describes the copy for warnings
+func (d DuplicateVideo) String() string {
+	return fmt.Sprintf("%s is a copy of %s", filepath.Base(d.Path), filepath.Base(d.Original))
+}
+
+// copySuffix matches the names copies get from Windows, macOS and browsers:
+// "name (1)", "name - Copy", "name copy 2"
+var copySuffix = regexp.MustCompile(`(?i)(\s*\(\d+\)|\s*-?\s*copy(\s*\(?\d+\)?)?)$`)
+
+// duplicateDurationTolerance is how far apart (in seconds) two probed lengths
+// may be for the videos to count as the same recording
+const duplicateDurationTolerance = 0.05
+
+// FindDuplicateVideos finds the videos among paths that are copies of another:
+// the same extension and size, and where both could be probed, the same length
+// and timecode. Only videos whose size matches another's are probed, so this
+// is cheap for a folder without copies. Of each set of copies, the one whose
+// name doesn't look like a copy is kept (the shortest name, then the first
+// alphabetically), and the rest are returned, sorted by path.
+func FindDuplicateVideos(ff *ffmpeg.FFmpeg, paths []string) []DuplicateVideo {
+	type sizeKey struct {
+		ext  string
+		size int64
+	}
+	bySize := make(map[sizeKey][]string)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() == 0 {
+			continue
+		}
+		key := sizeKey{strings.ToLower(filepath.Ext(path)), info.Size()}
+		bySize[key] = append(bySize[key], path)
+	}
+
+	var duplicates []DuplicateVideo
+	for _, same := range bySize {
+		if len(same) < 2 {
+			continue
+		}
+		sort.Slice(same, func(i, j int) bool { return originalFirst(same[i], same[j]) })
+
+		probes := make(map[string]*ffmpeg.VideoMetadataInfo)
+		probe := func(path string) *ffmpeg.VideoMetadataInfo {
+			if info, ok := probes[path]; ok {
+				return info
+			}
+			info, _ := ff.CheckVideoMetadata(path)
+			probes[path] = info
+			return info
+		}
+
+		// Each video is compared with the originals kept so far
+		var originals []string
+		for _, path := range same {
+			copied := ""
+			for _, original := range originals {
+				if sameRecording(probe(original), probe(path)) {
+					copied = original
+					break
+				}
+			}
+			if copied == "" {
+				originals = append(originals, path)
+				continue
+			}
+			duplicates = append(duplicates, DuplicateVideo{Path: path, Original: copied})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Path < duplicates[j].Path })
+	return duplicates
+}
+
+// originalFirst orders copies of a video so the likely original comes first
+func originalFirst(a, b string) bool {
+	aBase := strings.TrimSuffix(filepath.Base(a), filepath.Ext(a))
+	bBase := strings.TrimSuffix(filepath.Base(b), filepath.Ext(b))
+	if aCopy, bCopy := copySuffix.MatchString(aBase), copySuffix.MatchString(bBase); aCopy != bCopy {
+		return bCopy
+	}
+	if len(aBase) != len(bBase) {
+		return len(aBase) < len(bBase)
+	}
+	return aBase < bBase
+}
+
+// sameRecording reports whether two probed videos of the same size can be the
+// same recording. Whatever either probe couldn't read doesn't count against it.
+func sameRecording(a, b *ffmpeg.VideoMetadataInfo) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	if a.Duration > 0 && b.Duration > 0 && math.Abs(a.Duration-b.Duration) > duplicateDurationTolerance {
+		return false
+	}
+	if a.HasTimecode && b.HasTimecode && a.Timecode != b.Timecode {
+		return false
+	}
+	return true
+}
+
+// WithoutDuplicates returns paths without the copies in duplicates
+func WithoutDuplicates(paths []string, duplicates []DuplicateVideo) []string {
+	var kept []string
+	for _, path := range paths {
+		copied := false
+		for _, d := range duplicates {
+			if filepath.Clean(d.Path) == filepath.Clean(path) {
+				copied = true
+				break
+			}
+		}
+		if !copied {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GameInfo describes a game: who played, when and where, and how it ended.
+// It is entered in Step 1 and fills in name templates, tags, the scoreboard,
+// reel descriptions and reports. Empty fields are unknown.
+type GameInfo struct {
+	Name       string `json:"name,omitempty"`        // e.g. "Hawks vs Wolves - Semifinal" ("" = from the teams)
+	Team       string `json:"team,omitempty"`        // Home team ("" = the team name in the settings)
+	Opponent   string `json:"opponent,omitempty"`    // Away team
+	Date       string `json:"date,omitempty"`        // As 2006-01-02 ("" = the day of the first highlight)
+	Location   string `json:"location,omitempty"`    // Rink or arena
+	FinalScore string `json:"final_score,omitempty"` // Home-away, e.g. "4-2" ("" = the last score entered)
+}
+
+// Matchup returns the teams as "Hawks vs Wolves", "vs Wolves" without a
+// team name, or "" without an opponent
+func (g GameInfo) Matchup() string {
+	if g.Opponent == "" {
+		return ""
+	}
+	return strings.TrimSpace(g.Team + " vs " + g.Opponent)
+}
+
+// Day returns the game date, or zero if none was entered
+func (g GameInfo) Day() time.Time {
+	day, err := ParseGameDate(g.Date)
+	if err != nil {
+		return time.Time{}
+	}
+	return day
+}
+
+// Summary describes the game in one line for descriptions and reports, e.g.
+// "Hawks vs Wolves, 2026-10-18 at Centennial Arena - Final 4-2"
+func (g GameInfo) Summary() string {
+	summary := g.Matchup()
+	if g.Date != "" {
+		summary = strings.TrimPrefix(summary+", "+g.Date, ", ")
+	}
+	if g.Location != "" {
+		summary = strings.TrimSpace(summary + " at " + g.Location)
+	}
+	if g.FinalScore != "" {
+		summary = strings.TrimPrefix(summary+" - Final "+g.FinalScore, " - ")
+	}
+	return summary
+}
+
+// ParseGameDate parses a game date as YYYY-MM-DD ("" = none)
+func ParseGameDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", s)
+	}
+	return day, nil
+}
+
+// ParseFinalScore parses a final score as home-away, e.g. "4-2"
+func ParseFinalScore(s string) (home, away int, err error) {
+	if n, _ := fmt.Sscanf(strings.TrimSpace(s), "%d-%d", &home, &away); n != 2 || home < 0 || away < 0 {
+		return 0, 0, fmt.Errorf("invalid score %q (expected e.g. 4-2)", s)
+	}
+	return home, away, nil
+}
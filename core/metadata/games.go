@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GameSplit divides the periods of a working folder that holds more than one
+// game (e.g. a morning and an afternoon game recorded onto the same card) into
+// separate games
+type GameSplit struct {
+	// MinGap starts a new game where a period's recording starts this long
+	// after the previous one ended (0 = don't split on gaps)
+	MinGap time.Duration
+	// Starts are the clock times of day games started at. A new game begins
+	// with the period recording at (or first after) each one.
+	Starts []time.Duration
+}
+
+// enabled returns true if the split can do anything
+func (s GameSplit) enabled() bool {
+	return s.MinGap > 0 || len(s.Starts) > 0
+}
+
+// gameBreak returns true if a new game starts between two consecutive periods.
+// Periods whose clock time is unknown never start a new game.
+func (s GameSplit) gameBreak(prev, next PeriodSpan) bool {
+	if prev.Start.IsZero() || next.Start.IsZero() {
+		return false
+	}
+	if s.MinGap > 0 && next.Start.Sub(prev.End()) >= s.MinGap {
+		return true
+	}
+	prevEnd, nextEnd := TimeOfDay(prev.End()), TimeOfDay(next.End())
+	for _, start := range s.Starts {
+		if start >= prevEnd && start < nextEnd {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitGames splits an analysis into one result per game, in recording order.
+// Each game's periods are renumbered from "1Period" and its chapters numbered
+// from 1 in GlobalOrder, so every game gets its own clip set and reel. A
+// folder holding a single game returns result itself.
+func (a *Analyzer) SplitGames(result *AnalysisResult, split GameSplit) []*AnalysisResult {
+	if !split.enabled() || len(result.Periods) < 2 {
+		return []*AnalysisResult{result}
+	}
+
+	spans := a.periodSpans(result.Periods)
+	var games [][]int // Period indexes of each game
+	for i := range result.Periods {
+		if i == 0 || split.gameBreak(spans[i-1], spans[i]) {
+			games = append(games, nil)
+		}
+		games[len(games)-1] = append(games[len(games)-1], i)
+	}
+	if len(games) == 1 {
+		return []*AnalysisResult{result}
+	}
+
+	var results []*AnalysisResult
+	for _, indexes := range games {
+		game := &AnalysisResult{}
+		names := make(map[string]string) // Old period name -> name in the game
+		numbers := make(map[int]int)     // Old period number -> number in the game
+		var gameSpans []PeriodSpan
+		for _, i := range indexes {
+			period := result.Periods[i]
+			period.Name = renumberPeriod(period.Name, numbers)
+			names[result.Periods[i].Name] = period.Name
+			game.Periods = append(game.Periods, period)
+
+			span := spans[i]
+			span.Name = period.Name
+			gameSpans = append(gameSpans, span)
+		}
+
+		for _, ch := range result.Chapters {
+			if name, ok := names[ch.Period]; ok {
+				ch.Period = name
+				ch.GlobalOrder = len(game.Chapters) + 1
+				game.Chapters = append(game.Chapters, ch)
+			}
+		}
+		for _, ch := range result.DroppedChapters {
+			if name, ok := names[ch.Period]; ok {
+				ch.Period = name
+				game.DroppedChapters = append(game.DroppedChapters, ch)
+			}
+		}
+		game.Warnings = ValidatePeriods(gameSpans, game.Chapters)
+		results = append(results, game)
+	}
+	return results
+}
+
+// renumberPeriod renames a period ("3Period", "3Period-2") to its number within
+// its game, giving each old number the next new one the first time it is seen.
+// Names that aren't numbered are kept.
+func renumberPeriod(name string, numbers map[int]int) string {
+	var n int
+	if c, _ := fmt.Sscanf(name, "%dPeriod", &n); c != 1 {
+		return name
+	}
+	prefix := fmt.Sprintf("%dPeriod", n)
+	if !strings.HasPrefix(name, prefix) {
+		return name
+	}
+	if _, ok := numbers[n]; !ok {
+		numbers[n] = len(numbers) + 1
+	}
+	return fmt.Sprintf("%dPeriod", numbers[n]) + strings.TrimPrefix(name, prefix)
+}
+
+// GameStart returns the clock time of a game's first highlight (zero if none)
+func (result *AnalysisResult) GameStart() time.Time {
+	var start time.Time
+	for _, ch := range result.Chapters {
+		if !ch.ClockTime.IsZero() && (start.IsZero() || ch.ClockTime.Before(start)) {
+			start = ch.ClockTime
+		}
+	}
+	return start
+}
@@ -0,0 +1,496 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SpeedBurstLabel starts the label of chapters suggested from GPS speed bursts
+// rather than marked on the camera (see MergeSpeedBursts)
+const SpeedBurstLabel = "Speed burst"
+
+const (
+	// speedBurstMinDuration is how long the speed must stay over the threshold
+	// to count as a burst, so single noisy GPS fixes don't
+	speedBurstMinDuration = 1500 * time.Millisecond
+	// speedBurstGap joins bursts this close together into one
+	speedBurstGap = 5 * time.Second
+	// speedBurstMatchWindow is how close to a burst's end an existing chapter
+	// must be to cover it (HiLights are pressed just after the play)
+	speedBurstMatchWindow = 10 * time.Second
+)
+
+// SpeedSample is one GPS fix: the 2D ground speed at a video offset
+type SpeedSample struct {
+	At    time.Duration
+	Speed float64 // Meters per second
+}
+
+// SpeedBurst is a stretch of a video where the camera moved faster than the
+// threshold (e.g. a helmet camera on a breakaway)
+type SpeedBurst struct {
+	Start time.Duration
+	End   time.Duration
+	Peak  float64 // Highest speed in km/h
+}
+
+// GPSFix is one GPS position at a video offset
+type GPSFix struct {
+	At  time.Duration
+	Lat float64 // Degrees
+	Lon float64 // Degrees
+}
+
+// gps5Entry is one GPS5 entry of the telemetry, scaled: latitude, longitude,
+// altitude, 2D speed and 3D speed
+type gps5Entry struct {
+	At     time.Duration
+	Values [5]float64
+}
+
+// ReadGPSSpeeds reads the GPS5 samples of a GoPro MP4's GPMF telemetry track
+// and returns the 2D ground speed at each. Samples without a 2D or 3D fix are
+// skipped. Returns no samples (and no error) if the file has no telemetry.
+func ReadGPSSpeeds(path string) ([]SpeedSample, error) {
+	entries, err := readGPS5(path)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]SpeedSample, len(entries))
+	for i, e := range entries {
+		samples[i] = SpeedSample{At: e.At, Speed: e.Values[3]}
+	}
+	return samples, nil
+}
+
+// ReadGPSFixes reads the positions of a GoPro MP4's GPS5 telemetry, like
+// ReadGPSSpeeds reads the speeds
+func ReadGPSFixes(path string) ([]GPSFix, error) {
+	entries, err := readGPS5(path)
+	if err != nil {
+		return nil, err
+	}
+	fixes := make([]GPSFix, len(entries))
+	for i, e := range entries {
+		fixes[i] = GPSFix{At: e.At, Lat: e.Values[0], Lon: e.Values[1]}
+	}
+	return fixes, nil
+}
+
+// readGPS5 reads the GPS5 entries of a GoPro MP4's GPMF telemetry track (see
+// ReadGPSSpeeds)
+func readGPS5(path string) ([]gps5Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat video: %w", err)
+	}
+
+	moovStart, moovEnd, found, err := findBox(file, 0, info.Size(), "moov")
+	if err != nil || !found {
+		return nil, fmt.Errorf("no moov box in %s", path)
+	}
+
+	for pos := moovStart; ; {
+		trakStart, trakEnd, found, err := findBox(file, pos, moovEnd, "trak")
+		if err != nil || !found {
+			return nil, nil // No telemetry track
+		}
+		pos = trakEnd
+
+		track, err := readTelemetryTrack(file, trakStart, trakEnd)
+		if err != nil {
+			return nil, err
+		}
+		if track == nil {
+			continue // Not the GPMF track
+		}
+
+		var entries []gps5Entry
+		for i, s := range track.samples {
+			data := make([]byte, s.size)
+			if _, err := file.ReadAt(data, s.offset); err != nil {
+				return nil, fmt.Errorf("failed to read telemetry sample %d: %w", i, err)
+			}
+			entries = append(entries, gpmfGPS5(data, s.at, s.duration)...)
+		}
+		return entries, nil
+	}
+}
+
+// telemetrySample is where one GPMF sample is in the file and the video time it covers
+type telemetrySample struct {
+	offset   int64
+	size     int64
+	at       time.Duration
+	duration time.Duration
+}
+
+// telemetryTrack is the sample table of a GPMF track
+type telemetryTrack struct {
+	samples []telemetrySample
+}
+
+// readTelemetryTrack reads a trak box's sample table if it is a GPMF ("gpmd")
+// metadata track, or returns nil for any other track
+func readTelemetryTrack(r io.ReaderAt, trakStart, trakEnd int64) (*telemetryTrack, error) {
+	mdiaStart, mdiaEnd, found, err := findBox(r, trakStart, trakEnd, "mdia")
+	if err != nil || !found {
+		return nil, nil
+	}
+	minfStart, minfEnd, found, err := findBox(r, mdiaStart, mdiaEnd, "minf")
+	if err != nil || !found {
+		return nil, nil
+	}
+	stblStart, stblEnd, found, err := findBox(r, minfStart, minfEnd, "stbl")
+	if err != nil || !found {
+		return nil, nil
+	}
+
+	// The sample description says what the track holds
+	stsd, err := readBox(r, stblStart, stblEnd, "stsd")
+	if err != nil || len(stsd) < 16 || string(stsd[12:16]) != "gpmd" {
+		return nil, nil
+	}
+
+	mdhd, err := readBox(r, mdiaStart, mdiaEnd, "mdhd")
+	if err != nil {
+		return nil, err
+	}
+	var timescale uint32
+	if len(mdhd) >= 24 && mdhd[0] == 1 {
+		timescale = binary.BigEndian.Uint32(mdhd[20:])
+	} else if len(mdhd) >= 16 {
+		timescale = binary.BigEndian.Uint32(mdhd[12:])
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("telemetry track has no timescale")
+	}
+
+	stts, err := readBox(r, stblStart, stblEnd, "stts")
+	if err != nil {
+		return nil, err
+	}
+	stsc, err := readBox(r, stblStart, stblEnd, "stsc")
+	if err != nil {
+		return nil, err
+	}
+	stsz, err := readBox(r, stblStart, stblEnd, "stsz")
+	if err != nil {
+		return nil, err
+	}
+	var chunks []int64
+	if stco, err := readBox(r, stblStart, stblEnd, "stco"); err == nil {
+		for _, v := range tableEntries(stco, 4) {
+			chunks = append(chunks, int64(binary.BigEndian.Uint32(v)))
+		}
+	} else if co64, err := readBox(r, stblStart, stblEnd, "co64"); err == nil {
+		for _, v := range tableEntries(co64, 8) {
+			chunks = append(chunks, int64(binary.BigEndian.Uint64(v)))
+		}
+	} else {
+		return nil, fmt.Errorf("telemetry track has no chunk offsets")
+	}
+
+	// Sample sizes: one fixed size, or a table
+	if len(stsz) < 12 {
+		return nil, fmt.Errorf("invalid stsz box")
+	}
+	fixedSize := int64(binary.BigEndian.Uint32(stsz[4:]))
+	count := int(binary.BigEndian.Uint32(stsz[8:]))
+	sizes := make([]int64, count)
+	for i := range sizes {
+		switch {
+		case fixedSize > 0:
+			sizes[i] = fixedSize
+		case 12+(i+1)*4 <= len(stsz):
+			sizes[i] = int64(binary.BigEndian.Uint32(stsz[12+i*4:]))
+		}
+	}
+
+	// Sample durations, run-length coded
+	var durations []uint32
+	for _, e := range tableEntries(stts, 8) {
+		n := binary.BigEndian.Uint32(e)
+		delta := binary.BigEndian.Uint32(e[4:])
+		for j := uint32(0); j < n && len(durations) < count; j++ {
+			durations = append(durations, delta)
+		}
+	}
+
+	// Samples per chunk, run-length coded by first chunk (1-based)
+	type chunkRun struct{ firstChunk, perChunk int }
+	var runs []chunkRun
+	for _, e := range tableEntries(stsc, 12) {
+		runs = append(runs, chunkRun{
+			firstChunk: int(binary.BigEndian.Uint32(e)),
+			perChunk:   int(binary.BigEndian.Uint32(e[4:])),
+		})
+	}
+
+	track := &telemetryTrack{}
+	var ticks uint64
+	sample := 0
+	for c := 0; c < len(chunks) && sample < count; c++ {
+		perChunk := 0
+		for _, run := range runs {
+			if run.firstChunk <= c+1 {
+				perChunk = run.perChunk
+			}
+		}
+		offset := chunks[c]
+		for j := 0; j < perChunk && sample < count; j++ {
+			var delta uint32
+			if sample < len(durations) {
+				delta = durations[sample]
+			}
+			track.samples = append(track.samples, telemetrySample{
+				offset:   offset,
+				size:     sizes[sample],
+				at:       time.Duration(ticks * uint64(time.Second) / uint64(timescale)),
+				duration: time.Duration(uint64(delta) * uint64(time.Second) / uint64(timescale)),
+			})
+			offset += sizes[sample]
+			ticks += uint64(delta)
+			sample++
+		}
+	}
+	return track, nil
+}
+
+// readBox reads the payload of the first box of a type between start and end
+func readBox(r io.ReaderAt, start, end int64, boxType string) ([]byte, error) {
+	boxStart, boxEnd, found, err := findBox(r, start, end, boxType)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no %s box", boxType)
+	}
+	return readRange(r, boxStart, boxEnd)
+}
+
+// tableEntries splits a full box holding an entry count and a table of
+// fixed-size entries into its entries
+func tableEntries(payload []byte, entrySize int) [][]byte {
+	if len(payload) < 8 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint32(payload[4:]))
+	var entries [][]byte
+	for i := 0; i < count && 8+(i+1)*entrySize <= len(payload); i++ {
+		entries = append(entries, payload[8+i*entrySize:8+(i+1)*entrySize])
+	}
+	return entries
+}
+
+// gpmfGPS5 returns the GPS5 entries in one GPMF sample, spread evenly over
+// the time it covers
+func gpmfGPS5(data []byte, at, duration time.Duration) []gps5Entry {
+	var values [][5]float64
+	walkGPMFStreams(data, func(stream []byte) {
+		values = append(values, gps5Values(stream)...)
+	})
+
+	entries := make([]gps5Entry, len(values))
+	for i, v := range values {
+		entries[i] = gps5Entry{
+			At:     at + duration*time.Duration(i)/time.Duration(len(values)),
+			Values: v,
+		}
+	}
+	return entries
+}
+
+// walkGPMFStreams calls fn with the payload of each STRM container in GPMF data
+func walkGPMFStreams(data []byte, fn func(stream []byte)) {
+	forEachKLV(data, func(key string, typ byte, structSize, repeat int, payload []byte) {
+		switch {
+		case typ == 0 && key == "STRM":
+			fn(payload)
+		case typ == 0:
+			walkGPMFStreams(payload, fn)
+		}
+	})
+}
+
+// gps5Values returns the entries of a STRM's GPS5 values, each scaled by its
+// SCAL value, or nothing if the stream has no GPS fix
+func gps5Values(stream []byte) [][5]float64 {
+	scales := []float64{1}
+	fix := uint32(3) // Assume a fix unless GPSF says otherwise
+	var entries [][5]float64
+	forEachKLV(stream, func(key string, typ byte, structSize, repeat int, payload []byte) {
+		switch key {
+		case "SCAL":
+			scales = gpmfNumbers(typ, payload)
+		case "GPSF":
+			if len(payload) >= 4 {
+				fix = binary.BigEndian.Uint32(payload)
+			}
+		case "GPS5":
+			if typ != 'l' || structSize != 20 {
+				return
+			}
+			// One scale for every value, or one each
+			var scale [5]float64
+			for j := range scale {
+				scale[j] = scales[0]
+				if len(scales) >= 5 {
+					scale[j] = scales[j]
+				}
+				if scale[j] == 0 {
+					return
+				}
+			}
+			for i := 0; i+20 <= len(payload); i += 20 {
+				var entry [5]float64
+				for j := range entry {
+					entry[j] = float64(int32(binary.BigEndian.Uint32(payload[i+j*4:]))) / scale[j]
+				}
+				entries = append(entries, entry)
+			}
+		}
+	})
+	if fix < 2 {
+		return nil
+	}
+	return entries
+}
+
+// gpmfNumbers decodes a GPMF payload of integers ("l", "L", "s" or "S")
+func gpmfNumbers(typ byte, payload []byte) []float64 {
+	var numbers []float64
+	switch typ {
+	case 'l', 'L':
+		for i := 0; i+4 <= len(payload); i += 4 {
+			v := binary.BigEndian.Uint32(payload[i:])
+			if typ == 'l' {
+				numbers = append(numbers, float64(int32(v)))
+			} else {
+				numbers = append(numbers, float64(v))
+			}
+		}
+	case 's', 'S':
+		for i := 0; i+2 <= len(payload); i += 2 {
+			v := binary.BigEndian.Uint16(payload[i:])
+			if typ == 's' {
+				numbers = append(numbers, float64(int16(v)))
+			} else {
+				numbers = append(numbers, float64(v))
+			}
+		}
+	}
+	if len(numbers) == 0 {
+		return []float64{1}
+	}
+	return numbers
+}
+
+// forEachKLV calls fn for each KLV entry at the top level of GPMF data
+func forEachKLV(data []byte, fn func(key string, typ byte, structSize, repeat int, payload []byte)) {
+	for pos := 0; pos+8 <= len(data); {
+		key := string(data[pos : pos+4])
+		typ := data[pos+4]
+		structSize := int(data[pos+5])
+		repeat := int(binary.BigEndian.Uint16(data[pos+6 : pos+8]))
+
+		payloadLen := structSize * repeat
+		payloadStart := pos + 8
+		if payloadStart+payloadLen > len(data) {
+			return
+		}
+		fn(key, typ, structSize, repeat, data[payloadStart:payloadStart+payloadLen])
+
+		// Payloads are padded to 32-bit alignment
+		pos = payloadStart + (payloadLen+3)&^3
+	}
+}
+
+// DetectSpeedBursts finds the stretches where the speed stays over minKmh for
+// at least speedBurstMinDuration. Bursts close together are joined.
+func DetectSpeedBursts(samples []SpeedSample, minKmh float64) []SpeedBurst {
+	if minKmh <= 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].At < samples[j].At })
+
+	var bursts []SpeedBurst
+	var current *SpeedBurst
+	finish := func() {
+		if current != nil && current.End-current.Start >= speedBurstMinDuration {
+			if n := len(bursts); n > 0 && current.Start-bursts[n-1].End <= speedBurstGap {
+				bursts[n-1].End = current.End
+				bursts[n-1].Peak = max(bursts[n-1].Peak, current.Peak)
+			} else {
+				bursts = append(bursts, *current)
+			}
+		}
+		current = nil
+	}
+
+	for _, s := range samples {
+		kmh := s.Speed * 3.6
+		if kmh < minKmh {
+			finish()
+			continue
+		}
+		if current == nil {
+			current = &SpeedBurst{Start: s.At}
+		}
+		current.End = s.At
+		current.Peak = max(current.Peak, kmh)
+	}
+	finish()
+	return bursts
+}
+
+// MergeSpeedBursts adds a suggested chapter at the end of each burst that no
+// existing chapter covers (one within speedBurstMatchWindow of its end).
+// Added chapters are labelled SpeedBurstLabel with the peak speed, and
+// numbered after the existing ones (see numberAdded).
+func MergeSpeedBursts(chapters []Chapter, bursts []SpeedBurst) ([]Chapter, int) {
+	added := 0
+	for _, b := range bursts {
+		covered := false
+		for _, ch := range chapters {
+			diff := ch.VideoTime - b.End
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff <= speedBurstMatchWindow {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		chapters = append(chapters, Chapter{
+			StartMs:   b.End.Milliseconds(),
+			VideoTime: b.End.Truncate(time.Millisecond),
+			Label:     fmt.Sprintf("%s (%.0f km/h)", SpeedBurstLabel, b.Peak),
+		})
+		added++
+	}
+
+	numberAdded(chapters, added)
+	return chapters, added
+}
+
+// IsSpeedBurst returns true if a chapter was suggested from a GPS speed burst
+// rather than marked on the camera
+func IsSpeedBurst(ch Chapter) bool {
+	return strings.HasPrefix(ch.Label, SpeedBurstLabel)
+}
@@ -0,0 +1,210 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// quikMatchToleranceMs is how close a HiLight tag must be to an existing chapter
+// to be considered the same marker
+const quikMatchToleranceMs = 500
+
+// QuikHiLightLabel marks chapters that came from HiLight tags rather than the
+// exported chapter metadata (typically tags added later in the GoPro Quik app)
+const QuikHiLightLabel = "HiLight (Quik)"
+
+// ReadHiLights reads the HiLight tags stored in a GoPro MP4's moov/udta box and
+// returns their offsets in milliseconds, sorted.
+//
+// Two layouts are supported:
+//   - HMMT box: uint32 count followed by count uint32 millisecond offsets. Older
+//     cameras write in-camera tags here, and the Quik app rewrites it when tags
+//     are added on the phone.
+//   - GPMF box: KLV metadata where newer cameras and Quik store manual tags
+//     under HLMT/MANL as uint32 milliseconds.
+func ReadHiLights(path string) ([]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open video: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat video: %w", err)
+	}
+
+	moovStart, moovEnd, found, err := findBox(file, 0, info.Size(), "moov")
+	if err != nil || !found {
+		return nil, fmt.Errorf("no moov box in %s", path)
+	}
+	udtaStart, udtaEnd, found, err := findBox(file, moovStart, moovEnd, "udta")
+	if err != nil || !found {
+		return nil, nil // No user data, so no HiLights
+	}
+
+	seen := make(map[int64]bool)
+	var tags []int64
+	add := func(ms int64) {
+		if ms > 0 && !seen[ms] {
+			seen[ms] = true
+			tags = append(tags, ms)
+		}
+	}
+
+	if start, end, found, _ := findBox(file, udtaStart, udtaEnd, "HMMT"); found {
+		data, err := readRange(file, start, end)
+		if err == nil && len(data) >= 4 {
+			count := int(binary.BigEndian.Uint32(data))
+			for i := 0; i < count && 4+(i+1)*4 <= len(data); i++ {
+				add(int64(binary.BigEndian.Uint32(data[4+i*4:])))
+			}
+		}
+	}
+
+	if start, end, found, _ := findBox(file, udtaStart, udtaEnd, "GPMF"); found {
+		data, err := readRange(file, start, end)
+		if err == nil {
+			for _, ms := range gpmfManualTags(data, false) {
+				add(ms)
+			}
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	return tags, nil
+}
+
+// findBox scans the ISO BMFF boxes between start and end for one of the given
+// type and returns the range of its payload
+func findBox(r io.ReaderAt, start, end int64, boxType string) (int64, int64, bool, error) {
+	header := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return 0, 0, false, err
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		typ := string(header[4:8])
+		headerLen := int64(8)
+
+		switch size {
+		case 0: // Box extends to the end of its parent
+			size = end - pos
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return 0, 0, false, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+		if size < headerLen || pos+size > end {
+			return 0, 0, false, fmt.Errorf("invalid %q box at offset %d", typ, pos)
+		}
+
+		if typ == boxType {
+			return pos + headerLen, pos + size, true, nil
+		}
+		pos += size
+	}
+	return 0, 0, false, nil
+}
+
+// readRange reads a box payload into memory (udta boxes are small)
+func readRange(r io.ReaderAt, start, end int64) ([]byte, error) {
+	if end-start > 16*1024*1024 {
+		return nil, fmt.Errorf("box too large (%d bytes)", end-start)
+	}
+	data := make([]byte, end-start)
+	if _, err := r.ReadAt(data, start); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// gpmfManualTags walks GPMF KLV data and returns the values of MANL entries
+// found inside an HLMT container (inHLMT is set while inside one)
+func gpmfManualTags(data []byte, inHLMT bool) []int64 {
+	var tags []int64
+	for pos := 0; pos+8 <= len(data); {
+		key := string(data[pos : pos+4])
+		typ := data[pos+4]
+		structSize := int(data[pos+5])
+		repeat := int(binary.BigEndian.Uint16(data[pos+6 : pos+8]))
+
+		payloadLen := structSize * repeat
+		payloadStart := pos + 8
+		if payloadStart+payloadLen > len(data) {
+			break
+		}
+		payload := data[payloadStart : payloadStart+payloadLen]
+
+		switch {
+		case typ == 0: // Nested container
+			tags = append(tags, gpmfManualTags(payload, inHLMT || key == "HLMT")...)
+		case inHLMT && key == "MANL" && (typ == 'L' || typ == 'l') && structSize == 4:
+			for i := 0; i+4 <= len(payload); i += 4 {
+				tags = append(tags, int64(binary.BigEndian.Uint32(payload[i:])))
+			}
+		}
+
+		// Payloads are padded to 32-bit alignment
+		pos = payloadStart + (payloadLen+3)&^3
+	}
+	return tags
+}
+
+// MergeHiLights adds HiLight tags that don't already have a matching chapter.
+// Added chapters are labelled QuikHiLightLabel and numbered after the highest
+// existing number, so the camera's chapters keep theirs (see numberAdded).
+func MergeHiLights(chapters []Chapter, hilightsMs []int64) ([]Chapter, int) {
+	added := 0
+	for _, ms := range hilightsMs {
+		matched := false
+		for _, ch := range chapters {
+			diff := ch.StartMs - ms
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff <= quikMatchToleranceMs {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		chapters = append(chapters, Chapter{
+			StartMs:   ms,
+			VideoTime: time.Duration(ms) * time.Millisecond,
+			Label:     QuikHiLightLabel,
+		})
+		added++
+	}
+
+	numberAdded(chapters, added)
+	return chapters, added
+}
+
+// numberAdded numbers the last added of chapters in time order after the
+// highest number among the others, which keep theirs (clip names and the
+// Review report refer to them), then sorts all chapters by time
+func numberAdded(chapters []Chapter, added int) {
+	if added == 0 {
+		return
+	}
+	existing, fresh := chapters[:len(chapters)-added], chapters[len(chapters)-added:]
+	highest := 0
+	for _, ch := range existing {
+		highest = max(highest, ch.Number)
+	}
+	sort.Slice(fresh, func(i, j int) bool { return fresh[i].StartMs < fresh[j].StartMs })
+	for i := range fresh {
+		fresh[i].Number = highest + i + 1
+	}
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].StartMs < chapters[j].StartMs })
+}
@@ -0,0 +1,71 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeHiLightsKeepsNumbers(t *testing.T) {
+	// The camera's chapters as parsed, numbered by the camera
+	chapters := []Chapter{
+		chapterAt("", 1, 0, 0),
+		chapterAt("", 2, 0, 312.48),
+		chapterAt("", 3, 0, 451.2),
+	}
+	// Quik tags: one matches Ch2 within the tolerance, two are new (out of order)
+	merged, added := MergeHiLights(append([]Chapter{}, chapters...), []int64{600000, 312700, 100000})
+	if added != 2 {
+		t.Fatalf("added %d chapters, want 2", added)
+	}
+
+	want := []struct {
+		number int
+		start  int64
+		label  string
+	}{
+		{1, 0, ""},
+		{4, 100000, QuikHiLightLabel},
+		{2, 312480, ""},
+		{3, 451200, ""},
+		{5, 600000, QuikHiLightLabel},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("got %d chapters, want %d", len(merged), len(want))
+	}
+	for i, w := range want {
+		ch := merged[i]
+		if ch.Number != w.number || ch.StartMs != w.start || ch.Label != w.label {
+			t.Errorf("chapter %d = Ch%d at %dms %q, want Ch%d at %dms %q", i, ch.Number, ch.StartMs, ch.Label, w.number, w.start, w.label)
+		}
+		if ch.VideoTime != time.Duration(w.start)*time.Millisecond {
+			t.Errorf("chapter %d video time = %v, want %dms", i, ch.VideoTime, w.start)
+		}
+	}
+
+	// The camera's chapters keep their numbers after the merge
+	for _, before := range chapters {
+		found := false
+		for _, after := range merged {
+			if after.StartMs == before.StartMs {
+				found = true
+				if after.Number != before.Number {
+					t.Errorf("chapter at %dms renumbered from %d to %d", before.StartMs, before.Number, after.Number)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("chapter at %dms lost in the merge", before.StartMs)
+		}
+	}
+}
+
+func TestMergeHiLightsNothingNew(t *testing.T) {
+	chapters := []Chapter{chapterAt("", 7, 0, 10), chapterAt("", 9, 0, 20)}
+	merged, added := MergeHiLights(chapters, []int64{10200, 19900})
+	if added != 0 {
+		t.Errorf("added %d chapters, want 0", added)
+	}
+	if merged[0].Number != 7 || merged[1].Number != 9 {
+		t.Errorf("numbers = %d, %d; want 7, 9", merged[0].Number, merged[1].Number)
+	}
+}
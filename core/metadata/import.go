@@ -0,0 +1,255 @@
+package metadata
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportedEvent is an event time recorded outside the camera (e.g. by a team
+// statistician in Dartfish or a spreadsheet) that can be merged in as a Chapter
+type ImportedEvent struct {
+	Period string        // Period name, e.g. "1Period"
+	Label  string        // Event description, e.g. "Goal #12"
+	Offset time.Duration // Time from the start of the period video (when !IsClock)
+	Clock  time.Time     // Wall-clock time of day (when IsClock)
+	// IsClock is true when the event was recorded as a time of day (HH:MM:SS)
+	// rather than an elapsed time into the period (MM:SS)
+	IsClock bool
+}
+
+// ParseEventsFile reads events from a CSV or SRT file, based on its extension.
+// SRT files carry no period column, so all of their events go to defaultPeriod.
+func ParseEventsFile(path, defaultPeriod string) ([]ImportedEvent, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return ParseEventsSRT(path, defaultPeriod)
+	case ".csv", ".txt":
+		return ParseEventsCSV(path)
+	}
+	return nil, fmt.Errorf("unsupported event file type: %s", filepath.Ext(path))
+}
+
+// ParseEventsCSV reads events from a CSV file with "period, time, label" rows.
+//
+// The time column may be elapsed time into the period video (MM:SS or MM:SS.mmm)
+// or a wall-clock time of day (HH:MM:SS), which is mapped through the period
+// timecode. A header row and blank lines are skipped.
+func ParseEventsCSV(path string) ([]ImportedEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var events []ImportedEvent
+	line := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("error reading event file: %w", err)
+		}
+		if len(record) < 2 || strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		ev := ImportedEvent{Period: NormalizePeriodName(record[0])}
+		if len(record) > 2 {
+			ev.Label = strings.TrimSpace(strings.Join(record[2:], ","))
+		}
+
+		offset, clock, isClock, err := parseEventTime(record[1])
+		if err != nil {
+			if line == 1 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ev.Offset, ev.Clock, ev.IsClock = offset, clock, isClock
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+// ParseEventsSRT reads events from an SRT subtitle file. Each cue's start time
+// is taken as the offset into the period video and its text as the label.
+func ParseEventsSRT(path, period string) ([]ImportedEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer file.Close()
+
+	// 00:01:02,500 --> 00:01:05,000
+	cueRe := regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})[,.](\d{3})\s*-->`)
+
+	var events []ImportedEvent
+	var current *ImportedEvent
+	flush := func() {
+		if current != nil {
+			events = append(events, *current)
+			current = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		if line == "" {
+			flush()
+			continue
+		}
+
+		if matches := cueRe.FindStringSubmatch(line); matches != nil {
+			flush()
+			h, _ := strconv.Atoi(matches[1])
+			m, _ := strconv.Atoi(matches[2])
+			s, _ := strconv.Atoi(matches[3])
+			ms, _ := strconv.Atoi(matches[4])
+			current = &ImportedEvent{
+				Period: NormalizePeriodName(period),
+				Offset: time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+					time.Duration(s)*time.Second + time.Duration(ms)*time.Millisecond,
+			}
+			continue
+		}
+
+		// Cue text (the numeric cue index before the timing line is ignored)
+		if current != nil {
+			if current.Label != "" {
+				current.Label += " "
+			}
+			current.Label += line
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading event file: %w", err)
+	}
+
+	return events, nil
+}
+
+// NormalizePeriodName converts the period spellings used by stat sheets
+// ("1", "P1", "Period 1", "1st") to the analyzer's naming ("1Period")
+func NormalizePeriodName(name string) string {
+	name = strings.TrimSpace(name)
+	if digits := regexp.MustCompile(`\d+`).FindString(name); digits != "" {
+		n, _ := strconv.Atoi(digits)
+		return fmt.Sprintf("%dPeriod", n)
+	}
+	return name
+}
+
+// parseEventTime parses MM:SS[.mmm] as an offset or HH:MM:SS[.mmm] as a time of day
+func parseEventTime(s string) (offset time.Duration, clock time.Time, isClock bool, err error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, time.Time{}, false, fmt.Errorf("invalid event time: %q", s)
+	}
+
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, time.Time{}, false, fmt.Errorf("invalid event time: %q", s)
+	}
+	mins, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return 0, time.Time{}, false, fmt.Errorf("invalid event time: %q", s)
+	}
+
+	if len(parts) == 2 {
+		offset = time.Duration(mins)*time.Minute + time.Duration(secs*float64(time.Second))
+		return offset, time.Time{}, false, nil
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, time.Time{}, false, fmt.Errorf("invalid event time: %q", s)
+	}
+
+	// Dated today like ParseTimecodeToTime; MergeImportedEvents moves it onto
+	// the recording's date
+	now := time.Now()
+	clock = time.Date(now.Year(), now.Month(), now.Day(), hours, mins, 0, 0, time.Local).
+		Add(time.Duration(secs * float64(time.Second)))
+	return 0, clock, true, nil
+}
+
+// MergeImportedEvents converts events to Chapters and merges them into the analysis.
+// periodStarts maps each period name to the wall-clock time of its first frame.
+// Imported chapters are numbered after the period's existing chapters and all
+// chapters are re-sorted so GlobalOrder stays chronological.
+//
+// Returns the number of events added and a list of events that were skipped
+// (unknown period or before the recording started).
+func (result *AnalysisResult) MergeImportedEvents(events []ImportedEvent, periodStarts map[string]time.Time) (int, []string) {
+	nextNumber := make(map[string]int)
+	for _, ch := range result.Chapters {
+		if ch.Number >= nextNumber[ch.Period] {
+			nextNumber[ch.Period] = ch.Number + 1
+		}
+	}
+
+	var skipped []string
+	added := 0
+	for _, ev := range events {
+		start, ok := periodStarts[ev.Period]
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s %q: unknown period", ev.Period, ev.Label))
+			continue
+		}
+
+		offset := ev.Offset
+		if ev.IsClock {
+			// Event sheets only give the time of day, so take it on the period's date
+			offset = NearestDay(start, TimeOfDay(ev.Clock), start.Location()).Sub(start)
+		}
+		if offset < 0 {
+			skipped = append(skipped, fmt.Sprintf("%s %q: before recording started", ev.Period, ev.Label))
+			continue
+		}
+
+		if nextNumber[ev.Period] == 0 {
+			nextNumber[ev.Period] = 1
+		}
+		result.Chapters = append(result.Chapters, Chapter{
+			Number:    nextNumber[ev.Period],
+			StartMs:   offset.Milliseconds(),
+			VideoTime: offset,
+			ClockTime: start.Add(offset),
+			Period:    ev.Period,
+			Label:     ev.Label,
+		})
+		nextNumber[ev.Period]++
+		added++
+	}
+
+	sort.SliceStable(result.Chapters, func(i, j int) bool {
+		return result.Chapters[i].ClockTime.Before(result.Chapters[j].ClockTime)
+	})
+	for i := range result.Chapters {
+		result.Chapters[i].GlobalOrder = i + 1
+	}
+
+	return added, skipped
+}
@@ -3,6 +3,7 @@ package metadata
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -44,6 +45,10 @@ type ClipGroup struct {
 	// OverlapInfo contains human-readable info about the overlap for UI display.
 	// FUTURE EXTENSION (Option B): Display this in UI to let user choose merge vs separate
 	OverlapInfo string
+
+	// Extended indicates the later chapters only lengthened the first chapter's
+	// clip (see ExtendFirst): the clip is named and marked for the first chapter.
+	Extended bool
 }
 
 // DetectOverlappingChapters analyzes chapters and groups overlapping ones together.
@@ -58,10 +63,16 @@ type ClipGroup struct {
 // Returns:
 //   - []ClipGroup: Groups of chapters, where overlapping chapters are merged
 //
-// FUTURE EXTENSION (Option B): Add a `mergeOverlaps bool` parameter to control behavior:
-//   - When true (current behavior): automatically merge overlapping chapters
-//   - When false: keep chapters separate but populate OverlapInfo for UI warnings
+// To handle overlapping chapters another way, see GroupChaptersWith.
 func DetectOverlappingChapters(chapters []Chapter, beforePadding, afterPadding float64) []ClipGroup {
+	return GroupChaptersWith(chapters, beforePadding, afterPadding, MergeAll{})
+}
+
+// GroupChaptersWith returns the clips chapters are extracted as, with policy
+// deciding which of each period's chapters share a clip. Chapters are never
+// grouped across periods (different video files). Groups are sorted by their
+// primary chapter's global order.
+func GroupChaptersWith(chapters []Chapter, beforePadding, afterPadding float64, policy OverlapPolicy) []ClipGroup {
 	if len(chapters) == 0 {
 		return nil
 	}
@@ -84,8 +95,10 @@ func DetectOverlappingChapters(chapters []Chapter, beforePadding, afterPadding f
 			return sorted[i].VideoTime < sorted[j].VideoTime
 		})
 
-		// Build groups by detecting overlaps
-		groups := buildOverlapGroups(sorted, beforePadding, afterPadding, period)
+		groups := policy.Group(sorted, beforePadding, afterPadding)
+		for i := range groups {
+			groups[i].Period = period
+		}
 		allGroups = append(allGroups, groups...)
 	}
 
@@ -97,20 +110,31 @@ func DetectOverlappingChapters(chapters []Chapter, beforePadding, afterPadding f
 	return allGroups
 }
 
+// GroupChapters returns the clips chapters are extracted as. With merge set,
+// overlapping chapters are merged (MergeAll); otherwise every chapter gets its
+// own clip (NeverMerge).
+func GroupChapters(chapters []Chapter, beforePadding, afterPadding float64, merge bool) []ClipGroup {
+	if merge {
+		return GroupChaptersWith(chapters, beforePadding, afterPadding, MergeAll{})
+	}
+	return GroupChaptersWith(chapters, beforePadding, afterPadding, NeverMerge{})
+}
+
 // buildOverlapGroups creates ClipGroups from a sorted list of chapters from the same period.
-// This is the core overlap detection algorithm.
+// This is the core grouping algorithm the overlap policies share.
 //
 // Algorithm:
 //  1. Start with the first chapter as the current group
-//  2. For each subsequent chapter, check if its clip would overlap with the current group
-//  3. If overlap: extend the current group to include this chapter
-//  4. If no overlap: finalize the current group and start a new one
+//  2. For each subsequent chapter, ask joins whether it belongs with the one before
+//     (prev), given where the current group's clip ends
+//  3. If it joins: extend the current group to include this chapter
+//  4. If not: finalize the current group and start a new one
 //
-// Overlap condition:
+// Overlap condition (MergeAll):
 //
 //	next_chapter_start - beforePadding < current_group_end
 //	Which simplifies to: next_chapter_time < current_group_end + beforePadding
-func buildOverlapGroups(sortedChapters []Chapter, beforePadding, afterPadding float64, period string) []ClipGroup {
+func buildOverlapGroups(sortedChapters []Chapter, beforePadding, afterPadding float64, joins func(prev, next Chapter, groupEnd float64) bool) []ClipGroup {
 	if len(sortedChapters) == 0 {
 		return nil
 	}
@@ -122,7 +146,6 @@ func buildOverlapGroups(sortedChapters []Chapter, beforePadding, afterPadding fl
 		Chapters:       []Chapter{sortedChapters[0]},
 		StartTime:      maxFloat(0, sortedChapters[0].VideoTime.Seconds()-beforePadding),
 		EndTime:        sortedChapters[0].VideoTime.Seconds() + afterPadding,
-		Period:         period,
 		PrimaryChapter: sortedChapters[0],
 		IsOverlap:      false,
 	}
@@ -132,14 +155,14 @@ func buildOverlapGroups(sortedChapters []Chapter, beforePadding, afterPadding fl
 		ch := sortedChapters[i]
 		chStartTime := maxFloat(0, ch.VideoTime.Seconds()-beforePadding)
 
-		// Check for overlap: does this chapter's clip start before the current group ends?
-		if chStartTime < currentGroup.EndTime {
-			// Overlap detected - merge into current group
+		// Does this chapter belong in the current group (e.g. its clip overlaps)?
+		if joins(sortedChapters[i-1], ch, currentGroup.EndTime) {
+			// Joins - merge into current group
 			currentGroup.Chapters = append(currentGroup.Chapters, ch)
 			currentGroup.EndTime = ch.VideoTime.Seconds() + afterPadding
 			currentGroup.IsOverlap = true
 		} else {
-			// No overlap - finalize current group and start new one
+			// Doesn't join - finalize current group and start new one
 			finalizeGroup(&currentGroup)
 			groups = append(groups, currentGroup)
 
@@ -147,7 +170,6 @@ func buildOverlapGroups(sortedChapters []Chapter, beforePadding, afterPadding fl
 				Chapters:       []Chapter{ch},
 				StartTime:      chStartTime,
 				EndTime:        ch.VideoTime.Seconds() + afterPadding,
-				Period:         period,
 				PrimaryChapter: ch,
 				IsOverlap:      false,
 			}
@@ -165,6 +187,12 @@ func buildOverlapGroups(sortedChapters []Chapter, beforePadding, afterPadding fl
 func finalizeGroup(group *ClipGroup) {
 	group.Duration = group.EndTime - group.StartTime
 
+	if group.Extended {
+		last := group.Chapters[len(group.Chapters)-1]
+		extra := last.VideoTime - group.Chapters[0].VideoTime
+		group.OverlapInfo = fmt.Sprintf("Extended by %.1fs to cover the highlights up to Ch%02d", extra.Seconds(), last.Number)
+	}
+
 	// Build overlap info string for UI display
 	// FUTURE EXTENSION (Option B): Use this info to show warnings and let user choose
 	if group.IsOverlap {
@@ -189,31 +217,57 @@ func finalizeGroup(group *ClipGroup) {
 
 // GenerateGroupFilename creates an output filename for a ClipGroup.
 // For single-chapter groups, uses the standard naming.
-// For merged groups, indicates the range of chapters included.
+// For merged groups, indicates the chapters included (see ChapterLabel).
 // Format matches standard clip naming for proper sort order:
 //
 //	{GlobalOrder}_{ClockTime}_{Period}_Ch{First}-{Last}.mp4
 //	Example: 041_12-15-45-871_3Period_Ch05-06.mp4
+//	Not consecutive: 041_12-15-45-871_3Period_Ch03+07.mp4
 func GenerateGroupFilename(group ClipGroup) string {
 	if !group.IsOverlap {
 		// Single chapter - use standard naming
 		return GenerateClipFilename(group.PrimaryChapter)
 	}
 
-	// Merged group - include range in filename
+	// Merged group - include the chapters in filename
 	// Use same format as single clips for proper sorting
 	first := group.PrimaryChapter
-	last := group.Chapters[len(group.Chapters)-1]
 
-	return fmt.Sprintf("%03d_%s_%s_Ch%02d-%02d.mp4",
+	return fmt.Sprintf("%03d_%s_%s_%s.mp4",
 		first.GlobalOrder,
 		FormatClockTime(first.ClockTime),
 		sanitizeFilename(first.Period),
-		first.Number,
-		last.Number,
+		ChapterLabel(group),
 	)
 }
 
+// ChapterLabel names the chapters of a group: "Ch07" for a single highlight,
+// "Ch05-06" for merged highlights with consecutive numbers, and "Ch03+07"
+// when the numbers aren't consecutive. HiLights added after recording are
+// numbered after the camera's chapters (see MergeHiLights), so a merged group
+// can hold Ch03 and Ch07 with nothing in between.
+func ChapterLabel(group ClipGroup) string {
+	if !group.IsOverlap {
+		return fmt.Sprintf("Ch%02d", group.PrimaryChapter.Number)
+	}
+
+	numbers := make([]int, 0, len(group.Chapters))
+	for _, ch := range group.Chapters {
+		numbers = append(numbers, ch.Number)
+	}
+	sort.Ints(numbers)
+	lowest, highest := numbers[0], numbers[len(numbers)-1]
+	if highest-lowest == len(numbers)-1 {
+		return fmt.Sprintf("Ch%02d-%02d", lowest, highest)
+	}
+
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = fmt.Sprintf("%02d", n)
+	}
+	return "Ch" + strings.Join(parts, "+")
+}
+
 // GetOverlapSummary returns a summary string describing all overlaps detected.
 // Useful for displaying in the UI before extraction.
 //
@@ -238,6 +292,16 @@ func GetOverlapSummary(groups []ClipGroup) string {
 		overlapCount, totalMerged, overlapCount)
 }
 
+// TotalDuration returns the combined length in seconds of all clips in groups.
+// Overlapping highlights are already merged, so shared footage is counted once.
+func TotalDuration(groups []ClipGroup) float64 {
+	var total float64
+	for _, g := range groups {
+		total += g.Duration
+	}
+	return total
+}
+
 // maxFloat returns the maximum of two float64 values.
 func maxFloat(a, b float64) float64 {
 	if a > b {
@@ -251,10 +315,16 @@ func maxFloat(a, b float64) float64 {
 type ClipChapterInfo struct {
 	// OffsetMs is the chapter position in milliseconds from the start of the clip
 	OffsetMs int64
-	// Title is the chapter title (e.g., "Highlight 1", "Ch03")
+	// Title is the chapter title (e.g., "Highlight 1", "Ch03", or the chapter's own title)
 	Title string
 }
 
+// CaptureTime returns when the first frame of the clip was recorded: the
+// clock time of its start in the video, or the zero time if not known
+func (g ClipGroup) CaptureTime() time.Time {
+	return g.PrimaryChapter.ClockAt(g.StartTime)
+}
+
 // GetClipChapters returns chapter markers for embedding in the extracted clip.
 // Each chapter marks the beginning of a highlight within the merged clip.
 // For single-chapter clips, returns one chapter at 0ms (clip start).
@@ -263,6 +333,9 @@ func (g *ClipGroup) GetClipChapters() []ClipChapterInfo {
 	var chapters []ClipChapterInfo
 
 	for i, ch := range g.Chapters {
+		if g.Extended && i > 0 {
+			break // Later chapters only lengthened the clip
+		}
 		// Calculate offset from clip start to this highlight
 		// Clip starts at g.StartTime, highlight is at ch.VideoTime
 		offsetSec := ch.VideoTime.Seconds() - g.StartTime
@@ -271,7 +344,12 @@ func (g *ClipGroup) GetClipChapters() []ClipChapterInfo {
 		}
 
 		title := fmt.Sprintf("Highlight %d (Ch%02d)", i+1, ch.Number)
-		if !g.IsOverlap {
+		switch {
+		case ch.Title != "" && g.IsOverlap:
+			title = fmt.Sprintf("%s (Ch%02d)", ch.Title, ch.Number)
+		case ch.Title != "":
+			title = ch.Title
+		case !g.IsOverlap:
 			title = fmt.Sprintf("Ch%02d", ch.Number)
 		}
 
@@ -301,3 +379,84 @@ func CalculateRecommendedAfterTime(chapter1Time, chapter2Time time.Duration, ori
 	gap := chapter2Time.Seconds() - chapter1Time.Seconds()
 	return gap + originalAfter
 }
+
+// CrossPeriodWarning describes two highlights from different periods whose clock
+// times are close enough that their clips would likely show the same play.
+// This happens when periods come from one continuous recording that was split
+// into separate files (e.g., end of period 1 and start of period 2).
+//
+// Clips are never merged across periods (they come from different video files),
+// so these are only reported as warnings for the user to review.
+type CrossPeriodWarning struct {
+	// First is the earlier highlight (by clock time).
+	First Chapter
+
+	// Second is the later highlight, from a different period than First.
+	Second Chapter
+
+	// Gap is the clock time difference between the two highlights.
+	Gap time.Duration
+}
+
+// DetectCrossPeriodOverlaps finds near-duplicate highlights that fall on either
+// side of a period boundary. Unlike DetectOverlappingChapters, which works on
+// video times within a single file, this compares real-world clock times so it
+// can relate chapters from different files.
+//
+// Parameters:
+//   - chapters: List of chapters with ClockTime populated (any order)
+//   - windowSec: Maximum clock time gap in seconds for two highlights to be reported
+//
+// Returns:
+//   - []CrossPeriodWarning: One entry per adjacent pair from different periods within the window
+func DetectCrossPeriodOverlaps(chapters []Chapter, windowSec float64) []CrossPeriodWarning {
+	if len(chapters) < 2 || windowSec <= 0 {
+		return nil
+	}
+
+	// Sort by clock time so chapters from different files interleave correctly
+	sorted := make([]Chapter, len(chapters))
+	copy(sorted, chapters)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ClockTime.Before(sorted[j].ClockTime)
+	})
+
+	window := time.Duration(windowSec * float64(time.Second))
+
+	var warnings []CrossPeriodWarning
+	for i := 1; i < len(sorted); i++ {
+		prev := sorted[i-1]
+		curr := sorted[i]
+		if prev.Period == curr.Period {
+			continue
+		}
+
+		gap := curr.ClockTime.Sub(prev.ClockTime)
+		if gap <= window {
+			warnings = append(warnings, CrossPeriodWarning{
+				First:  prev,
+				Second: curr,
+				Gap:    gap,
+			})
+		}
+	}
+
+	return warnings
+}
+
+// GetCrossPeriodSummary returns a summary string describing near-duplicate
+// highlights across period boundaries. Returns "" if there are none.
+func GetCrossPeriodSummary(warnings []CrossPeriodWarning) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+
+	var pairs []string
+	for _, w := range warnings {
+		pairs = append(pairs, fmt.Sprintf("#%d/#%d (%.1fs)",
+			w.First.GlobalOrder, w.Second.GlobalOrder, w.Gap.Seconds()))
+	}
+
+	return fmt.Sprintf("%d possible duplicate highlights across periods: %s",
+		len(warnings), strings.Join(pairs, ", "))
+}
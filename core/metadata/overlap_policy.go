@@ -0,0 +1,104 @@
+package metadata
+
+import "fmt"
+
+// OverlapPolicy decides which of a period's highlights are extracted together
+// as one clip. Sports and users differ: a hockey rush and its goal are one
+// play, while a volleyball rally or a soccer chance is posted on its own.
+// Use GroupChaptersWith to group chapters with a policy.
+type OverlapPolicy interface {
+	// Group groups one period's chapters, sorted by video time, into clips
+	// with the given padding (seconds). Period is filled in by the caller.
+	Group(sorted []Chapter, beforePadding, afterPadding float64) []ClipGroup
+}
+
+// Names of the overlap policies, as saved in settings and presets (see
+// OverlapPolicyNamed)
+const (
+	OverlapMergeAll    = "merge"
+	OverlapNeverMerge  = "separate"
+	OverlapMergeBelow  = "gap"
+	OverlapExtendFirst = "extend"
+)
+
+// MergeAll merges highlights whose clips would overlap into one clip, with a
+// chapter marker for each (the default; see DetectOverlappingChapters)
+type MergeAll struct{}
+
+// Group implements OverlapPolicy
+func (MergeAll) Group(sorted []Chapter, beforePadding, afterPadding float64) []ClipGroup {
+	return buildOverlapGroups(sorted, beforePadding, afterPadding, clipsOverlap(beforePadding))
+}
+
+// NeverMerge gives every highlight its own clip, even if it repeats footage of
+// the one before
+type NeverMerge struct{}
+
+// Group implements OverlapPolicy
+func (NeverMerge) Group(sorted []Chapter, beforePadding, afterPadding float64) []ClipGroup {
+	return buildOverlapGroups(sorted, beforePadding, afterPadding, func(Chapter, Chapter, float64) bool {
+		return false
+	})
+}
+
+// MergeBelowGap merges highlights marked less than Gap seconds after the one
+// before, whether or not their clips overlap, e.g. a shot and its rebound.
+// Highlights further apart get their own clips even if those overlap.
+type MergeBelowGap struct {
+	Gap float64
+}
+
+// Group implements OverlapPolicy
+func (p MergeBelowGap) Group(sorted []Chapter, beforePadding, afterPadding float64) []ClipGroup {
+	return buildOverlapGroups(sorted, beforePadding, afterPadding, func(prev, next Chapter, _ float64) bool {
+		return (next.VideoTime - prev.VideoTime).Seconds() < p.Gap
+	})
+}
+
+// ExtendFirst lengthens the first highlight's clip to cover highlights whose
+// clips would overlap it, instead of merging them: the clip keeps the first
+// highlight's name and only its chapter marker, e.g. when the button is
+// pressed again during the celebration
+type ExtendFirst struct{}
+
+// Group implements OverlapPolicy
+func (ExtendFirst) Group(sorted []Chapter, beforePadding, afterPadding float64) []ClipGroup {
+	groups := buildOverlapGroups(sorted, beforePadding, afterPadding, clipsOverlap(beforePadding))
+	for i := range groups {
+		if groups[i].IsOverlap {
+			groups[i].IsOverlap = false
+			groups[i].Extended = true
+			groups[i].OverlapInfo = ""
+			finalizeGroup(&groups[i])
+		}
+	}
+	return groups
+}
+
+// clipsOverlap joins a chapter to the group before when its clip would start
+// before the group's clip ends
+func clipsOverlap(beforePadding float64) func(prev, next Chapter, groupEnd float64) bool {
+	return func(_, next Chapter, groupEnd float64) bool {
+		return maxFloat(0, next.VideoTime.Seconds()-beforePadding) < groupEnd
+	}
+}
+
+// OverlapPolicyNamed returns the policy called name (see OverlapMergeAll and
+// the other names), with gap seconds for OverlapMergeBelow. An empty name is
+// MergeAll.
+func OverlapPolicyNamed(name string, gap float64) (OverlapPolicy, error) {
+	switch name {
+	case OverlapMergeAll, "":
+		return MergeAll{}, nil
+	case OverlapNeverMerge:
+		return NeverMerge{}, nil
+	case OverlapMergeBelow:
+		if gap <= 0 {
+			return nil, fmt.Errorf("the merge gap must be over 0 seconds")
+		}
+		return MergeBelowGap{Gap: gap}, nil
+	case OverlapExtendFirst:
+		return ExtendFirst{}, nil
+	}
+	return nil, fmt.Errorf("unknown overlap policy %q", name)
+}
@@ -0,0 +1,184 @@
+package metadata
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chapterAt returns a chapter of period at sec seconds into its video
+func chapterAt(period string, number, order int, sec float64) Chapter {
+	return Chapter{
+		Number:      number,
+		StartMs:     int64(sec * 1000),
+		VideoTime:   time.Duration(sec * float64(time.Second)),
+		ClockTime:   time.Date(2025, 11, 14, 19, 30, 0, 0, time.UTC).Add(time.Duration(sec * float64(time.Second))),
+		GlobalOrder: order,
+		Period:      period,
+	}
+}
+
+// groupNumbers returns the chapter numbers of each group
+func groupNumbers(groups []ClipGroup) [][]int {
+	var numbers [][]int
+	for _, g := range groups {
+		var n []int
+		for _, ch := range g.Chapters {
+			n = append(n, ch.Number)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+func TestGroupChaptersWith(t *testing.T) {
+	// With 8s before and 2s after: Ch2's clip starts at 92s, before Ch1's ends
+	// at 102s; Ch3 is 20s later, clear of Ch2's; Ch4 is in another period
+	chapters := []Chapter{
+		chapterAt("1Period", 1, 1, 100),
+		chapterAt("1Period", 2, 2, 100+4),
+		chapterAt("1Period", 3, 3, 100+24),
+		chapterAt("2Period", 1, 4, 5),
+	}
+
+	tests := []struct {
+		name   string
+		policy OverlapPolicy
+		want   [][]int
+	}{
+		{"merge", MergeAll{}, [][]int{{1, 2}, {3}, {1}}},
+		{"separate", NeverMerge{}, [][]int{{1}, {2}, {3}, {1}}},
+		{"gap below 5s", MergeBelowGap{Gap: 5}, [][]int{{1, 2}, {3}, {1}}},
+		{"gap below 30s", MergeBelowGap{Gap: 30}, [][]int{{1, 2, 3}, {1}}},
+		{"extend", ExtendFirst{}, [][]int{{1, 2}, {3}, {1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := GroupChaptersWith(chapters, 8, 2, tt.policy)
+			if got := groupNumbers(groups); !slices.EqualFunc(got, tt.want, slices.Equal[[]int]) {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupChaptersWithAddedHiLights(t *testing.T) {
+	// A HiLight added in Quik 3s after Ch1 is numbered after the camera's
+	// chapters (Ch4) and merges with Ch1
+	camera := []Chapter{
+		chapterAt("", 1, 0, 100),
+		chapterAt("", 2, 0, 150),
+		chapterAt("", 3, 0, 200),
+	}
+	chapters, _ := MergeHiLights(camera, []int64{103000})
+	for i := range chapters {
+		chapters[i].Period = "1Period"
+		chapters[i].GlobalOrder = i + 1
+	}
+
+	groups := GroupChaptersWith(chapters, 8, 2, MergeAll{})
+	if got, want := groupNumbers(groups), [][]int{{1, 4}, {2}, {3}}; !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+		t.Fatalf("groups = %v, want %v", got, want)
+	}
+
+	// Named as a set, not as the range Ch01-04
+	merged := groups[0]
+	if got, want := ChapterLabel(merged), "Ch01+04"; got != want {
+		t.Errorf("ChapterLabel = %q, want %q", got, want)
+	}
+	if got := GenerateGroupFilename(merged); !strings.HasSuffix(got, "_1Period_Ch01+04.mp4") {
+		t.Errorf("GenerateGroupFilename = %q, want it to end in _1Period_Ch01+04.mp4", got)
+	}
+	if got, want := GroupTemplateValues(merged).Chapter, "Ch01+04"; got != want {
+		t.Errorf("{chapter} = %q, want %q", got, want)
+	}
+}
+
+func TestDetectOverlappingChaptersTiming(t *testing.T) {
+	chapters := []Chapter{
+		chapterAt("1Period", 1, 1, 100),
+		chapterAt("1Period", 2, 2, 104),
+		chapterAt("1Period", 3, 3, 5),
+	}
+	groups := DetectOverlappingChapters(chapters, 8, 2)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+
+	// Ch3 comes first in the video but last in global order; its start is
+	// clamped to the start of the video
+	merged, early := groups[0], groups[1]
+	if !merged.IsOverlap || merged.StartTime != 92 || merged.EndTime != 106 || merged.Duration != 14 {
+		t.Errorf("merged group = overlap %v, %.1f-%.1f (%.1fs), want overlap true, 92-106 (14s)",
+			merged.IsOverlap, merged.StartTime, merged.EndTime, merged.Duration)
+	}
+	if merged.Period != "1Period" || merged.PrimaryChapter.Number != 1 {
+		t.Errorf("merged group is %s Ch%d, want 1Period Ch1", merged.Period, merged.PrimaryChapter.Number)
+	}
+	if early.IsOverlap || early.StartTime != 0 || early.EndTime != 7 {
+		t.Errorf("early group = overlap %v, %.1f-%.1f, want overlap false, 0-7", early.IsOverlap, early.StartTime, early.EndTime)
+	}
+}
+
+func TestExtendFirstKeepsFirstChapter(t *testing.T) {
+	chapters := []Chapter{
+		chapterAt("1Period", 1, 1, 100),
+		chapterAt("1Period", 2, 2, 104),
+	}
+	groups := GroupChaptersWith(chapters, 8, 2, ExtendFirst{})
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	g := groups[0]
+	if g.IsOverlap || !g.Extended || g.EndTime != 106 {
+		t.Errorf("group = overlap %v, extended %v, ends %.1f; want overlap false, extended true, ends 106", g.IsOverlap, g.Extended, g.EndTime)
+	}
+	if got, want := GenerateGroupFilename(g), GenerateClipFilename(chapters[0]); got != want {
+		t.Errorf("filename = %q, want the first chapter's %q", got, want)
+	}
+}
+
+func TestGenerateGroupFilename(t *testing.T) {
+	first := chapterAt("3 Period", 5, 41, 45.871)
+	first.ClockTime = time.Date(2025, 11, 14, 12, 15, 45, 871e6, time.UTC)
+	last := chapterAt("3 Period", 6, 42, 50)
+	added := chapterAt("3 Period", 9, 42, 48) // Numbered after the camera's chapters
+
+	tests := []struct {
+		name  string
+		group ClipGroup
+		want  string
+	}{
+		{
+			"single",
+			ClipGroup{Chapters: []Chapter{first}, PrimaryChapter: first},
+			"041_12-15-45-871_3_Period_Ch05.mp4",
+		},
+		{
+			"merged",
+			ClipGroup{Chapters: []Chapter{first, last}, PrimaryChapter: first, IsOverlap: true},
+			"041_12-15-45-871_3_Period_Ch05-06.mp4",
+		},
+		{
+			"not consecutive",
+			ClipGroup{Chapters: []Chapter{first, added}, PrimaryChapter: first, IsOverlap: true},
+			"041_12-15-45-871_3_Period_Ch05+09.mp4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenerateGroupFilename(tt.group); got != tt.want {
+				t.Errorf("GenerateGroupFilename = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateClipFilenameSanitizes(t *testing.T) {
+	ch := chapterAt(`OT: "Sudden" <Death>?`, 1, 7, 0)
+	ch.ClockTime = time.Date(2025, 11, 14, 21, 5, 9, 0, time.UTC)
+	if got, want := GenerateClipFilename(ch), "007_21-05-09-000_OT_Sudden_Death_Ch01.mp4"; got != want {
+		t.Errorf("GenerateClipFilename = %q, want %q", got, want)
+	}
+}
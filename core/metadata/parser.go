@@ -0,0 +1,338 @@
+package metadata
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chapter represents a single chapter/highlight marker in a video
+type Chapter struct {
+	Number      int           // Chapter number within its period
+	StartMs     int64         // Start time in milliseconds from video start
+	VideoTime   time.Duration // Time offset in the video
+	ClockTime   time.Time     // Real-world clock time (from GoPro timecode)
+	GlobalOrder int           // Order across all periods
+	Period      string        // Period name
+	Label       string        // Optional description (e.g. from an imported stat sheet)
+	Title       string        // Chapter title from the metadata file or renamed by the user ("" = none)
+}
+
+// ClockAt returns the real-world time of a position (seconds) in the
+// chapter's video, or the zero time if the chapter has no clock time
+func (c Chapter) ClockAt(videoSec float64) time.Time {
+	if c.ClockTime.IsZero() {
+		return time.Time{}
+	}
+	return c.ClockTime.Add(time.Duration((videoSec - c.VideoTime.Seconds()) * float64(time.Second)))
+}
+
+// Period represents a recording period with associated files
+type Period struct {
+	Name           string
+	VideoFile      string
+	MetadataFile   string
+	SourceGoPro    string
+	UseMovMetadata bool // If true, extract metadata from MOV file directly
+	// Part of VideoFile the period covers when one recording was split into
+	// several periods, as video offsets (0 = from the start / to the end)
+	SplitStart time.Duration `json:",omitempty"`
+	SplitEnd   time.Duration `json:",omitempty"`
+	// Frame rate of the video's timecode and whether it is drop-frame, read
+	// by the analysis (0 = not read yet, taken as DefaultTimecodeFPS)
+	TimecodeFPS float64 `json:",omitempty"`
+	DropFrame   bool    `json:",omitempty"`
+}
+
+// DirectPeriod returns a period that uses a GoPro original (MP4) as its video,
+// with no converted MOV: chapters, HiLights and timecode are all read from it
+func DirectPeriod(name, videoPath string) Period {
+	return Period{
+		Name:         name,
+		VideoFile:    videoPath,
+		MetadataFile: videoPath,
+		SourceGoPro:  videoPath,
+	}
+}
+
+// IsDirect returns true if the period's video is a GoPro original rather than
+// a converted MOV. These long-GOP files are best re-encoded, since
+// stream-copied clips can only start on a keyframe.
+func (p Period) IsDirect() bool {
+	return p.VideoFile == p.SourceGoPro && !strings.EqualFold(filepath.Ext(p.VideoFile), ".mov")
+}
+
+// ParseFFMetadata parses an FFmpeg metadata file and extracts chapter markers
+// with their titles
+func ParseFFMetadata(path string) ([]Chapter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer file.Close()
+
+	var chapters []Chapter
+	chapterNum := 0
+	inChapter := false // In a [CHAPTER] section (title= elsewhere is the file's title)
+
+	// Regex patterns
+	startRe := regexp.MustCompile(`^START=(\d+)`)
+	timebaseRe := regexp.MustCompile(`^TIMEBASE=1/(\d+)`)
+
+	// Default timebase: 1/1000 (milliseconds) for backward compatibility
+	// GoPro metadata uses 1/1000, but Shutter Encoder MOV uses 1/10000000
+	timebaseDenom := int64(1000)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			inChapter = line == "[CHAPTER]"
+			continue
+		}
+		if title, ok := strings.CutPrefix(line, "title="); ok {
+			if inChapter && len(chapters) > 0 {
+				chapters[len(chapters)-1].Title = unescapeMetadata(title)
+			}
+			continue
+		}
+
+		// Check for TIMEBASE line (appears before START in each [CHAPTER] block)
+		if matches := timebaseRe.FindStringSubmatch(line); matches != nil {
+			denom, _ := strconv.ParseInt(matches[1], 10, 64)
+			if denom > 0 {
+				timebaseDenom = denom
+			}
+			continue
+		}
+
+		if matches := startRe.FindStringSubmatch(line); matches != nil {
+			startValue, _ := strconv.ParseInt(matches[1], 10, 64)
+			chapterNum++
+
+			// Convert to milliseconds based on timebase
+			// startValue is in units of 1/timebaseDenom seconds
+			// To get milliseconds: startValue * 1000 / timebaseDenom
+			startMs := (startValue * 1000) / timebaseDenom
+
+			chapters = append(chapters, Chapter{
+				Number:    chapterNum,
+				StartMs:   startMs,
+				VideoTime: time.Duration(startMs) * time.Millisecond,
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading metadata file: %w", err)
+	}
+
+	return chapters, nil
+}
+
+// unescapeMetadata undoes the backslash escapes of an FFMETADATA value
+// ("\=", "\;", "\#", "\\")
+func unescapeMetadata(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ParseTimecodeToTime parses a GoPro timecode string and returns a time.Time
+// Assumes the timecode represents time of day today in the local timezone,
+// counted at DefaultTimecodeFPS; use ParseTimecodeAt when the recording date
+// and frame rate are known
+func ParseTimecodeToTime(timecode string) (time.Time, error) {
+	return ParseTimecodeOn(timecode, time.Now(), time.Local)
+}
+
+// ParseTimecodeOn parses a GoPro timecode string as a time of day in loc on
+// the day nearest to reference (usually the file's creation time), so clock
+// times keep their real date when footage is processed days later. Frames
+// are counted at DefaultTimecodeFPS.
+func ParseTimecodeOn(timecode string, reference time.Time, loc *time.Location) (time.Time, error) {
+	return ParseTimecodeAt(timecode, DefaultTimecodeFPS, false, reference, loc)
+}
+
+// ParseTimecodeAt is ParseTimecodeOn for a timecode counting frames at fps,
+// drop-frame or not (see TimecodeSecondsAt)
+func ParseTimecodeAt(timecode string, fps float64, dropFrame bool, reference time.Time, loc *time.Location) (time.Time, error) {
+	seconds, err := TimecodeSecondsAt(timecode, fps, dropFrame)
+	if err != nil {
+		return time.Time{}, err
+	}
+	timeOfDay := time.Duration(math.Round(seconds*1000)) * time.Millisecond
+	return NearestDay(reference, timeOfDay, loc), nil
+}
+
+// NearestDay returns the time timeOfDay in loc on whichever day puts it
+// closest to reference. The day before and after are considered because a
+// camera's creation time may be stamped in another zone than its timecode
+// (GoPros write local time marked as UTC), and recordings can cross midnight.
+// Built from wall-clock fields, so days with a DST change come out right.
+func NearestDay(reference time.Time, timeOfDay time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.Local
+	}
+	ref := reference.In(loc)
+	hours := int(timeOfDay / time.Hour)
+	minutes := int(timeOfDay % time.Hour / time.Minute)
+	seconds := int(timeOfDay % time.Minute / time.Second)
+	nanos := int(timeOfDay % time.Second)
+
+	var best time.Time
+	for _, days := range []int{0, -1, 1} {
+		t := time.Date(ref.Year(), ref.Month(), ref.Day()+days, hours, minutes, seconds, nanos, loc)
+		if best.IsZero() || absDuration(t.Sub(reference)) < absDuration(best.Sub(reference)) {
+			best = t
+		}
+	}
+	return best
+}
+
+// absDuration returns the absolute value of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// TimecodeToSeconds converts a timecode string to total seconds, counting
+// frames at DefaultTimecodeFPS (see TimecodeSecondsAt)
+func TimecodeToSeconds(timecode string) (float64, error) {
+	return TimecodeSecondsAt(timecode, DefaultTimecodeFPS, false)
+}
+
+// TimecodeSecondsAt converts a timecode string to total seconds for a
+// timecode counting frames at fps (0 = DefaultTimecodeFPS). Each timecode
+// second holds the rate rounded up to whole frames (30 for 29.97), so FF is
+// a fraction of that. A drop-frame timecode (marked by dropFrame or written
+// HH:MM:SS;FF) skips frame numbers to keep up with the clock, so it is
+// counted back to a frame number and divided by the real rate.
+func TimecodeSecondsAt(timecode string, fps float64, dropFrame bool) (float64, error) {
+	re := regexp.MustCompile(`(\d{2}):(\d{2}):(\d{2})([:;])(\d{2})`)
+	matches := re.FindStringSubmatch(timecode)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid timecode format: %s", timecode)
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+	frames, _ := strconv.Atoi(matches[5])
+
+	if fps <= 0 {
+		fps = DefaultTimecodeFPS
+	}
+	base := math.Round(fps)
+	wholeSeconds := float64(hours*3600 + minutes*60 + seconds)
+
+	// Drop-frame only exists for the NTSC rates (29.97, 59.94, ...)
+	if (dropFrame || matches[4] == ";") && math.Abs(fps-base) > 0.01 {
+		// Two frame numbers are skipped each minute at 29.97 (four at 59.94),
+		// except every tenth minute
+		dropped := math.Round(base / 15)
+		totalMinutes := float64(hours*60 + minutes)
+		frameNumber := wholeSeconds*base + float64(frames) - dropped*(totalMinutes-math.Floor(totalMinutes/10))
+		return frameNumber / fps, nil
+	}
+	return wholeSeconds + float64(frames)/base, nil
+}
+
+// MapChaptersToClockTime maps chapter video times to real clock times
+// using the GoPro timecode as the reference point
+func MapChaptersToClockTime(chapters []Chapter, goProTimecode string) ([]Chapter, error) {
+	startTime, err := ParseTimecodeToTime(goProTimecode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GoPro timecode: %w", err)
+	}
+
+	return MapChaptersFrom(chapters, startTime), nil
+}
+
+// MapChaptersFrom maps chapter video times to real clock times, given the
+// clock time of the video's first frame
+func MapChaptersFrom(chapters []Chapter, startTime time.Time) []Chapter {
+	result := make([]Chapter, len(chapters))
+	for i, ch := range chapters {
+		result[i] = ch
+		result[i].ClockTime = startTime.Add(ch.VideoTime)
+	}
+
+	return result
+}
+
+// MergeAndSortChapters combines chapters from multiple periods and sorts them chronologically
+func MergeAndSortChapters(periodChapters map[string][]Chapter) []Chapter {
+	var allChapters []Chapter
+
+	for periodName, chapters := range periodChapters {
+		for _, ch := range chapters {
+			ch.Period = periodName
+			allChapters = append(allChapters, ch)
+		}
+	}
+
+	// Sort by clock time
+	sort.Slice(allChapters, func(i, j int) bool {
+		return allChapters[i].ClockTime.Before(allChapters[j].ClockTime)
+	})
+
+	// Assign global order
+	for i := range allChapters {
+		allChapters[i].GlobalOrder = i + 1
+	}
+
+	return allChapters
+}
+
+// FormatClockTime formats a time as HH-MM-SS-mmm for filenames
+func FormatClockTime(t time.Time) string {
+	return fmt.Sprintf("%02d-%02d-%02d-%03d",
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond()/1e6)
+}
+
+// FormatVideoTime formats a duration as MM:SS for display
+func FormatVideoTime(d time.Duration) string {
+	minutes := int(d.Minutes())
+	seconds := int(d.Seconds()) % 60
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+// GenerateClipFilename generates a filename for an extracted clip
+func GenerateClipFilename(ch Chapter) string {
+	return fmt.Sprintf("%03d_%s_%s_Ch%02d.mp4",
+		ch.GlobalOrder,
+		FormatClockTime(ch.ClockTime),
+		sanitizeFilename(ch.Period),
+		ch.Number,
+	)
+}
+
+// sanitizeFilename removes characters that are invalid in filenames
+func sanitizeFilename(name string) string {
+	// Replace spaces with underscores and remove invalid characters
+	name = strings.ReplaceAll(name, " ", "_")
+	re := regexp.MustCompile(`[<>:"/\\|?*]`)
+	return re.ReplaceAllString(name, "")
+}
@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestParseFFMetadataGolden parses each testdata/*.ffmetadata file and compares
+// the chapters with its .golden.json (go test -update rewrites them)
+func TestParseFFMetadataGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.ffmetadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata/*.ffmetadata files")
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".ffmetadata")
+		t.Run(name, func(t *testing.T) {
+			chapters, err := ParseFFMetadata(file)
+			if err != nil {
+				t.Fatalf("ParseFFMetadata: %v", err)
+			}
+			got, err := json.MarshalIndent(chapters, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+				t.Errorf("chapters differ from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestParseFFMetadataMissing(t *testing.T) {
+	if _, err := ParseFFMetadata(filepath.Join(t.TempDir(), "none.ffmetadata")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,181 @@
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PeriodSplit splits recordings that cover more than one period (the camera
+// was never stopped) into logical periods, so clips still get the right
+// period labels
+type PeriodSplit struct {
+	// MinGap starts a new period where two HiLights in one recording are
+	// further apart than this (0 = don't split on gaps)
+	MinGap time.Duration
+	// Starts are the clock times of day periods started at (e.g. from the
+	// scoresheet). A new period begins at each one inside a recording.
+	Starts []time.Duration
+}
+
+// enabled returns true if the split can do anything
+func (s PeriodSplit) enabled() bool {
+	return s.MinGap > 0 || len(s.Starts) > 0
+}
+
+// ParsePeriodStarts parses period start times of day entered as HH:MM or
+// HH:MM:SS, separated by commas, spaces or new lines (e.g. "19:42, 20:31")
+func ParsePeriodStarts(text string) ([]time.Duration, error) {
+	var starts []time.Duration
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		start, err := parseClock(field)
+		if err != nil {
+			return nil, err
+		}
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts, nil
+}
+
+// splitBound is a video offset where a new period starts
+type splitBound struct {
+	at      time.Duration
+	entered bool // From an entered start time (preferred over a gap)
+}
+
+// bounds returns the video offsets where a recording with these chapters
+// (sorted by video time) should be split. Each resulting part has at least
+// one chapter; where an entered start time and a gap fall between the same
+// two HiLights, the start time wins.
+func (s PeriodSplit) bounds(chapters []Chapter) []time.Duration {
+	if len(chapters) < 2 {
+		return nil
+	}
+
+	var candidates []splitBound
+	if s.MinGap > 0 {
+		for i := 1; i < len(chapters); i++ {
+			if gap := chapters[i].VideoTime - chapters[i-1].VideoTime; gap > s.MinGap {
+				// Split halfway through the break
+				candidates = append(candidates, splitBound{at: chapters[i-1].VideoTime + gap/2})
+			}
+		}
+	}
+
+	// Time of day of the recording's first frame
+	recordingStart := TimeOfDay(chapters[0].ClockTime) - chapters[0].VideoTime
+	for _, start := range s.Starts {
+		candidates = append(candidates, splitBound{at: start - recordingStart, entered: true})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].at < candidates[j].at })
+
+	// Keep one bound per break between HiLights
+	var kept []splitBound
+	next := 0 // First chapter after the last kept bound
+	for _, b := range candidates {
+		if b.at <= chapters[0].VideoTime || b.at > chapters[len(chapters)-1].VideoTime {
+			continue
+		}
+		after := sort.Search(len(chapters), func(i int) bool { return chapters[i].VideoTime >= b.at })
+		if len(kept) > 0 && after == next {
+			// No HiLight since the last bound: same break
+			if b.entered && !kept[len(kept)-1].entered {
+				kept[len(kept)-1] = b
+			}
+			continue
+		}
+		kept = append(kept, b)
+		next = after
+	}
+
+	var offsets []time.Duration
+	for _, b := range kept {
+		offsets = append(offsets, b.at)
+	}
+	return offsets
+}
+
+// splitPeriods splits each period at its bounds and renumbers all periods in
+// order ("1Period", "2Period", ...; a period continuing the previous one
+// keeps its "-part" suffix). Chapters (by period name) and dropped chapters
+// move to the part they fall in and are renumbered within it. Returns the
+// inputs unchanged if nothing is split.
+func (s PeriodSplit) splitPeriods(periods []Period, periodChapters map[string][]Chapter, dropped []Chapter) ([]Period, map[string][]Chapter, []Chapter) {
+	if !s.enabled() {
+		return periods, periodChapters, dropped
+	}
+
+	periodBounds := make(map[string][]time.Duration)
+	split := false
+	for _, p := range periods {
+		chapters := append([]Chapter{}, periodChapters[p.Name]...)
+		sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].VideoTime < chapters[j].VideoTime })
+		periodChapters[p.Name] = chapters
+		if bounds := s.bounds(chapters); len(bounds) > 0 {
+			periodBounds[p.Name] = bounds
+			split = true
+		}
+	}
+	if !split {
+		return periods, periodChapters, dropped
+	}
+
+	// partName returns the name of the part of an original period that
+	// contains videoTime, once the parts below are named
+	partNames := make(map[string][]string)
+	partName := func(period string, videoTime time.Duration) string {
+		bounds := periodBounds[period]
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i] > videoTime })
+		return partNames[period][i]
+	}
+
+	var newPeriods []Period
+	number, part := 0, 1
+	for _, p := range periods {
+		bounds := periodBounds[p.Name]
+		for i := 0; i <= len(bounds); i++ {
+			newPeriod := p
+			if i > 0 {
+				newPeriod.SplitStart = bounds[i-1]
+			}
+			if i < len(bounds) {
+				newPeriod.SplitEnd = bounds[i]
+			}
+
+			var n, k int
+			if c, _ := fmt.Sscanf(p.Name, "%dPeriod-%d", &n, &k); i == 0 && c == 2 && number > 0 {
+				part++
+				newPeriod.Name = fmt.Sprintf("%dPeriod-%d", number, part)
+			} else {
+				number++
+				part = 1
+				newPeriod.Name = fmt.Sprintf("%dPeriod", number)
+			}
+			partNames[p.Name] = append(partNames[p.Name], newPeriod.Name)
+			newPeriods = append(newPeriods, newPeriod)
+		}
+	}
+
+	newChapters := make(map[string][]Chapter)
+	for period, chapters := range periodChapters {
+		for _, ch := range chapters {
+			name := partName(period, ch.VideoTime)
+			ch.Number = len(newChapters[name]) + 1
+			newChapters[name] = append(newChapters[name], ch)
+		}
+	}
+
+	var newDropped []Chapter
+	for _, ch := range dropped {
+		if _, ok := partNames[ch.Period]; ok {
+			ch.Period = partName(ch.Period, ch.VideoTime)
+		}
+		newDropped = append(newDropped, ch)
+	}
+
+	return newPeriods, newChapters, newDropped
+}
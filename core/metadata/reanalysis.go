@@ -0,0 +1,196 @@
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reanalysisMatchToleranceMs is how far apart (ms into the video) a chapter of
+// a re-run analysis may be from one of the previous analysis and still be
+// taken as the same highlight
+const reanalysisMatchToleranceMs = 1000
+
+// Key identifies a chapter within its analysis by period and position in the
+// video, for keeping choices made about it (e.g. whether it is selected)
+func (c Chapter) Key() string {
+	return fmt.Sprintf("%s@%d", c.Period, c.StartMs)
+}
+
+// AnalysisDiff matches the chapters of a re-run analysis (e.g. after fixing a
+// timecode or re-extracting metadata) to those of the previous one
+type AnalysisDiff struct {
+	// Matched maps each new chapter's Key to the previous chapter's Key
+	Matched map[string]string
+	Added   []Chapter // New chapters with no previous match
+	Removed []Chapter // Previous chapters with no match in the new analysis
+}
+
+// DiffAnalyses matches the chapters of current to those of previous. Chapters
+// match when they come from the same video file and start within a second of
+// each other, so renamed or renumbered periods and changed clock times don't
+// break the match.
+func DiffAnalyses(previous, current *AnalysisResult) *AnalysisDiff {
+	diff := &AnalysisDiff{Matched: make(map[string]string)}
+
+	// Previous chapters by source, in video order
+	bySource := make(map[string][]Chapter)
+	for _, ch := range previous.Chapters {
+		source := previous.chapterSource(ch)
+		bySource[source] = append(bySource[source], ch)
+	}
+
+	used := make(map[string]bool)
+	for _, ch := range current.Chapters {
+		var best *Chapter
+		var bestDelta int64
+		candidates := bySource[current.chapterSource(ch)]
+		for i := range candidates {
+			prev := &candidates[i]
+			if used[prev.Key()] {
+				continue
+			}
+			delta := prev.StartMs - ch.StartMs
+			if delta < 0 {
+				delta = -delta
+			}
+			if delta <= reanalysisMatchToleranceMs && (best == nil || delta < bestDelta) {
+				best, bestDelta = prev, delta
+			}
+		}
+		if best == nil {
+			diff.Added = append(diff.Added, ch)
+			continue
+		}
+		used[best.Key()] = true
+		diff.Matched[ch.Key()] = best.Key()
+	}
+
+	for _, ch := range previous.Chapters {
+		if !used[ch.Key()] {
+			diff.Removed = append(diff.Removed, ch)
+		}
+	}
+	return diff
+}
+
+// chapterSource returns the video file a chapter is in (its period name if the
+// file isn't known)
+func (result *AnalysisResult) chapterSource(ch Chapter) string {
+	if file := result.GetPeriodVideoFile(ch.Period); file != "" {
+		return file
+	}
+	return ch.Period
+}
+
+// Summary describes the diff, e.g. "41 chapters matched, 2 new, 1 no longer found"
+func (d *AnalysisDiff) Summary() string {
+	parts := []string{fmt.Sprintf("%d chapters matched", len(d.Matched))}
+	if len(d.Added) > 0 {
+		parts = append(parts, fmt.Sprintf("%d new", len(d.Added)))
+	}
+	if len(d.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("%d no longer found", len(d.Removed)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CarryOver copies what was added to the previous analysis by hand into this
+// re-run of it: labels of matched chapters that have none now, chapter titles
+// (which may have been renamed) and imported
+// events (see MergeImportedEvents), which no metadata re-creates. Imported
+// events keep their place in the video and take the clock time shift of the
+// other chapters in their file; events in a file with no matched chapter are
+// dropped. Returns the number of labels and events carried over.
+func (result *AnalysisResult) CarryOver(previous *AnalysisResult) (labels, events int) {
+	diff := DiffAnalyses(previous, result)
+
+	prevByKey := make(map[string]Chapter, len(previous.Chapters))
+	for _, ch := range previous.Chapters {
+		prevByKey[ch.Key()] = ch
+	}
+
+	// Matched chapters give the period and clock shift of each source file
+	type fileShift struct {
+		chapter Chapter // A matched chapter of the re-run analysis
+		prev    Chapter // The chapter it matched
+	}
+	shifts := make(map[string]fileShift)
+	for i, ch := range result.Chapters {
+		prevKey, ok := diff.Matched[ch.Key()]
+		if !ok {
+			continue
+		}
+		prev := prevByKey[prevKey]
+		if result.Chapters[i].Label == "" && prev.Label != "" {
+			result.Chapters[i].Label = prev.Label
+			labels++
+		}
+		if prev.Title != "" {
+			result.Chapters[i].Title = prev.Title
+		}
+		source := result.chapterSource(ch)
+		if _, ok := shifts[source]; !ok {
+			shifts[source] = fileShift{chapter: ch, prev: prev}
+		}
+	}
+
+	nextNumber := make(map[string]int)
+	for _, ch := range result.Chapters {
+		if ch.Number >= nextNumber[ch.Period] {
+			nextNumber[ch.Period] = ch.Number + 1
+		}
+	}
+
+	for _, prev := range diff.Removed {
+		// Camera HiLights that are gone stay gone; only imported events are re-added
+		if prev.Label == "" || prev.Label == QuikHiLightLabel {
+			continue
+		}
+		shift, ok := shifts[previous.chapterSource(prev)]
+		if !ok {
+			continue
+		}
+		period := result.periodAt(shift.chapter.Period, prev.VideoTime)
+		if nextNumber[period] == 0 {
+			nextNumber[period] = 1
+		}
+		result.Chapters = append(result.Chapters, Chapter{
+			Number:    nextNumber[period],
+			StartMs:   prev.StartMs,
+			VideoTime: prev.VideoTime,
+			ClockTime: prev.ClockTime.Add(shift.chapter.ClockTime.Sub(shift.prev.ClockTime)),
+			Period:    period,
+			Label:     prev.Label,
+			Title:     prev.Title,
+		})
+		nextNumber[period]++
+		events++
+	}
+
+	if events > 0 {
+		sort.SliceStable(result.Chapters, func(i, j int) bool {
+			return result.Chapters[i].ClockTime.Before(result.Chapters[j].ClockTime)
+		})
+		for i := range result.Chapters {
+			result.Chapters[i].GlobalOrder = i + 1
+		}
+	}
+	return labels, events
+}
+
+// periodAt returns the period covering videoTime in the same video file as
+// the named period (which may have been split into several)
+func (result *AnalysisResult) periodAt(period string, videoTime time.Duration) string {
+	file := result.GetPeriodVideoFile(period)
+	for _, p := range result.Periods {
+		if p.VideoFile != file || file == "" {
+			continue
+		}
+		if videoTime >= p.SplitStart && (p.SplitEnd == 0 || videoTime < p.SplitEnd) {
+			return p.Name
+		}
+	}
+	return period
+}
@@ -0,0 +1,146 @@
+package metadata
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	// RinkAspect is the width of a rink over its length (85 by 200 ft)
+	RinkAspect = 85.0 / 200.0
+	// rinkMinFixes is how many GPS fixes a game needs to fit a rink to
+	rinkMinFixes = 30
+	// rinkMinLength is how far (in meters) the camera must have moved along
+	// the rink for its fixes to show its shape
+	rinkMinLength = 15.0
+	// rinkTrim is the share of fixes at each edge left out of the fit, so a
+	// walk to the locker room or a stray fix doesn't shrink the rink
+	rinkTrim = 0.02
+	// fixMatchWindow is how close to a moment a fix must be to place it
+	fixMatchWindow = 2 * time.Second
+)
+
+// Meters per degree of latitude, and of longitude at the equator
+const (
+	metersPerDegreeLat = 110540.0
+	metersPerDegreeLon = 111320.0
+)
+
+// RinkPoint is a position on a rink diagram: X from one end (0) to the other
+// (1), Y from one side (0) to the other (1)
+type RinkPoint struct {
+	X, Y float64
+}
+
+// RinkFrame maps GPS positions onto a rink diagram (see FitRink)
+type RinkFrame struct {
+	lat0, lon0 float64 // Origin of the local plane
+	cos, sin   float64 // Direction of the rink's length on it (east = 0)
+	// Extent of the rink along its length (u) and across (v), in meters
+	minU, maxU, minV, maxV float64
+}
+
+// FitRink fits a rink to where the camera went during a game: the rink's
+// length runs along the direction the fixes spread furthest, and it covers
+// most of them, widened or lengthened to a rink's proportions (RinkAspect).
+// A camera that stayed in one end only fills that end. Returns an error if
+// there are too few fixes or they hardly move, e.g. indoors without a fix.
+func FitRink(fixes []GPSFix) (*RinkFrame, error) {
+	if len(fixes) < rinkMinFixes {
+		return nil, fmt.Errorf("only %d GPS fixes, at least %d are needed", len(fixes), rinkMinFixes)
+	}
+
+	var lat0, lon0 float64
+	for _, fix := range fixes {
+		lat0 += fix.Lat
+		lon0 += fix.Lon
+	}
+	r := &RinkFrame{lat0: lat0 / float64(len(fixes)), lon0: lon0 / float64(len(fixes)), cos: 1}
+
+	// The length runs along the principal axis of the fixes
+	var sxx, syy, sxy float64
+	for _, fix := range fixes {
+		x, y := r.local(fix)
+		sxx += x * x
+		syy += y * y
+		sxy += x * y
+	}
+	angle := math.Atan2(2*sxy, sxx-syy) / 2
+	r.cos, r.sin = math.Cos(angle), math.Sin(angle)
+
+	us := make([]float64, len(fixes))
+	vs := make([]float64, len(fixes))
+	for i, fix := range fixes {
+		us[i], vs[i] = r.along(fix)
+	}
+	r.minU, r.maxU = trimmedRange(us)
+	r.minV, r.maxV = trimmedRange(vs)
+
+	length, width := r.maxU-r.minU, r.maxV-r.minV
+	if length < rinkMinLength {
+		return nil, fmt.Errorf("the camera only moved %.0f m, too little to show the rink", length)
+	}
+	if width < length*RinkAspect {
+		grow := (length*RinkAspect - width) / 2
+		r.minV, r.maxV = r.minV-grow, r.maxV+grow
+	} else {
+		grow := (width/RinkAspect - length) / 2
+		r.minU, r.maxU = r.minU-grow, r.maxU+grow
+	}
+	return r, nil
+}
+
+// Place returns where a fix is on the rink diagram, kept inside the rink
+func (r *RinkFrame) Place(fix GPSFix) RinkPoint {
+	u, v := r.along(fix)
+	return RinkPoint{
+		X: min(max((u-r.minU)/(r.maxU-r.minU), 0), 1),
+		Y: min(max((v-r.minV)/(r.maxV-r.minV), 0), 1),
+	}
+}
+
+// local returns a fix's position in meters east and north of the origin
+func (r *RinkFrame) local(fix GPSFix) (x, y float64) {
+	x = (fix.Lon - r.lon0) * metersPerDegreeLon * math.Cos(r.lat0*math.Pi/180)
+	y = (fix.Lat - r.lat0) * metersPerDegreeLat
+	return x, y
+}
+
+// along returns a fix's position in meters along the rink's length and across it
+func (r *RinkFrame) along(fix GPSFix) (u, v float64) {
+	x, y := r.local(fix)
+	return x*r.cos + y*r.sin, -x*r.sin + y*r.cos
+}
+
+// trimmedRange returns the range of values, leaving out rinkTrim of them at
+// each end. values is left as it is.
+func trimmedRange(values []float64) (lo, hi float64) {
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	cut := int(float64(len(sorted)) * rinkTrim)
+	return sorted[cut], sorted[len(sorted)-1-cut]
+}
+
+// FixAt returns the fix closest to a video offset, if one is within
+// fixMatchWindow of it. fixes must be in time order, as ReadGPSFixes returns them.
+func FixAt(fixes []GPSFix, at time.Duration) (GPSFix, bool) {
+	i := sort.Search(len(fixes), func(i int) bool { return fixes[i].At >= at })
+	best, found := GPSFix{}, false
+	var bestDiff time.Duration
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(fixes) {
+			continue
+		}
+		diff := fixes[j].At - at
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= fixMatchWindow && (!found || diff < bestDiff) {
+			best, found, bestDiff = fixes[j], true, diff
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,180 @@
+package metadata
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Somewhere near a rink, for fixes made from meters east and north of it
+const testLat, testLon = 44.97, -93.26
+
+// fixAt returns a fix x meters east and y meters north of testLat/testLon
+func fixAt(at time.Duration, x, y float64) GPSFix {
+	return GPSFix{
+		At:  at,
+		Lat: testLat + y/metersPerDegreeLat,
+		Lon: testLon + x/(metersPerDegreeLon*math.Cos(testLat*math.Pi/180)),
+	}
+}
+
+// trackAlong returns n fixes a second apart along a line length meters long
+// at angle (radians, east = 0) through the origin, zigzagging width meters
+// across it
+func trackAlong(n int, length, width, angle float64) []GPSFix {
+	fixes := make([]GPSFix, n)
+	for i := range fixes {
+		u := length * (float64(i)/float64(n-1) - 0.5)
+		v := width / 2
+		if i%2 == 1 {
+			v = -v
+		}
+		x := u*math.Cos(angle) - v*math.Sin(angle)
+		y := u*math.Sin(angle) + v*math.Cos(angle)
+		fixes[i] = fixAt(time.Duration(i)*time.Second, x, y)
+	}
+	return fixes
+}
+
+func near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestFitRinkDiagonalTrack(t *testing.T) {
+	// Up and down a rink that runs north-east
+	fixes := trackAlong(200, 50, 6, math.Pi/4)
+	r, err := FitRink(fixes)
+	if err != nil {
+		t.Fatalf("FitRink: %v", err)
+	}
+
+	// The length runs along the track, either way round
+	angle := math.Atan2(r.sin, r.cos)
+	if !near(math.Abs(math.Sin(angle)), math.Sin(math.Pi/4), 0.01) || r.cos*r.sin <= 0 {
+		t.Errorf("rink length runs at %.1f°, want 45° or -135°", angle*180/math.Pi)
+	}
+
+	// The ends of the track are at the ends of the rink, down its middle
+	first, last := r.Place(fixes[0]), r.Place(fixes[len(fixes)-1])
+	if !near(math.Abs(last.X-first.X), 1, 0.05) {
+		t.Errorf("track ends at X %.2f and %.2f, want about 0 and 1", first.X, last.X)
+	}
+	mid := r.Place(fixAt(0, 0, 0))
+	if !near(mid.X, 0.5, 0.02) || !near(mid.Y, 0.5, 0.02) {
+		t.Errorf("center of the track placed at (%.2f, %.2f), want (0.5, 0.5)", mid.X, mid.Y)
+	}
+}
+
+func TestFitRinkPadsToAspect(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixes    []GPSFix
+		min, max float64 // Expected length of the rink (m)
+	}{
+		// A narrow track keeps its length and is widened to a rink's proportions
+		{"narrow", trackAlong(200, 60, 2, 0), 55, 60},
+		// A track wider than a rink's proportions (30 m across 60) is lengthened to them
+		{"wide", trackAlong(200, 60, 30, math.Pi/2), 30 / RinkAspect, 80},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := FitRink(tt.fixes)
+			if err != nil {
+				t.Fatalf("FitRink: %v", err)
+			}
+			length, width := r.maxU-r.minU, r.maxV-r.minV
+			if !near(width/length, RinkAspect, 1e-9) {
+				t.Errorf("rink is %.1f by %.1f m (aspect %.3f), want aspect %.3f", length, width, width/length, RinkAspect)
+			}
+			if length < tt.min || length > tt.max {
+				t.Errorf("rink is %.1f m long, want %.1f-%.1f", length, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestFitRinkTooFewFixes(t *testing.T) {
+	_, err := FitRink(trackAlong(rinkMinFixes-1, 60, 2, 0))
+	if err == nil || !strings.Contains(err.Error(), "GPS fixes") {
+		t.Errorf("FitRink with %d fixes: error %v, want one about too few fixes", rinkMinFixes-1, err)
+	}
+	if _, err := FitRink(trackAlong(rinkMinFixes, 60, 2, 0)); err != nil {
+		t.Errorf("FitRink with %d fixes: %v", rinkMinFixes, err)
+	}
+}
+
+func TestFitRinkTooLittleMovement(t *testing.T) {
+	// Sitting in the stands, with the fix wandering a few meters
+	_, err := FitRink(trackAlong(100, rinkMinLength/2, 1, 0.3))
+	if err == nil || !strings.Contains(err.Error(), "too little") {
+		t.Errorf("FitRink of a camera that barely moved: error %v, want one about too little movement", err)
+	}
+}
+
+func TestFitRinkLeavesFixesAlone(t *testing.T) {
+	fixes := trackAlong(100, 50, 6, 1)
+	before := append([]GPSFix{}, fixes...)
+	if _, err := FitRink(fixes); err != nil {
+		t.Fatal(err)
+	}
+	for i := range fixes {
+		if fixes[i] != before[i] {
+			t.Fatalf("fix %d changed from %+v to %+v", i, before[i], fixes[i])
+		}
+	}
+}
+
+func TestTrimmedRangeKeepsOrder(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(100 - i) // 100 down to 1
+	}
+	lo, hi := trimmedRange(values)
+	if lo != 3 || hi != 98 {
+		t.Errorf("trimmedRange = %v-%v, want 3-98 (2 left out at each end)", lo, hi)
+	}
+	if values[0] != 100 || values[99] != 1 {
+		t.Errorf("trimmedRange sorted its input: starts %v, ends %v", values[0], values[99])
+	}
+}
+
+func TestFixAt(t *testing.T) {
+	fixes := []GPSFix{
+		fixAt(0, 0, 0),
+		fixAt(10*time.Second, 1, 0),
+		fixAt(13*time.Second, 2, 0),
+	}
+	tests := []struct {
+		name  string
+		at    time.Duration
+		want  time.Duration // At of the fix found
+		found bool
+	}{
+		{"exact", 10 * time.Second, 10 * time.Second, true},
+		{"window before the first", -fixMatchWindow, 0, true},
+		{"just outside before the first", -fixMatchWindow - time.Millisecond, 0, false},
+		{"window after the last", 13*time.Second + fixMatchWindow, 13 * time.Second, true},
+		{"just outside after the last", 13*time.Second + fixMatchWindow + time.Millisecond, 0, false},
+		{"window after a fix", fixMatchWindow, 0, true},
+		{"window before a fix", 10*time.Second - fixMatchWindow, 10 * time.Second, true},
+		{"between, out of reach of both", 5 * time.Second, 0, false},
+		{"both neighbours, earlier closer", 11 * time.Second, 10 * time.Second, true},
+		{"both neighbours, later closer", 12 * time.Second, 13 * time.Second, true},
+		{"both neighbours, as close", 11500 * time.Millisecond, 10 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fix, ok := FixAt(fixes, tt.at)
+			if ok != tt.found {
+				t.Fatalf("FixAt(%v) found %v, want %v", tt.at, ok, tt.found)
+			}
+			if ok && fix.At != tt.want {
+				t.Errorf("FixAt(%v) = fix at %v, want %v", tt.at, fix.At, tt.want)
+			}
+		})
+	}
+	if _, ok := FixAt(nil, 0); ok {
+		t.Error("FixAt with no fixes found one")
+	}
+}
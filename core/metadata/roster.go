@@ -0,0 +1,207 @@
+package metadata
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Player is one player on the team's roster
+type Player struct {
+	Number string `json:"number"` // Jersey number as written, e.g. "9" or "00"
+	Name   string `json:"name"`
+}
+
+// String returns the player as "#9 Connor Smith", or "#9" without a name
+func (p Player) String() string {
+	return strings.TrimSpace("#" + p.Number + " " + p.Name)
+}
+
+// ParseRoster parses a roster with one player per line, as typed or exported
+// from a team app: "9 Connor Smith", "#9 Connor Smith", "Connor Smith 9", or
+// CSV/tab-separated columns with the number in one and the name in the others
+// ("9,Connor,Smith"). A header row naming the columns (e.g. "Number,First
+// Name,Last Name,Position") picks the number and name columns, so others are
+// left out. Blank lines and lines starting with "# " or "//" are skipped. A
+// number listed twice is an error.
+func ParseRoster(text string) ([]Player, error) {
+	var roster []Player
+	var columns []string         // Header row: "number", "name" or "" by column
+	seen := make(map[string]int) // Line each number was listed on
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ';' || r == '\t' })
+		if len(fields) < 2 {
+			fields = strings.Fields(line)
+		}
+		var player Player
+		var name []string
+		for i, field := range fields {
+			field = strings.Trim(strings.TrimSpace(field), `"`)
+			if columns != nil && (i >= len(columns) || columns[i] == "") {
+				continue
+			}
+			if number, ok := jerseyNumber(field); ok && player.Number == "" {
+				player.Number = number
+				continue
+			}
+			if field != "" && (columns == nil || columns[i] == "name") {
+				name = append(name, field)
+			}
+		}
+		player.Name = strings.Join(name, " ")
+
+		if player.Number == "" {
+			if len(roster) == 0 {
+				columns = rosterColumns(fields) // A header row
+				continue
+			}
+			return nil, fmt.Errorf("line %d: no jersey number in %q", lineNum, line)
+		}
+		if first, ok := seen[player.Number]; ok {
+			return nil, fmt.Errorf("line %d: #%s is already on line %d", lineNum, player.Number, first)
+		}
+		seen[player.Number] = lineNum
+		roster = append(roster, player)
+	}
+	return roster, nil
+}
+
+// rosterColumns reads a roster's header row: which columns hold the number
+// and the name ("" for the others). It returns nil if it names neither.
+func rosterColumns(header []string) []string {
+	columns := make([]string, len(header))
+	found := false
+	for i, h := range header {
+		h = strings.ToLower(strings.Trim(strings.TrimSpace(h), `"`))
+		switch {
+		case strings.Contains(h, "number") || strings.Contains(h, "jersey") || h == "#" || h == "no" || h == "no.":
+			columns[i] = "number"
+		case strings.Contains(h, "name") || h == "player" || h == "first" || h == "last":
+			columns[i] = "name"
+		default:
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	return columns
+}
+
+// jerseyNumber returns the number in a field like "9", "#9" or "No. 9"
+func jerseyNumber(field string) (string, bool) {
+	number := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(field, "No."), "#"))
+	if number == "" || len(number) > 3 {
+		return "", false
+	}
+	for _, r := range number {
+		if !unicode.IsDigit(r) {
+			return "", false
+		}
+	}
+	return number, true
+}
+
+// FindPlayer returns the roster's player with a jersey number, or a player
+// with just the number if they aren't on it (any more)
+func FindPlayer(roster []Player, number string) Player {
+	for _, p := range roster {
+		if p.Number == number {
+			return p
+		}
+	}
+	return Player{Number: number}
+}
+
+// TaggedClip is what a player tally needs to know about one clip
+type TaggedClip struct {
+	Players  []string // Jersey numbers of the players tagged in it
+	Goal     bool     // Whether it shows a goal
+	Duration float64  // Length in seconds
+}
+
+// PlayerStats is one player's tally over a game's (or season's) clips
+type PlayerStats struct {
+	Player     Player
+	Goals      int     // Clips showing a goal the player was tagged in
+	Highlights int     // Clips the player was tagged in
+	ScreenTime float64 // Seconds of those clips
+}
+
+// TallyPlayers counts each player's clips, goals and screen time. Every roster
+// player is listed, even without clips, as is each player tagged who isn't on
+// the roster. Most goals come first, then most highlights, then most screen
+// time, then in jersey number order.
+func TallyPlayers(roster []Player, clips []TaggedClip) []PlayerStats {
+	byNumber := make(map[string]*PlayerStats)
+	var stats []*PlayerStats
+	add := func(p Player) *PlayerStats {
+		s := &PlayerStats{Player: p}
+		byNumber[p.Number] = s
+		stats = append(stats, s)
+		return s
+	}
+	for _, p := range roster {
+		add(p)
+	}
+
+	for _, clip := range clips {
+		counted := make(map[string]bool, len(clip.Players))
+		for _, number := range clip.Players {
+			if counted[number] {
+				continue
+			}
+			counted[number] = true
+			s := byNumber[number]
+			if s == nil {
+				s = add(Player{Number: number})
+			}
+			s.Highlights++
+			s.ScreenTime += clip.Duration
+			if clip.Goal {
+				s.Goals++
+			}
+		}
+	}
+
+	result := make([]PlayerStats, len(stats))
+	for i, s := range stats {
+		result[i] = *s
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		switch {
+		case a.Goals != b.Goals:
+			return a.Goals > b.Goals
+		case a.Highlights != b.Highlights:
+			return a.Highlights > b.Highlights
+		case a.ScreenTime != b.ScreenTime:
+			return a.ScreenTime > b.ScreenTime
+		}
+		return lessNumber(a.Player.Number, b.Player.Number)
+	})
+	return result
+}
+
+// lessNumber orders jersey numbers by value, "0" before "00" before "1"
+func lessNumber(a, b string) bool {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		return len(ta) < len(tb)
+	}
+	if ta != tb {
+		return ta < tb
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,145 @@
+package metadata
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScoreEvent is a score change entered by hand, e.g. "P1 14:05 1-0": the
+// score became Home-Away at that clock time in that period
+type ScoreEvent struct {
+	Period string        // Short period name, e.g. "P1" ("" = any period)
+	Clock  time.Duration // Clock time of day, as shown for {clock}
+	Home   int
+	Away   int
+}
+
+// ScoreTimeline is a game's score changes in the order they happened
+type ScoreTimeline []ScoreEvent
+
+// ScoreSpan is the score shown from Offset seconds into a clip until the next
+// span (or the end of the clip)
+type ScoreSpan struct {
+	Offset float64
+	Home   int
+	Away   int
+}
+
+// ParseScoreTimeline parses one score change per line, as
+// "<period> <clock> <home>-<away>" (e.g. "P2 14:30 1-1" or "2Period 14:30:15 1-1").
+// The period may be left out. Blank lines and lines starting with # are skipped.
+func ParseScoreTimeline(text string) (ScoreTimeline, error) {
+	var timeline ScoreTimeline
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		var event ScoreEvent
+		switch len(fields) {
+		case 2:
+		case 3:
+			event.Period = strings.ToUpper(ShortPeriodName(fields[0]))
+			fields = fields[1:]
+		default:
+			return nil, fmt.Errorf("line %d: expected \"<period> <clock> <home>-<away>\", got %q", lineNum, line)
+		}
+
+		clock, err := parseClock(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		event.Clock = clock
+
+		if n, _ := fmt.Sscanf(fields[1], "%d-%d", &event.Home, &event.Away); n != 2 || event.Home < 0 || event.Away < 0 {
+			return nil, fmt.Errorf("line %d: invalid score %q (expected e.g. 2-1)", lineNum, fields[1])
+		}
+		timeline = append(timeline, event)
+	}
+	return timeline, nil
+}
+
+// parseClock parses a clock time of day as HH:MM or HH:MM:SS
+func parseClock(s string) (time.Duration, error) {
+	var h, m, sec int
+	n, _ := fmt.Sscanf(s, "%d:%d:%d", &h, &m, &sec)
+	if n < 2 || h < 0 || h > 23 || m < 0 || m > 59 || sec < 0 || sec > 59 {
+		return 0, fmt.Errorf("invalid clock time %q (expected HH:MM or HH:MM:SS)", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second, nil
+}
+
+// TimeOfDay returns the clock time of day of t, for comparing with ScoreEvent.Clock
+func TimeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
+
+// ScoreAt returns the score at a clock time in a period. periods lists the
+// game's short period names in order; an event counts if its period comes
+// earlier, or it is in the same period (or either period is unknown) at or
+// before clock.
+func (t ScoreTimeline) ScoreAt(periods []string, period string, clock time.Duration) (home, away int) {
+	for _, event := range t {
+		if eventBefore(periods, event, period, clock) {
+			home, away = event.Home, event.Away
+		}
+	}
+	return home, away
+}
+
+// ClipScores returns the score over a clip of the given duration (seconds)
+// starting at clock time start in period: the score at the start, then one
+// span per change during the clip
+func (t ScoreTimeline) ClipScores(periods []string, period string, start time.Duration, duration float64) []ScoreSpan {
+	home, away := t.ScoreAt(periods, period, start)
+	spans := []ScoreSpan{{Offset: 0, Home: home, Away: away}}
+
+	end := start + time.Duration(duration*float64(time.Second))
+	for _, event := range t {
+		if event.Clock <= start || event.Clock >= end || !samePeriod(periods, event.Period, period) {
+			continue
+		}
+		spans = append(spans, ScoreSpan{
+			Offset: (event.Clock - start).Seconds(),
+			Home:   event.Home,
+			Away:   event.Away,
+		})
+	}
+	return spans
+}
+
+// eventBefore returns true if event happened at or before clock in period
+func eventBefore(periods []string, event ScoreEvent, period string, clock time.Duration) bool {
+	eventRank, rank := periodRank(periods, event.Period), periodRank(periods, period)
+	if eventRank >= 0 && rank >= 0 && eventRank != rank {
+		return eventRank < rank
+	}
+	return event.Clock <= clock
+}
+
+// samePeriod returns true if the two periods can't be told apart
+func samePeriod(periods []string, a, b string) bool {
+	rankA, rankB := periodRank(periods, a), periodRank(periods, b)
+	return rankA < 0 || rankB < 0 || rankA == rankB
+}
+
+// periodRank returns the position of a period in periods, or -1 if unknown
+func periodRank(periods []string, period string) int {
+	if period == "" {
+		return -1
+	}
+	for i, p := range periods {
+		if strings.EqualFold(ShortPeriodName(p), ShortPeriodName(period)) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,269 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SourceFormat describes the period video files referenced by an NLE sidecar
+type SourceFormat struct {
+	FrameRate float64            // e.g. 59.94 or 60
+	Width     int                // e.g. 1920
+	Height    int                // e.g. 1080
+	Durations map[string]float64 // Period name -> video duration in seconds
+}
+
+// isNTSC returns true for fractional rates such as 29.97 or 59.94
+func (sf SourceFormat) isNTSC() bool {
+	return math.Abs(sf.FrameRate-math.Round(sf.FrameRate)) > 0.01
+}
+
+// timebase returns the integer frame count per timecode second (e.g. 60 for 59.94)
+func (sf SourceFormat) timebase() int {
+	tb := int(math.Round(sf.FrameRate))
+	if tb <= 0 {
+		return 60
+	}
+	return tb
+}
+
+// toFrames converts seconds to a frame count at the source frame rate
+func (sf SourceFormat) toFrames(sec float64) int64 {
+	fps := sf.FrameRate
+	if fps <= 0 {
+		fps = 60
+	}
+	return int64(math.Round(sec * fps))
+}
+
+// timecode formats a frame count as non-drop HH:MM:SS:FF
+func (sf SourceFormat) timecode(frames int64) string {
+	tb := int64(sf.timebase())
+	ff := frames % tb
+	totalSec := frames / tb
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", totalSec/3600, (totalSec%3600)/60, totalSec%60, ff)
+}
+
+// fcpTime formats a frame count as an FCPXML rational time (e.g. "1001/60000s")
+func (sf SourceFormat) fcpTime(frames int64) string {
+	if frames == 0 {
+		return "0s"
+	}
+	tb := int64(sf.timebase())
+	if sf.isNTSC() {
+		return fmt.Sprintf("%d/%ds", frames*1001, tb*1000)
+	}
+	return fmt.Sprintf("%d/%ds", frames, tb)
+}
+
+// WriteEDL writes a CMX3600 EDL with one event per clip group, referencing the
+// period video files at the clip in/out points. Each highlight becomes a marker
+// (* LOC) so DaVinci Resolve imports them onto the timeline.
+func WriteEDL(path, title string, result *AnalysisResult, groups []ClipGroup, format SourceFormat) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "TITLE: %s\n", title)
+	fmt.Fprintf(&sb, "FCM: NON-DROP FRAME\n\n")
+
+	var recordFrames int64
+	for i, g := range groups {
+		srcIn := format.toFrames(g.StartTime)
+		srcOut := format.toFrames(g.EndTime)
+		length := srcOut - srcIn
+
+		videoFile := result.GetPeriodVideoFile(g.Period)
+		fmt.Fprintf(&sb, "%03d  AX       AA/V  C        %s %s %s %s\n",
+			i+1,
+			format.timecode(srcIn), format.timecode(srcOut),
+			format.timecode(recordFrames), format.timecode(recordFrames+length),
+		)
+		fmt.Fprintf(&sb, "* FROM CLIP NAME: %s\n", filepath.Base(videoFile))
+
+		// Markers are positioned on the record (timeline) side
+		for _, ch := range g.Chapters {
+			offset := format.toFrames(ch.VideoTime.Seconds()) - srcIn
+			if offset < 0 {
+				offset = 0
+			}
+			fmt.Fprintf(&sb, "* LOC: %s RED     %s Ch%02d (#%d %s)\n",
+				format.timecode(recordFrames+offset),
+				ch.Period, ch.Number, ch.GlobalOrder, ch.ClockTime.Format("15:04:05"))
+		}
+		sb.WriteString("\n")
+
+		recordFrames += length
+	}
+
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write EDL: %w", err)
+	}
+	return nil
+}
+
+// FCPXML document structure (only the elements we need)
+type fcpxmlDoc struct {
+	XMLName   xml.Name        `xml:"fcpxml"`
+	Version   string          `xml:"version,attr"`
+	Resources fcpxmlResources `xml:"resources"`
+	Library   fcpxmlLibrary   `xml:"library"`
+}
+
+type fcpxmlResources struct {
+	Format fcpxmlFormat  `xml:"format"`
+	Assets []fcpxmlAsset `xml:"asset"`
+}
+
+type fcpxmlFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxmlAsset struct {
+	ID       string         `xml:"id,attr"`
+	Name     string         `xml:"name,attr"`
+	Start    string         `xml:"start,attr"`
+	Duration string         `xml:"duration,attr"`
+	HasVideo string         `xml:"hasVideo,attr"`
+	HasAudio string         `xml:"hasAudio,attr"`
+	Format   string         `xml:"format,attr"`
+	MediaRep fcpxmlMediaRep `xml:"media-rep"`
+}
+
+type fcpxmlMediaRep struct {
+	Kind string `xml:"kind,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+type fcpxmlLibrary struct {
+	Event fcpxmlEvent `xml:"event"`
+}
+
+type fcpxmlEvent struct {
+	Name    string        `xml:"name,attr"`
+	Project fcpxmlProject `xml:"project"`
+}
+
+type fcpxmlProject struct {
+	Name     string         `xml:"name,attr"`
+	Sequence fcpxmlSequence `xml:"sequence"`
+}
+
+type fcpxmlSequence struct {
+	Format   string           `xml:"format,attr"`
+	Duration string           `xml:"duration,attr"`
+	TCStart  string           `xml:"tcStart,attr"`
+	TCFormat string           `xml:"tcFormat,attr"`
+	Clips    []fcpxmlAssetRef `xml:"spine>asset-clip"`
+}
+
+type fcpxmlAssetRef struct {
+	Ref      string         `xml:"ref,attr"`
+	Name     string         `xml:"name,attr"`
+	Offset   string         `xml:"offset,attr"`
+	Start    string         `xml:"start,attr"`
+	Duration string         `xml:"duration,attr"`
+	Markers  []fcpxmlMarker `xml:"marker"`
+}
+
+type fcpxmlMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+// WriteFCPXML writes an FCPXML 1.9 project (importable by DaVinci Resolve and
+// Final Cut Pro) with one asset per period video and one clip per clip group.
+// Highlights become markers on each clip, positioned at the original HiLight.
+func WriteFCPXML(path, title string, result *AnalysisResult, groups []ClipGroup, format SourceFormat) error {
+	doc := fcpxmlDoc{Version: "1.9"}
+	doc.Resources.Format = fcpxmlFormat{
+		ID:            "r0",
+		FrameDuration: format.fcpTime(1),
+		Width:         format.Width,
+		Height:        format.Height,
+	}
+
+	// One asset per period video
+	assetIDs := make(map[string]string)
+	for i, p := range result.Periods {
+		id := fmt.Sprintf("r%d", i+1)
+		assetIDs[p.Name] = id
+
+		absPath, err := filepath.Abs(p.VideoFile)
+		if err != nil {
+			absPath = p.VideoFile
+		}
+		doc.Resources.Assets = append(doc.Resources.Assets, fcpxmlAsset{
+			ID:       id,
+			Name:     strings.TrimSuffix(filepath.Base(p.VideoFile), filepath.Ext(p.VideoFile)),
+			Start:    "0s",
+			Duration: format.fcpTime(format.toFrames(format.Durations[p.Name])),
+			HasVideo: "1",
+			HasAudio: "1",
+			Format:   "r0",
+			MediaRep: fcpxmlMediaRep{Kind: "original-media", Src: fileURL(absPath)},
+		})
+	}
+
+	// One asset-clip per clip group, laid end to end on the spine
+	var offset int64
+	for _, g := range groups {
+		srcIn := format.toFrames(g.StartTime)
+		length := format.toFrames(g.EndTime) - srcIn
+
+		clipName := strings.TrimSuffix(GenerateGroupFilename(g), ".mp4")
+		clip := fcpxmlAssetRef{
+			Ref:      assetIDs[g.Period],
+			Name:     clipName,
+			Offset:   format.fcpTime(offset),
+			Start:    format.fcpTime(srcIn),
+			Duration: format.fcpTime(length),
+		}
+
+		// Marker times are in the asset's own timeline (source time)
+		for _, ch := range g.Chapters {
+			clip.Markers = append(clip.Markers, fcpxmlMarker{
+				Start:    format.fcpTime(format.toFrames(ch.VideoTime.Seconds())),
+				Duration: format.fcpTime(1),
+				Value:    fmt.Sprintf("%s Ch%02d (#%d %s)", ch.Period, ch.Number, ch.GlobalOrder, ch.ClockTime.Format("15:04:05")),
+			})
+		}
+
+		doc.Library.Event.Project.Sequence.Clips = append(doc.Library.Event.Project.Sequence.Clips, clip)
+		offset += length
+	}
+
+	doc.Library.Event.Name = title
+	doc.Library.Event.Project.Name = title
+	doc.Library.Event.Project.Sequence.Format = "r0"
+	doc.Library.Event.Project.Sequence.Duration = format.fcpTime(offset)
+	doc.Library.Event.Project.Sequence.TCStart = "0s"
+	doc.Library.Event.Project.Sequence.TCFormat = "NDF"
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode FCPXML: %w", err)
+	}
+
+	out := xml.Header + "<!DOCTYPE fcpxml>\n" + string(data) + "\n"
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		return fmt.Errorf("failed to write FCPXML: %w", err)
+	}
+	return nil
+}
+
+// fileURL converts a local path to a file:// URL (handles Windows drive letters)
+func fileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TemplateTokens lists the tokens a name template can use, for help text
+var TemplateTokens = []string{"{game}", "{team}", "{opponent}", "{date}", "{location}", "{score}", "{period}", "{clock}", "{chapter}", "{order}", "{label}", "{note}"}
+
+// TemplateValues are the values substituted into a name template. Values that
+// don't apply (e.g. {chapter} for a whole reel) are left empty.
+type TemplateValues struct {
+	Game     string    // {game}: game name, e.g. "Hawks vs Wolves"
+	Team     string    // {team}: team name
+	Opponent string    // {opponent}: the other team's name
+	Date     time.Time // {date}: game date, as 2006-01-02 (zero = unknown)
+	Location string    // {location}: rink or arena
+	Score    string    // {score}: final score, e.g. "4-2"
+	Period   string    // {period}: short period name, e.g. "P2"
+	Clock    time.Time // {clock}: clock time of the highlight, as 15:04
+	Chapter  string    // {chapter}: e.g. "Ch07", or "Ch05-06" for merged highlights (see ChapterLabel)
+	Order    int       // {order}: position across all periods, as 041 (0 = none)
+	Label    string    // {label}: highlight description from an imported stat sheet
+	Note     string    // {note}: note typed on the clip in Step 3
+}
+
+// GroupTemplateValues returns the template values describing a clip group.
+// The game's details (name, teams, location, score) are left for the caller
+// to fill in.
+func GroupTemplateValues(group ClipGroup) TemplateValues {
+	first := group.PrimaryChapter
+	return TemplateValues{
+		Date:    first.ClockTime,
+		Period:  ShortPeriodName(group.Period),
+		Clock:   first.ClockTime,
+		Chapter: ChapterLabel(group),
+		Order:   first.GlobalOrder,
+		Label:   first.Label,
+	}
+}
+
+// ShortPeriodName turns a period name into its short form ("2Period" and
+// "2Period-2" -> "P2"). Other names are returned unchanged.
+func ShortPeriodName(name string) string {
+	var number int
+	if n, _ := fmt.Sscanf(name, "%dPeriod", &number); n == 1 {
+		return fmt.Sprintf("P%d", number)
+	}
+	return name
+}
+
+// ExpandTemplate replaces the tokens in template with values, e.g.
+// "{period} {clock} {chapter}" -> "P2 12:45 Ch07". Runs of spaces and
+// separators left by empty values are tidied up. Unknown tokens are kept as is.
+func ExpandTemplate(template string, values TemplateValues) string {
+	var date, clock, order string
+	if !values.Date.IsZero() {
+		date = values.Date.Format("2006-01-02")
+	}
+	if !values.Clock.IsZero() {
+		clock = values.Clock.Format("15:04")
+	}
+	if values.Order > 0 {
+		order = fmt.Sprintf("%03d", values.Order)
+	}
+
+	expanded := strings.NewReplacer(
+		"{game}", values.Game,
+		"{team}", values.Team,
+		"{opponent}", values.Opponent,
+		"{date}", date,
+		"{location}", values.Location,
+		"{score}", values.Score,
+		"{period}", values.Period,
+		"{clock}", clock,
+		"{chapter}", values.Chapter,
+		"{order}", order,
+		"{label}", values.Label,
+		"{note}", values.Note,
+	).Replace(template)
+
+	return strings.Trim(strings.Join(strings.Fields(expanded), " "), " -,")
+}
+
+// AppendNote adds a clip's note to text expanded from template ("P2 12:45 Ch07
+// - Backhand roof shot by #9"), unless the template already places it with
+// {note}. text is returned unchanged if there is no note.
+func AppendNote(text, template, note string) string {
+	if note == "" || strings.Contains(template, "{note}") {
+		return text
+	}
+	if text == "" {
+		return note
+	}
+	return text + " - " + note
+}
+
+// invalidPathChars are the characters removed from folder names
+var invalidPathChars = regexp.MustCompile(`[<>:"/\\|?*]`)
+
+// ExpandPathTemplate expands a folder template such as "{game}/{date}/clips"
+// into a relative path. Each folder name is expanded separately, ":" becomes
+// "-" (clock times) and other characters invalid in file names are removed.
+// Folders that expand to nothing are left out.
+func ExpandPathTemplate(template string, values TemplateValues) string {
+	var folders []string
+	for _, segment := range strings.FieldsFunc(template, func(r rune) bool { return r == '/' || r == '\\' }) {
+		folder := strings.ReplaceAll(ExpandTemplate(segment, values), ":", "-")
+		folder = strings.Trim(invalidPathChars.ReplaceAllString(folder, ""), " .")
+		if folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+	return filepath.Join(folders...)
+}
@@ -0,0 +1,78 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimecodeFPS is the frame rate a timecode is taken to count frames at
+// when the video's can't be read (GoPro's usual 59.94, rounded)
+const DefaultTimecodeFPS = 60.0
+
+// MissingTimecodeError is returned by the analysis when a period's start
+// timecode can't be read, e.g. a MOV exported from Quik or re-muxed without
+// its tmcd track. Entering the start by hand (see Analyzer.SetManualStarts)
+// lets the analysis go ahead.
+type MissingTimecodeError struct {
+	Period    string
+	VideoFile string
+	Err       error
+}
+
+func (e *MissingTimecodeError) Error() string {
+	return fmt.Sprintf("failed to get timecode for %s: %v", e.Period, e.Err)
+}
+
+func (e *MissingTimecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ParseTimeOfDay parses a clock time of day entered by hand as HH:MM or HH:MM:SS
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	return parseClock(strings.TrimSpace(s))
+}
+
+// FormatTimeOfDay formats a time of day as HH:MM:SS, wrapping past midnight
+func FormatTimeOfDay(d time.Duration) string {
+	d %= 24 * time.Hour
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	total := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// ParseOffset parses a signed offset entered as MM:SS or H:MM:SS, e.g.
+// "25:00" or "-1:30"
+func ParseOffset(s string) (time.Duration, error) {
+	text := strings.TrimSpace(s)
+	sign := time.Duration(1)
+	s = strings.TrimPrefix(text, "+")
+	if rest, ok := strings.CutPrefix(text, "-"); ok {
+		sign, s = -1, rest
+	}
+
+	var h, m, sec int
+	var n int
+	switch strings.Count(s, ":") {
+	case 1:
+		n, _ = fmt.Sscanf(s, "%d:%d", &m, &sec)
+		n++
+	case 2:
+		n, _ = fmt.Sscanf(s, "%d:%d:%d", &h, &m, &sec)
+	}
+	if n != 3 || h < 0 || m < 0 || sec < 0 || sec > 59 || (h > 0 && m > 59) {
+		return 0, fmt.Errorf("invalid offset %q (expected MM:SS or H:MM:SS, e.g. 25:00 or -1:30)", text)
+	}
+	return sign * (time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second), nil
+}
+
+// TimecodeTimeOfDay returns the time of day of a GoPro timecode (HH:MM:SS:FF)
+func TimecodeTimeOfDay(timecode string) (time.Duration, error) {
+	seconds, err := TimecodeToSeconds(timecode)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
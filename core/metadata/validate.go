@@ -0,0 +1,152 @@
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// overlapTolerance is how much two periods' clock ranges may overlap before
+	// it is reported (timecodes are only frame-accurate to the camera clock)
+	overlapTolerance = 2 * time.Second
+	// maxPeriodGap is the longest believable break between two periods of one game
+	maxPeriodGap = 90 * time.Minute
+	// chapterRangeTolerance allows for HiLights pressed on the very last frame
+	chapterRangeTolerance = time.Second
+)
+
+// PeriodWarning is a sanity check on the analysis that failed, with a
+// suggestion for what is likely wrong
+type PeriodWarning struct {
+	Kind       string   `json:"kind"` // "overlap", "order", "gap" or "chapter_range"
+	Periods    []string `json:"periods"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion"`
+}
+
+// String formats the warning with its suggestion for display
+func (w PeriodWarning) String() string {
+	return w.Message + "\n    " + w.Suggestion
+}
+
+// PeriodSpan is the clock time range a period's video covers
+type PeriodSpan struct {
+	Name     string
+	Start    time.Time     // Clock time of the first frame (zero if unknown)
+	Duration time.Duration // Video duration (0 if unknown)
+	Offset   time.Duration // Where the period starts in its video (split recordings)
+}
+
+// End returns the clock time of the last frame
+func (s PeriodSpan) End() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// ValidatePeriods checks the periods (in the order they were set up) and their
+// chapters for problems that would otherwise silently produce misordered or
+// missing clips: periods whose clock ranges overlap, periods that start before
+// the one listed ahead of them, implausibly long gaps between periods, and
+// chapters past the end of their video.
+func ValidatePeriods(spans []PeriodSpan, chapters []Chapter) []PeriodWarning {
+	var warnings []PeriodWarning
+
+	// Chapters must fall inside their video
+	for _, span := range spans {
+		if span.Duration <= 0 {
+			continue
+		}
+		outside := 0
+		for _, ch := range chapters {
+			if ch.Period == span.Name && ch.VideoTime-span.Offset > span.Duration+chapterRangeTolerance {
+				outside++
+			}
+		}
+		if outside > 0 {
+			warnings = append(warnings, PeriodWarning{
+				Kind:    "chapter_range",
+				Periods: []string{span.Name},
+				Message: fmt.Sprintf("%s: %d HiLights are after the end of the video (%s long)",
+					span.Name, outside, FormatVideoTime(span.Duration)),
+				Suggestion: "The chapter metadata probably belongs to a different recording. Check that the " +
+					"_metadata.txt / GoPro MP4 is paired with the right MOV, or that the MOV wasn't trimmed.",
+			})
+		}
+	}
+
+	var known []PeriodSpan
+	for _, span := range spans {
+		if !span.Start.IsZero() {
+			known = append(known, span)
+		}
+	}
+
+	// Periods should start in the order they are numbered
+	for i := 1; i < len(known); i++ {
+		prev, curr := known[i-1], known[i]
+		if curr.Start.Before(prev.Start) {
+			warnings = append(warnings, PeriodWarning{
+				Kind:    "order",
+				Periods: []string{prev.Name, curr.Name},
+				Message: fmt.Sprintf("%s starts at %s, before %s (%s)",
+					curr.Name, curr.Start.Format("15:04:05"), prev.Name, prev.Start.Format("15:04:05")),
+				Suggestion: "Periods are numbered in file name order. Reorder them in Step 1, or if the " +
+					"order is right, check the timecode of both files (camera clock, or wrong file pairing).",
+			})
+		}
+	}
+
+	// In clock order, one camera's recordings can't overlap and shouldn't be hours apart
+	sorted := append([]PeriodSpan{}, known...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	for i := 1; i < len(sorted); i++ {
+		prev, curr := sorted[i-1], sorted[i]
+		if prev.Duration <= 0 {
+			continue
+		}
+
+		gap := curr.Start.Sub(prev.End())
+		switch {
+		case gap < -overlapTolerance:
+			warnings = append(warnings, PeriodWarning{
+				Kind:    "overlap",
+				Periods: []string{prev.Name, curr.Name},
+				Message: fmt.Sprintf("%s (%s-%s) and %s (%s-%s) overlap by %s",
+					prev.Name, prev.Start.Format("15:04:05"), prev.End().Format("15:04:05"),
+					curr.Name, curr.Start.Format("15:04:05"), curr.End().Format("15:04:05"),
+					FormatVideoTime(-gap)),
+				Suggestion: "One camera can't record both at once, so a timecode is probably wrong: a MOV " +
+					"converted without its timecode (extract metadata from the GoPro MP4 instead), the same " +
+					"recording loaded twice, or files from another camera or game.",
+			})
+		case gap > maxPeriodGap:
+			warnings = append(warnings, PeriodWarning{
+				Kind:    "gap",
+				Periods: []string{prev.Name, curr.Name},
+				Message: fmt.Sprintf("%s ends at %s but %s only starts at %s (%s later)",
+					prev.Name, prev.End().Format("15:04:05"), curr.Name, curr.Start.Format("15:04:05"),
+					gap.Round(time.Minute)),
+				Suggestion: "Check that both files are from this game, and that the camera clock " +
+					"(and so the timecode) was right on both.",
+			})
+		}
+	}
+
+	return warnings
+}
+
+// GetWarningsSummary returns the analysis warnings as one line each with
+// their suggestions. Returns "" if there are none.
+func (result *AnalysisResult) GetWarningsSummary() string {
+	if len(result.Warnings) == 0 {
+		return ""
+	}
+
+	summary := fmt.Sprintf("%d problems found:", len(result.Warnings))
+	for _, w := range result.Warnings {
+		summary += "\n- " + w.String()
+	}
+	return summary
+}
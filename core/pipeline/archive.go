@@ -0,0 +1,238 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// archiveBufferSize is how much of a file is copied (and hashed) between
+// progress reports
+const archiveBufferSize = 4 << 20
+
+// rawGoProPattern matches the file names of GoPro MP4 originals, including
+// the parts of split recordings ("GX010092.MP4", "GH020092.MP4")
+var rawGoProPattern = regexp.MustCompile(`(?i)^(GX|GH)\d{6}\.mp4$`)
+
+// IsRawGoPro returns true if path is named like an MP4 straight off a GoPro
+func IsRawGoPro(path string) bool {
+	return rawGoProPattern.MatchString(filepath.Base(path))
+}
+
+// ArchiveManifest lists the files moved by one ArchiveOriginals run with their
+// checksums, written next to them so a copy can be checked years later (see
+// ManifestName)
+type ArchiveManifest struct {
+	Game    string         `json:"game,omitempty"`
+	Created time.Time      `json:"created"`
+	Files   []ArchivedFile `json:"files"`
+	// Error is why the run stopped before moving every file ("" = it didn't)
+	Error string `json:"error,omitempty"`
+}
+
+// ArchivedFile is one file moved into an archive
+type ArchivedFile struct {
+	Source   string `json:"source"`   // Where it was
+	Archived string `json:"archived"` // Where it is now
+	Bytes    int64  `json:"bytes"`
+	SHA256   string `json:"sha256"` // Hex, as printed by sha256sum
+}
+
+// ManifestName returns the file name of an archive manifest, e.g.
+// "archive-manifest-20241109-193012.json", named by when the run started so
+// each run keeps its own
+func ManifestName(created time.Time) string {
+	return fmt.Sprintf("archive-manifest-%s.json", created.Format("20060102-150405"))
+}
+
+// ArchiveOriginals moves sources into folder one at a time: each is copied,
+// the copy is read back and its SHA-256 compared with the original's, and
+// only then is the original deleted. A file already in folder with the same
+// checksum (from an earlier, interrupted run) is kept and the original just
+// deleted; one with different contents is an error. progress is called with
+// the bytes copied so far and in total; returning an error from it stops the
+// run. The manifest lists the files moved, even when the run stops early, and
+// is written to folder; its path is returned with it ("" if nothing was moved).
+func ArchiveOriginals(sources []string, folder, game string, progress func(done, total int64) error) (*ArchiveManifest, string, error) {
+	manifest := &ArchiveManifest{Game: game, Created: time.Now()}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return manifest, "", fmt.Errorf("failed to create archive folder: %w", err)
+	}
+
+	var total int64
+	for _, source := range sources {
+		info, err := os.Stat(source)
+		if err != nil {
+			return manifest, "", fmt.Errorf("failed to read %s: %w", filepath.Base(source), err)
+		}
+		total += info.Size()
+	}
+
+	var done int64
+	var runErr error
+	for _, source := range sources {
+		file, err := archiveFile(source, folder, func(n int64) error {
+			return progress(done+n, total)
+		})
+		if err != nil {
+			runErr = err
+			break
+		}
+		done += file.Bytes
+		manifest.Files = append(manifest.Files, file)
+	}
+	if len(manifest.Files) == 0 {
+		return manifest, "", runErr
+	}
+	if runErr != nil {
+		manifest.Error = runErr.Error()
+	}
+
+	path, err := writeManifest(folder, manifest)
+	if runErr != nil {
+		return manifest, path, runErr
+	}
+	return manifest, path, err
+}
+
+// archiveFile moves one file into folder, verified as ArchiveOriginals
+// describes. progress is called with the bytes of it copied so far.
+func archiveFile(source, folder string, progress func(n int64) error) (ArchivedFile, error) {
+	name := filepath.Base(source)
+	dest := filepath.Join(folder, name)
+	file := ArchivedFile{Source: source, Archived: dest}
+
+	if _, err := os.Stat(dest); err == nil {
+		// Left by an earlier run that stopped before deleting the original
+		sum, size, err := hashFile(source, progress)
+		if err != nil {
+			return file, err
+		}
+		archived, _, err := hashFile(dest, nil)
+		if err != nil {
+			return file, err
+		}
+		if archived != sum {
+			return file, fmt.Errorf("%s is already in the archive with different contents", name)
+		}
+		file.SHA256, file.Bytes = sum, size
+	} else {
+		// Named like the ffmpeg package's unfinished writes ("clip.partial.mp4")
+		tmpPath := strings.TrimSuffix(dest, filepath.Ext(dest)) + ".partial" + filepath.Ext(dest)
+		sum, size, err := copyHashed(source, tmpPath, progress)
+		if err == nil {
+			var copied string
+			copied, _, err = hashFile(tmpPath, nil)
+			if err == nil && copied != sum {
+				err = fmt.Errorf("the copy of %s doesn't match the original (SHA-256 %s, not %s)", name, copied, sum)
+			}
+		}
+		if err == nil {
+			if err = os.Rename(tmpPath, dest); err != nil {
+				err = fmt.Errorf("failed to move %s into place: %w", name, err)
+			}
+		}
+		if err != nil {
+			os.Remove(tmpPath)
+			return file, err
+		}
+		file.SHA256, file.Bytes = sum, size
+	}
+
+	if err := os.Remove(source); err != nil {
+		return file, fmt.Errorf("%s was archived but the original couldn't be deleted: %w", name, err)
+	}
+	return file, nil
+}
+
+// copyHashed copies source to dest, flushed to disk, and returns the
+// SHA-256 of what was read
+func copyHashed(source, dest string, progress func(n int64) error) (string, int64, error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", filepath.Base(source), err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create %s: %w", filepath.Base(dest), err)
+	}
+	h := sha256.New()
+	size, err := copyProgress(io.MultiWriter(out, h), in, progress)
+	if err == nil {
+		// The copy is read back next, which must come from the disk
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to copy %s: %w", filepath.Base(source), err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// hashFile returns the SHA-256 and size of a file
+func hashFile(path string, progress func(n int64) error) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := copyProgress(h, f, progress)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// copyProgress copies r to w, calling progress (if not nil) with the bytes
+// copied after each buffer
+func copyProgress(w io.Writer, r io.Reader, progress func(n int64) error) (int64, error) {
+	buf := make([]byte, archiveBufferSize)
+	var n int64
+	for {
+		read, err := r.Read(buf)
+		if read > 0 {
+			if _, err := w.Write(buf[:read]); err != nil {
+				return n, err
+			}
+			n += int64(read)
+			if progress != nil {
+				if err := progress(n); err != nil {
+					return n, err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// writeManifest writes manifest to folder (see ManifestName)
+func writeManifest(folder string, manifest *ArchiveManifest) (string, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode archive manifest: %w", err)
+	}
+	path := filepath.Join(folder, ManifestName(manifest.Created))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	return path, nil
+}
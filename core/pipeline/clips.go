@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// clipExts are the containers clips are extracted to: .mp4 when re-encoded,
+// .mov when stream copied from a MOV (see Extractor)
+var clipExts = map[string]bool{".mp4": true, ".mov": true}
+
+// IsClip returns true if path is a clip that can be combined into a reel: an
+// .mp4 or .mov that isn't still being written
+func IsClip(path string) bool {
+	return clipExts[strings.ToLower(filepath.Ext(path))] && !ffmpeg.IsPartial(path)
+}
+
+// ListClips returns the clips in folder (see IsClip), sorted by name, which
+// puts extracted clips in game order
+func ListClips(folder string) ([]string, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	var clips []string
+	for _, entry := range entries {
+		if !entry.IsDir() && IsClip(entry.Name()) {
+			clips = append(clips, filepath.Join(folder, entry.Name()))
+		}
+	}
+	sort.Strings(clips)
+	return clips, nil
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// mountDepth is how many path elements name a removable drive under each
+// folder that drives are mounted in (e.g. /media/<user>/<drive>)
+var mountDepth = map[string]int{
+	"Volumes": 2, // macOS: /Volumes/<drive>
+	"mnt":     2, // /mnt/<drive>
+	"media":   3, // /media/<user>/<drive>
+	"run":     4, // /run/media/<user>/<drive>
+}
+
+// SourceDrive returns the drive a file is on, for per-drive extraction limits:
+// the volume on Windows (e.g. "E:" or \\server\share), the mount folder of a
+// removable drive elsewhere (e.g. /media/me/GAMES), or "/" for the system drive
+func SourceDrive(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if volume := filepath.VolumeName(path); volume != "" {
+		return strings.ToUpper(volume)
+	}
+
+	parts := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+	depth, ok := mountDepth[parts[0]]
+	if !ok || len(parts) <= depth || (parts[0] == "run" && parts[1] != "media") {
+		return "/"
+	}
+	return "/" + strings.Join(parts[:depth], "/")
+}
+
+// ForEachByDrive calls fn with the index of each of paths, running up to
+// workers(drive) calls at once for the paths on each drive (see SourceDrive),
+// so sources on different drives are read in parallel without thrashing a
+// single disk. Each drive's paths are started in order. A nil workers runs
+// them all one at a time, in order.
+func ForEachByDrive(paths []string, workers func(drive string) int, fn func(i int)) {
+	var drives []string
+	queued := make(map[string][]int)
+	for i, path := range paths {
+		drive := ""
+		if workers != nil {
+			drive = SourceDrive(path)
+		}
+		if _, ok := queued[drive]; !ok {
+			drives = append(drives, drive)
+		}
+		queued[drive] = append(queued[drive], i)
+	}
+
+	var wg sync.WaitGroup
+	for _, drive := range drives {
+		queue := make(chan int, len(queued[drive]))
+		for _, i := range queued[drive] {
+			queue <- i
+		}
+		close(queue)
+
+		n := 1
+		if workers != nil {
+			n = max(workers(drive), 1)
+		}
+		for w := 0; w < n && w < len(queued[drive]); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					fn(i)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
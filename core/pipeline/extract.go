@@ -0,0 +1,401 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// Extractor extracts clip groups from the periods of an analysis
+type Extractor struct {
+	FF       *ffmpeg.FFmpeg
+	Analysis *metadata.AnalysisResult
+
+	// StreamCopy writes .mov clips without re-encoding; otherwise clips are
+	// re-encoded to .mp4 with the watermark and color correction applied
+	StreamCopy bool
+	// StreamCopyMP4 writes stream-copied clips of H.264 sources as .mp4
+	// rather than .mov (see ffmpeg.CanStreamCopyMP4), ready to upload
+	StreamCopyMP4 bool
+	// EditCopies also writes a stream-copied .mov of each re-encoded clip
+	// into the clip folder's edit folder (see EditCopyPath), from the same
+	// decode of the source (see ffmpeg.ExtractClipDual)
+	EditCopies bool
+	// Tags returns the metadata tags for a clip (nil = none)
+	Tags func(group metadata.ClipGroup) ffmpeg.Tags
+	// CaptureTimes dates each clip by when its footage was recorded (see
+	// metadata.ClipGroup.CaptureTime): its creation_time tag and the file's
+	// modification time, so photo libraries file it under the game
+	CaptureTimes bool
+	// Watermark is the logo overlaid on re-encoded clips (nil = none)
+	Watermark *ffmpeg.Watermark
+	// Color returns the color correction for a period (nil = none)
+	Color func(period string) *ffmpeg.ColorCorrection
+	// Photos also saves a full-size JPEG of each highlight into a photos
+	// folder inside the clip folder, named like the highlight's clip
+	Photos bool
+	// PhotoFrames is how many more photos to save on each side of the
+	// highlight's frame, one frame apart (0 = just the highlight's)
+	PhotoFrames int
+	// DriveWorkers returns how many clips ExtractGroups may cut at once from
+	// the sources on a drive (see SourceDrive), e.g. 1 for a spinning disk and
+	// 3 for an SSD. nil = one clip at a time, in order.
+	DriveWorkers func(drive string) int
+}
+
+// PhotoFolder is the folder inside the clip folder that photos go into
+const PhotoFolder = "photos"
+
+// EditFolder is the folder inside the clip folder that the stream-copied edit
+// copies of re-encoded clips go into (see Extractor.EditCopies)
+const EditFolder = "edit"
+
+// EditCopyPath returns where the edit copy of a clip goes: a .mov of the same
+// name in the edit folder next to it
+func EditCopyPath(clipPath string) string {
+	name := filepath.Base(clipPath)
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".mov"
+	return filepath.Join(filepath.Dir(clipPath), EditFolder, name)
+}
+
+// Callbacks let a caller follow and control ExtractGroups. All are optional.
+// All but Checkpoint are called one at a time, even when clips are cut in
+// parallel (see Extractor.DriveWorkers).
+type Callbacks struct {
+	// Checkpoint is called before each clip; an error stops the run (e.g. to
+	// cancel, or to block while paused)
+	Checkpoint func() error
+	// Progress reports overall progress (0-1) and a status message
+	Progress func(progress float64, status string)
+	// Extracted is called with each clip written, in the order of the groups
+	Extracted func(path string, group metadata.ClipGroup)
+	// Failed is called when the index'th group fails; the run goes on
+	Failed func(index int, group metadata.ClipGroup, err error)
+	// Notice is called once for each source worth knowing about, e.g. one
+	// without audio, whose clips are silent
+	Notice func(message string)
+}
+
+// ExtractGroup extracts one clip group into outputFolder with its chapter markers
+// and metadata tags embedded and returns the clip's path. Stream copy writes .mov
+// (.mp4 for H.264 sources with StreamCopyMP4), re-encode .mp4 (plus its edit
+// copy with EditCopies).
+// If the clip runs past the end of a GoPro chapter file, it continues into the next one.
+// The clip is written under a temporary name first (see ffmpeg.WriteOutput).
+func (e *Extractor) ExtractGroup(group metadata.ClipGroup, outputFolder string) (string, error) {
+	videoFile := e.Analysis.GetPeriodVideoFile(group.Period)
+	if videoFile == "" {
+		return "", fmt.Errorf("no video file for period %s", group.Period)
+	}
+
+	// Use pre-calculated timing from the ClipGroup
+	startSec := group.StartTime
+	duration := group.Duration
+
+	// Get chapter markers for this clip
+	var chapters []ffmpeg.ClipChapter
+	for _, ch := range group.GetClipChapters() {
+		chapters = append(chapters, ffmpeg.ClipChapter{
+			OffsetMs: ch.OffsetMs,
+			Title:    ch.Title,
+		})
+	}
+
+	// Generate output filename with appropriate extension
+	clipName := metadata.GenerateGroupFilename(group)
+	if e.StreamCopy && !(e.StreamCopyMP4 && e.FF.CanStreamCopyMP4(videoFile)) {
+		clipName = clipName[:len(clipName)-4] + ".mov"
+	}
+	outputFile := filepath.Join(outputFolder, clipName)
+
+	var tags ffmpeg.Tags
+	if e.Tags != nil {
+		tags = e.Tags(group)
+	}
+	if e.CaptureTimes {
+		tags = tags.WithCreationTime(group.CaptureTime())
+	}
+	var color *ffmpeg.ColorCorrection
+	if e.Color != nil {
+		color = e.Color(group.Period)
+	}
+
+	editCopy := e.EditCopies && !e.StreamCopy
+	editPath := EditCopyPath(outputFile)
+	if editCopy && !e.FF.DryRun() {
+		if err := os.MkdirAll(filepath.Dir(editPath), 0755); err != nil {
+			return "", fmt.Errorf("failed to create edit folder: %w", err)
+		}
+	}
+
+	span, spans := e.SpanSource(group.Period, startSec, duration)
+	err := e.FF.WriteOutput(outputFile, func(path string) error {
+		switch {
+		case spans && e.StreamCopy:
+			return e.FF.ExtractClipStreamCopySpanning(span, path, startSec, duration, chapters, tags)
+		case spans && editCopy:
+			// The two files are joined in different ways, so they take two runs
+			if err := e.FF.ExtractClipSpanning(span, path, startSec, duration, chapters, tags, e.Watermark, color); err != nil {
+				return err
+			}
+			return e.FF.WriteOutput(editPath, func(copyPath string) error {
+				return e.FF.ExtractClipStreamCopySpanning(span, copyPath, startSec, duration, chapters, tags)
+			})
+		case spans:
+			return e.FF.ExtractClipSpanning(span, path, startSec, duration, chapters, tags, e.Watermark, color)
+		case e.StreamCopy:
+			return e.FF.ExtractClipStreamCopyWithChapters(videoFile, path, startSec, duration, chapters, tags)
+		case editCopy:
+			return e.FF.WriteOutput(editPath, func(copyPath string) error {
+				return e.FF.ExtractClipDual(videoFile, path, copyPath, startSec, duration, chapters, tags, e.Watermark, color)
+			})
+		}
+		return e.FF.ExtractClipWithChapters(videoFile, path, startSec, duration, chapters, tags, e.Watermark, color)
+	})
+	if err != nil {
+		return "", err
+	}
+	if e.CaptureTimes && !e.FF.DryRun() {
+		if err := ffmpeg.SetFileTime(outputFile, group.CaptureTime()); err != nil {
+			return "", err
+		}
+		if editCopy {
+			if err := ffmpeg.SetFileTime(editPath, group.CaptureTime()); err != nil {
+				return "", err
+			}
+		}
+	}
+	return outputFile, nil
+}
+
+// ExtractPhotos saves the photos of each highlight in a clip group (see
+// Extractor.Photos) into outputFolder's photo folder and returns their paths.
+// A highlight's photos are named after its clip, with _01, _02, ... added when
+// there is more than one (the middle one is the highlight's frame).
+func (e *Extractor) ExtractPhotos(group metadata.ClipGroup, outputFolder string) ([]string, error) {
+	videoFile := e.Analysis.GetPeriodVideoFile(group.Period)
+	if videoFile == "" {
+		return nil, fmt.Errorf("no video file for period %s", group.Period)
+	}
+
+	photoFolder := filepath.Join(outputFolder, PhotoFolder)
+	if !e.FF.DryRun() {
+		if err := os.MkdirAll(photoFolder, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create photo folder: %w", err)
+		}
+	}
+
+	// Frames around the highlight are one frame apart, and kept inside the video
+	frame := 1.0 / 60
+	if info, err := e.FF.GetStreamInfo(videoFile); err == nil && info.FPS() > 0 {
+		frame = 1 / info.FPS()
+	}
+	duration, err := e.FF.GetDuration(videoFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duration: %w", err)
+	}
+
+	var color *ffmpeg.ColorCorrection
+	if e.Color != nil {
+		color = e.Color(group.Period)
+	}
+
+	var photos []string
+	for _, ch := range group.Chapters {
+		base := strings.TrimSuffix(metadata.GenerateClipFilename(ch), ".mp4")
+		for i := -e.PhotoFrames; i <= e.PhotoFrames; i++ {
+			name := base + ".jpg"
+			if e.PhotoFrames > 0 {
+				name = fmt.Sprintf("%s_%02d.jpg", base, i+e.PhotoFrames+1)
+			}
+			outputFile := filepath.Join(photoFolder, name)
+
+			atSec := min(max(ch.VideoTime.Seconds()+float64(i)*frame, 0), duration-frame)
+			err := e.FF.WriteOutput(outputFile, func(path string) error {
+				return e.FF.ExtractStill(videoFile, path, atSec, color)
+			})
+			if err != nil {
+				return photos, err
+			}
+			photos = append(photos, outputFile)
+		}
+	}
+	return photos, nil
+}
+
+// SpanSource checks whether a clip from startSec for durationSec runs past the end
+// of the period's video and the recording continues in the next GoPro chapter file
+// (also loaded as a period). Returns the span and true if the clip should be
+// extracted across both files.
+func (e *Extractor) SpanSource(periodName string, startSec, durationSec float64) (ffmpeg.SpanSource, bool) {
+	next := e.Analysis.NextChapterPeriod(periodName)
+	if next == nil {
+		return ffmpeg.SpanSource{}, false
+	}
+
+	videoFile := e.Analysis.GetPeriodVideoFile(periodName)
+	firstDuration, err := e.FF.GetDuration(videoFile)
+	if err != nil || startSec+durationSec <= firstDuration || startSec >= firstDuration {
+		return ffmpeg.SpanSource{}, false
+	}
+
+	return ffmpeg.SpanSource{
+		FirstPath:     videoFile,
+		FirstDuration: firstDuration,
+		NextPath:      next.VideoFile,
+	}, true
+}
+
+// ExtractGroups extracts clip groups into outputFolder, carrying on past failed
+// clips. Clips are cut one at a time in order, or, with DriveWorkers set, up to
+// that many at once from each source drive. Partial files left by an
+// interrupted run are removed first.
+// Returns how many clips were extracted, and an error if any failed or the
+// run was stopped by cb.Checkpoint.
+func (e *Extractor) ExtractGroups(groups []metadata.ClipGroup, outputFolder string, cb Callbacks) (int, error) {
+	// mu serializes the callbacks and guards the counts below
+	var mu sync.Mutex
+	report := func(progress float64, status string) {
+		if cb.Progress != nil {
+			cb.Progress(progress, status)
+		}
+	}
+
+	if !e.FF.DryRun() {
+		// Clear out half-written clips left by an interrupted run
+		if removed, err := ffmpeg.CleanPartials(outputFolder); err == nil && len(removed) > 0 {
+			report(0, fmt.Sprintf("Removed %d partial files left by an interrupted run", len(removed)))
+		}
+	}
+
+	total := len(groups)
+	started := 0
+	completed := 0
+	failed := 0
+	var lastErr error
+	var stopErr error
+	photosFailed := 0
+	var photoErr error
+	checked := make(map[string]error) // Source video -> why it can't be used (nil = fine)
+
+	// Clips are reported to cb.Extracted in order, as each earlier one is done
+	outputs := make([]string, total)
+	finished := make([]bool, total)
+	next := 0
+	finish := func(i int, outputFile string) {
+		outputs[i], finished[i] = outputFile, true
+		for ; next < total && finished[next]; next++ {
+			if outputs[next] != "" && cb.Extracted != nil {
+				cb.Extracted(outputs[next], groups[next])
+			}
+		}
+	}
+
+	sources := make([]string, total)
+	for i, group := range groups {
+		sources[i] = e.Analysis.GetPeriodVideoFile(group.Period)
+	}
+
+	ForEachByDrive(sources, e.DriveWorkers, func(i int) {
+		group := groups[i]
+		mu.Lock()
+		stopped := stopErr != nil
+		mu.Unlock()
+		if !stopped && cb.Checkpoint != nil {
+			if err := cb.Checkpoint(); err != nil {
+				mu.Lock()
+				if stopErr == nil {
+					stopErr = err
+				}
+				mu.Unlock()
+				stopped = true
+			}
+		}
+		if stopped {
+			mu.Lock()
+			finish(i, "")
+			mu.Unlock()
+			return
+		}
+
+		// Build status message based on whether this is a merged group
+		mu.Lock()
+		started++
+		var status string
+		if group.IsOverlap {
+			status = fmt.Sprintf("Extracting %d/%d: %s Ch%d-%d (merged, %.1fs)...",
+				started, total, group.Period,
+				group.PrimaryChapter.Number,
+				group.Chapters[len(group.Chapters)-1].Number,
+				group.Duration)
+		} else {
+			status = fmt.Sprintf("Extracting %d/%d: %s Ch%d...",
+				started, total, group.Period, group.PrimaryChapter.Number)
+		}
+		report(float64(completed+failed)/float64(total), status)
+
+		// Check each source once, so one without video fails clearly and one
+		// without audio is mentioned rather than failing in ffmpeg
+		videoFile := sources[i]
+		sourceErr, done := checked[videoFile]
+		if !done && videoFile != "" {
+			check, err := e.FF.CheckSource(videoFile)
+			sourceErr = err
+			checked[videoFile] = err
+			if err == nil && check.NoAudio && cb.Notice != nil {
+				cb.Notice(check.SilentNotice(videoFile))
+			}
+		}
+		mu.Unlock()
+
+		outputFile, err := "", sourceErr
+		if err == nil {
+			outputFile, err = e.ExtractGroup(group, outputFolder)
+		}
+		if err != nil {
+			mu.Lock()
+			failed++
+			lastErr = err
+			if cb.Failed != nil {
+				cb.Failed(i, group, err)
+			}
+			finish(i, "")
+			mu.Unlock()
+			return
+		}
+
+		if e.Photos {
+			mu.Lock()
+			report((float64(completed+failed)+0.9)/float64(total), fmt.Sprintf("Saving photos %d/%d...", started, total))
+			mu.Unlock()
+			if _, err := e.ExtractPhotos(group, outputFolder); err != nil {
+				// The clip is fine, so the run goes on; the failure is reported at the end
+				mu.Lock()
+				photosFailed++
+				photoErr = err
+				mu.Unlock()
+			}
+		}
+
+		mu.Lock()
+		completed++
+		finish(i, outputFile)
+		mu.Unlock()
+	})
+
+	if stopErr != nil {
+		return completed, stopErr
+	}
+	if lastErr != nil {
+		return completed, fmt.Errorf("%d of %d clips failed, last error: %w", failed, total, lastErr)
+	}
+	if photoErr != nil {
+		return completed, fmt.Errorf("photos failed for %d of %d clips, last error: %w", photosFailed, total, photoErr)
+	}
+	return completed, nil
+}
@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// fakeGoPro returns an FFmpeg whose ffprobe answers like a GoPro recording
+// started at 19:30 with video and audio, and a game folder with the recording
+// and the chapters of testdata/gopro.ffmetadata. ffmpeg writes a small file.
+func fakeGoPro(t *testing.T) (*ffmpeg.FakeRunner, *ffmpeg.FFmpeg, metadata.Period) {
+	t.Helper()
+	fake := &ffmpeg.FakeRunner{}
+	fake.On("ffprobe", []string{"stream_tags=timecode"}, ffmpeg.FakeResponse{Stdout: "19:30:00:00\n"})
+	fake.On("ffprobe", []string{"format=duration"}, ffmpeg.FakeResponse{Stdout: "1210.0\n"})
+	fake.On("ffprobe", []string{"format_tags=creation_time"}, ffmpeg.FakeResponse{Stdout: "2025-11-14T19:31:02.000000Z\n"})
+	fake.On("ffprobe", []string{"-of", "compact=p=0"}, ffmpeg.FakeResponse{Stdout: "codec_name=h264|codec_type=video|width=1920|height=1080|r_frame_rate=30000/1001|pix_fmt=yuvj420p\n" +
+		"codec_name=aac|codec_type=audio|sample_rate=48000|channels=2\n"})
+	fake.On("ffmpeg", nil, ffmpeg.FakeResponse{Output: []byte("clip")})
+
+	folder := t.TempDir()
+	video := filepath.Join(folder, "1Period.MOV")
+	source := filepath.Join(folder, "GX010001.MP4")
+	for _, path := range []string{video, source} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta, err := filepath.Abs(filepath.Join("..", "metadata", "testdata", "gopro.ffmetadata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	period := metadata.Period{Name: "1Period", VideoFile: video, MetadataFile: meta, SourceGoPro: source}
+	return fake, ffmpeg.NewWithRunner(fake), period
+}
+
+// cutCalls returns the ffmpeg command lines among calls that cut a clip
+// (rather than e.g. list the decoders)
+func cutCalls(calls [][]string) [][]string {
+	var found [][]string
+	for _, call := range calls {
+		if call[0] == "ffmpeg" && slices.Contains(call, "-ss") {
+			found = append(found, call)
+		}
+	}
+	return found
+}
+
+// argAfter returns the argument following flag in a command line ("" = none)
+func argAfter(call []string, flag string) string {
+	if i := slices.Index(call, flag); i >= 0 && i+1 < len(call) {
+		return call[i+1]
+	}
+	return ""
+}
+
+func TestExtractGroupsStreamCopy(t *testing.T) {
+	fake, ff, period := fakeGoPro(t)
+	result, err := metadata.NewAnalyzer(ff).AnalyzePeriods([]metadata.Period{period})
+	if err != nil {
+		t.Fatalf("AnalyzePeriods: %v", err)
+	}
+	if len(result.Chapters) != 4 {
+		t.Fatalf("got %d chapters, want 4", len(result.Chapters))
+	}
+
+	// Ch3 and Ch4 are 4.7s apart, so their clips are merged into one
+	groups := metadata.DetectOverlappingChapters(result.Chapters, 8, 2)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+
+	out := t.TempDir()
+	ex := &Extractor{FF: ff, Analysis: result, StreamCopy: true}
+	var extracted []string
+	count, err := ex.ExtractGroups(groups, out, Callbacks{
+		Extracted: func(path string, _ metadata.ClipGroup) { extracted = append(extracted, path) },
+	})
+	if err != nil {
+		t.Fatalf("ExtractGroups: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("extracted %d clips, want 3", count)
+	}
+
+	want := []string{
+		filepath.Join(out, "001_19-30-00-000_1Period_Ch01.mov"),
+		filepath.Join(out, "002_19-35-12-480_1Period_Ch02.mov"),
+		filepath.Join(out, "003_19-37-31-200_1Period_Ch03-04.mov"),
+	}
+	if !slices.Equal(extracted, want) {
+		t.Errorf("extracted %v, want %v", extracted, want)
+	}
+	for _, path := range want {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("clip not written: %v", err)
+		}
+	}
+
+	// One stream copy cut per clip, from the period's video at the group's times
+	calls := cutCalls(fake.Calls())
+	if len(calls) != len(groups) {
+		t.Fatalf("ran ffmpeg %d times, want %d: %v", len(calls), len(groups), calls)
+	}
+	for i, call := range calls {
+		if input := argAfter(call, "-i"); input != period.VideoFile {
+			t.Errorf("clip %d cut from %q, want %q", i+1, input, period.VideoFile)
+		}
+		if codec := argAfter(call, "-c"); codec != "copy" {
+			t.Errorf("clip %d: -c %q, want copy", i+1, codec)
+		}
+		// Written under a temporary name, then renamed
+		partial := filepath.Join(out, strings.TrimSuffix(filepath.Base(want[i]), ".mov")+".partial.mov")
+		if output := call[len(call)-1]; output != partial {
+			t.Errorf("clip %d written to %q, want %q", i+1, output, partial)
+		}
+	}
+	if ss := argAfter(calls[2], "-ss"); ss != "443.200" {
+		t.Errorf("merged clip starts at %q, want 443.200 (8s before Ch3)", ss)
+	}
+	if d := argAfter(calls[2], "-t"); d != "14.700" {
+		t.Errorf("merged clip lasts %q, want 14.700 (to 2s after Ch4)", d)
+	}
+}
+
+func TestExtractGroupsSourceWithoutVideo(t *testing.T) {
+	fake, ff, period := fakeGoPro(t)
+	result, err := metadata.NewAnalyzer(ff).AnalyzePeriods([]metadata.Period{period})
+	if err != nil {
+		t.Fatalf("AnalyzePeriods: %v", err)
+	}
+	groups := metadata.DetectOverlappingChapters(result.Chapters, 8, 2)
+
+	// A later rule wins: the source now probes with audio only (on a new
+	// FFmpeg, which hasn't cached the probe)
+	fake.On("ffprobe", []string{"-of", "compact=p=0"}, ffmpeg.FakeResponse{Stdout: "codec_name=aac|codec_type=audio\n"})
+
+	failed := 0
+	ex := &Extractor{FF: ffmpeg.NewWithRunner(fake), Analysis: result, StreamCopy: true}
+	count, err := ex.ExtractGroups(groups, t.TempDir(), Callbacks{
+		Failed: func(int, metadata.ClipGroup, error) { failed++ },
+	})
+	if err == nil {
+		t.Error("expected an error for a source without video")
+	}
+	if count != 0 || failed != len(groups) {
+		t.Errorf("extracted %d and failed %d clips, want 0 and %d", count, failed, len(groups))
+	}
+	if calls := cutCalls(fake.Calls()); len(calls) != 0 {
+		t.Errorf("ran ffmpeg on a source without video: %v", calls)
+	}
+}
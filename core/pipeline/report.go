@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Report is a machine-readable summary of a batch run (clip extraction or
+// reel combine), written next to its outputs so scripts can collect stats
+// across games (see WriteReport)
+type Report struct {
+	Kind     string    `json:"kind"` // "extract" or "combine"
+	Game     string    `json:"game,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Seconds is how long the whole batch took
+	Seconds float64      `json:"seconds"`
+	Items   []ReportItem `json:"items"`
+	// Error is why the batch stopped or didn't finish cleanly ("" = it did)
+	Error string `json:"error,omitempty"`
+}
+
+// ReportItem is one output of a batch: a clip or a reel
+type ReportItem struct {
+	Inputs []string `json:"inputs"`
+	Output string   `json:"output,omitempty"`
+	// Chapters are the highlights a clip covers, by their number in the game
+	// (metadata.Chapter.GlobalOrder)
+	Chapters []int  `json:"chapters,omitempty"`
+	Period   string `json:"period,omitempty"`
+	// MediaSeconds is the length of the output video
+	MediaSeconds float64 `json:"media_seconds,omitempty"`
+	// EncodeSeconds is how long ffmpeg took to write it
+	EncodeSeconds float64 `json:"encode_seconds,omitempty"`
+	// Encoder is the video encoder used, e.g. "h264_nvenc", "libx264" or
+	// "copy" for stream copies
+	Encoder string `json:"encoder,omitempty"`
+	Bytes   int64  `json:"bytes,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReportName returns the file name of a batch report, e.g.
+// "extract-report-20241109-193012.json", named by when the batch started so
+// each batch keeps its own
+func ReportName(kind string, started time.Time) string {
+	return fmt.Sprintf("%s-report-%s.json", kind, started.Format("20060102-150405"))
+}
+
+// WriteReport finishes report and writes it to folder (see ReportName),
+// returning its path. Output sizes are filled in from the files.
+func WriteReport(folder string, report *Report) (string, error) {
+	if report.Finished.IsZero() {
+		report.Finished = time.Now()
+	}
+	report.Seconds = report.Finished.Sub(report.Started).Seconds()
+	for i, item := range report.Items {
+		if item.Output == "" || item.Bytes > 0 {
+			continue
+		}
+		if info, err := os.Stat(item.Output); err == nil {
+			report.Items[i].Bytes = info.Size()
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	path := filepath.Join(folder, ReportName(report.Kind, report.Started))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
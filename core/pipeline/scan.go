@@ -0,0 +1,150 @@
+// Package pipeline runs the clip extraction workflow without a GUI: scan a
+// working folder for periods and analyze their HiLights, pick chapters, group
+// overlapping ones and extract the groups as clips. The desktop app and the
+// control API are built on it; scripts can use it directly:
+//
+//	ff, _ := ffmpeg.New()
+//	scan, err := pipeline.ScanFolder(ff, folder, pipeline.ScanOptions{DedupThreshold: 2})
+//	groups := metadata.DetectOverlappingChapters(scan.Analysis.Chapters, 8, 2)
+//	ex := &pipeline.Extractor{FF: ff, Analysis: scan.Analysis}
+//	count, err := ex.ExtractGroups(groups, outputFolder, pipeline.Callbacks{})
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// ScanOptions controls how a working folder is scanned
+type ScanOptions struct {
+	// Excluded lists MOV files to leave out
+	Excluded []string
+	// DedupThreshold collapses HiLights closer than this many seconds (0 = keep all)
+	DedupThreshold float64
+	// Split divides recordings that cover several periods (zero = don't split)
+	Split metadata.PeriodSplit
+	// ClockZone is the zone the camera's clock was set to (nil = this computer's)
+	ClockZone *time.Location
+	// SpeedBurstKmh suggests chapters where the GPS speed stays over this (0 = off)
+	SpeedBurstKmh float64
+	// AutoChapters suggests chapters at intervals or audio spikes (zero = off).
+	// MOVs with a timecode but no chapters are scanned too when it is set.
+	AutoChapters metadata.AutoChapters
+	// ManualStarts are start times of day entered by hand for videos whose
+	// timecode is missing, by video file (see Analyzer.SetManualStarts)
+	ManualStarts map[string]time.Duration
+	// Games divides a folder holding several games (zero = one game; see ScanGames)
+	Games metadata.GameSplit
+	// ProbeWorkers is how many videos are probed at once (0 = DefaultProbeWorkers)
+	ProbeWorkers int
+}
+
+// DefaultProbeWorkers is how many videos a scan probes at once by default:
+// enough to hide the latency of a USB drive or network share without
+// thrashing a single disk
+const DefaultProbeWorkers = 4
+
+// Scan is a scanned and analyzed working folder
+type Scan struct {
+	Folder string
+	// Game is the game's number when the folder holds several (0 = the only game)
+	Game     int
+	Periods  []metadata.Period
+	Skipped  []string // Warnings for MOV files skipped for lack of metadata and for copies left out
+	Analysis *metadata.AnalysisResult
+}
+
+// ScanFolder finds the periods in folder (extracting metadata from the GoPro
+// MP4s where needed) and analyzes their HiLights into chapters
+func ScanFolder(ff *ffmpeg.FFmpeg, folder string, opts ScanOptions) (*Scan, error) {
+	result, skipped, _, err := analyzeFolder(ff, folder, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scan{
+		Folder:   folder,
+		Periods:  result.Periods, // After any splitting
+		Skipped:  skipped,
+		Analysis: result,
+	}, nil
+}
+
+// ScanGames is ScanFolder for a folder that may hold several games: the
+// analysis is split with opts.Games into one Scan per game, in recording
+// order, each with its own periods and chapters. A folder with one game
+// returns a single Scan with Game 0.
+func ScanGames(ff *ffmpeg.FFmpeg, folder string, opts ScanOptions) ([]*Scan, error) {
+	result, skipped, analyzer, err := analyzeFolder(ff, folder, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	games := analyzer.SplitGames(result, opts.Games)
+	var scans []*Scan
+	for i, game := range games {
+		scan := &Scan{
+			Folder:   folder,
+			Periods:  game.Periods,
+			Skipped:  skipped,
+			Analysis: game,
+		}
+		if len(games) > 1 {
+			scan.Game = i + 1
+		}
+		scans = append(scans, scan)
+	}
+	return scans, nil
+}
+
+// analyzeFolder discovers and analyzes the periods in folder, returning the
+// analysis, the skipped files' warnings and the analyzer used
+func analyzeFolder(ff *ffmpeg.FFmpeg, folder string, opts ScanOptions) (*metadata.AnalysisResult, []string, *metadata.Analyzer, error) {
+	workers := opts.ProbeWorkers
+	if workers <= 0 {
+		workers = DefaultProbeWorkers
+	}
+	periods, skipped, err := metadata.DiscoverPeriodsWith(ff, folder, opts.Excluded, metadata.DiscoverOptions{
+		Workers: workers,
+		Bare:    !opts.AutoChapters.IsZero(),
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(periods) == 0 {
+		return nil, nil, nil, fmt.Errorf("no periods with metadata found (%d skipped)", len(skipped))
+	}
+
+	analyzer := metadata.NewAnalyzer(ff)
+	analyzer.SetDedupThreshold(opts.DedupThreshold)
+	analyzer.SetPeriodSplit(opts.Split)
+	analyzer.SetClockZone(opts.ClockZone)
+	analyzer.SetSpeedBursts(opts.SpeedBurstKmh)
+	analyzer.SetAutoChapters(opts.AutoChapters)
+	analyzer.SetManualStarts(opts.ManualStarts)
+	result, err := analyzer.AnalyzePeriods(periods)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return result, skipped, analyzer, nil
+}
+
+// SelectChapters returns the chapters with the given global order numbers, in
+// analysis order (all chapters if orders is empty)
+func SelectChapters(result *metadata.AnalysisResult, orders []int) []metadata.Chapter {
+	wanted := make(map[int]bool, len(orders))
+	for _, order := range orders {
+		wanted[order] = true
+	}
+
+	var selected []metadata.Chapter
+	for _, ch := range result.Chapters {
+		if len(wanted) == 0 || wanted[ch.GlobalOrder] {
+			selected = append(selected, ch)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/jobs"
+)
+
+// ExtractRequest is the body of POST /api/extract
+type ExtractRequest struct {
+	// OutputFolder is a folder inside the output layout's clip folder (or the
+	// working folder, without a layout) to write the clips to ("" = that
+	// folder itself). See Within.
+	OutputFolder string `json:"output_folder"`
+	// Chapters lists the global order numbers to extract (empty = all)
+	Chapters []int `json:"chapters,omitempty"`
+	// SecondsBefore/SecondsAfter override the configured padding when set
+	SecondsBefore *float64 `json:"seconds_before,omitempty"`
+	SecondsAfter  *float64 `json:"seconds_after,omitempty"`
+	StreamCopy    bool     `json:"stream_copy"`
+}
+
+// CombineRequest is the body of POST /api/combine
+type CombineRequest struct {
+	// Clips lists the clip files to combine, in any order (empty = all clips
+	// from the last extraction). They must be clips from the last extraction,
+	// and are put in the configured reel order.
+	Clips []string `json:"clips,omitempty"`
+	// Output is the reel's path inside the output layout's reel folder, or
+	// the clips' folder without a layout ("" = combined_<time>.mp4 there).
+	// See Within.
+	Output string `json:"output,omitempty"`
+	// Reencode encodes the reel (with the watermark and conformed to the
+	// clips' most common format) instead of stream copying the clips
+	Reencode bool `json:"reencode"`
+	// CRF is the re-encoded reel's quality (0 = 23)
+	CRF int `json:"crf,omitempty"`
+}
+
+// Backend is the pipeline the API drives. The GUI implements it, so work started
+// over HTTP shows up in (and is serialized with) the desktop app.
+type Backend interface {
+	// ScanFolder starts a job that finds the periods in folder, a folder
+	// inside the configured games folder (see Within), and analyzes them
+	ScanFolder(folder string) (*jobs.Job, error)
+	// Analysis returns the current analysis, or nil if none has run
+	Analysis() *metadata.AnalysisResult
+	// Extract starts a clip extraction job
+	Extract(req ExtractRequest) (*jobs.Job, error)
+	// Clips returns the clip files from the last extraction
+	Clips() []string
+	// Combine starts a job combining clips into a reel
+	Combine(req CombineRequest) (*jobs.Job, error)
+	// ReelPath returns the last reel combined, or "" if there is none
+	ReelPath() string
+}
+
+// Server is the local HTTP control API
+type Server struct {
+	backend Backend
+	jobs    *jobs.Manager
+	mux     *http.ServeMux
+	token   string // Every /api/ request must carry it (see authorized)
+}
+
+// NewServer creates the API server. Requests to the API must carry token
+// (see NewToken), as "Authorization: Bearer <token>" or, for links like the
+// reel download, a "token" query parameter.
+func NewServer(backend Backend, jobManager *jobs.Manager, token string) *Server {
+	s := &Server{
+		backend: backend,
+		jobs:    jobManager,
+		mux:     http.NewServeMux(),
+		token:   token,
+	}
+
+	s.mux.HandleFunc("GET /api/status", s.handleStatus)
+	s.mux.HandleFunc("POST /api/scan", s.handleScan)
+	s.mux.HandleFunc("GET /api/chapters", s.handleChapters)
+	s.mux.HandleFunc("POST /api/extract", s.handleExtract)
+	s.mux.HandleFunc("GET /api/clips", s.handleClips)
+	s.mux.HandleFunc("POST /api/combine", s.handleCombine)
+	s.mux.HandleFunc("GET /api/reel", s.handleReel)
+	s.mux.HandleFunc("GET /api/jobs", s.handleJobs)
+	s.mux.HandleFunc("GET /api/jobs/{id}", s.handleJob)
+	s.mux.HandleFunc("POST /api/jobs/{id}/pause", s.handleJobAction(jobManager.Pause))
+	s.mux.HandleFunc("POST /api/jobs/{id}/resume", s.handleJobAction(jobManager.Resume))
+	s.mux.HandleFunc("POST /api/jobs/{id}/cancel", s.handleJobAction(jobManager.Cancel))
+
+	return s
+}
+
+// NewToken returns a random API token, made when the app starts
+func NewToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/") && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, errors.New("missing or wrong API token"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized returns true if a request carries the server's token
+func (s *Server) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// ListenAddress returns the address the API listens on for addr: a bare port
+// (":8765" or "8765") is served on 127.0.0.1 only, so other machines can
+// reach the API only when a host such as 0.0.0.0 is given
+func ListenAddress(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = "", strings.TrimPrefix(addr, ":")
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Within returns the path rel names inside folder ("" = folder itself). rel
+// must be relative and stay inside folder, so API callers can't read or
+// write files elsewhere on the computer.
+func Within(folder, rel string) (string, error) {
+	if folder == "" {
+		return "", errors.New("no folder is set up for this")
+	}
+	if rel == "" {
+		return folder, nil
+	}
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return "", fmt.Errorf("%q must be a relative path inside %s", rel, folder)
+	}
+	return filepath.Join(folder, filepath.FromSlash(rel)), nil
+}
+
+// ListenAndServe serves the API on addr (see ListenAddress) until it fails
+func (s *Server) ListenAndServe(addr string) error {
+	if err := http.ListenAndServe(ListenAddress(addr), s); err != nil {
+		return fmt.Errorf("control API stopped: %w", err)
+	}
+	return nil
+}
+
+// statusResponse is the body of GET /api/status
+type statusResponse struct {
+	Busy      bool           `json:"busy"`
+	ActiveJob *jobs.Snapshot `json:"active_job,omitempty"`
+	Queued    int            `json:"queued"`
+	Analyzed  bool           `json:"analyzed"`
+	Periods   int            `json:"periods"`
+	Chapters  int            `json:"chapters"`
+	// Warnings are the analysis sanity check failures (overlapping periods etc.)
+	Warnings []metadata.PeriodWarning `json:"warnings,omitempty"`
+	// Clips is how many clips the last extraction made
+	Clips int `json:"clips"`
+	// Reel is the file name of the last reel combined (see GET /api/reel)
+	Reel string `json:"reel,omitempty"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	resp := statusResponse{}
+	if job := s.jobs.Active(); job != nil {
+		snapshot := job.Snapshot()
+		resp.Busy = true
+		resp.ActiveJob = &snapshot
+	}
+	for _, job := range s.jobs.List() {
+		if job.State == jobs.Pending {
+			resp.Queued++
+		}
+	}
+	if result := s.backend.Analysis(); result != nil {
+		resp.Analyzed = true
+		resp.Periods = len(result.Periods)
+		resp.Chapters = len(result.Chapters)
+		resp.Warnings = result.Warnings
+	}
+	resp.Clips = len(s.backend.Clips())
+	if reel := s.backend.ReelPath(); reel != "" {
+		resp.Reel = filepath.Base(reel)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Folder string `json:"folder"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Folder == "" {
+		writeError(w, http.StatusBadRequest, errors.New(`expected {"folder": "<path inside the games folder>"}`))
+		return
+	}
+
+	job, err := s.backend.ScanFolder(req.Folder)
+	s.writeJob(w, job, err)
+}
+
+func (s *Server) handleChapters(w http.ResponseWriter, r *http.Request) {
+	result := s.backend.Analysis()
+	if result == nil {
+		writeError(w, http.StatusConflict, errors.New("no analysis yet, POST /api/scan first"))
+		return
+	}
+	writeJSON(w, http.StatusOK, result.Chapters)
+}
+
+func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
+	var req ExtractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if s.backend.Analysis() == nil {
+		writeError(w, http.StatusConflict, errors.New("no analysis yet, POST /api/scan first"))
+		return
+	}
+
+	job, err := s.backend.Extract(req)
+	s.writeJob(w, job, err)
+}
+
+func (s *Server) handleClips(w http.ResponseWriter, r *http.Request) {
+	clips := s.backend.Clips()
+	if clips == nil {
+		clips = []string{}
+	}
+	writeJSON(w, http.StatusOK, clips)
+}
+
+func (s *Server) handleCombine(w http.ResponseWriter, r *http.Request) {
+	var req CombineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	job, err := s.backend.Combine(req)
+	s.writeJob(w, job, err)
+}
+
+// handleReel downloads the last reel combined
+func (s *Server) handleReel(w http.ResponseWriter, r *http.Request) {
+	path := s.backend.ReelPath()
+	if path == "" {
+		writeError(w, http.StatusNotFound, errors.New("no reel combined yet, POST /api/combine first"))
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("reel is gone: %w", err))
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	http.ServeFile(w, r, path)
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.jobs.List())
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	snapshot, ok := s.jobs.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("no such job"))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// handleJobAction pauses, resumes or cancels a job
+func (s *Server) handleJobAction(action func(id string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, ok := s.jobs.Get(id); !ok {
+			writeError(w, http.StatusNotFound, errors.New("no such job"))
+			return
+		}
+		if err := action(id); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		snapshot, _ := s.jobs.Get(id)
+		writeJSON(w, http.StatusOK, snapshot)
+	}
+}
+
+// writeJob responds to a request that queued a job
+func (s *Server) writeJob(w http.ResponseWriter, job *jobs.Job, err error) {
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job.Snapshot())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
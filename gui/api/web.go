@@ -0,0 +1,21 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// webPage is the browser UI served by ServeWebUI
+//
+//go:embed web/index.html
+var webPage []byte
+
+// ServeWebUI adds a browser UI at / that drives the scan, extract and combine
+// endpoints, so the workflow can be run from another machine with nothing
+// installed
+func (s *Server) ServeWebUI() {
+	s.mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webPage)
+	})
+}
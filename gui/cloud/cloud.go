@@ -0,0 +1,236 @@
+// Package cloud uploads finished reels and clips to a team's shared folder on
+// Google Drive or Dropbox. Accounts are signed in to with OAuth in the
+// browser, using the team's own app registration (client ID).
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider names as saved in the config
+const (
+	GoogleDrive = "drive"
+	Dropbox     = "dropbox"
+)
+
+// chunkSize is how much of a file is sent per request. Google Drive needs a
+// multiple of 256 KiB and Dropbox one of 4 MiB.
+const chunkSize = 8 << 20
+
+// maxAttempts is how many times a request is tried before the upload fails
+const maxAttempts = 4
+
+// Token is an account's OAuth tokens
+type Token struct {
+	AccessToken  string    `json:"access_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// valid returns true if the access token can still be used
+func (t Token) valid() bool {
+	return t.AccessToken != "" && time.Now().Add(time.Minute).Before(t.Expiry)
+}
+
+// Account is a cloud storage account and the folder uploads go to
+type Account struct {
+	Provider     string `json:"provider"`                // GoogleDrive, Dropbox or "" (off)
+	ClientID     string `json:"client_id"`               // The app registration's client ID
+	ClientSecret string `json:"client_secret,omitempty"` // Google only (not secret for desktop apps)
+	// Folder is the Google Drive folder ID (from its URL; "" = My Drive) or
+	// the Dropbox folder path (e.g. "/Team/Highlights"; "" = the app folder root)
+	Folder string `json:"folder"`
+	Token  Token  `json:"token"`
+}
+
+// Connected returns true if the account has been signed in to
+func (a Account) Connected() bool {
+	return a.Token.RefreshToken != "" || a.Token.AccessToken != ""
+}
+
+// ProviderName returns the display name of a provider
+func ProviderName(provider string) string {
+	switch provider {
+	case GoogleDrive:
+		return "Google Drive"
+	case Dropbox:
+		return "Dropbox"
+	}
+	return provider
+}
+
+// provider is a cloud storage service's OAuth and upload API
+type provider interface {
+	authURL(clientID, redirect, state, challenge string) string
+	exchange(ctx context.Context, account Account, code, redirect, verifier string) (Token, error)
+	refresh(ctx context.Context, account Account) (Token, error)
+	// upload sends a file and returns a link to it, calling progress after
+	// each chunk and checkpoint before it
+	upload(ctx context.Context, c *Client, path string, progress func(sent, total int64), checkpoint func() error) (string, error)
+}
+
+// Client uploads to an account, refreshing its access token as needed
+type Client struct {
+	http     *http.Client
+	provider provider
+
+	mu      sync.Mutex
+	account Account
+	onToken func(Token)
+}
+
+// NewClient returns a client for account. onToken (may be nil) is called with
+// each new token, so it can be saved.
+func NewClient(account Account, onToken func(Token)) (*Client, error) {
+	var p provider
+	switch account.Provider {
+	case GoogleDrive:
+		p = drive{}
+	case Dropbox:
+		p = dropbox{}
+	case "":
+		return nil, fmt.Errorf("no cloud account is set up (see Settings)")
+	default:
+		return nil, fmt.Errorf("unknown cloud provider %q", account.Provider)
+	}
+	if strings.TrimSpace(account.ClientID) == "" {
+		return nil, fmt.Errorf("enter the %s app's client ID in Settings", ProviderName(account.Provider))
+	}
+	return &Client{
+		http: &http.Client{
+			Timeout: 10 * time.Minute,
+			// Drive answers a chunk with 308 "Resume Incomplete", which isn't a redirect
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		provider: p,
+		account:  account,
+		onToken:  onToken,
+	}, nil
+}
+
+// setToken records a new token
+func (c *Client) setToken(token Token) {
+	c.mu.Lock()
+	if token.RefreshToken == "" {
+		// Refreshing doesn't always return a new refresh token
+		token.RefreshToken = c.account.Token.RefreshToken
+	}
+	c.account.Token = token
+	onToken := c.onToken
+	c.mu.Unlock()
+
+	if onToken != nil {
+		onToken(token)
+	}
+}
+
+// accessToken returns a valid access token, refreshing it if it has expired
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	account := c.account
+	c.mu.Unlock()
+
+	if account.Token.valid() {
+		return account.Token.AccessToken, nil
+	}
+	if account.Token.RefreshToken == "" {
+		return "", fmt.Errorf("not signed in to %s (click Connect in Settings)", ProviderName(account.Provider))
+	}
+	token, err := c.provider.refresh(ctx, account)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh the %s sign-in: %w", ProviderName(account.Provider), err)
+	}
+	c.setToken(token)
+	return token.AccessToken, nil
+}
+
+// folder returns the folder uploads go to
+func (c *Client) folder() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.TrimSpace(c.account.Folder)
+}
+
+// Upload sends a file to the account's folder and returns a link to it.
+// progress (may be nil) is called with the bytes sent so far; checkpoint (may
+// be nil) is called before each chunk and stops the upload if it returns an
+// error. Failed requests are retried with backoff.
+func (c *Client) Upload(ctx context.Context, path string, progress func(sent, total int64), checkpoint func() error) (string, error) {
+	if progress == nil {
+		progress = func(int64, int64) {}
+	}
+	if checkpoint == nil {
+		checkpoint = func() error { return nil }
+	}
+	return c.provider.upload(ctx, c, path, progress, checkpoint)
+}
+
+// statusError is an unexpected HTTP response
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.code, strings.TrimSpace(e.body))
+}
+
+// checkStatus returns a statusError for a response that isn't one of ok
+func checkStatus(resp *http.Response, ok ...int) error {
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &statusError{code: resp.StatusCode, body: string(body)}
+}
+
+// retryable returns true if a failed request may succeed when sent again:
+// network errors, rate limits and server errors
+func retryable(err error) bool {
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusTooManyRequests || status.code >= 500
+	}
+	var netErr *url.Error
+	return errors.As(err, &netErr) && !errors.Is(err, context.Canceled)
+}
+
+// withRetry runs fn until it succeeds, fails with an error that isn't
+// retryable, or has been tried maxAttempts times, waiting longer after each try
+func withRetry(ctx context.Context, fn func() error) error {
+	wait := 2 * time.Second
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(); err == nil || !retryable(err) || attempt == maxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	return err
+}
+
+// readChunk reads up to chunkSize bytes of f from offset
+func readChunk(f *os.File, offset int64) ([]byte, error) {
+	buf := make([]byte, chunkSize)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
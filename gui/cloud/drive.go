@@ -0,0 +1,219 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Google OAuth and Drive endpoints
+const (
+	driveAuthURL   = "https://accounts.google.com/o/oauth2/v2/auth"
+	driveTokenURL  = "https://oauth2.googleapis.com/token"
+	driveUploadURL = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable&supportsAllDrives=true&fields=id,webViewLink"
+	// driveScope lets files be added to a folder shared with the account,
+	// which the narrower drive.file scope doesn't
+	driveScope = "https://www.googleapis.com/auth/drive"
+)
+
+// drive uploads to Google Drive with resumable uploads
+type drive struct{}
+
+func (drive) authURL(clientID, redirect, state, challenge string) string {
+	q := url.Values{
+		"client_id":             {clientID},
+		"redirect_uri":          {redirect},
+		"response_type":         {"code"},
+		"scope":                 {driveScope},
+		"access_type":           {"offline"},
+		"prompt":                {"consent"}, // Always return a refresh token
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return driveAuthURL + "?" + q.Encode()
+}
+
+func (drive) exchange(ctx context.Context, account Account, code, redirect, verifier string) (Token, error) {
+	return postToken(ctx, driveTokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirect},
+		"client_id":     {account.ClientID},
+		"client_secret": {account.ClientSecret},
+		"code_verifier": {verifier},
+	})
+}
+
+func (drive) refresh(ctx context.Context, account Account) (Token, error) {
+	return postToken(ctx, driveTokenURL, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {account.Token.RefreshToken},
+		"client_id":     {account.ClientID},
+		"client_secret": {account.ClientSecret},
+	})
+}
+
+// driveFile is the part of a Drive file resource the upload asks for
+type driveFile struct {
+	ID          string `json:"id"`
+	WebViewLink string `json:"webViewLink"`
+}
+
+// upload starts a resumable upload session and sends the file to it a chunk
+// at a time. A chunk that fails is retried from where Drive says it got to.
+func (d drive) upload(ctx context.Context, c *Client, path string, progress func(sent, total int64), checkpoint func() error) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	total := info.Size()
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// Start the session
+	metadata := map[string]any{"name": filepath.Base(path)}
+	if folder := c.folder(); folder != "" {
+		metadata["parents"] = []string{folder}
+	}
+	body, _ := json.Marshal(metadata)
+	var session string
+	err = withRetry(ctx, func() error {
+		access, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, driveUploadURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+access)
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		req.Header.Set("X-Upload-Content-Type", contentType)
+		req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(total, 10))
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			return err
+		}
+		session = resp.Header.Get("Location")
+		if session == "" {
+			return fmt.Errorf("no upload session in the response")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start uploading %s: %w", filepath.Base(path), err)
+	}
+
+	var offset int64
+	var file *driveFile
+	for file == nil {
+		if err := checkpoint(); err != nil {
+			return "", err
+		}
+		chunk, err := readChunk(f, offset)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+
+		err = withRetry(ctx, func() error {
+			next, done, err := d.sendChunk(ctx, c, session, chunk, offset, total)
+			if err != nil {
+				// Ask how much arrived, so the retry resends only the rest
+				if got, gotDone, statusErr := d.sendChunk(ctx, c, session, nil, -1, total); statusErr == nil {
+					if gotDone != nil {
+						offset, file = total, gotDone
+						return nil
+					}
+					if got != offset {
+						rest, readErr := readChunk(f, got)
+						if readErr != nil {
+							return readErr
+						}
+						chunk, offset = rest, got
+					}
+				}
+				return err
+			}
+			offset, file = next, done
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to upload %s: %w", filepath.Base(path), err)
+		}
+		progress(offset, total)
+	}
+
+	if file.WebViewLink != "" {
+		return file.WebViewLink, nil
+	}
+	return "https://drive.google.com/file/d/" + file.ID + "/view", nil
+}
+
+// sendChunk sends chunk at offset to an upload session, returning where the
+// next chunk starts, or the file once the upload is complete. offset -1 sends
+// nothing and just asks how much has arrived.
+func (drive) sendChunk(ctx context.Context, c *Client, session string, chunk []byte, offset, total int64) (int64, *driveFile, error) {
+	access, err := c.accessToken(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, session, bytes.NewReader(chunk))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+access)
+	if offset < 0 || len(chunk) == 0 {
+		// A status check, or the whole of an empty file
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", total))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(chunk))-1, total))
+	}
+	req.ContentLength = int64(len(chunk))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var file driveFile
+		if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+			return 0, nil, fmt.Errorf("failed to read upload response: %w", err)
+		}
+		return total, &file, nil
+	case http.StatusPermanentRedirect: // "Resume Incomplete": Range says what arrived
+		var next int64
+		if r := resp.Header.Get("Range"); r != "" {
+			if _, after, ok := strings.Cut(r, "-"); ok {
+				last, err := strconv.ParseInt(after, 10, 64)
+				if err == nil {
+					next = last + 1
+				}
+			}
+		}
+		return next, nil, nil
+	}
+	return 0, nil, checkStatus(resp)
+}
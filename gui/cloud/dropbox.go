@@ -0,0 +1,232 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Dropbox OAuth and API endpoints
+const (
+	dropboxAuthURL    = "https://www.dropbox.com/oauth2/authorize"
+	dropboxTokenURL   = "https://api.dropboxapi.com/oauth2/token"
+	dropboxContentURL = "https://content.dropboxapi.com/2/files/"
+	dropboxLinkURL    = "https://api.dropboxapi.com/2/sharing/create_shared_link_with_settings"
+)
+
+// dropbox uploads to Dropbox with upload sessions
+type dropbox struct{}
+
+func (dropbox) authURL(clientID, redirect, state, challenge string) string {
+	q := url.Values{
+		"client_id":             {clientID},
+		"redirect_uri":          {redirect},
+		"response_type":         {"code"},
+		"token_access_type":     {"offline"}, // Return a refresh token
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return dropboxAuthURL + "?" + q.Encode()
+}
+
+func (dropbox) exchange(ctx context.Context, account Account, code, redirect, verifier string) (Token, error) {
+	return postToken(ctx, dropboxTokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirect},
+		"client_id":     {account.ClientID},
+		"code_verifier": {verifier},
+	})
+}
+
+func (dropbox) refresh(ctx context.Context, account Account) (Token, error) {
+	return postToken(ctx, dropboxTokenURL, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {account.Token.RefreshToken},
+		"client_id":     {account.ClientID},
+	})
+}
+
+// dropboxCursor is where an upload session has got to
+type dropboxCursor struct {
+	SessionID string `json:"session_id"`
+	Offset    int64  `json:"offset"`
+}
+
+// dropboxError is the error of a Dropbox API response
+type dropboxError struct {
+	Error struct {
+		Tag           string `json:".tag"`
+		CorrectOffset *int64 `json:"correct_offset"`
+		// Set when a link can't be created because the file already has one
+		SharedLinkAlreadyExists *struct {
+			URL string `json:"url"`
+		} `json:"shared_link_already_exists"`
+	} `json:"error"`
+}
+
+// parseDropboxError returns the Dropbox error of a failed request, if any
+func parseDropboxError(err error) (dropboxError, bool) {
+	var status *statusError
+	var dbErr dropboxError
+	if !errors.As(err, &status) || status.code != http.StatusConflict {
+		return dbErr, false
+	}
+	return dbErr, json.Unmarshal([]byte(status.body), &dbErr) == nil
+}
+
+// apiArg encodes a Dropbox-API-Arg header, which has to be ASCII
+func apiArg(v any) string {
+	data, _ := json.Marshal(v)
+	var b strings.Builder
+	for _, r := range string(data) {
+		if r > 0x7e {
+			if r > 0xffff {
+				// Written as a UTF-16 surrogate pair
+				r -= 0x10000
+				fmt.Fprintf(&b, `\u%04x\u%04x`, 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// call sends a request to a Dropbox endpoint (content endpoints take the
+// arguments in a header) and decodes the response into out (may be nil)
+func (dropbox) call(ctx context.Context, c *Client, endpoint string, arg any, body []byte, content bool, out any) error {
+	access, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	var req *http.Request
+	if content {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Dropbox-API-Arg", apiArg(arg))
+			req.Header.Set("Content-Type", "application/octet-stream")
+		}
+	} else {
+		data, _ := json.Marshal(arg)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+access)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// upload sends the file through an upload session a chunk at a time, commits
+// it to the folder (renamed if the name is taken) and returns a shared link.
+// A chunk that fails is retried from the offset Dropbox says it got to.
+func (d dropbox) upload(ctx context.Context, c *Client, filePath string, progress func(sent, total int64), checkpoint func() error) (string, error) {
+	name := filepath.Base(filePath)
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	total := info.Size()
+
+	var start struct {
+		SessionID string `json:"session_id"`
+	}
+	err = withRetry(ctx, func() error {
+		return d.call(ctx, c, dropboxContentURL+"upload_session/start", map[string]bool{"close": false}, nil, true, &start)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start uploading %s: %w", name, err)
+	}
+
+	cursor := dropboxCursor{SessionID: start.SessionID}
+	for cursor.Offset < total {
+		if err := checkpoint(); err != nil {
+			return "", err
+		}
+		err = withRetry(ctx, func() error {
+			chunk, err := readChunk(f, cursor.Offset)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			arg := map[string]any{"cursor": cursor, "close": false}
+			err = d.call(ctx, c, dropboxContentURL+"upload_session/append_v2", arg, chunk, true, nil)
+			if dbErr, ok := parseDropboxError(err); ok && dbErr.Error.CorrectOffset != nil {
+				// Part of the chunk arrived before the failure: carry on from there
+				cursor.Offset = *dbErr.Error.CorrectOffset
+				return nil
+			}
+			if err == nil {
+				cursor.Offset += int64(len(chunk))
+			}
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		progress(cursor.Offset, total)
+	}
+
+	folder := strings.TrimRight(c.folder(), "/")
+	if folder != "" && !strings.HasPrefix(folder, "/") {
+		folder = "/" + folder
+	}
+	var committed struct {
+		PathDisplay string `json:"path_display"`
+	}
+	err = withRetry(ctx, func() error {
+		arg := map[string]any{
+			"cursor": cursor,
+			"commit": map[string]any{"path": path.Join("/", folder, name), "mode": "add", "autorename": true},
+		}
+		return d.call(ctx, c, dropboxContentURL+"upload_session/finish", arg, nil, true, &committed)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save %s: %w", name, err)
+	}
+
+	var link struct {
+		URL string `json:"url"`
+	}
+	err = withRetry(ctx, func() error {
+		return d.call(ctx, c, dropboxLinkURL, map[string]string{"path": committed.PathDisplay}, nil, false, &link)
+	})
+	if dbErr, ok := parseDropboxError(err); ok && dbErr.Error.SharedLinkAlreadyExists != nil {
+		link.URL, err = dbErr.Error.SharedLinkAlreadyExists.URL, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("uploaded %s but failed to create a link to it: %w", name, err)
+	}
+	return link.URL, nil
+}
@@ -0,0 +1,161 @@
+package cloud
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RedirectURI is where the browser is sent back to after signing in. It has
+// to be registered with the app (client ID) for Dropbox; Google accepts any
+// localhost address for desktop apps.
+const RedirectURI = "http://localhost:53682/"
+
+// redirectAddress is what the sign-in listener binds to
+const redirectAddress = "127.0.0.1:53682"
+
+// authTimeout is how long Authorize waits for the browser sign-in
+const authTimeout = 5 * time.Minute
+
+// tokenClient posts to the OAuth token endpoints
+var tokenClient = &http.Client{Timeout: time.Minute}
+
+// randomString returns n random bytes, base64url encoded
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Authorize signs in to the account: openURL shows the provider's sign-in
+// page in the browser, which redirects back to a listener on RedirectURI with
+// a code that is exchanged for tokens (PKCE, so no secret is needed to keep
+// the tokens safe)
+func (c *Client) Authorize(ctx context.Context, openURL func(string) error) error {
+	verifier, err := randomString(32)
+	if err != nil {
+		return fmt.Errorf("failed to create sign-in code: %w", err)
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return fmt.Errorf("failed to create sign-in code: %w", err)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	listener, err := net.Listen("tcp", redirectAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the sign-in on %s (is another sign-in open?): %w", redirectAddress, err)
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var res result
+		switch {
+		case query.Get("code") == "" && query.Get("error") == "":
+			// Not the redirect (e.g. the browser asking for a favicon)
+			http.NotFound(w, r)
+			return
+		case query.Get("state") != state:
+			res.err = fmt.Errorf("sign-in response doesn't match the request")
+		case query.Get("error") != "":
+			res.err = fmt.Errorf("sign-in refused: %s %s", query.Get("error"), query.Get("error_description"))
+		default:
+			res.code = query.Get("code")
+		}
+		message := "Signed in. You can close this window and go back to GoPro Clip Extractor."
+		if res.err != nil {
+			message = "Sign-in failed: " + res.err.Error()
+		}
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", html.EscapeString(message))
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	c.mu.Lock()
+	account := c.account
+	c.mu.Unlock()
+	if err := openURL(c.provider.authURL(account.ClientID, RedirectURI, state, challenge)); err != nil {
+		return fmt.Errorf("failed to open the browser: %w", err)
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-time.After(authTimeout):
+		return fmt.Errorf("timed out waiting for the browser sign-in")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	token, err := c.provider.exchange(ctx, account, res.code, RedirectURI, verifier)
+	if err != nil {
+		return fmt.Errorf("failed to finish signing in: %w", err)
+	}
+	c.setToken(token)
+	return nil
+}
+
+// postToken posts form to an OAuth token endpoint and returns the token in
+// the response
+func postToken(ctx context.Context, tokenURL string, form url.Values) (Token, error) {
+	encoded := form.Encode()
+	var token Token
+	err := withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(encoded))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := tokenClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if err := checkStatus(resp, http.StatusOK); err != nil {
+			return err
+		}
+
+		var body struct {
+			AccessToken  string `json:"access_token"`
+			RefreshToken string `json:"refresh_token"`
+			ExpiresIn    int    `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return fmt.Errorf("failed to read token: %w", err)
+		}
+		token = Token{
+			AccessToken:  body.AccessToken,
+			RefreshToken: body.RefreshToken,
+			Expiry:       time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+		}
+		return nil
+	})
+	if err == nil && token.AccessToken == "" {
+		err = fmt.Errorf("no access token in the response")
+	}
+	return token, err
+}
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
-	"gopro-gui/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/cloud"
 )
 
 // Config holds persistent application settings
@@ -15,29 +21,279 @@ type Config struct {
 	Periods        []metadata.Period `json:"periods"`
 	SecondsBefore  float64           `json:"seconds_before"`
 	SecondsAfter   float64           `json:"seconds_after"`
+	// MergeOverlaps extracts highlights whose clips would overlap as one clip
+	// (see metadata.GroupChapters). OverlapPolicy names the finer choice that
+	// replaced it (see metadata.OverlapPolicyNamed; "" = from MergeOverlaps),
+	// with OverlapGap seconds for metadata.OverlapMergeBelow.
+	MergeOverlaps bool    `json:"merge_overlaps"`
+	OverlapPolicy string  `json:"overlap_policy"`
+	OverlapGap    float64 `json:"overlap_gap"`
+	// PaddingPresets are the padding presets saved in Step 2, on top of the
+	// built-in ones (see BuiltinPaddingPresets)
+	PaddingPresets []PaddingPreset `json:"padding_presets,omitempty"`
+	// CrossPeriodWindow is the clock time window (seconds) for warning about
+	// near-duplicate highlights on either side of a period boundary
+	CrossPeriodWindow float64 `json:"cross_period_window"`
+	// DedupThreshold collapses HiLights closer than this many seconds (double presses)
+	DedupThreshold float64 `json:"dedup_threshold"`
+	// PeriodSplitGap splits one recording into periods where its HiLights
+	// are more than this many minutes apart (0 = off)
+	PeriodSplitGap float64 `json:"period_split_gap"`
+	// GameSplitGap splits a working folder into separate games where one
+	// period's recording starts more than this many minutes after the last
+	// one ended, e.g. a morning and an afternoon game on one card (0 = off)
+	GameSplitGap float64 `json:"game_split_gap"`
+	// SpeedBurstKmh suggests chapters where a GoPro MP4's GPS speed stays
+	// over this many km/h, e.g. a helmet camera on a breakaway (0 = off)
+	SpeedBurstKmh float64 `json:"speed_burst_kmh"`
+	// AutoClipInterval suggests a chapter every this many seconds through
+	// each period (0 = off) and AutoClipAudio one wherever the sound gets
+	// suddenly louder, for games where nobody pressed the HiLight button
+	AutoClipInterval float64 `json:"auto_clip_interval"`
+	AutoClipAudio    bool    `json:"auto_clip_audio"`
+	// DeadAirNoise and DeadAirMin say what Step 3's Trim Dead Air cuts from
+	// the start and end of clips: stretches of at least DeadAirMin seconds
+	// with the sound below DeadAirNoise dBFS or, with DeadAirFreeze, the
+	// picture standing still
+	DeadAirNoise  float64 `json:"dead_air_noise"`
+	DeadAirMin    float64 `json:"dead_air_min"`
+	DeadAirFreeze bool    `json:"dead_air_freeze"`
+	// HighlightPhotos also saves a JPEG of each highlight's frame when
+	// extracting clips, plus PhotoFrames frames on each side of it
+	HighlightPhotos bool `json:"highlight_photos"`
+	PhotoFrames     int  `json:"photo_frames"`
+	// EditCopies also writes a stream-copied MOV of each re-encoded clip for
+	// editing, from the same decode of the source (see pipeline.EditFolder)
+	EditCopies bool `json:"edit_copies"`
+	// TargetSizeMB is the file size cap used by the "Target File Size" encode mode
+	TargetSizeMB float64 `json:"target_size_mb"`
+	// IntroPath and OutroPath are bumpers (video or image) added to every combined reel
+	IntroPath string `json:"intro_path"`
+	OutroPath string `json:"outro_path"`
+	// MusicBedPath is the music played instead of the camera's sound in
+	// clips set to "Music bed" in Step 3
+	MusicBedPath string `json:"music_bed_path"`
+	// BumperStillDuration is how long (seconds) an image bumper is shown
+	BumperStillDuration float64 `json:"bumper_still_duration"`
+	// Intro montage of the top-rated clips, cut to the beat of a music track
+	// and added before every combined reel (see ffmpeg.Montage)
+	MontageOnReel    bool    `json:"montage_on_reel"`
+	MontageMusicPath string  `json:"montage_music_path"`
+	MontageSeconds   float64 `json:"montage_seconds"`
+	MontageBPM       float64 `json:"montage_bpm"` // 0 = detected from the music
+	MontageRamp      bool    `json:"montage_ramp"`
+	// Watermark logo overlay (applied to re-encoded clips and/or reels)
+	WatermarkPath    string  `json:"watermark_path"`
+	WatermarkCorner  string  `json:"watermark_corner"`
+	WatermarkOpacity float64 `json:"watermark_opacity"`
+	WatermarkScale   float64 `json:"watermark_scale"`
+	WatermarkOnClips bool    `json:"watermark_on_clips"`
+	WatermarkOnReel  bool    `json:"watermark_on_reel"`
+	// Scoreboard overlay burned into re-encoded reels from the game's score timeline
+	ScoreboardOnReel bool   `json:"scoreboard_on_reel"`
+	ScoreboardCorner string `json:"scoreboard_corner"`
+	// Clip counter ("3/27") burned into each clip of re-encoded reels
+	ClipCounterOnReel bool   `json:"clip_counter_on_reel"`
+	ClipCounterCorner string `json:"clip_counter_corner"`
+	// RoughSeekWindow is how far (seconds) before a clip the keyframe seek lands.
+	// 0 = automatic, from the source's keyframe interval.
+	RoughSeekWindow float64 `json:"rough_seek_window"`
+	// APIAddress is where the local control API listens (e.g. "127.0.0.1:8765").
+	// Empty = disabled.
+	APIAddress string `json:"api_address"`
+	// APIGamesFolder is the folder the control API and web UI may scan game
+	// folders in, named relative to it ("" = they can't start scans)
+	APIGamesFolder string `json:"api_games_folder,omitempty"`
+	// ExcludedVideos lists MOV files left out of the periods in Step 1
+	// (e.g. warmup or zamboni footage)
+	ExcludedVideos []string `json:"excluded_videos,omitempty"`
+	// EncodeSpeed is the encoder benchmark used for time estimates, refined
+	// by each re-encode that finishes (nil = not benchmarked yet)
+	EncodeSpeed *ffmpeg.EncodeSpeed `json:"encode_speed,omitempty"`
+	// TeamName is the {team} value in name templates
+	TeamName string `json:"team_name"`
+	// Roster is the team's players, tagged on clips in Step 3 (see metadata.ParseRoster)
+	Roster []metadata.Player `json:"roster,omitempty"`
+	// Templates for the metadata tags written into clips and reels
+	// (see metadata.ExpandTemplate). Empty = tag not written.
+	ClipTitleTemplate string `json:"clip_title_template"`
+	ReelTitleTemplate string `json:"reel_title_template"`
+	ArtistTemplate    string `json:"artist_template"`
+	CommentTemplate   string `json:"comment_template"`
+	// CaptionTemplate is the caption shown over each clip of a reel
+	CaptionTemplate string `json:"caption_template"`
+	// ReelCaptions is how reel captions are written: "none", "srt" (a .srt
+	// file next to the reel), "embedded" (a subtitle track) or "both"
+	ReelCaptions string `json:"reel_captions"`
+	// ReelTransition is how clips meet in a re-encoded reel: "cut", "fade"
+	// (each clip fades in and out) or "crossfade", over TransitionSeconds
+	ReelTransition    string  `json:"reel_transition"`
+	TransitionSeconds float64 `json:"transition_seconds"`
+	// ReelSegments builds re-encoded reels clip by clip and keeps the encoded
+	// clips next to the reel, so a rebuild only encodes the clips that changed
+	// (see ffmpeg.ConcatClipsSegmented). Crossfaded and target-size reels are
+	// always encoded whole.
+	ReelSegments bool `json:"reel_segments"`
+	// PeriodReels is which reels Step 4 makes: "full" (one reel of all the
+	// clips), "both" (the full reel and one per period) or "periods"
+	PeriodReels string `json:"period_reels"`
+	// ClipOrder is the order Step 4 puts the clips in (see the Step 4 order
+	// choices): "chronological", "rating", "goals" or "periods"
+	ClipOrder string `json:"clip_order"`
+	// VerticalReel also exports each reel as a 1080x1920 (9:16) video:
+	// "off", "center" (crop the middle) or "pan" (crop where set per clip)
+	VerticalReel string `json:"vertical_reel"`
+	// OutputRoot is the base folder of the per-game output layout
+	// ("" = folders are chosen by hand). Clips and reels are written to the
+	// folders under it named by ClipFolderTemplate and ReelFolderTemplate.
+	OutputRoot         string `json:"output_root"`
+	ClipFolderTemplate string `json:"clip_folder_template"`
+	ReelFolderTemplate string `json:"reel_folder_template"`
+	// ArchiveFolder is where the Storage tab last moved GoPro originals to
+	// ("" = never). Each game's files go in a folder under it named after the
+	// game's working folder.
+	ArchiveFolder string `json:"archive_folder,omitempty"`
+	// FFmpegPath is the ffmpeg executable to use ("" = look in bin/, then PATH).
+	// ffprobe must be next to it. Takes effect on the next launch. In portable
+	// mode it may be relative to the executable (see ResolvePath).
+	FFmpegPath string `json:"ffmpeg_path"`
+	// FFmpegArgs are custom arguments added to the encodes at each preset,
+	// by preset ("high", "balanced", "draft" or "reel", see
+	// ffmpeg.SetPresetArgs), typed as on a command line. They are checked
+	// against the ffmpeg in use when the app starts (see PresetArgs).
+	FFmpegArgs map[string]string `json:"ffmpeg_args,omitempty"`
+	// PreferCPU turns GPU (NVENC) encoding off, so every encode uses the CPU
+	PreferCPU bool `json:"prefer_cpu"`
+	// HDRMode is how clips from 10-bit/HDR sources are encoded: "tonemap"
+	// (8-bit H.264, HDR tone-mapped to SDR) or "preserve" (10-bit HEVC)
+	HDRMode string `json:"hdr_mode"`
+	// ClipQuality is how re-encoded clips are encoded: "high" (CRF 18),
+	// "balanced" or "draft" (fast, for rough cuts). Reels have their own.
+	ClipQuality string `json:"clip_quality"`
+	// AudioCopy copies AAC source audio into re-encoded clips instead of
+	// encoding it again (see ffmpeg.SetAudioCopy)
+	AudioCopy bool `json:"audio_copy"`
+	// StreamCopyMP4 stream copies H.264 sources into .mp4 clips instead of .mov
+	StreamCopyMP4 bool `json:"stream_copy_mp4"`
+	// CaptureTimes dates clips and reels by when their footage was recorded
+	// (creation_time tag and file modification time) instead of when they
+	// were written
+	CaptureTimes bool `json:"capture_times"`
+	// ReExtractWorkers is how many clips Step 3 re-extracts at once
+	ReExtractWorkers int `json:"re_extract_workers"`
+	// DriveWorkers is how many clips are extracted at once from the sources
+	// on a drive, by drive (see pipeline.SourceDrive), e.g. 1 for a spinning
+	// disk and 3 for an SSD. Drives not listed use the default of each step.
+	DriveWorkers map[string]int `json:"drive_workers"`
+	// FFmpegThreads limits the threads each ffmpeg run uses (0 = all cores)
+	// and LowPriority runs ffmpeg below normal priority, so background
+	// encodes leave the computer usable
+	FFmpegThreads int  `json:"ffmpeg_threads"`
+	LowPriority   bool `json:"low_priority"`
+	// QuietHours pauses the job queue during a daily time range, e.g.
+	// "17:00-22:00" ("" = never; see jobs.QuietHours)
+	QuietHours string `json:"quiet_hours"`
+	// Theme is "system", "light" or "dark"
+	Theme string `json:"theme"`
+	// AdvancedMode shows the controls for picking clip and video folders by
+	// hand in Steps 3 to 5, instead of only following on from Steps 1 and 2
+	AdvancedMode bool `json:"advanced_mode"`
+	// Hook commands run through the shell when an operation finishes
+	// ("" = none), with the output in $GOPRO_OUTPUT
+	HookAfterExtract string `json:"hook_after_extract"`
+	HookAfterCombine string `json:"hook_after_combine"`
+	HookAfterExport  string `json:"hook_after_export"`
+	// Cloud is the Google Drive or Dropbox account finished videos are
+	// uploaded to, with its sign-in tokens
+	Cloud cloud.Account `json:"cloud"`
+	// UploadAfterCombine and UploadAfterExport upload each reel combined in
+	// Step 4 and each full game export to the cloud folder once it's written
+	UploadAfterCombine bool `json:"upload_after_combine"`
+	UploadAfterExport  bool `json:"upload_after_export"`
+	// MultiInstance skips the warning shown when another copy is running
+	MultiInstance bool `json:"multi_instance"`
+
+	// saved is each setting as last loaded or saved, so Save only writes the
+	// ones this instance changed (see merge)
+	saved map[string]json.RawMessage
 }
 
 // DefaultConfig returns a new config with default values
 func DefaultConfig() *Config {
 	return &Config{
-		SecondsBefore: 8.0,
-		SecondsAfter:  2.0,
+		SecondsBefore:       8.0,
+		SecondsAfter:        2.0,
+		MergeOverlaps:       true,
+		OverlapGap:          5.0,
+		CrossPeriodWindow:   10.0,
+		DedupThreshold:      2.0,
+		TargetSizeMB:        2000,
+		BumperStillDuration: 3.0,
+		MontageSeconds:      25,
+		MontageRamp:         true,
+		WatermarkCorner:     "bottom-right",
+		WatermarkOpacity:    0.8,
+		WatermarkScale:      0.12,
+		ScoreboardCorner:    "top-left",
+		ClipCounterCorner:   "top-right",
+		ClipTitleTemplate:   "{period} {clock} {chapter}",
+		ReelTitleTemplate:   "{game} Highlights",
+		ArtistTemplate:      "{team}",
+		CommentTemplate:     "{game}",
+		CaptionTemplate:     "{period} - {clock} - {chapter} {label}",
+		ReelCaptions:        "none",
+		ReelTransition:      "cut",
+		TransitionSeconds:   0.5,
+		ReelSegments:        true,
+		PeriodReels:         "full",
+		ClipOrder:           "chronological",
+		VerticalReel:        "off",
+		ClipFolderTemplate:  "{game}/{date}/clips",
+		ReelFolderTemplate:  "{game}/{date}/reel",
+		ReExtractWorkers:    2,
+		DeadAirNoise:        -35,
+		DeadAirMin:          2,
+		DeadAirFreeze:       true,
+		HDRMode:             "tonemap",
+		ClipQuality:         "high",
+		AudioCopy:           true,
+		CaptureTimes:        true,
+		Theme:               "system",
 	}
 }
 
-// configPath returns the path to the config file
-func configPath() (string, error) {
-	configDir, err := os.UserConfigDir()
+// Dir returns the folder holding the config file and the app's other files
+// (e.g. logs), creating it if needed
+func Dir() (string, error) {
+	path, err := configPath()
 	if err != nil {
 		return "", err
 	}
+	return filepath.Dir(path), nil
+}
 
-	appDir := filepath.Join(configDir, "gopro-clip-extractor")
-	if err := os.MkdirAll(appDir, 0755); err != nil {
+// configPath returns the path to the config file (see appDir)
+func configPath() (string, error) {
+	dir, err := appDir()
+	if err != nil {
 		return "", err
 	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.json"), nil
+}
 
-	return filepath.Join(appDir, "config.json"), nil
+// Exists reports whether a config file has been saved, i.e. whether the app
+// has been set up before
+func Exists() bool {
+	path, err := configPath()
+	if err != nil {
+		return true // Nowhere to save one, so don't ask each launch
+	}
+	_, err = os.Stat(path)
+	return err == nil
 }
 
 // Load loads the config from disk, returning defaults if not found
@@ -60,24 +316,135 @@ func Load() (*Config, error) {
 	if err := json.NewDecoder(file).Decode(cfg); err != nil {
 		return DefaultConfig(), nil
 	}
+	cfg.saved, _ = cfg.fields()
 
 	return cfg, nil
 }
 
-// Save saves the config to disk
+// DriveWorkersFor returns how many clips may be extracted at once from a
+// drive: its DriveWorkers setting, or fallback if it has none
+func (c *Config) DriveWorkersFor(drive string, fallback int) int {
+	if n := c.DriveWorkers[drive]; n > 0 {
+		return n
+	}
+	return fallback
+}
+
+// AutoChapters returns the chapters the analysis suggests across each period
+// (see AutoClipInterval)
+func (c *Config) AutoChapters() metadata.AutoChapters {
+	return metadata.AutoChapters{
+		Interval:    time.Duration(c.AutoClipInterval * float64(time.Second)),
+		AudioSpikes: c.AutoClipAudio,
+	}
+}
+
+// DeadAir returns what counts as dead air when trimming clips (see DeadAirNoise)
+func (c *Config) DeadAir() ffmpeg.DeadAirOptions {
+	return ffmpeg.DeadAirOptions{NoiseDB: c.DeadAirNoise, MinDuration: c.DeadAirMin, Freeze: c.DeadAirFreeze}
+}
+
+// PresetArgs splits the FFmpegArgs of each preset and checks them with ff
+// (see ffmpeg.CheckPresetArgs), which runs ffmpeg once per preset that has
+// any. It returns the arguments ffmpeg accepted, for ff.SetPresetArgs, and
+// why the rest were left out.
+func (c *Config) PresetArgs(ff *ffmpeg.FFmpeg) (map[string][]string, []string) {
+	valid := make(map[string][]string)
+	var problems []string
+	for _, preset := range ffmpeg.Presets() {
+		args, err := ffmpeg.SplitArgs(c.FFmpegArgs[preset])
+		if err == nil && len(args) > 0 {
+			err = ff.CheckPresetArgs(preset, args)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", preset, err))
+			continue
+		}
+		if len(args) > 0 {
+			valid[preset] = args
+		}
+	}
+	return valid, problems
+}
+
+// Save saves the config to disk. Another instance may have saved since this
+// one loaded, so only the settings changed here are written over the file's;
+// the rest keep whatever is on disk. The file is locked while it is merged
+// and replaced atomically.
 func (c *Config) Save() error {
 	path, err := configPath()
 	if err != nil {
 		return err
 	}
 
-	file, err := os.Create(path)
+	unlock, err := lockFile(path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	defer unlock()
+
+	ours, err := c.fields()
+	if err != nil {
+		return err
+	}
+	merged := c.merge(ours, readFields(path))
+
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	c.saved = ours
+	return nil
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(c)
+// fields returns each setting's JSON value by key
+func (c *Config) fields() (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return fields, nil
+}
+
+// merge combines this instance's settings with those on disk: a setting
+// changed here since it was loaded or saved wins, any other keeps the value
+// on disk (which another instance may have changed)
+func (c *Config) merge(ours, onDisk map[string]json.RawMessage) map[string]json.RawMessage {
+	merged := make(map[string]json.RawMessage, len(ours))
+	for key, value := range onDisk {
+		merged[key] = value
+	}
+	for key, value := range ours {
+		before, loaded := c.saved[key]
+		_, stored := onDisk[key]
+		if !loaded || !stored || !bytes.Equal(before, value) {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// readFields reads the settings in the config file by key (nil if it can't be read)
+func readFields(path string) map[string]json.RawMessage {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return nil
+	}
+	return fields
 }
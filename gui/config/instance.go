@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// instanceHeartbeat is how often a running instance touches its slot file
+	instanceHeartbeat = 10 * time.Second
+	// instanceStaleAfter is how old a slot file may get before its instance is
+	// taken to have crashed and the slot is reused
+	instanceStaleAfter = 3 * instanceHeartbeat
+	// maxSlotGap is how many slots past its own an instance looks through
+	// when counting the others (instances keep their slot when lower ones free up)
+	maxSlotGap = 8
+
+	// lockRetry and lockTimeout bound how long a save waits for another
+	// instance to release the config lock
+	lockRetry   = 50 * time.Millisecond
+	lockTimeout = 5 * time.Second
+	// lockStaleAfter is how old a lock file may get before it is taken to be
+	// left over from a crash and removed
+	lockStaleAfter = 10 * time.Second
+)
+
+// slotMu guards currentSlot
+var (
+	slotMu      sync.Mutex
+	currentSlot = 1
+)
+
+// Instance is a running copy of the app's claim on a numbered slot. Each slot
+// has its own recovery file, so several copies can run at once without
+// overwriting each other's session.
+type Instance struct {
+	// Slot is this instance's number, from 1
+	Slot int
+	// Others is how many other instances were running when the slot was claimed
+	Others int
+
+	path string
+	stop chan struct{}
+}
+
+// ClaimInstance claims the lowest slot not held by a running instance and
+// keeps it alive until Release. A slot left by a crashed instance is reused,
+// so its recovery file is offered on the next launch.
+func ClaimInstance() (*Instance, error) {
+	dir, err := instancesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockFile(filepath.Join(dir, "claim"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	inst := &Instance{stop: make(chan struct{})}
+	for slot := 1; ; slot++ {
+		path := filepath.Join(dir, fmt.Sprintf("slot-%d", slot))
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < instanceStaleAfter {
+			inst.Others++
+			continue
+		}
+		if inst.Slot == 0 {
+			if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+				return nil, fmt.Errorf("failed to claim instance slot: %w", err)
+			}
+			inst.Slot, inst.path = slot, path
+		}
+		// Keep counting running instances in higher slots
+		if slot >= inst.Slot+maxSlotGap {
+			break
+		}
+	}
+
+	slotMu.Lock()
+	currentSlot = inst.Slot
+	slotMu.Unlock()
+
+	go inst.heartbeat()
+	return inst, nil
+}
+
+// heartbeat touches the slot file until Release
+func (inst *Instance) heartbeat() {
+	ticker := time.NewTicker(instanceHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			os.Chtimes(inst.path, now, now)
+		case <-inst.stop:
+			return
+		}
+	}
+}
+
+// Release gives up the slot (called on a clean exit)
+func (inst *Instance) Release() {
+	close(inst.stop)
+	os.Remove(inst.path)
+}
+
+// slot returns the slot of this process's instance (1 if none was claimed)
+func slot() int {
+	slotMu.Lock()
+	defer slotMu.Unlock()
+	return currentSlot
+}
+
+// instancesDir returns the folder holding the slot files (next to config.json)
+func instancesDir() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(path), "instances")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// lockFile takes an exclusive lock on path by creating path+".lock", waiting
+// for another instance to release it. Returns the function that releases it.
+func lockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", filepath.Base(path), err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for another instance to release %s", filepath.Base(path))
+		}
+		time.Sleep(lockRetry)
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// PaddingPreset is a named clip padding for a sport: how much of the play
+// before and after a highlight its clips show, and whether highlights close
+// together are merged into one clip (see Config.OverlapPolicy)
+type PaddingPreset struct {
+	Name          string  `json:"name"`
+	SecondsBefore float64 `json:"seconds_before"`
+	SecondsAfter  float64 `json:"seconds_after"`
+	MergeOverlaps bool    `json:"merge_overlaps"`
+	OverlapPolicy string  `json:"overlap_policy,omitempty"`
+	OverlapGap    float64 `json:"overlap_gap,omitempty"`
+}
+
+// Overlaps returns the name of the preset's overlap policy, from
+// MergeOverlaps for presets saved before there was a choice
+func (p PaddingPreset) Overlaps() string {
+	return overlapPolicyName(p.OverlapPolicy, p.MergeOverlaps)
+}
+
+// Overlaps returns the name of the overlap policy clips are grouped with,
+// from MergeOverlaps for configs saved before there was a choice
+func (c *Config) Overlaps() string {
+	return overlapPolicyName(c.OverlapPolicy, c.MergeOverlaps)
+}
+
+// OverlapGrouping returns the policy clips are grouped with (MergeAll if the
+// saved one isn't valid)
+func (c *Config) OverlapGrouping() metadata.OverlapPolicy {
+	policy, err := metadata.OverlapPolicyNamed(c.Overlaps(), c.OverlapGap)
+	if err != nil {
+		return metadata.MergeAll{}
+	}
+	return policy
+}
+
+// SetOverlaps sets the overlap policy clips are grouped with, keeping
+// MergeOverlaps in step for older versions reading the config
+func (c *Config) SetOverlaps(name string, gap float64) {
+	c.OverlapPolicy = name
+	c.OverlapGap = gap
+	c.MergeOverlaps = name != metadata.OverlapNeverMerge
+}
+
+// overlapPolicyName returns name, or the policy merge stood for if empty
+func overlapPolicyName(name string, merge bool) string {
+	switch {
+	case name != "":
+		return name
+	case merge:
+		return metadata.OverlapMergeAll
+	}
+	return metadata.OverlapNeverMerge
+}
+
+// BuiltinPaddingPresets are the padding presets every install has. A hockey
+// rush is short, while a soccer or lacrosse attack builds up for longer.
+var BuiltinPaddingPresets = []PaddingPreset{
+	{Name: "Hockey", SecondsBefore: 8, SecondsAfter: 2, MergeOverlaps: true, OverlapPolicy: metadata.OverlapMergeAll},
+	{Name: "Soccer", SecondsBefore: 15, SecondsAfter: 5, MergeOverlaps: true, OverlapPolicy: metadata.OverlapMergeAll},
+	{Name: "Lacrosse", SecondsBefore: 10, SecondsAfter: 3, MergeOverlaps: true, OverlapPolicy: metadata.OverlapMergeAll},
+}
+
+// IsBuiltinPreset returns true if name is one of the built-in padding presets
+func IsBuiltinPreset(name string) bool {
+	for _, p := range BuiltinPaddingPresets {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AllPaddingPresets returns the built-in padding presets followed by the
+// saved ones
+func (c *Config) AllPaddingPresets() []PaddingPreset {
+	return append(append([]PaddingPreset{}, BuiltinPaddingPresets...), c.PaddingPresets...)
+}
+
+// FindPaddingPreset returns the padding preset called name
+func (c *Config) FindPaddingPreset(name string) (PaddingPreset, bool) {
+	for _, p := range c.AllPaddingPresets() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PaddingPreset{}, false
+}
+
+// SavePaddingPreset adds a padding preset, replacing a saved one of the same
+// name. Built-in presets can't be replaced.
+func (c *Config) SavePaddingPreset(preset PaddingPreset) error {
+	if preset.Name == "" {
+		return fmt.Errorf("the preset needs a name")
+	}
+	if IsBuiltinPreset(preset.Name) {
+		return fmt.Errorf("%s is a built-in preset - choose another name", preset.Name)
+	}
+	for i, p := range c.PaddingPresets {
+		if p.Name == preset.Name {
+			c.PaddingPresets[i] = preset
+			return nil
+		}
+	}
+	c.PaddingPresets = append(c.PaddingPresets, preset)
+	return nil
+}
+
+// DeletePaddingPreset removes a saved padding preset
+func (c *Config) DeletePaddingPreset(name string) {
+	for i, p := range c.PaddingPresets {
+		if p.Name == name {
+			c.PaddingPresets = append(c.PaddingPresets[:i], c.PaddingPresets[i+1:]...)
+			return
+		}
+	}
+}
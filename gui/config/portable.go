@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PortableMarker is the file that, next to the executable, turns on portable
+// mode: settings, caches and logs are kept in PortableDataDir beside the
+// executable instead of the user profile, so the app runs off a USB stick
+const PortableMarker = "portable.txt"
+
+// PortableDataDir is the folder next to the executable that holds everything
+// the app writes in portable mode
+const PortableDataDir = "data"
+
+var (
+	portableMu     sync.Mutex
+	portableForced bool
+)
+
+// SetPortable turns portable mode on regardless of PortableMarker (the
+// -portable flag). Call it before loading the config.
+func SetPortable(enabled bool) {
+	portableMu.Lock()
+	portableForced = enabled
+	portableMu.Unlock()
+}
+
+// Portable returns true if the app runs in portable mode
+func Portable() bool {
+	portableMu.Lock()
+	forced := portableForced
+	portableMu.Unlock()
+	if forced {
+		return true
+	}
+
+	dir, err := exeDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, PortableMarker))
+	return err == nil
+}
+
+// exeDir returns the folder holding the executable, with symlinks resolved
+func exeDir() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Dir(path), nil
+}
+
+// appDir returns the folder holding config.json: PortableDataDir next to the
+// executable in portable mode, otherwise the user's config folder
+func appDir() (string, error) {
+	if Portable() {
+		dir, err := exeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, PortableDataDir), nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "gopro-clip-extractor"), nil
+}
+
+// CacheDir returns the folder for files that can be rebuilt (e.g. thumbnails),
+// creating it if needed: "cache" in the data folder in portable mode,
+// otherwise the user's cache folder (or the temp folder)
+func CacheDir() string {
+	dir := filepath.Join(os.TempDir(), "gopro-clip-extractor")
+	if Portable() {
+		if data, err := appDir(); err == nil {
+			dir = filepath.Join(data, "cache")
+		}
+	} else if cacheDir, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(cacheDir, "gopro-clip-extractor")
+	}
+	os.MkdirAll(dir, 0755)
+	return dir
+}
+
+// ResolvePath returns a path from the config as an absolute path. In
+// portable mode relative paths are relative to the executable, so they still
+// work when the stick gets another drive letter.
+func ResolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || !Portable() {
+		return path
+	}
+	dir, err := exeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// PortablePath returns a path to store in the config: in portable mode, a
+// path next to or below the executable is made relative to it (see ResolvePath)
+func PortablePath(path string) string {
+	if path == "" || !Portable() {
+		return path
+	}
+	dir, err := exeDir()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
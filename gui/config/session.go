@@ -0,0 +1,204 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// ClipEdit holds the per-clip timing changes made in Step 3
+type ClipEdit struct {
+	SecondsBefore float64 `json:"seconds_before"`
+	SecondsAfter  float64 `json:"seconds_after"`
+	// Audio is what was done with the clip's sound when it was re-extracted
+	Audio ffmpeg.ClipAudio `json:"audio,omitempty"`
+}
+
+// Session is a snapshot of the in-memory work state, auto-saved so an
+// interrupted session (crash, power loss) can be restored on the next launch
+type Session struct {
+	SavedAt        time.Time                `json:"saved_at"`
+	WorkingFolder  string                   `json:"working_folder"`
+	Periods        []metadata.Period        `json:"periods"`
+	Analysis       *metadata.AnalysisResult `json:"analysis,omitempty"`
+	ExtractedClips []string                 `json:"extracted_clips"`
+	// ClipEdits maps clip path -> timing edited in Step 3
+	ClipEdits map[string]ClipEdit `json:"clip_edits,omitempty"`
+	// PeriodColors maps period name -> color correction applied to its clips
+	PeriodColors map[string]ffmpeg.ColorCorrection `json:"period_colors,omitempty"`
+	// PeriodRotations maps period name -> degrees clockwise its clips are turned,
+	// overriding the rotation flag in the video
+	PeriodRotations map[string]int `json:"period_rotations,omitempty"`
+	// ClipGroups maps clip path -> the highlights it was extracted from
+	ClipGroups map[string]metadata.ClipGroup `json:"clip_groups,omitempty"`
+	// Deselected lists the chapters unticked in Step 2 (by metadata.Chapter.Key)
+	Deselected []string `json:"deselected,omitempty"`
+	// ClipRatings maps clip path -> star rating (1-5) given in Step 4
+	ClipRatings map[string]int `json:"clip_ratings,omitempty"`
+	// ClipPans maps clip path -> where a vertical reel crops it (0 = left
+	// edge, 0.5 = center, 1 = right edge)
+	ClipPans map[string]float64 `json:"clip_pans,omitempty"`
+	// ClipNotes maps a clip's first highlight (Chapter.Key) -> the note typed
+	// on it in Step 3, so the note follows the clip when it is re-extracted
+	ClipNotes map[string]string `json:"clip_notes,omitempty"`
+	// ClipPlayers maps a clip's first highlight (Chapter.Key) -> the jersey
+	// numbers of the players tagged on it in Step 3
+	ClipPlayers map[string][]string `json:"clip_players,omitempty"`
+	// ClipAudio maps clip path -> what is done with its sound, chosen in
+	// Step 3 (mute, quieter, music bed; see ffmpeg.ClipAudio)
+	ClipAudio map[string]ffmpeg.ClipAudio `json:"clip_audio,omitempty"`
+	// ReelPath is the last highlight reel combined in Step 4
+	ReelPath string `json:"reel_path,omitempty"`
+	// GameInfo is the game's teams, date, location and final score, entered
+	// in Step 1 (the name is the {game} value in name templates)
+	GameInfo metadata.GameInfo `json:"game_info"`
+	// GameName is the game's name in sessions saved before GameInfo
+	GameName string `json:"game_name,omitempty"`
+	// ScoreTimeline is the game's score changes as entered, one per line
+	// (see metadata.ParseScoreTimeline)
+	ScoreTimeline string `json:"score_timeline,omitempty"`
+	// Opponent is the away team's name in sessions saved before GameInfo
+	Opponent string `json:"opponent,omitempty"`
+	// ClockZone is the IANA time zone the camera's clock was set to
+	// ("" = this computer's), used to date the chapters' clock times
+	ClockZone string `json:"clock_zone,omitempty"`
+	// ManualTimecodes maps video path -> the clock time (HH:MM:SS) of its
+	// first frame, entered in Step 1 for videos that lost their timecode
+	ManualTimecodes map[string]string `json:"manual_timecodes,omitempty"`
+	// Game is which game of the working folder this is, when Step 1 split
+	// it into several (0 = the folder holds one game)
+	Game int `json:"game,omitempty"`
+	// History is the ffmpeg commands run for the game, oldest first, so an
+	// operation can be looked up and run again (see ffmpeg.Rerun)
+	History []ffmpeg.HistoryEntry `json:"history,omitempty"`
+	// Padding is the padding the game's clips are cut with, as chosen in
+	// Step 2 (Name is the preset it came from, "" if entered by hand)
+	Padding *PaddingPreset `json:"padding,omitempty"`
+	// ExportTrims maps MOV path -> the part of it the full game export keeps,
+	// cutting warmups and dead time picked in Step 5
+	ExportTrims map[string]ffmpeg.Trim `json:"export_trims,omitempty"`
+	// AudioTrack is which of the videos' audio streams the clips are cut
+	// with, chosen in Step 2 (0 = the first)
+	AudioTrack int `json:"audio_track,omitempty"`
+}
+
+// sessionPath returns the path to this instance's recovery file (next to
+// config.json): session.json for the first instance, session-<slot>.json for
+// copies running alongside it
+func sessionPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	name := "session.json"
+	if n := slot(); n > 1 {
+		name = fmt.Sprintf("session-%d.json", n)
+	}
+	return filepath.Join(filepath.Dir(path), name), nil
+}
+
+// SaveSession writes the session to the recovery file.
+// Writes to a temp file and renames it so a crash mid-write can't corrupt it.
+func SaveSession(s *Session) error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	return writeSession(path, s)
+}
+
+// SaveProject writes the session to a project file chosen by the user, so the
+// game can be re-cut later without scanning and analyzing it again
+func SaveProject(path string, s *Session) error {
+	return writeSession(path, s)
+}
+
+// writeSession writes a session to path through a temp file
+func writeSession(path string, s *Session) error {
+	s.SavedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession loads the recovery file. Returns nil (and no error) if there is
+// no interrupted session to restore.
+func LoadSession() (*Session, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return decodeSession(data)
+}
+
+// LoadProject loads a project file saved with SaveProject
+func LoadProject(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project: %w", err)
+	}
+	s, err := decodeSession(data)
+	if err != nil {
+		return nil, err
+	}
+	if s.Analysis == nil {
+		return nil, fmt.Errorf("%s has no analysis - is it a project file?", path)
+	}
+	return s, nil
+}
+
+// decodeSession decodes a session or project file
+func decodeSession(data []byte) (*Session, error) {
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &s, nil
+}
+
+// ClearSession removes the recovery file (called on a clean exit)
+func ClearSession() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ExistingClips returns the extracted clips that are still on disk,
+// i.e. the ones that were written successfully before the interruption
+func (s *Session) ExistingClips() []string {
+	var clips []string
+	for _, path := range s.ExtractedClips {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			clips = append(clips, path)
+		}
+	}
+	return clips
+}
@@ -2,7 +2,10 @@ module gopro-gui
 
 go 1.25.5
 
-require fyne.io/fyne/v2 v2.7.1
+require (
+	fyne.io/fyne/v2 v2.7.1
+	github.com/jacobe603/gopro-clip-extractor/core v0.0.0
+)
 
 require (
 	fyne.io/systray v1.11.1-0.20250603113521-ca66a66d8b58 // indirect
@@ -38,3 +41,6 @@ require (
 	golang.org/x/text v0.22.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+// The core library lives next to the GUI in this repository
+replace github.com/jacobe603/gopro-clip-extractor/core => ../core
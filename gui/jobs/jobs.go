@@ -0,0 +1,446 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the lifecycle state of a job
+type State string
+
+const (
+	Pending   State = "pending"
+	Running   State = "running"
+	Paused    State = "paused"
+	Completed State = "completed"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+// maxHistory caps how many finished jobs are kept for listing
+const maxHistory = 100
+
+// ErrCancelled is returned by Checkpoint once a job has been cancelled
+var ErrCancelled = errors.New("cancelled")
+
+// Job is a long-running operation (metadata extraction, clip extraction, reel
+// export, ...) started from the GUI or the control API.
+// Jobs run one at a time in the order they were submitted, since they share one
+// ffmpeg instance and usually the same disks.
+type Job struct {
+	ID    string
+	Kind  string
+	Title string
+
+	manager   *Manager
+	fn        func(job *Job) error
+	mu        sync.Mutex
+	resumed   *sync.Cond
+	state     State
+	paused    bool
+	cancelled bool
+	onCancel  []func() // Called when the running job is cancelled (see OnCancel)
+	progress  float64
+	message   string
+	err       string
+	submitted time.Time
+	started   time.Time
+	finished  time.Time
+}
+
+// Snapshot is a point-in-time copy of a job's status, safe to share and encode as JSON
+type Snapshot struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	State     State      `json:"state"`
+	Progress  float64    `json:"progress"`
+	Message   string     `json:"message,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Held      bool       `json:"held,omitempty"` // Waiting while the whole queue is paused (see Manager.Hold)
+	Submitted time.Time  `json:"submitted"`
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+}
+
+// Done returns true if the job has finished (successfully or not)
+func (s Snapshot) Done() bool {
+	return s.State == Completed || s.State == Failed || s.State == Cancelled
+}
+
+// Update sets the job's progress (0-1) and status message
+func (j *Job) Update(progress float64, message string) {
+	j.mu.Lock()
+	j.progress = progress
+	j.message = message
+	j.mu.Unlock()
+	j.manager.notify(j)
+}
+
+// Checkpoint is called by the job between units of work (clips, files). It
+// blocks while the job or the whole queue is paused (see Manager.Hold) and
+// returns ErrCancelled once it is cancelled, so pause and cancel take effect at
+// the next checkpoint.
+func (j *Job) Checkpoint() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for (j.paused || j.manager.Held()) && !j.cancelled {
+		j.resumed.Wait()
+	}
+	if j.cancelled {
+		return ErrCancelled
+	}
+	return nil
+}
+
+// OnCancel sets fn to be called when the job is cancelled while running, to
+// interrupt its work in progress (e.g. kill its ffmpeg processes). fn is
+// called right away if the job has already been cancelled.
+func (j *Job) OnCancel(fn func()) {
+	j.mu.Lock()
+	cancelled := j.cancelled
+	if !cancelled {
+		j.onCancel = append(j.onCancel, fn)
+	}
+	j.mu.Unlock()
+
+	if cancelled {
+		fn()
+	}
+}
+
+// Cancelled returns true if the job has been asked to stop
+func (j *Job) Cancelled() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.cancelled
+}
+
+// Snapshot returns the job's current status
+func (j *Job) Snapshot() Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s := Snapshot{
+		ID:        j.ID,
+		Kind:      j.Kind,
+		Title:     j.Title,
+		State:     j.state,
+		Progress:  j.progress,
+		Message:   j.message,
+		Error:     j.err,
+		Submitted: j.submitted,
+	}
+	if j.paused && (j.state == Running || j.state == Pending) {
+		s.State = Paused
+	}
+	if j.manager.Held() && (j.state == Running || j.state == Pending) {
+		s.Held = true
+	}
+	if !j.started.IsZero() {
+		started := j.started
+		s.Started = &started
+	}
+	if !j.finished.IsZero() {
+		finished := j.finished
+		s.Finished = &finished
+	}
+	return s
+}
+
+// finish records the job's outcome
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	j.finished = time.Now()
+	switch {
+	case j.cancelled || errors.Is(err, ErrCancelled):
+		j.state = Cancelled
+	case err != nil:
+		j.state = Failed
+		j.err = err.Error()
+	default:
+		j.state = Completed
+		j.progress = 1
+	}
+	j.mu.Unlock()
+}
+
+// Manager queues jobs, runs them one at a time and keeps their history
+type Manager struct {
+	mu        sync.Mutex
+	jobs      []*Job
+	queue     []*Job
+	nextID    int
+	active    *Job
+	listeners []func(Snapshot)
+	// held pauses the whole queue (see Hold). Atomic, since jobs read it
+	// with their own lock held.
+	held atomic.Bool
+}
+
+// NewManager creates an empty job manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Start queues fn to run in the background as a new job. It starts right away
+// if nothing else is running, otherwise after the jobs ahead of it finish.
+func (m *Manager) Start(kind, title string, fn func(job *Job) error) *Job {
+	m.mu.Lock()
+	m.nextID++
+	job := &Job{
+		ID:        fmt.Sprintf("%d", m.nextID),
+		Kind:      kind,
+		Title:     title,
+		manager:   m,
+		fn:        fn,
+		state:     Pending,
+		submitted: time.Now(),
+	}
+	job.resumed = sync.NewCond(&job.mu)
+	m.jobs = append(m.jobs, job)
+	m.queue = append(m.queue, job)
+	m.trimHistory()
+	m.mu.Unlock()
+
+	m.notify(job)
+	m.runNext()
+	return job
+}
+
+// Hold pauses the whole queue (e.g. during quiet hours) or lets it carry on:
+// while held, the running job waits at its next checkpoint and queued jobs
+// don't start. Jobs paused one by one stay paused when the hold ends.
+func (m *Manager) Hold(held bool) {
+	if m.held.Swap(held) == held {
+		return
+	}
+
+	m.mu.Lock()
+	active := m.active
+	pending := append([]*Job{}, m.queue...)
+	m.mu.Unlock()
+
+	if active != nil {
+		active.mu.Lock()
+		active.resumed.Broadcast()
+		active.mu.Unlock()
+		m.notify(active)
+	}
+	for _, job := range pending {
+		m.notify(job)
+	}
+	if !held {
+		m.runNext()
+	}
+}
+
+// Held returns true while the queue is paused with Hold
+func (m *Manager) Held() bool {
+	return m.held.Load()
+}
+
+// runNext starts the next queued job if none is running and the queue isn't
+// held. Queued jobs that are paused are skipped until resumed.
+func (m *Manager) runNext() {
+	m.mu.Lock()
+	if m.active != nil || m.Held() {
+		m.mu.Unlock()
+		return
+	}
+	var job *Job
+	for i, q := range m.queue {
+		q.mu.Lock()
+		paused := q.paused
+		q.mu.Unlock()
+		if !paused {
+			job = q
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			break
+		}
+	}
+	if job == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.active = job
+	m.mu.Unlock()
+
+	job.mu.Lock()
+	job.state = Running
+	job.started = time.Now()
+	job.mu.Unlock()
+	m.notify(job)
+
+	go func() {
+		err := job.Checkpoint() // In case it was cancelled just before starting
+		if err == nil {
+			err = job.fn(job)
+		}
+		job.finish(err)
+
+		m.mu.Lock()
+		m.active = nil
+		m.mu.Unlock()
+
+		m.notify(job)
+		m.runNext()
+	}()
+}
+
+// trimHistory drops the oldest finished jobs beyond maxHistory. Call with m.mu held.
+func (m *Manager) trimHistory() {
+	for len(m.jobs) > maxHistory && m.jobs[0].Snapshot().Done() {
+		m.jobs = m.jobs[1:]
+	}
+}
+
+// find returns a job by ID. Call with m.mu held.
+func (m *Manager) find(id string) *Job {
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// Pause pauses a running or queued job at its next checkpoint
+func (m *Manager) Pause(id string) error {
+	return m.setPaused(id, true)
+}
+
+// Resume resumes a paused job
+func (m *Manager) Resume(id string) error {
+	return m.setPaused(id, false)
+}
+
+func (m *Manager) setPaused(id string, paused bool) error {
+	m.mu.Lock()
+	job := m.find(id)
+	m.mu.Unlock()
+	if job == nil {
+		return fmt.Errorf("no such job: %s", id)
+	}
+
+	job.mu.Lock()
+	if job.state != Running && job.state != Pending {
+		job.mu.Unlock()
+		return fmt.Errorf("job %s is %s", id, job.state)
+	}
+	job.paused = paused
+	job.resumed.Broadcast()
+	job.mu.Unlock()
+
+	m.notify(job)
+	if !paused {
+		m.runNext() // A resumed queued job may be able to start now
+	}
+	return nil
+}
+
+// Cancel stops a job. A queued job is removed from the queue; a running job
+// stops at its next checkpoint and its OnCancel functions interrupt its current step.
+func (m *Manager) Cancel(id string) error {
+	m.mu.Lock()
+	job := m.find(id)
+	if job == nil {
+		m.mu.Unlock()
+		return fmt.Errorf("no such job: %s", id)
+	}
+
+	queued := false
+	for i, q := range m.queue {
+		if q == job {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			queued = true
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	job.mu.Lock()
+	if job.state != Running && job.state != Pending {
+		job.mu.Unlock()
+		return fmt.Errorf("job %s is %s", id, job.state)
+	}
+	job.cancelled = true
+	job.resumed.Broadcast()
+	hooks := job.onCancel
+	job.onCancel = nil
+	job.mu.Unlock()
+
+	if queued {
+		job.finish(ErrCancelled)
+	}
+	for _, fn := range hooks {
+		fn()
+	}
+
+	m.notify(job)
+	return nil
+}
+
+// Active returns the running job, or nil
+func (m *Manager) Active() *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// Get returns the status of a job by ID
+func (m *Manager) Get(id string) (Snapshot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if job := m.find(id); job != nil {
+		return job.Snapshot(), true
+	}
+	return Snapshot{}, false
+}
+
+// List returns the status of all known jobs, oldest first
+func (m *Manager) List() []Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshots := make([]Snapshot, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		snapshots = append(snapshots, job.Snapshot())
+	}
+	return snapshots
+}
+
+// ClearFinished removes completed, failed and cancelled jobs from the history
+func (m *Manager) ClearFinished() {
+	m.mu.Lock()
+	var kept []*Job
+	for _, job := range m.jobs {
+		if !job.Snapshot().Done() {
+			kept = append(kept, job)
+		}
+	}
+	m.jobs = kept
+	m.mu.Unlock()
+}
+
+// OnChange registers a callback run (from the job's goroutine) whenever a job
+// is queued, starts, reports progress, is paused or finishes
+func (m *Manager) OnChange(fn func(Snapshot)) {
+	m.mu.Lock()
+	m.listeners = append(m.listeners, fn)
+	m.mu.Unlock()
+}
+
+// notify sends a job's status to the listeners
+func (m *Manager) notify(job *Job) {
+	m.mu.Lock()
+	listeners := append([]func(Snapshot){}, m.listeners...)
+	m.mu.Unlock()
+
+	snapshot := job.Snapshot()
+	for _, fn := range listeners {
+		fn(snapshot)
+	}
+}
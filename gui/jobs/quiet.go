@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily time range during which the job queue is held (see
+// Manager.Hold), e.g. 17:00-22:00 while the family uses the computer. A range
+// that ends before it starts runs past midnight. The zero value is no range.
+type QuietHours struct {
+	Start, End time.Duration // Since midnight
+}
+
+// ParseQuietHours parses a range like "17:00-22:00" ("" = none)
+func ParseQuietHours(text string) (QuietHours, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return QuietHours{}, nil
+	}
+	from, to, ok := strings.Cut(text, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("quiet hours %q should look like 17:00-22:00", text)
+	}
+	start, err := parseTimeOfDay(from)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	end, err := parseTimeOfDay(to)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	if start == end {
+		return QuietHours{}, fmt.Errorf("quiet hours %q start and end at the same time", text)
+	}
+	return QuietHours{Start: start, End: end}, nil
+}
+
+// parseTimeOfDay parses "HH:MM" (24-hour) as the time since midnight
+func parseTimeOfDay(text string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM, 24-hour)", strings.TrimSpace(text))
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsZero returns true if no range is set
+func (q QuietHours) IsZero() bool {
+	return q.Start == q.End
+}
+
+// Contains returns true if t's time of day (in its location) is within the range
+func (q QuietHours) Contains(t time.Time) bool {
+	if q.IsZero() {
+		return false
+	}
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if q.Start < q.End {
+		return now >= q.Start && now < q.End
+	}
+	return now >= q.Start || now < q.End
+}
+
+// String formats the range like ParseQuietHours takes it ("" = none)
+func (q QuietHours) String() string {
+	if q.IsZero() {
+		return ""
+	}
+	return formatTimeOfDay(q.Start) + "-" + formatTimeOfDay(q.End)
+}
+
+// EndString returns when the range ends, e.g. "22:00"
+func (q QuietHours) EndString() string {
+	return formatTimeOfDay(q.End)
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
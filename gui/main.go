@@ -1,20 +1,43 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"time"
+	_ "time/tzdata" // Time zone names for the camera clock zone, on systems without a zone database (Windows)
 
+	"gopro-gui/config"
 	"gopro-gui/ui"
 )
 
 func main() {
+	apiAddr := flag.String("api", "", "serve the local control API on this address (e.g. 127.0.0.1:8765)")
+	serveAddr := flag.String("serve", "", "serve a browser UI for scanning, extracting and combining on this address (e.g. 0.0.0.0:8080 for other machines on the network; a bare :port listens on 127.0.0.1 only)")
+	multiInstance := flag.Bool("multi-instance", false, "don't warn when another copy is already running")
+	watchFolder := flag.String("watch", "", "run without a window, processing each game folder copied into this drop folder")
+	settle := flag.Duration("settle", 2*time.Minute, "with -watch, how long a game folder must stay unchanged before it is processed")
+	portable := flag.Bool("portable", false, "keep settings, caches and logs in a data folder next to the executable (also on when portable.txt is there)")
+	flag.Parse()
+
+	config.SetPortable(*portable)
+
+	if *watchFolder != "" {
+		os.Exit(runWatch(*watchFolder, *settle))
+	}
+
 	app, err := ui.NewApp()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing app: %v\n", err)
-		fmt.Fprintf(os.Stderr, "\nPlease ensure ffmpeg.exe and ffprobe.exe are in the bin/ folder.\n")
-		fmt.Fprintf(os.Stderr, "Download from: https://www.gyan.dev/ffmpeg/builds/\n")
 		os.Exit(1)
 	}
 
+	if *apiAddr != "" {
+		app.SetAPIAddress(*apiAddr)
+	}
+	if *serveAddr != "" {
+		app.SetWebAddress(*serveAddr)
+	}
+	app.SetMultiInstance(*multiInstance)
 	app.Run()
 }
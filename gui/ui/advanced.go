@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+)
+
+// setAdvancedControls registers a step's controls for picking folders by
+// hand, which are only shown in Advanced mode (Config.AdvancedMode). Without
+// it, each step follows on from the one before: Step 3 edits and Step 4
+// combines the clips extracted in Step 2, and Step 5 exports the periods of
+// the folder opened in Step 1. A rebuilt step replaces its controls.
+func (a *App) setAdvancedControls(step int, controls ...fyne.CanvasObject) {
+	if a.advancedControls == nil {
+		a.advancedControls = make(map[int][]fyne.CanvasObject)
+	}
+	a.advancedControls[step] = controls
+	a.showAdvancedControls()
+}
+
+// showAdvancedControls shows or hides the steps' manual controls after
+// Advanced mode is turned on or off
+func (a *App) showAdvancedControls() {
+	for _, controls := range a.advancedControls {
+		for _, c := range controls {
+			if a.cfg.AdvancedMode {
+				c.Show()
+			} else {
+				c.Hide()
+			}
+		}
+	}
+}
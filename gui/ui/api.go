@@ -0,0 +1,314 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/api"
+	"gopro-gui/jobs"
+)
+
+// SetAPIAddress enables the local control API on addr (e.g. "127.0.0.1:8765")
+// when the app runs. Overrides the address in the config.
+func (a *App) SetAPIAddress(addr string) {
+	a.cfg.APIAddress = addr
+}
+
+// SetWebAddress serves the control API with its browser UI on addr (e.g.
+// "0.0.0.0:8080" for other machines on the network, see api.ListenAddress)
+// when the app runs
+func (a *App) SetWebAddress(addr string) {
+	a.webAddress = addr
+}
+
+// startAPI serves the control API in the background if an address is
+// configured, and the browser UI if one was set. Both share the app's jobs.
+func (a *App) startAPI() {
+	if a.cfg.APIAddress != "" && a.cfg.APIAddress != a.webAddress {
+		a.serveAPI(a.cfg.APIAddress, false)
+	}
+	if a.webAddress != "" {
+		a.serveAPI(a.webAddress, true)
+	}
+}
+
+// serveAPI serves the control API on addr, with the browser UI if web is set,
+// showing an error if it stops
+func (a *App) serveAPI(addr string, web bool) {
+	server := api.NewServer(a, a.jobs, a.apiToken)
+	title := "Control API"
+	if web {
+		server.ServeWebUI()
+		title = "Web UI"
+	}
+	go func() {
+		if err := server.ListenAndServe(addr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			fyne.Do(func() {
+				a.showError(title, err.Error())
+			})
+		}
+	}()
+}
+
+// Analysis implements api.Backend
+func (a *App) Analysis() *metadata.AnalysisResult {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.analysisResult
+}
+
+// webLink returns the address of the browser UI with the API token, for
+// another machine to open ("" = not served)
+func (a *App) webLink() string {
+	if a.webAddress == "" {
+		return ""
+	}
+	addr := api.ListenAddress(a.webAddress)
+	if host, port, err := net.SplitHostPort(addr); err == nil && (host == "0.0.0.0" || host == "::") {
+		if name, err := os.Hostname(); err == nil {
+			addr = net.JoinHostPort(name, port)
+		}
+	}
+	return "http://" + addr + "/#token=" + a.apiToken
+}
+
+// ScanFolder implements api.Backend: finds the periods in folder, inside the
+// API games folder, (extracting metadata from the GoPro MP4s where needed)
+// and analyzes them, as Step 1 does
+func (a *App) ScanFolder(folder string) (*jobs.Job, error) {
+	if a.cfg.APIGamesFolder == "" {
+		return nil, fmt.Errorf("no games folder is set for the API in Settings")
+	}
+	folder, err := api.Within(a.cfg.APIGamesFolder, folder)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("not a folder: %s", folder)
+	}
+
+	return a.runJob("analyze", "Analyze "+folder, func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		job.Update(0, "Scanning folder...")
+		scan, err := pipeline.ScanFolder(ff, folder, pipeline.ScanOptions{
+			Excluded:       a.cfg.ExcludedVideos,
+			DedupThreshold: a.cfg.DedupThreshold,
+			Split:          metadata.PeriodSplit{MinGap: time.Duration(a.cfg.PeriodSplitGap * float64(time.Minute))},
+			ClockZone:      a.clockLocation(),
+			SpeedBurstKmh:  a.cfg.SpeedBurstKmh,
+			AutoChapters:   a.cfg.AutoChapters(),
+			ManualStarts:   a.manualStarts(),
+		})
+		if err != nil {
+			return err
+		}
+
+		a.setAnalysis(scan.Analysis, scan.Periods, folder, 0)
+		fyne.Do(func() {
+			a.markStepComplete(stepSetup)
+		})
+
+		msg := fmt.Sprintf("Found %d chapters across %d periods", len(scan.Analysis.Chapters), len(scan.Periods))
+		if len(scan.Skipped) > 0 {
+			msg += fmt.Sprintf(" (%d MOV files skipped)", len(scan.Skipped))
+		}
+		job.Update(1, msg)
+		return nil
+	}), nil
+}
+
+// Extract implements api.Backend: extracts the requested chapters (all if none
+// are listed) with the configured or requested padding, into the output
+// layout's clip folder (or the working folder) or a folder inside it
+func (a *App) Extract(req api.ExtractRequest) (*jobs.Job, error) {
+	result := a.Analysis()
+	if result == nil {
+		return nil, fmt.Errorf("no analysis yet")
+	}
+	base := a.clipOutputFolder()
+	if base == "" {
+		a.sessionMu.Lock()
+		base = a.workingFolder
+		a.sessionMu.Unlock()
+	}
+	folder, err := api.Within(base, req.OutputFolder)
+	if err != nil {
+		return nil, fmt.Errorf("output_folder: %w", err)
+	}
+	req.OutputFolder = folder
+	if err := os.MkdirAll(req.OutputFolder, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	selected := pipeline.SelectChapters(result, req.Chapters)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("none of the requested chapters exist")
+	}
+
+	secBefore, secAfter := a.cfg.SecondsBefore, a.cfg.SecondsAfter
+	if req.SecondsBefore != nil {
+		secBefore = *req.SecondsBefore
+	}
+	if req.SecondsAfter != nil {
+		secAfter = *req.SecondsAfter
+	}
+	groups := a.groupChapters(selected, secBefore, secAfter)
+
+	return a.runJob("extract", fmt.Sprintf("Extract %d clips", len(groups)), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		completed, err := a.extractGroups(job, ff, groups, req.OutputFolder, req.StreamCopy, false, nil)
+		if completed > 0 {
+			fyne.Do(func() {
+				a.markStepComplete(stepExtract)
+			})
+		}
+		if err != nil {
+			return err
+		}
+		job.Update(1, fmt.Sprintf("Extracted %d clips to %s", completed, req.OutputFolder))
+		return nil
+	}), nil
+}
+
+// Clips implements api.Backend
+func (a *App) Clips() []string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return append([]string(nil), a.extractedClips...)
+}
+
+// ReelPath implements api.Backend
+func (a *App) ReelPath() string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.reelPath
+}
+
+// Combine implements api.Backend: combines the requested clips (all from the
+// last extraction if none are listed, and only those) into one reel inside
+// the output layout's reel folder (or the clips' folder), in the configured order,
+// with the intro and outro, tags and chapter titles Step 4 would use. A
+// re-encoded reel also gets the watermark and Step 3's audio changes, and is
+// conformed to the clips' most common format.
+func (a *App) Combine(req api.CombineRequest) (*jobs.Job, error) {
+	extracted := a.Clips()
+	if len(extracted) == 0 {
+		return nil, fmt.Errorf("no clips extracted yet")
+	}
+	clips := extracted
+	if len(req.Clips) > 0 {
+		known := make(map[string]bool, len(extracted))
+		for _, clip := range extracted {
+			known[filepath.Clean(clip)] = true
+		}
+		clips = nil
+		for _, clip := range req.Clips {
+			if !known[filepath.Clean(clip)] {
+				return nil, fmt.Errorf("%s isn't one of the extracted clips (see GET /api/clips)", clip)
+			}
+			clips = append(clips, filepath.Clean(clip))
+		}
+	}
+	for _, clip := range clips {
+		if _, err := os.Stat(clip); err != nil {
+			return nil, fmt.Errorf("clip not found: %w", err)
+		}
+	}
+	clips = a.orderClips(clips)
+	if pending := len(a.reelAudio(clips)); pending > 0 && !req.Reencode {
+		return nil, fmt.Errorf("%d clips have audio changes from Step 3 that haven't been applied; re-encode the reel or apply them first", pending)
+	}
+
+	dir := a.reelOutputFolder()
+	if dir == "" {
+		dir = filepath.Dir(clips[0])
+	}
+	name := req.Output
+	if name == "" {
+		name = fmt.Sprintf("combined_%s.mp4", time.Now().Format("2006-01-02_15-04"))
+	}
+	output, err := api.Within(dir, name)
+	if err != nil {
+		return nil, fmt.Errorf("output: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output folder: %w", err)
+	}
+	if !strings.HasSuffix(strings.ToLower(output), ".mp4") {
+		output += ".mp4"
+	}
+	crf := "23"
+	if req.CRF > 0 {
+		crf = strconv.Itoa(req.CRF)
+	}
+	stillDuration := a.cfg.BumperStillDuration
+	if stillDuration <= 0 {
+		stillDuration = 3.0
+	}
+
+	return a.runJob("combine", "Combine reel "+filepath.Base(output), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		job.Update(0, fmt.Sprintf("Combining %d clips...", len(clips)))
+		batch := a.newReport("combine")
+		err := func() error {
+			reelInputs, cleanupBumpers, err := a.addBumpers(ff, clips, a.cfg.IntroPath, a.cfg.OutroPath, stillDuration, a.reelMontage(clips))
+			if err != nil {
+				return err
+			}
+			defer cleanupBumpers()
+
+			captured := a.reelCaptureTime(clips)
+			tags := a.captureTags(a.reelTags(), captured)
+			if req.Reencode {
+				opts := ffmpeg.ReelOptions{
+					Conform:       ff.MajorityConform(clips),
+					Watermark:     a.reelWatermark(),
+					Tags:          tags,
+					ChapterTitles: a.reelChapterTitles(clips),
+					Audio:         a.reelAudio(clips),
+					MusicPath:     a.cfg.MusicBedPath,
+				}
+				err = ff.WriteOutput(output, func(path string) error {
+					return ff.ConcatClipsWithEncode(reelInputs, path, crf, false, 0, opts)
+				})
+			} else {
+				err = ff.WriteOutput(output, func(path string) error {
+					return ff.ConcatClipsWithTitles(reelInputs, path, tags, a.reelChapterTitles(clips))
+				})
+			}
+			if err != nil {
+				return err
+			}
+			if err := a.stampCaptureTime(output, captured); err != nil {
+				return err
+			}
+			return a.writeReelDescription(output, reelInputs, clips, ffmpeg.Transition{})
+		}()
+		item := pipeline.ReportItem{Inputs: clips, Output: output}
+		if err != nil {
+			item.Error = err.Error()
+			batch.Error = err.Error()
+		}
+		batch.Items = append(batch.Items, item)
+		a.writeReport(filepath.Dir(output), batch)
+		if err != nil {
+			return err
+		}
+
+		a.setReelPath(output)
+		fyne.Do(func() {
+			a.markStepComplete(stepCombine)
+		})
+		job.Update(1, "Combined "+output)
+		return nil
+	}), nil
+}
@@ -1,14 +1,21 @@
 package ui
 
 import (
+	"fmt"
+	"os"
+	"sync"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/api"
 	"gopro-gui/config"
-	"gopro-gui/ffmpeg"
-	"gopro-gui/metadata"
+	"gopro-gui/jobs"
 )
 
 // App represents the main application
@@ -23,87 +30,228 @@ type App struct {
 	periods                []metadata.Period // Periods detected in Step 1
 	analysisResult         *metadata.AnalysisResult
 	extractedClips         []string // Clip files created in Step 2
+	clipEdits              map[string]config.ClipEdit // Step 3 timing edits by clip path
+	periodColors           map[string]ffmpeg.ColorCorrection // Color correction by period name
+	periodRotations        map[string]int // Rotation overrides (degrees clockwise) by period name
+	clipGroups             map[string]metadata.ClipGroup // Highlights each extracted clip covers, by clip path
+	deselected             map[string]bool // Chapters unticked in Step 2, by metadata.Chapter.Key
+	clipRatings            map[string]int // Step 4 star ratings (1-5) by clip path
+	clipPans               map[string]float64 // Vertical crop positions (0 = left, 1 = right) by clip path
+	clipNotes              map[string]string  // Step 3 notes by the clip's first highlight (Chapter.Key)
+	clipPlayers            map[string][]string // Jersey numbers tagged in Step 3 by the clip's first highlight (see roster.go)
+	clipAudio              map[string]ffmpeg.ClipAudio // Step 3 audio choices (mute, quieter, music bed) by clip path
+	exportTrims            map[string]ffmpeg.Trim // Step 5 full game in/out points by MOV path
+	reelPath               string // Last reel combined in Step 4
+	gameInfo               metadata.GameInfo // Teams, date, location and final score entered in Step 1 (see game_info.go)
+	scoreTimeline          string // Score changes for the scoreboard, as entered
+	clockZone              string // Time zone the camera clock was set to ("" = this computer's)
+	manualTimecodes        map[string]string // Start times (HH:MM:SS) entered in Step 1 by video path
+	game                   int // Game worked on when the folder holds several (0 = the only game)
+	history                []ffmpeg.HistoryEntry // ffmpeg commands run for this game, oldest first (see history.go)
+	padding                *config.PaddingPreset // Padding chosen in Step 2 for this game (nil = not chosen yet)
+	audioTrack             int // Source audio track the clips are cut with (0 = the first, see audio_track.go)
+
+	// games are the working folder's games when Step 1 split it into several,
+	// with the work on each game not currently open, by game number (see games.go)
+	games        []*metadata.AnalysisResult
+	gameSessions map[int]*config.Session
+
+	// sessionMu guards the shared state while it is auto-saved (see session.go)
+	sessionMu sync.Mutex
+
+	// instance is this copy's claim on a slot among those running at once
+	// (see instance.go), with its own temp folder; multiInstance skips the
+	// warning about the others
+	instance      *config.Instance
+	tempDir       string
+	multiInstance bool
+
+	// workDirs are the work folders opened in each project folder, by folder
+	// (see workdir.go)
+	workDirMu sync.Mutex
+	workDirs  map[string]*ffmpeg.WorkDir
+
+	// firstRun is set until the setup wizard saves the first config (see setup.go)
+	firstRun bool
+
+	// jobs runs long operations one at a time, shared with the control API
+	jobs *jobs.Manager
+
+	// webAddress serves the control API with the browser UI (see api.go)
+	webAddress string
+	// apiToken authorizes control API requests, made anew each launch
+	apiToken string
+
+	// quietOverride lets jobs run until the current quiet hours end (see quiet_hours.go)
+	quietOverride bool
+
+	// thumbs caches chapter thumbnails for Step 2's grid view
+	thumbs *thumbnailCache
+
+	// actions are the step actions run by keyboard shortcuts
+	actions stepActions
+
+	// settingsSync copies changed settings into each step (see settings.go)
+	settingsSync map[int]func()
+
+	// advancedControls are each step's controls shown in Advanced mode (see advanced.go)
+	advancedControls map[int][]fyne.CanvasObject
+
+	// Step status, by step index (see stale.go): stepDone for the tab title's
+	// tick, stale for why a step's results are out of date, with its banner.
+	// clipPadding is the padding the clips were extracted with (nil = unknown).
+	stepDone     map[int]bool
+	stale        map[int]string
+	staleBanners map[int]*fyne.Container
+	clipPadding  *padding
 
 	// Tab references for status updates
-	tabs     *container.AppTabs
-	tabItems []*container.TabItem
+	tabs        *container.AppTabs
+	tabItems    []*container.TabItem
+	reviewTab   *container.TabItem // Rebuilt each time it is selected
+	settingsTab *container.TabItem // Rebuilt each time it is selected
+	storageTab  *container.TabItem // Rebuilt each time it is selected
 }
 
 // NewApp creates a new application instance
 func NewApp() (*App, error) {
-	ff, err := ffmpeg.New()
-	if err != nil {
-		return nil, err
-	}
-
 	cfg, err := config.Load()
 	if err != nil {
 		cfg = config.DefaultConfig()
 	}
 
-	return &App{
-		ff:  ff,
-		cfg: cfg,
-	}, nil
+	a := &App{
+		cfg:      cfg,
+		jobs:     jobs.NewManager(),
+		firstRun: !config.Exists(),
+		apiToken: api.NewToken(),
+	}
+	a.claimInstance()
+
+	// Without ffmpeg, the setup wizard asks for one before the steps are shown
+	if ff, err := findFFmpeg(cfg); err == nil {
+		a.useFFmpeg(ff)
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return a, nil
 }
 
 // Run starts the application
 func (a *App) Run() {
 	a.fyneApp = app.NewWithID("com.gopro-clip-extractor")
 	a.window = a.fyneApp.NewWindow("GoPro Clip Extractor")
+	a.applyTheme()
 	a.window.Resize(fyne.NewSize(1000, 700))
 
+	// Offer to restore a session interrupted by a crash, then keep auto-saving
+	startSession := func() {
+		a.offerSessionRestore()
+		a.startAutoSave()
+		a.startAPI()
+		a.startQuietHours()
+		a.checkPresetArgs()
+	}
+	setup := a.firstRun || a.ff == nil
+	if setup {
+		a.window.SetContent(a.createSetupWizard(func() {
+			a.showSteps()
+			startSession()
+		}))
+	} else {
+		a.showSteps()
+	}
+
+	a.fyneApp.Lifecycle().SetOnStarted(func() {
+		a.confirmInstance(func() {
+			if !setup { // Otherwise the wizard starts the session when it's done
+				startSession()
+			}
+		})
+	})
+
+	a.window.SetOnClosed(func() {
+		if a.tabs != nil { // Closed during setup: ask again next launch
+			a.cfg.Save()
+		}
+		config.ClearSession() // Clean exit, nothing to recover
+		a.releaseInstance()
+	})
+
+	a.window.ShowAndRun()
+}
+
+// showSteps builds the step tabs into the window
+func (a *App) showSteps() {
 	// Create tab items and store references for status updates
+	a.reviewTab = container.NewTabItem("Review", a.createReviewTab())
+	a.settingsTab = container.NewTabItem("Settings", a.createSettingsTab())
+	a.storageTab = container.NewTabItem("Storage", a.createStorageTab(nil, ""))
 	a.tabItems = []*container.TabItem{
-		container.NewTabItem("1. Setup", a.createStep1Setup()),
-		container.NewTabItem("2. Extract Clips", a.createStep2Extract()),
-		container.NewTabItem("3. Edit Clips", a.createStep3Edit()),
-		container.NewTabItem("4. Combine", a.createStep4Combine()),
-		container.NewTabItem("5. Export Full Game", a.createStep5Export()),
+		container.NewTabItem(stepTitles[stepSetup], a.createStep1Setup()),
+		container.NewTabItem(stepTitles[stepExtract], a.withStaleBanner(stepExtract, a.createStep2Extract())),
+		container.NewTabItem(stepTitles[stepEdit], a.withStaleBanner(stepEdit, a.createStep3Edit())),
+		container.NewTabItem(stepTitles[stepCombine], a.withStaleBanner(stepCombine, a.createStep4Combine())),
+		container.NewTabItem(stepTitles[stepExport], a.createStep5Export()),
+		a.reviewTab,
+		container.NewTabItem("Jobs", a.createJobsTab()),
+		a.storageTab,
+		a.settingsTab,
 	}
 
 	// Create the tabbed interface
 	a.tabs = container.NewAppTabs(a.tabItems...)
 	a.tabs.SetTabLocation(container.TabLocationTop)
+	a.tabs.OnSelected = func(tab *container.TabItem) {
+		switch tab {
+		case a.reviewTab:
+			a.refreshReviewTab()
+		case a.storageTab:
+			a.refreshStorageTab(nil, "")
+		case a.settingsTab:
+			// Pick up options changed elsewhere (e.g. the Metadata Tags dialog)
+			a.settingsTab.Content = a.createSettingsTab()
+			a.tabs.Refresh()
+		}
+	}
 
 	a.window.SetContent(a.tabs)
-	a.window.SetOnClosed(func() {
-		a.cfg.Save()
+	a.setupShortcuts()
+	a.setupDrop()
+
+	// Tell the user when encodes fall back to CPU, and why
+	a.ff.SetFallbackHandler(func(e *ffmpeg.EncoderError) {
+		fyne.Do(func() {
+			a.showInfo("Using CPU Encoding",
+				"Fell back to CPU because: "+e.Reason()+"\n\nEncoding will be much slower than with the GPU.")
+		})
 	})
 
-	a.window.ShowAndRun()
+	// Keep the commands run with the project, so they can be run again
+	a.ff.SetHistoryHandler(a.addHistory)
+
+	a.ff.SetRoughSeekWindow(a.cfg.RoughSeekWindow)
+
+	// Check the GPU encoder once up front so failing NVENC isn't retried for every clip
+	go a.ff.CheckNVENC()
 }
 
-// markStepComplete updates a tab title to show completion status
+// markStepComplete updates a tab title to show completion status. A step
+// done again is no longer out of date.
 func (a *App) markStepComplete(stepIndex int) {
-	if stepIndex < 0 || stepIndex >= len(a.tabItems) {
-		return
-	}
-	titles := []string{
-		"1. Setup",
-		"2. Extract Clips",
-		"3. Edit Clips",
-		"4. Combine",
-		"5. Export Full Game",
+	if a.stepDone == nil {
+		a.stepDone = make(map[int]bool)
 	}
-	a.tabItems[stepIndex].Text = titles[stepIndex] + " ✓"
-	a.tabs.Refresh()
+	a.stepDone[stepIndex] = true
+	delete(a.stale, stepIndex)
+	a.showStale(stepIndex)
+	a.updateStepTitle(stepIndex)
 }
 
 // markStepIncomplete resets a tab title to show incomplete status
 func (a *App) markStepIncomplete(stepIndex int) {
-	if stepIndex < 0 || stepIndex >= len(a.tabItems) {
-		return
-	}
-	titles := []string{
-		"1. Setup",
-		"2. Extract Clips",
-		"3. Edit Clips",
-		"4. Combine",
-		"5. Export Full Game",
-	}
-	a.tabItems[stepIndex].Text = titles[stepIndex]
-	a.tabs.Refresh()
+	delete(a.stepDone, stepIndex)
+	a.updateStepTitle(stepIndex)
 }
 
 // showError displays an error dialog
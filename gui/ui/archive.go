@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/config"
+	"gopro-gui/jobs"
+)
+
+// archivePlan is what archiving a project's GoPro originals would move, and
+// anything that has to be done first
+type archivePlan struct {
+	files    []string
+	size     int64
+	problems []string // Nothing is moved while there are any
+}
+
+// planArchive finds the GoPro MP4 originals of a project (in its working
+// folder, and wherever its periods were read from, e.g. an SD card) and
+// checks that the game no longer needs them: every period is cut from a
+// converted MOV that is there, each original has its MOV (or the combined
+// file of its split recording), and the clips have been extracted.
+func planArchive(session *config.Session) archivePlan {
+	var plan archivePlan
+	listed := make(map[string]bool)
+	add := func(path string) {
+		size := fileSize(path)
+		if size < 0 || !pipeline.IsRawGoPro(path) || listed[filepath.Clean(path)] {
+			return
+		}
+		listed[filepath.Clean(path)] = true
+		plan.files = append(plan.files, path)
+		plan.size += size
+	}
+
+	names := make(map[string]bool)
+	if entries, err := os.ReadDir(session.WorkingFolder); err == nil {
+		for _, entry := range entries {
+			names[strings.ToLower(entry.Name())] = true
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				add(filepath.Join(session.WorkingFolder, entry.Name()))
+			}
+		}
+	}
+	for _, p := range session.Periods {
+		add(p.SourceGoPro)
+	}
+
+	if len(session.Periods) == 0 {
+		plan.problems = append(plan.problems, "The folder hasn't been analyzed yet.")
+	}
+	for _, p := range session.Periods {
+		switch {
+		case p.IsDirect():
+			plan.problems = append(plan.problems, fmt.Sprintf("%s is cut straight from %s. Convert it to a MOV first.",
+				p.Name, filepath.Base(p.VideoFile)))
+		case fileSize(p.VideoFile) < 0:
+			plan.problems = append(plan.problems, fmt.Sprintf("%s: %s is missing.", p.Name, filepath.Base(p.VideoFile)))
+		case pipeline.IsRawGoPro(p.MetadataFile):
+			plan.problems = append(plan.problems, fmt.Sprintf("%s reads its HiLights from %s. Extract its metadata in Step 1 first.",
+				p.Name, filepath.Base(p.MetadataFile)))
+		}
+	}
+
+	for _, path := range plan.files {
+		name := filepath.Base(path)
+		baseName := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+		combined := strings.ToLower(name[:2] + "_combined_" + name[4:8])
+		if names[baseName+".mov"] || names[combined+".mov"] || names[combined+".mp4"] {
+			continue
+		}
+		plan.problems = append(plan.problems, fmt.Sprintf("%s has no converted MOV in the working folder.", name))
+	}
+
+	missing := 0
+	for _, clip := range session.ExtractedClips {
+		if fileSize(clip) < 0 {
+			missing++
+		}
+	}
+	switch {
+	case len(session.ExtractedClips) == 0:
+		plan.problems = append(plan.problems, "No clips have been extracted yet.")
+	case missing > 0:
+		plan.problems = append(plan.problems, fmt.Sprintf("%d of %d extracted clips are missing.", missing, len(session.ExtractedClips)))
+	}
+	return plan
+}
+
+// archiveOriginals checks a project's GoPro originals can go (see
+// planArchive), asks where to, and moves them there as a job, each verified
+// by its SHA-256 before the original is deleted, with a manifest listing
+// them. onDone is called once the job ends.
+func (a *App) archiveOriginals(session *config.Session, onDone func()) {
+	plan := planArchive(session)
+	if len(plan.problems) > 0 {
+		a.showError("Can't Archive Yet", "The originals are still needed:\n\n- "+strings.Join(plan.problems, "\n- "))
+		return
+	}
+	if len(plan.files) == 0 {
+		a.showInfo("Nothing to Archive", "No GoPro MP4 originals were found for this game.")
+		return
+	}
+
+	folderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		root := uri.Path()
+		if len(root) > 2 && root[0] == '/' && root[2] == ':' {
+			root = root[1:]
+		}
+		a.cfg.ArchiveFolder = root
+		a.cfg.Save()
+
+		folder := filepath.Join(root, filepath.Base(session.WorkingFolder))
+		message := fmt.Sprintf("Move %d GoPro originals (%s) to %s?\n\n"+
+			"Each file is copied, checked against the original with SHA-256, and only then deleted. "+
+			"The clips and MOVs stay where they are. Re-analyzing the game afterwards won't pick up "+
+			"HiLights tagged in Quik or GPS speed bursts, which are read from the MP4s.",
+			len(plan.files), formatSize(float64(plan.size)), folder)
+		dialog.ShowConfirm("Archive Originals", message, func(ok bool) {
+			if ok {
+				a.runArchive(session, plan, folder, onDone)
+			}
+		}, a.window)
+	}, a.window)
+	if a.cfg.ArchiveFolder != "" {
+		if dir, err := storage.ListerForURI(storage.NewFileURI(a.cfg.ArchiveFolder)); err == nil {
+			folderDialog.SetLocation(dir)
+		}
+	}
+	folderDialog.Show()
+}
+
+// runArchive moves the files of plan to folder as a job
+func (a *App) runArchive(session *config.Session, plan archivePlan, folder string, onDone func()) {
+	game := session.GameInfo.Name
+	if game == "" {
+		game = filepath.Base(session.WorkingFolder)
+	}
+
+	title := fmt.Sprintf("Archive %d GoPro originals", len(plan.files))
+	a.runJob("archive", title, func(job *jobs.Job, _ *ffmpeg.FFmpeg) error {
+		manifest, manifestPath, err := pipeline.ArchiveOriginals(plan.files, folder, game, func(done, total int64) error {
+			if err := job.Checkpoint(); err != nil {
+				return err
+			}
+			job.Update(float64(done)/float64(total), fmt.Sprintf("Archived %s of %s...",
+				formatSize(float64(done)), formatSize(float64(total))))
+			return nil
+		})
+		fyne.Do(func() {
+			onDone()
+			if err != nil {
+				return
+			}
+			a.showInfo("Originals Archived", fmt.Sprintf("Moved and verified %d files. The manifest with their checksums is %s.",
+				len(manifest.Files), manifestPath))
+		})
+		if err != nil && len(manifest.Files) > 0 {
+			return fmt.Errorf("archived %d of %d files: %w", len(manifest.Files), len(plan.files), err)
+		}
+		return err
+	})
+}
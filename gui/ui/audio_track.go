@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// setAudioTrack records which of the videos' audio streams the game's clips
+// are cut with, applies it and saves the session. Clips already extracted
+// with another track are flagged as out of date.
+func (a *App) setAudioTrack(index int) {
+	a.sessionMu.Lock()
+	changed := index != a.audioTrack
+	a.audioTrack = index
+	a.sessionMu.Unlock()
+	if !changed {
+		return
+	}
+
+	a.applyAudioTrack()
+	a.saveSession()
+	if len(a.extractedClips) > 0 {
+		a.markStale(stepExtract, "the audio track was changed after the clips were extracted")
+	}
+}
+
+// applyAudioTrack passes the game's audio track to ffmpeg
+func (a *App) applyAudioTrack() {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	a.ff.SetAudioTrack(a.audioTrack)
+}
+
+// newAudioTrackSelect returns Step 2's audio track choice. The period videos
+// are probed in the background and the one with the most audio streams lists
+// them; with a single stream (or none) there's nothing to choose.
+func (a *App) newAudioTrackSelect() *widget.Select {
+	var tracks []ffmpeg.AudioTrack
+	sel := widget.NewSelect(nil, func(selected string) {
+		for _, track := range tracks {
+			if track.Label() == selected {
+				a.setAudioTrack(track.Index)
+			}
+		}
+	})
+	sel.PlaceHolder = "Probing..."
+	sel.Disable()
+
+	a.sessionMu.Lock()
+	var videos []string
+	for _, p := range a.periods {
+		videos = append(videos, p.VideoFile)
+	}
+	current := a.audioTrack
+	a.sessionMu.Unlock()
+
+	go func() {
+		var found []ffmpeg.AudioTrack
+		for _, video := range videos {
+			if probed, err := a.ff.GetAudioTracks(video); err == nil && len(probed) > len(found) {
+				found = probed
+			}
+		}
+		fyne.Do(func() {
+			tracks = found
+			var options []string
+			for _, track := range tracks {
+				options = append(options, track.Label())
+			}
+			sel.Options = options
+			switch {
+			case len(tracks) == 0:
+				sel.PlaceHolder = "No audio"
+			case current < len(tracks):
+				sel.Selected = options[current]
+			default:
+				sel.Selected = options[0] // Not in these videos: ffmpeg falls back to the first too
+			}
+			if len(tracks) > 1 {
+				sel.Enable()
+			}
+			sel.Refresh()
+		})
+	}()
+	return sel
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// captionModes are the Step 4 caption choices, by config.ReelCaptions value
+var captionModes = []struct {
+	value string
+	label string
+}{
+	{"none", "None"},
+	{"srt", "SRT file next to the reel"},
+	{"embedded", "Subtitle track in the reel"},
+	{"both", "SRT file and subtitle track"},
+}
+
+// captionModeLabel returns the display label of a caption mode
+func captionModeLabel(value string) string {
+	for _, m := range captionModes {
+		if m.value == value {
+			return m.label
+		}
+	}
+	return captionModes[0].label
+}
+
+// captionModeValue returns the caption mode with the given display label
+func captionModeValue(label string) string {
+	for _, m := range captionModes {
+		if m.label == label {
+			return m.value
+		}
+	}
+	return "none"
+}
+
+// clipCaption returns the caption for a clip: the caption template expanded
+// for its highlights, or the file name if they aren't known
+func (a *App) clipCaption(clipPath string) string {
+	group, ok := a.clipGroup(clipPath)
+	if !ok {
+		return strings.TrimSuffix(filepath.Base(clipPath), filepath.Ext(clipPath))
+	}
+	return metadata.ExpandTemplate(a.cfg.CaptionTemplate, a.groupTemplateValues(group))
+}
+
+// reelCaptions times a caption for each of the clips among a reel's inputs,
+// combined with the given transition
+func (a *App) reelCaptions(reelInputs, clips []string, transition ffmpeg.Transition) ([]ffmpeg.Caption, error) {
+	texts := make(map[string]string, len(clips))
+	for _, clip := range clips {
+		texts[clip] = a.clipCaption(clip)
+	}
+	return a.ff.ReelCaptionsWithTransition(reelInputs, texts, transition)
+}
+
+// writeReelCaptions adds captions to a combined reel as the mode asks: a .srt
+// file with the reel's name, a subtitle track in the reel, or both
+func (a *App) writeReelCaptions(ff *ffmpeg.FFmpeg, reelPath string, captions []ffmpeg.Caption, mode string) error {
+	if mode == "srt" || mode == "both" {
+		srtPath := strings.TrimSuffix(reelPath, filepath.Ext(reelPath)) + ".srt"
+		if err := os.WriteFile(srtPath, []byte(ffmpeg.FormatSRT(captions)), 0644); err != nil {
+			return fmt.Errorf("failed to write captions: %w", err)
+		}
+	}
+	if mode == "embedded" || mode == "both" {
+		// Remuxed to a partial file and moved over the reel, like any other output
+		return ff.WriteOutput(reelPath, func(path string) error {
+			return ff.EmbedCaptions(reelPath, path, captions)
+		})
+	}
+	return nil
+}
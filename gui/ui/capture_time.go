@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// captureTags adds at, when the footage was recorded, to an output's tags as
+// its creation_time, if Settings says to date outputs that way
+func (a *App) captureTags(tags ffmpeg.Tags, at time.Time) ffmpeg.Tags {
+	if !a.cfg.CaptureTimes {
+		return tags
+	}
+	return tags.WithCreationTime(at)
+}
+
+// stampCaptureTime sets a written output's modification time to at, when its
+// footage was recorded, if Settings says to date outputs that way
+func (a *App) stampCaptureTime(path string, at time.Time) error {
+	if !a.cfg.CaptureTimes || a.ff.DryRun() {
+		return nil
+	}
+	return ffmpeg.SetFileTime(path, at)
+}
+
+// reelCaptureTime returns when the earliest of a reel's clips was recorded:
+// from the highlights it was cut from, or for a clip from another session, its
+// own creation_time. The zero time if none is known.
+func (a *App) reelCaptureTime(clips []string) time.Time {
+	var earliest time.Time
+	for _, clip := range clips {
+		var at time.Time
+		if group, ok := a.clipGroup(clip); ok {
+			at = group.CaptureTime()
+		} else if created, err := a.ff.GetCreationTime(clip); err == nil {
+			at = created
+		}
+		if !at.IsZero() && (earliest.IsZero() || at.Before(earliest)) {
+			earliest = at
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// renameChapter sets a chapter's title in the analysis and in the clips
+// already extracted from it, so their reels and re-extractions use it
+func (a *App) renameChapter(ch metadata.Chapter, title string) {
+	a.sessionMu.Lock()
+	key := ch.Key()
+	for i := range a.analysisResult.Chapters {
+		if a.analysisResult.Chapters[i].Key() == key {
+			a.analysisResult.Chapters[i].Title = title
+		}
+	}
+	for path, group := range a.clipGroups {
+		renamed := false
+		for i := range group.Chapters {
+			if group.Chapters[i].Key() == key {
+				group.Chapters[i].Title = title
+				renamed = true
+			}
+		}
+		if group.PrimaryChapter.Key() == key {
+			group.PrimaryChapter.Title = title
+		}
+		if renamed {
+			a.clipGroups[path] = group
+		}
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// showRenameChapter shows a dialog for a chapter's title. onSave is called
+// after it is changed.
+func (a *App) showRenameChapter(ch metadata.Chapter, onSave func()) {
+	entry := widget.NewEntry()
+	entry.SetText(ch.Title)
+	entry.SetPlaceHolder(fmt.Sprintf("Ch%02d", ch.Number))
+
+	content := widget.NewForm(widget.NewFormItem("Title", entry))
+	d := dialog.NewCustomConfirm(
+		fmt.Sprintf("Rename %s Ch%02d", ch.Period, ch.Number), "Save", "Cancel", content, func(save bool) {
+			if !save {
+				return
+			}
+			a.renameChapter(ch, strings.TrimSpace(entry.Text))
+			if onSave != nil {
+				onSave()
+			}
+		}, a.window)
+	d.Resize(fyne.NewSize(400, 160))
+	d.Show()
+}
+
+// reelChapterTitles returns the current chapter titles of the given clips, so
+// chapters renamed since a clip was extracted are renamed in the reel too. A
+// clip's Step 3 note is added to the title of its first chapter.
+func (a *App) reelChapterTitles(clips []string) ffmpeg.ChapterTitles {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	titles := make(ffmpeg.ChapterTitles)
+	for _, clip := range clips {
+		group, ok := a.clipGroups[clip]
+		if !ok {
+			continue
+		}
+		for i, ch := range group.GetClipChapters() {
+			title := ch.Title
+			if i == 0 {
+				title = metadata.AppendNote(title, "", a.clipNotes[group.PrimaryChapter.Key()])
+			}
+			titles[clip] = append(titles[clip], title)
+		}
+	}
+	return titles
+}
+
+// retagClip rewrites an extracted clip's metadata tags (e.g. after its note
+// changed) without re-extracting it. The clip keeps its creation_time, and
+// its file date when clips are dated by their footage.
+func (a *App) retagClip(clipPath string, group metadata.ClipGroup) error {
+	tags := a.clipTags(group)
+	info, err := os.Stat(clipPath)
+	if err != nil {
+		return err
+	}
+	err = a.ff.WriteOutput(clipPath, func(path string) error {
+		return a.ff.RetagClip(clipPath, path, tags)
+	})
+	if err == nil {
+		err = a.stampCaptureTime(clipPath, info.ModTime())
+	}
+	return err
+}
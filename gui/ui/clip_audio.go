@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// clipAudioLabels are the clip audio options shown in Step 3, by option
+var clipAudioLabels = map[ffmpeg.ClipAudio]string{
+	ffmpeg.AudioKeep:  "Camera sound",
+	ffmpeg.AudioQuiet: "Quieter (-12 dB)",
+	ffmpeg.AudioMute:  "Muted",
+	ffmpeg.AudioMusic: "Music bed",
+}
+
+// clipAudioOption returns the clip audio option with the given label
+func clipAudioOption(label string) ffmpeg.ClipAudio {
+	for audio, l := range clipAudioLabels {
+		if l == label {
+			return audio
+		}
+	}
+	return ffmpeg.AudioKeep
+}
+
+// newClipAudioSelect returns a select for what is done with a clip's sound,
+// set to audio
+func newClipAudioSelect(audio ffmpeg.ClipAudio, changed func(ffmpeg.ClipAudio)) *widget.Select {
+	var options []string
+	for _, option := range ffmpeg.ClipAudios {
+		options = append(options, clipAudioLabels[option])
+	}
+	sel := widget.NewSelect(options, nil)
+	sel.SetSelected(clipAudioLabels[audio])
+	sel.OnChanged = func(selected string) {
+		changed(clipAudioOption(selected))
+	}
+	return sel
+}
+
+// setClipAudio records what is done with a clip's sound and saves the session
+func (a *App) setClipAudio(clipPath string, audio ffmpeg.ClipAudio) {
+	a.sessionMu.Lock()
+	if a.clipAudio == nil {
+		a.clipAudio = make(map[string]ffmpeg.ClipAudio)
+	}
+	if audio != ffmpeg.AudioKeep {
+		a.clipAudio[clipPath] = audio
+	} else {
+		delete(a.clipAudio, clipPath)
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// clipAudioFor returns what is done with a clip's sound (kept unless set)
+func (a *App) clipAudioFor(clipPath string) ffmpeg.ClipAudio {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.clipAudio[clipPath]
+}
+
+// reelAudio returns the audio choices a reel still has to apply to its clips:
+// those of clips not re-extracted with them in Step 3. A clip whose sound was
+// already changed can't get the camera's sound back without re-extracting, so
+// only muting and the music bed, which don't need it, are applied to those.
+func (a *App) reelAudio(clips []string) map[string]ffmpeg.ClipAudio {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	audio := make(map[string]ffmpeg.ClipAudio)
+	for _, clip := range clips {
+		wanted := a.clipAudio[clip]
+		applied := a.clipEdits[clip].Audio
+		if wanted == applied {
+			continue
+		}
+		if applied == ffmpeg.AudioKeep || wanted == ffmpeg.AudioMute || wanted == ffmpeg.AudioMusic {
+			audio[clip] = wanted
+		}
+	}
+	return audio
+}
+
+// selectMusicBed asks for the music played instead of the camera's sound in
+// clips set to "Music bed", then calls done with it
+func (a *App) selectMusicBed(done func(path string)) {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		path := reader.URI().Path()
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		a.cfg.MusicBedPath = path
+		a.cfg.Save()
+		done(path)
+	}, a.window)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".mp3", ".m4a", ".aac", ".wav", ".flac", ".ogg"}))
+	if a.cfg.MusicBedPath != "" {
+		if dir, err := storage.ListerForURI(storage.NewFileURI(filepath.Dir(a.cfg.MusicBedPath))); err == nil {
+			fileDialog.SetLocation(dir)
+		}
+	}
+	fileDialog.Show()
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// clipFacts is what the Step 4 order strategies know about a clip
+type clipFacts struct {
+	path   string
+	period string // Short period name ("P2", "" if unknown)
+	rating int    // Star rating (0 = unrated)
+	kind   int    // playGoal, playChance or playOther, from its highlights' labels
+}
+
+// Kinds of play a clip shows, in the order "Goals first" puts them
+const (
+	playGoal = iota
+	playChance
+	playOther
+)
+
+// chanceWords mark a highlight label as a scoring chance
+var chanceWords = []string{"chance", "shot", "save", "post", "breakaway", "odd man", "2-on-1", "3-on-2"}
+
+// clipOrders are the Step 4 orders for the reel, by config.ClipOrder value.
+// Each arranges clips given in chronological order; sorts are stable, so clips
+// that rank the same stay chronological.
+var clipOrders = []struct {
+	value   string
+	label   string
+	arrange func(clips []clipFacts) []clipFacts
+}{
+	{"chronological", "Chronological", func(clips []clipFacts) []clipFacts {
+		return clips
+	}},
+	{"rating", "Rating (best first)", func(clips []clipFacts) []clipFacts {
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].rating > clips[j].rating })
+		return clips
+	}},
+	{"goals", "Goals, then chances", func(clips []clipFacts) []clipFacts {
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].kind < clips[j].kind })
+		return clips
+	}},
+	{"periods", "Periods taking turns", arrangeRoundRobin},
+}
+
+// clipOrderLabel returns the display label of a clip order
+func clipOrderLabel(value string) string {
+	for _, o := range clipOrders {
+		if o.value == value {
+			return o.label
+		}
+	}
+	return clipOrders[0].label
+}
+
+// clipOrderValue returns the clip order with the given display label
+func clipOrderValue(label string) string {
+	for _, o := range clipOrders {
+		if o.label == label {
+			return o.value
+		}
+	}
+	return "chronological"
+}
+
+// arrangeRoundRobin takes one clip from each period in turn (the first of P1,
+// the first of P2, ..., then the second of each), so the reel moves through
+// the game rather than playing one period out. Clips of unknown period come last.
+func arrangeRoundRobin(clips []clipFacts) []clipFacts {
+	var periods []string
+	byPeriod := make(map[string][]clipFacts)
+	var unknown []clipFacts
+	for _, c := range clips {
+		if c.period == "" {
+			unknown = append(unknown, c)
+			continue
+		}
+		if _, ok := byPeriod[c.period]; !ok {
+			periods = append(periods, c.period)
+		}
+		byPeriod[c.period] = append(byPeriod[c.period], c)
+	}
+
+	result := make([]clipFacts, 0, len(clips))
+	for len(result) < len(clips)-len(unknown) {
+		for _, p := range periods {
+			if len(byPeriod[p]) > 0 {
+				result = append(result, byPeriod[p][0])
+				byPeriod[p] = byPeriod[p][1:]
+			}
+		}
+	}
+	return append(result, unknown...)
+}
+
+// orderClips puts clips in the reel order chosen in Step 4. Clips are first
+// put in chronological (file name) order.
+func (a *App) orderClips(clips []string) []string {
+	sorted := append([]string{}, clips...)
+	sort.Strings(sorted)
+
+	facts := make([]clipFacts, len(sorted))
+	for i, clip := range sorted {
+		facts[i] = clipFacts{
+			path:   clip,
+			period: a.clipPeriod(clip),
+			rating: a.clipRating(clip),
+			kind:   a.clipKind(clip),
+		}
+	}
+
+	for _, o := range clipOrders {
+		if o.value == a.cfg.ClipOrder {
+			facts = o.arrange(facts)
+			break
+		}
+	}
+
+	result := make([]string, len(facts))
+	for i, f := range facts {
+		result[i] = f.path
+	}
+	return result
+}
+
+// clipKind classifies a clip as a goal, a chance or other play from the labels
+// and titles of its highlights (e.g. imported from a stat sheet)
+func (a *App) clipKind(clipPath string) int {
+	group, ok := a.clipGroup(clipPath)
+	if !ok {
+		return playOther
+	}
+
+	kind := playOther
+	for _, ch := range group.Chapters {
+		text := strings.ToLower(ch.Label + " " + ch.Title)
+		for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			if word == "goal" || word == "goals" { // Not "goalie"
+				return playGoal
+			}
+		}
+		for _, word := range chanceWords {
+			if strings.Contains(text, word) {
+				kind = playChance
+			}
+		}
+	}
+	return kind
+}
+
+// ratingLabels are the Step 4 rating choices, indexed by stars
+var ratingLabels = []string{"Unrated", "1 star", "2 stars", "3 stars", "4 stars", "5 stars"}
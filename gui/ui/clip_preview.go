@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// lastFrameOffset is how far before a clip's end its last frame is taken from,
+// so the frame is inside the clip rather than the first one after it
+const lastFrameOffset = 0.05
+
+// clipBounds returns where a clip with the given timing starts and ends in its
+// period's video, in seconds (as doExtractClip cuts it)
+func (a *App) clipBounds(ce *clipEditEntry, timing clipTiming) (float64, float64) {
+	secBefore, secAfter := timing.seconds(a.cfg.SecondsBefore, a.cfg.SecondsAfter)
+	startSec := max(ce.chapter.VideoTime.Seconds()-secBefore, 0)
+	return startSec, startSec + secBefore + secAfter
+}
+
+// sourceFrame returns the video file and position of a frame atSec into a
+// period's video, following on into the next GoPro chapter file past its end
+func (a *App) sourceFrame(period string, atSec float64) (string, float64) {
+	if span, spans := a.spanSource(period, 0, atSec+lastFrameOffset); spans && atSec >= span.FirstDuration {
+		return span.NextPath, atSec - span.FirstDuration
+	}
+	return a.analysisResult.GetPeriodVideoFile(period), atSec
+}
+
+// showClipPreview shows the first and last frames of a clip with its current
+// timing next to those with the timing entered, so a trim can be checked
+// before it is re-extracted. onDone is passed to reExtractClip.
+func (a *App) showClipPreview(ce *clipEditEntry, onDone func()) {
+	if a.thumbs == nil {
+		a.thumbs = newThumbnailCache(a.ff)
+	}
+
+	frame := func(atSec float64) fyne.CanvasObject {
+		img := canvas.NewImageFromResource(theme.FileVideoIcon())
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(240, 135))
+
+		// Finding the file probes the video, so it's done off the UI thread
+		go func() {
+			videoFile, sec := a.sourceFrame(ce.chapter.Period, atSec)
+			var load func()
+			load = func() {
+				if path, ok := a.thumbs.get(videoFile, sec, load); ok {
+					fyne.Do(func() {
+						img.Resource = nil
+						img.File = path
+						img.Refresh()
+					})
+				}
+			}
+			load()
+		}()
+
+		caption := widget.NewLabel(formatDuration(atSec))
+		caption.Alignment = fyne.TextAlignCenter
+		return container.NewBorder(nil, caption, nil, nil, img)
+	}
+
+	row := func(title string, timing clipTiming) []fyne.CanvasObject {
+		secBefore, secAfter := timing.seconds(a.cfg.SecondsBefore, a.cfg.SecondsAfter)
+		start, end := a.clipBounds(ce, timing)
+		label := widget.NewLabel(fmt.Sprintf("%s\n%.1fs before\n%.1fs after", title, secBefore, secAfter))
+		return []fyne.CanvasObject{label, frame(start), frame(max(end-lastFrameOffset, start))}
+	}
+
+	cells := []fyne.CanvasObject{
+		widget.NewLabel(""),
+		widget.NewLabelWithStyle("First frame", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Last frame", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+	}
+	cells = append(cells, row("Current", ce.applied)...)
+	cells = append(cells, row("New", ce.timing())...)
+	grid := container.NewGridWithColumns(3, cells...)
+
+	note := widget.NewLabel("")
+	if !ce.dirty() {
+		note.SetText("The timing hasn't been changed - both rows show the clip as it is.")
+	}
+
+	d := dialog.NewCustomConfirm("Preview "+ce.card.Title, "Re-Extract", "Close",
+		container.NewVBox(grid, note), func(reExtract bool) {
+			if reExtract {
+				a.reExtractClip(ce, onDone)
+			}
+		}, a.window)
+	d.Resize(fyne.NewSize(700, 480))
+	d.Show()
+}
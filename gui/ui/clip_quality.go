@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// clipQualityOption is a clip quality in the selects, with the average
+// bitrate assumed for the Step 2 size estimate (GoPro footage)
+type clipQualityOption struct {
+	label string
+	mbps  float64
+}
+
+// clipQualityOptions are the clip qualities shown, by quality
+var clipQualityOptions = map[ffmpeg.ClipQuality]clipQualityOption{
+	ffmpeg.ClipHigh:     {"High (CRF 18) - final clips", reencodeEstimateMbps},
+	ffmpeg.ClipBalanced: {"Balanced (CRF 21) - about half the size", reencodeEstimateMbps / 2},
+	ffmpeg.ClipDraft:    {"Draft (CRF 27, fastest) - quick rough cuts", reencodeEstimateMbps / 5},
+}
+
+// newClipQualitySelect returns a select for the quality of re-encoded clips
+// (reels pick their own in Step 4), saving the choice to the config
+func (a *App) newClipQualitySelect() *widget.Select {
+	var options []string
+	for _, quality := range ffmpeg.ClipQualities {
+		options = append(options, clipQualityOptions[quality].label)
+	}
+
+	sel := widget.NewSelect(options, func(selected string) {
+		for quality, option := range clipQualityOptions {
+			if option.label == selected && a.ff.ClipQuality() != quality {
+				a.cfg.ClipQuality = string(quality)
+				a.ff.SetClipQuality(quality)
+				a.cfg.Save()
+			}
+		}
+	})
+	sel.SetSelected(clipQualityOptions[a.ff.ClipQuality()].label)
+	return sel
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseClockZone checks a time zone name as entered in Step 1 ("" or "Local"
+// = this computer's zone)
+func parseClockZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "local") {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q - use a name like America/Chicago", name)
+	}
+	return loc, nil
+}
+
+// clockLocation returns the zone the camera's clock was set to for the current
+// game (nil = this computer's)
+func (a *App) clockLocation() *time.Location {
+	a.sessionMu.Lock()
+	name := a.clockZone
+	a.sessionMu.Unlock()
+
+	loc, _ := parseClockZone(name)
+	return loc
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/cloud"
+	"gopro-gui/jobs"
+)
+
+// cloudClient returns a client for the cloud account set in Settings. New
+// sign-in tokens are saved to the config.
+func (a *App) cloudClient() (*cloud.Client, error) {
+	return cloud.NewClient(a.cfg.Cloud, func(token cloud.Token) {
+		fyne.Do(func() {
+			a.cfg.Cloud.Token = token
+			a.cfg.Save()
+		})
+	})
+}
+
+// connectCloud signs in to the cloud account in the browser, calling onDone
+// (on the UI thread) once it has finished, whether it worked or not
+func (a *App) connectCloud(onDone func()) {
+	client, err := a.cloudClient()
+	if err != nil {
+		a.showError("Cloud Upload", err.Error())
+		return
+	}
+	go func() {
+		err := client.Authorize(context.Background(), func(link string) error {
+			u, err := url.Parse(link)
+			if err != nil {
+				return err
+			}
+			fyne.DoAndWait(func() {
+				err = a.fyneApp.OpenURL(u)
+			})
+			return err
+		})
+		fyne.Do(func() {
+			if err != nil {
+				a.showError("Cloud Sign-in Failed", err.Error())
+			}
+			if onDone != nil {
+				onDone()
+			}
+		})
+	}()
+}
+
+// uploadToCloud queues a job uploading files to the cloud folder. Once it's
+// done the links are copied to the clipboard, ready to paste in the team
+// chat; files that failed (after the request retries) can be tried again.
+func (a *App) uploadToCloud(paths []string) (*jobs.Job, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("nothing to upload")
+	}
+	client, err := a.cloudClient()
+	if err != nil {
+		return nil, err
+	}
+	provider := cloud.ProviderName(a.cfg.Cloud.Provider)
+	if !a.cfg.Cloud.Connected() {
+		return nil, fmt.Errorf("not signed in to %s (click Connect in Settings)", provider)
+	}
+
+	title := fmt.Sprintf("Upload %s to %s", filepath.Base(paths[0]), provider)
+	if len(paths) > 1 {
+		title = fmt.Sprintf("Upload %d files to %s", len(paths), provider)
+	}
+	return a.runJob("upload", title, func(job *jobs.Job, _ *ffmpeg.FFmpeg) error {
+		var links, failures, failed []string
+		for i, path := range paths {
+			if err := job.Checkpoint(); err != nil {
+				return err
+			}
+			name := filepath.Base(path)
+			job.Update(float64(i)/float64(len(paths)), "Uploading "+name)
+			link, err := client.Upload(context.Background(), path, func(sent, total int64) {
+				done := 1.0
+				if total > 0 {
+					done = float64(sent) / float64(total)
+				}
+				job.Update((float64(i)+done)/float64(len(paths)), fmt.Sprintf("Uploading %s (%.0f of %.0f MB)",
+					name, float64(sent)/(1024*1024), float64(total)/(1024*1024)))
+			}, job.Checkpoint)
+			if errors.Is(err, jobs.ErrCancelled) {
+				return err
+			}
+			if err != nil {
+				failures = append(failures, err.Error())
+				failed = append(failed, path)
+				continue
+			}
+			if len(paths) > 1 {
+				link = name + ": " + link
+			}
+			links = append(links, link)
+		}
+
+		fyne.Do(func() {
+			if len(links) > 0 {
+				a.fyneApp.Clipboard().SetContent(strings.Join(links, "\n"))
+			}
+			if len(failed) == 0 {
+				a.showInfo("Upload Complete", fmt.Sprintf("Uploaded to %s. The link was copied to the clipboard:\n\n%s",
+					provider, strings.Join(links, "\n")))
+				return
+			}
+			message := fmt.Sprintf("%d of %d uploads to %s failed:\n\n%s", len(failed), len(paths), provider, strings.Join(failures, "\n"))
+			if len(links) > 0 {
+				message += "\n\nThe links of the files that made it were copied to the clipboard."
+			}
+			dialog.ShowConfirm("Upload Failed", message+"\n\nTry the failed files again?", func(retry bool) {
+				if retry {
+					a.startUpload(failed)
+				}
+			}, a.window)
+		})
+		if len(failed) > 0 {
+			return fmt.Errorf("%d of %d uploads failed: %s", len(failed), len(paths), strings.Join(failures, "; "))
+		}
+		return nil
+	}), nil
+}
+
+// startUpload uploads files to the cloud folder, showing why if it can't
+func (a *App) startUpload(paths []string) {
+	if _, err := a.uploadToCloud(paths); err != nil {
+		a.showError("Cloud Upload", err.Error())
+	}
+}
+
+// autoUpload uploads the files an operation wrote if Settings says to upload
+// after it. Files that weren't written (e.g. a vertical copy turned off) are
+// skipped.
+func (a *App) autoUpload(enabled bool, paths ...string) {
+	if !enabled {
+		return
+	}
+	var existing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	if len(existing) > 0 {
+		a.startUpload(existing)
+	}
+}
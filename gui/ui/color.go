@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// periodColor returns the color correction for a period's clips (nil if none)
+func (a *App) periodColor(period string) *ffmpeg.ColorCorrection {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	color, ok := a.periodColors[period]
+	if !ok || color.IsZero() {
+		return nil
+	}
+	return &color
+}
+
+// setPeriodColors replaces the per-period color corrections and saves the session
+func (a *App) setPeriodColors(colors map[string]ffmpeg.ColorCorrection) {
+	a.sessionMu.Lock()
+	a.periodColors = make(map[string]ffmpeg.ColorCorrection)
+	for period, color := range colors {
+		if !color.IsZero() {
+			a.periodColors[period] = color
+		}
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// previewTime returns a point in the period's video to preview the grade at:
+// its first HiLight if there is one, otherwise a minute in
+func (a *App) previewTime(period string) float64 {
+	if a.analysisResult != nil {
+		for _, ch := range a.analysisResult.Chapters {
+			if ch.Period == period {
+				return ch.VideoTime.Seconds()
+			}
+		}
+	}
+	return 60
+}
+
+// showColorSettings shows a dialog for setting each period's LUT, exposure,
+// white balance and rotation, with a preview frame
+func (a *App) showColorSettings() {
+	if len(a.periods) == 0 {
+		a.showError("No Periods", "Please scan a working folder in Step 1 first.")
+		return
+	}
+
+	// Edit a copy so Cancel discards changes to every period
+	edits := make(map[string]ffmpeg.ColorCorrection)
+	rotations := make(map[string]int) // -1 = Auto
+	videoFiles := make(map[string]string)
+	var names []string
+	for _, p := range a.periods {
+		names = append(names, p.Name)
+		videoFiles[p.Name] = p.VideoFile
+		if color := a.periodColor(p.Name); color != nil {
+			edits[p.Name] = *color
+		}
+		rotations[p.Name] = a.periodRotation(p.Name)
+	}
+	current := ""
+	loading := false // Suppresses edits while a period's values are loaded into the controls
+
+	lutLabel := widget.NewLabel("(none)")
+	exposureLabel := widget.NewLabel("")
+	exposureSlider := widget.NewSlider(-3, 3)
+	exposureSlider.Step = 0.1
+	tempLabel := widget.NewLabel("")
+	tempSlider := widget.NewSlider(3000, 10000)
+	tempSlider.Step = 100
+
+	update := func(change func(c *ffmpeg.ColorCorrection)) {
+		if loading || current == "" {
+			return
+		}
+		color := edits[current]
+		change(&color)
+		edits[current] = color
+	}
+
+	exposureSlider.OnChanged = func(v float64) {
+		exposureLabel.SetText(fmt.Sprintf("%+.1f stops", v))
+		update(func(c *ffmpeg.ColorCorrection) { c.Exposure = v })
+	}
+	tempSlider.OnChanged = func(v float64) {
+		tempLabel.SetText(fmt.Sprintf("%.0fK", v))
+		update(func(c *ffmpeg.ColorCorrection) { c.Temperature = v })
+	}
+
+	rotationFlagLabel := widget.NewLabel("")
+	rotationSelect := widget.NewSelect(rotationOptions, func(option string) {
+		if loading || current == "" {
+			return
+		}
+		rotations[current] = rotationDegrees(option)
+	})
+
+	selectLUTBtn := widget.NewButton("Select LUT (.cube)", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			lutLabel.SetText(filepath.Base(path))
+			update(func(c *ffmpeg.ColorCorrection) { c.LUTPath = path })
+		}, a.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".cube"}))
+		fileDialog.Show()
+	})
+	clearLUTBtn := widget.NewButton("Clear", func() {
+		lutLabel.SetText("(none)")
+		update(func(c *ffmpeg.ColorCorrection) { c.LUTPath = "" })
+	})
+
+	resetBtn := widget.NewButton("Reset Period", func() {
+		if current == "" {
+			return
+		}
+		delete(edits, current)
+		rotations[current] = -1
+		loading = true
+		rotationSelect.SetSelected("Auto")
+		lutLabel.SetText("(none)")
+		exposureSlider.SetValue(0)
+		tempSlider.SetValue(ffmpeg.NeutralTemperature)
+		loading = false
+	})
+
+	previewImage := canvas.NewImageFromResource(nil)
+	previewImage.FillMode = canvas.ImageFillContain
+	previewImage.SetMinSize(fyne.NewSize(480, 270))
+	previewStatus := widget.NewLabel("")
+
+	var previewPath string
+	previewBtn := widget.NewButton("Preview", func() {
+		if current == "" {
+			return
+		}
+		videoFile := videoFiles[current]
+		color := edits[current]
+		// Preview with the rotation being edited; the saved ones are put back on close
+		a.ff.SetRotationOverride(videoFile, rotations[current])
+		atSec := a.previewTime(current)
+		previewStatus.SetText("Rendering preview...")
+
+		go func() {
+			tmp, err := os.CreateTemp(a.ff.TempDir(), "gopro-color-preview-*.jpg")
+			if err != nil {
+				fyne.Do(func() { previewStatus.SetText("Error: " + err.Error()) })
+				return
+			}
+			tmp.Close()
+
+			err = a.ff.ExtractCorrectedFrame(videoFile, tmp.Name(), atSec, 960, &color)
+			fyne.Do(func() {
+				if err != nil {
+					os.Remove(tmp.Name())
+					previewStatus.SetText("Preview failed: " + err.Error())
+					return
+				}
+				if previewPath != "" {
+					os.Remove(previewPath)
+				}
+				previewPath = tmp.Name()
+				previewImage.File = previewPath
+				previewImage.Refresh()
+				previewStatus.SetText(fmt.Sprintf("%s at %.0fs", current, atSec))
+			})
+		}()
+	})
+
+	periodSelect := widget.NewSelect(names, func(name string) {
+		current = name
+		color := edits[name]
+
+		loading = true
+		lutLabel.SetText("(none)")
+		if color.LUTPath != "" {
+			lutLabel.SetText(filepath.Base(color.LUTPath))
+		}
+		exposureSlider.SetValue(color.Exposure)
+		temp := color.Temperature
+		if temp == 0 {
+			temp = ffmpeg.NeutralTemperature
+		}
+		tempSlider.SetValue(temp)
+		rotationSelect.SetSelected(rotationOption(rotations[name]))
+		loading = false
+
+		rotationFlagLabel.SetText("")
+		videoFile := videoFiles[name]
+		go func() {
+			flag := a.describeRotationFlag(videoFile)
+			fyne.Do(func() {
+				if current == name {
+					rotationFlagLabel.SetText("(video is " + flag + ")")
+				}
+			})
+		}()
+	})
+	periodSelect.SetSelected(names[0])
+
+	content := container.NewVBox(
+		container.NewHBox(widget.NewLabel("Period:"), periodSelect, resetBtn),
+		container.NewHBox(widget.NewLabel("LUT:"), lutLabel, selectLUTBtn, clearLUTBtn),
+		container.NewBorder(nil, nil, widget.NewLabel("Exposure:"), exposureLabel, exposureSlider),
+		container.NewBorder(nil, nil, widget.NewLabel("White balance:"), tempLabel, tempSlider),
+		widget.NewLabel("Lower = warmer, higher = cooler. Applied to re-encoded clips only."),
+		container.NewHBox(widget.NewLabel("Rotation:"), rotationSelect, rotationFlagLabel),
+		widget.NewLabel("Auto follows the video's rotation flag. Re-encoded clips are turned; stream-copied clips get the flag."),
+		container.NewHBox(previewBtn, previewStatus),
+		previewImage,
+	)
+
+	d := dialog.NewCustomConfirm("Color & Rotation", "Save", "Cancel", content, func(save bool) {
+		if previewPath != "" {
+			os.Remove(previewPath)
+		}
+		if save {
+			a.setPeriodColors(edits)
+			a.setPeriodRotations(rotations)
+		} else {
+			a.applyRotations()
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(600, 680))
+	d.Show()
+}
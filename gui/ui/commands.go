@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// commandOptions holds the "show command" and "dry run" toggles shown on the
+// extract, combine and export steps
+type commandOptions struct {
+	showCheck *widget.Check
+	dryCheck  *widget.Check
+}
+
+// newCommandOptions creates the toggles for one step
+func newCommandOptions() *commandOptions {
+	return &commandOptions{
+		showCheck: widget.NewCheck("Show ffmpeg commands", nil),
+		dryCheck:  widget.NewCheck("Dry run (check inputs and show the plan, don't encode)", nil),
+	}
+}
+
+// row returns the toggles laid out for a step
+func (o *commandOptions) row() fyne.CanvasObject {
+	return container.NewHBox(o.showCheck, o.dryCheck)
+}
+
+// dryRun returns true if the operation should only be planned
+func (o *commandOptions) dryRun() bool {
+	return o.dryCheck.Checked
+}
+
+// beginCommands starts recording ffmpeg commands for an operation
+func (a *App) beginCommands(opts *commandOptions) {
+	a.ff.TakeCommands() // Discard anything recorded earlier
+	a.ff.SetDryRun(opts.dryRun())
+}
+
+// endCommands stops recording and, if requested, shows the commands that were
+// run (or would have been, in a dry run). Call from the UI thread.
+func (a *App) endCommands(opts *commandOptions, title string) {
+	cmds := a.ff.TakeCommands()
+	a.ff.SetDryRun(false)
+
+	if !opts.showCheck.Checked && !opts.dryRun() {
+		return
+	}
+	a.showCommands(title, cmds, opts.dryRun())
+}
+
+// showCommands displays ffmpeg command lines in a copyable text box
+func (a *App) showCommands(title string, cmds []string, dryRun bool) {
+	heading := fmt.Sprintf("%d ffmpeg commands run:", len(cmds))
+	if dryRun {
+		heading = fmt.Sprintf("Dry run - %d ffmpeg commands would run (nothing was encoded):", len(cmds))
+	}
+
+	text := widget.NewMultiLineEntry()
+	text.SetText(strings.Join(cmds, "\n\n"))
+	text.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(widget.NewLabel(heading), nil, nil, nil, text)
+	d := dialog.NewCustom(title, "Close", content, a.window)
+	d.Resize(fyne.NewSize(900, 550))
+	d.Show()
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// frameSeekLead is taken off a frame's timestamp when its thumbnail is
+// extracted, so the millisecond rounding of the seek can't land on the frame
+// after it
+const frameSeekLead = 0.001
+
+// formatFrameTime formats a position in a video to the millisecond, e.g. "12:04.517"
+func formatFrameTime(sec float64) string {
+	ms := int(sec*1000 + 0.5)
+	return fmt.Sprintf("%d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
+
+// cutPoints are the exact first and last frames of a clip, where they are in
+// the period's video and in which file (a clip can run on into the next
+// GoPro chapter file)
+type cutPoints struct {
+	firstFile, lastFile string
+	first, last         float64 // Positions in firstFile and lastFile
+	firstSec, lastSec   float64 // Positions in the period's video
+}
+
+// clipCutPoints finds the frames a clip group will start and end on when it
+// is extracted, re-encoded or stream copied (see ffmpeg.FirstFrame). Probes
+// the video, so it must not run on the UI thread.
+func (a *App) clipCutPoints(group metadata.ClipGroup, streamCopy bool) (cutPoints, error) {
+	videoFile := a.analysisResult.GetPeriodVideoFile(group.Period)
+	cut := cutPoints{firstFile: videoFile, lastFile: videoFile}
+
+	endSec := group.StartTime + group.Duration
+	lastSec := endSec
+	var lastOffset float64 // Where lastFile starts in the period's video
+	if span, spans := a.spanSource(group.Period, group.StartTime, group.Duration); spans {
+		cut.lastFile = span.NextPath
+		lastSec = endSec - span.FirstDuration
+		lastOffset = span.FirstDuration
+	}
+
+	var err error
+	if cut.first, err = a.ff.FirstFrame(cut.firstFile, group.StartTime, streamCopy); err != nil {
+		return cut, err
+	}
+	if cut.last, err = a.ff.LastFrame(cut.lastFile, lastSec); err != nil {
+		return cut, err
+	}
+	cut.firstSec = cut.first
+	cut.lastSec = cut.last + lastOffset
+	return cut, nil
+}
+
+// showCutPreview shows the exact first and last frames a clip group will
+// have once extracted, next to its highlight's frame, so it can be checked
+// that the play is fully inside the clip before extracting
+func (a *App) showCutPreview(group metadata.ClipGroup, streamCopy bool) {
+	if a.thumbs == nil {
+		a.thumbs = newThumbnailCache(a.ff)
+	}
+
+	image := func() *canvas.Image {
+		img := canvas.NewImageFromResource(theme.FileVideoIcon())
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(240, 135))
+		return img
+	}
+	load := func(img *canvas.Image, videoFile string, atSec float64) {
+		var get func()
+		get = func() {
+			if path, ok := a.thumbs.get(videoFile, max(atSec-frameSeekLead, 0), get); ok {
+				fyne.Do(func() {
+					img.Resource = nil
+					img.File = path
+					img.Refresh()
+				})
+			}
+		}
+		get()
+	}
+
+	firstImg, highlightImg, lastImg := image(), image(), image()
+	firstCaption, lastCaption := widget.NewLabel("Finding frame..."), widget.NewLabel("Finding frame...")
+	firstCaption.Alignment = fyne.TextAlignCenter
+	lastCaption.Alignment = fyne.TextAlignCenter
+	primary := group.PrimaryChapter
+	highlightCaption := widget.NewLabel(formatFrameTime(primary.VideoTime.Seconds()))
+	highlightCaption.Alignment = fyne.TextAlignCenter
+
+	cell := func(title string, img *canvas.Image, caption *widget.Label) fyne.CanvasObject {
+		heading := widget.NewLabelWithStyle(title, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		return container.NewBorder(heading, caption, nil, nil, img)
+	}
+	grid := container.NewGridWithColumns(3,
+		cell("First frame", firstImg, firstCaption),
+		cell(fmt.Sprintf("HiLight Ch%02d", primary.Number), highlightImg, highlightCaption),
+		cell("Last frame", lastImg, lastCaption),
+	)
+
+	mode := "Re-encoded clips start on the exact frame."
+	if streamCopy {
+		mode = "Stream-copied clips can only start on a keyframe, so they start on the one at or before the cut."
+	}
+	note := widget.NewLabel(mode)
+	note.Wrapping = fyne.TextWrapWord
+
+	// Finding the frames probes the video, so it's done off the UI thread
+	go func() {
+		videoFile, sec := a.sourceFrame(primary.Period, primary.VideoTime.Seconds())
+		load(highlightImg, videoFile, sec)
+
+		cut, err := a.clipCutPoints(group, streamCopy)
+		if err != nil {
+			fyne.Do(func() {
+				firstCaption.SetText("")
+				lastCaption.SetText("")
+				note.SetText("Could not find the cut frames: " + err.Error())
+			})
+			return
+		}
+		load(firstImg, cut.firstFile, cut.first)
+		load(lastImg, cut.lastFile, cut.last)
+
+		lines := []string{mode}
+		if lead := group.StartTime - cut.firstSec; lead > 0.001 {
+			lines = append(lines, fmt.Sprintf("The keyframe is %.2fs before the cut at %s, so the clip has that much more lead-in.",
+				lead, formatFrameTime(group.StartTime)))
+		}
+		for _, ch := range group.Chapters {
+			at := ch.VideoTime.Seconds()
+			switch {
+			case at < cut.firstSec || at > cut.lastSec:
+				lines = append(lines, fmt.Sprintf("Ch%02d at %s is outside the clip.", ch.Number, formatFrameTime(at)))
+			default:
+				lines = append(lines, fmt.Sprintf("Ch%02d is %.2fs after the first frame and %.2fs before the last.",
+					ch.Number, at-cut.firstSec, cut.lastSec-at))
+			}
+		}
+		fyne.Do(func() {
+			firstCaption.SetText(formatFrameTime(cut.firstSec))
+			lastCaption.SetText(formatFrameTime(cut.lastSec))
+			note.SetText(strings.Join(lines, "\n"))
+		})
+	}()
+
+	title := fmt.Sprintf("Cut Points - %s Ch%02d", primary.Period, primary.Number)
+	if len(group.Chapters) > 1 {
+		title += fmt.Sprintf(" (+%d merged)", len(group.Chapters)-1)
+	}
+	d := dialog.NewCustom(title, "Close", container.NewVBox(grid, note), a.window)
+	d.Resize(fyne.NewSize(800, 420))
+	d.Show()
+}
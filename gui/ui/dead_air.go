@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/jobs"
+)
+
+// deadAirTrim is the tighter timing proposed for one clip
+type deadAirTrim struct {
+	ce                    *clipEditEntry
+	before, after         float64 // Timing the clip file was cut with
+	trimBefore, trimAfter float64 // Timing without the dead air
+}
+
+// changed reports whether the trim cuts anything off (at the entries' 0.1 s)
+func (t deadAirTrim) changed() bool {
+	return math.Abs(t.before-t.trimBefore) >= 0.05 || math.Abs(t.after-t.trimAfter) >= 0.05
+}
+
+// trimDeadAir checks clips for dead air at their start and end (see
+// Config.DeadAir) as a job, then stages the tighter timing in their entries,
+// to be applied like any timing edit. A single clip opens its preview;
+// several list what was trimmed, each with its own preview.
+func (a *App) trimDeadAir(entries []*clipEditEntry, statusLabel *widget.Label, onDone func()) {
+	// The clip files were cut with the applied timing, whatever is entered now
+	trims := make([]deadAirTrim, len(entries))
+	for i, ce := range entries {
+		before, after := ce.applied.seconds(a.cfg.SecondsBefore, a.cfg.SecondsAfter)
+		trims[i] = deadAirTrim{ce: ce, before: before, after: after}
+		ce.statusLabel.SetText("Checking for dead air...")
+	}
+	opts := a.cfg.DeadAir()
+
+	total := len(trims)
+	title := fmt.Sprintf("Find dead air in %d clips", total)
+	if total == 1 {
+		title = "Find dead air in " + filepath.Base(entries[0].clipPath)
+	}
+	job := a.runJob("deadair", title, func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		failed := 0
+		for i := range trims {
+			if err := job.Checkpoint(); err != nil {
+				return err
+			}
+			t := &trims[i]
+			dead, err := ff.DetectDeadAir(t.ce.clipPath, opts)
+			if err != nil {
+				failed++
+				fyne.Do(func() { t.ce.statusLabel.SetText("Error: " + errorSummary(err.Error())) })
+				continue
+			}
+			t.trimBefore, t.trimAfter = dead.Trim(t.before, t.after)
+			if !t.changed() {
+				fyne.Do(func() { t.ce.statusLabel.SetText("No dead air") })
+			}
+			progress := fmt.Sprintf("Checked %d/%d clips for dead air...", i+1, total)
+			job.Update(float64(i+1)/float64(total), progress)
+			fyne.Do(func() { statusLabel.SetText(progress) })
+		}
+
+		fyne.Do(func() {
+			var trimmed []deadAirTrim
+			for _, t := range trims {
+				if t.changed() {
+					t.ce.beforeEntry.SetText(fmt.Sprintf("%.1f", t.trimBefore))
+					t.ce.afterEntry.SetText(fmt.Sprintf("%.1f", t.trimAfter))
+					trimmed = append(trimmed, t)
+				}
+			}
+			onDone()
+			statusLabel.SetText(fmt.Sprintf("Trimmed dead air from %d of %d clips. Preview, then Apply All Changes.", len(trimmed), total))
+			switch {
+			case len(trimmed) == 1 && total == 1:
+				a.showClipPreview(trimmed[0].ce, onDone)
+			case len(trimmed) > 0:
+				a.showDeadAirTrims(trimmed, onDone)
+			}
+		})
+		if failed > 0 {
+			return fmt.Errorf("%d of %d clips couldn't be checked for dead air", failed, total)
+		}
+		return nil
+	})
+	a.showQueued(job, statusLabel)
+}
+
+// showDeadAirTrims lists the clips trimmed by trimDeadAir, with how much
+// came off each end and a preview of each
+func (a *App) showDeadAirTrims(trims []deadAirTrim, onDone func()) {
+	list := container.NewVBox()
+	for _, t := range trims {
+		t := t
+		text := fmt.Sprintf("%s: %.1fs off the start, %.1fs off the end",
+			filepath.Base(t.ce.clipPath), t.before-t.trimBefore, t.after-t.trimAfter)
+		list.Add(container.NewBorder(nil, nil, nil,
+			widget.NewButton("Preview", func() { a.showClipPreview(t.ce, onDone) }),
+			widget.NewLabel(text)))
+	}
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(560, 300))
+	content := container.NewBorder(
+		widget.NewLabel("The new timing is staged on each clip. Undo one by typing its old timing back in."),
+		nil, nil, nil, scroll)
+	dialog.ShowCustom("Dead Air Trimmed", "Close", content, a.window)
+}
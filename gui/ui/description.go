@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// descriptionPath returns where the YouTube description of a reel is written
+func descriptionPath(reelPath string) string {
+	return strings.TrimSuffix(reelPath, filepath.Ext(reelPath)) + "_youtube.txt"
+}
+
+// clipDescription returns a clip's line in a reel description: its title tag
+// (with its Step 3 note), or the file name if its highlights aren't known
+func (a *App) clipDescription(clipPath string) string {
+	group, ok := a.clipGroup(clipPath)
+	if !ok {
+		return strings.TrimSuffix(filepath.Base(clipPath), filepath.Ext(clipPath))
+	}
+	return a.clipTags(group)["title"]
+}
+
+// writeReelDescription writes a YouTube description next to a combined reel:
+// its title and the game's details, then a timestamp for each clip, which
+// YouTube turns into chapters
+func (a *App) writeReelDescription(reelPath string, reelInputs, clips []string, transition ffmpeg.Transition) error {
+	texts := make(map[string]string, len(clips))
+	for _, clip := range clips {
+		texts[clip] = a.clipDescription(clip)
+	}
+	captions, err := a.ff.ReelCaptionsWithTransition(reelInputs, texts, transition)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	title := a.reelTags()["title"]
+	if title != "" {
+		fmt.Fprintf(&b, "%s\n", title)
+	}
+	if summary := a.gameDetails().Summary(); summary != "" && summary != title {
+		fmt.Fprintf(&b, "%s\n", summary)
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	// YouTube only makes chapters if the first timestamp is 0:00
+	if len(captions) > 0 && captions[0].Start >= 1 {
+		fmt.Fprintf(&b, "0:00 Intro\n")
+	}
+	for _, c := range captions {
+		fmt.Fprintf(&b, "%s %s\n", youtubeTimestamp(c.Start), c.Text)
+	}
+
+	if err := os.WriteFile(descriptionPath(reelPath), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write description: %w", err)
+	}
+	return nil
+}
+
+// youtubeTimestamp formats seconds the way YouTube descriptions link them:
+// "1:05", or "1:02:05" past an hour
+func youtubeTimestamp(sec float64) string {
+	total := int(sec)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total%3600/60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
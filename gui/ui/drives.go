@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+)
+
+// driveDefault is the drive limit option that uses each step's default
+const driveDefault = "Default"
+
+// driveWorkers returns how many clips to extract at once from each source
+// drive: the drive's setting from the Drives dialog, or fallback
+func (a *App) driveWorkers(fallback int) func(drive string) int {
+	return func(drive string) int {
+		return a.cfg.DriveWorkersFor(drive, fallback)
+	}
+}
+
+// showDriveSettings shows a dialog for how many clips are extracted at once
+// from each drive the periods' videos are on. Clips from different drives are
+// always cut in parallel; a spinning disk is best left at 1, while an SSD
+// keeps up with several.
+func (a *App) showDriveSettings() {
+	if a.analysisResult == nil || len(a.analysisResult.Periods) == 0 {
+		a.showError("No Periods", "Please complete Step 1 first to find the periods' drives")
+		return
+	}
+
+	periods := make(map[string][]string)
+	for _, p := range a.analysisResult.Periods {
+		drive := pipeline.SourceDrive(p.VideoFile)
+		periods[drive] = append(periods[drive], p.Name)
+	}
+	var drives []string
+	for drive := range periods {
+		drives = append(drives, drive)
+	}
+	sort.Strings(drives)
+
+	options := []string{driveDefault}
+	for i := 1; i <= maxReExtractWorkers; i++ {
+		options = append(options, strconv.Itoa(i))
+	}
+
+	selects := make([]*widget.Select, len(drives))
+	form := widget.NewForm()
+	for i, drive := range drives {
+		sel := widget.NewSelect(options, nil)
+		if n := a.cfg.DriveWorkers[drive]; n > 0 {
+			sel.SetSelected(strconv.Itoa(min(n, maxReExtractWorkers)))
+		} else {
+			sel.SetSelected(driveDefault)
+		}
+		selects[i] = sel
+		form.Append(drive, container.NewVBox(sel, widget.NewLabel(strings.Join(periods[drive], ", "))))
+	}
+
+	help := widget.NewLabel("Clips from different drives are extracted in parallel. Set how many clips are cut at once\n" +
+		"from each drive: 1 for a spinning disk, 2-3 for an SSD. Default is 1 in Step 2, and the\n" +
+		"Settings tab's re-extract count in Step 3.")
+
+	d := dialog.NewCustomConfirm("Source Drives", "Save", "Cancel", container.NewVBox(help, form), func(save bool) {
+		if !save {
+			return
+		}
+		if a.cfg.DriveWorkers == nil {
+			a.cfg.DriveWorkers = make(map[string]int)
+		}
+		for i, drive := range drives {
+			if n, err := strconv.Atoi(selects[i].Selected); err == nil {
+				a.cfg.DriveWorkers[drive] = n
+			} else {
+				delete(a.cfg.DriveWorkers, drive)
+			}
+		}
+		a.cfg.Save()
+	}, a.window)
+	d.Resize(fyne.NewSize(550, 350))
+	d.Show()
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// droppedPaths returns the local paths of files dropped onto the window
+func droppedPaths(uris []fyne.URI) []string {
+	var paths []string
+	for _, uri := range uris {
+		if uri.Scheme() != "file" {
+			continue
+		}
+		path := uri.Path()
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// setupDrop handles folders and files dropped onto the window, saving the
+// folder dialogs' trips to deep SD card paths:
+//
+//	folder        open it as the working folder (Step 1), or combine the
+//	              clips in it (Step 4)
+//	MP4 / .360    extract their metadata into the working folder (Step 1)
+//	clips         add them to the clips to combine (Step 4)
+//
+// A folder dropped onto any other tab opens in Step 1.
+func (a *App) setupDrop() {
+	a.window.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		paths := droppedPaths(uris)
+		if len(paths) == 0 {
+			return
+		}
+
+		if a.tabs.SelectedIndex() == stepCombine {
+			if a.actions.dropClips != nil {
+				a.actions.dropClips(paths)
+			}
+			return
+		}
+
+		var folder string
+		var mp4s []string
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				folder = path
+				continue
+			}
+			ext := filepath.Ext(path)
+			name := strings.TrimSuffix(filepath.Base(path), ext)
+			if (strings.EqualFold(ext, ".mp4") || strings.EqualFold(ext, ffmpeg.Max360Ext)) && !strings.HasSuffix(name, "_metadata") {
+				mp4s = append(mp4s, path)
+			}
+		}
+
+		switch {
+		case folder != "" && a.actions.dropFolder != nil:
+			a.tabs.SelectIndex(stepSetup)
+			a.actions.dropFolder(folder)
+		case len(mp4s) > 0 && a.tabs.SelectedIndex() == stepSetup && a.actions.dropMP4s != nil:
+			a.actions.dropMP4s(mp4s)
+		}
+	})
+}
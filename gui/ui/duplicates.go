@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// duplicatesFolder is where Step 1 moves backup copies of videos, inside the
+// working folder. Scans don't look in subfolders, so they stay out of the periods.
+const duplicatesFolder = "duplicates"
+
+// duplicatesText describes the copies Step 1 left out of the periods
+func duplicatesText(duplicates []metadata.DuplicateVideo) string {
+	var lines []string
+	for _, d := range duplicates {
+		lines = append(lines, "  "+d.String())
+	}
+	return fmt.Sprintf("Left out %d copies of other videos (same size, length and timecode):\n%s\n"+
+		"Delete them or move them out of the folder to save space.", len(duplicates), strings.Join(lines, "\n"))
+}
+
+// confirmMoveDuplicates asks before moving the copies into the working
+// folder's duplicates folder, then calls done after they are moved
+func (a *App) confirmMoveDuplicates(folder string, duplicates []metadata.DuplicateVideo, done func()) {
+	target := filepath.Join(folder, duplicatesFolder)
+	dialog.ShowConfirm("Move Copies",
+		fmt.Sprintf("Move %d copies into %s?\nCheck they are copies before deleting them.", len(duplicates), target),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := moveDuplicates(target, duplicates); err != nil {
+				a.showError("Move Copies", err.Error())
+			}
+			done()
+		}, a.window)
+}
+
+// moveDuplicates moves the copies into folder, without replacing files
+// already there
+func moveDuplicates(folder string, duplicates []metadata.DuplicateVideo) error {
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", folder, err)
+	}
+	for _, d := range duplicates {
+		target := filepath.Join(folder, filepath.Base(d.Path))
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("%s already exists", target)
+		}
+		if err := os.Rename(d.Path, target); err != nil {
+			return fmt.Errorf("failed to move %s: %w", filepath.Base(d.Path), err)
+		}
+	}
+	return nil
+}
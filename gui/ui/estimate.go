@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/jobs"
+)
+
+// minProgressForEstimate is how far a job must be before its own progress is
+// used to estimate the time left (earlier, one slow clip skews it)
+const minProgressForEstimate = 0.05
+
+// runBenchmark measures encode speed with a sample of the first period's video
+// (or a test pattern if no folder is loaded) and saves it to the config.
+// onDone is called on the UI thread once the benchmark has succeeded.
+func (a *App) runBenchmark(statusLabel *widget.Label, onDone func()) {
+	sample := ""
+	if len(a.periods) > 0 {
+		sample = a.periods[0].VideoFile
+	}
+
+	statusLabel.SetText("Benchmarking encoders (about a minute)...")
+	job := a.runJob("benchmark", "Benchmark encoders", func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		job.Update(0, "Encoding a 10-second sample with each encoder...")
+		speed, err := ff.BenchmarkEncoders(sample)
+		fyne.Do(func() {
+			if err != nil {
+				statusLabel.SetText("Benchmark failed: " + err.Error())
+				return
+			}
+			a.cfg.EncodeSpeed = speed
+			a.cfg.Save()
+			statusLabel.SetText("Benchmark done. Time estimates are shown with the totals.")
+			onDone()
+		})
+		return err
+	})
+	a.showQueued(job, statusLabel)
+}
+
+// recordEncode refines the stored encode speed with a finished re-encode of
+// videoSeconds of source, so the next estimate is closer. Safe to call from
+// a job.
+func (a *App) recordEncode(forceCPU bool, videoSeconds float64, source *ffmpeg.StreamInfo, elapsed time.Duration) {
+	nvenc := !forceCPU && !a.ff.PreferCPU() && a.ff.NVENCStatus() == nil
+	fyne.Do(func() {
+		if a.cfg.EncodeSpeed == nil {
+			return
+		}
+		a.cfg.EncodeSpeed.Record(nvenc && a.cfg.EncodeSpeed.NVENC > 0, videoSeconds, source, elapsed)
+		a.cfg.Save()
+	})
+}
+
+// timeLeft estimates the time left in a job from how long it has taken so far,
+// e.g. " - ~6 min left". Returns "" until the job is far enough along.
+func timeLeft(started time.Time, progress float64) string {
+	if progress < minProgressForEstimate || progress >= 1 {
+		return ""
+	}
+	elapsed := time.Since(started)
+	remaining := time.Duration(float64(elapsed) * (1 - progress) / progress)
+	return fmt.Sprintf(" - %s left", ffmpeg.FormatEstimate(remaining))
+}
+
+// encodeEstimate describes how long re-encoding videoSeconds of source would
+// take, e.g. "~14 min with NVENC, ~95 min CPU". cpuOnly leaves NVENC out and
+// passes scales it for 2-pass encodes. Returns "" if there is no benchmark.
+func (a *App) encodeEstimate(videoSeconds float64, source *ffmpeg.StreamInfo, cpuOnly bool, passes int) string {
+	speed := a.cfg.EncodeSpeed
+	if speed == nil {
+		return ""
+	}
+	nvenc, cpu := speed.Estimate(videoSeconds*float64(passes), source)
+	if cpuOnly || nvenc == 0 {
+		return ffmpeg.FormatEstimate(cpu) + " (CPU)"
+	}
+	return fmt.Sprintf("%s with NVENC, %s CPU", ffmpeg.FormatEstimate(nvenc), ffmpeg.FormatEstimate(cpu))
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// trimFrameMargin keeps the out point's thumbnail this far before the end of
+// the video, where there may be no frame left to extract
+const trimFrameMargin = 0.5
+
+// showExportTrims lets the warmup before puck drop and the dead time after
+// each period be cut from the full game export. The in and out points are
+// picked with sliders, showing the frame at each. durations holds the MOVs'
+// lengths; onSaved is called once the trims are saved.
+func (a *App) showExportTrims(movFiles []string, durations map[string]float64, onSaved func()) {
+	if a.thumbs == nil {
+		a.thumbs = newThumbnailCache(a.ff)
+	}
+
+	trims := make(map[string]ffmpeg.Trim, len(movFiles))
+	names := make([]string, len(movFiles))
+	paths := make(map[string]string, len(movFiles))
+	for i, path := range movFiles {
+		trims[path] = a.exportTrim(path)
+		names[i] = filepath.Base(path)
+		paths[names[i]] = path
+	}
+
+	image := func() *canvas.Image {
+		img := canvas.NewImageFromResource(theme.FileVideoIcon())
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(320, 180))
+		return img
+	}
+	inImg, outImg := image(), image()
+	// Positions the images were last asked for, so a slow frame for an
+	// earlier position doesn't replace a newer one
+	wanted := map[*canvas.Image]string{}
+	load := func(img *canvas.Image, videoFile string, atSec float64) {
+		key := fmt.Sprintf("%s|%.0f", videoFile, atSec)
+		wanted[img] = key
+		var get func()
+		get = func() {
+			if path, ok := a.thumbs.get(videoFile, atSec, get); ok {
+				fyne.Do(func() {
+					if wanted[img] != key {
+						return
+					}
+					img.Resource = nil
+					img.File = path
+					img.Refresh()
+				})
+			}
+		}
+		get()
+	}
+
+	inLabel, outLabel := widget.NewLabel(""), widget.NewLabel("")
+	keptLabel := widget.NewLabel("")
+	inSlider := widget.NewSlider(0, 1)
+	outSlider := widget.NewSlider(0, 1)
+	inSlider.Step, outSlider.Step = 1, 1
+
+	var current string
+	var switching bool // Moving the sliders to another file's trim
+
+	// update stores the sliders' trim for the current file and shows it
+	update := func() {
+		dur := durations[current]
+		in, out := inSlider.Value, outSlider.Value
+		trim := ffmpeg.Trim{In: in}
+		if out < dur {
+			trim.Out = out
+		}
+		trims[current] = trim
+		inLabel.SetText("Starts at " + formatDuration(in))
+		outLabel.SetText("Ends at " + formatDuration(trim.End(dur)))
+		keptLabel.SetText(fmt.Sprintf("Keeps %s of %s", formatDuration(trim.Length(dur)), formatDuration(dur)))
+	}
+	loadFrames := func() {
+		dur := durations[current]
+		load(inImg, current, inSlider.Value)
+		load(outImg, current, max(min(outSlider.Value, dur-trimFrameMargin), 0))
+	}
+
+	inSlider.OnChanged = func(v float64) {
+		if switching {
+			return
+		}
+		// The start can't pass the end
+		if v >= outSlider.Value {
+			inSlider.SetValue(max(outSlider.Value-1, 0))
+			return
+		}
+		update()
+	}
+	outSlider.OnChanged = func(v float64) {
+		if switching {
+			return
+		}
+		if v <= inSlider.Value {
+			outSlider.SetValue(min(inSlider.Value+1, outSlider.Max))
+			return
+		}
+		update()
+	}
+	// Frames are extracted once a slider is let go, not for every step dragged past
+	inSlider.OnChangeEnded = func(float64) { loadFrames() }
+	outSlider.OnChangeEnded = func(float64) { loadFrames() }
+
+	choose := func(path string) {
+		current = path
+		dur := durations[path]
+		trim := trims[path]
+		switching = true
+		inSlider.Max, outSlider.Max = dur, dur
+		inSlider.SetValue(trim.In)
+		outSlider.SetValue(trim.End(dur))
+		switching = false
+		update()
+		loadFrames()
+	}
+
+	fileSelect := widget.NewSelect(names, func(name string) {
+		choose(paths[name])
+	})
+	resetBtn := widget.NewButton("Keep Whole Video", func() {
+		trims[current] = ffmpeg.Trim{}
+		choose(current)
+	})
+
+	cell := func(title string, img *canvas.Image, slider *widget.Slider, caption *widget.Label) fyne.CanvasObject {
+		heading := widget.NewLabelWithStyle(title, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		return container.NewBorder(heading, container.NewVBox(slider, caption), nil, nil, img)
+	}
+	help := widget.NewLabel("Drag the sliders to where play starts and stops. " +
+		"Highlights outside the kept part are left out of the chapters.")
+	help.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(
+		container.NewVBox(help, container.NewHBox(widget.NewLabel("Period video:"), fileSelect, resetBtn)),
+		keptLabel, nil, nil,
+		container.NewGridWithColumns(2,
+			cell("Start (in point)", inImg, inSlider, inLabel),
+			cell("End (out point)", outImg, outSlider, outLabel),
+		),
+	)
+	fileSelect.SetSelected(names[0])
+
+	d := dialog.NewCustomConfirm("Trim Period Videos", "Save", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		for _, path := range movFiles {
+			a.setExportTrim(path, trims[path])
+		}
+		if onSaved != nil {
+			onSaved()
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(760, 480))
+	d.Show()
+}
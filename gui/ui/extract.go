@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/jobs"
+)
+
+// extractor returns a pipeline extractor for the current analysis with the
+// app's clip tags, watermark and per-period color correction
+func (a *App) extractor(streamCopy bool) *pipeline.Extractor {
+	return &pipeline.Extractor{
+		FF:            a.ff,
+		Analysis:      a.analysisResult,
+		StreamCopy:    streamCopy,
+		StreamCopyMP4: a.cfg.StreamCopyMP4,
+		Tags:          a.clipTags,
+		CaptureTimes:  a.cfg.CaptureTimes,
+		Watermark:     a.clipWatermark(),
+		Color:         a.periodColor,
+
+		EditCopies:   a.cfg.EditCopies,
+		Photos:       a.cfg.HighlightPhotos,
+		PhotoFrames:  a.cfg.PhotoFrames,
+		DriveWorkers: a.driveWorkers(1),
+	}
+}
+
+// spanSource returns the span source for a clip if it runs past the end of
+// the period's video file (see pipeline.Extractor.SpanSource)
+func (a *App) spanSource(periodName string, startSec, durationSec float64) (ffmpeg.SpanSource, bool) {
+	return a.extractor(false).SpanSource(periodName, startSec, durationSec)
+}
+
+// extractGroups extracts clip groups in order as part of job, running ffmpeg
+// through the job's handle ff and reporting progress
+// to the job and to onProgress (may be nil). Unless dryRun, the extracted clips
+// replace a.extractedClips and the session is saved after each one, and a
+// report of the run is written to outputFolder (see writeReport).
+// Returns how many clips were extracted, and an error if any failed.
+func (a *App) extractGroups(job *jobs.Job, ff *ffmpeg.FFmpeg, groups []metadata.ClipGroup, outputFolder string, streamCopy, dryRun bool, onProgress func(progress float64, status string)) (int, error) {
+	report := func(progress float64, status string) {
+		job.Update(progress, status)
+		if onProgress != nil {
+			onProgress(progress, status)
+		}
+	}
+
+	if !dryRun {
+		a.extractedClips = []string{}
+		a.sessionMu.Lock()
+		a.clipGroups = nil
+		a.sessionMu.Unlock()
+	}
+
+	total := len(groups)
+	var notices []string
+	batch := a.newReport("extract")
+	item := func(group metadata.ClipGroup) pipeline.ReportItem {
+		item := pipeline.ReportItem{
+			Inputs:       []string{a.analysisResult.GetPeriodVideoFile(group.Period)},
+			Period:       group.Period,
+			MediaSeconds: group.Duration,
+		}
+		for _, ch := range group.Chapters {
+			item.Chapters = append(item.Chapters, ch.GlobalOrder)
+		}
+		return item
+	}
+	extractor := a.extractor(streamCopy)
+	extractor.FF = ff
+	completed, err := extractor.ExtractGroups(groups, outputFolder, pipeline.Callbacks{
+		Checkpoint: job.Checkpoint,
+		Progress:   report,
+		Extracted: func(outputFile string, group metadata.ClipGroup) {
+			if !dryRun {
+				a.extractedClips = append(a.extractedClips, outputFile)
+				a.setClipGroup(outputFile, group)
+				a.clearClipEdit(outputFile)
+				a.saveSession()
+			}
+			done := item(group)
+			done.Output = outputFile
+			batch.Items = append(batch.Items, done)
+		},
+		Failed: func(i int, group metadata.ClipGroup, err error) {
+			report(float64(i)/float64(total), "Error extracting: "+errorSummary(err.Error())+" (Details in the Jobs tab)")
+			failed := item(group)
+			failed.Error = err.Error()
+			batch.Items = append(batch.Items, failed)
+		},
+		Notice: func(message string) {
+			notices = append(notices, message)
+		},
+	})
+
+	if !dryRun {
+		if err != nil {
+			batch.Error = err.Error()
+		}
+		if status := a.writeReport(outputFolder, batch); status != "" {
+			notices = append(notices, status)
+		}
+	}
+
+	// Silent sources are fine, but worth saying why the clips have no sound
+	if len(notices) > 0 {
+		fyne.Do(func() {
+			a.showInfo("Extract Clips", strings.Join(notices, "\n"))
+		})
+	}
+
+	if !dryRun && completed > 0 {
+		a.runHook(a.cfg.HookAfterExtract, "extract", outputFolder)
+	}
+
+	return completed, err
+}
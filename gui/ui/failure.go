@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// showFailureDetails shows the full ffmpeg command and output behind a failed
+// operation's error message, with suggested fixes
+func (a *App) showFailureDetails(title, message string) {
+	failure := a.ff.FailureFor(message)
+
+	output := message
+	command := "(not an ffmpeg command, or no longer recorded)"
+	if failure != nil {
+		output = failure.Stderr
+		command = failure.Command
+	}
+
+	suggestions := ffmpeg.Suggest(output)
+	suggestionText := "No suggestions - see the ffmpeg output below."
+	if len(suggestions) > 0 {
+		suggestionText = "- " + strings.Join(suggestions, "\n- ")
+	}
+	suggestionLabel := widget.NewLabel(suggestionText)
+	suggestionLabel.Wrapping = fyne.TextWrapWord
+
+	commandText := widget.NewMultiLineEntry()
+	commandText.SetText(command)
+	commandText.Wrapping = fyne.TextWrapWord
+	commandText.SetMinRowsVisible(3)
+
+	outputText := widget.NewMultiLineEntry()
+	outputText.SetText(output)
+	outputText.Wrapping = fyne.TextWrapWord
+
+	copyBtn := widget.NewButton("Copy All", func() {
+		a.fyneApp.Clipboard().SetContent("Command:\n" + command + "\n\nOutput:\n" + output)
+	})
+
+	top := container.NewVBox(
+		widget.NewLabelWithStyle("Suggested fixes", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		suggestionLabel,
+		widget.NewLabelWithStyle("Command", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		commandText,
+		container.NewBorder(nil, nil,
+			widget.NewLabelWithStyle("ffmpeg output", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			copyBtn),
+	)
+	content := container.NewBorder(top, nil, nil, nil, outputText)
+
+	d := dialog.NewCustom(title, "Close", content, a.window)
+	d.Resize(fyne.NewSize(900, 600))
+	d.Show()
+}
+
+// errorSummary shortens an error carrying ffmpeg's whole output to one line:
+// what failed, then ffmpeg's last line, which usually names the problem
+func errorSummary(message string) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	if len(lines) == 1 {
+		return message
+	}
+	what, _, _ := strings.Cut(lines[0], ": ")
+	return what + ": " + strings.TrimSpace(lines[len(lines)-1])
+}
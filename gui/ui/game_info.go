@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// teamName returns the game's home team: the one entered for it, or the team
+// name in the settings. a.sessionMu must be held.
+func (a *App) teamName() string {
+	if a.gameInfo.Team != "" {
+		return a.gameInfo.Team
+	}
+	return a.cfg.TeamName
+}
+
+// gameDetails returns the game's details with what wasn't entered filled in:
+// the name (see currentGameName), the team from the settings, the day of the
+// first highlight and the last score of the scoreboard's timeline
+func (a *App) gameDetails() metadata.GameInfo {
+	a.sessionMu.Lock()
+	info := a.gameInfo
+	info.Team = a.teamName()
+	if info.Name == "" {
+		info.Name = a.defaultGameName()
+	}
+	result, timelineText := a.analysisResult, a.scoreTimeline
+	a.sessionMu.Unlock()
+
+	if info.Date == "" && result != nil {
+		if start := result.GameStart(); !start.IsZero() {
+			info.Date = start.Format("2006-01-02")
+		}
+	}
+	if info.FinalScore == "" {
+		if timeline, err := metadata.ParseScoreTimeline(timelineText); err == nil && len(timeline) > 0 {
+			last := timeline[len(timeline)-1]
+			info.FinalScore = fmt.Sprintf("%d-%d", last.Home, last.Away)
+		}
+	}
+	return info
+}
+
+// setGameInfo sets the game's details, saves the session and relabels the
+// folder's games
+func (a *App) setGameInfo(info metadata.GameInfo) {
+	info = metadata.GameInfo{
+		Name:       strings.TrimSpace(info.Name),
+		Team:       strings.TrimSpace(info.Team),
+		Opponent:   strings.TrimSpace(info.Opponent),
+		Date:       strings.TrimSpace(info.Date),
+		Location:   strings.TrimSpace(info.Location),
+		FinalScore: strings.TrimSpace(info.FinalScore),
+	}
+	a.sessionMu.Lock()
+	a.gameInfo = info
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+	if a.actions.showGames != nil {
+		a.actions.showGames()
+	}
+}
+
+// showGameInfo shows a dialog for the game's teams, date, location and final
+// score. Fields left empty show what is used instead.
+func (a *App) showGameInfo() {
+	if a.analysisResult == nil {
+		a.showError("Game Details", "Analyze the videos first")
+		return
+	}
+	a.sessionMu.Lock()
+	info := a.gameInfo
+	a.sessionMu.Unlock()
+	defaults := a.gameDetails()
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(info.Name)
+	nameEntry.SetPlaceHolder(defaults.Name)
+	teamEntry := widget.NewEntry()
+	teamEntry.SetText(info.Team)
+	teamEntry.SetPlaceHolder(a.cfg.TeamName)
+	opponentEntry := widget.NewEntry()
+	opponentEntry.SetText(info.Opponent)
+	dateEntry := widget.NewEntry()
+	dateEntry.SetText(info.Date)
+	dateEntry.SetPlaceHolder("YYYY-MM-DD")
+	if info.Date == "" && defaults.Date != "" {
+		dateEntry.SetPlaceHolder(defaults.Date + " (first highlight)")
+	}
+	dateEntry.Validator = func(s string) error {
+		_, err := metadata.ParseGameDate(s)
+		return err
+	}
+	locationEntry := widget.NewEntry()
+	locationEntry.SetText(info.Location)
+	scoreEntry := widget.NewEntry()
+	scoreEntry.SetText(info.FinalScore)
+	scoreEntry.SetPlaceHolder("e.g. 4-2")
+	if info.FinalScore == "" && defaults.FinalScore != "" {
+		scoreEntry.SetPlaceHolder(defaults.FinalScore + " (scoreboard)")
+	}
+	scoreEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		_, _, err := metadata.ParseFinalScore(s)
+		return err
+	}
+
+	entered := func() metadata.GameInfo {
+		return metadata.GameInfo{
+			Name:       nameEntry.Text,
+			Team:       teamEntry.Text,
+			Opponent:   opponentEntry.Text,
+			Date:       dateEntry.Text,
+			Location:   locationEntry.Text,
+			FinalScore: scoreEntry.Text,
+		}
+	}
+
+	previewLabel := widget.NewLabel("")
+	updatePreview := func(string) {
+		preview := entered()
+		if preview.Team == "" {
+			preview.Team = a.cfg.TeamName
+		}
+		if preview.Name == "" {
+			preview.Name = preview.Matchup()
+		}
+		if preview.Name == "" {
+			preview.Name = defaults.Name
+		}
+		if dateEntry.Validate() != nil || preview.Date == "" {
+			preview.Date = defaults.Date
+		}
+		if scoreEntry.Validate() != nil || preview.FinalScore == "" {
+			preview.FinalScore = defaults.FinalScore
+		}
+		values := metadata.TemplateValues{
+			Game:     preview.Name,
+			Team:     preview.Team,
+			Opponent: preview.Opponent,
+			Date:     preview.Day(),
+			Location: preview.Location,
+			Score:    preview.FinalScore,
+		}
+		previewLabel.SetText("Game: " + preview.Summary() +
+			"\nReel title: " + metadata.ExpandTemplate(a.cfg.ReelTitleTemplate, values))
+	}
+	for _, entry := range []*widget.Entry{nameEntry, teamEntry, opponentEntry, dateEntry, locationEntry, scoreEntry} {
+		entry.OnChanged = updatePreview
+	}
+	updatePreview("")
+
+	form := widget.NewForm(
+		widget.NewFormItem("Game name", nameEntry),
+		widget.NewFormItem("Team", teamEntry),
+		widget.NewFormItem("Opponent", opponentEntry),
+		widget.NewFormItem("Date", dateEntry),
+		widget.NewFormItem("Location", locationEntry),
+		widget.NewFormItem("Final score", scoreEntry),
+	)
+	content := container.NewVBox(
+		widget.NewLabel("Used in file and folder names, tags, the scoreboard, YouTube descriptions and reports.\n"+
+			"Tokens: {game} {team} {opponent} {date} {location} {score}. Leave a field empty to use the default shown."),
+		form,
+		widget.NewSeparator(),
+		previewLabel,
+	)
+
+	d := dialog.NewCustomConfirm("Game Details", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		if err := dateEntry.Validate(); err != nil {
+			a.showError("Game Details", err.Error())
+			return
+		}
+		if err := scoreEntry.Validate(); err != nil {
+			a.showError("Game Details", err.Error())
+			return
+		}
+		a.setGameInfo(entered())
+	}, a.window)
+	d.Resize(fyne.NewSize(550, 450))
+	d.Show()
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/config"
+)
+
+// gameLabel returns a game's entry in Step 1's game list, e.g.
+// "Game 2 - 14:05 - Hawks vs Wolves (3 periods, 41 highlights)", with the
+// name or opponent entered in its details
+func gameLabel(number int, game *metadata.AnalysisResult, info metadata.GameInfo) string {
+	label := fmt.Sprintf("Game %d", number)
+	if start := game.GameStart(); !start.IsZero() {
+		label += " - " + start.Format("15:04")
+	}
+	if name := info.Name; name != "" {
+		label += " - " + name
+	} else if info.Opponent != "" {
+		label += " - vs " + info.Opponent
+	}
+	return label + fmt.Sprintf(" (%d periods, %d highlights)", len(game.Periods), len(game.Chapters))
+}
+
+// setGames keeps the games Step 1 split the working folder into (nil for a
+// folder with one game). Work kept on the other games is dropped, as it was
+// based on the previous analysis.
+func (a *App) setGames(games []*metadata.AnalysisResult) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	if len(games) < 2 {
+		games = nil
+	}
+	a.games = games
+	a.gameSessions = nil
+}
+
+// selectGame switches to another game of the working folder (numbered from
+// 1): the current game's work is kept to come back to, and the other game's
+// analysis, choices, clips and reel are loaded into the steps
+func (a *App) selectGame(number int) {
+	a.sessionMu.Lock()
+	if number == a.game || number < 1 || number > len(a.games) {
+		a.sessionMu.Unlock()
+		return
+	}
+	if a.gameSessions == nil {
+		a.gameSessions = make(map[int]*config.Session)
+	}
+	if current := a.sessionSnapshot(); current != nil && a.game > 0 {
+		a.gameSessions[a.game] = current
+	}
+	session := a.gameSessions[number]
+	if session == nil {
+		session = a.newGameSession(number)
+	}
+	a.sessionMu.Unlock()
+
+	a.restoreSession(session, session.ExistingClips())
+}
+
+// newGameSession returns the starting state of a game not worked on yet: its
+// analysis, with the folder's clock zone and entered start times, and speed
+// burst suggestions unticked (see setAnalysis). a.sessionMu must be held.
+func (a *App) newGameSession(number int) *config.Session {
+	game := a.games[number-1]
+	session := &config.Session{
+		WorkingFolder:   a.workingFolder,
+		Periods:         game.Periods,
+		Analysis:        game,
+		ClockZone:       a.clockZone,
+		ManualTimecodes: a.manualTimecodes,
+		Game:            number,
+	}
+	for _, ch := range game.Chapters {
+		if metadata.IsSuggested(ch) {
+			session.Deselected = append(session.Deselected, ch.Key())
+		}
+	}
+	return session
+}
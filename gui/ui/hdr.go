@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// hdrModeLabels are the display labels of the HDR modes
+var hdrModeLabels = map[ffmpeg.HDRMode]string{
+	ffmpeg.HDRToneMap:  "Tone-map to SDR H.264 (plays everywhere)",
+	ffmpeg.HDRPreserve: "Keep 10-bit/HDR as HEVC",
+}
+
+// newHDRModeSelect returns a select for how clips from 10-bit/HDR sources are
+// encoded, saving the choice to the config
+func (a *App) newHDRModeSelect() *widget.Select {
+	var options []string
+	for _, mode := range ffmpeg.HDRModes {
+		options = append(options, hdrModeLabels[mode])
+	}
+
+	sel := widget.NewSelect(options, func(selected string) {
+		for mode, label := range hdrModeLabels {
+			if label == selected && a.ff.HDRMode() != mode {
+				a.cfg.HDRMode = string(mode)
+				a.ff.SetHDRMode(mode)
+				a.cfg.Save()
+			}
+		}
+	})
+	sel.SetSelected(hdrModeLabels[a.ff.HDRMode()])
+	return sel
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/jobs"
+)
+
+// maxHistory caps how many ffmpeg commands are kept in a project's history
+const maxHistory = 500
+
+// addHistory records an ffmpeg command that was run in the project's history
+// and saves the session. Called from the ffmpeg wrapper on any goroutine.
+func (a *App) addHistory(entry ffmpeg.HistoryEntry) {
+	a.sessionMu.Lock()
+	a.history = append(a.history, entry)
+	if len(a.history) > maxHistory {
+		a.history = a.history[len(a.history)-maxHistory:]
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// historyEntries returns the project's ffmpeg history, newest first
+func (a *App) historyEntries() []ffmpeg.HistoryEntry {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	entries := make([]ffmpeg.HistoryEntry, len(a.history))
+	for i, entry := range a.history {
+		entries[len(a.history)-1-i] = entry
+	}
+	return entries
+}
+
+// clearHistory forgets the project's ffmpeg history and saves the session
+func (a *App) clearHistory() {
+	a.sessionMu.Lock()
+	a.history = nil
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// showHistory lists the ffmpeg commands run for the project, newest first,
+// each with its details and a button to run it again
+func (a *App) showHistory() {
+	entries := a.historyEntries()
+
+	list := widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("Output")
+			title.Truncation = fyne.TextTruncateEllipsis
+			detail := widget.NewLabel("")
+			detail.Truncation = fyne.TextTruncateEllipsis
+			detailsBtn := widget.NewButton("Details", nil)
+			rerunBtn := widget.NewButtonWithIcon("Re-run", theme.MediaReplayIcon(), nil)
+			return container.NewBorder(nil, nil, nil,
+				container.NewHBox(detailsBtn, rerunBtn),
+				container.NewVBox(title, detail))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(entries) {
+				return
+			}
+			entry := entries[id]
+			row := obj.(*fyne.Container)
+			info := row.Objects[0].(*fyne.Container)
+			buttons := row.Objects[1].(*fyne.Container)
+
+			state := "done"
+			if !entry.Success {
+				state = "failed"
+			}
+			info.Objects[0].(*widget.Label).SetText(filepath.Base(entry.Output))
+			info.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%s - %s in %s - %d inputs",
+				entry.Time.Format("Jan 2 15:04:05"), state, formatDuration(entry.Duration.Seconds()), len(entry.Inputs)))
+			buttons.Objects[0].(*widget.Button).OnTapped = func() { a.showHistoryEntry(entry) }
+			buttons.Objects[1].(*widget.Button).OnTapped = func() { a.confirmRerun(entry) }
+		},
+	)
+
+	heading := widget.NewLabel(fmt.Sprintf("%d ffmpeg commands run for this project, newest first. "+
+		"Re-run writes the same output again from the same inputs.", len(entries)))
+	heading.Wrapping = fyne.TextWrapWord
+	if len(entries) == 0 {
+		heading.SetText("No ffmpeg commands have been run for this project yet.")
+	}
+
+	var d dialog.Dialog
+	clearBtn := widget.NewButton("Clear History", func() {
+		dialog.ShowConfirm("Clear History", "Forget all the ffmpeg commands run for this project?\n\nNo files are deleted.", func(ok bool) {
+			if !ok {
+				return
+			}
+			a.clearHistory()
+			d.Hide()
+		}, a.window)
+	})
+	if len(entries) == 0 {
+		clearBtn.Disable()
+	}
+
+	content := container.NewBorder(heading, container.NewHBox(clearBtn), nil, nil, list)
+	d = dialog.NewCustom("ffmpeg History", "Close", content, a.window)
+	d.Resize(fyne.NewSize(900, 600))
+	d.Show()
+}
+
+// showHistoryEntry shows a history entry's full command line, inputs and output
+func (a *App) showHistoryEntry(entry ffmpeg.HistoryEntry) {
+	state := "Succeeded"
+	if !entry.Success {
+		state = "Failed"
+	}
+	lines := []string{
+		fmt.Sprintf("%s %s after %s", state, entry.Time.Format("Jan 2 2006 15:04:05"), formatDuration(entry.Duration.Seconds())),
+		"",
+		"Output: " + entry.Output,
+	}
+	for _, input := range entry.Inputs {
+		if _, kept := entry.TempFiles[input]; kept {
+			input += " (temporary, kept with the history)"
+		}
+		lines = append(lines, "Input: "+input)
+	}
+	lines = append(lines, "", "Command:", entry.Command())
+
+	text := widget.NewMultiLineEntry()
+	text.SetText(strings.Join(lines, "\n"))
+	text.Wrapping = fyne.TextWrapWord
+
+	copyBtn := widget.NewButtonWithIcon("Copy Command", theme.ContentCopyIcon(), func() {
+		a.fyneApp.Clipboard().SetContent(entry.Command())
+	})
+
+	content := container.NewBorder(nil, container.NewHBox(copyBtn), nil, nil, text)
+	d := dialog.NewCustom(filepath.Base(entry.Output), "Close", content, a.window)
+	d.Resize(fyne.NewSize(800, 450))
+	d.Show()
+}
+
+// confirmRerun asks before running a history entry again, as it replaces its output
+func (a *App) confirmRerun(entry ffmpeg.HistoryEntry) {
+	msg := fmt.Sprintf("Run this ffmpeg command again?\n\n%s will be replaced when it finishes.", entry.Output)
+	dialog.ShowConfirm("Re-run", msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		title := "Re-run " + filepath.Base(entry.Output)
+		a.runJob("rerun", title, func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			job.Update(0, "Running ffmpeg...")
+			if err := ff.Rerun(entry); err != nil {
+				fyne.Do(func() { a.showError(title, err.Error()) })
+				return err
+			}
+			job.Update(1, "Wrote "+entry.Output)
+			return nil
+		})
+	}, a.window)
+}
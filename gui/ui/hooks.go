@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// runHook runs a hook command set in Settings through the shell, in the
+// background, once an operation has finished. event names the operation
+// ("extract", "combine" or "export") and output is the folder or file it
+// wrote; both are passed in the environment as GOPRO_EVENT and GOPRO_OUTPUT,
+// along with the game name as GOPRO_GAME. Failures are shown as an error.
+func (a *App) runHook(command, event, output string) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	game := a.gameDetails()
+	cmd.Env = append(os.Environ(),
+		"GOPRO_EVENT="+event,
+		"GOPRO_OUTPUT="+output,
+		"GOPRO_GAME="+game.Name,
+		"GOPRO_OPPONENT="+game.Opponent,
+		"GOPRO_DATE="+game.Date,
+	)
+	cmd.Dir = output
+	if info, err := os.Stat(output); err != nil || !info.IsDir() {
+		cmd.Dir = filepath.Dir(output)
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	go func() {
+		if err := cmd.Run(); err != nil {
+			message := "The " + event + " hook failed: " + err.Error()
+			if text := strings.TrimSpace(out.String()); text != "" {
+				message += "\n\n" + text
+			}
+			fyne.Do(func() {
+				a.showError("Hook Failed", message)
+			})
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"gopro-gui/config"
+)
+
+// instanceTempPrefix starts the name of each instance's temp folder, followed
+// by its slot number
+const instanceTempPrefix = "gopro-clip-extractor-"
+
+// SetMultiInstance turns the warning about other running copies off for this
+// run (the -multi-instance flag)
+func (a *App) SetMultiInstance(enabled bool) {
+	a.multiInstance = enabled
+}
+
+// claimInstance claims this copy's instance slot (its recovery file) and
+// creates its temp folder, so copies running at once don't share either
+func (a *App) claimInstance() {
+	instance, err := config.ClaimInstance()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	a.instance = instance
+
+	// Temp folders left in this slot by a copy that crashed
+	pattern := fmt.Sprintf("%s%d-", instanceTempPrefix, instance.Slot)
+	stale, _ := filepath.Glob(filepath.Join(os.TempDir(), pattern+"*"))
+	for _, dir := range stale {
+		os.RemoveAll(dir)
+	}
+
+	dir, err := os.MkdirTemp("", pattern+"*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	a.tempDir = dir
+}
+
+// releaseInstance gives up the instance slot and removes the temp folder,
+// and the project work folders
+func (a *App) releaseInstance() {
+	a.closeWorkDirs()
+	if a.tempDir != "" {
+		os.RemoveAll(a.tempDir)
+	}
+	if a.instance != nil {
+		a.instance.Release()
+	}
+}
+
+// confirmInstance asks whether to keep running when another copy was already
+// running, then calls next. Skipped in multi-instance mode.
+func (a *App) confirmInstance(next func()) {
+	if a.instance == nil || a.instance.Others == 0 || a.multiInstance || a.cfg.MultiInstance {
+		next()
+		return
+	}
+
+	msg := "GoPro Clip Extractor is already running.\n\n" +
+		"Copies running at once each keep their own temp files and recovery file, and\n" +
+		"settings changed in either are merged when saved. Avoid working on the same\n" +
+		"game folder in both.\n\nOpen this copy anyway?"
+	confirm := dialog.NewConfirm("Already Running", msg, func(open bool) {
+		if !open {
+			// Leave the slot's recovery file for the copy it belongs to
+			a.window.SetOnClosed(a.releaseInstance)
+			a.window.Close()
+			return
+		}
+		next()
+	}, a.window)
+	confirm.SetConfirmText("Open Anyway")
+	confirm.SetDismissText("Quit")
+	confirm.Show()
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/jobs"
+)
+
+// runJob queues a long operation on the shared job manager. The job runs
+// ffmpeg through its own handle (see ffmpeg.FFmpeg.WithCancel), so cancelling
+// it kills only its own processes and can't affect the next job.
+func (a *App) runJob(kind, title string, fn func(job *jobs.Job, ff *ffmpeg.FFmpeg) error) *jobs.Job {
+	return a.jobs.Start(kind, title, func(job *jobs.Job) error {
+		ff := a.ff.WithCancel()
+		job.OnCancel(func() {
+			ff.CancelExport() // Stop the job's running ffmpeg processes
+		})
+		return fn(job, ff)
+	})
+}
+
+// showQueued tells the user when an operation is waiting behind another job
+func (a *App) showQueued(job *jobs.Job, statusLabel *widget.Label) {
+	if job.Snapshot().State != jobs.Pending {
+		return
+	}
+	waitingFor := "another job"
+	if active := a.jobs.Active(); active != nil {
+		waitingFor = fmt.Sprintf("%q", active.Title)
+	}
+	statusLabel.SetText(fmt.Sprintf("Queued - will start after %s finishes (see the Jobs tab)", waitingFor))
+}
+
+// createJobsTab creates the panel listing running, queued and finished jobs
+func (a *App) createJobsTab() fyne.CanvasObject {
+	var snapshots []jobs.Snapshot
+
+	list := widget.NewList(
+		func() int {
+			return len(snapshots)
+		},
+		func() fyne.CanvasObject {
+			title := widget.NewLabel("Job")
+			state := widget.NewLabel("state")
+			progress := widget.NewProgressBar()
+			message := widget.NewLabel("")
+			message.Truncation = fyne.TextTruncateEllipsis
+			pauseBtn := widget.NewButton("Pause", nil)
+			cancelBtn := widget.NewButton("Cancel", nil)
+			detailsBtn := widget.NewButton("Details", nil)
+			return container.NewBorder(nil, nil, nil,
+				container.NewHBox(detailsBtn, pauseBtn, cancelBtn),
+				container.NewVBox(container.NewHBox(title, state), progress, message))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(snapshots) {
+				return
+			}
+			s := snapshots[id]
+			row := obj.(*fyne.Container)
+			info := row.Objects[0].(*fyne.Container)
+			buttons := row.Objects[1].(*fyne.Container)
+			header := info.Objects[0].(*fyne.Container)
+
+			header.Objects[0].(*widget.Label).SetText(s.Title)
+			stateText := string(s.State)
+			if s.Finished != nil && s.Started != nil {
+				stateText += " in " + formatDuration(s.Finished.Sub(*s.Started).Seconds())
+			} else if s.Started != nil && s.State == jobs.Running {
+				stateText += " for " + formatDuration(time.Since(*s.Started).Seconds())
+			}
+			if s.Held {
+				stateText += ", waiting for quiet hours to end"
+			}
+			header.Objects[1].(*widget.Label).SetText("(" + stateText + ")")
+			info.Objects[1].(*widget.ProgressBar).SetValue(s.Progress)
+			message := s.Message
+			if s.Error != "" {
+				message = "Error: " + errorSummary(s.Error)
+			}
+			info.Objects[2].(*widget.Label).SetText(message)
+
+			detailsBtn := buttons.Objects[0].(*widget.Button)
+			pauseBtn := buttons.Objects[1].(*widget.Button)
+			cancelBtn := buttons.Objects[2].(*widget.Button)
+
+			// Failed jobs link to the full ffmpeg command and output
+			if s.Error != "" {
+				detailsBtn.OnTapped = func() { a.showFailureDetails(s.Title, s.Error) }
+				detailsBtn.Show()
+			} else {
+				detailsBtn.Hide()
+			}
+
+			if s.State == jobs.Paused {
+				pauseBtn.SetText("Resume")
+				pauseBtn.OnTapped = func() { a.jobs.Resume(s.ID) }
+			} else {
+				pauseBtn.SetText("Pause")
+				pauseBtn.OnTapped = func() { a.jobs.Pause(s.ID) }
+			}
+			cancelBtn.OnTapped = func() { a.jobs.Cancel(s.ID) }
+			if s.Done() {
+				pauseBtn.Disable()
+				cancelBtn.Disable()
+			} else {
+				pauseBtn.Enable()
+				cancelBtn.Enable()
+			}
+		},
+	)
+
+	refresh := func() {
+		snapshots = a.jobs.List()
+		// Newest first
+		for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+			snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+		}
+		list.Refresh()
+	}
+	a.jobs.OnChange(func(jobs.Snapshot) {
+		fyne.Do(refresh)
+	})
+
+	clearBtn := widget.NewButton("Clear Finished", func() {
+		a.jobs.ClearFinished()
+		refresh()
+	})
+
+	// Quiet hours (see quiet_hours.go), with a way to run jobs anyway
+	quietLabel := widget.NewLabel("")
+	runNowBtn := widget.NewButton("Run Now", a.runDuringQuietHours)
+	quietRow := container.NewHBox(quietLabel, runNowBtn)
+	a.actions.showQuietHours = func() {
+		if !a.jobs.Held() {
+			quietRow.Hide()
+			return
+		}
+		quietLabel.SetText("Quiet hours until " + a.quietHours().EndString() + ": jobs pause after their current file or clip.")
+		quietRow.Show()
+	}
+	a.actions.showQuietHours()
+
+	header := container.NewVBox(
+		widget.NewLabel("Jobs"),
+		widget.NewSeparator(),
+		widget.NewLabel("Long operations run one at a time in the order they were started. Pause and cancel take effect after the current file or clip."),
+		quietRow,
+		container.NewHBox(clearBtn, widget.NewButton("ffmpeg History...", a.showHistory)),
+	)
+
+	return container.NewBorder(header, nil, nil, nil, list)
+}
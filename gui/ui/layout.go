@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// layoutFolder expands a folder template of the output layout for this game,
+// or returns "" if no layout is set up
+func (a *App) layoutFolder(template string) string {
+	if a.cfg.OutputRoot == "" {
+		return ""
+	}
+	return filepath.Join(a.cfg.OutputRoot, metadata.ExpandPathTemplate(template, a.gameTemplateValues()))
+}
+
+// clipOutputFolder returns the layout's folder for this game's clips ("" = no layout)
+func (a *App) clipOutputFolder() string {
+	return a.layoutFolder(a.cfg.ClipFolderTemplate)
+}
+
+// reelOutputFolder returns the layout's folder for this game's reels and
+// full game exports ("" = no layout)
+func (a *App) reelOutputFolder() string {
+	return a.layoutFolder(a.cfg.ReelFolderTemplate)
+}
+
+// showOutputLayout shows a dialog for the per-game output layout. onSave is
+// called after the layout is saved.
+func (a *App) showOutputLayout(onSave func()) {
+	rootPath := a.cfg.OutputRoot
+	rootLabel := widget.NewLabel("(none - choose folders by hand)")
+	if rootPath != "" {
+		rootLabel.SetText(rootPath)
+	}
+
+	clipEntry := widget.NewEntry()
+	clipEntry.SetText(a.cfg.ClipFolderTemplate)
+	reelEntry := widget.NewEntry()
+	reelEntry.SetText(a.cfg.ReelFolderTemplate)
+
+	previewLabel := widget.NewLabel("")
+	updatePreview := func(string) {
+		if rootPath == "" {
+			previewLabel.SetText("Set a base folder to use the layout.")
+			return
+		}
+		values := a.gameTemplateValues()
+		previewLabel.SetText("Clips: " + filepath.Join(rootPath, metadata.ExpandPathTemplate(clipEntry.Text, values)) +
+			"\nReels: " + filepath.Join(rootPath, metadata.ExpandPathTemplate(reelEntry.Text, values)))
+	}
+	clipEntry.OnChanged = updatePreview
+	reelEntry.OnChanged = updatePreview
+	updatePreview("")
+
+	selectRootBtn := widget.NewButton("Select Base Folder", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			path := uri.Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			rootPath = path
+			rootLabel.SetText(path)
+			updatePreview("")
+		}, a.window)
+	})
+	clearRootBtn := widget.NewButton("Clear", func() {
+		rootPath = ""
+		rootLabel.SetText("(none - choose folders by hand)")
+		updatePreview("")
+	})
+
+	content := container.NewVBox(
+		widget.NewLabel("Clips and reels of each game go into their own folders under the base folder,\n"+
+			"created automatically. Choosing a folder by hand still overrides the layout."),
+		container.NewHBox(widget.NewLabel("Base folder:"), rootLabel, selectRootBtn, clearRootBtn),
+		widget.NewForm(
+			widget.NewFormItem("Clips", clipEntry),
+			widget.NewFormItem("Reels", reelEntry),
+		),
+		widget.NewLabel("Tokens: "+strings.Join(metadata.TemplateTokens, " ")+"\n"+
+			"{game} is set in Metadata Tags (default: the working folder's name)."),
+		widget.NewSeparator(),
+		previewLabel,
+	)
+
+	d := dialog.NewCustomConfirm("Output Layout", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		a.cfg.OutputRoot = rootPath
+		a.cfg.ClipFolderTemplate = strings.TrimSpace(clipEntry.Text)
+		a.cfg.ReelFolderTemplate = strings.TrimSpace(reelEntry.Text)
+		a.cfg.Save()
+		onSave()
+	}, a.window)
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
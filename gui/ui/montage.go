@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// highlightOffset returns how far (seconds) into a clip its first highlight
+// is: the padding it was cut with before the highlight
+func (a *App) highlightOffset(clipPath string) float64 {
+	group, ok := a.clipGroup(clipPath)
+	if !ok {
+		return a.cfg.SecondsBefore
+	}
+	at := group.PrimaryChapter.VideoTime.Seconds()
+	if edit, edited := a.clipEdit(clipPath); edited {
+		return min(edit.SecondsBefore, at)
+	}
+	return at - group.StartTime
+}
+
+// reelMontage returns the intro montage to lead a reel of clips with, or nil
+// if it's turned off. The snippets are the clips by star rating, best first
+// (unrated clips last, in reel order), shown in the reel's order.
+func (a *App) reelMontage(clips []string) *ffmpeg.Montage {
+	if !a.cfg.MontageOnReel || len(clips) == 0 {
+		return nil
+	}
+
+	ranked := append([]string{}, clips...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return a.clipRating(ranked[i]) > a.clipRating(ranked[j])
+	})
+	order := make(map[string]int, len(clips))
+	for i, clip := range clips {
+		order[clip] = i
+	}
+
+	montage := &ffmpeg.Montage{
+		MusicPath: a.cfg.MontageMusicPath,
+		Length:    a.cfg.MontageSeconds,
+		BPM:       a.cfg.MontageBPM,
+		Ramp:      a.cfg.MontageRamp,
+	}
+	for _, clip := range ranked {
+		montage.Snippets = append(montage.Snippets, ffmpeg.MontageSnippet{
+			ClipPath: clip,
+			At:       a.highlightOffset(clip),
+			Order:    order[clip],
+		})
+	}
+	return montage
+}
+
+// showMontageSettings shows the intro montage options: whether reels get one,
+// its music, length and tempo, and speed ramping
+func (a *App) showMontageSettings() {
+	musicPath := a.cfg.MontageMusicPath
+	musicLabel := widget.NewLabel("(none)")
+	if musicPath != "" {
+		musicLabel.SetText(filepath.Base(musicPath))
+	}
+
+	bpmEntry := widget.NewEntry()
+	bpmEntry.SetPlaceHolder("auto")
+	if a.cfg.MontageBPM > 0 {
+		bpmEntry.SetText(strconv.FormatFloat(a.cfg.MontageBPM, 'f', -1, 64))
+	}
+	tempoLabel := widget.NewLabel("")
+
+	selectMusicBtn := widget.NewButton("Select Music", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			musicPath = path
+			musicLabel.SetText(filepath.Base(path))
+			bpmEntry.SetText("") // The old track's tempo
+			tempoLabel.SetText("")
+		}, a.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".mp3", ".m4a", ".aac", ".wav", ".flac", ".ogg"}))
+		fileDialog.Show()
+	})
+
+	var detectBtn *widget.Button
+	detectBtn = widget.NewButton("Detect", func() {
+		if musicPath == "" {
+			a.showError("Montage", "Select the music first")
+			return
+		}
+		detectBtn.Disable()
+		tempoLabel.SetText("Listening...")
+		path := musicPath
+		go func() {
+			bpm, _, err := a.ff.DetectTempo(path)
+			fyne.Do(func() {
+				detectBtn.Enable()
+				if err != nil {
+					tempoLabel.SetText("No clear beat - enter the tempo")
+					return
+				}
+				bpmEntry.SetText(strconv.FormatFloat(bpm, 'f', -1, 64))
+				tempoLabel.SetText("Detected")
+			})
+		}()
+	})
+
+	lengthLabel := widget.NewLabel("")
+	lengthSlider := widget.NewSlider(10, 45)
+	lengthSlider.Step = 1
+	lengthSlider.OnChanged = func(v float64) {
+		lengthLabel.SetText(fmt.Sprintf("%.0f s", v))
+	}
+	lengthSlider.SetValue(a.cfg.MontageSeconds)
+
+	onReelCheck := widget.NewCheck("Add an intro montage to combined reels", nil)
+	onReelCheck.SetChecked(a.cfg.MontageOnReel)
+	rampCheck := widget.NewCheck("Speed ramp (fast into each highlight, slow motion through it)", nil)
+	rampCheck.SetChecked(a.cfg.MontageRamp)
+
+	note := widget.NewLabel("Each top-rated clip is shown for about a second - a whole number of beats - " +
+		"with the cuts on the beat of the music, which replaces the camera's sound. " +
+		"Rate clips in Step 4 to choose them; unrated clips fill in after the rated ones.")
+	note.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(
+		onReelCheck,
+		container.NewHBox(widget.NewLabel("Music:"), musicLabel, selectMusicBtn),
+		container.NewBorder(nil, nil, widget.NewLabel("Length:"), lengthLabel, lengthSlider),
+		container.NewHBox(widget.NewLabel("Tempo (BPM):"), bpmEntry, detectBtn, tempoLabel),
+		rampCheck,
+		note,
+	)
+
+	d := dialog.NewCustomConfirm("Intro Montage", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		bpm, err := strconv.ParseFloat(strings.TrimSpace(bpmEntry.Text), 64)
+		if err != nil || bpm < 0 {
+			bpm = 0 // Detected when the montage is made
+		}
+		a.cfg.MontageOnReel = onReelCheck.Checked
+		a.cfg.MontageMusicPath = musicPath
+		a.cfg.MontageSeconds = lengthSlider.Value
+		a.cfg.MontageBPM = bpm
+		a.cfg.MontageRamp = rampCheck.Checked
+		a.cfg.Save()
+	}, a.window)
+	d.Resize(fyne.NewSize(550, 380))
+	d.Show()
+}
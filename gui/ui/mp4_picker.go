@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// showMP4Picker shows a dialog listing the MP4 (and GoPro MAX .360) files in a folder with checkboxes,
+// so several GoPro chapter files can be picked at once (e.g. straight off the SD card).
+// onSelected is called with the full paths of the checked files.
+func (a *App) showMP4Picker(startFolder string, onSelected func(paths []string)) {
+	var files []string
+	var checks []*widget.Check
+
+	folderLabel := widget.NewLabel("(no folder)")
+	folderLabel.Wrapping = fyne.TextWrapWord
+	countLabel := widget.NewLabel("")
+	listContainer := container.NewVBox()
+
+	updateCount := func() {
+		selected := 0
+		for _, c := range checks {
+			if c.Checked {
+				selected++
+			}
+		}
+		countLabel.SetText(fmt.Sprintf("%d of %d files selected", selected, len(files)))
+	}
+
+	loadFolder := func(folder string) {
+		files = nil
+		checks = nil
+		listContainer.Objects = nil
+		folderLabel.SetText(folder)
+
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			listContainer.Add(widget.NewLabel("Error reading folder: " + err.Error()))
+			listContainer.Refresh()
+			return
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			ext := filepath.Ext(name)
+			if entry.IsDir() || !(strings.EqualFold(ext, ".mp4") || strings.EqualFold(ext, ffmpeg.Max360Ext)) {
+				continue
+			}
+			// Skip our own chapter-only MP4s
+			if strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), "_metadata") {
+				continue
+			}
+			files = append(files, filepath.Join(folder, name))
+		}
+		sort.Strings(files)
+
+		if len(files) == 0 {
+			listContainer.Add(widget.NewLabel("No MP4 or .360 files in this folder."))
+		}
+		for _, path := range files {
+			check := widget.NewCheck(filepath.Base(path), func(bool) { updateCount() })
+			checks = append(checks, check)
+			listContainer.Add(check)
+		}
+		listContainer.Refresh()
+		updateCount()
+	}
+
+	browseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			path := uri.Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			loadFolder(path)
+		}, a.window)
+	})
+
+	// GoPro names HEVC recordings GX<chapter><video>.MP4
+	selectGoProBtn := widget.NewButton("Select all GX*.MP4", func() {
+		for i, c := range checks {
+			c.SetChecked(strings.HasPrefix(strings.ToUpper(filepath.Base(files[i])), "GX"))
+		}
+	})
+	selectAllBtn := widget.NewButton("Select All", func() {
+		for _, c := range checks {
+			c.SetChecked(true)
+		}
+	})
+	selectNoneBtn := widget.NewButton("Select None", func() {
+		for _, c := range checks {
+			c.SetChecked(false)
+		}
+	})
+
+	scroll := container.NewScroll(listContainer)
+	scroll.SetMinSize(fyne.NewSize(450, 300))
+
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabel("Folder:"), browseBtn, folderLabel),
+			container.NewHBox(selectGoProBtn, selectAllBtn, selectNoneBtn),
+		),
+		countLabel, nil, nil,
+		scroll,
+	)
+
+	d := dialog.NewCustomConfirm("Select GoPro MP4 Files", "Extract Metadata", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		var selected []string
+		for i, c := range checks {
+			if c.Checked {
+				selected = append(selected, files[i])
+			}
+		}
+		if len(selected) > 0 {
+			onSelected(selected)
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(600, 500))
+
+	if startFolder != "" {
+		loadFolder(startFolder)
+	}
+	d.Show()
+}
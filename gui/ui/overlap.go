@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// overlapOptions are the ways highlights whose clips overlap can be extracted,
+// in the order they are offered (see metadata.OverlapPolicy)
+var overlapOptions = []struct {
+	name  string
+	label string
+}{
+	{metadata.OverlapMergeAll, "Merge into one clip"},
+	{metadata.OverlapExtendFirst, "Extend the first clip"},
+	{metadata.OverlapMergeBelow, "Merge if marked within"},
+	{metadata.OverlapNeverMerge, "Keep separate clips"},
+}
+
+// overlapChoice is Step 2's choice of how overlapping highlights are
+// extracted, with the gap for merging highlights marked close together
+type overlapChoice struct {
+	sel       *widget.Select
+	gap       *widget.Entry
+	gapLabel  *widget.Label
+	OnChanged func()
+	setting   bool // Filling in the widgets from set
+}
+
+// newOverlapChoice returns a choice set to the named policy and gap
+func newOverlapChoice(name string, gap float64) *overlapChoice {
+	c := &overlapChoice{gap: widget.NewEntry(), gapLabel: widget.NewLabel("s")}
+	var labels []string
+	for _, option := range overlapOptions {
+		labels = append(labels, option.label)
+	}
+	c.sel = widget.NewSelect(labels, func(string) {
+		c.showGap()
+		c.changed()
+	})
+	c.gap.OnChanged = func(string) { c.changed() }
+	c.set(name, gap)
+	return c
+}
+
+// row returns the choice's widgets laid out for Step 2
+func (c *overlapChoice) row() fyne.CanvasObject {
+	return container.NewHBox(widget.NewLabel("Overlapping highlights:"), c.sel, c.gap, c.gapLabel)
+}
+
+// set selects the named policy and fills in the gap
+func (c *overlapChoice) set(name string, gap float64) {
+	c.setting = true
+	for _, option := range overlapOptions {
+		if option.name == name {
+			c.sel.SetSelected(option.label)
+		}
+	}
+	c.gap.SetText(fmt.Sprintf("%g", gap))
+	c.setting = false
+	c.showGap()
+}
+
+// value returns the chosen policy's name and the gap. ok is false if the gap
+// is needed and isn't a number over 0.
+func (c *overlapChoice) value() (name string, gap float64, ok bool) {
+	name = metadata.OverlapMergeAll
+	for _, option := range overlapOptions {
+		if option.label == c.sel.Selected {
+			name = option.name
+		}
+	}
+	gap, err := strconv.ParseFloat(strings.TrimSpace(c.gap.Text), 64)
+	if name == metadata.OverlapMergeBelow && (err != nil || gap <= 0) {
+		return name, 0, false
+	}
+	return name, gap, true
+}
+
+// showGap shows the gap entry only for the policy that uses it
+func (c *overlapChoice) showGap() {
+	if name, _, _ := c.value(); name == metadata.OverlapMergeBelow {
+		c.gap.Show()
+		c.gapLabel.Show()
+	} else {
+		c.gap.Hide()
+		c.gapLabel.Hide()
+	}
+}
+
+// changed calls OnChanged for changes made by the user
+func (c *overlapChoice) changed() {
+	if !c.setting && c.OnChanged != nil {
+		c.OnChanged()
+	}
+}
@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/config"
+)
+
+// groupChapters returns the clips chapters are extracted as with the given
+// padding, grouping overlapping ones as chosen in Step 2
+func (a *App) groupChapters(chapters []metadata.Chapter, before, after float64) []metadata.ClipGroup {
+	return metadata.GroupChaptersWith(chapters, before, after, a.cfg.OverlapGrouping())
+}
+
+// setPadding records the padding the game's clips are cut with, saved with
+// the project
+func (a *App) setPadding(padding config.PaddingPreset) {
+	a.sessionMu.Lock()
+	a.padding = &padding
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// applyPadding makes a project's padding the one Step 2 starts with
+func (a *App) applyPadding(padding *config.PaddingPreset) {
+	if padding == nil {
+		return
+	}
+	a.cfg.SecondsBefore = padding.SecondsBefore
+	a.cfg.SecondsAfter = padding.SecondsAfter
+	a.cfg.SetOverlaps(padding.Overlaps(), padding.OverlapGap)
+	a.cfg.Save()
+}
+
+// paddingPresetPicker is Step 2's padding preset choice. Picking a preset
+// fills in the padding entries and overlap choice; editing them so they no
+// longer match shows "Custom".
+type paddingPresetPicker struct {
+	a             *App
+	before, after *widget.Entry
+	overlap       *overlapChoice
+	sel           *widget.Select
+	deleteBtn     *widget.Button
+	applying      bool // Filling in the entries from a preset
+}
+
+// newPaddingPresetPicker returns a preset picker for Step 2's padding entries
+// and overlap choice, set to the project's preset if it still matches them
+func (a *App) newPaddingPresetPicker(before, after *widget.Entry, overlap *overlapChoice) *paddingPresetPicker {
+	p := &paddingPresetPicker{a: a, before: before, after: after, overlap: overlap}
+	p.sel = widget.NewSelect(nil, p.choose)
+	p.sel.PlaceHolder = "Custom"
+	p.deleteBtn = widget.NewButton("Delete Preset", p.delete)
+
+	a.sessionMu.Lock()
+	name := ""
+	if a.padding != nil {
+		name = a.padding.Name
+	}
+	a.sessionMu.Unlock()
+	p.refresh(name)
+	return p
+}
+
+// row returns the picker's widgets laid out for Step 2
+func (p *paddingPresetPicker) row() fyne.CanvasObject {
+	return container.NewHBox(
+		widget.NewLabel("Padding preset:"),
+		p.sel,
+		widget.NewButton("Save as Preset...", p.save),
+		p.deleteBtn,
+		p.overlap.row(),
+	)
+}
+
+// refresh reloads the preset names and selects name if it matches the
+// entries, or else the first preset that does
+func (p *paddingPresetPicker) refresh(name string) {
+	var names []string
+	for _, preset := range p.a.cfg.AllPaddingPresets() {
+		names = append(names, preset.Name)
+	}
+	p.sel.Options = names
+	p.sel.Refresh()
+	p.match(name)
+}
+
+// match selects the preset called name if the entries still hold its
+// padding, or else the first preset that matches them ("Custom" if none)
+func (p *paddingPresetPicker) match(name string) {
+	if p.applying {
+		return
+	}
+	current, ok := p.current()
+	selected := ""
+	if ok {
+		if preset, found := p.a.cfg.FindPaddingPreset(name); found && samePadding(preset, current) {
+			selected = name
+		} else {
+			for _, preset := range p.a.cfg.AllPaddingPresets() {
+				if samePadding(preset, current) {
+					selected = preset.Name
+					break
+				}
+			}
+		}
+	}
+
+	p.applying = true
+	if selected == "" {
+		p.sel.ClearSelected()
+	} else {
+		p.sel.SetSelected(selected)
+	}
+	p.applying = false
+	p.updateDelete()
+}
+
+// choose fills in the padding of the preset picked
+func (p *paddingPresetPicker) choose(name string) {
+	if p.applying {
+		return
+	}
+	preset, ok := p.a.cfg.FindPaddingPreset(name)
+	if !ok {
+		return
+	}
+	p.applying = true
+	p.before.SetText(fmt.Sprintf("%g", preset.SecondsBefore))
+	p.after.SetText(fmt.Sprintf("%g", preset.SecondsAfter))
+	p.overlap.set(preset.Overlaps(), preset.OverlapGap)
+	p.applying = false
+	p.updateDelete()
+
+	p.a.cfg.SecondsBefore = preset.SecondsBefore
+	p.a.cfg.SecondsAfter = preset.SecondsAfter
+	p.a.cfg.SetOverlaps(preset.Overlaps(), preset.OverlapGap)
+	p.a.cfg.Save()
+	if p.a.analysisResult != nil {
+		p.a.setPadding(preset)
+	}
+}
+
+// current returns the padding in the entries, named after the selected
+// preset ("" = Custom). ok is false if an entry isn't a number.
+func (p *paddingPresetPicker) current() (preset config.PaddingPreset, ok bool) {
+	before, errBefore := strconv.ParseFloat(strings.TrimSpace(p.before.Text), 64)
+	after, errAfter := strconv.ParseFloat(strings.TrimSpace(p.after.Text), 64)
+	overlaps, gap, gapOK := p.overlap.value()
+	if errBefore != nil || errAfter != nil || !gapOK {
+		return config.PaddingPreset{}, false
+	}
+	return config.PaddingPreset{
+		Name:          p.sel.Selected,
+		SecondsBefore: before,
+		SecondsAfter:  after,
+		MergeOverlaps: overlaps != metadata.OverlapNeverMerge,
+		OverlapPolicy: overlaps,
+		OverlapGap:    gap,
+	}, true
+}
+
+// updateDelete enables Delete Preset for a saved (not built-in) preset
+func (p *paddingPresetPicker) updateDelete() {
+	if p.sel.Selected != "" && !config.IsBuiltinPreset(p.sel.Selected) {
+		p.deleteBtn.Enable()
+	} else {
+		p.deleteBtn.Disable()
+	}
+}
+
+// save asks for a name and saves the padding in the entries as a preset
+func (p *paddingPresetPicker) save() {
+	preset, ok := p.current()
+	if !ok {
+		p.a.showError("Invalid Setting", "Please enter valid seconds before/after")
+		return
+	}
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("e.g. Field Hockey")
+	if !config.IsBuiltinPreset(preset.Name) {
+		nameEntry.SetText(preset.Name)
+	}
+	dialog.ShowForm("Save Padding Preset", "Save", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Name", nameEntry)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			preset.Name = strings.TrimSpace(nameEntry.Text)
+			if err := p.a.cfg.SavePaddingPreset(preset); err != nil {
+				p.a.showError("Padding Preset", err.Error())
+				return
+			}
+			p.a.cfg.Save()
+			p.refresh(preset.Name)
+			if p.a.analysisResult != nil {
+				p.a.setPadding(preset)
+			}
+		}, p.a.window)
+}
+
+// delete removes the selected saved preset
+func (p *paddingPresetPicker) delete() {
+	name := p.sel.Selected
+	if name == "" || config.IsBuiltinPreset(name) {
+		return
+	}
+	dialog.ShowConfirm("Delete Padding Preset", fmt.Sprintf("Delete the %s preset?", name), func(ok bool) {
+		if !ok {
+			return
+		}
+		p.a.cfg.DeletePaddingPreset(name)
+		p.a.cfg.Save()
+		p.refresh("")
+	}, p.a.window)
+}
+
+// samePadding returns true if two paddings cut the same clips
+func samePadding(a, b config.PaddingPreset) bool {
+	if a.Overlaps() == metadata.OverlapMergeBelow && a.OverlapGap != b.OverlapGap {
+		return false
+	}
+	return a.SecondsBefore == b.SecondsBefore && a.SecondsAfter == b.SecondsAfter && a.Overlaps() == b.Overlaps()
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// periodReelModes are the Step 4 choices of which reels to make, by
+// config.PeriodReels value
+var periodReelModes = []struct {
+	value string
+	label string
+}{
+	{"full", "Full reel"},
+	{"both", "Full reel + one per period"},
+	{"periods", "One per period"},
+}
+
+// periodReelModeLabel returns the display label of a period reel mode
+func periodReelModeLabel(value string) string {
+	for _, m := range periodReelModes {
+		if m.value == value {
+			return m.label
+		}
+	}
+	return periodReelModes[0].label
+}
+
+// periodReelModeValue returns the period reel mode with the given display label
+func periodReelModeValue(label string) string {
+	for _, m := range periodReelModes {
+		if m.label == label {
+			return m.value
+		}
+	}
+	return "full"
+}
+
+// reelTarget is one reel to combine in Step 4
+type reelTarget struct {
+	clips  []string
+	output string
+}
+
+// clipPeriod returns the short name of the period a clip is from ("P2"), from
+// the highlights it was extracted from or else its file name
+// (e.g. "041_12-45-10-000_2Period_Ch07.mp4"). "" if unknown.
+func (a *App) clipPeriod(clipPath string) string {
+	if group, ok := a.clipGroup(clipPath); ok && group.Period != "" {
+		return metadata.ShortPeriodName(group.Period)
+	}
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(clipPath), filepath.Ext(clipPath)), "_")
+	if len(parts) >= 4 && strings.HasPrefix(parts[3], "Ch") {
+		return metadata.ShortPeriodName(parts[2])
+	}
+	return ""
+}
+
+// periodReels splits clips (in reel order) into one reel per period, written
+// as Reel_P1.mp4, Reel_P2.mp4, ... in outputDir. Periods split into parts share
+// a reel. Also returns the clips whose period isn't known, which are left out.
+func (a *App) periodReels(clips []string, outputDir string) ([]reelTarget, []string) {
+	var reels []reelTarget
+	index := make(map[string]int) // Short period name -> position in reels
+	var unknown []string
+	for _, clip := range clips {
+		period := a.clipPeriod(clip)
+		if period == "" {
+			unknown = append(unknown, clip)
+			continue
+		}
+		i, ok := index[period]
+		if !ok {
+			i = len(reels)
+			index[period] = i
+			reels = append(reels, reelTarget{
+				output: filepath.Join(outputDir, metadata.ExpandPathTemplate("Reel_{period}", metadata.TemplateValues{Period: period})+".mp4"),
+			})
+		}
+		reels[i].clips = append(reels[i].clips, clip)
+	}
+	return reels, unknown
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"gopro-gui/jobs"
+)
+
+// quietHoursInterval is how often the clock is checked against the quiet hours
+const quietHoursInterval = 30 * time.Second
+
+// startQuietHours holds the job queue during the quiet hours set in Settings,
+// checking the clock every quietHoursInterval
+func (a *App) startQuietHours() {
+	a.checkQuietHours()
+	go func() {
+		ticker := time.NewTicker(quietHoursInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			fyne.Do(a.checkQuietHours)
+		}
+	}()
+}
+
+// quietHours returns the quiet hours set in Settings (zero if none or invalid)
+func (a *App) quietHours() jobs.QuietHours {
+	quiet, err := jobs.ParseQuietHours(a.cfg.QuietHours)
+	if err != nil {
+		return jobs.QuietHours{}
+	}
+	return quiet
+}
+
+// checkQuietHours holds the job queue if it is quiet hours now and releases it
+// otherwise, unless Run Now let jobs run until they end
+func (a *App) checkQuietHours() {
+	quiet := a.quietHours().Contains(time.Now())
+	if !quiet {
+		a.quietOverride = false
+	}
+	a.jobs.Hold(quiet && !a.quietOverride)
+	if a.actions.showQuietHours != nil {
+		a.actions.showQuietHours()
+	}
+}
+
+// runDuringQuietHours lets jobs run until the current quiet hours end
+func (a *App) runDuringQuietHours() {
+	a.quietOverride = true
+	a.checkQuietHours()
+}
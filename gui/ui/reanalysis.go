@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// chapterSelected returns whether a chapter is ticked in Step 2 (all are until unticked)
+func (a *App) chapterSelected(ch metadata.Chapter) bool {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return !a.deselected[ch.Key()]
+}
+
+// setChapterSelected records a chapter being ticked or unticked in Step 2, so
+// the choice survives re-analyzing the folder
+func (a *App) setChapterSelected(ch metadata.Chapter, selected bool) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if selected {
+		delete(a.deselected, ch.Key())
+		return
+	}
+	if a.deselected == nil {
+		a.deselected = make(map[string]bool)
+	}
+	a.deselected[ch.Key()] = true
+}
+
+// carryOver moves the choices made about the previous analysis of the working
+// folder onto its re-run: chapter labels, titles and imported events, Step 2
+// deselections (new speed burst suggestions start unticked) and the highlights each extracted clip covers. Step 3 timing
+// edits belong to the clip files and are kept as they are. Called with
+// sessionMu held; returns a summary of the diff and what was kept.
+func (a *App) carryOver(previous, result *metadata.AnalysisResult) string {
+	labels, events := result.CarryOver(previous)
+	diff := metadata.DiffAnalyses(previous, result)
+
+	// Previous chapter key -> the chapter it is now
+	moved := make(map[string]metadata.Chapter, len(diff.Matched))
+	for _, ch := range result.Chapters {
+		if prevKey, ok := diff.Matched[ch.Key()]; ok {
+			moved[prevKey] = ch
+		}
+	}
+
+	deselected := make(map[string]bool)
+	for key := range a.deselected {
+		if ch, ok := moved[key]; ok {
+			deselected[ch.Key()] = true
+		}
+	}
+	a.deselected = deselected
+
+	groups := make(map[string]metadata.ClipGroup, len(a.clipGroups))
+	for path, group := range a.clipGroups {
+		var chapters []metadata.Chapter
+		for _, ch := range group.Chapters {
+			if now, ok := moved[ch.Key()]; ok {
+				chapters = append(chapters, now)
+			}
+		}
+		if len(chapters) == 0 {
+			continue // Its highlights are gone; the clip itself stays
+		}
+		group.Chapters = chapters
+		group.PrimaryChapter = chapters[0]
+		group.Period = chapters[0].Period
+		groups[path] = group
+	}
+	a.clipGroups = groups
+
+	var kept []string
+	if len(deselected) > 0 {
+		kept = append(kept, fmt.Sprintf("%d deselected chapters", len(deselected)))
+	}
+	if labels > 0 {
+		kept = append(kept, fmt.Sprintf("%d labels", labels))
+	}
+	if events > 0 {
+		kept = append(kept, fmt.Sprintf("%d imported events", events))
+	}
+	if len(a.clipEdits) > 0 {
+		kept = append(kept, fmt.Sprintf("%d clip timing edits", len(a.clipEdits)))
+	}
+
+	// New suggested chapters start unticked, as on a first analysis
+	for _, ch := range result.Chapters {
+		if _, ok := diff.Matched[ch.Key()]; !ok && metadata.IsSuggested(ch) {
+			deselected[ch.Key()] = true
+		}
+	}
+
+	summary := "Re-analysis: " + diff.Summary()
+	if len(kept) > 0 {
+		summary += "; kept " + strings.Join(kept, ", ")
+	}
+	return summary
+}
@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"gopro-gui/config"
+)
+
+// Clip modes in the re-cut dialog
+const (
+	recutReEncode   = "Re-encode (frame-accurate)"
+	recutStreamCopy = "Stream copy (fast, cuts on keyframes)"
+)
+
+// saveProject saves the analysis and edits to a project file, so the game can
+// be re-cut later without scanning and analyzing it again
+func (a *App) saveProject() {
+	a.sessionMu.Lock()
+	session := a.sessionSnapshot()
+	folder := a.workingFolder
+	a.sessionMu.Unlock()
+	if session == nil {
+		a.showError("Nothing to Save", "Please analyze a game in Step 1 first")
+		return
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		writer.Close()
+		path := writer.URI().Path()
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		if err := config.SaveProject(path, session); err != nil {
+			a.showError("Save Failed", err.Error())
+			return
+		}
+		a.showInfo("Project Saved", "Saved "+filepath.Base(path)+"\n\nOpen it from Step 1 to re-cut the game with new settings.")
+	}, a.window)
+	name := a.currentGameName()
+	if name == "" {
+		name = "Game"
+	}
+	saveDialog.SetFileName(name + "_project.json")
+	if folder != "" {
+		if dir, err := storage.ListerForURI(storage.NewFileURI(folder)); err == nil {
+			saveDialog.SetLocation(dir)
+		}
+	}
+	saveDialog.Show()
+}
+
+// openProject loads a project file saved with saveProject and offers to re-cut it
+func (a *App) openProject() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		path := reader.URI().Path()
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		session, err := config.LoadProject(path)
+		if err != nil {
+			a.showError("Open Project", err.Error())
+			return
+		}
+		a.restoreSession(session, session.ExistingClips())
+		a.showRecut()
+	}, a.window)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	fileDialog.Show()
+}
+
+// showRecut shows a dialog for re-cutting the game with new padding, encoder
+// and clip title: every clip is extracted again from the stored analysis
+// (Step 2), and the reel combined again (Step 4), without scanning or
+// analyzing the videos again
+func (a *App) showRecut() {
+	if a.analysisResult == nil {
+		a.showError("Nothing to Re-cut", "Please analyze a game in Step 1 or open a project first")
+		return
+	}
+
+	beforeEntry := widget.NewEntry()
+	beforeEntry.SetText(strconv.FormatFloat(a.cfg.SecondsBefore, 'f', -1, 64))
+	beforeEntry.Validator = numberSetting(0, 300)
+	afterEntry := widget.NewEntry()
+	afterEntry.SetText(strconv.FormatFloat(a.cfg.SecondsAfter, 'f', -1, 64))
+	afterEntry.Validator = numberSetting(0, 300)
+	titleEntry := widget.NewEntry()
+	titleEntry.SetText(a.cfg.ClipTitleTemplate)
+	titleEntry.SetPlaceHolder("e.g. {game} - {period} {clock}")
+
+	modeSelect := widget.NewSelect([]string{recutReEncode, recutStreamCopy}, nil)
+	modeSelect.SetSelected(recutReEncode)
+	if a.hasDirectPeriods() {
+		modeSelect.Disable() // Direct periods are always re-encoded
+	}
+	encoderRadio := widget.NewRadioGroup([]string{encoderGPU, encoderCPU}, nil)
+	encoderRadio.Required = true
+	if a.cfg.PreferCPU {
+		encoderRadio.SetSelected(encoderCPU)
+	} else {
+		encoderRadio.SetSelected(encoderGPU)
+	}
+	combineCheck := widget.NewCheck("Combine a new reel afterwards (Step 4 settings)", nil)
+	combineCheck.SetChecked(true)
+
+	a.sessionMu.Lock()
+	oldClips := append([]string(nil), a.extractedClips...)
+	edits := len(a.clipEdits)
+	a.sessionMu.Unlock()
+
+	note := fmt.Sprintf("The selected chapters are extracted again from the stored analysis. "+
+		"The %d current clips are replaced", len(oldClips))
+	if edits > 0 {
+		note += fmt.Sprintf(" and the Step 3 timing edits of %d clips are dropped", edits)
+	}
+	noteLabel := widget.NewLabel(note + ".")
+	noteLabel.Wrapping = fyne.TextWrapWord
+
+	form := widget.NewForm(
+		widget.NewFormItem("Seconds before highlight", beforeEntry),
+		widget.NewFormItem("Seconds after highlight", afterEntry),
+		widget.NewFormItem("Clip title", titleEntry),
+		widget.NewFormItem("Clips", modeSelect),
+		widget.NewFormItem("Encoder", encoderRadio),
+		widget.NewFormItem("", combineCheck),
+	)
+
+	d := dialog.NewCustomConfirm("Re-cut Game", "Re-cut", "Cancel", container.NewVBox(form, noteLabel), func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := beforeEntry.Validate(); err != nil {
+			a.showError("Invalid Setting", "Seconds before: "+err.Error())
+			return
+		}
+		if err := afterEntry.Validate(); err != nil {
+			a.showError("Invalid Setting", "Seconds after: "+err.Error())
+			return
+		}
+
+		folder := a.cfg.LastOutputDir
+		if len(oldClips) > 0 {
+			folder = filepath.Dir(oldClips[0])
+		}
+		if folder == "" {
+			folder = a.clipOutputFolder()
+		}
+		if folder == "" {
+			a.showError("No Output Folder", "Please select an output folder in Step 2 or set up an output layout")
+			return
+		}
+
+		a.cfg.SecondsBefore, _ = strconv.ParseFloat(strings.TrimSpace(beforeEntry.Text), 64)
+		a.cfg.SecondsAfter, _ = strconv.ParseFloat(strings.TrimSpace(afterEntry.Text), 64)
+		a.cfg.ClipTitleTemplate = strings.TrimSpace(titleEntry.Text)
+		a.cfg.PreferCPU = encoderRadio.Selected == encoderCPU
+		a.ff.SetPreferCPU(a.cfg.PreferCPU)
+		a.cfg.Save()
+
+		a.recut(folder, oldClips, modeSelect.Selected == recutStreamCopy, combineCheck.Checked)
+	}, a.window)
+	d.Resize(fyne.NewSize(600, 420))
+	d.Show()
+}
+
+// recut replaces oldClips with clips extracted again into folder, then
+// rebuilds Step 3 and combines a new reel if combine is set
+func (a *App) recut(folder string, oldClips []string, streamCopy, combine bool) {
+	if a.actions.recutClips == nil {
+		return
+	}
+
+	// The old cuts no longer match the new padding
+	for _, clip := range oldClips {
+		if err := os.Remove(clip); err != nil && !os.IsNotExist(err) {
+			a.showError("Re-cut", fmt.Sprintf("Could not remove %s: %v", filepath.Base(clip), err))
+			return
+		}
+	}
+	a.sessionMu.Lock()
+	a.extractedClips = nil
+	a.clipEdits = nil
+	a.sessionMu.Unlock()
+	a.saveSession()
+
+	a.tabs.SelectIndex(stepExtract)
+	a.actions.recutClips(folder, streamCopy, func() {
+		a.tabItems[stepEdit].Content = a.withStaleBanner(stepEdit, a.createStep3Edit())
+		a.clearStale(stepEdit)
+		a.tabs.Refresh()
+		if combine && a.actions.recutReel != nil {
+			a.tabs.SelectIndex(stepCombine)
+			a.actions.recutReel()
+		}
+	})
+}
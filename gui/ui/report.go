@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+)
+
+// newReport starts the report of a batch ("extract" or "combine") begun now
+func (a *App) newReport(kind string) *pipeline.Report {
+	return &pipeline.Report{
+		Kind:    kind,
+		Game:    a.currentGameName(),
+		Started: time.Now(),
+	}
+}
+
+// writeReport writes a batch's report into folder (see pipeline.WriteReport),
+// after filling in how each output was written from the ffmpeg history: the
+// video encoder and the time ffmpeg took, over all passes. Reports are
+// extras, so a failure to write one only shows in the status returned ("" =
+// written).
+func (a *App) writeReport(folder string, report *pipeline.Report) string {
+	a.sessionMu.Lock()
+	var history []ffmpeg.HistoryEntry
+	for _, entry := range a.history {
+		if !entry.Time.Before(report.Started) {
+			history = append(history, entry)
+		}
+	}
+	a.sessionMu.Unlock()
+
+	for i := range report.Items {
+		item := &report.Items[i]
+		for _, entry := range history {
+			if entry.Output != item.Output {
+				continue
+			}
+			item.EncodeSeconds += entry.Duration.Seconds()
+			if encoder := entry.Encoder(); encoder != "" {
+				item.Encoder = encoder
+			}
+		}
+		if item.MediaSeconds == 0 && item.Output != "" && item.Error == "" {
+			item.MediaSeconds, _ = a.ff.GetDuration(item.Output)
+		}
+	}
+
+	if _, err := pipeline.WriteReport(folder, report); err != nil {
+		return "Report not written: " + err.Error()
+	}
+	return ""
+}
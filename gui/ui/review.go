@@ -0,0 +1,518 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/jobs"
+)
+
+// gameReport is a read-only snapshot of the project state, shown in the Review
+// tab and exported as HTML
+type gameReport struct {
+	Generated     time.Time
+	WorkingFolder string
+	Game          metadata.GameInfo // With defaults filled in (see gameDetails)
+	Periods       []reportPeriod
+	Chapters      []metadata.Chapter
+	Clips         []reportClip
+	MergedGroups  []metadata.ClipGroup
+	Players       []metadata.PlayerStats // Only once players are tagged in Step 3
+	Warnings      []metadata.PeriodWarning
+	ReelPath      string
+	ReelSize      int64 // -1 if the reel file is missing
+	// ProcessingTime is how long the completed jobs of this run took
+	ProcessingTime time.Duration
+	JobCount       int
+}
+
+// reportPeriod is one period in the report
+type reportPeriod struct {
+	Name      string
+	VideoFile string
+	Chapters  int
+}
+
+// reportClip is one extracted clip in the report
+type reportClip struct {
+	Path       string
+	Size       int64  // -1 if the file is missing
+	Highlights string // e.g. "1Period Ch03-04 (merged)"
+	Clock      string // Clock time of the first highlight, e.g. "19:42:05" ("" if unknown)
+	Rating     int    // Step 4 star rating (0 = unrated)
+	Note       string // Step 3 note
+	Players    string // Step 3 player tags, e.g. "#9 Connor Smith, #12 Ava Lee"
+}
+
+// buildReport collects the report from the current project state
+func (a *App) buildReport() *gameReport {
+	game := a.gameDetails()
+	a.sessionMu.Lock()
+	report := &gameReport{
+		Generated:     time.Now(),
+		WorkingFolder: a.workingFolder,
+		Game:          game,
+		ReelPath:      a.reelPath,
+	}
+	result := a.analysisResult
+	clips := append([]string{}, a.extractedClips...)
+	groups := make(map[string]metadata.ClipGroup, len(a.clipGroups))
+	for path, group := range a.clipGroups {
+		groups[path] = group
+	}
+	ratings := make(map[string]int, len(a.clipRatings))
+	for path, stars := range a.clipRatings {
+		ratings[path] = stars
+	}
+	notes := make(map[string]string, len(a.clipNotes))
+	for key, note := range a.clipNotes {
+		notes[key] = note
+	}
+	players := make(map[string][]string, len(a.clipPlayers))
+	for key, numbers := range a.clipPlayers {
+		players[key] = numbers
+	}
+	a.sessionMu.Unlock()
+
+	if result != nil {
+		report.Chapters = result.Chapters
+		report.Warnings = result.Warnings
+		for _, p := range result.Periods {
+			count := 0
+			for _, ch := range result.Chapters {
+				if ch.Period == p.Name {
+					count++
+				}
+			}
+			report.Periods = append(report.Periods, reportPeriod{Name: p.Name, VideoFile: p.VideoFile, Chapters: count})
+		}
+	}
+
+	var tagged []metadata.TaggedClip
+	for _, path := range clips {
+		clip := reportClip{Path: path, Size: fileSize(path), Rating: ratings[path]}
+		if group, ok := groups[path]; ok {
+			clip.Note = notes[group.PrimaryChapter.Key()]
+			if numbers := players[group.PrimaryChapter.Key()]; len(numbers) > 0 {
+				clip.Players = a.playersText(numbers)
+				tagged = append(tagged, metadata.TaggedClip{
+					Players:  numbers,
+					Goal:     a.clipKind(path) == playGoal,
+					Duration: a.clipLength(path, group),
+				})
+			}
+			if !group.PrimaryChapter.ClockTime.IsZero() {
+				clip.Clock = group.PrimaryChapter.ClockTime.Format("15:04:05")
+			}
+			clip.Highlights = fmt.Sprintf("%s Ch%02d", group.Period, group.PrimaryChapter.Number)
+			if group.IsOverlap {
+				clip.Highlights = fmt.Sprintf("%s %s (merged)", group.Period, metadata.ChapterLabel(group))
+				report.MergedGroups = append(report.MergedGroups, group)
+			}
+		}
+		report.Clips = append(report.Clips, clip)
+	}
+	if len(tagged) > 0 {
+		report.Players = metadata.TallyPlayers(a.cfg.Roster, tagged)
+	}
+	sort.Slice(report.MergedGroups, func(i, j int) bool {
+		return report.MergedGroups[i].PrimaryChapter.GlobalOrder < report.MergedGroups[j].PrimaryChapter.GlobalOrder
+	})
+
+	if report.ReelPath != "" {
+		report.ReelSize = fileSize(report.ReelPath)
+	}
+
+	for _, job := range a.jobs.List() {
+		if job.State == jobs.Completed && job.Started != nil && job.Finished != nil {
+			report.ProcessingTime += job.Finished.Sub(*job.Started)
+			report.JobCount++
+		}
+	}
+
+	return report
+}
+
+// fileSize returns a file's size in bytes, or -1 if it doesn't exist
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// outputFolder returns the folder to open from the Review tab: where the clips
+// were extracted, else where the reel was written, else the working folder
+func (r *gameReport) outputFolder() string {
+	switch {
+	case len(r.Clips) > 0:
+		return filepath.Dir(r.Clips[0].Path)
+	case r.ReelPath != "":
+		return filepath.Dir(r.ReelPath)
+	}
+	return r.WorkingFolder
+}
+
+// createReviewTab creates the read-only game summary. It is rebuilt each time
+// the tab is selected, so it always reflects the current state.
+func (a *App) createReviewTab() fyne.CanvasObject {
+	report := a.buildReport()
+
+	openFolderBtn := widget.NewButton("Open Output Folder", func() {
+		folder := report.outputFolder()
+		if folder == "" {
+			return
+		}
+		u, err := url.Parse(storage.NewFileURI(folder).String())
+		if err != nil {
+			a.showError("Open Folder", err.Error())
+			return
+		}
+		if err := a.fyneApp.OpenURL(u); err != nil {
+			a.showError("Open Folder", "Could not open "+folder+": "+err.Error())
+		}
+	})
+	if report.outputFolder() == "" {
+		openFolderBtn.Disable()
+	}
+
+	exportBtn := widget.NewButton("Export HTML...", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := report.writeHTML(writer); err != nil {
+				a.showError("Export Failed", err.Error())
+				return
+			}
+			a.showInfo("Report Exported", "Saved "+writer.URI().Name())
+		}, a.window)
+		saveDialog.SetFileName(fmt.Sprintf("GameReport_%s.html", report.Generated.Format("2006-01-02")))
+		if report.WorkingFolder != "" {
+			if dir, err := storage.ListerForURI(storage.NewFileURI(report.WorkingFolder)); err == nil {
+				saveDialog.SetLocation(dir)
+			}
+		}
+		saveDialog.Show()
+	})
+
+	exportCSVBtn := widget.NewButton("Export CSV...", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := report.writeCSV(writer); err != nil {
+				a.showError("Export Failed", err.Error())
+				return
+			}
+			a.showInfo("Clips Exported", "Saved "+writer.URI().Name())
+		}, a.window)
+		saveDialog.SetFileName(fmt.Sprintf("Clips_%s.csv", report.Generated.Format("2006-01-02")))
+		if report.WorkingFolder != "" {
+			if dir, err := storage.ListerForURI(storage.NewFileURI(report.WorkingFolder)); err == nil {
+				saveDialog.SetLocation(dir)
+			}
+		}
+		saveDialog.Show()
+	})
+	if len(report.Clips) == 0 {
+		exportCSVBtn.Disable()
+	}
+
+	exportPlayersBtn := widget.NewButton("Export Player Stats...", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := report.writePlayersCSV(writer); err != nil {
+				a.showError("Export Failed", err.Error())
+				return
+			}
+			a.showInfo("Player Stats Exported", "Saved "+writer.URI().Name())
+		}, a.window)
+		saveDialog.SetFileName(fmt.Sprintf("Players_%s.csv", report.Generated.Format("2006-01-02")))
+		if report.WorkingFolder != "" {
+			if dir, err := storage.ListerForURI(storage.NewFileURI(report.WorkingFolder)); err == nil {
+				saveDialog.SetLocation(dir)
+			}
+		}
+		saveDialog.Show()
+	})
+	if len(report.Players) == 0 {
+		exportPlayersBtn.Disable()
+	}
+
+	saveProjectBtn := widget.NewButton("Save Project...", a.saveProject)
+	recutBtn := widget.NewButton("Re-cut...", a.showRecut)
+	rinkMapBtn := widget.NewButton("Rink Map...", a.showRinkMap)
+	if a.analysisResult == nil {
+		saveProjectBtn.Disable()
+		recutBtn.Disable()
+		rinkMapBtn.Disable()
+	}
+
+	refreshBtn := widget.NewButton("Refresh", func() {
+		a.refreshReviewTab()
+	})
+
+	monospace := func(text string) *widget.Label {
+		label := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		label.Wrapping = fyne.TextWrapWord
+		return label
+	}
+
+	body := container.NewVBox()
+	if report.Chapters == nil && len(report.Clips) == 0 {
+		body.Add(widget.NewLabel("Nothing to review yet. Analyze a folder in Step 1 first."))
+	} else {
+		if summary := report.Game.Summary(); summary != "" {
+			body.Add(widget.NewLabelWithStyle(summary, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		body.Add(widget.NewLabel("Working folder: " + report.WorkingFolder))
+
+		var periods []string
+		for _, p := range report.Periods {
+			periods = append(periods, fmt.Sprintf("%-12s %3d HiLights  %s", p.Name, p.Chapters, filepath.Base(p.VideoFile)))
+		}
+		body.Add(widget.NewCard("Periods", "", monospace(strings.Join(periods, "\n"))))
+
+		if len(report.Warnings) > 0 {
+			var warnings []string
+			for _, w := range report.Warnings {
+				warnings = append(warnings, w.String())
+			}
+			body.Add(widget.NewCard("Warnings", "", monospace(strings.Join(warnings, "\n"))))
+		}
+
+		var chapters []string
+		for _, ch := range report.Chapters {
+			chapters = append(chapters, fmt.Sprintf("#%03d  %s  %-12s Ch%02d @ %s  %s",
+				ch.GlobalOrder, ch.ClockTime.Format("15:04:05"), ch.Period, ch.Number,
+				metadata.FormatVideoTime(ch.VideoTime), ch.Label))
+		}
+		body.Add(widget.NewCard("Chapters", fmt.Sprintf("%d HiLights", len(report.Chapters)),
+			monospace(strings.Join(chapters, "\n"))))
+
+		var clips []string
+		var totalSize int64
+		for _, clip := range report.Clips {
+			size := "missing"
+			if clip.Size >= 0 {
+				size = formatSize(float64(clip.Size))
+				totalSize += clip.Size
+			}
+			line := fmt.Sprintf("%-10s %s  %s", size, filepath.Base(clip.Path), clip.Highlights)
+			if clip.Note != "" {
+				line += "  " + clip.Note
+			}
+			if clip.Players != "" {
+				line += "  [" + clip.Players + "]"
+			}
+			clips = append(clips, line)
+		}
+		if len(clips) == 0 {
+			clips = append(clips, "No clips extracted yet.")
+		}
+		body.Add(widget.NewCard("Extracted Clips",
+			fmt.Sprintf("%d clips, %s", len(report.Clips), formatSize(float64(totalSize))),
+			monospace(strings.Join(clips, "\n"))))
+
+		if len(report.MergedGroups) > 0 {
+			var merged []string
+			for _, g := range report.MergedGroups {
+				var numbers []string
+				for _, ch := range g.Chapters {
+					numbers = append(numbers, fmt.Sprintf("Ch%02d", ch.Number))
+				}
+				merged = append(merged, fmt.Sprintf("%s %s -> one %.1fs clip", g.Period, strings.Join(numbers, "+"), g.Duration))
+			}
+			body.Add(widget.NewCard("Merged Overlap Groups", "", monospace(strings.Join(merged, "\n"))))
+		}
+
+		if len(report.Players) > 0 {
+			var players []string
+			for _, s := range report.Players {
+				players = append(players, fmt.Sprintf("%-24s %2d goals  %2d clips  %s",
+					s.Player, s.Goals, s.Highlights, formatDuration(s.ScreenTime)))
+			}
+			body.Add(widget.NewCard("Players", "Goals, clips and screen time from the Step 3 tags",
+				monospace(strings.Join(players, "\n"))))
+		}
+
+		reel := "No reel combined yet."
+		if report.ReelPath != "" {
+			reel = report.ReelPath
+			if report.ReelSize >= 0 {
+				reel += " (" + formatSize(float64(report.ReelSize)) + ")"
+			} else {
+				reel += " (missing)"
+			}
+		}
+		body.Add(widget.NewCard("Highlight Reel", "", monospace(reel)))
+
+		body.Add(widget.NewLabel(fmt.Sprintf("Processing time: %s over %d jobs this session",
+			formatDuration(report.ProcessingTime.Seconds()), report.JobCount)))
+	}
+
+	header := container.NewVBox(
+		widget.NewLabel("Review"),
+		widget.NewSeparator(),
+		container.NewHBox(openFolderBtn, exportBtn, exportCSVBtn, exportPlayersBtn, rinkMapBtn, saveProjectBtn, recutBtn, refreshBtn),
+	)
+
+	return container.NewBorder(header, nil, nil, nil, container.NewVScroll(body))
+}
+
+// refreshReviewTab rebuilds the Review tab from the current state
+func (a *App) refreshReviewTab() {
+	if a.reviewTab == nil {
+		return
+	}
+	a.reviewTab.Content = a.createReviewTab()
+	a.tabs.Refresh()
+}
+
+// reportTemplate renders the report as a standalone HTML page
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"clock":     func(t time.Time) string { return t.Format("15:04:05") },
+	"videoTime": metadata.FormatVideoTime,
+	"size": func(n int64) string {
+		if n < 0 {
+			return "missing"
+		}
+		return formatSize(float64(n))
+	},
+	"base":     filepath.Base,
+	"duration": func(d time.Duration) string { return formatDuration(d.Seconds()) },
+	"seconds":  formatDuration,
+	"last":     func(chs []metadata.Chapter) metadata.Chapter { return chs[len(chs)-1] },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Game Report - {{if .Game.Name}}{{.Game.Name}}{{else}}{{base .WorkingFolder}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+.warning { color: #a40; }
+</style>
+</head>
+<body>
+<h1>Game Report</h1>
+{{with .Game.Summary}}<p><strong>{{.}}</strong></p>{{end}}
+<p>Working folder: {{.WorkingFolder}}<br>Generated {{.Generated.Format "2006-01-02 15:04"}}</p>
+
+<h2>Periods</h2>
+<table>
+<tr><th>Period</th><th>Video</th><th>HiLights</th></tr>
+{{range .Periods}}<tr><td>{{.Name}}</td><td>{{base .VideoFile}}</td><td>{{.Chapters}}</td></tr>
+{{end}}</table>
+{{if .Warnings}}
+<h2>Warnings</h2>
+<ul>
+{{range .Warnings}}<li class="warning">{{.Message}}<br><small>{{.Suggestion}}</small></li>
+{{end}}</ul>
+{{end}}
+<h2>Chapters</h2>
+<table>
+<tr><th>#</th><th>Clock time</th><th>Period</th><th>Chapter</th><th>Video time</th><th>Label</th></tr>
+{{range .Chapters}}<tr><td>{{.GlobalOrder}}</td><td>{{clock .ClockTime}}</td><td>{{.Period}}</td><td>{{.Number}}</td><td>{{videoTime .VideoTime}}</td><td>{{.Label}}</td></tr>
+{{end}}</table>
+
+<h2>Extracted Clips</h2>
+{{if .Clips}}<table>
+<tr><th>Clip</th><th>Highlights</th><th>Note</th><th>Players</th><th>Size</th></tr>
+{{range .Clips}}<tr><td>{{base .Path}}</td><td>{{.Highlights}}</td><td>{{.Note}}</td><td>{{.Players}}</td><td>{{size .Size}}</td></tr>
+{{end}}</table>{{else}}<p>No clips extracted.</p>{{end}}
+{{if .Players}}
+<h2>Players</h2>
+<table>
+<tr><th>Player</th><th>Goals</th><th>Clips</th><th>Screen time</th></tr>
+{{range .Players}}<tr><td>{{.Player}}</td><td>{{.Goals}}</td><td>{{.Highlights}}</td><td>{{seconds .ScreenTime}}</td></tr>
+{{end}}</table>
+{{end}}{{if .MergedGroups}}
+<h2>Merged Overlap Groups</h2>
+<table>
+<tr><th>Period</th><th>Chapters</th><th>Clip length</th></tr>
+{{range .MergedGroups}}<tr><td>{{.Period}}</td><td>Ch{{.PrimaryChapter.Number}}-{{(last .Chapters).Number}} ({{len .Chapters}} HiLights)</td><td>{{printf "%.1f" .Duration}}s</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Highlight Reel</h2>
+<p>{{if .ReelPath}}{{.ReelPath}} ({{size .ReelSize}}){{else}}No reel combined.{{end}}</p>
+
+<p>Processing time: {{duration .ProcessingTime}} over {{.JobCount}} jobs</p>
+</body>
+</html>
+`))
+
+// writeHTML writes the report as a standalone HTML page
+func (r *gameReport) writeHTML(w io.Writer) error {
+	if err := reportTemplate.Execute(w, r); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// writeCSV writes the extracted clips as a spreadsheet, one row per clip. Each
+// row starts with the game's details, so a season's sheets can be pasted together.
+func (r *gameReport) writeCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	game := []string{r.Game.Name, r.Game.Date, r.Game.Opponent, r.Game.Location, r.Game.FinalScore}
+	out.Write([]string{"Game", "Date", "Opponent", "Location", "Final score", "Clip", "Highlights", "Clock", "Rating", "Note", "Players", "Size (bytes)"})
+	for _, clip := range r.Clips {
+		var rating, size string
+		if clip.Rating > 0 {
+			rating = strconv.Itoa(clip.Rating)
+		}
+		if clip.Size >= 0 {
+			size = strconv.FormatInt(clip.Size, 10)
+		}
+		out.Write(append(append([]string{}, game...), filepath.Base(clip.Path), clip.Highlights, clip.Clock, rating, clip.Note, clip.Players, size))
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write clips: %w", err)
+	}
+	return nil
+}
+
+// writePlayersCSV writes the player tally as a spreadsheet, one row per
+// player, starting with the game's details like writeCSV, so a season's sheets
+// can be pasted together and summed per player
+func (r *gameReport) writePlayersCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	game := []string{r.Game.Name, r.Game.Date, r.Game.Opponent, r.Game.Location, r.Game.FinalScore}
+	out.Write([]string{"Game", "Date", "Opponent", "Location", "Final score", "Number", "Name", "Goals", "Highlights", "Screen time (s)"})
+	for _, s := range r.Players {
+		out.Write(append(append([]string{}, game...), s.Player.Number, s.Player.Name,
+			strconv.Itoa(s.Goals), strconv.Itoa(s.Highlights), strconv.FormatFloat(s.ScreenTime, 'f', 1, 64)))
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write player stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/jobs"
+)
+
+// Rink diagram geometry in feet (NHL markings)
+const (
+	rinkLength       = 200.0
+	rinkWidth        = rinkLength * metadata.RinkAspect
+	rinkCorner       = 28.0 // Corner radius
+	rinkGoalLine     = 11.0 // From the end boards
+	rinkBlueLine     = 75.0
+	rinkCircle       = 15.0 // Faceoff circle radius
+	rinkFaceoffEnd   = 31.0 // End zone faceoff spots from the end boards
+	rinkFaceoffSide  = 22.0 // and from the middle of the rink
+	rinkMargin       = 4.0
+	rinkDot          = 2.5
+	rinkSelectedDot  = 4.5
+	rinkDiagramWidth = 720 // Pixels in the map dialog
+)
+
+// rinkPeriodColors are the dot colors of the periods, in order
+var rinkPeriodColors = []color.NRGBA{
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+}
+
+// rinkHighlight is a HiLight placed on the rink map
+type rinkHighlight struct {
+	chapter metadata.Chapter
+	at      metadata.RinkPoint
+	period  int // Index of its period, for its color
+}
+
+// rinkMap is where a game's HiLights were on the rink
+type rinkMap struct {
+	periods    []string
+	highlights []rinkHighlight
+	unplaced   int // HiLights without a GPS fix near them
+}
+
+// placeHighlights reads the GPS telemetry of each period's GoPro MP4, fits
+// the rink to where the camera went over the whole game, and places each
+// HiLight where the camera was when it was pressed
+func placeHighlights(result *metadata.AnalysisResult, progress func(n, total int) error) (*rinkMap, error) {
+	m := &rinkMap{}
+	fixes := make(map[string][]metadata.GPSFix)
+	var all []metadata.GPSFix
+	for i, p := range result.Periods {
+		m.periods = append(m.periods, p.Name)
+		if err := progress(i, len(result.Periods)); err != nil {
+			return nil, err
+		}
+		ext := filepath.Ext(p.SourceGoPro)
+		if !strings.EqualFold(ext, ".mp4") && !strings.EqualFold(ext, ffmpeg.Max360Ext) {
+			continue
+		}
+		periodFixes, err := metadata.ReadGPSFixes(p.SourceGoPro)
+		if err != nil {
+			continue // Archived or unreadable: its HiLights go unplaced
+		}
+		fixes[p.Name] = periodFixes
+		all = append(all, periodFixes...)
+	}
+
+	frame, err := metadata.FitRink(all)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range result.Chapters {
+		fix, ok := metadata.FixAt(fixes[ch.Period], ch.VideoTime)
+		if !ok {
+			m.unplaced++
+			continue
+		}
+		period := 0
+		for i, name := range m.periods {
+			if name == ch.Period {
+				period = i
+			}
+		}
+		m.highlights = append(m.highlights, rinkHighlight{chapter: ch, at: frame.Place(fix), period: period})
+	}
+	return m, nil
+}
+
+// describe returns a HiLight's line in the map's list and its tooltip
+func (h rinkHighlight) describe() string {
+	ch := h.chapter
+	text := fmt.Sprintf("#%03d  %s  %s Ch%02d @ %s", ch.GlobalOrder, ch.ClockTime.Format("15:04:05"),
+		ch.Period, ch.Number, metadata.FormatVideoTime(ch.VideoTime))
+	if ch.Label != "" {
+		text += "  " + ch.Label
+	}
+	return text
+}
+
+// svg draws the rink with the HiLights as dots in the colors of their
+// periods, the one at selected (-1 = none) bigger and outlined. turned turns
+// the rink around, for when it came out the wrong way round. Browsers also
+// show each dot's HiLight number and a tooltip; the app's SVG renderer
+// skips text.
+func (m *rinkMap) svg(selected int, turned bool) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="%g %g %g %g" width="%d" height="%d">`+"\n",
+		-rinkMargin, -rinkMargin, rinkLength+2*rinkMargin, rinkWidth+2*rinkMargin,
+		rinkDiagramWidth, int(math.Round(rinkDiagramWidth*(rinkWidth+2*rinkMargin)/(rinkLength+2*rinkMargin))))
+	fmt.Fprintf(&b, `<rect x="0" y="0" width="%g" height="%g" rx="%g" ry="%g" fill="#ffffff" stroke="#333333" stroke-width="1"/>`+"\n",
+		rinkLength, rinkWidth, rinkCorner, rinkCorner)
+
+	// Lines across the rink stop at the boards, which curve in the corners
+	across := func(x float64, stroke string, width float64) {
+		inset := 0.0
+		if edge := min(x, rinkLength-x); edge < rinkCorner {
+			inset = rinkCorner - math.Sqrt(rinkCorner*rinkCorner-(rinkCorner-edge)*(rinkCorner-edge))
+		}
+		fmt.Fprintf(&b, `<line x1="%g" y1="%.2f" x2="%g" y2="%.2f" stroke="%s" stroke-width="%g"/>`+"\n",
+			x, inset, x, rinkWidth-inset, stroke, width)
+	}
+	across(rinkGoalLine, "#cc0000", 0.5)
+	across(rinkLength-rinkGoalLine, "#cc0000", 0.5)
+	across(rinkBlueLine, "#0033cc", 1.5)
+	across(rinkLength-rinkBlueLine, "#0033cc", 1.5)
+	across(rinkLength/2, "#cc0000", 1.5)
+
+	circle := func(x, y float64, stroke string) {
+		fmt.Fprintf(&b, `<circle cx="%g" cy="%g" r="%g" fill="none" stroke="%s" stroke-width="0.5"/>`+"\n", x, y, rinkCircle, stroke)
+	}
+	circle(rinkLength/2, rinkWidth/2, "#0033cc")
+	for _, x := range []float64{rinkFaceoffEnd, rinkLength - rinkFaceoffEnd} {
+		for _, y := range []float64{rinkWidth/2 - rinkFaceoffSide, rinkWidth/2 + rinkFaceoffSide} {
+			circle(x, y, "#cc0000")
+		}
+	}
+
+	// The selected dot goes last, on top
+	order := make([]int, 0, len(m.highlights))
+	for i := range m.highlights {
+		if i != selected {
+			order = append(order, i)
+		}
+	}
+	if selected >= 0 && selected < len(m.highlights) {
+		order = append(order, selected)
+	}
+	for _, i := range order {
+		h := m.highlights[i]
+		x, y := h.at.X, h.at.Y
+		if turned {
+			x, y = 1-x, 1-y
+		}
+		x, y = x*rinkLength, y*rinkWidth
+		c := rinkPeriodColors[h.period%len(rinkPeriodColors)]
+		fill := fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+		r, stroke := rinkDot, "none"
+		if i == selected {
+			r, stroke = rinkSelectedDot, "#000000"
+		}
+		fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="%g" fill="%s" fill-opacity="0.85" stroke="%s" stroke-width="0.8"><title>%s</title></circle>`+"\n",
+			x, y, r, fill, stroke, html.EscapeString(h.describe()))
+		fmt.Fprintf(&b, `<text x="%.2f" y="%.2f" font-size="3.5" font-family="sans-serif" fill="#333333">%d</text>`+"\n",
+			x+r+0.5, y+1.2, h.chapter.GlobalOrder)
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// showRinkMap reads where the camera was at each HiLight as a job, then shows
+// them on a rink diagram
+func (a *App) showRinkMap() {
+	a.sessionMu.Lock()
+	result := a.analysisResult
+	a.sessionMu.Unlock()
+	if result == nil {
+		return
+	}
+
+	a.runJob("rinkmap", "Place HiLights on the rink", func(job *jobs.Job, _ *ffmpeg.FFmpeg) error {
+		m, err := placeHighlights(result, func(n, total int) error {
+			if err := job.Checkpoint(); err != nil {
+				return err
+			}
+			job.Update(float64(n)/float64(total), fmt.Sprintf("Reading GPS of period %d of %d...", n+1, total))
+			return nil
+		})
+		if errors.Is(err, jobs.ErrCancelled) {
+			return err
+		}
+		fyne.Do(func() {
+			switch {
+			case err != nil:
+				a.showError("No Rink Map", "The HiLights can't be placed on the rink: "+err.Error()+".\n\n"+
+					"The map needs GPS from the GoPro MP4s, so it only works outdoors with GPS turned on, "+
+					"before the originals are archived.")
+			case len(m.highlights) == 0:
+				a.showInfo("No Rink Map", "None of the HiLights have a GPS fix near them.")
+			default:
+				a.rinkMapDialog(m)
+			}
+		})
+		return err
+	})
+}
+
+// rinkMapDialog shows the HiLights on the rink: picking one in the list
+// marks its dot
+func (a *App) rinkMapDialog(m *rinkMap) {
+	selected, turned := -1, false
+	image := canvas.NewImageFromResource(nil)
+	image.FillMode = canvas.ImageFillContain
+	image.SetMinSize(fyne.NewSize(rinkDiagramWidth, rinkDiagramWidth*(rinkWidth+2*rinkMargin)/(rinkLength+2*rinkMargin)))
+	draw := func() {
+		image.Resource = fyne.NewStaticResource(fmt.Sprintf("rink-%d-%t.svg", selected, turned), m.svg(selected, turned))
+		image.Refresh()
+	}
+	draw()
+
+	list := widget.NewList(
+		func() int { return len(m.highlights) },
+		func() fyne.CanvasObject {
+			return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(m.highlights[id].describe())
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		draw()
+	}
+
+	legend := container.NewHBox()
+	for i, name := range m.periods {
+		text := canvas.NewText("● "+name, rinkPeriodColors[i%len(rinkPeriodColors)])
+		text.TextStyle = fyne.TextStyle{Bold: true}
+		legend.Add(text)
+	}
+	note := fmt.Sprintf("%d HiLights placed where the camera was when each was pressed.", len(m.highlights))
+	if m.unplaced > 0 {
+		note += fmt.Sprintf(" %d had no GPS fix.", m.unplaced)
+	}
+	note += "\nThe rink is fitted to where the camera went during the game, so it is only as good as the GPS."
+
+	turnBtn := widget.NewButton("Turn Around", func() {
+		turned = !turned
+		draw()
+	})
+	saveBtn := widget.NewButton("Save Image...", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write(m.svg(-1, turned)); err != nil {
+				a.showError("Save Failed", err.Error())
+				return
+			}
+			a.showInfo("Rink Map Saved", "Saved "+writer.URI().Name())
+		}, a.window)
+		saveDialog.SetFileName(fmt.Sprintf("RinkMap_%s.svg", time.Now().Format("2006-01-02")))
+		a.sessionMu.Lock()
+		folder := a.workingFolder
+		a.sessionMu.Unlock()
+		if folder != "" {
+			if dir, err := storage.ListerForURI(storage.NewFileURI(folder)); err == nil {
+				saveDialog.SetLocation(dir)
+			}
+		}
+		saveDialog.Show()
+	})
+
+	top := container.NewVBox(image, container.NewBorder(nil, nil, legend, container.NewHBox(turnBtn, saveBtn)), widget.NewLabel(note))
+	d := dialog.NewCustom("Rink Map", "Close", container.NewBorder(top, nil, nil, nil, list), a.window)
+	d.Resize(fyne.NewSize(800, 750))
+	d.Show()
+}
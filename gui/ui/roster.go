@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// setClipPlayers records the players tagged on the clip whose first highlight
+// is chapter (by jersey number, none clears them) and saves the session
+func (a *App) setClipPlayers(chapter metadata.Chapter, numbers []string) {
+	a.sessionMu.Lock()
+	if a.clipPlayers == nil {
+		a.clipPlayers = make(map[string][]string)
+	}
+	if len(numbers) > 0 {
+		a.clipPlayers[chapter.Key()] = numbers
+	} else {
+		delete(a.clipPlayers, chapter.Key())
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// clipPlayersOf returns the jersey numbers tagged on the clip whose first
+// highlight is chapter
+func (a *App) clipPlayersOf(chapter metadata.Chapter) []string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return append([]string{}, a.clipPlayers[chapter.Key()]...)
+}
+
+// playersText lists tagged players by number and name, e.g. "#9 Connor Smith, #12 Ava Lee"
+func (a *App) playersText(numbers []string) string {
+	var players []string
+	for _, number := range numbers {
+		players = append(players, metadata.FindPlayer(a.cfg.Roster, number).String())
+	}
+	return strings.Join(players, ", ")
+}
+
+// clipLength returns the length in seconds of a clip cut from group, with
+// the Step 3 timing it was re-extracted with, if any
+func (a *App) clipLength(clipPath string, group metadata.ClipGroup) float64 {
+	edit, ok := a.clipEdit(clipPath)
+	if !ok || len(group.Chapters) == 0 {
+		return group.Duration
+	}
+	first, last := group.Chapters[0], group.Chapters[len(group.Chapters)-1]
+	return (last.VideoTime - first.VideoTime).Seconds() + edit.SecondsBefore + edit.SecondsAfter
+}
+
+// rosterText writes the roster one player per line, as ParseRoster reads it
+func rosterText(roster []metadata.Player) string {
+	var lines []string
+	for _, p := range roster {
+		lines = append(lines, strings.TrimSpace(p.Number+" "+p.Name))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showRoster edits the team's roster, typed in or imported from a CSV or text
+// export of a team app, and calls onSaved once it is saved
+func (a *App) showRoster(onSaved func()) {
+	rosterEntry := widget.NewMultiLineEntry()
+	rosterEntry.SetText(rosterText(a.cfg.Roster))
+	rosterEntry.SetPlaceHolder("9 Connor Smith\n12 Ava Lee\n31 Max Roy")
+	rosterEntry.SetMinRowsVisible(12)
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	updateStatus := func(text string) {
+		roster, err := metadata.ParseRoster(text)
+		switch {
+		case err != nil:
+			statusLabel.SetText("Error: " + err.Error())
+		case len(roster) == 0:
+			statusLabel.SetText("No players entered.")
+		default:
+			statusLabel.SetText(fmt.Sprintf("%d players", len(roster)))
+		}
+	}
+	rosterEntry.OnChanged = updateStatus
+	updateStatus(rosterEntry.Text)
+
+	importBtn := widget.NewButton("Import...", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				a.showError("Import Failed", err.Error())
+				return
+			}
+			rosterEntry.SetText(string(data))
+		}, a.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".txt", ".tsv"}))
+		fileDialog.Show()
+	})
+
+	content := container.NewVBox(
+		widget.NewLabel("Enter one player per line as <number> <name>, or import a CSV with number and name columns.\n"+
+			"Players are tagged on clips in Step 3 by number, so fixing a name later updates every game."),
+		container.NewHBox(importBtn),
+	)
+	body := container.NewBorder(content, statusLabel, nil, nil, rosterEntry)
+
+	d := dialog.NewCustomConfirm("Roster", "Save", "Cancel", body, func(save bool) {
+		if !save {
+			return
+		}
+		roster, err := metadata.ParseRoster(rosterEntry.Text)
+		if err != nil {
+			a.showError("Roster Not Saved", err.Error())
+			return
+		}
+		a.cfg.Roster = roster
+		a.cfg.Save()
+		if onSaved != nil {
+			onSaved()
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(600, 550))
+	d.Show()
+}
+
+// showPlayerPicker tags players from the roster on the clip whose first
+// highlight is chapter, and calls onDone with the numbers tagged
+func (a *App) showPlayerPicker(chapter metadata.Chapter, onDone func(numbers []string)) {
+	if len(a.cfg.Roster) == 0 {
+		dialog.ShowConfirm("No Roster", "Enter the team's roster first?", func(ok bool) {
+			if ok {
+				a.showRoster(func() { a.showPlayerPicker(chapter, onDone) })
+			}
+		}, a.window)
+		return
+	}
+
+	tagged := make(map[string]bool)
+	for _, number := range a.clipPlayersOf(chapter) {
+		tagged[number] = true
+	}
+
+	// Players tagged before they left the roster stay ticked
+	players := append([]metadata.Player{}, a.cfg.Roster...)
+	onRoster := make(map[string]bool, len(players))
+	for _, p := range players {
+		onRoster[p.Number] = true
+	}
+	for _, number := range a.clipPlayersOf(chapter) {
+		if !onRoster[number] {
+			players = append(players, metadata.Player{Number: number})
+		}
+	}
+
+	checks := container.NewGridWithColumns(3)
+	var boxes []*widget.Check
+	for _, p := range players {
+		check := widget.NewCheck(p.String(), nil)
+		check.SetChecked(tagged[p.Number])
+		boxes = append(boxes, check)
+		checks.Add(check)
+	}
+	scroll := container.NewVScroll(checks)
+	scroll.SetMinSize(fyne.NewSize(520, 300))
+
+	d := dialog.NewCustomConfirm("Players in This Clip", "Save", "Cancel", scroll, func(save bool) {
+		if !save {
+			return
+		}
+		var numbers []string
+		for i, check := range boxes {
+			if check.Checked {
+				numbers = append(numbers, players[i].Number)
+			}
+		}
+		a.setClipPlayers(chapter, numbers)
+		onDone(numbers)
+	}, a.window)
+	d.Show()
+}
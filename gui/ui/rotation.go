@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+)
+
+// rotationOptions are the choices for a period's rotation, "Auto" meaning
+// the video's own rotation flag is used
+var rotationOptions = []string{"Auto", "0°", "90° clockwise", "180°", "270° clockwise"}
+
+// rotationDegrees maps a rotation option to degrees clockwise (-1 = Auto)
+func rotationDegrees(option string) int {
+	switch option {
+	case "0°":
+		return 0
+	case "90° clockwise":
+		return 90
+	case "180°":
+		return 180
+	case "270° clockwise":
+		return 270
+	}
+	return -1
+}
+
+// rotationOption returns the option for degrees clockwise (-1 = Auto)
+func rotationOption(deg int) string {
+	for _, option := range rotationOptions {
+		if rotationDegrees(option) == deg {
+			return option
+		}
+	}
+	return "Auto"
+}
+
+// periodRotation returns the period's rotation override in degrees clockwise,
+// or -1 if its video's rotation flag is used
+func (a *App) periodRotation(period string) int {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	deg, ok := a.periodRotations[period]
+	if !ok {
+		return -1
+	}
+	return deg
+}
+
+// setPeriodRotations replaces the per-period rotation overrides (-1 = Auto),
+// applies them and saves the session
+func (a *App) setPeriodRotations(rotations map[string]int) {
+	a.sessionMu.Lock()
+	a.periodRotations = make(map[string]int)
+	for period, deg := range rotations {
+		if deg >= 0 {
+			a.periodRotations[period] = deg
+		}
+	}
+	a.sessionMu.Unlock()
+
+	a.applyRotations()
+	a.saveSession()
+}
+
+// applyRotations passes the periods' rotation overrides to ffmpeg, keyed by
+// their video files
+func (a *App) applyRotations() {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	a.ff.ClearRotationOverrides()
+	for _, p := range a.periods {
+		if deg, ok := a.periodRotations[p.Name]; ok {
+			a.ff.SetRotationOverride(p.VideoFile, deg)
+		}
+	}
+}
+
+// describeRotationFlag describes a period video's own rotation flag for display
+func (a *App) describeRotationFlag(videoFile string) string {
+	o, err := a.ff.GetOrientation(videoFile)
+	switch {
+	case err != nil:
+		return "rotation flag unknown"
+	case o.Rotation == 0:
+		return "no rotation flag"
+	}
+	return fmt.Sprintf("flagged %d° clockwise", o.Rotation)
+}
@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// setScoreboard sets the session's score timeline and opponent and saves the session
+func (a *App) setScoreboard(timeline, opponent string) {
+	a.sessionMu.Lock()
+	a.scoreTimeline = timeline
+	a.gameInfo.Opponent = strings.TrimSpace(opponent)
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// periodNames returns the session's period names in game order
+func (a *App) periodNames() []string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	names := make([]string, len(a.periods))
+	for i, p := range a.periods {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// clipClock returns the period a clip is from, the clock time of day its
+// first frame was recorded at, and its length in seconds. ok is false if the
+// clip's highlights or their clock times aren't known.
+func (a *App) clipClock(clipPath string) (period string, start time.Duration, duration float64, ok bool) {
+	group, ok := a.clipGroup(clipPath)
+	if !ok || group.PrimaryChapter.ClockTime.IsZero() {
+		return "", 0, 0, false
+	}
+	first := group.PrimaryChapter
+
+	startSec, duration := group.StartTime, group.Duration
+	if edit, edited := a.clipEdit(clipPath); edited {
+		startSec = max(first.VideoTime.Seconds()-edit.SecondsBefore, 0)
+		duration = edit.SecondsBefore + edit.SecondsAfter
+	}
+
+	lead := time.Duration((first.VideoTime.Seconds() - startSec) * float64(time.Second))
+	return group.Period, metadata.TimeOfDay(first.ClockTime) - lead, duration, true
+}
+
+// scoreText formats the scoreboard, e.g. "Hawks 2 - 1 Wolves  P2"
+func (a *App) scoreText(home, away int, period string) string {
+	a.sessionMu.Lock()
+	homeName, awayName := a.teamName(), a.gameInfo.Opponent
+	a.sessionMu.Unlock()
+	if homeName == "" {
+		homeName = "Home"
+	}
+	if awayName == "" {
+		awayName = "Away"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %d - %d %s  %s", homeName, home, away, awayName, metadata.ShortPeriodName(period)))
+}
+
+// reelScoreboard returns the scoreboard to burn into a reel of clips (nil if
+// disabled or no score was entered). durations are the clips' measured
+// lengths in seconds; clips without a known clock time get no scoreboard.
+func (a *App) reelScoreboard(clips []string, durations map[string]float64) (*ffmpeg.Scoreboard, error) {
+	if !a.cfg.ScoreboardOnReel {
+		return nil, nil
+	}
+	a.sessionMu.Lock()
+	text := a.scoreTimeline
+	a.sessionMu.Unlock()
+
+	timeline, err := metadata.ParseScoreTimeline(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid score timeline: %w", err)
+	}
+	if len(timeline) == 0 {
+		return nil, nil
+	}
+
+	periods := a.periodNames()
+	scoreboard := &ffmpeg.Scoreboard{
+		Corner:   a.cfg.ScoreboardCorner,
+		FontFile: ffmpeg.DefaultFontFile(),
+		Clips:    make(map[string][]ffmpeg.ScoreText),
+	}
+	for _, clip := range clips {
+		period, start, duration, ok := a.clipClock(clip)
+		if !ok {
+			continue
+		}
+		if measured := durations[clip]; measured > 0 {
+			duration = measured
+		}
+		for _, span := range timeline.ClipScores(periods, period, start, duration) {
+			scoreboard.Clips[clip] = append(scoreboard.Clips[clip], ffmpeg.ScoreText{
+				Start: span.Offset,
+				Text:  a.scoreText(span.Home, span.Away, period),
+			})
+		}
+	}
+	return scoreboard, nil
+}
+
+// showScoreboardSettings shows a dialog for entering the game's score
+// timeline and the scoreboard burned into re-encoded reels
+func (a *App) showScoreboardSettings() {
+	enableCheck := widget.NewCheck("Burn scoreboard into re-encoded reels", nil)
+	enableCheck.SetChecked(a.cfg.ScoreboardOnReel)
+
+	cornerSelect := widget.NewSelect(ffmpeg.WatermarkCorners, nil)
+	cornerSelect.SetSelected(a.cfg.ScoreboardCorner)
+	if cornerSelect.Selected == "" {
+		cornerSelect.SetSelected("top-left")
+	}
+
+	a.sessionMu.Lock()
+	timelineText, opponent, teamName := a.scoreTimeline, a.gameInfo.Opponent, a.teamName()
+	a.sessionMu.Unlock()
+
+	opponentEntry := widget.NewEntry()
+	opponentEntry.SetText(opponent)
+	opponentEntry.SetPlaceHolder("Away")
+
+	timelineEntry := widget.NewMultiLineEntry()
+	timelineEntry.SetText(timelineText)
+	timelineEntry.SetPlaceHolder("P1 14:05 1-0\nP2 14:52 1-1\nP3 15:31 2-1")
+	timelineEntry.SetMinRowsVisible(8)
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.Wrapping = fyne.TextWrapWord
+	updateStatus := func(text string) {
+		timeline, err := metadata.ParseScoreTimeline(text)
+		switch {
+		case err != nil:
+			statusLabel.SetText("Error: " + err.Error())
+		case len(timeline) == 0:
+			statusLabel.SetText("No score changes entered - the scoreboard is left out.")
+		default:
+			last := timeline[len(timeline)-1]
+			statusLabel.SetText(fmt.Sprintf("%d score changes, final score %d-%d", len(timeline), last.Home, last.Away))
+		}
+	}
+	timelineEntry.OnChanged = updateStatus
+	updateStatus(timelineText)
+
+	if teamName == "" {
+		teamName = "Home (set the team in Game Details)"
+	}
+
+	content := container.NewVBox(
+		widget.NewLabel("Enter each score change as <period> <clock> <home>-<away>, one per line.\n"+
+			"Clock times are the camera clock shown next to each highlight in Step 2 (HH:MM or HH:MM:SS).\n"+
+			"Each clip shows the score at its clock time, updating when a goal falls inside it."),
+		enableCheck,
+		widget.NewForm(
+			widget.NewFormItem("Home", widget.NewLabel(teamName)),
+			widget.NewFormItem("Away", opponentEntry),
+			widget.NewFormItem("Position", cornerSelect),
+		),
+	)
+	body := container.NewBorder(content, statusLabel, nil, nil, timelineEntry)
+
+	d := dialog.NewCustomConfirm("Scoreboard", "Save", "Cancel", body, func(save bool) {
+		if !save {
+			return
+		}
+		a.cfg.ScoreboardOnReel = enableCheck.Checked
+		a.cfg.ScoreboardCorner = cornerSelect.Selected
+		a.cfg.Save()
+		a.setScoreboard(timelineEntry.Text, opponentEntry.Text)
+	}, a.window)
+	d.Resize(fyne.NewSize(650, 550))
+	d.Show()
+}
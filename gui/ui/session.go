@@ -0,0 +1,467 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/config"
+)
+
+// autoSaveInterval is how often the session is saved in the background
+// (it is also saved after analysis and after every extracted clip)
+const autoSaveInterval = 30 * time.Second
+
+// saveSession writes the current work state to the recovery file
+func (a *App) saveSession() {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	if session := a.sessionSnapshot(); session != nil {
+		config.SaveSession(session)
+	}
+}
+
+// sessionSnapshot returns a copy of the current work state, or nil before
+// the first analysis (nothing worth restoring yet). a.sessionMu must be held.
+func (a *App) sessionSnapshot() *config.Session {
+	if a.analysisResult == nil {
+		return nil
+	}
+
+	edits := make(map[string]config.ClipEdit, len(a.clipEdits))
+	for path, edit := range a.clipEdits {
+		edits[path] = edit
+	}
+
+	colors := make(map[string]ffmpeg.ColorCorrection, len(a.periodColors))
+	for period, color := range a.periodColors {
+		colors[period] = color
+	}
+
+	rotations := make(map[string]int, len(a.periodRotations))
+	for period, deg := range a.periodRotations {
+		rotations[period] = deg
+	}
+
+	groups := make(map[string]metadata.ClipGroup, len(a.clipGroups))
+	for path, group := range a.clipGroups {
+		groups[path] = group
+	}
+
+	ratings := make(map[string]int, len(a.clipRatings))
+	for path, stars := range a.clipRatings {
+		ratings[path] = stars
+	}
+
+	pans := make(map[string]float64, len(a.clipPans))
+	for path, x := range a.clipPans {
+		pans[path] = x
+	}
+
+	notes := make(map[string]string, len(a.clipNotes))
+	for key, note := range a.clipNotes {
+		notes[key] = note
+	}
+
+	players := make(map[string][]string, len(a.clipPlayers))
+	for key, numbers := range a.clipPlayers {
+		players[key] = append([]string{}, numbers...)
+	}
+
+	audio := make(map[string]ffmpeg.ClipAudio, len(a.clipAudio))
+	for path, treatment := range a.clipAudio {
+		audio[path] = treatment
+	}
+
+	trims := make(map[string]ffmpeg.Trim, len(a.exportTrims))
+	for path, trim := range a.exportTrims {
+		trims[path] = trim
+	}
+
+	timecodes := make(map[string]string, len(a.manualTimecodes))
+	for path, start := range a.manualTimecodes {
+		timecodes[path] = start
+	}
+
+	var deselected []string
+	for key := range a.deselected {
+		deselected = append(deselected, key)
+	}
+	sort.Strings(deselected)
+
+	return &config.Session{
+		WorkingFolder:   a.workingFolder,
+		Periods:         a.periods,
+		Analysis:        a.analysisResult,
+		ExtractedClips:  append([]string{}, a.extractedClips...),
+		ClipEdits:       edits,
+		PeriodColors:    colors,
+		PeriodRotations: rotations,
+		ClipGroups:      groups,
+		Deselected:      deselected,
+		ClipRatings:     ratings,
+		ClipPans:        pans,
+		ClipNotes:       notes,
+		ClipPlayers:     players,
+		ClipAudio:       audio,
+		ReelPath:        a.reelPath,
+		GameInfo:        a.gameInfo,
+		ScoreTimeline:   a.scoreTimeline,
+		ClockZone:       a.clockZone,
+		ManualTimecodes: timecodes,
+		Game:            a.game,
+		History:         append([]ffmpeg.HistoryEntry{}, a.history...),
+		Padding:         a.padding,
+		ExportTrims:     trims,
+		AudioTrack:      a.audioTrack,
+	}
+}
+
+// setAnalysis makes a new analysis current (from Step 1 or the control API)
+// and saves it. A new folder starts from scratch. Re-analyzing the same folder
+// keeps period color corrections, rotations, the audio track, the game details
+// and score timeline, and carries chapter choices and Step 3 edits over to the
+// matching chapters (see carryOver); the returned summary says what was kept
+// ("" for a new folder).
+// game is which of the folder's games result is (0 = the folder holds one);
+// another game of the same folder also starts from scratch.
+func (a *App) setAnalysis(result *metadata.AnalysisResult, periods []metadata.Period, workingFolder string, game int) string {
+	var summary string
+	a.sessionMu.Lock()
+	if workingFolder != a.workingFolder || game != a.game {
+		a.periodColors = nil
+		a.periodRotations = nil
+		a.gameInfo = metadata.GameInfo{}
+		a.scoreTimeline = ""
+		a.clipEdits = nil
+		a.clipGroups = nil
+		a.deselected = nil
+		a.clipRatings = nil
+		a.clipPans = nil
+		a.clipNotes = nil
+		a.clipPlayers = nil
+		a.clipAudio = nil
+		a.history = nil
+		a.padding = nil
+		a.exportTrims = nil
+		a.audioTrack = 0
+		// Start times entered for another game's videos
+		videos := make(map[string]bool, len(periods))
+		for _, p := range periods {
+			videos[p.VideoFile] = true
+		}
+		for path := range a.manualTimecodes {
+			if !videos[path] {
+				delete(a.manualTimecodes, path)
+			}
+		}
+		// Suggested chapters (speed bursts, intervals, audio spikes) start unticked, so only the ones wanted are extracted
+		for _, ch := range result.Chapters {
+			if metadata.IsSuggested(ch) {
+				if a.deselected == nil {
+					a.deselected = make(map[string]bool)
+				}
+				a.deselected[ch.Key()] = true
+			}
+		}
+	} else if a.analysisResult != nil {
+		summary = a.carryOver(a.analysisResult, result)
+	}
+	hadClips := len(a.extractedClips) > 0
+	a.analysisResult = result
+	a.periods = periods
+	a.workingFolder = workingFolder
+	a.game = game
+	a.reelPath = ""
+	a.sessionMu.Unlock()
+	a.useWorkDir(workingFolder)
+	a.applyRotations()
+	a.applyAudioTrack()
+
+	a.cfg.Periods = periods
+	a.cfg.Save()
+	a.saveSession()
+
+	// The clips and anything made from them were cut from the old analysis
+	if hadClips {
+		fyne.Do(func() {
+			a.markStale(stepExtract, "the videos were analyzed again after the clips were extracted")
+		})
+	}
+	return summary
+}
+
+// setClipEdit records the Step 3 timing and audio a clip was re-extracted
+// with and saves the session
+func (a *App) setClipEdit(clipPath string, before, after float64, audio ffmpeg.ClipAudio) {
+	a.sessionMu.Lock()
+	if a.clipEdits == nil {
+		a.clipEdits = make(map[string]config.ClipEdit)
+	}
+	a.clipEdits[clipPath] = config.ClipEdit{SecondsBefore: before, SecondsAfter: after, Audio: audio}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// clearClipEdit forgets the Step 3 edit of a clip cut again in Step 2, which
+// has Step 2's padding and the camera's sound
+func (a *App) clearClipEdit(clipPath string) {
+	a.sessionMu.Lock()
+	delete(a.clipEdits, clipPath)
+	a.sessionMu.Unlock()
+}
+
+// setClipGroup records which highlights an extracted clip covers, for the Review tab
+func (a *App) setClipGroup(clipPath string, group metadata.ClipGroup) {
+	a.sessionMu.Lock()
+	if a.clipGroups == nil {
+		a.clipGroups = make(map[string]metadata.ClipGroup)
+	}
+	a.clipGroups[clipPath] = group
+	a.sessionMu.Unlock()
+}
+
+// setReelPath records the reel combined in Step 4 and saves the session
+func (a *App) setReelPath(path string) {
+	a.sessionMu.Lock()
+	a.reelPath = path
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// setClipRating records a clip's Step 4 star rating (0 = unrated) and saves the session
+func (a *App) setClipRating(clipPath string, stars int) {
+	a.sessionMu.Lock()
+	if a.clipRatings == nil {
+		a.clipRatings = make(map[string]int)
+	}
+	if stars > 0 {
+		a.clipRatings[clipPath] = stars
+	} else {
+		delete(a.clipRatings, clipPath)
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// clipRating returns a clip's Step 4 star rating (0 = unrated)
+func (a *App) clipRating(clipPath string) int {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.clipRatings[clipPath]
+}
+
+// setClipPan records where a vertical reel crops a clip (0 = left, 1 = right)
+// and saves the session
+func (a *App) setClipPan(clipPath string, x float64) {
+	a.sessionMu.Lock()
+	if a.clipPans == nil {
+		a.clipPans = make(map[string]float64)
+	}
+	a.clipPans[clipPath] = x
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// clipPan returns where a vertical reel crops a clip (centered unless set)
+func (a *App) clipPan(clipPath string) float64 {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if x, ok := a.clipPans[clipPath]; ok {
+		return x
+	}
+	return 0.5
+}
+
+// setExportTrim records the part of a MOV the full game export keeps (a zero
+// trim keeps all of it) and saves the session
+func (a *App) setExportTrim(movPath string, trim ffmpeg.Trim) {
+	a.sessionMu.Lock()
+	if trim.IsZero() {
+		delete(a.exportTrims, movPath)
+	} else {
+		if a.exportTrims == nil {
+			a.exportTrims = make(map[string]ffmpeg.Trim)
+		}
+		a.exportTrims[movPath] = trim
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// exportTrim returns the part of a MOV the full game export keeps (zero = all of it)
+func (a *App) exportTrim(movPath string) ffmpeg.Trim {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.exportTrims[movPath]
+}
+
+// setClipNote records the Step 3 note of the clip whose first highlight is
+// chapter ("" clears it) and saves the session
+func (a *App) setClipNote(chapter metadata.Chapter, note string) {
+	a.sessionMu.Lock()
+	if a.clipNotes == nil {
+		a.clipNotes = make(map[string]string)
+	}
+	if note != "" {
+		a.clipNotes[chapter.Key()] = note
+	} else {
+		delete(a.clipNotes, chapter.Key())
+	}
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// clipNote returns the Step 3 note of the clip whose first highlight is chapter
+func (a *App) clipNote(chapter metadata.Chapter) string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.clipNotes[chapter.Key()]
+}
+
+// clipGroup returns the highlights an extracted clip covers, if recorded
+func (a *App) clipGroup(clipPath string) (metadata.ClipGroup, bool) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	group, ok := a.clipGroups[clipPath]
+	return group, ok
+}
+
+// clipEdit returns the Step 3 timing recorded for a clip, if any
+func (a *App) clipEdit(clipPath string) (config.ClipEdit, bool) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	edit, ok := a.clipEdits[clipPath]
+	return edit, ok
+}
+
+// hasDirectPeriods returns true if any analyzed period cuts its clips straight
+// from a GoPro MP4 (see metadata.Period.IsDirect)
+func (a *App) hasDirectPeriods() bool {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	for _, p := range a.periods {
+		if p.IsDirect() {
+			return true
+		}
+	}
+	return false
+}
+
+// startAutoSave saves the session periodically until the app exits
+func (a *App) startAutoSave() {
+	go func() {
+		ticker := time.NewTicker(autoSaveInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.saveSession()
+		}
+	}()
+}
+
+// offerSessionRestore checks for a session left behind by a crash and asks
+// whether to restore it
+func (a *App) offerSessionRestore() {
+	session, err := config.LoadSession()
+	if err != nil || session == nil || session.Analysis == nil {
+		return
+	}
+
+	existing := session.ExistingClips()
+	msg := fmt.Sprintf("The previous session was interrupted (last saved %s).\n\n"+
+		"Working folder: %s\n%d chapters analyzed, %d of %d clips written.\n\nRestore it?",
+		session.SavedAt.Format("Jan 2 15:04"),
+		session.WorkingFolder,
+		len(session.Analysis.Chapters), len(existing), len(session.ExtractedClips))
+
+	dialog.ShowConfirm("Restore Session", msg, func(restore bool) {
+		if !restore {
+			config.ClearSession()
+			return
+		}
+		a.restoreSession(session, existing)
+	}, a.window)
+}
+
+// restoreSession loads a recovered session into the app and rebuilds the steps
+// so they pick up the restored state
+func (a *App) restoreSession(session *config.Session, clips []string) {
+	a.sessionMu.Lock()
+	if session.WorkingFolder != a.workingFolder || session.Game == 0 {
+		// The games of another folder, or of an analysis before it was split
+		a.games = nil
+		a.gameSessions = nil
+	}
+	a.workingFolder = session.WorkingFolder
+	a.periods = session.Periods
+	a.analysisResult = session.Analysis
+	a.extractedClips = clips
+	a.clipEdits = session.ClipEdits
+	a.periodColors = session.PeriodColors
+	a.periodRotations = session.PeriodRotations
+	a.clipGroups = session.ClipGroups
+	a.deselected = make(map[string]bool, len(session.Deselected))
+	for _, key := range session.Deselected {
+		a.deselected[key] = true
+	}
+	a.clipRatings = session.ClipRatings
+	a.clipPans = session.ClipPans
+	a.clipNotes = session.ClipNotes
+	a.clipPlayers = session.ClipPlayers
+	a.clipAudio = session.ClipAudio
+	a.reelPath = session.ReelPath
+	a.gameInfo = session.GameInfo
+	if a.gameInfo.Name == "" && a.gameInfo.Opponent == "" {
+		// Saved before GameInfo
+		a.gameInfo.Name, a.gameInfo.Opponent = session.GameName, session.Opponent
+	}
+	a.scoreTimeline = session.ScoreTimeline
+	a.clockZone = session.ClockZone
+	a.manualTimecodes = session.ManualTimecodes
+	a.game = session.Game
+	a.history = session.History
+	a.padding = session.Padding
+	a.exportTrims = session.ExportTrims
+	a.audioTrack = session.AudioTrack
+	a.sessionMu.Unlock()
+	a.useWorkDir(session.WorkingFolder)
+	a.applyRotations()
+	a.applyAudioTrack()
+	a.applyPadding(session.Padding)
+	if a.actions.showGames != nil {
+		a.actions.showGames()
+	}
+
+	// Nothing restored is out of date, and the padding it was cut with is unknown
+	a.stale = nil
+	a.clipPadding = nil
+	a.tabItems[stepExtract].Content = a.withStaleBanner(stepExtract, a.createStep2Extract())
+	a.tabItems[stepEdit].Content = a.withStaleBanner(stepEdit, a.createStep3Edit())
+	a.tabItems[stepCombine].Content = a.withStaleBanner(stepCombine, a.createStep4Combine())
+	for step := stepExtract; step <= lastDependentStep; step++ {
+		a.updateStepTitle(step)
+	}
+	a.markStepComplete(stepSetup)
+	if len(clips) > 0 {
+		a.markStepComplete(stepExtract)
+		a.tabs.SelectIndex(stepEdit)
+	} else {
+		a.tabs.SelectIndex(stepExtract)
+	}
+	a.tabs.Refresh()
+
+	a.saveSession()
+}
@@ -0,0 +1,533 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+
+	"gopro-gui/cloud"
+	"gopro-gui/config"
+	"gopro-gui/jobs"
+)
+
+// themeNames maps config.Theme values to their display names
+var themeNames = []struct {
+	value string
+	label string
+}{
+	{"system", "Follow system"},
+	{"light", "Light"},
+	{"dark", "Dark"},
+}
+
+// variantTheme is the default theme locked to its light or dark variant
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+// Color implements fyne.Theme
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
+// applyTheme switches the app to the configured theme
+func (a *App) applyTheme() {
+	switch a.cfg.Theme {
+	case "light":
+		a.fyneApp.Settings().SetTheme(variantTheme{theme.DefaultTheme(), theme.VariantLight})
+	case "dark":
+		a.fyneApp.Settings().SetTheme(variantTheme{theme.DefaultTheme(), theme.VariantDark})
+	default:
+		a.fyneApp.Settings().SetTheme(theme.DefaultTheme())
+	}
+}
+
+// setSettingsSync registers a function that copies the settings into a step's
+// own fields (e.g. Step 2's padding entries) after they change in the Settings
+// tab. A rebuilt step replaces its function.
+func (a *App) setSettingsSync(step int, sync func()) {
+	if a.settingsSync == nil {
+		a.settingsSync = make(map[int]func())
+	}
+	a.settingsSync[step] = sync
+}
+
+// syncSettings updates the steps after a change in the Settings tab
+func (a *App) syncSettings() {
+	for _, sync := range a.settingsSync {
+		sync()
+	}
+}
+
+// numberSetting returns a validator for a number between lo and hi
+func numberSetting(lo, hi float64) fyne.StringValidator {
+	return func(text string) error {
+		value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return fmt.Errorf("enter a number")
+		}
+		if value < lo || value > hi {
+			return fmt.Errorf("must be between %g and %g", lo, hi)
+		}
+		return nil
+	}
+}
+
+// settingEntry returns an entry for a setting. Each edit that passes validate
+// (nil = any text) is applied with set and saved right away.
+func (a *App) settingEntry(value string, validate fyne.StringValidator, set func(string)) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(value)
+	entry.Validator = validate
+	entry.OnChanged = func(text string) {
+		if validate != nil && validate(text) != nil {
+			return
+		}
+		set(strings.TrimSpace(text))
+		a.cfg.Save()
+	}
+	return entry
+}
+
+// numberEntry returns an entry for a numeric setting between lo and hi
+func (a *App) numberEntry(value, lo, hi float64, set func(float64)) *widget.Entry {
+	return a.settingEntry(strconv.FormatFloat(value, 'f', -1, 64), numberSetting(lo, hi), func(text string) {
+		number, _ := strconv.ParseFloat(text, 64)
+		set(number)
+		a.syncSettings()
+	})
+}
+
+// templateEntry returns an entry for a name template
+func (a *App) templateEntry(value string, set func(string)) *widget.Entry {
+	return a.settingEntry(value, func(text string) error {
+		if strings.Count(text, "{") != strings.Count(text, "}") {
+			return fmt.Errorf("unbalanced braces")
+		}
+		return nil
+	}, set)
+}
+
+// createSettingsTab creates the Settings tab, which edits every persistent
+// option. Changes are saved as they are made.
+func (a *App) createSettingsTab() fyne.CanvasObject {
+	// Clip timing
+	autoClipAudioCheck := widget.NewCheck("Suggest clips where the sound gets suddenly louder (cheers, horn, whistle)", func(checked bool) {
+		a.cfg.AutoClipAudio = checked
+		a.cfg.Save()
+	})
+	autoClipAudioCheck.SetChecked(a.cfg.AutoClipAudio)
+	deadAirFreezeCheck := widget.NewCheck("Also trim where the picture stands still", func(checked bool) {
+		a.cfg.DeadAirFreeze = checked
+		a.cfg.Save()
+	})
+	deadAirFreezeCheck.SetChecked(a.cfg.DeadAirFreeze)
+	timingForm := widget.NewForm(
+		widget.NewFormItem("Seconds before highlight", a.numberEntry(a.cfg.SecondsBefore, 0, 300, func(v float64) { a.cfg.SecondsBefore = v })),
+		widget.NewFormItem("Seconds after highlight", a.numberEntry(a.cfg.SecondsAfter, 0, 300, func(v float64) { a.cfg.SecondsAfter = v })),
+		widget.NewFormItem("Double-press threshold (s)", a.numberEntry(a.cfg.DedupThreshold, 0, 60, func(v float64) { a.cfg.DedupThreshold = v })),
+		widget.NewFormItem("Cross-period window (s)", a.numberEntry(a.cfg.CrossPeriodWindow, 0, 600, func(v float64) { a.cfg.CrossPeriodWindow = v })),
+		widget.NewFormItem("Split recordings at HiLight gaps over (min, 0 = off)", a.numberEntry(a.cfg.PeriodSplitGap, 0, 600, func(v float64) { a.cfg.PeriodSplitGap = v })),
+		widget.NewFormItem("Split folders into games at recording gaps over (min, 0 = off)", a.numberEntry(a.cfg.GameSplitGap, 0, 1440, func(v float64) { a.cfg.GameSplitGap = v })),
+		widget.NewFormItem("Suggest highlights at GPS speeds over (km/h, 0 = off)", a.numberEntry(a.cfg.SpeedBurstKmh, 0, 200, func(v float64) { a.cfg.SpeedBurstKmh = v })),
+		widget.NewFormItem("Suggest a clip every (s, 0 = off)", a.numberEntry(a.cfg.AutoClipInterval, 0, 3600, func(v float64) { a.cfg.AutoClipInterval = v })),
+		widget.NewFormItem("", autoClipAudioCheck),
+		widget.NewFormItem("Trim dead air quieter than (dBFS)", a.numberEntry(a.cfg.DeadAirNoise, -90, 0, func(v float64) { a.cfg.DeadAirNoise = v })),
+		widget.NewFormItem("Trim dead air lasting at least (s)", a.numberEntry(a.cfg.DeadAirMin, 0.5, 60, func(v float64) { a.cfg.DeadAirMin = v })),
+		widget.NewFormItem("", deadAirFreezeCheck),
+	)
+
+	// Output folders and names
+	rootLabel := widget.NewLabel("")
+	showRoot := func() {
+		if a.cfg.OutputRoot == "" {
+			rootLabel.SetText("(none - choose folders by hand)")
+		} else {
+			rootLabel.SetText(a.cfg.OutputRoot)
+		}
+	}
+	showRoot()
+	selectRootBtn := widget.NewButton("Select", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			path := uri.Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			a.cfg.OutputRoot = path
+			a.cfg.Save()
+			showRoot()
+		}, a.window)
+	})
+	clearRootBtn := widget.NewButton("Clear", func() {
+		a.cfg.OutputRoot = ""
+		a.cfg.Save()
+		showRoot()
+	})
+
+	outputForm := widget.NewForm(
+		widget.NewFormItem("Output base folder", container.NewBorder(nil, nil, nil, container.NewHBox(selectRootBtn, clearRootBtn), rootLabel)),
+		widget.NewFormItem("Clip folder", a.templateEntry(a.cfg.ClipFolderTemplate, func(v string) { a.cfg.ClipFolderTemplate = v })),
+		widget.NewFormItem("Reel folder", a.templateEntry(a.cfg.ReelFolderTemplate, func(v string) { a.cfg.ReelFolderTemplate = v })),
+		widget.NewFormItem("Team", a.settingEntry(a.cfg.TeamName, nil, func(v string) { a.cfg.TeamName = v })),
+		widget.NewFormItem("Clip title", a.templateEntry(a.cfg.ClipTitleTemplate, func(v string) { a.cfg.ClipTitleTemplate = v })),
+		widget.NewFormItem("Reel title", a.templateEntry(a.cfg.ReelTitleTemplate, func(v string) { a.cfg.ReelTitleTemplate = v })),
+		widget.NewFormItem("Artist", a.templateEntry(a.cfg.ArtistTemplate, func(v string) { a.cfg.ArtistTemplate = v })),
+		widget.NewFormItem("Comment", a.templateEntry(a.cfg.CommentTemplate, func(v string) { a.cfg.CommentTemplate = v })),
+		widget.NewFormItem("Reel caption", a.templateEntry(a.cfg.CaptionTemplate, func(v string) { a.cfg.CaptionTemplate = v })),
+	)
+	tokensLabel := widget.NewLabel("Tokens: " + strings.Join(metadata.TemplateTokens, " "))
+
+	// Encoding
+	cpuCheck := widget.NewCheck("Encode on the CPU only (turn GPU/NVENC encoding off)", func(checked bool) {
+		a.cfg.PreferCPU = checked
+		a.ff.SetPreferCPU(checked)
+		a.cfg.Save()
+	})
+	cpuCheck.SetChecked(a.cfg.PreferCPU)
+	audioCopyCheck := widget.NewCheck("Keep the camera's AAC audio as it is in re-encoded clips (no audio re-encode)", func(checked bool) {
+		a.cfg.AudioCopy = checked
+		a.ff.SetAudioCopy(checked)
+		a.cfg.Save()
+	})
+	audioCopyCheck.SetChecked(a.cfg.AudioCopy)
+	captureTimesCheck := widget.NewCheck("Date clips and reels by when they were recorded (creation time and file date), for photo libraries", func(checked bool) {
+		a.cfg.CaptureTimes = checked
+		a.cfg.Save()
+	})
+	captureTimesCheck.SetChecked(a.cfg.CaptureTimes)
+	lowPriorityCheck := widget.NewCheck("Run ffmpeg at low priority, so the computer stays usable while encoding", func(checked bool) {
+		a.cfg.LowPriority = checked
+		a.ff.SetLowPriority(checked)
+		a.cfg.Save()
+	})
+	lowPriorityCheck.SetChecked(a.cfg.LowPriority)
+	quietEntry := widget.NewEntry()
+	quietEntry.SetText(a.cfg.QuietHours)
+	quietEntry.SetPlaceHolder("e.g. 17:00-22:00 (empty = never)")
+	quietEntry.Validator = func(s string) error {
+		_, err := jobs.ParseQuietHours(s)
+		return err
+	}
+	quietEntry.OnChanged = func(s string) {
+		quiet, err := jobs.ParseQuietHours(s)
+		if err != nil {
+			return
+		}
+		a.cfg.QuietHours = quiet.String()
+		a.cfg.Save()
+		a.checkQuietHours()
+	}
+
+	workerOptions := make([]string, maxReExtractWorkers)
+	for i := range workerOptions {
+		workerOptions[i] = strconv.Itoa(i + 1)
+	}
+	workersSelect := widget.NewSelect(workerOptions, func(selected string) {
+		a.cfg.ReExtractWorkers, _ = strconv.Atoi(selected)
+		a.cfg.Save()
+	})
+	workersSelect.SetSelected(strconv.Itoa(min(max(a.cfg.ReExtractWorkers, 1), maxReExtractWorkers)))
+
+	encodingForm := widget.NewForm(
+		widget.NewFormItem("", cpuCheck),
+		widget.NewFormItem("", audioCopyCheck),
+		widget.NewFormItem("", captureTimesCheck),
+		widget.NewFormItem("10-bit/HDR sources", a.newHDRModeSelect()),
+		widget.NewFormItem("Clip quality", a.newClipQualitySelect()),
+		widget.NewFormItem("Target file size (MB)", a.numberEntry(a.cfg.TargetSizeMB, 1, 1000000, func(v float64) { a.cfg.TargetSizeMB = v })),
+		widget.NewFormItem("Rough seek window (s, 0 = auto)", a.numberEntry(a.cfg.RoughSeekWindow, 0, 120, func(v float64) {
+			a.cfg.RoughSeekWindow = v
+			a.ff.SetRoughSeekWindow(v)
+		})),
+		widget.NewFormItem("Clips re-extracted at once", workersSelect),
+		widget.NewFormItem("", lowPriorityCheck),
+		widget.NewFormItem("ffmpeg threads (0 = all cores)", a.numberEntry(float64(a.cfg.FFmpegThreads), 0, 256, func(v float64) {
+			a.cfg.FFmpegThreads = int(v)
+			a.ff.SetThreads(a.cfg.FFmpegThreads)
+		})),
+		widget.NewFormItem("Quiet hours (jobs pause)", quietEntry),
+	)
+
+	// Appearance
+	var themeLabels []string
+	for _, t := range themeNames {
+		themeLabels = append(themeLabels, t.label)
+	}
+	themeSelect := widget.NewSelect(themeLabels, nil)
+	for _, t := range themeNames {
+		if t.value == a.cfg.Theme {
+			themeSelect.SetSelected(t.label)
+		}
+	}
+	if themeSelect.Selected == "" {
+		themeSelect.SetSelected(themeNames[0].label)
+	}
+	themeSelect.OnChanged = func(selected string) {
+		for _, t := range themeNames {
+			if t.label == selected && t.value != a.cfg.Theme {
+				a.cfg.Theme = t.value
+				a.cfg.Save()
+				a.applyTheme()
+			}
+		}
+	}
+
+	// Advanced: take effect on the next launch
+	ffmpegEntry := a.settingEntry(a.cfg.FFmpegPath, func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		_, err := ffmpeg.NewFromPath(config.ResolvePath(strings.TrimSpace(text)))
+		return err
+	}, func(v string) { a.cfg.FFmpegPath = v })
+	ffmpegEntry.SetPlaceHolder("(automatic: bin/ folder, then PATH)")
+	browseFFmpegBtn := widget.NewButton("Browse", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			// On a portable stick, keep it relative so it survives a new drive letter
+			ffmpegEntry.SetText(config.PortablePath(path))
+		}, a.window)
+	})
+
+	// Custom arguments per encoding preset, checked against ffmpeg at startup
+	presetArgsForm := widget.NewForm()
+	for _, preset := range ffmpeg.Presets() {
+		label := "Reels and exports"
+		if option, ok := clipQualityOptions[ffmpeg.ClipQuality(preset)]; ok {
+			label = "Clips: " + strings.SplitN(option.label, " (", 2)[0]
+		}
+		entry := a.settingEntry(a.cfg.FFmpegArgs[preset], func(text string) error {
+			_, err := ffmpeg.SplitArgs(text)
+			return err
+		}, func(v string) {
+			args := maps.Clone(a.cfg.FFmpegArgs)
+			if args == nil {
+				args = make(map[string]string)
+			}
+			if v == "" {
+				delete(args, preset)
+			} else {
+				args[preset] = v
+			}
+			a.cfg.FFmpegArgs = args
+		})
+		entry.SetPlaceHolder("(none) e.g. -c:v libsvtav1 -crf 32")
+		presetArgsForm.Append(label, entry)
+	}
+	presetArgsHelp := widget.NewLabel("Added after the app's own encoder options, so they override them. Split like a shell command line:\n" +
+		"quote Windows paths ('C:\\Fonts\\arial.ttf'). Each preset's are tried on a short test encode at startup (with NVENC too\n" +
+		"when GPU encoding is on), and left out if ffmpeg rejects them. Two-pass target size reels don't use them.")
+
+	apiEntry := a.settingEntry(a.cfg.APIAddress, func(text string) error {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		_, _, err := net.SplitHostPort(strings.TrimSpace(text))
+		return err
+	}, func(v string) { a.cfg.APIAddress = v })
+	apiEntry.SetPlaceHolder("(off) e.g. 127.0.0.1:8765")
+	gamesFolderEntry := a.settingEntry(a.cfg.APIGamesFolder, nil, func(v string) { a.cfg.APIGamesFolder = v })
+	gamesFolderEntry.SetPlaceHolder("(scans off) folder the API may scan game folders in")
+	browseGamesBtn := widget.NewButton("Browse", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			path := uri.Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			gamesFolderEntry.SetText(path)
+		}, a.window)
+	})
+	tokenCopyBtn := widget.NewButton("Copy", func() {
+		a.fyneApp.Clipboard().SetContent(a.apiToken)
+	})
+	apiItems := []*widget.FormItem{
+		widget.NewFormItem("Control API address", apiEntry),
+		widget.NewFormItem("API games folder", container.NewBorder(nil, nil, nil, browseGamesBtn, gamesFolderEntry)),
+		widget.NewFormItem("API token", container.NewBorder(nil, nil, nil, tokenCopyBtn, widget.NewLabel(a.apiToken))),
+	}
+	if link := a.webLink(); link != "" {
+		linkCopyBtn := widget.NewButton("Copy", func() {
+			a.fyneApp.Clipboard().SetContent(link)
+		})
+		apiItems = append(apiItems, widget.NewFormItem("Web UI", container.NewBorder(nil, nil, nil, linkCopyBtn, widget.NewLabel(link))))
+	}
+
+	advancedCheck := widget.NewCheck("Advanced mode: pick clip and video folders by hand in Steps 3-5", func(checked bool) {
+		a.cfg.AdvancedMode = checked
+		a.cfg.Save()
+		a.showAdvancedControls()
+	})
+	advancedCheck.SetChecked(a.cfg.AdvancedMode)
+
+	multiCheck := widget.NewCheck("Don't warn when another copy is already running", func(checked bool) {
+		a.cfg.MultiInstance = checked
+		a.cfg.Save()
+	})
+	multiCheck.SetChecked(a.cfg.MultiInstance)
+
+	advancedForm := widget.NewForm(
+		widget.NewFormItem("ffmpeg executable", container.NewBorder(nil, nil, nil, browseFFmpegBtn, ffmpegEntry)),
+		widget.NewFormItem("In use", widget.NewLabel(a.ff.Path())),
+	)
+	for _, item := range apiItems {
+		advancedForm.AppendItem(item)
+	}
+	advancedForm.Append("Multiple copies", multiCheck)
+
+	// Hooks
+	hookEntry := func(value string, set func(string)) *widget.Entry {
+		entry := a.settingEntry(value, nil, set)
+		entry.SetPlaceHolder("(none)")
+		return entry
+	}
+	hooksForm := widget.NewForm(
+		widget.NewFormItem("After extracting clips", hookEntry(a.cfg.HookAfterExtract, func(v string) { a.cfg.HookAfterExtract = v })),
+		widget.NewFormItem("After combining a reel", hookEntry(a.cfg.HookAfterCombine, func(v string) { a.cfg.HookAfterCombine = v })),
+		widget.NewFormItem("After a full game export", hookEntry(a.cfg.HookAfterExport, func(v string) { a.cfg.HookAfterExport = v })),
+	)
+	hooksHelp := widget.NewLabel("Commands run through the shell when the operation succeeds. The clip folder or output file is in\n" +
+		"GOPRO_OUTPUT, the operation in GOPRO_EVENT and the game name in GOPRO_GAME.")
+
+	// Cloud upload
+	providers := []string{"Off", cloud.ProviderName(cloud.GoogleDrive), cloud.ProviderName(cloud.Dropbox)}
+	providerValues := []string{"", cloud.GoogleDrive, cloud.Dropbox}
+	cloudStatus := widget.NewLabel("")
+	connectBtn := widget.NewButton("Connect", nil)
+	disconnectBtn := widget.NewButton("Disconnect", nil)
+	showCloud := func() {
+		switch {
+		case a.cfg.Cloud.Provider == "":
+			cloudStatus.SetText("Uploads are off")
+			connectBtn.Disable()
+			disconnectBtn.Disable()
+		case a.cfg.Cloud.Connected():
+			cloudStatus.SetText("Signed in to " + cloud.ProviderName(a.cfg.Cloud.Provider))
+			connectBtn.Enable()
+			disconnectBtn.Enable()
+		default:
+			cloudStatus.SetText("Not signed in")
+			connectBtn.Enable()
+			disconnectBtn.Disable()
+		}
+	}
+	// signOut forgets the tokens, which belong to the old account or app
+	signOut := func() {
+		a.cfg.Cloud.Token = cloud.Token{}
+		a.cfg.Save()
+		showCloud()
+	}
+	connectBtn.OnTapped = func() {
+		cloudStatus.SetText("Waiting for the browser sign-in...")
+		connectBtn.Disable()
+		a.connectCloud(showCloud)
+	}
+	disconnectBtn.OnTapped = signOut
+	providerSelect := widget.NewSelect(providers, nil)
+	for i, value := range providerValues {
+		if value == a.cfg.Cloud.Provider {
+			providerSelect.SetSelected(providers[i])
+		}
+	}
+	providerSelect.OnChanged = func(selected string) {
+		for i, label := range providers {
+			if label == selected && providerValues[i] != a.cfg.Cloud.Provider {
+				a.cfg.Cloud.Provider = providerValues[i]
+				signOut()
+			}
+		}
+	}
+	clientIDEntry := a.settingEntry(a.cfg.Cloud.ClientID, nil, func(v string) {
+		if v != a.cfg.Cloud.ClientID {
+			a.cfg.Cloud.ClientID = v
+			signOut()
+		}
+	})
+	clientSecretEntry := a.settingEntry(a.cfg.Cloud.ClientSecret, nil, func(v string) { a.cfg.Cloud.ClientSecret = v })
+	clientSecretEntry.Password = true
+	clientSecretEntry.SetPlaceHolder("(Google Drive only)")
+	folderEntry := a.settingEntry(a.cfg.Cloud.Folder, nil, func(v string) { a.cfg.Cloud.Folder = v })
+	folderEntry.SetPlaceHolder("Drive folder ID, or Dropbox path like /Team/Highlights")
+	uploadCombineCheck := widget.NewCheck("Upload reels after combining (Step 4)", func(checked bool) {
+		a.cfg.UploadAfterCombine = checked
+		a.cfg.Save()
+	})
+	uploadCombineCheck.SetChecked(a.cfg.UploadAfterCombine)
+	uploadExportCheck := widget.NewCheck("Upload the full game after exporting (Step 5)", func(checked bool) {
+		a.cfg.UploadAfterExport = checked
+		a.cfg.Save()
+	})
+	uploadExportCheck.SetChecked(a.cfg.UploadAfterExport)
+	showCloud()
+	cloudForm := widget.NewForm(
+		widget.NewFormItem("Service", providerSelect),
+		widget.NewFormItem("App client ID", clientIDEntry),
+		widget.NewFormItem("App client secret", clientSecretEntry),
+		widget.NewFormItem("Team folder", folderEntry),
+		widget.NewFormItem("Account", container.NewHBox(cloudStatus, connectBtn, disconnectBtn)),
+		widget.NewFormItem("Automatic uploads", container.NewVBox(uploadCombineCheck, uploadExportCheck)),
+	)
+	cloudHelp := widget.NewLabel("Uses your team's own Google Cloud (desktop app) or Dropbox app registration. Add\n" +
+		cloud.RedirectURI + " as its redirect URI. Links to uploads are copied to the clipboard.")
+
+	bold := fyne.TextStyle{Bold: true}
+	content := container.NewVBox(
+		widget.NewLabel("Settings are saved as you change them."),
+		widget.NewLabelWithStyle("Clip Timing", fyne.TextAlignLeading, bold),
+		timingForm,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Output Folders and Names", fyne.TextAlignLeading, bold),
+		outputForm,
+		tokensLabel,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Encoding", fyne.TextAlignLeading, bold),
+		encodingForm,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Appearance", fyne.TextAlignLeading, bold),
+		widget.NewForm(
+			widget.NewFormItem("Theme", themeSelect),
+			widget.NewFormItem("Workflow", advancedCheck),
+		),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Advanced (applied on the next launch)", fyne.TextAlignLeading, bold),
+		advancedForm,
+		widget.NewLabel("Custom ffmpeg arguments"),
+		presetArgsForm,
+		presetArgsHelp,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Hooks", fyne.TextAlignLeading, bold),
+		hooksForm,
+		hooksHelp,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Cloud Upload", fyne.TextAlignLeading, bold),
+		cloudForm,
+		cloudHelp,
+	)
+
+	return container.NewVScroll(container.NewPadded(content))
+}
@@ -0,0 +1,402 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/config"
+)
+
+// Encoder choices in the setup wizard
+const (
+	encoderGPU = "GPU (NVIDIA NVENC), falling back to the CPU"
+	encoderCPU = "CPU only"
+)
+
+// findFFmpeg returns the ffmpeg set in Settings, or else the one in bin/ or
+// on the PATH. An ffmpeg set in Settings that has gone missing falls back to
+// the usual search.
+func findFFmpeg(cfg *config.Config) (*ffmpeg.FFmpeg, error) {
+	if cfg.FFmpegPath != "" {
+		ff, err := ffmpeg.NewFromPath(config.ResolvePath(cfg.FFmpegPath))
+		if err == nil {
+			return ff, nil
+		}
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return ffmpeg.New()
+}
+
+// useFFmpeg makes ff the ffmpeg the app runs, with the configured encoder settings
+func (a *App) useFFmpeg(ff *ffmpeg.FFmpeg) {
+	ff.SetPreferCPU(a.cfg.PreferCPU)
+	ff.SetAudioCopy(a.cfg.AudioCopy)
+	ff.SetHDRMode(ffmpeg.HDRMode(a.cfg.HDRMode))
+	ff.SetClipQuality(ffmpeg.ClipQuality(a.cfg.ClipQuality))
+	ff.SetThreads(a.cfg.FFmpegThreads)
+	ff.SetLowPriority(a.cfg.LowPriority)
+	ff.SetTempDir(a.tempDir)
+	a.ff = ff
+}
+
+// checkPresetArgs checks the custom ffmpeg arguments of each encoding preset
+// in the background and has ffmpeg use those it accepts, telling the user
+// about any left out. Encodes started before it finishes go without them.
+func (a *App) checkPresetArgs() {
+	if len(a.cfg.FFmpegArgs) == 0 {
+		return
+	}
+	ff := a.ff
+	cfg := &config.Config{FFmpegArgs: maps.Clone(a.cfg.FFmpegArgs)}
+	go func() {
+		presetArgs, problems := cfg.PresetArgs(ff)
+		ff.SetPresetArgs(presetArgs)
+		if len(problems) > 0 {
+			fyne.Do(func() {
+				a.showError("Custom ffmpeg Arguments Left Out", "These arguments were rejected by "+ff.Path()+
+					" and aren't used until they are fixed in Settings:\n\n"+strings.Join(problems, "\n"))
+			})
+		}
+	}()
+}
+
+// wizardPage is one page of the setup wizard. ready (nil = always) says
+// whether Next can be clicked; apply (may be nil) copies its choices into
+// the config when the wizard is finished.
+type wizardPage struct {
+	title   string
+	content fyne.CanvasObject
+	ready   func() bool
+	apply   func()
+}
+
+// createSetupWizard returns the first-run setup wizard: it finds (or
+// downloads) ffmpeg and asks for the clip padding, output folders and
+// encoder, then saves the config and calls done. When the app has been set
+// up before and only ffmpeg is missing, it asks for ffmpeg alone.
+func (a *App) createSetupWizard(done func()) fyne.CanvasObject {
+	var update func()
+	changed := func() { update() }
+
+	pages := []wizardPage{a.ffmpegSetupPage(changed)}
+	if a.firstRun {
+		pages = append(pages, a.paddingSetupPage(changed), a.foldersSetupPage(), a.encoderSetupPage())
+	}
+
+	current := 0
+	header := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	body := container.NewStack()
+	var backBtn, nextBtn *widget.Button
+	backBtn = widget.NewButton("Back", func() {
+		current--
+		update()
+	})
+	nextBtn = widget.NewButton("Next", func() {
+		if current < len(pages)-1 {
+			current++
+			update()
+			return
+		}
+		for _, page := range pages {
+			if page.apply != nil {
+				page.apply()
+			}
+		}
+		a.cfg.Save()
+		a.firstRun = false
+		done()
+	})
+	nextBtn.Importance = widget.HighImportance
+
+	update = func() {
+		page := pages[current]
+		header.SetText(fmt.Sprintf("Setup - %s (%d of %d)", page.title, current+1, len(pages)))
+		body.Objects = []fyne.CanvasObject{page.content}
+		body.Refresh()
+		if current == 0 {
+			backBtn.Disable()
+		} else {
+			backBtn.Enable()
+		}
+		if current == len(pages)-1 {
+			nextBtn.SetText("Finish")
+		} else {
+			nextBtn.SetText("Next")
+		}
+		if page.ready == nil || page.ready() {
+			nextBtn.Enable()
+		} else {
+			nextBtn.Disable()
+		}
+	}
+	update()
+
+	buttons := container.NewBorder(nil, nil, nil, container.NewHBox(backBtn, nextBtn))
+	return container.NewPadded(container.NewBorder(header, buttons, nil, nil, container.NewVScroll(body)))
+}
+
+// ffmpegSetupPage returns the wizard page that finds ffmpeg: it offers the
+// download where there is one, a Browse button and a fresh search. changed
+// is called when ffmpeg is found.
+func (a *App) ffmpegSetupPage(changed func()) wizardPage {
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	showStatus := func() {
+		if a.ff != nil {
+			status.SetText("Found ffmpeg: " + a.ff.Path())
+			return
+		}
+		status.SetText("ffmpeg and ffprobe weren't found in the bin/ folder next to the app or on the PATH. " +
+			"The app needs them to read and cut the videos.")
+	}
+	showStatus()
+
+	found := func(ff *ffmpeg.FFmpeg, path string) {
+		if path != "" {
+			// On a portable stick, keep it relative so it survives a new drive letter
+			a.cfg.FFmpegPath = config.PortablePath(path)
+		}
+		a.useFFmpeg(ff)
+		showStatus()
+		changed()
+	}
+
+	progress := widget.NewProgressBar()
+	progress.Hide()
+	var downloadBtn *widget.Button
+	downloadBtn = widget.NewButton("Download ffmpeg", func() {
+		dir, err := config.Dir()
+		if err != nil {
+			a.showError("Download Failed", err.Error())
+			return
+		}
+		downloadBtn.Disable()
+		progress.SetValue(0)
+		progress.Show()
+		status.SetText("Downloading ffmpeg from " + ffmpeg.DownloadURL + " ...")
+		go func() {
+			path, err := ffmpeg.Download(filepath.Join(dir, "bin"), func(done float64) {
+				fyne.Do(func() {
+					if done >= 0 {
+						progress.SetValue(done)
+					}
+				})
+			})
+			var ff *ffmpeg.FFmpeg
+			if err == nil {
+				ff, err = ffmpeg.NewFromPath(path)
+			}
+			fyne.Do(func() {
+				downloadBtn.Enable()
+				progress.Hide()
+				if err != nil {
+					showStatus()
+					a.showError("Download Failed", err.Error())
+					return
+				}
+				found(ff, path)
+			})
+		}()
+	})
+
+	browseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			ff, err := ffmpeg.NewFromPath(path)
+			if err != nil {
+				a.showError("ffmpeg Not Usable", err.Error())
+				return
+			}
+			found(ff, path)
+		}, a.window)
+	})
+
+	checkBtn := widget.NewButton("Check Again", func() {
+		ff, err := findFFmpeg(a.cfg)
+		if err != nil {
+			a.showError("ffmpeg Not Found", err.Error())
+			return
+		}
+		found(ff, "")
+	})
+
+	var help string
+	buttons := container.NewHBox()
+	if ffmpeg.CanDownload() {
+		help = "Download a build of ffmpeg (about 100 MB) into the folder the app keeps its settings in, " +
+			"or pick an ffmpeg.exe you already have (ffprobe.exe must be next to it)."
+		buttons.Add(downloadBtn)
+	} else {
+		help = "Install ffmpeg (e.g. \"brew install ffmpeg\" or \"sudo apt install ffmpeg\") and click Check Again, " +
+			"or pick an ffmpeg executable (ffprobe must be next to it)."
+	}
+	buttons.Add(browseBtn)
+	buttons.Add(checkBtn)
+	helpLabel := widget.NewLabel(help)
+	helpLabel.Wrapping = fyne.TextWrapWord
+
+	return wizardPage{
+		title:   "ffmpeg",
+		content: container.NewVBox(status, helpLabel, buttons, progress),
+		ready:   func() bool { return a.ff != nil },
+	}
+}
+
+// paddingSetupPage returns the wizard page for the default time kept before
+// and after each highlight. changed is called on each edit.
+func (a *App) paddingSetupPage(changed func()) wizardPage {
+	beforeEntry := widget.NewEntry()
+	beforeEntry.SetText(strconv.FormatFloat(a.cfg.SecondsBefore, 'f', -1, 64))
+	beforeEntry.Validator = numberSetting(0, 300)
+	beforeEntry.OnChanged = func(string) { changed() }
+	afterEntry := widget.NewEntry()
+	afterEntry.SetText(strconv.FormatFloat(a.cfg.SecondsAfter, 'f', -1, 64))
+	afterEntry.Validator = numberSetting(0, 300)
+	afterEntry.OnChanged = func(string) { changed() }
+
+	help := widget.NewLabel("Each clip starts this long before the HiLight press and ends this long after it. " +
+		"Clips can still be trimmed one by one in Step 3, and the defaults changed in Settings.")
+	help.Wrapping = fyne.TextWrapWord
+	form := widget.NewForm(
+		widget.NewFormItem("Seconds before highlight", beforeEntry),
+		widget.NewFormItem("Seconds after highlight", afterEntry),
+	)
+
+	return wizardPage{
+		title:   "Clip Padding",
+		content: container.NewVBox(help, form),
+		ready: func() bool {
+			return beforeEntry.Validate() == nil && afterEntry.Validate() == nil
+		},
+		apply: func() {
+			a.cfg.SecondsBefore, _ = strconv.ParseFloat(strings.TrimSpace(beforeEntry.Text), 64)
+			a.cfg.SecondsAfter, _ = strconv.ParseFloat(strings.TrimSpace(afterEntry.Text), 64)
+		},
+	}
+}
+
+// foldersSetupPage returns the wizard page for the per-game output layout
+func (a *App) foldersSetupPage() wizardPage {
+	rootPath := a.cfg.OutputRoot
+	rootLabel := widget.NewLabel("")
+	clipEntry := widget.NewEntry()
+	clipEntry.SetText(a.cfg.ClipFolderTemplate)
+	reelEntry := widget.NewEntry()
+	reelEntry.SetText(a.cfg.ReelFolderTemplate)
+	templates := widget.NewForm(
+		widget.NewFormItem("Clip folder", clipEntry),
+		widget.NewFormItem("Reel folder", reelEntry),
+	)
+
+	showRoot := func() {
+		if rootPath == "" {
+			rootLabel.SetText("(none - choose folders by hand)")
+			templates.Hide()
+		} else {
+			rootLabel.SetText(rootPath)
+			templates.Show()
+		}
+	}
+	showRoot()
+	selectBtn := widget.NewButton("Select", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			path := uri.Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			rootPath = path
+			showRoot()
+		}, a.window)
+	})
+	clearBtn := widget.NewButton("Clear", func() {
+		rootPath = ""
+		showRoot()
+	})
+
+	help := widget.NewLabel("With a base folder, each game's clips and reels go into their own folders under it, " +
+		"named from the templates (tokens: {game}, {date}, {team}). Without one, you choose the folders in each step.")
+	help.Wrapping = fyne.TextWrapWord
+	form := widget.NewForm(
+		widget.NewFormItem("Output base folder", container.NewBorder(nil, nil, nil, container.NewHBox(selectBtn, clearBtn), rootLabel)),
+	)
+
+	return wizardPage{
+		title:   "Output Folders",
+		content: container.NewVBox(help, form, templates),
+		apply: func() {
+			a.cfg.OutputRoot = rootPath
+			if text := strings.TrimSpace(clipEntry.Text); text != "" {
+				a.cfg.ClipFolderTemplate = text
+			}
+			if text := strings.TrimSpace(reelEntry.Text); text != "" {
+				a.cfg.ReelFolderTemplate = text
+			}
+		},
+	}
+}
+
+// encoderSetupPage returns the wizard page for the preferred encoder, with a
+// test of whether NVENC works on this computer
+func (a *App) encoderSetupPage() wizardPage {
+	choice := widget.NewRadioGroup([]string{encoderGPU, encoderCPU}, nil)
+	choice.Required = true
+	if a.cfg.PreferCPU {
+		choice.SetSelected(encoderCPU)
+	} else {
+		choice.SetSelected(encoderGPU)
+	}
+
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+	checkBtn := widget.NewButton("Test GPU Encoding", func() {
+		if a.ff == nil {
+			return
+		}
+		status.SetText("Testing NVENC...")
+		go func() {
+			result := a.ff.CheckNVENC()
+			fyne.Do(func() {
+				if result == nil {
+					status.SetText("NVENC works on this computer.")
+					return
+				}
+				status.SetText("NVENC isn't available (" + result.Reason() + "). Encodes will use the CPU.")
+				choice.SetSelected(encoderCPU)
+			})
+		}()
+	})
+
+	help := widget.NewLabel("Re-encoded clips and reels are much faster on an NVIDIA GPU. " +
+		"When NVENC fails, the CPU is used instead. This can be changed in Settings.")
+	help.Wrapping = fyne.TextWrapWord
+
+	return wizardPage{
+		title:   "Encoder",
+		content: container.NewVBox(help, choice, container.NewHBox(checkBtn), status),
+		apply: func() {
+			a.cfg.PreferCPU = choice.Selected == encoderCPU
+			a.ff.SetPreferCPU(a.cfg.PreferCPU)
+		},
+	}
+}
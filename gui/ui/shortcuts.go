@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// stepActions are the step actions behind the keyboard shortcuts. Each step
+// registers its own as it is built, so a rebuilt step replaces them.
+type stepActions struct {
+	openFolder func()          // Step 1: Select Folder
+	extract    func()          // Step 2: Extract Selected Clips
+	moveClip   func(delta int) // Step 3: focus the previous (-1) or next (+1) clip
+	combine    func()          // Step 4: Combine Clips
+
+	// Re-cut (see recut.go): extract the selected chapters again into a
+	// folder then call done, and combine the new clips with Step 4's settings
+	recutClips func(folder string, streamCopy bool, done func())
+	recutReel  func()
+
+	// Refreshes of out-of-date steps (see stale.go): reload Step 3's clips,
+	// and Step 4's clips from Step 2
+	reloadEdits func()
+	reloadClips func()
+
+	// Files and folders dropped onto the window (see drop.go): a working
+	// folder to scan and GoPro MP4s to extract metadata from in Step 1, and
+	// a clip folder or clips to combine in Step 4
+	dropFolder func(path string)
+	dropMP4s   func(paths []string)
+	dropClips  func(paths []string)
+
+	// Step 1's game list, shown again when the working folder's games change
+	// (see games.go)
+	showGames func()
+
+	// The Jobs tab's quiet hours line, shown again when the queue is held or
+	// released (see quiet_hours.go)
+	showQuietHours func()
+}
+
+// tapAction returns an action that taps btn, unless it is disabled
+func tapAction(btn *widget.Button) func() {
+	return func() {
+		if !btn.Disabled() && btn.OnTapped != nil {
+			btn.OnTapped()
+		}
+	}
+}
+
+// setupShortcuts registers the window's keyboard shortcuts:
+//
+//	Ctrl+1..5     go to step 1-5
+//	Ctrl+O        open a folder (Step 1)
+//	Ctrl+E        extract the selected clips (Step 2)
+//	Up/Down       previous/next clip in Step 3 (Ctrl+Up/Down while typing)
+//	Ctrl+Enter    combine the selected clips (Step 4)
+//
+// Tab moves between controls and Space toggles a focused checkbox.
+func (a *App) setupShortcuts() {
+	canvas := a.window.Canvas()
+	add := func(key fyne.KeyName, action func()) {
+		canvas.AddShortcut(&desktop.CustomShortcut{KeyName: key, Modifier: fyne.KeyModifierShortcutDefault},
+			func(fyne.Shortcut) { action() })
+	}
+
+	for i, key := range []fyne.KeyName{fyne.Key1, fyne.Key2, fyne.Key3, fyne.Key4, fyne.Key5} {
+		step := i
+		add(key, func() { a.tabs.SelectIndex(step) })
+	}
+
+	// runStep shows a step and runs one of its actions, if it has registered it
+	runStep := func(step int, action func() func()) func() {
+		return func() {
+			a.tabs.SelectIndex(step)
+			if fn := action(); fn != nil {
+				fn()
+			}
+		}
+	}
+	add(fyne.KeyO, runStep(stepSetup, func() func() { return a.actions.openFolder }))
+	add(fyne.KeyE, runStep(stepExtract, func() func() { return a.actions.extract }))
+	combine := runStep(stepCombine, func() func() { return a.actions.combine })
+	add(fyne.KeyReturn, combine)
+	add(fyne.KeyEnter, combine)
+
+	moveClip := func(delta int) {
+		if a.tabs.SelectedIndex() == stepEdit && a.actions.moveClip != nil {
+			a.actions.moveClip(delta)
+		}
+	}
+	add(fyne.KeyUp, func() { moveClip(-1) })
+	add(fyne.KeyDown, func() { moveClip(1) })
+	canvas.SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		switch ev.Name {
+		case fyne.KeyUp:
+			moveClip(-1)
+		case fyne.KeyDown:
+			moveClip(1)
+		}
+	})
+}
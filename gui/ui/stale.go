@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Step indexes: the step tabs' positions, and the keys of each step's status
+// and stale banner, so every flow marks the same steps
+const (
+	stepSetup = iota
+	stepExtract
+	stepEdit
+	stepCombine
+	stepExport
+)
+
+// stepTitles are the step tabs' titles, by step index
+var stepTitles = []string{
+	stepSetup:   "1. Setup",
+	stepExtract: "2. Extract Clips",
+	stepEdit:    "3. Edit Clips",
+	stepCombine: "4. Combine",
+	stepExport:  "5. Export Full Game",
+}
+
+// lastDependentStep is the last step whose results depend on the ones before
+// it (Step 5 exports the periods, not the clips)
+const lastDependentStep = stepCombine
+
+// padding is the seconds before and after each highlight that clips were
+// extracted with
+type padding struct {
+	before, after float64
+}
+
+// updateStepTitle shows a step's status in its tab title: ✓ when done, ⚠ when
+// its results are out of date
+func (a *App) updateStepTitle(step int) {
+	if step < 0 || step >= len(stepTitles) || step >= len(a.tabItems) {
+		return
+	}
+	title := stepTitles[step]
+	if a.stepDone[step] {
+		title += " ✓"
+	}
+	if a.stale[step] != "" {
+		title += " ⚠"
+	}
+	a.tabItems[step].Text = title
+	a.tabs.Refresh()
+}
+
+// markStale flags step and the steps after it that depend on it as out of
+// date, with why (e.g. "the videos were analyzed again"). Each shows a warning
+// banner with a refresh button until it is done again, refreshed or dismissed.
+func (a *App) markStale(step int, reason string) {
+	if a.stale == nil {
+		a.stale = make(map[int]string)
+	}
+	for s := step; s <= lastDependentStep; s++ {
+		a.stale[s] = reason
+		a.showStale(s)
+		a.updateStepTitle(s)
+	}
+}
+
+// clearStale clears a step's out-of-date warning
+func (a *App) clearStale(step int) {
+	if a.stale[step] == "" {
+		return
+	}
+	delete(a.stale, step)
+	a.showStale(step)
+	a.updateStepTitle(step)
+}
+
+// withStaleBanner returns a step's content with room above it for the
+// out-of-date banner. Call it each time the step's content is (re)built.
+func (a *App) withStaleBanner(step int, content fyne.CanvasObject) fyne.CanvasObject {
+	if a.staleBanners == nil {
+		a.staleBanners = make(map[int]*fyne.Container)
+	}
+	a.staleBanners[step] = container.NewVBox()
+	a.showStale(step)
+	return container.NewBorder(a.staleBanners[step], nil, nil, nil, content)
+}
+
+// showStale fills in a step's banner from its out-of-date reason, or hides it
+func (a *App) showStale(step int) {
+	banner := a.staleBanners[step]
+	if banner == nil {
+		return
+	}
+	banner.Objects = nil
+	reason := a.stale[step]
+	if reason == "" {
+		banner.Refresh()
+		return
+	}
+
+	var label string
+	var refresh func()
+	switch step {
+	case stepExtract:
+		label, refresh = "Re-extract Clips", a.actions.extract
+	case stepEdit:
+		label, refresh = "Reload Clips", a.actions.reloadEdits
+	case stepCombine:
+		label, refresh = "Reload Clips", a.actions.reloadClips
+	}
+
+	message := widget.NewLabel(fmt.Sprintf("Out of date: %s.", reason))
+	message.Importance = widget.WarningImportance
+	message.Wrapping = fyne.TextWrapWord
+	buttons := container.NewHBox()
+	if refresh != nil {
+		refreshBtn := widget.NewButton(label, func() {
+			refresh()
+			if step != stepExtract { // Re-extracting clears it when the clips are done
+				a.clearStale(step)
+			}
+		})
+		refreshBtn.Importance = widget.HighImportance
+		buttons.Add(refreshBtn)
+	}
+	buttons.Add(widget.NewButton("Dismiss", func() {
+		a.clearStale(step)
+	}))
+
+	banner.Add(container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), buttons, message))
+	banner.Add(widget.NewSeparator())
+	banner.Refresh()
+}
+
+// checkPadding flags the clips as out of date when the padding entered in
+// Step 2 differs from the padding they were extracted with
+func (a *App) checkPadding(before, after float64) {
+	if a.clipPadding == nil || len(a.extractedClips) == 0 {
+		return
+	}
+	if before == a.clipPadding.before && after == a.clipPadding.after {
+		return
+	}
+	a.markStale(stepExtract, fmt.Sprintf("the padding is now %gs before/%gs after, but the clips were extracted with %gs/%gs",
+		before, after, a.clipPadding.before, a.clipPadding.after))
+}
@@ -1,40 +1,175 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
-	"gopro-gui/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/jobs"
 )
 
 // detectedFile holds information about a detected file
 type detectedFile struct {
 	path         string
 	baseName     string
-	fileType     string // "mov", "mp4", "metadata"
+	fileType     string // "mov", "mp4", "360", "metadata"
 	hasTimecode  bool
-	timecode     string
+	timecode     string // As read from the file ("" = none)
+	startTime    string // Start time entered by hand (see timecodes.go), used instead of the timecode
 	hasChapters  bool
 	chapterCount int
+	source       *ffmpeg.SourceCheck // Codec and decode/360 warnings (nil if not probed)
+	duration     float64             // Length in seconds (0 if not probed)
 }
 
 // detectedPeriodInfo holds auto-detected period information
 type detectedPeriodInfo struct {
-	name           string
-	movFile        *detectedFile
+	movFile        *detectedFile // Converted MOV (the MP4 itself if it was never converted)
 	mp4File        *detectedFile
 	metadataFile   *detectedFile
 	metadataSource string // "mov", "metadata", "needs_extraction"
 	ready          bool
+	excluded       bool // Left out of the analysis (e.g. warmup or zamboni footage)
+	mergeWithPrev  bool // Continues the previous included file's period (e.g. recording restarted)
+	useMP4         bool // Cut clips straight from the GoPro MP4 (see metadata.DirectPeriod)
+}
+
+const (
+	// periodsPerPage is how many period cards Step 1 shows at once
+	periodsPerPage = 20
+	// scanWorkers is how many files a scan probes at once per drive, unless
+	// the drive has its own setting
+	scanWorkers = pipeline.DefaultProbeWorkers
+	// scanProgressInterval is how often a scan's progress is redrawn. Probes
+	// finish in bursts (and instantly from the probe cache on a rescan), so
+	// they aren't each worth a trip to the UI thread.
+	scanProgressInterval = 200 * time.Millisecond
+)
+
+// everyTick calls update on the UI thread every interval until the returned
+// stop is called, which waits for an update in progress and then runs it once
+// more so the last change is shown
+func everyTick(interval time.Duration, update func()) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				fyne.DoAndWait(update)
+				return
+			case <-ticker.C:
+				fyne.DoAndWait(update)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// timecodeText returns the start a file's card shows: the timecode, or the
+// start time entered by hand
+func (f *detectedFile) timecodeText() string {
+	if f.startTime != "" {
+		return f.startTime + " (entered)"
+	}
+	return f.timecode
+}
+
+// formatText returns the resolution, frame rate and length a file's card
+// shows ("" if it wasn't probed)
+func (f *detectedFile) formatText() string {
+	if f.source == nil {
+		return ""
+	}
+	text := f.source.Format()
+	if f.duration > 0 {
+		text += ", " + formatDuration(f.duration) + " long"
+	}
+	return text
+}
+
+// formatKey identifies how a file was recorded: resolution, frame rate and
+// codec, e.g. "3840x2160 @ 59.94 fps HEVC 10-bit" ("" if it wasn't probed)
+func (f *detectedFile) formatKey() string {
+	if f.source == nil {
+		return ""
+	}
+	codec, _, _ := strings.Cut(f.source.Label(), ",")
+	return f.source.Format() + " " + codec
+}
+
+// commonFormat returns the format (see formatKey) most of the included
+// periods were recorded in, so a period recorded differently (e.g. one left
+// at 1080p30) can be flagged before clips are cut. Ties go to the earliest
+// period; "" if fewer than two periods were probed.
+func commonFormat(periods []*detectedPeriodInfo) string {
+	counts := make(map[string]int)
+	var keys []string
+	for _, p := range periods {
+		if key := p.video().formatKey(); !p.excluded && key != "" {
+			if counts[key] == 0 {
+				keys = append(keys, key)
+			}
+			counts[key]++
+		}
+	}
+	common := ""
+	total := 0
+	for _, key := range keys {
+		total += counts[key]
+		if counts[key] > counts[common] {
+			common = key
+		}
+	}
+	if total < 2 {
+		return ""
+	}
+	return common
+}
+
+// video returns the file the period's clips are cut from
+func (p *detectedPeriodInfo) video() *detectedFile {
+	if p.useMP4 && p.mp4File != nil {
+		return p.mp4File
+	}
+	return p.movFile
+}
+
+// isReady returns true if the period has the metadata the analysis needs. An
+// MP4 used directly only needs its timecode (its chapters are read from it).
+func (p *detectedPeriodInfo) isReady() bool {
+	if p.useMP4 && p.mp4File != nil {
+		return p.mp4File.hasTimecode
+	}
+	return p.ready
+}
+
+// canUseMP4 returns true if the period has a GoPro MP4 its clips can be cut
+// from directly (.360 files must be reframed first)
+func (p *detectedPeriodInfo) canUseMP4() bool {
+	return p.mp4File != nil && p.mp4File.fileType == "mp4"
 }
 
 // splitGoProGroup holds information about split GoPro files
@@ -148,6 +283,14 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 	filesFoundLabel := widget.NewLabel("")
 	filesFoundLabel.Wrapping = fyne.TextWrapWord
 
+	// Backup copies of videos found by the last scan (see duplicates.go)
+	var duplicates []metadata.DuplicateVideo
+	duplicatesLabel := widget.NewLabel("")
+	duplicatesLabel.Wrapping = fyne.TextWrapWord
+	moveDuplicatesBtn := widget.NewButton("Move Copies Aside...", nil)
+	duplicatesSection := container.NewVBox(duplicatesLabel, container.NewHBox(moveDuplicatesBtn))
+	duplicatesSection.Hide()
+
 	periodsContainer := container.NewVBox()
 	periodsScroll := container.NewScroll(periodsContainer)
 	periodsScroll.SetMinSize(fyne.NewSize(0, 250))
@@ -174,12 +317,310 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 
 	analyzeBtn := widget.NewButton("Analyze & Continue", nil)
 	analyzeBtn.Disable()
+	startTimesBtn := widget.NewButton("Start Times...", nil)
+	startTimesBtn.Disable()
+
+	// Double-press dedup threshold (0 disables)
+	dedupEntry := widget.NewEntry()
+	dedupEntry.SetText(fmt.Sprintf("%.1f", a.cfg.DedupThreshold))
+	// Splitting one long recording into periods (gap 0 and no start times = off)
+	splitGapEntry := widget.NewEntry()
+	splitGapEntry.SetText(fmt.Sprintf("%g", a.cfg.PeriodSplitGap))
+	periodStartsEntry := widget.NewEntry()
+	periodStartsEntry.SetPlaceHolder("e.g. 19:42, 20:31")
+	// Splitting a folder that holds several games (gap 0 and no start times = off)
+	gameGapEntry := widget.NewEntry()
+	gameGapEntry.SetText(fmt.Sprintf("%g", a.cfg.GameSplitGap))
+	gameStartsEntry := widget.NewEntry()
+	gameStartsEntry.SetPlaceHolder("e.g. 9:00, 14:30")
+	// Game worked on, when the folder holds several
+	gameSelect := widget.NewSelect(nil, nil)
+	gameRow := container.NewBorder(nil, nil, widget.NewLabel("Game:"), nil, gameSelect)
+	gameRow.Hide()
+	a.actions.showGames = func() {
+		a.sessionMu.Lock()
+		var labels []string
+		for i, game := range a.games {
+			info := a.gameInfo
+			if session := a.gameSessions[i+1]; i+1 != a.game && session != nil {
+				info = session.GameInfo
+			}
+			labels = append(labels, gameLabel(i+1, game, info))
+		}
+		current := a.game
+		a.sessionMu.Unlock()
+
+		gameSelect.OnChanged = nil
+		gameSelect.SetOptions(labels)
+		if len(labels) == 0 {
+			gameSelect.ClearSelected()
+			gameRow.Hide()
+			return
+		}
+		gameSelect.SetSelectedIndex(current - 1)
+		gameSelect.OnChanged = func(string) {
+			a.selectGame(gameSelect.SelectedIndex() + 1)
+		}
+		gameRow.Show()
+	}
+	// Zone the camera's clock was set to, for games recorded away from home
+	clockZoneEntry := widget.NewEntry()
+	clockZoneEntry.SetText(a.clockZone)
+	clockZoneEntry.SetPlaceHolder("this computer's (e.g. America/Chicago)")
+	a.setSettingsSync(stepSetup, func() {
+		dedupEntry.SetText(fmt.Sprintf("%.1f", a.cfg.DedupThreshold))
+		splitGapEntry.SetText(fmt.Sprintf("%g", a.cfg.PeriodSplitGap))
+		gameGapEntry.SetText(fmt.Sprintf("%g", a.cfg.GameSplitGap))
+	})
+
+	// User arrangement of the detected periods, kept across rescans of the same folder
+	var periodOrder []string         // MOV paths in the order the user arranged them
+	mergedPaths := map[string]bool{} // MOV paths merged into the previous period
+	directPaths := map[string]bool{} // MOV paths whose GoPro MP4 is used directly
+	orderIndex := func(path string) int {
+		for i, p := range periodOrder {
+			if p == path {
+				return i
+			}
+		}
+		return len(periodOrder)
+	}
+
+	// Last "Verify Sources" result by MOV path, shown on the cards
+	verifyResults := map[string]*ffmpeg.VerifyResult{}
+
+	// Page of period cards shown, when there are more than fit on one (see periodsPerPage)
+	periodPage := 0
+
+	// renderPeriods rebuilds the period cards and updates the buttons for the
+	// current order, merges and exclusions
+	var renderPeriods func()
+	renderPeriods = func() {
+		periodsContainer.Objects = nil
+		names := periodNames(detectedPeriods)
+
+		move := func(i, j int) {
+			detectedPeriods[i], detectedPeriods[j] = detectedPeriods[j], detectedPeriods[i]
+			periodOrder = nil
+			for _, dp := range detectedPeriods {
+				periodOrder = append(periodOrder, dp.movFile.path)
+			}
+			renderPeriods()
+		}
+
+		// Only the current page's cards are built, so a folder of hundreds of
+		// files doesn't build hundreds of cards
+		pages := (len(detectedPeriods) + periodsPerPage - 1) / periodsPerPage
+		periodPage = min(periodPage, max(pages-1, 0))
+		pageStart := periodPage * periodsPerPage
+		pageEnd := pageStart + periodsPerPage
+		if pages > 1 {
+			prevBtn := widget.NewButton("Previous", func() {
+				periodPage--
+				renderPeriods()
+			})
+			if periodPage == 0 {
+				prevBtn.Disable()
+			}
+			nextBtn := widget.NewButton("Next", func() {
+				periodPage++
+				renderPeriods()
+			})
+			if periodPage == pages-1 {
+				nextBtn.Disable()
+			}
+			periodsContainer.Add(container.NewHBox(prevBtn,
+				widget.NewLabel(fmt.Sprintf("Videos %d-%d of %d (page %d of %d)",
+					pageStart+1, min(pageEnd, len(detectedPeriods)), len(detectedPeriods), periodPage+1, pages)),
+				nextBtn))
+		}
+
+		common := commonFormat(detectedPeriods)
+		mismatched := 0
+		included := 0
+		needsExtraction := false
+		allReady := true
+		for i, period := range detectedPeriods {
+			mov := period.movFile
+			video := period.video()
+
+			mismatch := !period.excluded && common != "" && video.formatKey() != "" && video.formatKey() != common
+			if mismatch {
+				mismatched++
+			}
+			firstIncluded := included == 0
+			title := "Excluded"
+			if !period.excluded {
+				title = displayPeriodName(names[i])
+				included++
+				if !period.isReady() {
+					allReady = false
+					if !period.useMP4 && period.metadataFile == nil && period.mp4File != nil && period.mp4File.hasChapters {
+						needsExtraction = true
+					}
+				}
+			}
+			if i < pageStart || i >= pageEnd {
+				continue
+			}
+
+			var statusText string
+			switch {
+			case period.useMP4:
+				statusText = fmt.Sprintf("Timecode: %s, %d chapters (from the MP4, used directly)", video.timecodeText(), video.chapterCount)
+				if !video.hasTimecode {
+					statusText = "No timecode in the MP4 - enter its start under Start Times..."
+				}
+			case period.metadataSource == "mov" && !mov.hasTimecode:
+				statusText = "No timecode - enter the start under Start Times..."
+			case period.metadataSource == "mov" && mov.chapterCount == 0:
+				statusText = fmt.Sprintf("Timecode: %s, no chapters (candidate clips are suggested, see Settings)", mov.timecodeText())
+			case period.metadataSource == "mov":
+				statusText = fmt.Sprintf("Timecode: %s, %d chapters (from MOV)", mov.timecodeText(), mov.chapterCount)
+			case period.metadataSource == "metadata":
+				statusText = "Using _metadata.txt file"
+				if mov.startTime != "" {
+					statusText += ", start " + mov.timecodeText()
+				}
+			case period.metadataSource == "needs_extraction":
+				if (period.metadataFile != nil || mov.hasChapters) && (period.mp4File == nil || !period.mp4File.hasChapters) {
+					statusText = "No timecode - enter the start under Start Times..."
+				} else if period.mp4File != nil && period.mp4File.hasChapters {
+					statusText = fmt.Sprintf("Need to extract (%d chapters in GoPro file)", period.mp4File.chapterCount)
+				} else {
+					statusText = "No metadata available"
+				}
+			}
+
+			videoText := fmt.Sprintf("Video: %s", filepath.Base(video.path))
+			if video.source != nil {
+				videoText += fmt.Sprintf(" (%s)", video.source.Label())
+			}
+			cardContent := container.NewVBox(
+				widget.NewLabel(videoText),
+				widget.NewLabel(fmt.Sprintf("Status: %s", statusText)),
+			)
+
+			if period.mp4File != nil && period.mp4File != mov {
+				sourceText := fmt.Sprintf("GoPro source: %s", filepath.Base(period.mp4File.path))
+				if period.mp4File.source != nil {
+					sourceText += fmt.Sprintf(" (%s)", period.mp4File.source.Label())
+				}
+				cardContent.Add(widget.NewLabel(sourceText))
+			}
+
+			if format := video.formatText(); format != "" {
+				cardContent.Add(widget.NewLabel("Format: " + format))
+			}
+			if mismatch {
+				mismatchLabel := widget.NewLabel(fmt.Sprintf("Warning: recorded as %s, unlike the other periods (%s) - "+
+					"check the camera settings; a reel mixing them has to be re-encoded to one format", video.formatKey(), common))
+				mismatchLabel.Wrapping = fyne.TextWrapWord
+				cardContent.Add(mismatchLabel)
+			}
+
+			if video.source != nil {
+				for _, warning := range video.source.Warnings {
+					warningLabel := widget.NewLabel("Warning: " + warning)
+					warningLabel.Wrapping = fyne.TextWrapWord
+					cardContent.Add(warningLabel)
+				}
+			}
+
+			if result, ok := verifyResults[video.path]; ok {
+				verifyText := "Verified: decodes cleanly"
+				if !result.OK() {
+					verifyText = "Verify found problems:\n" + result.Summary()
+				}
+				verifyLabel := widget.NewLabel(verifyText)
+				verifyLabel.Wrapping = fyne.TextWrapWord
+				cardContent.Add(verifyLabel)
+			}
+
+			upBtn := widget.NewButton("Move Up", func() { move(i, i-1) })
+			if i == 0 {
+				upBtn.Disable()
+			}
+			downBtn := widget.NewButton("Move Down", func() { move(i, i+1) })
+			if i == len(detectedPeriods)-1 {
+				downBtn.Disable()
+			}
+
+			mergeCheck := widget.NewCheck("Same period as previous", nil)
+			mergeCheck.SetChecked(period.mergeWithPrev)
+			mergeCheck.OnChanged = func(checked bool) {
+				period.mergeWithPrev = checked
+				mergedPaths[mov.path] = checked
+				renderPeriods()
+			}
+			if period.excluded || firstIncluded {
+				mergeCheck.Disable()
+			}
+
+			excludeCheck := widget.NewCheck("Exclude", nil)
+			excludeCheck.SetChecked(period.excluded)
+			excludeCheck.OnChanged = func(checked bool) {
+				period.excluded = checked
+				a.setExcludedVideo(mov.path, checked)
+				renderPeriods()
+			}
+
+			row := container.NewHBox(upBtn, downBtn, mergeCheck, excludeCheck)
+			if period.canUseMP4() {
+				// Quick reels without converting: cut clips from the GoPro MP4 itself
+				directCheck := widget.NewCheck("Use MP4 directly", nil)
+				directCheck.SetChecked(period.useMP4)
+				directCheck.OnChanged = func(checked bool) {
+					period.useMP4 = checked
+					directPaths[mov.path] = checked
+					renderPeriods()
+				}
+				if period.mp4File == mov {
+					directCheck.Disable() // No MOV to use instead
+				}
+				row.Add(directCheck)
+			}
+			cardContent.Add(row)
+
+			periodsContainer.Add(widget.NewCard(title, mov.baseName, cardContent))
+		}
+		periodsContainer.Refresh()
+
+		// Update buttons based on status
+		if needsExtraction {
+			extractBtn.Show()
+			statusLabel.SetText("Some MOV files are missing metadata. Click 'Extract Metadata' to extract from GoPro files.")
+		} else {
+			extractBtn.Hide()
+		}
+
+		if included == 0 {
+			startTimesBtn.Disable()
+		} else {
+			startTimesBtn.Enable()
+		}
+		if included == 0 {
+			analyzeBtn.Disable()
+			statusLabel.SetText("All videos are excluded. Untick 'Exclude' on at least one to analyze.")
+		} else if allReady {
+			analyzeBtn.Enable()
+			status := fmt.Sprintf("Ready! Found %d periods with metadata.", countPeriods(names))
+			if mismatched > 0 {
+				status += fmt.Sprintf(" %d recorded in a different format from the rest - see the warnings on their cards.", mismatched)
+			}
+			statusLabel.SetText(status)
+		} else if !needsExtraction {
+			analyzeBtn.Disable()
+			statusLabel.SetText("Some periods are missing required metadata.")
+		}
+	}
 
 	// Scan and categorize folder (runs in background)
 	scanFolder := func(folderPath string) {
 		// Clear previous results and show scanning indicator
 		detectedPeriods = nil
 		splitGroups = nil
+		verifyResults = map[string]*ffmpeg.VerifyResult{}
 		splitCheckboxes = nil
 		periodsContainer.Objects = nil
 		periodsContainer.Refresh()
@@ -187,10 +628,13 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 		splitContainer.Refresh()
 		splitSection.Hide()
 		filesFoundLabel.SetText("")
+		duplicates = nil
+		duplicatesSection.Hide()
 		statusLabel.SetText("Scanning folder...")
 		scanProgressBar.SetValue(0)
 		scanProgressBar.Show()
 		analyzeBtn.Disable()
+		startTimesBtn.Disable()
 		extractBtn.Hide()
 		combineBtn.Disable()
 
@@ -225,7 +669,7 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 				fullPath := filepath.Join(folderPath, name)
 
 				switch ext {
-				case ".mov", ".mp4":
+				case ".mov", ".mp4", ffmpeg.Max360Ext:
 					if ext == ".mp4" && strings.HasSuffix(baseName, "_metadata") {
 						continue
 					}
@@ -255,14 +699,27 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 				return
 			}
 
-			// Second pass: check metadata for each video file (slow)
-			var movFiles, mp4Files []detectedFile
+			// Second pass: check metadata for each video file (slow). A few files
+			// are probed at once per drive, so a big archive folder is scanned
+			// in a fraction of the time without thrashing a spinning disk.
+			probed := make([]detectedFile, totalFiles)
+			paths := make([]string, totalFiles)
 			for i, vf := range videoFiles {
-				fyne.Do(func() {
-					scanProgressBar.SetValue(float64(i) / float64(totalFiles))
-					statusLabel.SetText(fmt.Sprintf("Scanning %d/%d: %s...", i+1, totalFiles, filepath.Base(vf.path)))
-				})
-
+				paths[i] = vf.path
+			}
+			var scanned atomic.Int32
+			var lastScanned atomic.Value // Name of the file probed last
+			stopProgress := everyTick(scanProgressInterval, func() {
+				n := scanned.Load()
+				name, _ := lastScanned.Load().(string)
+				if n == 0 {
+					return
+				}
+				scanProgressBar.SetValue(float64(n) / float64(totalFiles))
+				statusLabel.SetText(fmt.Sprintf("Scanned %d/%d: %s...", n, totalFiles, name))
+			})
+			pipeline.ForEachByDrive(paths, a.driveWorkers(scanWorkers), func(i int) {
+				vf := videoFiles[i]
 				df := detectedFile{
 					path:     vf.path,
 					baseName: vf.baseName,
@@ -276,12 +733,39 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 					df.timecode = info.Timecode
 					df.hasChapters = info.HasChapters
 					df.chapterCount = info.ChapterCount
+					df.duration = info.Duration
+				}
+				if start := a.manualTimecode(vf.path); start != "" {
+					df.startTime = start
+					df.hasTimecode = true
 				}
+				if check, err := a.ff.CheckSource(vf.path); err == nil {
+					df.source = check
+				}
+				probed[i] = df
+
+				lastScanned.Store(filepath.Base(vf.path))
+				scanned.Add(1)
+			})
+			stopProgress()
+
+			// Backup copies (e.g. "GX010092 (1).MP4") would be bogus extra periods
+			foundDuplicates := metadata.FindDuplicateVideos(a.ff, paths)
+			copies := make(map[string]bool)
+			for _, d := range foundDuplicates {
+				copies[d.Path] = true
+			}
 
+			// MP4 and .360 files are GoPro originals, matched to MOVs by name
+			var movFiles, mp4Files []detectedFile
+			for i, vf := range videoFiles {
+				if copies[vf.path] {
+					continue
+				}
 				if vf.ext == ".mov" {
-					movFiles = append(movFiles, df)
+					movFiles = append(movFiles, probed[i])
 				} else {
-					mp4Files = append(mp4Files, df)
+					mp4Files = append(mp4Files, probed[i])
 				}
 			}
 
@@ -292,8 +776,36 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 			// Update UI with results
 			fyne.Do(func() {
 				scanProgressBar.SetValue(1.0)
-				filesFoundLabel.SetText(fmt.Sprintf("Found: %d MOV files, %d MP4 files, %d metadata files",
+				filesFoundLabel.SetText(fmt.Sprintf("Found: %d MOV files, %d MP4/.360 files, %d metadata files",
 					len(movFiles), len(mp4Files), len(metaFiles)))
+				duplicates = foundDuplicates
+				if len(duplicates) > 0 {
+					duplicatesLabel.SetText(duplicatesText(duplicates))
+					duplicatesSection.Show()
+				}
+
+				// 360° recordings can only be used once reframed to a MOV of the same name
+				var unframed []string
+				for _, src := range mp4Files {
+					if src.fileType != "360" {
+						continue
+					}
+					reframed := false
+					for _, mov := range movFiles {
+						if mov.baseName == src.baseName {
+							reframed = true
+						}
+					}
+					if !reframed {
+						unframed = append(unframed, filepath.Base(src.path))
+					}
+				}
+				if len(unframed) > 0 {
+					filesFoundLabel.SetText(filesFoundLabel.Text + fmt.Sprintf(
+						"\nGoPro MAX 360° files without a reframed MOV: %s. Reframe them in GoPro Player or Quik "+
+							"and export with the same name (e.g. GS010092.MOV) into this folder; the .360 is then "+
+							"used for HiLights and timecode.", strings.Join(unframed, ", ")))
+				}
 
 				// Show split GoPro files section if any detected
 				splitGroups = detectedSplitGroups
@@ -340,21 +852,13 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 				}
 				splitContainer.Refresh()
 
-				if len(movFiles) == 0 {
-					statusLabel.SetText("No MOV files found. Please select a folder with converted video files.")
-					scanProgressBar.Hide()
-					return
-				}
-
 				// Auto-create periods based on MOV files
-				needsExtraction := false
-				allReady := true
-
 				for i := range movFiles {
 					mov := &movFiles[i]
 					period := &detectedPeriodInfo{
-						name:    fmt.Sprintf("Period %d", i+1),
-						movFile: mov,
+						movFile:       mov,
+						excluded:      a.isExcludedVideo(mov.path),
+						mergeWithPrev: mergedPaths[mov.path],
 					}
 
 					// Find matching MP4 by base name
@@ -384,71 +888,77 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 						} else {
 							period.metadataSource = "needs_extraction"
 							period.ready = false
-							allReady = false
 						}
-					} else if period.mp4File != nil && period.mp4File.hasChapters {
-						period.metadataSource = "needs_extraction"
-						period.ready = false
-						needsExtraction = true
-						allReady = false
+					} else if !a.cfg.AutoChapters().IsZero() && (period.mp4File == nil || !period.mp4File.hasChapters) {
+						// No chapters anywhere: the analysis suggests them
+						period.metadataSource = "mov"
+						period.ready = mov.hasTimecode
 					} else {
 						period.metadataSource = "needs_extraction"
 						period.ready = false
-						allReady = false
 					}
 
+					period.useMP4 = directPaths[mov.path] && period.canUseMP4()
 					detectedPeriods = append(detectedPeriods, period)
+				}
 
-					// Create period card
-					var statusText string
-					switch period.metadataSource {
-					case "mov":
-						statusText = fmt.Sprintf("Timecode: %s, %d chapters (from MOV)", mov.timecode, mov.chapterCount)
-					case "metadata":
-						statusText = "Using _metadata.txt file"
-					case "needs_extraction":
-						if period.mp4File != nil && period.mp4File.hasChapters {
-							statusText = fmt.Sprintf("Need to extract (%d chapters in GoPro file)", period.mp4File.chapterCount)
-						} else {
-							statusText = "No metadata available"
+				// GoPro MP4s never converted to a MOV are used directly
+				for i := range mp4Files {
+					mp4 := &mp4Files[i]
+					converted := false
+					for _, mov := range movFiles {
+						if mov.baseName == mp4.baseName {
+							converted = true
 						}
 					}
-
-					cardContent := container.NewVBox(
-						widget.NewLabel(fmt.Sprintf("Video: %s", filepath.Base(mov.path))),
-						widget.NewLabel(fmt.Sprintf("Status: %s", statusText)),
-					)
-
-					if period.mp4File != nil {
-						cardContent.Add(widget.NewLabel(fmt.Sprintf("GoPro source: %s", filepath.Base(period.mp4File.path))))
+					if converted || mp4.fileType != "mp4" {
+						continue
 					}
-
-					card := widget.NewCard(period.name, mov.baseName, cardContent)
-					periodsContainer.Add(card)
+					detectedPeriods = append(detectedPeriods, &detectedPeriodInfo{
+						movFile:       mp4,
+						mp4File:       mp4,
+						excluded:      a.isExcludedVideo(mp4.path),
+						mergeWithPrev: mergedPaths[mp4.path],
+						useMP4:        true,
+					})
 				}
 
-				periodsContainer.Refresh()
-				scanProgressBar.Hide()
-
-				// Update buttons based on status
-				if needsExtraction {
-					extractBtn.Show()
-					statusLabel.SetText("Some MOV files are missing metadata. Click 'Extract Metadata' to extract from GoPro files.")
-				} else {
-					extractBtn.Hide()
+				if len(detectedPeriods) == 0 {
+					statusLabel.SetText("No MOV or GoPro MP4 files found. Please select a folder with GoPro footage.")
+					scanProgressBar.Hide()
+					return
 				}
 
-				if allReady {
-					analyzeBtn.Enable()
-					statusLabel.SetText(fmt.Sprintf("Ready! Found %d periods with metadata.", len(detectedPeriods)))
-				} else if !needsExtraction {
-					analyzeBtn.Disable()
-					statusLabel.SetText("Some periods are missing required metadata.")
-				}
+				// By name, then in the order the user arranged before this rescan
+				sort.SliceStable(detectedPeriods, func(i, j int) bool {
+					return detectedPeriods[i].movFile.baseName < detectedPeriods[j].movFile.baseName
+				})
+				sort.SliceStable(detectedPeriods, func(i, j int) bool {
+					return orderIndex(detectedPeriods[i].movFile.path) < orderIndex(detectedPeriods[j].movFile.path)
+				})
+
+				scanProgressBar.Hide()
+				renderPeriods()
 			})
 		}()
 	}
 
+	// openFolder makes path the working folder and scans it
+	openFolder := func(path string) {
+		if path != workingFolder {
+			periodOrder = nil
+			mergedPaths = map[string]bool{}
+			directPaths = map[string]bool{}
+			periodPage = 0
+		}
+		workingFolder = path
+		folderLabel.SetText(path)
+		a.cfg.LastWorkingDir = path
+		a.useWorkDir(path)
+
+		scanFolder(path)
+	}
+
 	// Select folder button
 	selectFolderBtn := widget.NewButton("Select Folder", func() {
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
@@ -459,14 +969,16 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
 				path = path[1:]
 			}
-			workingFolder = path
-			folderLabel.SetText(path)
-			a.cfg.LastWorkingDir = path
-
-			scanFolder(path)
+			openFolder(path)
 		}, a.window)
 	})
 
+	moveDuplicatesBtn.OnTapped = func() {
+		a.confirmMoveDuplicates(workingFolder, duplicates, func() {
+			scanFolder(workingFolder)
+		})
+	}
+
 	// Refresh button
 	refreshBtn := widget.NewButton("Refresh", func() {
 		if workingFolder != "" {
@@ -474,6 +986,28 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 		}
 	})
 
+	// Start times entered by hand, for videos whose timecode is missing or wrong
+	startTimesBtn.OnTapped = func() {
+		var videos []timecodeVideo
+		names := periodNames(detectedPeriods)
+		for i, dp := range detectedPeriods {
+			if dp.excluded {
+				continue
+			}
+			video := dp.video()
+			timecode := video.timecode
+			if !dp.useMP4 && dp.metadataSource != "mov" && dp.mp4File != nil {
+				timecode = dp.mp4File.timecode // The analysis reads it from the GoPro MP4
+			}
+			videos = append(videos, timecodeVideo{period: displayPeriodName(names[i]), path: video.path, timecode: timecode})
+		}
+		a.showManualTimecodes(videos, func() {
+			if workingFolder != "" {
+				scanFolder(workingFolder) // Periods without a timecode may be ready now
+			}
+		})
+	}
+
 	// Extract metadata button
 	extractBtn.OnTapped = func() {
 		if workingFolder == "" {
@@ -484,23 +1018,28 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 		extractProgressBar.Show()
 		extractProgressBar.SetValue(0)
 
-		go func() {
-			var toExtract []*detectedPeriodInfo
-			for _, p := range detectedPeriods {
-				if p.metadataSource == "needs_extraction" && p.mp4File != nil {
-					toExtract = append(toExtract, p)
-				}
+		var toExtract []*detectedPeriodInfo
+		for _, p := range detectedPeriods {
+			if !p.excluded && !p.useMP4 && p.metadataSource == "needs_extraction" && p.mp4File != nil {
+				toExtract = append(toExtract, p)
 			}
+		}
 
+		job := a.runJob("metadata", fmt.Sprintf("Extract metadata (%d files)", len(toExtract)), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
 			for i, p := range toExtract {
+				if job.Checkpoint() != nil {
+					break
+				}
+				status := fmt.Sprintf("Extracting %d/%d: %s...", i+1, len(toExtract), p.mp4File.baseName)
+				job.Update(float64(i)/float64(len(toExtract)), status)
 				fyne.Do(func() {
 					extractProgressBar.SetValue(float64(i) / float64(len(toExtract)))
-					statusLabel.SetText(fmt.Sprintf("Extracting %d/%d: %s...", i+1, len(toExtract), p.mp4File.baseName))
+					statusLabel.SetText(status)
 				})
 
 				// Generate output path
 				outputPath := filepath.Join(workingFolder, p.mp4File.baseName+"_metadata.txt")
-				err := a.ff.ExtractMetadata(p.mp4File.path, outputPath)
+				err := ff.ExtractMetadata(p.mp4File.path, outputPath)
 				if err == nil {
 					p.metadataFile = &detectedFile{
 						path:     outputPath,
@@ -518,8 +1057,62 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 				extractBtn.Enable()
 				scanFolder(workingFolder) // Refresh the display
 			})
-		}()
+			return nil
+		})
+		a.showQueued(job, statusLabel)
+	}
+
+	// Pick MP4 files by hand (e.g. from the SD card) and extract their metadata
+	// into the working folder, where the scan matches them to MOVs by name
+	var pickMP4Btn *widget.Button
+	extractMP4s := func(paths []string) {
+		pickMP4Btn.Disable()
+		extractProgressBar.Show()
+		extractProgressBar.SetValue(0)
+
+		job := a.runJob("metadata", fmt.Sprintf("Extract metadata (%d files)", len(paths)), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			failed := 0
+			for i, path := range paths {
+				if job.Checkpoint() != nil {
+					break
+				}
+				baseName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+				status := fmt.Sprintf("Extracting %d/%d: %s...", i+1, len(paths), baseName)
+				job.Update(float64(i)/float64(len(paths)), status)
+				fyne.Do(func() {
+					extractProgressBar.SetValue(float64(i) / float64(len(paths)))
+					statusLabel.SetText(status)
+				})
+
+				outputPath := filepath.Join(workingFolder, baseName+"_metadata.txt")
+				if err := ff.ExtractMetadata(path, outputPath); err != nil {
+					failed++
+				}
+			}
+
+			fyne.Do(func() {
+				extractProgressBar.SetValue(1.0)
+				extractProgressBar.Hide()
+				pickMP4Btn.Enable()
+				if failed > 0 {
+					a.showError("Extraction Errors", fmt.Sprintf("Failed to extract metadata from %d of %d files", failed, len(paths)))
+				}
+				scanFolder(workingFolder) // Refresh the display
+			})
+			if failed > 0 {
+				return fmt.Errorf("failed to extract metadata from %d of %d files", failed, len(paths))
+			}
+			return nil
+		})
+		a.showQueued(job, statusLabel)
 	}
+	pickMP4Btn = widget.NewButton("Extract Metadata from MP4 Files...", func() {
+		if workingFolder == "" {
+			a.showError("No Folder", "Please select a working folder first")
+			return
+		}
+		a.showMP4Picker(workingFolder, extractMP4s)
+	})
 
 	// Combine split files button
 	combineBtn.OnTapped = func() {
@@ -543,20 +1136,36 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 		combineProgressBar.Show()
 		combineProgressBar.SetValue(0)
 
-		go func() {
+		job := a.runJob("split", fmt.Sprintf("Combine %d split recordings", len(toCombine)), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			var lastErr error
+			var warnings []string
 			for i, group := range toCombine {
+				if job.Checkpoint() != nil {
+					break
+				}
+				status := fmt.Sprintf("Combining %d/%d: %s Video %s (%d parts)...",
+					i+1, len(toCombine), group.fileType, group.videoID, len(group.files))
+				job.Update(float64(i)/float64(len(toCombine)), status)
 				fyne.Do(func() {
 					combineProgressBar.SetValue(float64(i) / float64(len(toCombine)))
-					statusLabel.SetText(fmt.Sprintf("Combining %d/%d: %s Video %s (%d parts)...",
-						i+1, len(toCombine), group.fileType, group.videoID, len(group.files)))
+					statusLabel.SetText(status)
 				})
 
 				// Output filename: {prefix}_combined_{videoID}.{ext}
 				outputPath := filepath.Join(workingFolder,
 					fmt.Sprintf("%s_combined_%s.%s", group.prefix, group.videoID, group.fileType))
 
-				err := a.ff.CombineSplitGoPro(group.files, outputPath)
+				// Parts whose timecode doesn't follow on from the previous part still
+				// combine, but clock times after the join will be off
+				if partWarnings, err := ff.CheckSplitSegments(group.files); err == nil {
+					warnings = append(warnings, partWarnings...)
+				}
+
+				err := ff.WriteOutput(outputPath, func(path string) error {
+					return ff.CombineSplitGoPro(group.files, path)
+				})
 				if err != nil {
+					lastErr = err
 					fyne.Do(func() {
 						statusLabel.SetText(fmt.Sprintf("Error combining video %s: %s", group.videoID, err.Error()))
 					})
@@ -569,25 +1178,136 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 				combineBtn.Enable()
 				statusLabel.SetText(fmt.Sprintf("Combined %d video groups. Rescanning folder...", len(toCombine)))
 				scanFolder(workingFolder) // Refresh to show new combined files
+				if len(warnings) > 0 {
+					dialog.ShowInformation("Split Files Don't Line Up",
+						"The timecodes of some parts don't match their durations:\n\n- "+strings.Join(warnings, "\n- "), a.window)
+				}
 			})
-		}()
+			return lastErr
+		})
+		a.showQueued(job, statusLabel)
 	}
 
+	// Verify Sources: decode every included video end to end so a truncated or
+	// corrupt copy is found before clips are extracted from it
+	verifyBtn := widget.NewButton("Verify Sources", func() {
+		var paths []string
+		for _, dp := range detectedPeriods {
+			if !dp.excluded {
+				paths = append(paths, dp.video().path)
+			}
+		}
+		if len(paths) == 0 {
+			a.showError("No Videos", "Scan a working folder with at least one included video first.")
+			return
+		}
+
+		statusLabel.SetText(fmt.Sprintf("Verifying %d videos...", len(paths)))
+		job := a.runJob("verify", fmt.Sprintf("Verify %d source videos", len(paths)), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			var problems []string
+			for i, path := range paths {
+				if err := job.Checkpoint(); err != nil {
+					return err
+				}
+				name := filepath.Base(path)
+				result, err := ff.VerifySource(path, func(fraction float64) {
+					job.Update((float64(i)+fraction)/float64(len(paths)),
+						fmt.Sprintf("Verifying %d/%d: %s (%.0f%%)", i+1, len(paths), name, fraction*100))
+				})
+				if err != nil {
+					return err
+				}
+				if !result.OK() {
+					problems = append(problems, name+": "+result.Summary())
+				}
+				fyne.Do(func() {
+					verifyResults[path] = result
+					renderPeriods()
+					statusLabel.SetText(fmt.Sprintf("Verified %d/%d videos...", i+1, len(paths)))
+				})
+			}
+
+			fyne.Do(func() {
+				if len(problems) == 0 {
+					statusLabel.SetText(fmt.Sprintf("Verified %d videos: all decode cleanly.", len(paths)))
+					return
+				}
+				statusLabel.SetText(fmt.Sprintf("%d of %d videos have problems - see the period cards.", len(problems), len(paths)))
+				dialog.ShowInformation("Source Problems",
+					strings.Join(problems, "\n\n")+"\n\nClips from corrupt sections will be broken or missing. "+
+						"Copy the file off the SD card again if you still have it.", a.window)
+			})
+			return nil
+		})
+		a.showQueued(job, statusLabel)
+	})
+
 	// Analyze & Continue button
 	analyzeBtn.OnTapped = func() {
 		if len(detectedPeriods) == 0 {
 			return
 		}
 
+		dedupThreshold, err := strconv.ParseFloat(dedupEntry.Text, 64)
+		if err != nil || dedupThreshold < 0 {
+			dedupThreshold = 0
+		}
+		a.cfg.DedupThreshold = dedupThreshold
+
+		splitGap, err := strconv.ParseFloat(splitGapEntry.Text, 64)
+		if err != nil || splitGap < 0 {
+			splitGap = 0
+		}
+		a.cfg.PeriodSplitGap = splitGap
+		periodStarts, err := metadata.ParsePeriodStarts(periodStartsEntry.Text)
+		if err != nil {
+			a.showError("Invalid Period Start Times", err.Error())
+			return
+		}
+		split := metadata.PeriodSplit{
+			MinGap: time.Duration(splitGap * float64(time.Minute)),
+			Starts: periodStarts,
+		}
+		gameGap, err := strconv.ParseFloat(gameGapEntry.Text, 64)
+		if err != nil || gameGap < 0 {
+			gameGap = 0
+		}
+		a.cfg.GameSplitGap = gameGap
+		gameStarts, err := metadata.ParsePeriodStarts(gameStartsEntry.Text)
+		if err != nil {
+			a.showError("Invalid Game Start Times", err.Error())
+			return
+		}
+		gameSplit := metadata.GameSplit{
+			MinGap: time.Duration(gameGap * float64(time.Minute)),
+			Starts: gameStarts,
+		}
+		clockZone, err := parseClockZone(clockZoneEntry.Text)
+		if err != nil {
+			a.showError("Invalid Time Zone", err.Error())
+			return
+		}
+		a.sessionMu.Lock()
+		a.clockZone = strings.TrimSpace(clockZoneEntry.Text)
+		a.sessionMu.Unlock()
+
 		analyzeBtn.Disable()
 		statusLabel.SetText("Analyzing periods...")
 
-		go func() {
+		job := a.runJob("analyze", "Analyze "+workingFolder, func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
 			// Build periods for analysis
 			var periods []metadata.Period
+			names := periodNames(detectedPeriods)
 			for i, dp := range detectedPeriods {
+				if dp.excluded {
+					continue
+				}
+				if dp.useMP4 {
+					periods = append(periods, metadata.DirectPeriod(names[i], dp.mp4File.path))
+					continue
+				}
 				p := metadata.Period{
-					Name:      fmt.Sprintf("%dPeriod", i+1),
+					Name:      names[i],
 					VideoFile: dp.movFile.path,
 				}
 
@@ -609,36 +1329,91 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 			}
 
 			// Run analysis
-			analyzer := metadata.NewAnalyzer(a.ff)
+			analyzer := metadata.NewAnalyzer(ff)
+			analyzer.SetDedupThreshold(dedupThreshold)
+			analyzer.SetPeriodSplit(split)
+			analyzer.SetClockZone(clockZone)
+			analyzer.SetSpeedBursts(a.cfg.SpeedBurstKmh)
+			analyzer.SetAutoChapters(a.cfg.AutoChapters())
+			analyzer.SetManualStarts(a.manualStarts())
 			result, err := analyzer.AnalyzePeriods(periods)
 			if err != nil {
+				var missing *metadata.MissingTimecodeError
 				fyne.Do(func() {
 					statusLabel.SetText("Error: " + err.Error())
 					analyzeBtn.Enable()
+					// Analysis can go ahead once the start is entered by hand
+					if errors.As(err, &missing) {
+						startTimesBtn.OnTapped()
+					}
 				})
-				return
+				return err
+			}
+
+			// A folder holding several games is worked on one game at a time,
+			// staying on the same game when it is analyzed again
+			games := analyzer.SplitGames(result, gameSplit)
+			game := 0
+			if len(games) > 1 {
+				a.sessionMu.Lock()
+				game = 1
+				if workingFolder == a.workingFolder && a.game > 0 && a.game <= len(games) {
+					game = a.game
+				}
+				a.sessionMu.Unlock()
+				result = games[game-1]
 			}
+			a.setGames(games)
 
-			a.analysisResult = result
-			a.periods = periods
-			a.workingFolder = workingFolder
-			a.cfg.Periods = periods
-			a.cfg.Save()
+			// Periods as analyzed, after any splitting of long recordings
+			carried := a.setAnalysis(result, result.Periods, workingFolder, game)
 
 			fyne.Do(func() {
-				statusLabel.SetText(fmt.Sprintf("Analysis complete! Found %d chapters across %d periods.",
-					len(result.Chapters), len(periods)))
-				a.markStepComplete(0)
+				doneMsg := fmt.Sprintf("Analysis complete! Found %d chapters across %d periods.",
+					len(result.Chapters), len(result.Periods))
+				if len(games) > 1 {
+					doneMsg = fmt.Sprintf("Analysis complete! The folder holds %d games; Game %d has %d chapters across %d periods (choose another game below).",
+						len(games), game, len(result.Chapters), len(result.Periods))
+				}
+				a.actions.showGames()
+				if carried != "" {
+					doneMsg += "\n" + carried
+				}
+				if dedupSummary := result.GetDedupSummary(); dedupSummary != "" {
+					doneMsg += "\n" + dedupSummary
+				}
+				statusLabel.SetText(doneMsg)
+				a.markStepComplete(stepSetup)
 				analyzeBtn.Enable()
 
+				// Stay on Step 1 so problems can be fixed here (reorder, exclude, re-pair files)
+				if warnings := result.GetWarningsSummary(); warnings != "" {
+					statusLabel.SetText(doneMsg + "\n" + warnings)
+					a.showAnalysisWarnings(result)
+					return
+				}
+
 				// Auto-switch to next tab
-				a.tabs.SelectIndex(1)
+				a.tabs.SelectIndex(stepExtract)
 			})
-		}()
+			return nil
+		})
+		a.showQueued(job, statusLabel)
+	}
+
+	a.actions.openFolder = tapAction(selectFolderBtn)
+	a.actions.dropFolder = openFolder
+	a.actions.dropMP4s = func(paths []string) {
+		if workingFolder == "" {
+			a.showError("No Folder", "Please select a working folder first")
+			return
+		}
+		extractMP4s(paths)
 	}
 
 	// Layout
-	folderRow := container.NewBorder(nil, nil, widget.NewLabel("Working Folder:"), container.NewHBox(selectFolderBtn, refreshBtn), folderLabel)
+	openProjectBtn := widget.NewButton("Open Project...", a.openProject)
+	folderRow := container.NewBorder(nil, nil, widget.NewLabel("Working Folder:"), container.NewHBox(selectFolderBtn, refreshBtn, openProjectBtn), folderLabel)
 
 	// Build split section UI
 	splitSection.Objects = []fyne.CanvasObject{
@@ -655,22 +1430,152 @@ func (a *App) createStep1Setup() fyne.CanvasObject {
 		folderRow,
 		widget.NewSeparator(),
 		filesFoundLabel,
+		duplicatesSection,
 		splitSection,
 		widget.NewSeparator(),
 		widget.NewLabel("Detected Periods"),
 	)
 
 	extractRow := container.NewVBox(
-		extractBtn,
+		container.NewHBox(extractBtn, pickMP4Btn, verifyBtn),
 		extractProgressBar,
 	)
 
+	dedupRow := container.NewHBox(
+		widget.NewLabel("Ignore repeat HiLights within (seconds):"),
+		dedupEntry,
+	)
+
+	clockZoneRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Camera clock time zone:"),
+		nil,
+		clockZoneEntry,
+	)
+
+	splitRow := container.NewBorder(nil, nil,
+		container.NewHBox(
+			widget.NewLabel("One recording, several periods? Split at HiLight gaps over (min, 0 = off):"),
+			splitGapEntry,
+			widget.NewLabel("or at period start times:"),
+		),
+		nil,
+		periodStartsEntry,
+	)
+
+	gameSplitRow := container.NewBorder(nil, nil,
+		container.NewHBox(
+			widget.NewLabel("Several games in this folder? Split at recording gaps over (min, 0 = off):"),
+			gameGapEntry,
+			widget.NewLabel("or at game start times:"),
+		),
+		nil,
+		gameStartsEntry,
+	)
+
 	footer := container.NewVBox(
 		widget.NewSeparator(),
 		extractRow,
 		statusLabel,
-		analyzeBtn,
+		dedupRow,
+		splitRow,
+		gameSplitRow,
+		clockZoneRow,
+		container.NewBorder(nil, nil, nil, container.NewHBox(startTimesBtn, widget.NewButton("Game Details...", a.showGameInfo)), analyzeBtn),
+		gameRow,
 	)
 
 	return container.NewBorder(header, footer, nil, nil, periodsScroll)
 }
+
+// periodNames returns the analysis name for each detected period ("" if excluded).
+// Included files are numbered in order; a file merged with the previous one
+// shares its number as a further part ("2Period", "2Period-2").
+func periodNames(detected []*detectedPeriodInfo) []string {
+	names := make([]string, len(detected))
+	number, part := 0, 1
+	for i, dp := range detected {
+		if dp.excluded {
+			continue
+		}
+		if dp.mergeWithPrev && number > 0 {
+			part++
+			names[i] = fmt.Sprintf("%dPeriod-%d", number, part)
+			continue
+		}
+		number++
+		part = 1
+		names[i] = fmt.Sprintf("%dPeriod", number)
+	}
+	return names
+}
+
+// displayPeriodName turns an analysis name into a card title ("2Period-2" -> "Period 2 (part 2)")
+func displayPeriodName(name string) string {
+	var number, part int
+	if n, _ := fmt.Sscanf(name, "%dPeriod-%d", &number, &part); n == 2 {
+		return fmt.Sprintf("Period %d (part %d)", number, part)
+	}
+	return fmt.Sprintf("Period %d", number)
+}
+
+// countPeriods returns how many logical periods the names describe (merged parts count once)
+func countPeriods(names []string) int {
+	count := 0
+	for _, name := range names {
+		if name != "" && !strings.Contains(name, "-") {
+			count++
+		}
+	}
+	return count
+}
+
+// isExcludedVideo reports whether a video was excluded from the periods in Step 1
+func (a *App) isExcludedVideo(path string) bool {
+	for _, p := range a.cfg.ExcludedVideos {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// setExcludedVideo adds or removes a video from the excluded list and saves the config,
+// so warmup or zamboni footage stays excluded when the folder is scanned again
+func (a *App) setExcludedVideo(path string, excluded bool) {
+	var kept []string
+	for _, p := range a.cfg.ExcludedVideos {
+		if p != path {
+			kept = append(kept, p)
+		}
+	}
+	if excluded {
+		kept = append(kept, path)
+	}
+	a.cfg.ExcludedVideos = kept
+	a.cfg.Save()
+}
+
+// showAnalysisWarnings lists the problems the analysis sanity checks found,
+// with a suggested fix for each, and offers to carry on to Step 2 anyway
+func (a *App) showAnalysisWarnings(result *metadata.AnalysisResult) {
+	list := container.NewVBox()
+	for _, w := range result.Warnings {
+		message := widget.NewLabelWithStyle(w.Message, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		message.Wrapping = fyne.TextWrapWord
+		suggestion := widget.NewLabel(w.Suggestion)
+		suggestion.Wrapping = fyne.TextWrapWord
+		list.Add(message)
+		list.Add(suggestion)
+		list.Add(widget.NewSeparator())
+	}
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(560, 300))
+
+	d := dialog.NewCustomConfirm("Check Periods", "Continue Anyway", "Fix in Step 1", scroll, func(proceed bool) {
+		if proceed {
+			a.tabs.SelectIndex(stepExtract)
+		}
+	}, a.window)
+	d.Show()
+}
@@ -2,16 +2,25 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
-	"gopro-gui/ffmpeg"
-	"gopro-gui/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/jobs"
 )
 
 // createStep2Extract creates the clip extraction UI
@@ -21,61 +30,460 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 	var checkboxes []*widget.Check
 	chaptersContainer := container.NewVBox()
 
-	// Output folder
-	outputFolderLabel := widget.NewLabel("(none selected)")
+	// Output folder ("" = the output layout's clip folder, if one is set up)
+	outputFolderLabel := widget.NewLabel("")
 	var outputFolder string
+	showOutputFolder := func() {
+		switch {
+		case outputFolder != "":
+			outputFolderLabel.SetText(outputFolder)
+		case a.cfg.OutputRoot != "":
+			outputFolderLabel.SetText(filepath.Join(a.cfg.OutputRoot, a.cfg.ClipFolderTemplate) + " (output layout)")
+		default:
+			outputFolderLabel.SetText("(none selected)")
+		}
+	}
+	showOutputFolder()
 
 	// Timing settings
 	beforeEntry := widget.NewEntry()
-	beforeEntry.SetText(fmt.Sprintf("%.0f", a.cfg.SecondsBefore))
+	beforeEntry.SetText(fmt.Sprintf("%g", a.cfg.SecondsBefore))
 	afterEntry := widget.NewEntry()
-	afterEntry.SetText(fmt.Sprintf("%.0f", a.cfg.SecondsAfter))
+	afterEntry.SetText(fmt.Sprintf("%g", a.cfg.SecondsAfter))
+	crossPeriodEntry := widget.NewEntry()
+	crossPeriodEntry.SetText(fmt.Sprintf("%g", a.cfg.CrossPeriodWindow))
+	roughSeekEntry := widget.NewEntry()
+	roughSeekEntry.SetText(fmt.Sprintf("%g", a.cfg.RoughSeekWindow))
+	overlapChoice := newOverlapChoice(a.cfg.Overlaps(), a.cfg.OverlapGap)
+	presetPicker := a.newPaddingPresetPicker(beforeEntry, afterEntry, overlapChoice)
+	hdrSelect := a.newHDRModeSelect()
+	qualitySelect := a.newClipQualitySelect()
+	audioTrackSelect := a.newAudioTrackSelect()
+	a.setSettingsSync(stepExtract, func() {
+		beforeEntry.SetText(fmt.Sprintf("%g", a.cfg.SecondsBefore))
+		afterEntry.SetText(fmt.Sprintf("%g", a.cfg.SecondsAfter))
+		overlapChoice.set(a.cfg.Overlaps(), a.cfg.OverlapGap)
+		crossPeriodEntry.SetText(fmt.Sprintf("%g", a.cfg.CrossPeriodWindow))
+		roughSeekEntry.SetText(fmt.Sprintf("%g", a.cfg.RoughSeekWindow))
+		hdrSelect.SetSelected(hdrModeLabels[a.ff.HDRMode()])
+		qualitySelect.SetSelected(clipQualityOptions[a.ff.ClipQuality()].label)
+	})
 
 	// Encoding mode
 	streamCopyCheck := widget.NewCheck("Stream copy (MOV for Shotcut/editing) - Fast, no re-encoding", nil)
 	streamCopyCheck.SetChecked(false) // Default to re-encode for YouTube
+	mp4Check := widget.NewCheck("Copy H.264 sources into MP4 (ready to upload)", func(checked bool) {
+		a.cfg.StreamCopyMP4 = checked
+		a.cfg.Save()
+	})
+	mp4Check.SetChecked(a.cfg.StreamCopyMP4)
+	mp4Check.Disable() // Enabled when stream copy is checked
+	// Re-encoded clips can get a stream copy for editing from the same decode
+	editCopyCheck := widget.NewCheck("Also write a stream-copied MOV of each clip for editing (edit folder, same pass)", nil)
+	editCopyCheck.SetChecked(a.cfg.EditCopies)
+
+	// Photos of each highlight for social posts, saved next to the clips
+	photoFramesEntry := widget.NewEntry()
+	photoFramesEntry.SetText(strconv.Itoa(a.cfg.PhotoFrames))
+	photosCheck := widget.NewCheck("Save a JPEG photo at each highlight (photos folder)", func(checked bool) {
+		if checked {
+			photoFramesEntry.Enable()
+		} else {
+			photoFramesEntry.Disable()
+		}
+	})
+	photosCheck.SetChecked(a.cfg.HighlightPhotos)
+	if !a.cfg.HighlightPhotos {
+		photoFramesEntry.Disable()
+	}
+
+	// Show command / dry run toggles
+	cmdOpts := newCommandOptions()
 
 	// Status
 	statusLabel := widget.NewLabel("")
 	progressBar := widget.NewProgressBar()
 	progressBar.Hide()
 
+	// Live totals for the current selection and padding, so a target reel length can
+	// be hit before extracting
+	totalsLabel := widget.NewLabel("")
+	sourceRates := make(map[string]float64) // Period video -> bytes per second (stream copy estimate)
+	probingRates := false
+	var sourceInfo *ffmpeg.StreamInfo // First period's format, for re-encode time estimates
+	probingInfo := false
+	benchmarkBtn := widget.NewButton("Benchmark Encoders", nil)
+	var updateTotals func()
+	updateTotals = func() {
+		if a.analysisResult == nil || len(a.analysisResult.Chapters) == 0 {
+			totalsLabel.SetText("")
+			return
+		}
+		secBefore, errBefore := strconv.ParseFloat(beforeEntry.Text, 64)
+		secAfter, errAfter := strconv.ParseFloat(afterEntry.Text, 64)
+		if errBefore != nil || errAfter != nil {
+			totalsLabel.SetText("Enter valid seconds before/after to see totals")
+			return
+		}
+
+		var selected []metadata.Chapter
+		for _, ch := range a.analysisResult.Chapters {
+			if selectedChapters[ch.GlobalOrder] {
+				selected = append(selected, ch)
+			}
+		}
+		groups := a.groupChapters(selected, secBefore, secAfter)
+		total := metadata.TotalDuration(groups)
+		text := fmt.Sprintf("%d clips, estimated %s of footage", len(groups), formatDuration(total))
+
+		if !streamCopyCheck.Checked {
+			mbps := clipQualityOptions[a.ff.ClipQuality()].mbps
+			text += fmt.Sprintf(", ~%s re-encoded", formatSize(total*mbps*1000*1000/8))
+			if a.cfg.EncodeSpeed == nil {
+				text += " (benchmark the encoders for a time estimate)"
+			} else {
+				text += ", " + a.encodeEstimate(total, sourceInfo, false, 1)
+			}
+			if sourceInfo == nil && !probingInfo && len(a.periods) > 0 {
+				probingInfo = true
+				videoFile := a.periods[0].VideoFile
+				go func() {
+					info, _ := a.ff.GetStreamInfo(videoFile)
+					fyne.Do(func() {
+						sourceInfo = info // Stays nil if unreadable; estimates assume 1080p60
+						updateTotals()
+					})
+				}()
+			}
+			totalsLabel.SetText(text)
+			return
+		}
+
+		// Stream copy keeps the source bitrate, which is probed once per period video
+		var bytes float64
+		var missing []string
+		unknown := false
+		for _, g := range groups {
+			videoFile := a.analysisResult.GetPeriodVideoFile(g.Period)
+			rate, ok := sourceRates[videoFile]
+			if !ok {
+				missing = append(missing, videoFile)
+				continue
+			}
+			if rate == 0 {
+				unknown = true
+			}
+			bytes += rate * g.Duration
+		}
+		if len(missing) == 0 && !unknown {
+			text += fmt.Sprintf(", ~%s stream copied", formatSize(bytes))
+		} else if !probingRates {
+			probingRates = true
+			go func() {
+				rates := make(map[string]float64)
+				for _, videoFile := range missing {
+					if _, done := rates[videoFile]; done {
+						continue
+					}
+					if rate, err := a.sourceBytesPerSecond(videoFile); err == nil {
+						rates[videoFile] = rate
+					} else {
+						rates[videoFile] = 0 // Don't probe again; size shown as unknown
+					}
+				}
+				fyne.Do(func() {
+					for videoFile, rate := range rates {
+						sourceRates[videoFile] = rate
+					}
+					probingRates = false
+					updateTotals()
+				})
+			}()
+		}
+		if a.hasDirectPeriods() {
+			text += "\nGoPro MP4s are used directly: stream-copied clips start at the nearest keyframe (re-encode for exact cuts)"
+		}
+		totalsLabel.SetText(text)
+	}
+	benchmarkBtn.OnTapped = func() {
+		a.runBenchmark(statusLabel, func() {
+			benchmarkBtn.SetText("Re-run Benchmark")
+			updateTotals()
+		})
+	}
+	if a.cfg.EncodeSpeed != nil {
+		benchmarkBtn.SetText("Re-run Benchmark")
+	}
+	// checkPadding flags the clips as out of date when the padding changes
+	checkPadding := func() {
+		before, errBefore := strconv.ParseFloat(beforeEntry.Text, 64)
+		after, errAfter := strconv.ParseFloat(afterEntry.Text, 64)
+		if errBefore == nil && errAfter == nil {
+			a.checkPadding(before, after)
+		}
+	}
+	beforeEntry.OnChanged = func(string) {
+		updateTotals()
+		checkPadding()
+		presetPicker.match(presetPicker.sel.Selected)
+	}
+	afterEntry.OnChanged = func(string) {
+		updateTotals()
+		checkPadding()
+		presetPicker.match(presetPicker.sel.Selected)
+	}
+	overlapChoice.OnChanged = func() {
+		name, gap, ok := overlapChoice.value()
+		if !ok {
+			return
+		}
+		a.cfg.SetOverlaps(name, gap)
+		a.cfg.Save()
+		updateTotals()
+		presetPicker.match(presetPicker.sel.Selected)
+	}
+	streamCopyCheck.OnChanged = func(checked bool) {
+		if checked {
+			mp4Check.Enable()
+			qualitySelect.Disable()
+			editCopyCheck.Disable()
+		} else {
+			mp4Check.Disable()
+			qualitySelect.Enable()
+			editCopyCheck.Enable()
+		}
+		updateTotals()
+	}
+	qualityChanged := qualitySelect.OnChanged
+	qualitySelect.OnChanged = func(selected string) {
+		qualityChanged(selected)
+		updateTotals()
+	}
+
 	// Refresh chapters list
-	refreshChapters := func() {
+	// previewCut shows the exact first and last frames of the clip a chapter
+	// will be extracted in, merged with the selected chapters it overlaps
+	previewCut := func(ch metadata.Chapter) {
+		secBefore, errBefore := strconv.ParseFloat(beforeEntry.Text, 64)
+		secAfter, errAfter := strconv.ParseFloat(afterEntry.Text, 64)
+		if errBefore != nil || errAfter != nil {
+			a.showError("Invalid Setting", "Please enter valid seconds before/after")
+			return
+		}
+		chapters := []metadata.Chapter{ch}
+		for _, other := range a.analysisResult.Chapters {
+			if selectedChapters[other.GlobalOrder] && other.GlobalOrder != ch.GlobalOrder {
+				chapters = append(chapters, other)
+			}
+		}
+		for _, group := range a.groupChapters(chapters, secBefore, secAfter) {
+			for _, member := range group.Chapters {
+				if member.GlobalOrder == ch.GlobalOrder {
+					a.showCutPreview(group, streamCopyCheck.Checked)
+					return
+				}
+			}
+		}
+	}
+
+	var refreshChapters func()
+	refreshChapters = func() {
 		chaptersContainer.Objects = nil
 		checkboxes = nil
 		selectedChapters = make(map[int]bool)
 
+		// GoPro MP4s used directly are long-GOP, so only re-encoding cuts them exactly
+		if a.hasDirectPeriods() {
+			streamCopyCheck.SetChecked(false)
+		}
+
 		if a.analysisResult == nil || len(a.analysisResult.Chapters) == 0 {
 			chaptersContainer.Add(widget.NewLabel("No chapters available. Complete Step 1 first."))
 			chaptersContainer.Refresh()
+			updateTotals()
 			return
 		}
 
 		for _, ch := range a.analysisResult.Chapters {
 			ch := ch // capture for closure
+			text := fmt.Sprintf("%03d. [%s] %s Ch%02d @ %s",
+				ch.GlobalOrder,
+				ch.Period,
+				ch.ClockTime.Format("15:04:05"),
+				ch.Number,
+				metadata.FormatVideoTime(ch.VideoTime),
+			)
+			if ch.Title != "" {
+				text += fmt.Sprintf(" %q", ch.Title)
+			}
+			if ch.Label != "" {
+				text += " - " + ch.Label
+			}
 			check := widget.NewCheck(
-				fmt.Sprintf("%03d. [%s] %s Ch%02d @ %s",
-					ch.GlobalOrder,
-					ch.Period,
-					ch.ClockTime.Format("15:04:05"),
-					ch.Number,
-					metadata.FormatVideoTime(ch.VideoTime),
-				),
+				text,
 				func(checked bool) {
 					selectedChapters[ch.GlobalOrder] = checked
+					a.setChapterSelected(ch, checked)
+					updateTotals()
 				},
 			)
-			check.SetChecked(true)
-			selectedChapters[ch.GlobalOrder] = true
+			selected := a.chapterSelected(ch)
+			check.SetChecked(selected)
+			selectedChapters[ch.GlobalOrder] = selected
 			checkboxes = append(checkboxes, check)
-			chaptersContainer.Add(check)
+			renameBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				a.showRenameChapter(ch, refreshChapters)
+			})
+			renameBtn.Importance = widget.LowImportance
+			cutBtn := widget.NewButtonWithIcon("", theme.VisibilityIcon(), func() {
+				previewCut(ch)
+			})
+			cutBtn.Importance = widget.LowImportance
+			chaptersContainer.Add(container.NewBorder(nil, nil, nil, container.NewHBox(cutBtn, renameBtn), check))
 		}
 		chaptersContainer.Refresh()
+		updateTotals()
+	}
+
+	// Thumbnail grid view: a frame from each chapter's timestamp, so highlights can be
+	// recognized at a glance. GridWrap only builds visible cells, so thumbnails are
+	// generated lazily as the grid is scrolled.
+	if a.thumbs == nil {
+		a.thumbs = newThumbnailCache(a.ff)
 	}
+	var chapterGrid *widget.GridWrap
+	chapterGrid = widget.NewGridWrap(
+		func() int {
+			if a.analysisResult == nil {
+				return 0
+			}
+			return len(a.analysisResult.Chapters)
+		},
+		func() fyne.CanvasObject {
+			img := canvas.NewImageFromResource(theme.FileVideoIcon())
+			img.FillMode = canvas.ImageFillContain
+			img.SetMinSize(fyne.NewSize(192, 108))
+			return container.NewVBox(img, widget.NewCheck("000 0Period Ch00 00:00:00", nil))
+		},
+		func(id widget.GridWrapItemID, obj fyne.CanvasObject) {
+			if a.analysisResult == nil || id >= len(a.analysisResult.Chapters) {
+				return
+			}
+			ch := a.analysisResult.Chapters[id]
+			box := obj.(*fyne.Container)
+			img := box.Objects[0].(*canvas.Image)
+			check := box.Objects[1].(*widget.Check)
+
+			// Detach the handler while syncing so the refresh doesn't change the selection
+			check.OnChanged = nil
+			check.Text = fmt.Sprintf("%03d %s Ch%02d %s",
+				ch.GlobalOrder, ch.Period, ch.Number, ch.ClockTime.Format("15:04:05"))
+			check.SetChecked(selectedChapters[ch.GlobalOrder])
+			check.OnChanged = func(checked bool) {
+				selectedChapters[ch.GlobalOrder] = checked
+				if id < len(checkboxes) {
+					checkboxes[id].SetChecked(checked)
+				}
+			}
+
+			videoFile := a.analysisResult.GetPeriodVideoFile(ch.Period)
+			path, ok := a.thumbs.get(videoFile, ch.VideoTime.Seconds(), func() {
+				fyne.Do(func() {
+					chapterGrid.RefreshItem(id)
+				})
+			})
+			if ok {
+				img.Resource = nil
+				img.File = path
+			} else {
+				img.File = ""
+				img.Resource = theme.FileVideoIcon()
+			}
+			img.Refresh()
+		},
+	)
 
 	refreshBtn := widget.NewButton("Refresh Chapters", func() {
 		refreshChapters()
+		chapterGrid.Refresh()
+	})
+
+	// Import event times recorded outside the camera (stat sheet CSV or SRT)
+	importEvents := func(path, defaultPeriod string) {
+		statusLabel.SetText("Importing events from " + filepath.Base(path) + "...")
+
+		go func() {
+			events, err := metadata.ParseEventsFile(path, defaultPeriod)
+			if err != nil {
+				fyne.Do(func() {
+					statusLabel.SetText("Import failed: " + err.Error())
+				})
+				return
+			}
+
+			analyzer := metadata.NewAnalyzer(a.ff)
+			analyzer.SetClockZone(a.clockLocation())
+			analyzer.SetManualStarts(a.manualStarts())
+			starts, err := analyzer.PeriodStartTimes(a.analysisResult)
+			if err != nil {
+				fyne.Do(func() {
+					statusLabel.SetText("Import failed: " + err.Error())
+				})
+				return
+			}
+
+			added, skipped := a.analysisResult.MergeImportedEvents(events, starts)
+
+			fyne.Do(func() {
+				refreshChapters()
+				msg := fmt.Sprintf("Imported %d events from %s", added, filepath.Base(path))
+				if len(skipped) > 0 {
+					msg += fmt.Sprintf(" (%d skipped)", len(skipped))
+					a.showInfo("Skipped Events", strings.Join(skipped, "\n"))
+				}
+				statusLabel.SetText(msg)
+			})
+		}()
+	}
+
+	importBtn := widget.NewButton("Import Events (CSV/SRT)...", func() {
+		if a.analysisResult == nil || len(a.analysisResult.Periods) == 0 {
+			a.showError("No Analysis", "Please complete Step 1 first to analyze periods")
+			return
+		}
+
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+
+			// CSV rows name their period; SRT cues need one chosen here
+			if strings.ToLower(filepath.Ext(path)) != ".srt" {
+				importEvents(path, "")
+				return
+			}
+
+			var periodNames []string
+			for _, p := range a.analysisResult.Periods {
+				periodNames = append(periodNames, p.Name)
+			}
+			periodSelect := widget.NewSelect(periodNames, nil)
+			periodSelect.SetSelectedIndex(0)
+			dialog.ShowCustomConfirm("SRT Period", "Import", "Cancel",
+				container.NewVBox(widget.NewLabel("Which period video do these subtitles belong to?"), periodSelect),
+				func(ok bool) {
+					if ok {
+						importEvents(path, periodSelect.Selected)
+					}
+				}, a.window)
+		}, a.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".srt", ".txt"}))
+		fileDialog.Show()
 	})
 
 	selectAllBtn := widget.NewButton("Select All", func() {
@@ -85,6 +493,7 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 				selectedChapters[a.analysisResult.Chapters[i].GlobalOrder] = true
 			}
 		}
+		chapterGrid.Refresh()
 	})
 
 	deselectAllBtn := widget.NewButton("Deselect All", func() {
@@ -94,6 +503,7 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 				selectedChapters[a.analysisResult.Chapters[i].GlobalOrder] = false
 			}
 		}
+		chapterGrid.Refresh()
 	})
 
 	selectOutputBtn := widget.NewButton("Select Output Folder", func() {
@@ -106,21 +516,42 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 				path = path[1:]
 			}
 			outputFolder = path
-			outputFolderLabel.SetText(path)
+			showOutputFolder()
 			a.cfg.LastOutputDir = path
 		}, a.window)
 	})
+	layoutBtn := widget.NewButton("Output Layout...", func() {
+		a.showOutputLayout(func() {
+			// Saving the layout switches back to it from a hand-picked folder
+			if a.cfg.OutputRoot != "" {
+				outputFolder = ""
+			}
+			showOutputFolder()
+		})
+	})
 
+	var afterExtract func() // Next step of a re-cut, run once the clips are extracted
 	extractBtn := widget.NewButton("Extract Selected Clips", func() {
+		then := afterExtract
+		afterExtract = nil
 		if a.analysisResult == nil || len(a.analysisResult.Chapters) == 0 {
 			a.showError("No Chapters", "Please complete Step 1 first to analyze chapters")
 			return
 		}
 
-		if outputFolder == "" {
-			a.showError("No Output Folder", "Please select an output folder")
+		clipFolder := outputFolder
+		if clipFolder == "" {
+			clipFolder = a.clipOutputFolder()
+		}
+		if clipFolder == "" {
+			a.showError("No Output Folder", "Please select an output folder or set up an output layout")
+			return
+		}
+		if err := os.MkdirAll(clipFolder, 0755); err != nil {
+			a.showError("Output Folder", fmt.Sprintf("Could not create %s: %v", clipFolder, err))
 			return
 		}
+		a.cfg.LastOutputDir = clipFolder
 
 		// Parse timing
 		secBefore, err := strconv.ParseFloat(beforeEntry.Text, 64)
@@ -131,8 +562,25 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 		if err != nil {
 			secAfter = 2.0
 		}
+		crossWindow, err := strconv.ParseFloat(crossPeriodEntry.Text, 64)
+		if err != nil {
+			crossWindow = 10.0
+		}
 		a.cfg.SecondsBefore = secBefore
 		a.cfg.SecondsAfter = secAfter
+		a.cfg.CrossPeriodWindow = crossWindow
+		if padding, ok := presetPicker.current(); ok {
+			a.setPadding(padding)
+		}
+		if roughSeek, err := strconv.ParseFloat(roughSeekEntry.Text, 64); err == nil && roughSeek >= 0 {
+			a.cfg.RoughSeekWindow = roughSeek
+		}
+		a.ff.SetRoughSeekWindow(a.cfg.RoughSeekWindow)
+		a.cfg.HighlightPhotos = photosCheck.Checked
+		a.cfg.EditCopies = editCopyCheck.Checked
+		if frames, err := strconv.Atoi(photoFramesEntry.Text); err == nil && frames >= 0 {
+			a.cfg.PhotoFrames = frames
+		}
 
 		// Get selected chapters
 		var toExtract []metadata.Chapter
@@ -148,8 +596,8 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 		}
 
 		// Detect and merge overlapping chapters to avoid repeated video content
-		// FUTURE EXTENSION (Option B): Add UI to let user choose merge vs separate
-		clipGroups := metadata.DetectOverlappingChapters(toExtract, secBefore, secAfter)
+		// (unless the padding preset keeps them separate)
+		clipGroups := a.groupChapters(toExtract, secBefore, secAfter)
 
 		// Show overlap summary if any overlaps were detected
 		overlapSummary := metadata.GetOverlapSummary(clipGroups)
@@ -157,101 +605,167 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 			statusLabel.SetText(overlapSummary)
 		}
 
+		// Warn about near-duplicate highlights across period boundaries
+		// (can't merge these since they come from different video files)
+		crossPeriodSummary := metadata.GetCrossPeriodSummary(
+			metadata.DetectCrossPeriodOverlaps(toExtract, crossWindow))
+		if crossPeriodSummary != "" {
+			dialog.ShowInformation("Possible Duplicate Highlights",
+				crossPeriodSummary+"\n\nThese highlights are close together in clock time but in different periods.\n"+
+					"Deselect one of each pair if they show the same play.", a.window)
+		}
+
 		progressBar.Show()
 		progressBar.SetValue(0)
-		a.extractedClips = []string{}
-
-		go func() {
+		dryRun := cmdOpts.dryRun()
+		streamCopy := streamCopyCheck.Checked
+		footage := metadata.TotalDuration(clipGroups)
+		source := sourceInfo
+		hadClips := len(a.extractedClips) > 0
+
+		job := a.runJob("extract", fmt.Sprintf("Extract %d clips", len(clipGroups)), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			a.beginCommands(cmdOpts)
 			totalClips := len(clipGroups)
-			completedClips := 0
-
-			for _, group := range clipGroups {
-				// Capture values for this iteration
-				currentClip := completedClips + 1
-				periodName := group.Period
-
-				// Build status message based on whether this is a merged group
-				var statusMsg string
-				if group.IsOverlap {
-					statusMsg = fmt.Sprintf("Extracting %d/%d: %s Ch%d-%d (merged, %.1fs)...",
-						currentClip, totalClips, periodName,
-						group.PrimaryChapter.Number,
-						group.Chapters[len(group.Chapters)-1].Number,
-						group.Duration)
-				} else {
-					statusMsg = fmt.Sprintf("Extracting %d/%d: %s Ch%d...",
-						currentClip, totalClips, periodName, group.PrimaryChapter.Number)
-				}
-
-				// Update status BEFORE starting extraction
-				fyne.Do(func() {
-					progressBar.SetValue(float64(completedClips) / float64(totalClips))
-					statusLabel.SetText(statusMsg)
-				})
-
-				// Get video file for this group's period
-				videoFile := a.analysisResult.GetPeriodVideoFile(group.Period)
-				if videoFile == "" {
-					fyne.Do(func() {
-						statusLabel.SetText(fmt.Sprintf("Error: No video file for period %s", periodName))
-					})
-					continue
-				}
-
-				// Use pre-calculated timing from the ClipGroup
-				startSec := group.StartTime
-				duration := group.Duration
-
-				// Get chapter markers for this clip
-				clipChapterInfo := group.GetClipChapters()
-				var chapters []ffmpeg.ClipChapter
-				for _, ch := range clipChapterInfo {
-					chapters = append(chapters, ffmpeg.ClipChapter{
-						OffsetMs: ch.OffsetMs,
-						Title:    ch.Title,
-					})
-				}
-
-				// Generate output filename with appropriate extension
-				clipName := metadata.GenerateGroupFilename(group)
-				if streamCopyCheck.Checked {
-					// Change extension to .mov for stream copy
-					clipName = clipName[:len(clipName)-4] + ".mov"
-				}
-				outputFile := filepath.Join(outputFolder, clipName)
-
-				// Extract the clip with chapter markers embedded
-				var err error
-				if streamCopyCheck.Checked {
-					err = a.ff.ExtractClipStreamCopyWithChapters(videoFile, outputFile, startSec, duration, chapters)
-				} else {
-					err = a.ff.ExtractClipWithChapters(videoFile, outputFile, startSec, duration, chapters)
-				}
-				if err != nil {
+			started := time.Now()
+			completedClips, extractErr := a.extractGroups(job, ff, clipGroups, clipFolder, streamCopy, dryRun,
+				func(progress float64, status string) {
+					status += timeLeft(started, progress)
 					fyne.Do(func() {
-						statusLabel.SetText(fmt.Sprintf("Error extracting: %s", err.Error()))
+						progressBar.SetValue(progress)
+						statusLabel.SetText(status)
 					})
-				} else {
-					a.extractedClips = append(a.extractedClips, outputFile)
-					completedClips++
-				}
+				})
+			if !streamCopy && !dryRun && extractErr == nil && completedClips == totalClips {
+				a.recordEncode(false, footage, source, time.Since(started))
 			}
 
 			finalCount := len(a.extractedClips)
 			fyne.Do(func() {
 				progressBar.SetValue(1.0)
 				progressBar.Hide()
+				a.endCommands(cmdOpts, "Extract Clips")
+				if dryRun {
+					statusLabel.SetText(fmt.Sprintf("Dry run: %d of %d clips checked, nothing was written", completedClips, totalClips))
+					return
+				}
 				var doneMsg string
 				if overlapSummary != "" {
 					doneMsg = fmt.Sprintf("Done! Extracted %d clips (%s)", finalCount, overlapSummary)
 				} else {
-					doneMsg = fmt.Sprintf("Done! Extracted %d clips to %s", finalCount, outputFolder)
+					doneMsg = fmt.Sprintf("Done! Extracted %d clips to %s", finalCount, clipFolder)
+				}
+				if a.cfg.HighlightPhotos {
+					doneMsg += "\nPhotos saved to " + filepath.Join(clipFolder, pipeline.PhotoFolder)
+				}
+				if a.cfg.EditCopies && !streamCopy {
+					doneMsg += "\nEditing copies saved to " + filepath.Join(clipFolder, pipeline.EditFolder)
+				}
+				if crossPeriodSummary != "" {
+					doneMsg += "\nWarning: " + crossPeriodSummary
 				}
 				statusLabel.SetText(doneMsg)
 				// Mark step complete if we extracted at least one clip
 				if finalCount > 0 {
-					a.markStepComplete(1)
+					a.clipPadding = &padding{secBefore, secAfter}
+					a.markStepComplete(stepExtract)
+					if hadClips {
+						a.markStale(stepEdit, "the clips were extracted again")
+					}
+				}
+				if then != nil && extractErr == nil && finalCount > 0 {
+					then()
+				}
+			})
+			return extractErr
+		})
+		a.showQueued(job, statusLabel)
+	})
+
+	// Export the selected clips as an NLE project (EDL + FCPXML) referencing the period videos
+	exportNLEBtn := widget.NewButton("Export EDL/FCPXML", func() {
+		if a.analysisResult == nil || len(a.analysisResult.Chapters) == 0 {
+			a.showError("No Chapters", "Please complete Step 1 first to analyze chapters")
+			return
+		}
+
+		exportFolder := outputFolder
+		if exportFolder == "" {
+			exportFolder = a.clipOutputFolder()
+		}
+		if exportFolder == "" {
+			exportFolder = a.workingFolder
+		}
+		if exportFolder == "" {
+			a.showError("No Output Folder", "Please select an output folder")
+			return
+		}
+		if err := os.MkdirAll(exportFolder, 0755); err != nil {
+			a.showError("Output Folder", fmt.Sprintf("Could not create %s: %v", exportFolder, err))
+			return
+		}
+
+		secBefore, err := strconv.ParseFloat(beforeEntry.Text, 64)
+		if err != nil {
+			secBefore = 8.0
+		}
+		secAfter, err := strconv.ParseFloat(afterEntry.Text, 64)
+		if err != nil {
+			secAfter = 2.0
+		}
+
+		var selected []metadata.Chapter
+		for _, ch := range a.analysisResult.Chapters {
+			if selectedChapters[ch.GlobalOrder] {
+				selected = append(selected, ch)
+			}
+		}
+		if len(selected) == 0 {
+			a.showError("No Selection", "Please select at least one chapter to export")
+			return
+		}
+		clipGroups := a.groupChapters(selected, secBefore, secAfter)
+
+		statusLabel.SetText("Writing EDL/FCPXML...")
+		go func() {
+			// Probe the period videos for frame rate, resolution and durations
+			format := metadata.SourceFormat{
+				FrameRate: 60,
+				Width:     1920,
+				Height:    1080,
+				Durations: make(map[string]float64),
+			}
+			for i, p := range a.analysisResult.Periods {
+				if i == 0 {
+					if info, err := a.ff.GetStreamInfo(p.VideoFile); err == nil {
+						if fps := info.FPS(); fps > 0 {
+							format.FrameRate = fps
+						}
+						format.Width = info.Width
+						format.Height = info.Height
+					}
+				}
+				dur, _ := a.ff.GetDuration(p.VideoFile)
+				format.Durations[p.Name] = dur
+			}
+
+			title := "Highlights"
+			if game := a.currentGameName(); game != "" {
+				title = game + " Highlights"
+			}
+			edlPath := filepath.Join(exportFolder, "highlights.edl")
+			fcpxmlPath := filepath.Join(exportFolder, "highlights.fcpxml")
+
+			err := metadata.WriteEDL(edlPath, title, a.analysisResult, clipGroups, format)
+			if err == nil {
+				err = metadata.WriteFCPXML(fcpxmlPath, title, a.analysisResult, clipGroups, format)
+			}
+
+			fyne.Do(func() {
+				if err != nil {
+					statusLabel.SetText("Error: " + err.Error())
+					return
 				}
+				statusLabel.SetText(fmt.Sprintf("Exported %d clips to:\n%s\n%s", len(clipGroups), edlPath, fcpxmlPath))
 			})
 		}()
 	})
@@ -260,29 +774,82 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 	refreshChapters()
 
 	// Layout
-	timingRow := container.NewHBox(
+	timingRow := container.NewVBox(presetPicker.row(), container.NewHBox(
 		widget.NewLabel("Seconds before:"),
 		beforeEntry,
 		widget.NewLabel("Seconds after:"),
 		afterEntry,
-	)
+		widget.NewLabel("Cross-period warning window:"),
+		crossPeriodEntry,
+		widget.NewLabel("Rough seek window (s, 0 = auto):"),
+		roughSeekEntry,
+	))
+
+	watermarkBtn := widget.NewButton("Watermark...", func() {
+		a.showWatermarkSettings()
+	})
+	colorBtn := widget.NewButton("Color & Rotation...", func() {
+		a.showColorSettings()
+	})
+	tagsBtn := widget.NewButton("Metadata Tags...", func() {
+		a.showTagSettings()
+	})
+	drivesBtn := widget.NewButton("Drives...", func() {
+		a.showDriveSettings()
+	})
 
 	encodingRow := container.NewVBox(
 		streamCopyCheck,
-		widget.NewLabel("  Unchecked = Re-encode to MP4 (H.264) for YouTube"),
+		container.NewHBox(widget.NewLabel("  "), mp4Check),
+		container.NewHBox(widget.NewLabel("  Unchecked = Re-encode to MP4 (H.264) for YouTube"), watermarkBtn, colorBtn, tagsBtn),
+		container.NewHBox(widget.NewLabel("  Quality:"), qualitySelect, widget.NewLabel("10-bit/HDR sources:"), hdrSelect),
+		container.NewHBox(widget.NewLabel("  Audio track:"), audioTrackSelect),
+		container.NewHBox(widget.NewLabel("  "), editCopyCheck),
+		container.NewHBox(photosCheck, widget.NewLabel("Frames on each side:"), photoFramesEntry),
+		cmdOpts.row(),
 	)
 
-	selectionBtns := container.NewHBox(refreshBtn, selectAllBtn, deselectAllBtn)
+	selectionBtns := container.NewHBox(refreshBtn, selectAllBtn, deselectAllBtn, importBtn)
 
 	outputRow := container.NewHBox(
 		widget.NewLabel("Output folder:"),
 		outputFolderLabel,
 		selectOutputBtn,
+		layoutBtn,
+		drivesBtn,
 	)
 
+	a.actions.extract = tapAction(extractBtn)
+	a.actions.recutClips = func(folder string, streamCopy bool, done func()) {
+		outputFolder = folder
+		showOutputFolder()
+		a.syncSettings() // Padding from the re-cut
+		if !a.hasDirectPeriods() {
+			streamCopyCheck.SetChecked(streamCopy)
+		}
+		afterExtract = done
+		extractBtn.OnTapped()
+	}
+
 	scroll := container.NewScroll(chaptersContainer)
 	scroll.SetMinSize(fyne.NewSize(0, 300))
 
+	// List / thumbnail view switch
+	chapterGrid.Hide()
+	viewRadio := widget.NewRadioGroup([]string{"List", "Thumbnails"}, func(view string) {
+		if view == "Thumbnails" {
+			scroll.Hide()
+			chapterGrid.Show()
+			chapterGrid.Refresh()
+		} else {
+			chapterGrid.Hide()
+			scroll.Show()
+		}
+	})
+	viewRadio.Horizontal = true
+	viewRadio.SetSelected("List")
+	chapterViews := container.NewStack(scroll, chapterGrid)
+
 	return container.NewVBox(
 		widget.NewLabel("Step 2: Select and Extract Clips"),
 		widget.NewSeparator(),
@@ -290,11 +857,12 @@ func (a *App) createStep2Extract() fyne.CanvasObject {
 		encodingRow,
 		widget.NewSeparator(),
 		widget.NewLabel("Select chapters to extract:"),
-		selectionBtns,
-		scroll,
+		container.NewHBox(selectionBtns, widget.NewLabel("  View:"), viewRadio),
+		chapterViews,
+		container.NewHBox(totalsLabel, benchmarkBtn),
 		widget.NewSeparator(),
 		outputRow,
-		extractBtn,
+		container.NewHBox(extractBtn, exportNLEBtn),
 		statusLabel,
 		progressBar,
 	)
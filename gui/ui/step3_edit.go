@@ -2,10 +2,10 @@ package ui
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -13,9 +13,18 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
-	"gopro-gui/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/jobs"
 )
 
+// maxReExtractWorkers caps how many clips "Apply All Changes" re-extracts at
+// once from each source drive (config.ReExtractWorkers, set in Settings), and
+// the per-drive limits set in Step 2
+const maxReExtractWorkers = 8
+
 // clipEditEntry holds the UI elements for editing a single clip
 type clipEditEntry struct {
 	chapter     metadata.Chapter
@@ -23,6 +32,44 @@ type clipEditEntry struct {
 	beforeEntry *widget.Entry
 	afterEntry  *widget.Entry
 	statusLabel *widget.Label
+	detailsBtn  *widget.Button // Shown when the last extraction failed
+	card        *widget.Card
+	audio       ffmpeg.ClipAudio // What is done with the clip's sound, as chosen
+
+	// applied is the timing the clip file was last extracted with; the edit
+	// is staged (dirty) while the entries differ from it
+	applied clipTiming
+}
+
+// clipTiming is the before/after timing as entered, with the audio choice
+type clipTiming struct {
+	before string
+	after  string
+	audio  ffmpeg.ClipAudio
+}
+
+// timing returns the timing currently entered (call on the UI thread)
+func (ce *clipEditEntry) timing() clipTiming {
+	return clipTiming{before: ce.beforeEntry.Text, after: ce.afterEntry.Text, audio: ce.audio}
+}
+
+// dirty returns true if the timing or audio has been changed since the clip was extracted
+func (ce *clipEditEntry) dirty() bool {
+	return ce.timing() != ce.applied
+}
+
+// seconds returns the seconds before and after the HiLight, falling back to
+// the configured defaults for values that don't parse
+func (t clipTiming) seconds(cfgBefore, cfgAfter float64) (float64, float64) {
+	secBefore, err := strconv.ParseFloat(t.before, 64)
+	if err != nil {
+		secBefore = cfgBefore
+	}
+	secAfter, err := strconv.ParseFloat(t.after, 64)
+	if err != nil {
+		secAfter = cfgAfter
+	}
+	return secBefore, secAfter
 }
 
 // createStep3Edit creates the clip editing UI
@@ -31,6 +78,38 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 	clipsContainer := container.NewVBox()
 
 	statusLabel := widget.NewLabel("")
+	var applyBtn *widget.Button
+
+	// Music played instead of the camera's sound in clips set to "Music bed"
+	musicBedLabel := widget.NewLabel("")
+	showMusicBed := func(path string) {
+		if path == "" {
+			musicBedLabel.SetText("Music bed: (none)")
+			return
+		}
+		musicBedLabel.SetText("Music bed: " + filepath.Base(path))
+	}
+	showMusicBed(a.cfg.MusicBedPath)
+	musicBedBtn := widget.NewButton("Choose Music Bed...", func() {
+		a.selectMusicBed(showMusicBed)
+	})
+
+	// updatePending shows how many clips have staged edits on the Apply button
+	updatePending := func() {
+		pending := 0
+		for _, ce := range clipEntries {
+			if ce.dirty() {
+				pending++
+			}
+		}
+		if pending == 0 {
+			applyBtn.SetText("Apply All Changes")
+			applyBtn.Disable()
+			return
+		}
+		applyBtn.SetText(fmt.Sprintf("Apply All Changes (%d)", pending))
+		applyBtn.Enable()
+	}
 
 	// Helper to match clip filename to chapter (handles both .mp4 and .mov)
 	matchClipToChapter := func(clipName string) *metadata.Chapter {
@@ -76,11 +155,49 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 				beforeEntry: widget.NewEntry(),
 				afterEntry:  widget.NewEntry(),
 				statusLabel: widget.NewLabel(""),
+				detailsBtn:  widget.NewButton("Details", nil),
+			}
+			ce.detailsBtn.Hide()
+
+			// Set default values from config, or the timing from an earlier edit
+			edit, edited := a.clipEdit(clipPath)
+			if edited {
+				ce.beforeEntry.SetText(fmt.Sprintf("%.1f", edit.SecondsBefore))
+				ce.afterEntry.SetText(fmt.Sprintf("%.1f", edit.SecondsAfter))
+			} else {
+				ce.beforeEntry.SetText(fmt.Sprintf("%.1f", a.cfg.SecondsBefore))
+				ce.afterEntry.SetText(fmt.Sprintf("%.1f", a.cfg.SecondsAfter))
+			}
+			ce.applied = ce.timing()
+			// An audio choice not re-extracted yet stays staged
+			ce.applied.audio = edit.Audio
+			ce.audio = a.clipAudioFor(clipPath)
+			if ce.dirty() {
+				ce.statusLabel.SetText("Changed (not applied)")
 			}
 
-			// Set default values from config
-			ce.beforeEntry.SetText(fmt.Sprintf("%.1f", a.cfg.SecondsBefore))
-			ce.afterEntry.SetText(fmt.Sprintf("%.1f", a.cfg.SecondsAfter))
+			// Editing the timing stages the change until it is applied
+			onEdit := func(string) {
+				if ce.dirty() {
+					ce.statusLabel.SetText("Changed (not applied)")
+				} else {
+					ce.statusLabel.SetText("")
+				}
+				updatePending()
+			}
+			ce.beforeEntry.OnChanged = onEdit
+			ce.afterEntry.OnChanged = onEdit
+
+			// The audio choice is kept as soon as it is made, so a re-encoded
+			// reel applies it even before the clip is re-extracted
+			audioSelect := newClipAudioSelect(ce.audio, func(audio ffmpeg.ClipAudio) {
+				ce.audio = audio
+				a.setClipAudio(ce.clipPath, audio)
+				onEdit("")
+				if audio == ffmpeg.AudioMusic && a.cfg.MusicBedPath == "" {
+					a.selectMusicBed(showMusicBed)
+				}
+			})
 
 			clipEntries = append(clipEntries, ce)
 
@@ -101,6 +218,8 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 				widget.NewLabel("  After:"),
 				ce.afterEntry,
 				widget.NewLabel("s"),
+				widget.NewLabel("  Audio:"),
+				audioSelect,
 			)
 
 			// Make entries smaller
@@ -110,7 +229,53 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 			reExtractBtn := widget.NewButton("Re-Extract", func() {
 				// Capture the entry for this closure
 				entry := ce
-				a.reExtractClip(entry)
+				a.reExtractClip(entry, updatePending)
+			})
+
+			// Compare the first and last frames before and after the change
+			previewBtn := widget.NewButton("Preview", func() {
+				a.showClipPreview(ce, updatePending)
+			})
+
+			// Stage a tighter timing without the quiet start and end, then preview it
+			trimBtn := widget.NewButton("Trim Dead Air", func() {
+				a.trimDeadAir([]*clipEditEntry{ce}, statusLabel, updatePending)
+			})
+
+			// A note goes into the clip's title, its reel chapter and exports.
+			// It is kept as typed; Enter also writes it into the clip file.
+			noteEntry := widget.NewEntry()
+			noteEntry.SetPlaceHolder("Note, e.g. Backhand roof shot by #9 (Enter updates the clip's title)")
+			noteEntry.SetText(a.clipNote(ce.chapter))
+			noteEntry.OnChanged = func(text string) {
+				a.setClipNote(ce.chapter, strings.TrimSpace(text))
+			}
+			noteEntry.OnSubmitted = func(string) {
+				group, ok := a.clipGroup(ce.clipPath)
+				if !ok {
+					group = metadata.ClipGroup{Chapters: []metadata.Chapter{ce.chapter}, Period: ce.chapter.Period, PrimaryChapter: ce.chapter}
+				}
+				ce.statusLabel.SetText("Updating title...")
+				go func() {
+					err := a.retagClip(ce.clipPath, group)
+					fyne.Do(func() {
+						if err != nil {
+							ce.statusLabel.SetText("Error: " + errorSummary(err.Error()))
+							return
+						}
+						ce.statusLabel.SetText("Title updated")
+					})
+				}()
+			}
+
+			// Players are tagged by jersey number against the roster, for the
+			// per-player counts in Review
+			playersLabel := widget.NewLabel(a.playersText(a.clipPlayersOf(ce.chapter)))
+			playersLabel.Wrapping = fyne.TextWrapWord
+			playersBtn := widget.NewButton("Players...", func() {
+				a.showPlayerPicker(ce.chapter, func(numbers []string) {
+					playersLabel.SetText(a.playersText(numbers))
+				})
 			})
 
 			card := widget.NewCard(
@@ -118,10 +283,13 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 				filepath.Base(ce.clipPath),
 				container.NewVBox(
 					timingRow,
-					container.NewHBox(reExtractBtn, ce.statusLabel),
+					container.NewBorder(nil, nil, widget.NewLabel("Note:"), nil, noteEntry),
+					container.NewBorder(nil, nil, playersBtn, nil, playersLabel),
+					container.NewHBox(previewBtn, trimBtn, reExtractBtn, ce.statusLabel, ce.detailsBtn),
 				),
 			)
 
+			ce.card = card
 			clipsContainer.Add(card)
 		}
 
@@ -130,11 +298,14 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 		}
 
 		clipsContainer.Refresh()
+		updatePending()
 	}
 
 	refreshBtn := widget.NewButton("Refresh", func() {
 		refreshClips()
+		a.clearStale(stepEdit)
 	})
+	a.actions.reloadEdits = refreshClips
 
 	// Load clips from a folder (for when clips were extracted in a previous session)
 	loadFromFolderBtn := widget.NewButton("Load from Folder", func() {
@@ -153,32 +324,51 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 			}
 
 			// Scan folder for clip files (.mp4 and .mov)
-			entries, err := os.ReadDir(folderPath)
+			clips, err := pipeline.ListClips(folderPath)
 			if err != nil {
 				a.showError("Error", "Failed to read folder: "+err.Error())
 				return
 			}
 
-			a.extractedClips = nil
-			for _, entry := range entries {
-				if entry.IsDir() {
-					continue
+			var loaded []string
+			for _, clipPath := range clips {
+				// Only add if it matches a chapter
+				if matchClipToChapter(filepath.Base(clipPath)) != nil {
+					loaded = append(loaded, clipPath)
 				}
-				ext := strings.ToLower(filepath.Ext(entry.Name()))
-				if ext == ".mp4" || ext == ".mov" {
-					clipPath := filepath.Join(folderPath, entry.Name())
-					// Only add if it matches a chapter
-					if matchClipToChapter(entry.Name()) != nil {
-						a.extractedClips = append(a.extractedClips, clipPath)
-					}
+			}
+
+			// The loaded clips stand in for Step 2's, as if extracted there
+			a.sessionMu.Lock()
+			a.extractedClips = loaded
+			a.sessionMu.Unlock()
+			a.saveSession()
+			if len(loaded) > 0 {
+				a.clipPadding = nil // Unknown for clips made elsewhere
+				a.markStepComplete(stepExtract)
+				if a.reelPath != "" {
+					a.markStale(stepCombine, "clips were loaded from a folder in Step 3 after the reel was combined")
 				}
 			}
 
-			statusLabel.SetText(fmt.Sprintf("Loaded %d clips from folder", len(a.extractedClips)))
+			statusLabel.SetText(fmt.Sprintf("Loaded %d clips from folder", len(loaded)))
 			refreshClips()
 		}, a.window)
 	})
 
+	trimAllBtn := widget.NewButton("Trim Dead Air (All)", func() {
+		if len(clipEntries) == 0 {
+			a.showError("No Clips", "No clips to trim")
+			return
+		}
+		a.trimDeadAir(clipEntries, statusLabel, updatePending)
+	})
+
+	// Names on the clips' player tags follow the roster
+	rosterBtn := widget.NewButton("Roster...", func() {
+		a.showRoster(refreshClips)
+	})
+
 	reExtractAllBtn := widget.NewButton("Re-Extract All With New Timings", func() {
 		if len(clipEntries) == 0 {
 			a.showError("No Clips", "No clips to re-extract")
@@ -186,21 +376,25 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 		}
 
 		statusLabel.SetText("Re-extracting all clips...")
+		a.reExtractClips(clipEntries, statusLabel, updatePending)
+	})
 
-		go func() {
-			for i, ce := range clipEntries {
-				progress := fmt.Sprintf("Re-extracting %d/%d...", i+1, len(clipEntries))
-				fyne.Do(func() {
-					statusLabel.SetText(progress)
-				})
-				a.reExtractClipSync(ce)
+	// Apply staged edits: re-extract only the clips whose timing changed
+	applyBtn = widget.NewButton("Apply All Changes", func() {
+		var dirty []*clipEditEntry
+		for _, ce := range clipEntries {
+			if ce.dirty() {
+				dirty = append(dirty, ce)
 			}
+		}
+		if len(dirty) == 0 {
+			return
+		}
 
-			fyne.Do(func() {
-				statusLabel.SetText(fmt.Sprintf("Done! Re-extracted %d clips.", len(clipEntries)))
-			})
-		}()
+		statusLabel.SetText(fmt.Sprintf("Applying changes to %d clips...", len(dirty)))
+		a.reExtractClips(dirty, statusLabel, updatePending)
 	})
+	applyBtn.Importance = widget.HighImportance
 
 	// Initial refresh
 	refreshClips()
@@ -209,15 +403,40 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 	scroll := container.NewScroll(clipsContainer)
 	scroll.SetMinSize(fyne.NewSize(0, 400))
 
-	helpText := widget.NewLabel("Adjust the before/after timing for individual clips and re-extract them.\n" +
+	// Up/Down move between clips, focusing the Before entry of each
+	a.actions.moveClip = func(delta int) {
+		if len(clipEntries) == 0 {
+			return
+		}
+		focused := a.window.Canvas().Focused()
+		next := 0
+		if delta < 0 {
+			next = len(clipEntries) - 1
+		}
+		for i, ce := range clipEntries {
+			if focused == ce.beforeEntry || focused == ce.afterEntry {
+				next = min(max(i+delta, 0), len(clipEntries)-1)
+				break
+			}
+		}
+		ce := clipEntries[next]
+		a.window.Canvas().Focus(ce.beforeEntry)
+		scroll.ScrollToOffset(fyne.NewPos(0, ce.card.Position().Y))
+	}
+
+	helpText := widget.NewLabel("Adjust the before/after timing and the sound of individual clips (mute shouting, turn it down, " +
+		"or play the music bed instead). Changes are staged until you click \"Apply All Changes\", which re-extracts " +
+		"only the changed clips, or \"Re-Extract\" on a single clip.\n" +
 		"This will overwrite the existing clip files.")
 	helpText.Wrapping = fyne.TextWrapWord
 
+	a.setAdvancedControls(stepEdit, loadFromFolderBtn)
 	header := container.NewVBox(
 		widget.NewLabel("Step 3: Edit Clips"),
 		widget.NewSeparator(),
 		helpText,
-		container.NewHBox(refreshBtn, loadFromFolderBtn, reExtractAllBtn),
+		container.NewHBox(refreshBtn, loadFromFolderBtn, applyBtn, trimAllBtn, reExtractAllBtn, rosterBtn),
+		container.NewHBox(musicBedLabel, musicBedBtn),
 		widget.NewSeparator(),
 	)
 
@@ -229,48 +448,107 @@ func (a *App) createStep3Edit() fyne.CanvasObject {
 	return container.NewBorder(header, footer, nil, nil, scroll)
 }
 
-// reExtractClip re-extracts a single clip with updated timing (runs async for UI responsiveness)
-func (a *App) reExtractClip(ce *clipEditEntry) {
+// reExtractClip re-extracts a single clip with updated timing (runs async for UI responsiveness).
+// onDone is called on the UI thread once it has finished.
+func (a *App) reExtractClip(ce *clipEditEntry, onDone func()) {
 	// Immediately show "Extracting..." status
 	ce.statusLabel.SetText("Extracting...")
 	ce.statusLabel.Refresh()
 
-	// Run extraction in background so UI stays responsive
-	go func() {
-		a.doExtractClip(ce)
-	}()
-}
+	timing := ce.timing()
 
-// reExtractClipSync re-extracts a single clip synchronously (for batch operations)
-func (a *App) reExtractClipSync(ce *clipEditEntry) {
-	// Show "Extracting..." status
-	fyne.Do(func() {
-		ce.statusLabel.SetText("Extracting...")
-		ce.statusLabel.Refresh()
+	// Run extraction as a job so it doesn't collide with other encodes
+	job := a.runJob("reextract", "Re-extract "+filepath.Base(ce.clipPath), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		fyne.Do(func() {
+			ce.statusLabel.SetText("Extracting...")
+		})
+		err := a.doExtractClip(ff, ce, timing)
+		fyne.Do(onDone)
+		return err
 	})
-
-	a.doExtractClip(ce)
+	if job.Snapshot().State == jobs.Pending {
+		ce.statusLabel.SetText("Queued...")
+	}
 }
 
-// doExtractClip performs the actual extraction work
-func (a *App) doExtractClip(ce *clipEditEntry) {
-	// Parse timing values
-	secBefore, err := strconv.ParseFloat(ce.beforeEntry.Text, 64)
-	if err != nil {
-		secBefore = a.cfg.SecondsBefore
+// reExtractClips re-extracts clips as one job, several at a time,
+// with combined progress on statusLabel. onDone is called on the UI thread
+// once they have all finished.
+func (a *App) reExtractClips(entries []*clipEditEntry, statusLabel *widget.Label, onDone func()) {
+	// Read the timings now, on the UI thread, so later edits are staged for the next batch
+	type clipWork struct {
+		ce     *clipEditEntry
+		timing clipTiming
 	}
-	secAfter, err := strconv.ParseFloat(ce.afterEntry.Text, 64)
-	if err != nil {
-		secAfter = a.cfg.SecondsAfter
+	var work []clipWork
+	for _, ce := range entries {
+		work = append(work, clipWork{ce, ce.timing()})
+		ce.statusLabel.SetText("Queued...")
 	}
 
+	total := len(work)
+	job := a.runJob("reextract", fmt.Sprintf("Re-extract %d clips", total), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+		var mu sync.Mutex
+		done, failed := 0, 0
+
+		// Up to ReExtractWorkers clips at once from each source drive
+		sources := make([]string, total)
+		for i, w := range work {
+			sources[i] = a.analysisResult.GetPeriodVideoFile(w.ce.chapter.Period)
+		}
+		workers := a.driveWorkers(min(max(a.cfg.ReExtractWorkers, 1), maxReExtractWorkers))
+		pipeline.ForEachByDrive(sources, workers, func(i int) {
+			if job.Checkpoint() != nil {
+				return
+			}
+			err := a.doExtractClip(ff, work[i].ce, work[i].timing)
+
+			mu.Lock()
+			if err != nil {
+				failed++
+			} else {
+				done++
+			}
+			progress := fmt.Sprintf("Re-extracted %d/%d...", done+failed, total)
+			job.Update(float64(done+failed)/float64(total), progress)
+			mu.Unlock()
+
+			fyne.Do(func() {
+				statusLabel.SetText(progress)
+			})
+		})
+
+		fyne.Do(func() {
+			statusLabel.SetText(fmt.Sprintf("Done! Re-extracted %d clips.", done))
+			onDone()
+			if done > 0 && a.reelPath != "" {
+				a.markStale(stepCombine, "clips were re-extracted in Step 3 after the reel was combined")
+			}
+		})
+		if failed > 0 {
+			return fmt.Errorf("%d of %d clips failed to re-extract", failed, total)
+		}
+		return nil
+	})
+	a.showQueued(job, statusLabel)
+}
+
+// doExtractClip performs the actual extraction work with the given timing,
+// running ffmpeg through the job's handle ff
+func (a *App) doExtractClip(ff *ffmpeg.FFmpeg, ce *clipEditEntry, timing clipTiming) error {
+	fyne.Do(func() {
+		ce.statusLabel.SetText("Extracting...")
+	})
+
+	secBefore, secAfter := timing.seconds(a.cfg.SecondsBefore, a.cfg.SecondsAfter)
+
 	// Get video file for this chapter's period
 	videoFile := a.analysisResult.GetPeriodVideoFile(ce.chapter.Period)
 	if videoFile == "" {
 		fyne.Do(func() {
 			ce.statusLabel.SetText("Error: No video file")
 		})
-		return
+		return fmt.Errorf("no video file for period %s", ce.chapter.Period)
 	}
 
 	// Calculate clip timing
@@ -280,17 +558,53 @@ func (a *App) doExtractClip(ce *clipEditEntry) {
 	}
 	duration := secBefore + secAfter
 
-	// Extract the clip (overwrites existing)
-	err = a.ff.ExtractClip(videoFile, ce.clipPath, startSec, duration)
+	group, ok := a.clipGroup(ce.clipPath)
+	if !ok {
+		group = metadata.ClipGroup{Chapters: []metadata.Chapter{ce.chapter}, Period: ce.chapter.Period, PrimaryChapter: ce.chapter}
+	}
+	captured := ce.chapter.ClockAt(startSec)
+	tags := a.captureTags(a.clipTags(group), captured)
+
+	// Extract the clip (overwrites existing), continuing into the next chapter
+	// file if needed, then mute it, turn it down or lay the music bed under it
+	span, spans := a.spanSource(ce.chapter.Period, startSec, duration)
+	err := ff.WriteOutput(ce.clipPath, func(path string) error {
+		return ff.WithClipAudio(path, timing.audio, a.cfg.MusicBedPath, func(path string) error {
+			if spans {
+				return ff.ExtractClipSpanning(span, path, startSec, duration, nil, tags, a.clipWatermark(), a.periodColor(ce.chapter.Period))
+			}
+			return ff.ExtractClipWithChapters(videoFile, path, startSec, duration, nil, tags, a.clipWatermark(), a.periodColor(ce.chapter.Period))
+		})
+	})
+
+	if err == nil {
+		err = a.stampCaptureTime(ce.clipPath, captured)
+	}
+	if err == nil {
+		a.setClipEdit(ce.clipPath, secBefore, secAfter, timing.audio)
+	}
 
 	// Show completion with timestamp so user knows it's a fresh extraction
 	fyne.Do(func() {
 		if err != nil {
-			ce.statusLabel.SetText("Error: " + err.Error())
+			ce.statusLabel.SetText("Error: " + errorSummary(err.Error()))
+			message := err.Error()
+			ce.detailsBtn.OnTapped = func() {
+				a.showFailureDetails("Re-extract "+filepath.Base(ce.clipPath), message)
+			}
+			ce.detailsBtn.Show()
 		} else {
+			ce.detailsBtn.Hide()
 			timestamp := time.Now().Format("15:04:05")
 			ce.statusLabel.SetText(fmt.Sprintf("Done! (%s)", timestamp))
+
+			// Edits made while it was extracting stay staged
+			ce.applied = timing
+			if ce.dirty() {
+				ce.statusLabel.SetText(fmt.Sprintf("Done! (%s) - changed since", timestamp))
+			}
 		}
 		ce.statusLabel.Refresh()
 	})
+	return err
 }
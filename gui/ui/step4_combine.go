@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +12,12 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/cloud"
+	"gopro-gui/jobs"
 )
 
 // createStep4Combine creates the combine clips UI
@@ -20,6 +26,7 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 	selectedClips := make(map[string]bool)
 	var checkboxes []*widget.Check
 	clipsContainer := container.NewVBox()
+	clipRows := make(map[string]fyne.CanvasObject) // Each clip's check and rating, by clip path
 
 	// Input/Output
 	inputFolderLabel := widget.NewLabel("(none selected)")
@@ -37,8 +44,12 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 	// Timer and cancel control
 	var timerStop chan bool
 	var combineRunning bool
+	var combineJob *jobs.Job
 
 	cancelBtn := widget.NewButton("Cancel", nil)
+
+	// Show command / dry run toggles
+	cmdOpts := newCommandOptions()
 	cancelBtn.Hide()
 
 	// Encoding options
@@ -50,38 +61,341 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		"Balanced (CRF 20) - ~8 Mbps",
 		"Smaller File (CRF 23) - ~5 Mbps",
 		"Smallest (CPU, CRF 23) - best compression",
+		"Target File Size (2-pass) - fits upload cap",
 	}, nil)
+
+	// Target size (only used by the "Target File Size" preset)
+	targetSizeEntry := widget.NewEntry()
+	targetSizeEntry.SetText(fmt.Sprintf("%.0f", a.cfg.TargetSizeMB))
+	a.setSettingsSync(stepCombine, func() {
+		targetSizeEntry.SetText(fmt.Sprintf("%.0f", a.cfg.TargetSizeMB))
+	})
+	targetSizeRow := container.NewHBox(widget.NewLabel("  Max size (MB):"), targetSizeEntry)
+	targetSizeRow.Hide()
+
+	qualitySelect.OnChanged = func(selected string) {
+		if reencodeCheck.Checked && selected == "Target File Size (2-pass) - fits upload cap" {
+			targetSizeRow.Show()
+		} else {
+			targetSizeRow.Hide()
+		}
+	}
 	qualitySelect.SetSelected("Smaller File (CRF 23) - ~5 Mbps")
 	qualitySelect.Disable() // Disabled until re-encode is checked
 
+	// Captions, one per clip, as an .srt file and/or a subtitle track
+	var captionLabels []string
+	for _, m := range captionModes {
+		captionLabels = append(captionLabels, m.label)
+	}
+	captionSelect := widget.NewSelect(captionLabels, func(selected string) {
+		a.cfg.ReelCaptions = captionModeValue(selected)
+	})
+	captionSelect.SetSelected(captionModeLabel(a.cfg.ReelCaptions))
+
+	// A vertical (9:16) copy of each reel for social media, cropped centered
+	// or where each clip is panned to
+	var verticalLabels []string
+	for _, m := range verticalModes {
+		verticalLabels = append(verticalLabels, m.label)
+	}
+	panBtn := widget.NewButton("Pan Clips...", func() {
+		var clips []string
+		for clip, selected := range selectedClips {
+			if selected {
+				clips = append(clips, clip)
+			}
+		}
+		a.showVerticalPans(a.orderClips(clips))
+	})
+	verticalSelect := widget.NewSelect(verticalLabels, func(selected string) {
+		a.cfg.VerticalReel = verticalModeValue(selected)
+		if a.cfg.VerticalReel == "pan" {
+			panBtn.Enable()
+		} else {
+			panBtn.Disable()
+		}
+	})
+	verticalSelect.SetSelected(verticalModeLabel(a.cfg.VerticalReel))
+
+	// The full reel and/or one reel per period, with the same settings
+	var periodReelLabels []string
+	for _, m := range periodReelModes {
+		periodReelLabels = append(periodReelLabels, m.label)
+	}
+	periodReelSelect := widget.NewSelect(periodReelLabels, func(selected string) {
+		a.cfg.PeriodReels = periodReelModeValue(selected)
+	})
+	periodReelSelect.SetSelected(periodReelModeLabel(a.cfg.PeriodReels))
+
+	// Intro/outro bumpers (video or image), conformed to the reel's format
+	introLabel := widget.NewLabel("(none)")
+	outroLabel := widget.NewLabel("(none)")
+	if a.cfg.IntroPath != "" {
+		introLabel.SetText(filepath.Base(a.cfg.IntroPath))
+	}
+	if a.cfg.OutroPath != "" {
+		outroLabel.SetText(filepath.Base(a.cfg.OutroPath))
+	}
+	stillDurationEntry := widget.NewEntry()
+	stillDurationEntry.SetText(fmt.Sprintf("%.1f", a.cfg.BumperStillDuration))
+
+	selectBumper := func(target *string, label *widget.Label) {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			*target = path
+			label.SetText(filepath.Base(path))
+		}, a.window)
+	}
+
+	selectIntroBtn := widget.NewButton("Select Intro", func() {
+		selectBumper(&a.cfg.IntroPath, introLabel)
+	})
+	clearIntroBtn := widget.NewButton("Clear", func() {
+		a.cfg.IntroPath = ""
+		introLabel.SetText("(none)")
+	})
+	selectOutroBtn := widget.NewButton("Select Outro", func() {
+		selectBumper(&a.cfg.OutroPath, outroLabel)
+	})
+	clearOutroBtn := widget.NewButton("Clear", func() {
+		a.cfg.OutroPath = ""
+		outroLabel.SetText("(none)")
+	})
+
+	// Conform format for the encode path ("Auto" = majority of selected clips)
+	conformResSelect := widget.NewSelect([]string{
+		"Auto (most common)",
+		"3840x2160",
+		"2704x1520",
+		"1920x1080",
+		"1280x720",
+	}, nil)
+	conformResSelect.SetSelected("Auto (most common)")
+	conformResSelect.Disable()
+
+	conformFpsSelect := widget.NewSelect([]string{
+		"Auto (most common)",
+		"24",
+		"25",
+		"30",
+		"50",
+		"60",
+		"120",
+	}, nil)
+	conformFpsSelect.SetSelected("Auto (most common)")
+	conformFpsSelect.Disable()
+
+	// Fades or crossfades between clips (re-encode only)
+	var transitionLabels []string
+	for _, s := range transitionStyles {
+		transitionLabels = append(transitionLabels, s.label)
+	}
+	transitionSelect := widget.NewSelect(transitionLabels, func(selected string) {
+		a.cfg.ReelTransition = transitionStyleValue(selected)
+	})
+	transitionSelect.SetSelected(transitionStyleLabel(a.cfg.ReelTransition))
+	transitionSelect.Disable()
+	transitionSecondsEntry := widget.NewEntry()
+	transitionSecondsEntry.SetText(fmt.Sprintf("%g", a.cfg.TransitionSeconds))
+	transitionSecondsEntry.Disable()
+
+	// Clip counter ("3/27") burned into the corner of each clip
+	counterCheck := widget.NewCheck("Clip counter (3/27)", func(checked bool) {
+		a.cfg.ClipCounterOnReel = checked
+	})
+	counterCheck.SetChecked(a.cfg.ClipCounterOnReel)
+	counterCheck.Disable()
+	counterCornerSelect := widget.NewSelect(ffmpeg.WatermarkCorners, func(selected string) {
+		a.cfg.ClipCounterCorner = selected
+	})
+	counterCornerSelect.SetSelected(a.cfg.ClipCounterCorner)
+	if counterCornerSelect.Selected == "" {
+		counterCornerSelect.SetSelected("top-right")
+	}
+	counterCornerSelect.Disable()
+
+	// Keep each clip's encode so a rebuild only encodes the clips that changed
+	segmentsCheck := widget.NewCheck("Quick rebuilds (keep each clip's encode next to the reel)", func(checked bool) {
+		a.cfg.ReelSegments = checked
+	})
+	segmentsCheck.SetChecked(a.cfg.ReelSegments)
+	segmentsCheck.Disable()
+
 	reencodeCheck.OnChanged = func(checked bool) {
 		if checked {
 			qualitySelect.Enable()
+			conformResSelect.Enable()
+			conformFpsSelect.Enable()
+			transitionSelect.Enable()
+			transitionSecondsEntry.Enable()
+			counterCheck.Enable()
+			counterCornerSelect.Enable()
+			segmentsCheck.Enable()
 		} else {
 			qualitySelect.Disable()
+			conformResSelect.Disable()
+			conformFpsSelect.Disable()
+			transitionSelect.Disable()
+			transitionSecondsEntry.Disable()
+			counterCheck.Disable()
+			counterCornerSelect.Disable()
+			segmentsCheck.Disable()
 		}
+		qualitySelect.OnChanged(qualitySelect.Selected)
+	}
+
+	// Live total of the selected clips' durations, probed from the files once each
+	totalsLabel := widget.NewLabel("")
+	clipDurations := make(map[string]float64) // Clip path -> seconds (-1 = probe failed)
+	probingDurations := false
+	var clipInfo *ffmpeg.StreamInfo // Format of the first clip measured, for re-encode time estimates
+	reelPasses := func() int {
+		if qualitySelect.Selected == "Target File Size (2-pass) - fits upload cap" {
+			return 2
+		}
+		return 1
+	}
+	cpuOnly := func() bool {
+		return qualitySelect.Selected == "Smallest (CPU, CRF 23) - best compression"
+	}
+	reelEstimate := func(seconds float64) string {
+		return a.encodeEstimate(seconds, clipInfo, cpuOnly(), reelPasses())
+	}
+	var updateTotals func()
+	updateTotals = func() {
+		var count, unknown int
+		var total float64
+		var missing []string
+		for clip, selected := range selectedClips {
+			if !selected {
+				continue
+			}
+			count++
+			dur, ok := clipDurations[clip]
+			switch {
+			case !ok:
+				missing = append(missing, clip)
+			case dur < 0:
+				unknown++
+			default:
+				total += dur
+			}
+		}
+		if count == 0 {
+			totalsLabel.SetText("")
+			return
+		}
+
+		text := fmt.Sprintf("%d clips selected, total %s", count, formatDuration(total))
+		if len(missing) > 0 {
+			text += fmt.Sprintf(" (measuring %d more...)", len(missing))
+			if !probingDurations {
+				probingDurations = true
+				needInfo := clipInfo == nil
+				go func() {
+					durations := make(map[string]float64)
+					for _, clip := range missing {
+						dur, err := a.ff.GetDuration(clip)
+						if err != nil {
+							dur = -1
+						}
+						durations[clip] = dur
+					}
+					var info *ffmpeg.StreamInfo
+					if needInfo {
+						info, _ = a.ff.GetStreamInfo(missing[0])
+					}
+					fyne.Do(func() {
+						for clip, dur := range durations {
+							clipDurations[clip] = dur
+						}
+						if clipInfo == nil {
+							clipInfo = info
+						}
+						probingDurations = false
+						updateTotals()
+					})
+				}()
+			}
+		} else if unknown > 0 {
+			text += fmt.Sprintf(" (%d unreadable)", unknown)
+		}
+		if reencodeCheck.Checked && len(missing) == 0 {
+			if estimate := reelEstimate(total); estimate != "" {
+				text += ", re-encode " + estimate
+			}
+		}
+		totalsLabel.SetText(text)
+	}
+
+	qualityChanged := qualitySelect.OnChanged
+	qualitySelect.OnChanged = func(selected string) {
+		qualityChanged(selected)
+		updateTotals()
+	}
+
+	// Show the clips in the chosen reel order
+	arrangeClips := func() {
+		var clips []string
+		for clip := range clipRows {
+			clips = append(clips, clip)
+		}
+		clipsContainer.Objects = nil
+		for _, clip := range a.orderClips(clips) {
+			clipsContainer.Add(clipRows[clip])
+		}
+		clipsContainer.Refresh()
+	}
+
+	// addClip adds a clip to the list, selected, with its star rating
+	addClip := func(clip string) {
+		check := widget.NewCheck(filepath.Base(clip), func(checked bool) {
+			selectedClips[clip] = checked
+			updateTotals()
+		})
+		check.SetChecked(true)
+		selectedClips[clip] = true
+		checkboxes = append(checkboxes, check)
+
+		rating := widget.NewSelect(ratingLabels, nil)
+		rating.SetSelected(ratingLabels[a.clipRating(clip)])
+		rating.OnChanged = func(selected string) {
+			for stars, label := range ratingLabels {
+				if label == selected && stars != a.clipRating(clip) {
+					a.setClipRating(clip, stars)
+					if a.cfg.ClipOrder == "rating" {
+						arrangeClips()
+					}
+				}
+			}
+		}
+		clipRows[clip] = container.NewBorder(nil, nil, nil, rating, check)
 	}
 
 	// Refresh clips list from folder
 	refreshClips := func() {
 		clipsContainer.Objects = nil
 		checkboxes = nil
+		clipRows = make(map[string]fyne.CanvasObject)
 		selectedClips = make(map[string]bool)
+		clipDurations = make(map[string]float64) // Clips may have been re-extracted
+		defer updateTotals()
 
 		if inputFolder == "" {
 			// Try to use extracted clips from step 2
 			if len(a.extractedClips) > 0 {
 				for _, clip := range a.extractedClips {
-					clip := clip
-					check := widget.NewCheck(filepath.Base(clip), func(checked bool) {
-						selectedClips[clip] = checked
-					})
-					check.SetChecked(true)
-					selectedClips[clip] = true
-					checkboxes = append(checkboxes, check)
-					clipsContainer.Add(check)
+					addClip(clip)
 				}
-				clipsContainer.Refresh()
+				arrangeClips()
 				return
 			}
 
@@ -90,47 +404,38 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 			return
 		}
 
-		// Scan input folder for MP4 files
-		entries, err := os.ReadDir(inputFolder)
+		// Scan input folder for clips from either extraction mode (.mp4 and .mov)
+		clips, err := pipeline.ListClips(inputFolder)
 		if err != nil {
 			clipsContainer.Add(widget.NewLabel("Error reading folder: " + err.Error()))
 			clipsContainer.Refresh()
 			return
 		}
 
-		var clips []string
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
-			ext := strings.ToLower(filepath.Ext(entry.Name()))
-			if ext == ".mp4" || ext == ".mov" {
-				clips = append(clips, filepath.Join(inputFolder, entry.Name()))
-			}
-		}
-
-		// Sort by filename (which should be chronological with our naming scheme)
-		sort.Strings(clips)
-
 		if len(clips) == 0 {
-			clipsContainer.Add(widget.NewLabel("No MP4 files found in folder"))
+			clipsContainer.Add(widget.NewLabel("No MP4 or MOV clips found in folder"))
 			clipsContainer.Refresh()
 			return
 		}
 
 		for _, clip := range clips {
-			clip := clip
-			check := widget.NewCheck(filepath.Base(clip), func(checked bool) {
-				selectedClips[clip] = checked
-			})
-			check.SetChecked(true)
-			selectedClips[clip] = true
-			checkboxes = append(checkboxes, check)
-			clipsContainer.Add(check)
+			addClip(clip)
 		}
-		clipsContainer.Refresh()
+		arrangeClips()
 	}
 
+	// Reel order: chronological (file names, which start with the clip's
+	// position in the game) or one of the other strategies in clipOrders
+	var clipOrderLabels []string
+	for _, o := range clipOrders {
+		clipOrderLabels = append(clipOrderLabels, o.label)
+	}
+	clipOrderSelect := widget.NewSelect(clipOrderLabels, func(selected string) {
+		a.cfg.ClipOrder = clipOrderValue(selected)
+		arrangeClips()
+	})
+	clipOrderSelect.SetSelected(clipOrderLabel(a.cfg.ClipOrder))
+
 	selectInputBtn := widget.NewButton("Select Input Folder", func() {
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
 			if err != nil || uri == nil {
@@ -146,7 +451,32 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		}, a.window)
 	})
 
+	// Clips dropped onto the window join the list, selected; a dropped
+	// folder becomes the input folder (see drop.go)
+	a.actions.dropClips = func(paths []string) {
+		var added int
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				inputFolder = path
+				inputFolderLabel.SetText(path)
+				refreshClips()
+				continue
+			}
+			if !pipeline.IsClip(path) || clipRows[path] != nil {
+				continue
+			}
+			addClip(path)
+			added++
+		}
+		if added > 0 {
+			arrangeClips()
+			updateTotals()
+			statusLabel.SetText(fmt.Sprintf("Added %d dropped clips.", added))
+		}
+	}
+
 	useStep2Btn := widget.NewButton("Use Clips from Step 2", func() {
+		a.clearStale(stepCombine)
 		// Use the output folder from Step 2 (where clips were extracted to)
 		if a.cfg.LastOutputDir != "" {
 			inputFolder = a.cfg.LastOutputDir
@@ -184,6 +514,7 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		for clip := range selectedClips {
 			selectedClips[clip] = true
 		}
+		updateTotals()
 	})
 
 	deselectAllBtn := widget.NewButton("Deselect All", func() {
@@ -193,17 +524,44 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		for clip := range selectedClips {
 			selectedClips[clip] = false
 		}
+		updateTotals()
 	})
 
 	// Cancel button handler
 	cancelBtn.OnTapped = func() {
-		if combineRunning {
-			statusLabel.SetText("Cancelling...")
-			a.ff.CancelExport()
+		if !combineRunning || combineJob == nil {
+			return
+		}
+		queued := combineJob.Snapshot().State == jobs.Pending
+		statusLabel.SetText("Cancelling...")
+		a.jobs.Cancel(combineJob.ID) // Stops the running ffmpeg process
+
+		// A queued combine never starts, so reset the UI here
+		if queued {
+			combineRunning = false
+			progressBar.Hide()
+			cancelBtn.Hide()
+			statusLabel.SetText("Combine cancelled.")
 		}
 	}
 
-	combineBtn := widget.NewButton("Combine Clips", func() {
+	var combineBtn *widget.Button
+	combineStale := false // Combine anyway, though the clips are out of date
+	combineBtn = widget.NewButton("Combine Clips", func() {
+		// Don't combine outdated clips without asking
+		if reason := a.stale[stepCombine]; reason != "" && !combineStale {
+			dialog.ShowConfirm("Clips Out of Date",
+				"These clips are out of date: "+reason+".\n\nReload them from Step 2 first, or combine them anyway?",
+				func(anyway bool) {
+					if anyway {
+						combineStale = true
+						combineBtn.OnTapped()
+					}
+				}, a.window)
+			return
+		}
+		combineStale = false
+
 		// Get selected clips
 		var toCombine []string
 		for clip, selected := range selectedClips {
@@ -221,8 +579,22 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 			return // Already running
 		}
 
-		// Sort clips by filename
-		sort.Strings(toCombine)
+		// Put the clips in the chosen reel order
+		toCombine = a.orderClips(toCombine)
+
+		// A clip cut off while being written (e.g. by a network share dropping
+		// out) has no index and breaks the concat, so catch it up front
+		var unreadable []string
+		for _, clip := range toCombine {
+			if clipDurations[clip] < 0 {
+				unreadable = append(unreadable, filepath.Base(clip))
+			}
+		}
+		if len(unreadable) > 0 {
+			a.showError("Unreadable Clips", fmt.Sprintf("These clips can't be read, probably because writing them was cut off:\n\n%s\n\n"+
+				"Re-extract them in Step 2 or 3, or uncheck them.", strings.Join(unreadable, "\n")))
+			return
+		}
 
 		// Parse encoding settings first (needed for output extension)
 		useReencode := reencodeCheck.Checked
@@ -230,8 +602,13 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		// Generate output filename if not set
 		finalOutput := outputFile
 		if finalOutput == "" {
-			var outputDir string
-			if inputFolder != "" {
+			outputDir := a.reelOutputFolder()
+			if outputDir != "" {
+				if err := os.MkdirAll(outputDir, 0755); err != nil {
+					a.showError("Output Folder", fmt.Sprintf("Could not create %s: %v", outputDir, err))
+					return
+				}
+			} else if inputFolder != "" {
 				outputDir = filepath.Dir(inputFolder)
 			} else if len(toCombine) > 0 {
 				outputDir = filepath.Dir(toCombine[0])
@@ -240,20 +617,41 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 			}
 			timestamp := time.Now().Format("2006-01-02_15-04")
 
-			// Determine output extension:
-			// - Re-encode always outputs MP4
-			// - Stream copy uses same extension as input files
-			ext := ".mp4"
-			if !useReencode && len(toCombine) > 0 {
-				ext = strings.ToLower(filepath.Ext(toCombine[0]))
-				if ext != ".mp4" && ext != ".mov" {
-					ext = ".mp4" // fallback
-				}
+			// Reels are always MP4: stream copied MOV clips are remuxed into it
+			finalOutput = filepath.Join(outputDir, fmt.Sprintf("combined_%s.mp4", timestamp))
+		}
+		// The combine functions add .mp4 to other names; match that here so the
+		// reel is written under a temporary name and moved to the right one
+		if !strings.HasSuffix(strings.ToLower(finalOutput), ".mp4") {
+			finalOutput += ".mp4"
+		}
+
+		// The full reel and/or one per period (Reel_P1.mp4, ...) next to it
+		var reels []reelTarget
+		var unknownPeriod []string
+		if a.cfg.PeriodReels != "periods" {
+			reels = append(reels, reelTarget{clips: toCombine, output: finalOutput})
+		}
+		if a.cfg.PeriodReels == "both" || a.cfg.PeriodReels == "periods" {
+			var periodTargets []reelTarget
+			periodTargets, unknownPeriod = a.periodReels(toCombine, filepath.Dir(finalOutput))
+			if len(periodTargets) == 0 {
+				a.showError("No Periods", "The periods of the selected clips aren't known, so they can't be split into period reels.\n\n"+
+					"Extract them in Step 2, or choose Reels: Full reel.")
+				return
 			}
-			finalOutput = filepath.Join(outputDir, fmt.Sprintf("combined_%s%s", timestamp, ext))
+			reels = append(reels, periodTargets...)
+		}
+		// Audio chosen in Step 3 but not re-extracted yet is applied while encoding
+		if pending := len(a.reelAudio(toCombine)); pending > 0 && !useReencode {
+			a.showError("Audio Not Applied", fmt.Sprintf("%d of the selected clips have audio changes from Step 3 that haven't been applied.\n\n"+
+				"Apply them in Step 3, or re-encode the reel so they are applied while combining.", pending))
+			return
 		}
+
 		crf := "23"
 		forceCPU := false
+		var targetSizeMB float64
 		encoderName := "Stream Copy"
 
 		if useReencode {
@@ -271,48 +669,137 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 				crf = "23"
 				forceCPU = true
 				encoderName = "CPU (CRF 23)"
+			case "Target File Size (2-pass) - fits upload cap":
+				size, err := strconv.ParseFloat(targetSizeEntry.Text, 64)
+				if err != nil || size <= 0 {
+					a.showError("Invalid Size", "Please enter a maximum file size in MB")
+					return
+				}
+				targetSizeMB = size
+				a.cfg.TargetSizeMB = size
+				encoderName = fmt.Sprintf("target size (%.0f MB)", size)
+			}
+		}
+
+		// Conform settings (resolved against the clips in the background)
+		conformRes := conformResSelect.Selected
+		conformFps := conformFpsSelect.Selected
+
+		// Watermark, scoreboard, clip counter and transitions only apply when re-encoding
+		watermark := a.reelWatermark()
+		var scoreboard *ffmpeg.Scoreboard
+		var transition ffmpeg.Transition
+		if useReencode {
+			seconds, err := strconv.ParseFloat(transitionSecondsEntry.Text, 64)
+			if err != nil || seconds <= 0 {
+				seconds = ffmpeg.DefaultTransitionDuration
+			}
+			a.cfg.TransitionSeconds = seconds
+			transition = ffmpeg.Transition{Style: a.cfg.ReelTransition, Duration: seconds}
+
+			sb, err := a.reelScoreboard(toCombine, clipDurations)
+			if err != nil {
+				a.showError("Scoreboard", err.Error()+"\n\nFix it in Scoreboard... or turn the scoreboard off.")
+				return
 			}
+			scoreboard = sb
 		}
+		counter := useReencode && a.cfg.ClipCounterOnReel
+		segmented := a.cfg.ReelSegments
+
+		// Bumper settings
+		introPath := a.cfg.IntroPath
+		outroPath := a.cfg.OutroPath
+		stillDuration, err := strconv.ParseFloat(stillDurationEntry.Text, 64)
+		if err != nil || stillDuration <= 0 {
+			stillDuration = 3.0
+		}
+		a.cfg.BumperStillDuration = stillDuration
+		if a.cfg.MontageOnReel && a.cfg.MontageMusicPath == "" {
+			a.showError("Intro Montage", "Choose the montage's music in Intro Montage... or turn the montage off.")
+			return
+		}
+		captionMode := a.cfg.ReelCaptions
+		verticalMode := a.cfg.VerticalReel
 
-		// Reset cancel state
-		a.ff.ResetCancel()
 		combineRunning = true
+		dryRun := cmdOpts.dryRun()
+
+		// Expected encode time from the benchmark, counted down next to the elapsed time
+		var footage float64
+		for _, reel := range reels {
+			for _, clip := range reel.clips {
+				if dur := clipDurations[clip]; dur > 0 {
+					footage += dur
+				}
+			}
+		}
+		footage *= float64(reelPasses())
+		source := clipInfo
+		var expected time.Duration
+		if a.cfg.EncodeSpeed != nil {
+			nvencTime, cpuTime := a.cfg.EncodeSpeed.Estimate(footage, source)
+			expected = nvencTime
+			if forceCPU || nvencTime == 0 || a.ff.PreferCPU() || a.ff.NVENCStatus() != nil {
+				expected = cpuTime
+			}
+		}
 
 		progressBar.Show()
 		progressBar.SetValue(0)
 		elapsedLabel.SetText("")
+		cancelBtn.Show()
 
+		var startMsg string
 		if useReencode {
-			cancelBtn.Show()
-			statusLabel.SetText(fmt.Sprintf("Combining %d clips with %s encoding...", len(toCombine), encoderName))
+			startMsg = fmt.Sprintf("Combining %d clips with %s encoding...", len(toCombine), encoderName)
 		} else {
-			statusLabel.SetText(fmt.Sprintf("Combining %d clips (stream copy)...", len(toCombine)))
+			startMsg = fmt.Sprintf("Combining %d clips (stream copy)...", len(toCombine))
 		}
+		statusLabel.SetText(startMsg)
 
-		// Start elapsed time timer for re-encode
-		startTime := time.Now()
-		timerStop = make(chan bool, 1)
+		jobTitle := "Combine reel " + filepath.Base(reels[0].output)
+		if len(reels) > 1 {
+			jobTitle = fmt.Sprintf("Combine %d reels", len(reels))
+		}
+		combineJob = a.runJob("combine", jobTitle, func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			a.beginCommands(cmdOpts)
+			job.Update(0, startMsg)
+			fyne.Do(func() {
+				statusLabel.SetText(startMsg)
+			})
 
-		if useReencode {
-			go func() {
-				ticker := time.NewTicker(1 * time.Second)
-				defer ticker.Stop()
-
-				for {
-					select {
-					case <-ticker.C:
-						elapsed := time.Since(startTime)
-						fyne.Do(func() {
-							elapsedLabel.SetText(fmt.Sprintf("Elapsed: %s", formatDuration(elapsed.Seconds())))
-						})
-					case <-timerStop:
-						return
+			// Start elapsed time timer for re-encode
+			startTime := time.Now()
+			timerStop = make(chan bool, 1)
+
+			if useReencode {
+				go func() {
+					ticker := time.NewTicker(1 * time.Second)
+					defer ticker.Stop()
+
+					for {
+						select {
+						case <-ticker.C:
+							elapsed := time.Since(startTime)
+							text := fmt.Sprintf("Elapsed: %s", formatDuration(elapsed.Seconds()))
+							if expected > 0 {
+								if elapsed < expected {
+									text += fmt.Sprintf(" (%s left)", ffmpeg.FormatEstimate(expected-elapsed))
+								} else {
+									text += " (taking longer than estimated)"
+								}
+							}
+							fyne.Do(func() {
+								elapsedLabel.SetText(text)
+							})
+						case <-timerStop:
+							return
+						}
 					}
-				}
-			}()
-		}
+				}()
+			}
 
-		go func() {
 			fyne.Do(func() {
 				if !useReencode {
 					progressBar.SetValue(0.5) // Indeterminate for stream copy
@@ -321,43 +808,201 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 				}
 			})
 
+			// Clips encoded and reused by each reel built clip by clip
+			var rebuilt []ffmpeg.SegmentStats
+
+			// combineReel writes one reel of clips to output
+			combineReel := func(clips []string, output string) error {
+				// Conform intro/outro to the clips' format and add them to the reel
+				reelInputs, cleanupBumpers, err := a.addBumpers(ff, clips, introPath, outroPath, stillDuration, a.reelMontage(clips))
+				if err != nil {
+					return err
+				}
+				defer cleanupBumpers()
+
+				// Dated by the earliest clip's footage (if Settings says to)
+				captured := a.reelCaptureTime(clips)
+				tags := a.captureTags(a.reelTags(), captured)
+
+				if len(reelInputs) > len(clips) {
+					fyne.Do(func() {
+						statusLabel.SetText(fmt.Sprintf("Combining %d clips with intro/outro...", len(clips)))
+					})
+				}
+				if useReencode {
+					opts := ffmpeg.ReelOptions{
+						Conform:       resolveConform(ff, clips, conformRes, conformFps),
+						Watermark:     watermark,
+						Scoreboard:    scoreboard,
+						Tags:          tags,
+						Transition:    transition,
+						ChapterTitles: a.reelChapterTitles(clips),
+						Audio:         a.reelAudio(clips),
+						MusicPath:     a.cfg.MusicBedPath,
+					}
+					if counter {
+						// Each reel counts its own clips; bumpers aren't counted
+						opts.Counter = &ffmpeg.ClipCounter{
+							Corner:   a.cfg.ClipCounterCorner,
+							FontFile: ffmpeg.DefaultFontFile(),
+							Clips:    clips,
+						}
+					}
+					fyne.Do(func() {
+						statusLabel.SetText(fmt.Sprintf("Combining %d clips with %s encoding, conformed to %s...",
+							len(clips), encoderName, opts.Conform))
+					})
+					if segmented && ffmpeg.CanSegmentReel(opts, targetSizeMB) {
+						// Clips unchanged since the last build keep their encode
+						var stats ffmpeg.SegmentStats
+						err = ff.WriteOutput(output, func(path string) error {
+							stats, err = ff.ConcatClipsSegmented(reelInputs, path, ffmpeg.SegmentFolder(output), crf, forceCPU, opts,
+								func(n, total, reused int) {
+									msg := fmt.Sprintf("Encoding clip %d of %d with %s (%d unchanged)...", n, total, encoderName, reused)
+									job.Update(float64(n-1)/float64(total), msg)
+									fyne.Do(func() {
+										statusLabel.SetText(msg)
+									})
+								})
+							return err
+						})
+						if err == nil {
+							rebuilt = append(rebuilt, stats)
+						}
+					} else {
+						err = ff.WriteOutput(output, func(path string) error {
+							return ff.ConcatClipsWithEncode(reelInputs, path, crf, forceCPU, targetSizeMB, opts)
+						})
+					}
+				} else {
+					err = ff.WriteOutput(output, func(path string) error {
+						return ff.ConcatClipsWithTitles(reelInputs, path, tags, a.reelChapterTitles(clips))
+					})
+				}
+				if err == nil && captionMode != "none" && !dryRun {
+					fyne.Do(func() {
+						statusLabel.SetText("Adding captions...")
+					})
+					var captions []ffmpeg.Caption
+					captions, err = a.reelCaptions(reelInputs, clips, transition)
+					if err == nil {
+						err = a.writeReelCaptions(ff, output, captions, captionMode)
+					}
+				}
+				if err == nil && !dryRun {
+					// Chapter timestamps for the upload, with each clip's note
+					err = a.writeReelDescription(output, reelInputs, clips, transition)
+				}
+				if err == nil && verticalMode != "off" && !dryRun {
+					fyne.Do(func() {
+						statusLabel.SetText("Exporting vertical reel...")
+					})
+					var pans []ffmpeg.VerticalPan
+					pans, err = ff.VerticalPans(reelInputs, a.reelPans(clips), transition)
+					if err == nil {
+						// Two-pass sizing is for the main reel; the vertical copy uses CRF 23
+						verticalCRF := crf
+						if targetSizeMB > 0 {
+							verticalCRF = "23"
+						}
+						err = ff.WriteOutput(verticalPath(output), func(path string) error {
+							return ff.ExportVertical(output, path, verticalCRF, forceCPU, pans, func(p float64, msg string) {
+								fyne.Do(func() {
+									statusLabel.SetText(msg)
+								})
+							})
+						})
+						if err == nil {
+							err = a.stampCaptureTime(verticalPath(output), captured)
+						}
+					}
+				}
+				if err == nil {
+					err = a.stampCaptureTime(output, captured)
+				}
+				return err
+			}
+
 			var err error
-			if useReencode {
-				err = a.ff.ConcatClipsWithEncode(toCombine, finalOutput, crf, forceCPU)
-			} else {
-				err = a.ff.ConcatClips(toCombine, finalOutput)
+			batch := a.newReport("combine")
+			for i, reel := range reels {
+				if len(reels) > 1 {
+					msg := fmt.Sprintf("Reel %d/%d: %s (%d clips)...", i+1, len(reels), filepath.Base(reel.output), len(reel.clips))
+					job.Update(float64(i)/float64(len(reels)), msg)
+					fyne.Do(func() {
+						statusLabel.SetText(msg)
+					})
+				}
+				err = combineReel(reel.clips, reel.output)
+				item := pipeline.ReportItem{Inputs: reel.clips, Output: reel.output}
+				if err != nil {
+					item.Error = err.Error()
+				}
+				batch.Items = append(batch.Items, item)
+				if err != nil {
+					break
+				}
+				if verticalMode != "off" {
+					batch.Items = append(batch.Items, pipeline.ReportItem{Inputs: []string{reel.output}, Output: verticalPath(reel.output)})
+				}
+			}
+			reportStatus := ""
+			if !dryRun {
+				if err != nil {
+					batch.Error = err.Error()
+				}
+				reportStatus = a.writeReport(filepath.Dir(reels[0].output), batch)
 			}
 
 			// Stop the timer
 			close(timerStop)
 			combineRunning = false
 			totalElapsed := time.Since(startTime)
+			reusedClips := 0
+			for _, stats := range rebuilt {
+				reusedClips += stats.Reused
+			}
+			// A rebuild that reused clips says nothing about the encode speed
+			if useReencode && !dryRun && err == nil && reusedClips == 0 {
+				a.recordEncode(forceCPU, footage, source, totalElapsed)
+			}
 
 			fyne.Do(func() {
 				progressBar.SetValue(1.0)
 				progressBar.Hide()
 				cancelBtn.Hide()
+				a.endCommands(cmdOpts, "Combine Clips")
+
+				if dryRun {
+					elapsedLabel.SetText("")
+					if err != nil {
+						statusLabel.SetText("Dry run failed: " + err.Error())
+					} else {
+						statusLabel.SetText(fmt.Sprintf("Dry run: %d clips checked, nothing was written", len(toCombine)))
+					}
+					return
+				}
 
 				if err != nil {
-					if a.ff.IsCancelled() {
+					if ff.IsCancelled() {
 						elapsedLabel.SetText(fmt.Sprintf("Cancelled after %s", formatDuration(totalElapsed.Seconds())))
 						statusLabel.SetText("Combine cancelled.")
-						os.Remove(finalOutput)
 					} else {
 						elapsedLabel.SetText("")
 						statusLabel.SetText("Error: " + err.Error())
 					}
 				} else {
 					// Get final file size
-					info, _ := os.Stat(finalOutput)
-					var sizeStr string
-					if info != nil {
+					fileSize := func(path string) string {
+						info, _ := os.Stat(path)
+						if info == nil {
+							return ""
+						}
 						sizeMB := float64(info.Size()) / (1024 * 1024)
 						if sizeMB > 1024 {
-							sizeStr = fmt.Sprintf("%.1f GB", sizeMB/1024)
-						} else {
-							sizeStr = fmt.Sprintf("%.0f MB", sizeMB)
+							return fmt.Sprintf("%.1f GB", sizeMB/1024)
 						}
+						return fmt.Sprintf("%.0f MB", sizeMB)
 					}
 
 					if useReencode {
@@ -365,17 +1010,59 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 					} else {
 						elapsedLabel.SetText("")
 					}
-					statusLabel.SetText(fmt.Sprintf("Done! Combined %d clips into:\n%s\nSize: %s", len(toCombine), finalOutput, sizeStr))
-					a.markStepComplete(3)
+					if len(reels) == 1 {
+						msg := fmt.Sprintf("Done! Combined %d clips into:\n%s\nSize: %s", len(reels[0].clips), reels[0].output, fileSize(reels[0].output))
+						if verticalMode != "off" {
+							vertical := verticalPath(reels[0].output)
+							msg += fmt.Sprintf("\nVertical: %s (%s)", vertical, fileSize(vertical))
+						}
+						statusLabel.SetText(msg)
+					} else {
+						msg := fmt.Sprintf("Done! Combined %d reels:", len(reels))
+						for _, reel := range reels {
+							msg += fmt.Sprintf("\n%s (%d clips, %s)", reel.output, len(reel.clips), fileSize(reel.output))
+						}
+						if verticalMode != "off" {
+							msg += "\nVertical copies saved next to each reel as *_vertical.mp4"
+						}
+						if len(unknownPeriod) > 0 {
+							msg += fmt.Sprintf("\n%d clips from an unknown period were left out of the period reels", len(unknownPeriod))
+						}
+						statusLabel.SetText(msg)
+					}
+					if reusedClips > 0 {
+						encodedClips := 0
+						for _, stats := range rebuilt {
+							encodedClips += stats.Encoded
+						}
+						statusLabel.SetText(statusLabel.Text + fmt.Sprintf("\nQuick rebuild: encoded %d changed clips, reused %d", encodedClips, reusedClips))
+					}
+					if reportStatus != "" {
+						statusLabel.SetText(statusLabel.Text + "\n" + reportStatus)
+					}
+					a.setReelPath(reels[0].output)
+					a.markStepComplete(stepCombine)
+					var uploads []string
+					for _, reel := range reels {
+						a.runHook(a.cfg.HookAfterCombine, "combine", reel.output)
+						uploads = append(uploads, reel.output)
+						if verticalMode != "off" {
+							uploads = append(uploads, verticalPath(reel.output))
+						}
+					}
+					a.autoUpload(a.cfg.UploadAfterCombine, uploads...)
 				}
 			})
-		}()
+			return err
+		})
+		a.showQueued(combineJob, statusLabel)
 	})
 
 	// Initial refresh
 	refreshClips()
 
 	// Layout
+	a.setAdvancedControls(stepCombine, selectInputBtn)
 	inputRow := container.NewHBox(
 		widget.NewLabel("Input:"),
 		inputFolderLabel,
@@ -389,12 +1076,72 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		selectOutputBtn,
 	)
 
+	watermarkBtn := widget.NewButton("Watermark...", func() {
+		a.showWatermarkSettings()
+	})
+	tagsBtn := widget.NewButton("Metadata Tags...", func() {
+		a.showTagSettings()
+	})
+	scoreboardBtn := widget.NewButton("Scoreboard...", func() {
+		a.showScoreboardSettings()
+	})
+
+	bumperRow := container.NewVBox(
+		container.NewHBox(widget.NewLabel("Intro:"), introLabel, selectIntroBtn, clearIntroBtn),
+		container.NewHBox(widget.NewLabel("Outro:"), outroLabel, selectOutroBtn, clearOutroBtn),
+		container.NewHBox(widget.NewLabel("Montage:"), widget.NewButton("Intro Montage...", a.showMontageSettings)),
+		container.NewHBox(widget.NewLabel("  Image bumper duration (s):"), stillDurationEntry),
+		container.NewHBox(widget.NewLabel("Captions:"), captionSelect),
+		container.NewHBox(widget.NewLabel("Reels:"), periodReelSelect),
+		container.NewHBox(widget.NewLabel("Vertical:"), verticalSelect, panBtn),
+	)
+
 	encodingRow := container.NewVBox(
-		reencodeCheck,
+		container.NewHBox(reencodeCheck, watermarkBtn, tagsBtn, scoreboardBtn),
 		container.NewHBox(widget.NewLabel("  Quality:"), qualitySelect),
+		targetSizeRow,
+		container.NewHBox(widget.NewLabel("  Conform to:"), conformResSelect, widget.NewLabel("fps:"), conformFpsSelect),
+		container.NewHBox(widget.NewLabel("  Transitions:"), transitionSelect, widget.NewLabel("seconds:"), transitionSecondsEntry),
+		container.NewHBox(widget.NewLabel("  "), counterCheck, widget.NewLabel("position:"), counterCornerSelect),
+		container.NewHBox(widget.NewLabel("  "), segmentsCheck),
+		cmdOpts.row(),
 	)
 
-	selectionBtns := container.NewHBox(selectAllBtn, deselectAllBtn)
+	uploadBtn := widget.NewButton("Upload Selected...", func() {
+		var clips []string
+		for clip, selected := range selectedClips {
+			if selected {
+				clips = append(clips, clip)
+			}
+		}
+		if len(clips) == 0 {
+			a.showError("No Clips Selected", "Please select at least one clip to upload")
+			return
+		}
+		clips = a.orderClips(clips)
+		var size int64
+		for _, clip := range clips {
+			if info, err := os.Stat(clip); err == nil {
+				size += info.Size()
+			}
+		}
+		dialog.ShowConfirm("Upload Clips",
+			fmt.Sprintf("Upload %d clips (%.0f MB) to %s?", len(clips), float64(size)/(1024*1024), cloud.ProviderName(a.cfg.Cloud.Provider)),
+			func(ok bool) {
+				if ok {
+					a.startUpload(clips)
+				}
+			}, a.window)
+	})
+
+	selectionBtns := container.NewHBox(selectAllBtn, deselectAllBtn, widget.NewLabel("Order:"), clipOrderSelect, uploadBtn)
+
+	a.actions.combine = tapAction(combineBtn)
+	a.actions.reloadClips = useStep2Btn.OnTapped
+	a.actions.recutReel = func() {
+		useStep2Btn.OnTapped()
+		combineBtn.OnTapped()
+	}
 
 	scroll := container.NewScroll(clipsContainer)
 	scroll.SetMinSize(fyne.NewSize(0, 250))
@@ -404,11 +1151,13 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		widget.NewSeparator(),
 		inputRow,
 		outputRow,
+		bumperRow,
 		encodingRow,
 		widget.NewSeparator(),
 		widget.NewLabel("Select clips to combine (in order):"),
 		selectionBtns,
 		scroll,
+		totalsLabel,
 		widget.NewSeparator(),
 		container.NewHBox(combineBtn, cancelBtn),
 		progressBar,
@@ -416,3 +1165,80 @@ func (a *App) createStep4Combine() fyne.CanvasObject {
 		statusLabel,
 	)
 }
+
+// addBumpers conforms the intro/outro bumpers to match the first clip (codec,
+// resolution, fps, audio), makes the intro montage (nil = none) in the same
+// format, and returns the full list of reel inputs: montage, intro, clips, outro.
+// The returned cleanup func removes the temporary conformed bumper files.
+func (a *App) addBumpers(ff *ffmpeg.FFmpeg, clips []string, introPath, outroPath string, stillDuration float64, montage *ffmpeg.Montage) ([]string, func(), error) {
+	noop := func() {}
+	if introPath == "" && outroPath == "" && montage == nil {
+		return clips, noop, nil
+	}
+
+	ref, err := ff.GetStreamInfo(clips[0])
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to read clip format: %w", err)
+	}
+
+	bumperDir, err := os.MkdirTemp(ff.TempDir(), "gopro-bumpers-*")
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to create temp folder: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(bumperDir) }
+
+	// Named "Intro"/"Outro" so the reel's chapter titles read nicely
+	ext := filepath.Ext(clips[0])
+	inputs := append([]string{}, clips...)
+
+	if introPath != "" {
+		intro := filepath.Join(bumperDir, "Intro"+ext)
+		if err := ff.ConformBumper(introPath, intro, ref, stillDuration); err != nil {
+			cleanup()
+			return nil, noop, err
+		}
+		inputs = append([]string{intro}, inputs...)
+	}
+
+	if montage != nil {
+		path := filepath.Join(bumperDir, "Montage"+ext)
+		if err := ff.CreateMontage(*montage, path, ref); err != nil {
+			cleanup()
+			return nil, noop, err
+		}
+		inputs = append([]string{path}, inputs...)
+	}
+
+	if outroPath != "" {
+		outro := filepath.Join(bumperDir, "Outro"+ext)
+		if err := ff.ConformBumper(outroPath, outro, ref, stillDuration); err != nil {
+			cleanup()
+			return nil, noop, err
+		}
+		inputs = append(inputs, outro)
+	}
+
+	return inputs, cleanup, nil
+}
+
+// resolveConform turns the Step 4 conform selections into an ffmpeg.Conform.
+// "Auto" values are taken from the most common format among the selected clips.
+func resolveConform(ff *ffmpeg.FFmpeg, clips []string, resolution, fps string) ffmpeg.Conform {
+	conform := ffmpeg.DefaultConform
+	if strings.HasPrefix(resolution, "Auto") || strings.HasPrefix(fps, "Auto") {
+		conform = ff.MajorityConform(clips)
+	}
+
+	if !strings.HasPrefix(resolution, "Auto") {
+		var w, h int
+		if n, _ := fmt.Sscanf(resolution, "%dx%d", &w, &h); n == 2 {
+			conform.Width = w
+			conform.Height = h
+		}
+	}
+	if !strings.HasPrefix(fps, "Auto") {
+		conform.FrameRate = fps
+	}
+
+	return conform
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,13 +13,18 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/jobs"
 )
 
 // createStep5Export creates the full game export UI
 func (a *App) createStep5Export() fyne.CanvasObject {
 	// MOV files list
 	var movFiles []string
-	var totalSourceDuration float64
+	var movDurations map[string]float64 // Full lengths of the MOVs, by path
+	var totalSourceDuration float64     // Length of the export once trimmed
 	movListLabel := widget.NewLabel("No MOV files detected")
 	movListLabel.Wrapping = fyne.TextWrapWord
 
@@ -36,9 +42,13 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 	// Timer control
 	var timerStop chan bool
 	var exportRunning bool
+	var exportJob *jobs.Job
 
 	// Cancel button
 	cancelBtn := widget.NewButton("Cancel", nil)
+
+	// Show command / dry run toggles
+	cmdOpts := newCommandOptions()
 	cancelBtn.Hide()
 
 	// Quality preset
@@ -47,18 +57,44 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 		"Balanced (CRF 20) - ~8 Mbps",
 		"Smaller File (CRF 23) - ~5 Mbps",
 		"Smallest (CPU, CRF 23) - ~5 Mbps, slower",
+		"Target File Size (2-pass) - fits upload cap",
 	}, nil)
+
+	// Target size (only used by the "Target File Size" preset)
+	targetSizeEntry := widget.NewEntry()
+	targetSizeEntry.SetText(fmt.Sprintf("%.0f", a.cfg.TargetSizeMB))
+	targetSizeRow := container.NewHBox(widget.NewLabel("Max size (MB):"), targetSizeEntry)
+	targetSizeRow.Hide()
+
+	qualitySelect.OnChanged = func(selected string) {
+		if selected == "Target File Size (2-pass) - fits upload cap" {
+			targetSizeRow.Show()
+		} else {
+			targetSizeRow.Hide()
+		}
+	}
 	qualitySelect.SetSelected("Balanced (CRF 20) - ~8 Mbps")
 
+	// The folder the MOVs are exported from: Step 1's working folder, or one
+	// picked by hand in Advanced mode, which leaves Step 1's alone
+	var pickedFolder string
+	movFolder := func() string {
+		if pickedFolder != "" && a.cfg.AdvancedMode {
+			return pickedFolder
+		}
+		return a.workingFolder
+	}
+
 	// Refresh MOV files from working folder
 	refreshMOVs := func() {
 		movFiles = nil
-		if a.workingFolder == "" {
+		folder := movFolder()
+		if folder == "" {
 			movListLabel.SetText("No working folder set. Complete Step 1 first.")
 			return
 		}
 
-		entries, err := os.ReadDir(a.workingFolder)
+		entries, err := os.ReadDir(folder)
 		if err != nil {
 			movListLabel.SetText("Error reading folder: " + err.Error())
 			return
@@ -70,7 +106,7 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 			}
 			ext := strings.ToLower(filepath.Ext(entry.Name()))
 			if ext == ".mov" {
-				movFiles = append(movFiles, filepath.Join(a.workingFolder, entry.Name()))
+				movFiles = append(movFiles, filepath.Join(folder, entry.Name()))
 			}
 		}
 
@@ -83,11 +119,17 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 
 		// Build display text with durations
 		var lines []string
+		movDurations = make(map[string]float64, len(movFiles))
 		totalSourceDuration = 0
 		for _, f := range movFiles {
 			dur, _ := a.ff.GetDuration(f)
-			totalSourceDuration += dur
+			movDurations[f] = dur
+			trim := a.exportTrim(f)
+			totalSourceDuration += trim.Length(dur)
 			durStr := formatDuration(dur)
+			if !trim.IsZero() {
+				durStr = fmt.Sprintf("%s, trimmed to %s-%s", durStr, formatDuration(trim.In), formatDuration(trim.End(dur)))
+			}
 			lines = append(lines, fmt.Sprintf("  %s (%s)", filepath.Base(f), durStr))
 		}
 		lines = append(lines, fmt.Sprintf("\nTotal: %s", formatDuration(totalSourceDuration)))
@@ -107,11 +149,20 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
 				path = path[1:]
 			}
-			a.workingFolder = path
+			pickedFolder = path
+			a.useWorkDir(path)
 			refreshMOVs()
 		}, a.window)
 	})
 
+	trimBtn := widget.NewButton("Trim Periods...", func() {
+		if len(movFiles) == 0 {
+			a.showError("No Files", "No MOV files to trim. Click Refresh or select a folder.")
+			return
+		}
+		a.showExportTrims(movFiles, movDurations, refreshMOVs)
+	})
+
 	selectOutputBtn := widget.NewButton("Select Output File", func() {
 		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
 			if err != nil || writer == nil {
@@ -133,9 +184,19 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 
 	// Cancel button handler
 	cancelBtn.OnTapped = func() {
-		if exportRunning {
-			statusLabel.SetText("Cancelling export...")
-			a.ff.CancelExport()
+		if !exportRunning || exportJob == nil {
+			return
+		}
+		queued := exportJob.Snapshot().State == jobs.Pending
+		statusLabel.SetText("Cancelling export...")
+		a.jobs.Cancel(exportJob.ID) // Stops the running ffmpeg process
+
+		// A queued export never starts, so reset the UI here
+		if queued {
+			exportRunning = false
+			progressBar.Hide()
+			cancelBtn.Hide()
+			statusLabel.SetText("Export cancelled.")
 		}
 	}
 
@@ -153,12 +214,21 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 		finalOutput := outputFile
 		if finalOutput == "" {
 			timestamp := time.Now().Format("2006-01-02")
-			finalOutput = filepath.Join(a.workingFolder, fmt.Sprintf("FullGame_%s.mp4", timestamp))
+			outputDir := movFolder()
+			if reelFolder := a.reelOutputFolder(); reelFolder != "" {
+				if err := os.MkdirAll(reelFolder, 0755); err != nil {
+					a.showError("Output Folder", fmt.Sprintf("Could not create %s: %v", reelFolder, err))
+					return
+				}
+				outputDir = reelFolder
+			}
+			finalOutput = filepath.Join(outputDir, fmt.Sprintf("FullGame_%s.mp4", timestamp))
 		}
 
 		// Parse quality setting
 		crf := "20" // default balanced
 		forceCPU := false
+		var targetSizeMB float64
 		encoderName := "GPU (NVENC)"
 		switch qualitySelect.Selected {
 		case "High Quality (CRF 18) - ~12 Mbps":
@@ -171,11 +241,25 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 			crf = "23"
 			forceCPU = true
 			encoderName = "CPU (libx264)"
+		case "Target File Size (2-pass) - fits upload cap":
+			size, err := strconv.ParseFloat(targetSizeEntry.Text, 64)
+			if err != nil || size <= 0 {
+				a.showError("Invalid Size", "Please enter a maximum file size in MB")
+				return
+			}
+			targetSizeMB = size
+			a.cfg.TargetSizeMB = size
+		}
+
+		trims := make(map[string]ffmpeg.Trim, len(movFiles))
+		for _, f := range movFiles {
+			if trim := a.exportTrim(f); !trim.IsZero() {
+				trims[f] = trim
+			}
 		}
 
-		// Reset cancel state
-		a.ff.ResetCancel()
 		exportRunning = true
+		dryRun := cmdOpts.dryRun()
 
 		// Show UI elements
 		progressBar.Show()
@@ -185,41 +269,56 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 
 		// Build descriptive status
 		durationStr := formatDuration(totalSourceDuration)
-		statusLabel.SetText(fmt.Sprintf("Encoding %s video using %s (CRF %s)...", durationStr, encoderName, crf))
+		var startMsg string
+		if targetSizeMB > 0 {
+			startMsg = fmt.Sprintf("Encoding %s video to fit %.0f MB...", durationStr, targetSizeMB)
+		} else {
+			startMsg = fmt.Sprintf("Encoding %s video using %s (CRF %s)...", durationStr, encoderName, crf)
+		}
+		statusLabel.SetText(startMsg)
 
-		// Start elapsed time timer
-		startTime := time.Now()
-		timerStop = make(chan bool, 1)
+		exportJob = a.runJob("export", "Export full game "+filepath.Base(finalOutput), func(job *jobs.Job, ff *ffmpeg.FFmpeg) error {
+			a.beginCommands(cmdOpts)
+			job.Update(0, startMsg)
+			fyne.Do(func() {
+				statusLabel.SetText(startMsg)
+			})
 
-		go func() {
-			ticker := time.NewTicker(1 * time.Second)
-			defer ticker.Stop()
+			// Start elapsed time timer
+			startTime := time.Now()
+			timerStop = make(chan bool, 1)
 
-			for {
-				select {
-				case <-ticker.C:
-					elapsed := time.Since(startTime)
-					fyne.Do(func() {
-						elapsedLabel.SetText(fmt.Sprintf("Elapsed: %s", formatDuration(elapsed.Seconds())))
-					})
-				case <-timerStop:
-					return
-				}
-			}
-		}()
+			go func() {
+				ticker := time.NewTicker(1 * time.Second)
+				defer ticker.Stop()
 
-		go func() {
-			// Export with chapter preservation
-			err := a.ff.ExportFullGame(movFiles, finalOutput, crf, forceCPU, func(progress float64, status string) {
-				fyne.Do(func() {
-					progressBar.SetValue(progress)
-					// Keep showing elapsed time in status
-					if progress < 1.0 && progress > 0.1 {
+				for {
+					select {
+					case <-ticker.C:
 						elapsed := time.Since(startTime)
-						statusLabel.SetText(fmt.Sprintf("%s (Elapsed: %s)", status, formatDuration(elapsed.Seconds())))
-					} else {
-						statusLabel.SetText(status)
+						fyne.Do(func() {
+							elapsedLabel.SetText(fmt.Sprintf("Elapsed: %s", formatDuration(elapsed.Seconds())))
+						})
+					case <-timerStop:
+						return
 					}
+				}
+			}()
+
+			// Export with chapter preservation
+			err := ff.WriteOutput(finalOutput, func(path string) error {
+				return ff.ExportFullGameTrimmed(movFiles, trims, path, crf, forceCPU, targetSizeMB, func(progress float64, status string) {
+					job.Update(progress, status)
+					fyne.Do(func() {
+						progressBar.SetValue(progress)
+						// Keep showing elapsed time in status
+						if progress < 1.0 && progress > 0.1 {
+							elapsed := time.Since(startTime)
+							statusLabel.SetText(fmt.Sprintf("%s (Elapsed: %s)", status, formatDuration(elapsed.Seconds())))
+						} else {
+							statusLabel.SetText(status)
+						}
+					})
 				})
 			})
 
@@ -231,13 +330,22 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 			fyne.Do(func() {
 				progressBar.Hide()
 				cancelBtn.Hide()
+				a.endCommands(cmdOpts, "Export Full Game")
+
+				if dryRun {
+					elapsedLabel.SetText("")
+					if err != nil {
+						statusLabel.SetText("Dry run failed: " + err.Error())
+					} else {
+						statusLabel.SetText(fmt.Sprintf("Dry run: %d files checked, nothing was written", len(movFiles)))
+					}
+					return
+				}
 
 				if err != nil {
-					if a.ff.IsCancelled() {
+					if ff.IsCancelled() {
 						elapsedLabel.SetText(fmt.Sprintf("Cancelled after %s", formatDuration(totalElapsed.Seconds())))
 						statusLabel.SetText("Export cancelled.")
-						// Clean up partial output file
-						os.Remove(finalOutput)
 					} else {
 						elapsedLabel.SetText(fmt.Sprintf("Failed after %s", formatDuration(totalElapsed.Seconds())))
 						statusLabel.SetText("Error: " + err.Error())
@@ -256,10 +364,14 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 					}
 					elapsedLabel.SetText(fmt.Sprintf("Completed in %s", formatDuration(totalElapsed.Seconds())))
 					statusLabel.SetText(fmt.Sprintf("Done! Exported to:\n%s\nSize: %s", finalOutput, sizeStr))
-					a.markStepComplete(4)
+					a.markStepComplete(stepExport)
+					a.runHook(a.cfg.HookAfterExport, "export", finalOutput)
+					a.autoUpload(a.cfg.UploadAfterExport, finalOutput)
 				}
 			})
-		}()
+			return err
+		})
+		a.showQueued(exportJob, statusLabel)
 	})
 
 	// Initial refresh
@@ -278,9 +390,10 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 		widget.NewSeparator(),
 	)
 
+	a.setAdvancedControls(stepExport, selectFolderBtn)
 	filesSection := container.NewVBox(
 		widget.NewLabel("Source MOV Files:"),
-		container.NewHBox(refreshBtn, selectFolderBtn),
+		container.NewHBox(refreshBtn, selectFolderBtn, trimBtn),
 		movListLabel,
 		widget.NewSeparator(),
 	)
@@ -295,6 +408,8 @@ func (a *App) createStep5Export() fyne.CanvasObject {
 			widget.NewLabel("Quality:"),
 			qualitySelect,
 		),
+		targetSizeRow,
+		cmdOpts.row(),
 		widget.NewSeparator(),
 	)
 
@@ -0,0 +1,384 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/config"
+)
+
+// combinedSplitPattern matches the files Step 1 combines split GoPro videos
+// into ("GX_combined_0092.MP4"): prefix, video ID and extension
+var combinedSplitPattern = regexp.MustCompile(`^(GX|GH)_combined_(\d{4})\.(MP4|MOV)$`)
+
+// storageCategory is one kind of file a project leaves on disk, listed in the
+// Storage tab
+type storageCategory struct {
+	name      string
+	note      string // What deleting the files means
+	files     []string
+	size      int64
+	protected bool // Source files, which are never deleted
+	keep      bool // Finished outputs, unticked by default
+}
+
+// storageInventory lists the files a project has on disk by category: the
+// source videos (protected), the intermediate files made from them, the clips
+// and the finished reels. Files that can't be made again from the sources,
+// such as a combined split file whose parts are gone, count as sources.
+// Each file is listed once, in the first category it is found for.
+func storageInventory(session *config.Session) []*storageCategory {
+	listed := make(map[string]bool)
+	add := func(c *storageCategory, path string) {
+		size := fileSize(path)
+		if size < 0 || listed[filepath.Clean(path)] {
+			return
+		}
+		listed[filepath.Clean(path)] = true
+		c.files = append(c.files, path)
+		c.size += size
+	}
+
+	sources := &storageCategory{name: "Source files", protected: true,
+		note: "Camera videos, and files that can't be made again from them. Never deleted here."}
+	combined := &storageCategory{name: "Combined split files",
+		note: "Made from the split GoPro files in Step 1. Combine them again there before re-extracting clips."}
+	meta := &storageCategory{name: "Metadata files",
+		note: "Chapters read from the GoPro MP4s. Extracted again when the folder is next scanned."}
+	clips := &storageCategory{name: "Draft clips",
+		note: "Steps 3 and 4 work from these. Extract them again in Step 2 or with Re-cut."}
+	segments := &storageCategory{name: "Reel segments",
+		note: "Each clip's encode, kept so a re-encoded reel rebuilds only the clips that changed. The next rebuild encodes every clip."}
+	reels := &storageCategory{name: "Reels", keep: true,
+		note: "Highlight reels with their period, vertical, caption and description files."}
+	exports := &storageCategory{name: "Full game exports", keep: true,
+		note: "Step 5 exports."}
+	projects := &storageCategory{name: "Project files", keep: true,
+		note: "Saved projects, needed to re-cut the game without analyzing it again."}
+	partials := &storageCategory{name: "Unfinished writes",
+		note: "Left by writes that were cut off. Safe to delete."}
+
+	folders := []string{session.WorkingFolder}
+	if len(session.ExtractedClips) > 0 {
+		folders = append(folders, filepath.Dir(session.ExtractedClips[0]))
+	}
+	if session.ReelPath != "" {
+		folders = append(folders, filepath.Dir(session.ReelPath))
+	}
+
+	for _, clip := range session.ExtractedClips {
+		add(clips, clip)
+	}
+
+	if session.ReelPath != "" {
+		reelFiles := []string{session.ReelPath}
+		if matches, err := filepath.Glob(filepath.Join(filepath.Dir(session.ReelPath), "Reel_P*.mp4")); err == nil {
+			reelFiles = append(reelFiles, matches...)
+		}
+		for _, reel := range reelFiles {
+			if ffmpeg.IsPartial(reel) || strings.HasSuffix(reel, "_vertical.mp4") {
+				continue
+			}
+			add(reels, reel)
+			add(reels, verticalPath(reel))
+			add(reels, strings.TrimSuffix(reel, filepath.Ext(reel))+".srt")
+			add(reels, descriptionPath(reel))
+			if files, err := filepath.Glob(filepath.Join(ffmpeg.SegmentFolder(reel), "*")); err == nil {
+				for _, file := range files {
+					add(segments, file)
+				}
+			}
+		}
+	}
+
+	// The working folder: camera videos and what Step 1 made from them
+	if entries, err := os.ReadDir(session.WorkingFolder); err == nil {
+		names := make(map[string]bool)
+		for _, entry := range entries {
+			names[strings.ToLower(entry.Name())] = true
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			name := entry.Name()
+			path := filepath.Join(session.WorkingFolder, name)
+			ext := strings.ToLower(filepath.Ext(name))
+			baseName := strings.TrimSuffix(name, filepath.Ext(name))
+			switch {
+			case ffmpeg.IsPartial(name):
+				add(partials, path)
+			case combinedSplitPattern.MatchString(name):
+				m := combinedSplitPattern.FindStringSubmatch(name)
+				firstPart := strings.ToLower(m[1] + "01" + m[2] + "." + m[3])
+				if names[firstPart] {
+					add(combined, path)
+				} else {
+					add(sources, path)
+				}
+			case ext == ".txt" && strings.HasSuffix(baseName, "_metadata"):
+				goproName := strings.ToLower(strings.TrimSuffix(baseName, "_metadata"))
+				if names[goproName+".mp4"] || names[goproName+ffmpeg.Max360Ext] {
+					add(meta, path)
+				} else {
+					add(sources, path)
+				}
+			case ext == ".mov" || ext == ".mp4" || ext == ffmpeg.Max360Ext:
+				if !strings.HasPrefix(name, "FullGame_") {
+					add(sources, path)
+				}
+			}
+		}
+	}
+	for _, p := range session.Periods {
+		add(sources, p.VideoFile)
+		if p.SourceGoPro != p.VideoFile {
+			add(sources, p.SourceGoPro)
+		}
+	}
+
+	// Exports, projects and unfinished writes anywhere the project writes to
+	for _, folder := range folders {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			name := entry.Name()
+			path := filepath.Join(folder, name)
+			switch {
+			case ffmpeg.IsPartial(name):
+				add(partials, path)
+			case strings.HasPrefix(name, "FullGame_") && strings.EqualFold(filepath.Ext(name), ".mp4"):
+				add(exports, path)
+			case strings.HasSuffix(name, "_project.json"):
+				add(projects, path)
+			}
+		}
+	}
+
+	return []*storageCategory{sources, combined, meta, clips, segments, reels, exports, projects, partials}
+}
+
+// deleteStorage deletes the files of the chosen categories and returns the
+// paths removed. Protected categories are skipped.
+func deleteStorage(categories []*storageCategory) ([]string, error) {
+	var removed []string
+	for _, c := range categories {
+		if c.protected {
+			continue
+		}
+		for _, path := range c.files {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return removed, fmt.Errorf("failed to delete %s: %w", filepath.Base(path), err)
+			}
+			removed = append(removed, path)
+		}
+	}
+	return removed, nil
+}
+
+// forgetDeleted drops deleted clips and reels from the current project, so
+// the steps that used them show they have to be done again
+func (a *App) forgetDeleted(removed []string) {
+	deleted := make(map[string]bool, len(removed))
+	for _, path := range removed {
+		deleted[filepath.Clean(path)] = true
+	}
+
+	a.sessionMu.Lock()
+	var clips []string
+	for _, clip := range a.extractedClips {
+		if !deleted[filepath.Clean(clip)] {
+			clips = append(clips, clip)
+		}
+	}
+	clipsDeleted := len(clips) < len(a.extractedClips)
+	a.extractedClips = clips
+	reelDeleted := a.reelPath != "" && deleted[filepath.Clean(a.reelPath)]
+	if reelDeleted {
+		a.reelPath = ""
+	}
+	a.sessionMu.Unlock()
+	a.saveSession()
+
+	if clipsDeleted {
+		a.markStepIncomplete(stepExtract)
+		a.markStale(stepEdit, "clips were deleted in the Storage tab")
+	}
+	if reelDeleted {
+		a.markStepIncomplete(stepCombine)
+	}
+}
+
+// createStorageTab lists the files of a project (the current one when
+// session is nil) by category with their sizes, and deletes the categories
+// ticked. It is rebuilt each time the tab is selected.
+func (a *App) createStorageTab(session *config.Session, projectPath string) fyne.CanvasObject {
+	current := session == nil
+	if current {
+		a.sessionMu.Lock()
+		session = a.sessionSnapshot()
+		a.sessionMu.Unlock()
+	}
+
+	openProjectBtn := widget.NewButton("Other Project...", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			project, err := config.LoadProject(path)
+			if err != nil {
+				a.showError("Open Project", err.Error())
+				return
+			}
+			a.refreshStorageTab(project, path)
+		}, a.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fileDialog.Show()
+	})
+	currentBtn := widget.NewButton("Current Project", func() {
+		a.refreshStorageTab(nil, "")
+	})
+	refresh := func() {
+		if current {
+			a.refreshStorageTab(nil, "")
+		} else {
+			a.refreshStorageTab(session, projectPath)
+		}
+	}
+	refreshBtn := widget.NewButton("Refresh", refresh)
+	if current {
+		currentBtn.Disable()
+	}
+	// Moves the GoPro MP4s off the SD card and working drive once the game is done with them
+	archiveBtn := widget.NewButton("Archive Originals...", func() {
+		a.archiveOriginals(session, refresh)
+	})
+	if session == nil {
+		archiveBtn.Disable()
+	}
+
+	header := container.NewVBox(
+		widget.NewLabel("Storage"),
+		widget.NewSeparator(),
+		container.NewHBox(currentBtn, openProjectBtn, refreshBtn, archiveBtn),
+	)
+
+	if session == nil {
+		body := widget.NewLabel("Nothing to manage yet. Analyze a folder in Step 1 or open a project.")
+		return container.NewBorder(header, nil, nil, nil, body)
+	}
+
+	project := "Current project: " + session.WorkingFolder
+	if !current {
+		project = "Project: " + projectPath
+	}
+
+	categories := storageInventory(session)
+	checks := make(map[*storageCategory]*widget.Check)
+	selectedLabel := widget.NewLabel("")
+	deleteBtn := widget.NewButton("Delete Selected...", nil)
+	deleteBtn.Importance = widget.DangerImportance
+
+	chosen := func() []*storageCategory {
+		var list []*storageCategory
+		for _, c := range categories {
+			if check := checks[c]; check != nil && check.Checked {
+				list = append(list, c)
+			}
+		}
+		return list
+	}
+	updateSelected := func() {
+		var files int
+		var size int64
+		for _, c := range chosen() {
+			files += len(c.files)
+			size += c.size
+		}
+		selectedLabel.SetText(fmt.Sprintf("Selected: %d files, %s", files, formatSize(float64(size))))
+		if files == 0 {
+			deleteBtn.Disable()
+		} else {
+			deleteBtn.Enable()
+		}
+	}
+
+	body := container.NewVBox(widget.NewLabel(project))
+	var total int64
+	for _, c := range categories {
+		total += c.size
+		title := fmt.Sprintf("%s - %d files, %s", c.name, len(c.files), formatSize(float64(c.size)))
+		var row fyne.CanvasObject
+		if c.protected || len(c.files) == 0 {
+			row = widget.NewLabel("      " + title)
+		} else {
+			check := widget.NewCheck(title, func(bool) { updateSelected() })
+			check.SetChecked(!c.keep)
+			checks[c] = check
+			row = check
+		}
+		note := widget.NewLabel("      " + c.note)
+		note.Wrapping = fyne.TextWrapWord
+		body.Add(container.NewVBox(row, note))
+	}
+	body.Add(widget.NewSeparator())
+	body.Add(widget.NewLabel("Total on disk: " + formatSize(float64(total))))
+	updateSelected()
+
+	deleteBtn.OnTapped = func() {
+		list := chosen()
+		var names []string
+		for _, c := range list {
+			names = append(names, fmt.Sprintf("%s (%d files, %s)", c.name, len(c.files), formatSize(float64(c.size))))
+		}
+		message := "Delete these files? This can't be undone.\n\n- " + strings.Join(names, "\n- ")
+		dialog.ShowConfirm("Delete Files", message, func(ok bool) {
+			if !ok {
+				return
+			}
+			removed, err := deleteStorage(list)
+			if current {
+				a.forgetDeleted(removed)
+			}
+			if err != nil {
+				a.showError("Delete Failed", err.Error())
+			} else {
+				a.showInfo("Files Deleted", fmt.Sprintf("Deleted %d files.", len(removed)))
+			}
+			refresh()
+		}, a.window)
+	}
+
+	footer := container.NewHBox(deleteBtn, selectedLabel)
+	return container.NewBorder(header, footer, nil, nil, container.NewVScroll(body))
+}
+
+// refreshStorageTab rebuilds the Storage tab for a project (nil = the current one)
+func (a *App) refreshStorageTab(session *config.Session, projectPath string) {
+	if a.storageTab == nil {
+		return
+	}
+	a.storageTab.Content = a.createStorageTab(session, projectPath)
+	a.tabs.Refresh()
+}
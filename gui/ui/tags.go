@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// currentGameName returns the game name set for this session, or the default
+// if none was set (see defaultGameName)
+func (a *App) currentGameName() string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	if a.gameInfo.Name != "" {
+		return a.gameInfo.Name
+	}
+	return a.defaultGameName()
+}
+
+// defaultGameName returns the game name used when none is set: the teams
+// (e.g. "Hawks vs Wolves") once the opponent is entered, otherwise the working
+// folder's name, numbered when the folder holds several games. a.sessionMu
+// must be held.
+func (a *App) defaultGameName() string {
+	info := a.gameInfo
+	info.Team = a.teamName()
+	if matchup := info.Matchup(); matchup != "" {
+		return matchup
+	}
+	if a.workingFolder == "" {
+		return ""
+	}
+	if a.game > 0 {
+		return fmt.Sprintf("%s Game %d", filepath.Base(a.workingFolder), a.game)
+	}
+	return filepath.Base(a.workingFolder)
+}
+
+// setGameName sets the session's game name and saves the session
+func (a *App) setGameName(name string) {
+	a.sessionMu.Lock()
+	a.gameInfo.Name = strings.TrimSpace(name)
+	a.sessionMu.Unlock()
+
+	a.saveSession()
+}
+
+// gameTemplateValues returns the template values shared by every clip of the
+// game, from its details (see gameDetails)
+func (a *App) gameTemplateValues() metadata.TemplateValues {
+	info := a.gameDetails()
+	return metadata.TemplateValues{
+		Game:     info.Name,
+		Team:     info.Team,
+		Opponent: info.Opponent,
+		Date:     info.Day(),
+		Location: info.Location,
+		Score:    info.FinalScore,
+	}
+}
+
+// groupTemplateValues returns the template values for a clip group, with the
+// note typed on its clip in Step 3. The date is the highlight's own unless a
+// game date was entered.
+func (a *App) groupTemplateValues(group metadata.ClipGroup) metadata.TemplateValues {
+	values := metadata.GroupTemplateValues(group)
+	values.Note = a.clipNote(group.PrimaryChapter)
+	game := a.gameTemplateValues()
+	values.Game = game.Game
+	values.Team = game.Team
+	values.Opponent = game.Opponent
+	values.Location = game.Location
+	values.Score = game.Score
+
+	a.sessionMu.Lock()
+	dateEntered := a.gameInfo.Date != ""
+	a.sessionMu.Unlock()
+	if dateEntered || values.Date.IsZero() {
+		values.Date = game.Date
+	}
+	return values
+}
+
+// buildTags expands the configured tag templates. titleTemplate is the clip
+// or reel title template; a clip's note goes at the end of its title unless
+// the template places it.
+func (a *App) buildTags(titleTemplate string, values metadata.TemplateValues) ffmpeg.Tags {
+	title := metadata.AppendNote(metadata.ExpandTemplate(titleTemplate, values), titleTemplate, values.Note)
+	tags := ffmpeg.Tags{
+		"title":   title,
+		"artist":  metadata.ExpandTemplate(a.cfg.ArtistTemplate, values),
+		"comment": metadata.ExpandTemplate(a.cfg.CommentTemplate, values),
+	}
+	if !values.Date.IsZero() {
+		tags["date"] = values.Date.Format("2006-01-02")
+	}
+	return tags
+}
+
+// clipTags returns the metadata tags for a clip extracted from group
+func (a *App) clipTags(group metadata.ClipGroup) ffmpeg.Tags {
+	return a.buildTags(a.cfg.ClipTitleTemplate, a.groupTemplateValues(group))
+}
+
+// reelTags returns the metadata tags for a combined reel
+func (a *App) reelTags() ffmpeg.Tags {
+	return a.buildTags(a.cfg.ReelTitleTemplate, a.gameTemplateValues())
+}
+
+// showTagSettings shows a dialog for the game and team names and the
+// templates of the metadata tags written into clips and reels
+func (a *App) showTagSettings() {
+	gameEntry := widget.NewEntry()
+	a.sessionMu.Lock()
+	gameEntry.SetText(a.gameInfo.Name)
+	if name := a.defaultGameName(); name != "" {
+		gameEntry.SetPlaceHolder(name)
+	}
+	a.sessionMu.Unlock()
+
+	teamEntry := widget.NewEntry()
+	teamEntry.SetText(a.cfg.TeamName)
+
+	clipTitleEntry := widget.NewEntry()
+	clipTitleEntry.SetText(a.cfg.ClipTitleTemplate)
+	reelTitleEntry := widget.NewEntry()
+	reelTitleEntry.SetText(a.cfg.ReelTitleTemplate)
+	artistEntry := widget.NewEntry()
+	artistEntry.SetText(a.cfg.ArtistTemplate)
+	commentEntry := widget.NewEntry()
+	commentEntry.SetText(a.cfg.CommentTemplate)
+	captionEntry := widget.NewEntry()
+	captionEntry.SetText(a.cfg.CaptionTemplate)
+
+	// Preview with the first highlight (or a sample if nothing is analyzed yet)
+	sample := metadata.ClipGroup{
+		Chapters:       []metadata.Chapter{{Number: 7, GlobalOrder: 12, Period: "2Period"}},
+		Period:         "2Period",
+		PrimaryChapter: metadata.Chapter{Number: 7, GlobalOrder: 12, Period: "2Period"},
+	}
+	if a.analysisResult != nil && len(a.analysisResult.Chapters) > 0 {
+		ch := a.analysisResult.Chapters[0]
+		sample = metadata.ClipGroup{Chapters: []metadata.Chapter{ch}, Period: ch.Period, PrimaryChapter: ch}
+	}
+
+	previewLabel := widget.NewLabel("")
+	updatePreview := func(string) {
+		reel := a.gameTemplateValues()
+		if game := strings.TrimSpace(gameEntry.Text); game != "" {
+			reel.Game = game
+		}
+		reel.Team = strings.TrimSpace(teamEntry.Text)
+		values := a.groupTemplateValues(sample)
+		values.Game, values.Team = reel.Game, reel.Team
+
+		previewLabel.SetText("Clip: " + metadata.ExpandTemplate(clipTitleEntry.Text, values) +
+			"\nReel: " + metadata.ExpandTemplate(reelTitleEntry.Text, reel) +
+			"\nArtist: " + metadata.ExpandTemplate(artistEntry.Text, values) +
+			"\nComment: " + metadata.ExpandTemplate(commentEntry.Text, values) +
+			"\nCaption: " + metadata.ExpandTemplate(captionEntry.Text, values))
+	}
+	for _, entry := range []*widget.Entry{gameEntry, teamEntry, clipTitleEntry, reelTitleEntry, artistEntry, commentEntry, captionEntry} {
+		entry.OnChanged = updatePreview
+	}
+	updatePreview("")
+
+	form := widget.NewForm(
+		widget.NewFormItem("Game", gameEntry),
+		widget.NewFormItem("Team", teamEntry),
+		widget.NewFormItem("Clip title", clipTitleEntry),
+		widget.NewFormItem("Reel title", reelTitleEntry),
+		widget.NewFormItem("Artist", artistEntry),
+		widget.NewFormItem("Comment", commentEntry),
+		widget.NewFormItem("Reel caption", captionEntry),
+	)
+	content := container.NewVBox(
+		widget.NewLabel("Tags written into every clip and reel, for media libraries like Plex.\n"+
+			"The date tag is the game date. Leave a template empty to skip its tag.\n"+
+			"Reel captions are shown over each clip when Step 4 writes captions."),
+		form,
+		widget.NewLabel("Tokens: "+strings.Join(metadata.TemplateTokens, " ")),
+		widget.NewSeparator(),
+		previewLabel,
+	)
+
+	d := dialog.NewCustomConfirm("Metadata Tags", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		a.cfg.TeamName = strings.TrimSpace(teamEntry.Text)
+		a.cfg.ClipTitleTemplate = strings.TrimSpace(clipTitleEntry.Text)
+		a.cfg.ReelTitleTemplate = strings.TrimSpace(reelTitleEntry.Text)
+		a.cfg.ArtistTemplate = strings.TrimSpace(artistEntry.Text)
+		a.cfg.CommentTemplate = strings.TrimSpace(commentEntry.Text)
+		a.cfg.CaptionTemplate = strings.TrimSpace(captionEntry.Text)
+		a.cfg.Save()
+		a.setGameName(gameEntry.Text)
+	}, a.window)
+	d.Resize(fyne.NewSize(550, 500))
+	d.Show()
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/config"
+)
+
+const (
+	// thumbnailWidth is the width in pixels of generated chapter thumbnails
+	thumbnailWidth = 320
+	// thumbnailWorkers is how many frames are extracted at once
+	thumbnailWorkers = 2
+)
+
+// thumbnailJob is a frame waiting to be extracted
+type thumbnailJob struct {
+	videoFile string
+	atSec     float64
+	path      string
+}
+
+// thumbnailCache generates chapter thumbnails in the background and keeps them
+// on disk, so reopening the same footage doesn't extract the frames again
+type thumbnailCache struct {
+	ff    *ffmpeg.FFmpeg
+	dir   string
+	queue chan thumbnailJob
+
+	mu      sync.Mutex
+	pending map[string][]func() // Thumbnail path -> callbacks waiting for it
+	failed  map[string]bool     // Frames that could not be extracted (not retried)
+}
+
+// newThumbnailCache creates the cache and starts its workers
+func newThumbnailCache(ff *ffmpeg.FFmpeg) *thumbnailCache {
+	dir := filepath.Join(config.CacheDir(), "thumbnails")
+	os.MkdirAll(dir, 0755)
+
+	c := &thumbnailCache{
+		ff:      ff,
+		dir:     dir,
+		queue:   make(chan thumbnailJob, 256),
+		pending: make(map[string][]func()),
+		failed:  make(map[string]bool),
+	}
+	for i := 0; i < thumbnailWorkers; i++ {
+		go c.worker()
+	}
+	return c
+}
+
+// path returns the cache file for a frame. The key includes the video's size and
+// modification time so a re-converted video gets fresh thumbnails.
+func (c *thumbnailCache) path(videoFile string, atSec float64) string {
+	var stamp string
+	if info, err := os.Stat(videoFile); err == nil {
+		stamp = fmt.Sprintf("%d-%d", info.Size(), info.ModTime().Unix())
+	}
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%d", videoFile, stamp, int64(atSec*1000), thumbnailWidth)))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".jpg")
+}
+
+// get returns the thumbnail path if it is already cached. Otherwise it queues the
+// frame for extraction, calls onReady (from a worker goroutine) once it exists,
+// and returns false.
+func (c *thumbnailCache) get(videoFile string, atSec float64, onReady func()) (string, bool) {
+	path := c.path(videoFile, atSec)
+	if _, err := os.Stat(path); err == nil {
+		return path, true
+	}
+
+	c.mu.Lock()
+	if c.failed[path] {
+		c.mu.Unlock()
+		return "", false
+	}
+	callbacks, queued := c.pending[path]
+	c.pending[path] = append(callbacks, onReady)
+	c.mu.Unlock()
+
+	if !queued {
+		// Send from a goroutine so a full queue never blocks the UI thread
+		go func() {
+			c.queue <- thumbnailJob{videoFile: videoFile, atSec: atSec, path: path}
+		}()
+	}
+	return "", false
+}
+
+// worker extracts queued frames
+func (c *thumbnailCache) worker() {
+	for job := range c.queue {
+		// Write to a temp name so a half-written JPEG is never picked up
+		tmpPath := job.path + ".tmp.jpg"
+		err := c.ff.ExtractFrame(job.videoFile, tmpPath, job.atSec, thumbnailWidth)
+		if err == nil {
+			os.Rename(tmpPath, job.path)
+		} else {
+			os.Remove(tmpPath)
+		}
+
+		c.mu.Lock()
+		if err != nil {
+			c.failed[job.path] = true
+		}
+		callbacks := c.pending[job.path]
+		delete(c.pending, job.path)
+		c.mu.Unlock()
+
+		for _, cb := range callbacks {
+			if cb != nil {
+				cb()
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+)
+
+// timecodeVideo is a period's video in the start times dialog
+type timecodeVideo struct {
+	period   string // Period name
+	path     string // Video the start time is for (metadata.Period.VideoFile)
+	timecode string // Timecode read from the files ("" = none)
+}
+
+// manualTimecode returns the start time entered for a video ("" = none)
+func (a *App) manualTimecode(videoPath string) string {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.manualTimecodes[videoPath]
+}
+
+// manualStarts returns the start times entered in Step 1 for the analyzer,
+// by video file
+func (a *App) manualStarts() map[string]time.Duration {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	starts := make(map[string]time.Duration, len(a.manualTimecodes))
+	for path, text := range a.manualTimecodes {
+		if start, err := metadata.ParseTimeOfDay(text); err == nil {
+			starts[path] = start
+		}
+	}
+	return starts
+}
+
+// showManualTimecodes shows a dialog for entering the clock time of each
+// video's first frame, for converted files that lost their timecode track (or
+// have a wrong one). A start can be copied from another period plus an
+// offset. onSave is called after the times are saved.
+func (a *App) showManualTimecodes(videos []timecodeVideo, onSave func()) {
+	if len(videos) == 0 {
+		return
+	}
+
+	entries := make([]*widget.Entry, len(videos))
+	form := widget.NewForm()
+	var names []string
+	for i, video := range videos {
+		entry := widget.NewEntry()
+		entry.SetText(a.manualTimecode(video.path))
+		entry.Validator = func(text string) error {
+			if strings.TrimSpace(text) == "" {
+				return nil
+			}
+			_, err := metadata.ParseTimeOfDay(text)
+			return err
+		}
+		status := "no timecode - enter the start"
+		if video.timecode != "" {
+			status = "timecode " + video.timecode + " - leave empty to use it"
+			entry.SetPlaceHolder("from the file")
+		} else {
+			entry.SetPlaceHolder("HH:MM:SS")
+		}
+		entries[i] = entry
+		names = append(names, video.period)
+		form.Append(video.period, container.NewVBox(entry,
+			widget.NewLabel(fmt.Sprintf("%s (%s)", filepath.Base(video.path), status))))
+	}
+
+	// start returns the start a video has now: entered, or else its timecode
+	start := func(i int) (time.Duration, error) {
+		if text := strings.TrimSpace(entries[i].Text); text != "" {
+			return metadata.ParseTimeOfDay(text)
+		}
+		if videos[i].timecode != "" {
+			return metadata.TimecodeTimeOfDay(videos[i].timecode)
+		}
+		return 0, fmt.Errorf("%s has no start time to copy", videos[i].period)
+	}
+
+	// Copy a start from another period, plus an offset
+	targetSelect := widget.NewSelect(names, nil)
+	sourceSelect := widget.NewSelect(names, nil)
+	offsetEntry := widget.NewEntry()
+	offsetEntry.SetPlaceHolder("e.g. 35:00")
+	for i, video := range videos {
+		if video.timecode == "" && targetSelect.Selected == "" {
+			targetSelect.SetSelectedIndex(i)
+		}
+		if video.timecode != "" && sourceSelect.Selected == "" {
+			sourceSelect.SetSelectedIndex(i)
+		}
+	}
+	copyBtn := widget.NewButton("Copy", func() {
+		target, source := targetSelect.SelectedIndex(), sourceSelect.SelectedIndex()
+		if target < 0 || source < 0 {
+			return
+		}
+		from, err := start(source)
+		if err != nil {
+			a.showError("Can't Copy Start", err.Error())
+			return
+		}
+		var offset time.Duration
+		if strings.TrimSpace(offsetEntry.Text) != "" {
+			if offset, err = metadata.ParseOffset(offsetEntry.Text); err != nil {
+				a.showError("Can't Copy Start", err.Error())
+				return
+			}
+		}
+		entries[target].SetText(metadata.FormatTimeOfDay(from + offset))
+	})
+	copyRow := container.NewHBox(
+		widget.NewLabel("Set"), targetSelect,
+		widget.NewLabel("to"), sourceSelect,
+		widget.NewLabel("+"), offsetEntry, copyBtn,
+	)
+
+	help := widget.NewLabel("Enter the camera clock time of each video's first frame (HH:MM:SS) where the timecode\n" +
+		"is missing, e.g. a MOV exported from Quik or re-muxed without its timecode track. To copy from\n" +
+		"another period, add the time between the two starts (e.g. that period's length plus the intermission;\n" +
+		"a minus sign goes back).")
+
+	content := container.NewVBox(
+		help,
+		widget.NewSeparator(),
+		form,
+		widget.NewSeparator(),
+		copyRow,
+	)
+
+	d := dialog.NewCustomConfirm("Start Times", "Save", "Cancel", container.NewVScroll(content), func(save bool) {
+		if !save {
+			return
+		}
+		starts := make(map[string]string)
+		for i, entry := range entries {
+			if strings.TrimSpace(entry.Text) == "" {
+				continue
+			}
+			start, err := metadata.ParseTimeOfDay(entry.Text)
+			if err != nil {
+				a.showError("Invalid Start Time", videos[i].period+": "+err.Error())
+				return
+			}
+			starts[videos[i].path] = metadata.FormatTimeOfDay(start)
+		}
+
+		a.sessionMu.Lock()
+		if a.manualTimecodes == nil {
+			a.manualTimecodes = make(map[string]string)
+		}
+		for _, video := range videos {
+			delete(a.manualTimecodes, video.path)
+		}
+		for path, start := range starts {
+			a.manualTimecodes[path] = start
+		}
+		a.sessionMu.Unlock()
+		a.saveSession()
+
+		if onSave != nil {
+			onSave()
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(700, 500))
+	d.Show()
+}
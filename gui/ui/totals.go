@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+)
+
+// reencodeEstimateMbps is the average bitrate assumed when estimating the size of
+// re-encoded clips (H.264 at QP/CRF 18 from GoPro footage; lower qualities
+// scale it, see clipQualityOptions). Only used for the
+// Step 2 size estimate; actual sizes depend on resolution and motion.
+const reencodeEstimateMbps = 30.0
+
+// formatSize formats a byte count as MB or GB
+func formatSize(bytes float64) string {
+	sizeMB := bytes / (1024 * 1024)
+	if sizeMB > 1024 {
+		return fmt.Sprintf("%.1f GB", sizeMB/1024)
+	}
+	return fmt.Sprintf("%.0f MB", sizeMB)
+}
+
+// sourceBytesPerSecond returns a video's average data rate (file size / duration),
+// used to estimate the size of stream-copied clips
+func (a *App) sourceBytesPerSecond(videoPath string) (float64, error) {
+	info, err := os.Stat(videoPath)
+	if err != nil {
+		return 0, err
+	}
+	dur, err := a.ff.GetDuration(videoPath)
+	if err != nil {
+		return 0, err
+	}
+	if dur <= 0 {
+		return 0, fmt.Errorf("no duration for %s", videoPath)
+	}
+	return float64(info.Size()) / dur, nil
+}
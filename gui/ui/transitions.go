@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// transitionStyles are the Step 4 transition choices, by config.ReelTransition value
+var transitionStyles = []struct {
+	value string
+	label string
+}{
+	{ffmpeg.TransitionCut, "Hard cut"},
+	{ffmpeg.TransitionFade, "Fade in/out"},
+	{ffmpeg.TransitionCrossfade, "Crossfade"},
+}
+
+// transitionStyleLabel returns the display label of a transition style
+func transitionStyleLabel(value string) string {
+	for _, s := range transitionStyles {
+		if s.value == value {
+			return s.label
+		}
+	}
+	return transitionStyles[0].label
+}
+
+// transitionStyleValue returns the transition style with the given display label
+func transitionStyleValue(label string) string {
+	for _, s := range transitionStyles {
+		if s.label == label {
+			return s.value
+		}
+	}
+	return ffmpeg.TransitionCut
+}
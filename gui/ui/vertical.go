@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// verticalModes are the Step 4 choices for a vertical (9:16) copy of each
+// reel, by config.VerticalReel value
+var verticalModes = []struct {
+	value string
+	label string
+}{
+	{"off", "Off"},
+	{"center", "9:16, centered"},
+	{"pan", "9:16, panned per clip"},
+}
+
+// verticalModeLabel returns the display label of a vertical mode
+func verticalModeLabel(value string) string {
+	for _, m := range verticalModes {
+		if m.value == value {
+			return m.label
+		}
+	}
+	return verticalModes[0].label
+}
+
+// verticalModeValue returns the vertical mode with the given display label
+func verticalModeValue(label string) string {
+	for _, m := range verticalModes {
+		if m.label == label {
+			return m.value
+		}
+	}
+	return "off"
+}
+
+// verticalPath returns where the vertical copy of a reel is written
+func verticalPath(reelPath string) string {
+	ext := filepath.Ext(reelPath)
+	return reelPath[:len(reelPath)-len(ext)] + "_vertical.mp4"
+}
+
+// reelPans returns the crop position of each clip for a vertical reel: none
+// (all centered) unless the clips are panned one by one
+func (a *App) reelPans(clips []string) map[string]float64 {
+	pans := make(map[string]float64)
+	if a.cfg.VerticalReel != "pan" {
+		return pans
+	}
+	for _, clip := range clips {
+		pans[clip] = a.clipPan(clip)
+	}
+	return pans
+}
+
+// panLabel describes a crop position
+func panLabel(x float64) string {
+	switch {
+	case x < 0.05:
+		return "Left"
+	case x > 0.95:
+		return "Right"
+	case x > 0.45 && x < 0.55:
+		return "Center"
+	}
+	return fmt.Sprintf("%.0f%%", x*100)
+}
+
+// showVerticalPans lets each clip's vertical crop be moved left or right of
+// center, next to the clip's highlight frame
+func (a *App) showVerticalPans(clips []string) {
+	if len(clips) == 0 {
+		a.showError("No Clips", "Select the clips to pan first")
+		return
+	}
+	if a.thumbs == nil {
+		a.thumbs = newThumbnailCache(a.ff)
+	}
+
+	sliders := make(map[string]*widget.Slider)
+	rows := container.NewVBox()
+	for _, clip := range clips {
+		img := canvas.NewImageFromResource(theme.FileVideoIcon())
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(160, 90))
+
+		// The highlight is SecondsBefore into the clip
+		var load func()
+		load = func() {
+			if path, ok := a.thumbs.get(clip, a.cfg.SecondsBefore, load); ok {
+				fyne.Do(func() {
+					img.Resource = nil
+					img.File = path
+					img.Refresh()
+				})
+			}
+		}
+		go load()
+
+		valueLabel := widget.NewLabel("")
+		slider := widget.NewSlider(0, 1)
+		slider.Step = 0.05
+		slider.OnChanged = func(v float64) {
+			valueLabel.SetText(panLabel(v))
+		}
+		slider.SetValue(a.clipPan(clip))
+		sliders[clip] = slider
+
+		rows.Add(container.NewBorder(nil, nil, img, nil, container.NewVBox(
+			widget.NewLabel(filepath.Base(clip)),
+			container.NewBorder(nil, nil, widget.NewLabel("Crop:"), valueLabel, slider),
+		)))
+	}
+
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(0, 400))
+
+	d := dialog.NewCustomConfirm("Vertical Crop per Clip", "Save", "Cancel", scroll, func(save bool) {
+		if !save {
+			return
+		}
+		for clip, slider := range sliders {
+			a.setClipPan(clip, slider.Value)
+		}
+	}, a.window)
+	d.Resize(fyne.NewSize(600, 500))
+	d.Show()
+}
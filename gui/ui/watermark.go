@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// currentWatermark builds the watermark from config, or nil if no logo is set
+func (a *App) currentWatermark() *ffmpeg.Watermark {
+	if a.cfg.WatermarkPath == "" {
+		return nil
+	}
+	return &ffmpeg.Watermark{
+		ImagePath: a.cfg.WatermarkPath,
+		Corner:    a.cfg.WatermarkCorner,
+		Opacity:   a.cfg.WatermarkOpacity,
+		Scale:     a.cfg.WatermarkScale,
+	}
+}
+
+// clipWatermark returns the watermark to apply to extracted clips (nil if disabled)
+func (a *App) clipWatermark() *ffmpeg.Watermark {
+	if !a.cfg.WatermarkOnClips {
+		return nil
+	}
+	return a.currentWatermark()
+}
+
+// reelWatermark returns the watermark to apply to combined reels (nil if disabled)
+func (a *App) reelWatermark() *ffmpeg.Watermark {
+	if !a.cfg.WatermarkOnReel {
+		return nil
+	}
+	return a.currentWatermark()
+}
+
+// showWatermarkSettings shows a dialog for configuring the logo overlay
+func (a *App) showWatermarkSettings() {
+	logoLabel := widget.NewLabel("(none)")
+	if a.cfg.WatermarkPath != "" {
+		logoLabel.SetText(filepath.Base(a.cfg.WatermarkPath))
+	}
+	logoPath := a.cfg.WatermarkPath
+
+	selectLogoBtn := widget.NewButton("Select Logo PNG", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			path := reader.URI().Path()
+			if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			logoPath = path
+			logoLabel.SetText(filepath.Base(path))
+		}, a.window)
+	})
+	clearLogoBtn := widget.NewButton("Clear", func() {
+		logoPath = ""
+		logoLabel.SetText("(none)")
+	})
+
+	cornerSelect := widget.NewSelect(ffmpeg.WatermarkCorners, nil)
+	cornerSelect.SetSelected(a.cfg.WatermarkCorner)
+
+	opacityLabel := widget.NewLabel("")
+	opacitySlider := widget.NewSlider(0.1, 1.0)
+	opacitySlider.Step = 0.05
+	opacitySlider.OnChanged = func(v float64) {
+		opacityLabel.SetText(fmt.Sprintf("%.0f%%", v*100))
+	}
+	opacitySlider.SetValue(a.cfg.WatermarkOpacity)
+
+	sizeLabel := widget.NewLabel("")
+	sizeSlider := widget.NewSlider(0.03, 0.5)
+	sizeSlider.Step = 0.01
+	sizeSlider.OnChanged = func(v float64) {
+		sizeLabel.SetText(fmt.Sprintf("%.0f%% of width", v*100))
+	}
+	sizeSlider.SetValue(a.cfg.WatermarkScale)
+
+	onClipsCheck := widget.NewCheck("Apply to extracted clips (re-encode only)", nil)
+	onClipsCheck.SetChecked(a.cfg.WatermarkOnClips)
+	onReelCheck := widget.NewCheck("Apply to combined reels (re-encode only)", nil)
+	onReelCheck.SetChecked(a.cfg.WatermarkOnReel)
+
+	content := container.NewVBox(
+		container.NewHBox(widget.NewLabel("Logo:"), logoLabel, selectLogoBtn, clearLogoBtn),
+		container.NewHBox(widget.NewLabel("Corner:"), cornerSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("Opacity:"), opacityLabel, opacitySlider),
+		container.NewBorder(nil, nil, widget.NewLabel("Size:"), sizeLabel, sizeSlider),
+		onClipsCheck,
+		onReelCheck,
+	)
+
+	d := dialog.NewCustomConfirm("Watermark", "Save", "Cancel", content, func(save bool) {
+		if !save {
+			return
+		}
+		a.cfg.WatermarkPath = logoPath
+		a.cfg.WatermarkCorner = cornerSelect.Selected
+		a.cfg.WatermarkOpacity = opacitySlider.Value
+		a.cfg.WatermarkScale = sizeSlider.Value
+		a.cfg.WatermarkOnClips = onClipsCheck.Checked
+		a.cfg.WatermarkOnReel = onReelCheck.Checked
+		a.cfg.Save()
+	}, a.window)
+	d.Resize(fyne.NewSize(500, 350))
+	d.Show()
+}
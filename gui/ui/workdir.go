@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+)
+
+// useWorkDir makes ffmpeg write its work files (concat lists, chapter files,
+// pass logs) into folder's work folder, so they stay with the project and
+// what a crash leaves behind is cleaned up the next time the folder is opened
+// (see ffmpeg.WorkDir). The work folders of folders opened before are kept
+// until the app is closed, as a job may still be using one. If the work
+// folder can't be created (e.g. a read-only card), the instance's temp folder
+// is used instead.
+func (a *App) useWorkDir(folder string) {
+	if a.ff == nil || folder == "" {
+		return
+	}
+	folder = filepath.Clean(folder)
+
+	a.workDirMu.Lock()
+	defer a.workDirMu.Unlock()
+	work, ok := a.workDirs[folder]
+	if !ok {
+		var err error
+		if work, err = ffmpeg.OpenWorkDir(folder); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			a.ff.SetTempDir(a.tempDir)
+			return
+		}
+		if a.workDirs == nil {
+			a.workDirs = make(map[string]*ffmpeg.WorkDir)
+		}
+		a.workDirs[folder] = work
+	}
+	a.ff.SetTempDir(work.Path())
+}
+
+// closeWorkDirs removes the work folders this run opened
+func (a *App) closeWorkDirs() {
+	a.workDirMu.Lock()
+	defer a.workDirMu.Unlock()
+	for folder, work := range a.workDirs {
+		if err := work.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		delete(a.workDirs, folder)
+	}
+}
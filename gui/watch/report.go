@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+)
+
+// report is the summary of one processed game folder
+type report struct {
+	Game         string
+	Folder       string
+	OutputFolder string
+	Started      time.Time
+	Finished     time.Time
+	Scan         *pipeline.Scan
+	Groups       int      // Clips to extract (after merging overlapping highlights)
+	Clips        []string // Clips extracted
+	Failures     []string // Clips that failed, with the error
+	Notices      []string // Sources worth knowing about (e.g. no audio)
+}
+
+// write saves the report as plain text
+func (r *report) write(path string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Game:      %s\n", r.Game)
+	fmt.Fprintf(&b, "Folder:    %s\n", r.Folder)
+	fmt.Fprintf(&b, "Output:    %s\n", r.OutputFolder)
+	fmt.Fprintf(&b, "Processed: %s (took %s)\n", r.Finished.Format("2006-01-02 15:04"), r.Finished.Sub(r.Started).Round(time.Second))
+
+	counts := make(map[string]int)
+	for _, ch := range r.Scan.Analysis.Chapters {
+		counts[ch.Period]++
+	}
+	fmt.Fprintf(&b, "\nPeriods (%d):\n", len(r.Scan.Periods))
+	for _, p := range r.Scan.Periods {
+		fmt.Fprintf(&b, "  %s  %s  %d chapters\n", p.Name, filepath.Base(p.VideoFile), counts[p.Name])
+	}
+	if len(r.Scan.Skipped) > 0 {
+		fmt.Fprintf(&b, "\nSkipped (%d):\n", len(r.Scan.Skipped))
+		for _, warning := range r.Scan.Skipped {
+			fmt.Fprintf(&b, "  %s\n", warning)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nClips (%d of %d from %d chapters):\n", len(r.Clips), r.Groups, len(r.Scan.Analysis.Chapters))
+	for _, clip := range r.Clips {
+		fmt.Fprintf(&b, "  %s\n", filepath.Base(clip))
+	}
+	if len(r.Failures) > 0 {
+		fmt.Fprintf(&b, "\nFailed (%d):\n", len(r.Failures))
+		for _, failure := range r.Failures {
+			fmt.Fprintf(&b, "  %s\n", failure)
+		}
+	}
+	if len(r.Notices) > 0 {
+		fmt.Fprintf(&b, "\nNotes:\n")
+		for _, notice := range r.Notices {
+			fmt.Fprintf(&b, "  %s\n", notice)
+		}
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
@@ -0,0 +1,355 @@
+// Package watch runs the app without its window, as a daemon that processes
+// game folders copied into a drop folder: once a folder's files stop growing,
+// its periods are analyzed and every highlight is extracted with the
+// configured padding, and a report is written next to the clips.
+package watch
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+	"github.com/jacobe603/gopro-clip-extractor/core/metadata"
+	"github.com/jacobe603/gopro-clip-extractor/core/pipeline"
+
+	"gopro-gui/config"
+	"gopro-gui/jobs"
+)
+
+const (
+	// DoneMarker is written into a game folder once it has been processed, so
+	// it isn't processed again (delete it to process the folder again)
+	DoneMarker = ".gopro-watch-done"
+	// ReportName is the report written into each game's clip folder
+	ReportName = "watch-report.txt"
+)
+
+// Options configure a Watcher
+type Options struct {
+	// Folder is the drop folder; each folder copied into it is one game
+	Folder string
+	FF     *ffmpeg.FFmpeg
+	Config *config.Config
+	Log    *log.Logger
+	// PollInterval is how often the drop folder is checked
+	PollInterval time.Duration
+	// SettleTime is how long a game folder's files must stay unchanged
+	// before it is taken to be copied completely
+	SettleTime time.Duration
+}
+
+// Watcher watches one drop folder
+type Watcher struct {
+	opts    Options
+	pending map[string]*pendingFolder // Game folders waiting to settle, by path
+	failed  map[string]string         // Snapshot each failed folder had, so it is only retried once its files change
+	quiet   bool                      // Set while settled folders wait for the quiet hours to end
+}
+
+// pendingFolder is a game folder whose copy may still be running
+type pendingFolder struct {
+	snapshot string    // Names, sizes and times of its files (see snapshot)
+	since    time.Time // When the snapshot last changed
+}
+
+// New creates a watcher. Zero intervals get the defaults (poll every 10
+// seconds, settle for 2 minutes).
+func New(opts Options) *Watcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 10 * time.Second
+	}
+	if opts.SettleTime <= 0 {
+		opts.SettleTime = 2 * time.Minute
+	}
+	if opts.Log == nil {
+		opts.Log = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &Watcher{
+		opts:    opts,
+		pending: make(map[string]*pendingFolder),
+		failed:  make(map[string]string),
+	}
+}
+
+// Run watches the drop folder until stop is closed. Game folders are processed
+// one at a time, in between checks.
+func (w *Watcher) Run(stop <-chan struct{}) error {
+	info, err := os.Stat(w.opts.Folder)
+	if err != nil {
+		return fmt.Errorf("failed to read drop folder: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a folder", w.opts.Folder)
+	}
+
+	w.opts.Log.Printf("Watching %s (checking every %s, processing folders unchanged for %s)",
+		w.opts.Folder, w.opts.PollInterval, w.opts.SettleTime)
+
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.poll()
+		select {
+		case <-stop:
+			w.opts.Log.Printf("Stopped watching %s", w.opts.Folder)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// inQuietHours reports whether it is the configured quiet hours, when settled
+// folders wait instead of being processed. Entering and leaving them is logged.
+func (w *Watcher) inQuietHours() bool {
+	quiet, err := jobs.ParseQuietHours(w.opts.Config.QuietHours)
+	inside := err == nil && quiet.Contains(time.Now())
+	if inside && !w.quiet {
+		w.opts.Log.Printf("Quiet hours until %s, waiting to process folders", quiet.EndString())
+	} else if !inside && w.quiet {
+		w.opts.Log.Printf("Quiet hours are over, processing folders")
+	}
+	w.quiet = inside
+	return inside
+}
+
+// poll checks each game folder in the drop folder and processes those whose
+// files have stopped changing
+func (w *Watcher) poll() {
+	entries, err := os.ReadDir(w.opts.Folder)
+	if err != nil {
+		w.opts.Log.Printf("Failed to read drop folder: %v", err)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		folder := filepath.Join(w.opts.Folder, entry.Name())
+		seen[folder] = true
+		if _, err := os.Stat(filepath.Join(folder, DoneMarker)); err == nil {
+			continue
+		}
+
+		snap, videos, err := snapshot(folder)
+		if err != nil || videos == 0 {
+			delete(w.pending, folder)
+			continue
+		}
+		if w.failed[folder] == snap {
+			continue
+		}
+
+		p := w.pending[folder]
+		if p == nil || p.snapshot != snap {
+			if p == nil {
+				w.opts.Log.Printf("Found %s, waiting for the copy to finish", entry.Name())
+			}
+			w.pending[folder] = &pendingFolder{snapshot: snap, since: time.Now()}
+			continue
+		}
+		if time.Since(p.since) < w.opts.SettleTime {
+			continue
+		}
+		if w.inQuietHours() {
+			continue
+		}
+
+		delete(w.pending, folder)
+		delete(w.failed, folder)
+		if err := w.process(folder); err != nil {
+			w.opts.Log.Printf("Failed to process %s: %v", entry.Name(), err)
+			// Processing may have written metadata files, so take the snapshot again
+			if after, _, err := snapshot(folder); err == nil {
+				snap = after
+			}
+			w.failed[folder] = snap
+		}
+	}
+
+	// Forget folders that were moved or deleted
+	for folder := range w.pending {
+		if !seen[folder] {
+			delete(w.pending, folder)
+		}
+	}
+	for folder := range w.failed {
+		if !seen[folder] {
+			delete(w.failed, folder)
+		}
+	}
+}
+
+// snapshot describes the files in a game folder by name, size and modification
+// time, so a copy in progress shows up as a changing snapshot. Also returns how
+// many of them are videos.
+func snapshot(folder string) (string, int, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var lines []string
+	videos := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", 0, err
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".mov", ".mp4":
+			videos++
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d:%d", entry.Name(), info.Size(), info.ModTime().UnixNano()))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n"), videos, nil
+}
+
+// process runs the pipeline on one game folder: analyze its periods, extract
+// every highlight, then write the report and the done marker. A folder that
+// holds several games (see Config.GameSplitGap) gets clips and a report per game.
+func (w *Watcher) process(folder string) error {
+	cfg := w.opts.Config
+	name := filepath.Base(folder)
+	w.opts.Log.Printf("Processing %s", name)
+
+	// Work files go in the game folder, so a crash leaves nothing in the
+	// system temp folder and the next run cleans them up
+	if work, err := ffmpeg.OpenWorkDir(folder); err != nil {
+		w.opts.Log.Printf("%s: %v, using the temp folder", name, err)
+	} else {
+		defer work.Close()
+		defer w.opts.FF.SetTempDir(w.opts.FF.TempDir())
+		w.opts.FF.SetTempDir(work.Path())
+	}
+
+	scans, err := pipeline.ScanGames(w.opts.FF, folder, pipeline.ScanOptions{
+		Excluded:       cfg.ExcludedVideos,
+		DedupThreshold: cfg.DedupThreshold,
+		Split:          metadata.PeriodSplit{MinGap: time.Duration(cfg.PeriodSplitGap * float64(time.Minute))},
+		SpeedBurstKmh:  cfg.SpeedBurstKmh,
+		AutoChapters:   cfg.AutoChapters(),
+		Games:          metadata.GameSplit{MinGap: time.Duration(cfg.GameSplitGap * float64(time.Minute))},
+	})
+	if err != nil {
+		return err
+	}
+	for _, warning := range scans[0].Skipped {
+		w.opts.Log.Printf("%s: %s", name, warning)
+	}
+	if len(scans) > 1 {
+		w.opts.Log.Printf("%s: found %d games", name, len(scans))
+	}
+
+	var reports []string
+	for _, scan := range scans {
+		reportPath, err := w.processGame(scan)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, reportPath)
+	}
+
+	// Folders with some failed clips are still done; the reports list the failures
+	marker := fmt.Sprintf("Processed %s\nReport: %s\n", time.Now().Format(time.RFC3339), strings.Join(reports, "\nReport: "))
+	if err := os.WriteFile(filepath.Join(folder, DoneMarker), []byte(marker), 0644); err != nil {
+		w.opts.Log.Printf("%s: failed to mark folder as done: %v", name, err)
+	}
+	return nil
+}
+
+// processGame extracts every highlight of one scanned game and writes its
+// report, returning the report's path
+func (w *Watcher) processGame(scan *pipeline.Scan) (string, error) {
+	cfg := w.opts.Config
+	game := filepath.Base(scan.Folder)
+	if scan.Game > 0 {
+		game = fmt.Sprintf("%s Game %d", game, scan.Game)
+	}
+	started := time.Now()
+	w.opts.Log.Printf("%s: found %d chapters across %d periods", game, len(scan.Analysis.Chapters), len(scan.Periods))
+
+	outputFolder := w.outputFolder(scan, game)
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	groups := metadata.GroupChaptersWith(scan.Analysis.Chapters, cfg.SecondsBefore, cfg.SecondsAfter, cfg.OverlapGrouping())
+	report := &report{
+		Game:         game,
+		Folder:       scan.Folder,
+		OutputFolder: outputFolder,
+		Started:      started,
+		Scan:         scan,
+		Groups:       len(groups),
+	}
+
+	ex := &pipeline.Extractor{
+		FF:           w.opts.FF,
+		Analysis:     scan.Analysis,
+		CaptureTimes: cfg.CaptureTimes,
+		DriveWorkers: func(drive string) int {
+			return cfg.DriveWorkersFor(drive, 1)
+		},
+	}
+	completed, extractErr := ex.ExtractGroups(groups, outputFolder, pipeline.Callbacks{
+		Progress: func(progress float64, status string) {
+			w.opts.Log.Printf("%s: %s", game, status)
+		},
+		Extracted: func(path string, group metadata.ClipGroup) {
+			report.Clips = append(report.Clips, path)
+		},
+		Failed: func(index int, group metadata.ClipGroup, err error) {
+			failure := fmt.Sprintf("%s Ch%d: %v", group.Period, group.PrimaryChapter.Number, err)
+			report.Failures = append(report.Failures, failure)
+			w.opts.Log.Printf("%s: failed to extract %s", game, failure)
+		},
+		Notice: func(message string) {
+			report.Notices = append(report.Notices, message)
+			w.opts.Log.Printf("%s: %s", game, message)
+		},
+	})
+	report.Finished = time.Now()
+
+	reportPath := filepath.Join(outputFolder, ReportName)
+	if err := report.write(reportPath); err != nil {
+		w.opts.Log.Printf("%s: failed to write report: %v", game, err)
+	}
+	if extractErr != nil && completed == 0 {
+		return "", extractErr
+	}
+
+	w.opts.Log.Printf("%s: extracted %d of %d clips to %s in %s", game, completed, len(groups), outputFolder,
+		report.Finished.Sub(started).Round(time.Second))
+	return reportPath, nil
+}
+
+// outputFolder returns where a game's clips go: the output layout's clip
+// folder (with the game's name as {game}), or a "clips" folder inside the
+// game folder if no layout is set up (with a folder per game if it holds several)
+func (w *Watcher) outputFolder(scan *pipeline.Scan, game string) string {
+	cfg := w.opts.Config
+	if cfg.OutputRoot == "" {
+		if scan.Game > 0 {
+			return filepath.Join(scan.Folder, "clips", fmt.Sprintf("Game %d", scan.Game))
+		}
+		return filepath.Join(scan.Folder, "clips")
+	}
+
+	values := metadata.TemplateValues{
+		Game: game,
+		Team: cfg.TeamName,
+		Date: scan.Analysis.GameStart(),
+	}
+	return filepath.Join(cfg.OutputRoot, metadata.ExpandPathTemplate(cfg.ClipFolderTemplate, values))
+}
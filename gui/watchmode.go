@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/jacobe603/gopro-clip-extractor/core/ffmpeg"
+
+	"gopro-gui/config"
+	"gopro-gui/watch"
+)
+
+// runWatch runs headless, processing game folders copied into folder until
+// interrupted (see package watch). Logs go to the console and to watch.log in
+// the config folder. Returns the exit code.
+func runWatch(folder string, settle time.Duration) int {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	if dir, err := config.Dir(); err == nil {
+		logPath := filepath.Join(dir, "watch.log")
+		file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err == nil {
+			defer file.Close()
+			logger.SetOutput(io.MultiWriter(os.Stderr, file))
+			logger.Printf("Logging to %s", logPath)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	// Same ffmpeg search as the desktop app
+	var ff *ffmpeg.FFmpeg
+	if cfg.FFmpegPath != "" {
+		ff, err = ffmpeg.NewFromPath(config.ResolvePath(cfg.FFmpegPath))
+		if err != nil {
+			logger.Print(err)
+		}
+	}
+	if ff == nil {
+		ff, err = ffmpeg.New()
+		if err != nil {
+			logger.Printf("Error initializing ffmpeg: %v", err)
+			return 1
+		}
+	}
+	ff.SetPreferCPU(cfg.PreferCPU)
+	ff.SetAudioCopy(cfg.AudioCopy)
+	ff.SetHDRMode(ffmpeg.HDRMode(cfg.HDRMode))
+	ff.SetClipQuality(ffmpeg.ClipQuality(cfg.ClipQuality))
+	ff.SetRoughSeekWindow(cfg.RoughSeekWindow)
+	ff.SetThreads(cfg.FFmpegThreads)
+	ff.SetLowPriority(cfg.LowPriority)
+	presetArgs, problems := cfg.PresetArgs(ff)
+	for _, problem := range problems {
+		logger.Printf("Custom ffmpeg arguments left out: %s", problem)
+	}
+	ff.SetPresetArgs(presetArgs)
+	ff.SetFallbackHandler(func(e *ffmpeg.EncoderError) {
+		logger.Printf("Using CPU encoding: %s", e.Reason())
+	})
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	w := watch.New(watch.Options{
+		Folder:     folder,
+		FF:         ff,
+		Config:     cfg,
+		Log:        logger,
+		SettleTime: settle,
+	})
+	if err := w.Run(stop); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		logger.Print(err)
+		return 1
+	}
+	return 0
+}